@@ -0,0 +1,141 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.8
+// source: gateway/config/v1/config_service.proto
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ConfigServiceClient is the client API for ConfigService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConfigServiceClient interface {
+	// StreamConfig subscribes the config snapshots, the gateway sends a
+	// ConfigRequest to subscribe and then ACK or NACK every ConfigResponse.
+	StreamConfig(ctx context.Context, opts ...grpc.CallOption) (ConfigService_StreamConfigClient, error)
+}
+
+type configServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConfigServiceClient(cc grpc.ClientConnInterface) ConfigServiceClient {
+	return &configServiceClient{cc}
+}
+
+func (c *configServiceClient) StreamConfig(ctx context.Context, opts ...grpc.CallOption) (ConfigService_StreamConfigClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ConfigService_ServiceDesc.Streams[0], "/gateway.config.v1.ConfigService/StreamConfig", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &configServiceStreamConfigClient{stream}
+	return x, nil
+}
+
+type ConfigService_StreamConfigClient interface {
+	Send(*ConfigRequest) error
+	Recv() (*ConfigResponse, error)
+	grpc.ClientStream
+}
+
+type configServiceStreamConfigClient struct {
+	grpc.ClientStream
+}
+
+func (x *configServiceStreamConfigClient) Send(m *ConfigRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *configServiceStreamConfigClient) Recv() (*ConfigResponse, error) {
+	m := new(ConfigResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConfigServiceServer is the server API for ConfigService service.
+// All implementations must embed UnimplementedConfigServiceServer
+// for forward compatibility
+type ConfigServiceServer interface {
+	// StreamConfig subscribes the config snapshots, the gateway sends a
+	// ConfigRequest to subscribe and then ACK or NACK every ConfigResponse.
+	StreamConfig(ConfigService_StreamConfigServer) error
+	mustEmbedUnimplementedConfigServiceServer()
+}
+
+// UnimplementedConfigServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedConfigServiceServer struct {
+}
+
+func (UnimplementedConfigServiceServer) StreamConfig(ConfigService_StreamConfigServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamConfig not implemented")
+}
+func (UnimplementedConfigServiceServer) mustEmbedUnimplementedConfigServiceServer() {}
+
+// UnsafeConfigServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConfigServiceServer will
+// result in compilation errors.
+type UnsafeConfigServiceServer interface {
+	mustEmbedUnimplementedConfigServiceServer()
+}
+
+func RegisterConfigServiceServer(s grpc.ServiceRegistrar, srv ConfigServiceServer) {
+	s.RegisterService(&ConfigService_ServiceDesc, srv)
+}
+
+func _ConfigService_StreamConfig_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConfigServiceServer).StreamConfig(&configServiceStreamConfigServer{stream})
+}
+
+type ConfigService_StreamConfigServer interface {
+	Send(*ConfigResponse) error
+	Recv() (*ConfigRequest, error)
+	grpc.ServerStream
+}
+
+type configServiceStreamConfigServer struct {
+	grpc.ServerStream
+}
+
+func (x *configServiceStreamConfigServer) Send(m *ConfigResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *configServiceStreamConfigServer) Recv() (*ConfigRequest, error) {
+	m := new(ConfigRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConfigService_ServiceDesc is the grpc.ServiceDesc for ConfigService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConfigService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gateway.config.v1.ConfigService",
+	HandlerType: (*ConfigServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamConfig",
+			Handler:       _ConfigService_StreamConfig_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "gateway/config/v1/config_service.proto",
+}
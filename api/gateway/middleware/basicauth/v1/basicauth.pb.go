@@ -0,0 +1,196 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/basicauth/v1/basicauth.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// BasicAuth middleware config, the passwords are the hashes of htpasswd by
+// bcrypt ($2y$), MD5 ($apr1$) or SHA1 ({SHA}), the requests without valid
+// credentials are rejected with 401. The request is tagged with the consumer
+// of the same name as the user if any.
+type BasicAuth struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the users to the password hashes, e.g. alice: $2y$05$...
+	Users map[string]string `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the htpasswd file of the users, it's reloaded once modified, the inline
+	// users take precedence
+	HtpasswdFile string `protobuf:"bytes,2,opt,name=htpasswd_file,json=htpasswdFile,proto3" json:"htpasswd_file,omitempty"`
+	// default is Restricted
+	Realm string `protobuf:"bytes,3,opt,name=realm,proto3" json:"realm,omitempty"`
+	// forwards the credentials to the upstream, they're removed by default
+	Forward bool `protobuf:"varint,4,opt,name=forward,proto3" json:"forward,omitempty"`
+}
+
+func (x *BasicAuth) Reset() {
+	*x = BasicAuth{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_basicauth_v1_basicauth_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BasicAuth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasicAuth) ProtoMessage() {}
+
+func (x *BasicAuth) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_basicauth_v1_basicauth_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasicAuth.ProtoReflect.Descriptor instead.
+func (*BasicAuth) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BasicAuth) GetUsers() map[string]string {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *BasicAuth) GetHtpasswdFile() string {
+	if x != nil {
+		return x.HtpasswdFile
+	}
+	return ""
+}
+
+func (x *BasicAuth) GetRealm() string {
+	if x != nil {
+		return x.Realm
+	}
+	return ""
+}
+
+func (x *BasicAuth) GetForward() bool {
+	if x != nil {
+		return x.Forward
+	}
+	return false
+}
+
+var File_gateway_middleware_basicauth_v1_basicauth_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_basicauth_v1_basicauth_proto_rawDesc = []byte{
+	0x0a, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x62, 0x61, 0x73, 0x69, 0x63, 0x61, 0x75, 0x74, 0x68, 0x2f, 0x76,
+	0x31, 0x2f, 0x62, 0x61, 0x73, 0x69, 0x63, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x1f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c,
+	0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x62, 0x61, 0x73, 0x69, 0x63, 0x61, 0x75, 0x74, 0x68, 0x2e,
+	0x76, 0x31, 0x22, 0xe7, 0x01, 0x0a, 0x09, 0x42, 0x61, 0x73, 0x69, 0x63, 0x41, 0x75, 0x74, 0x68,
+	0x12, 0x4b, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x35, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2e, 0x62, 0x61, 0x73, 0x69, 0x63, 0x61, 0x75, 0x74, 0x68, 0x2e, 0x76,
+	0x31, 0x2e, 0x42, 0x61, 0x73, 0x69, 0x63, 0x41, 0x75, 0x74, 0x68, 0x2e, 0x55, 0x73, 0x65, 0x72,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x12, 0x23, 0x0a,
+	0x0d, 0x68, 0x74, 0x70, 0x61, 0x73, 0x73, 0x77, 0x64, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x74, 0x70, 0x61, 0x73, 0x73, 0x77, 0x64, 0x46, 0x69,
+	0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x61, 0x6c, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x72, 0x65, 0x61, 0x6c, 0x6d, 0x12, 0x18, 0x0a, 0x07, 0x66, 0x6f, 0x72, 0x77,
+	0x61, 0x72, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x66, 0x6f, 0x72, 0x77, 0x61,
+	0x72, 0x64, 0x1a, 0x38, 0x0a, 0x0a, 0x55, 0x73, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x42, 0x5a, 0x40,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72,
+	0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77,
+	0x61, 0x72, 0x65, 0x2f, 0x62, 0x61, 0x73, 0x69, 0x63, 0x61, 0x75, 0x74, 0x68, 0x2f, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescOnce sync.Once
+	file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescData = file_gateway_middleware_basicauth_v1_basicauth_proto_rawDesc
+)
+
+func file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescData)
+	})
+	return file_gateway_middleware_basicauth_v1_basicauth_proto_rawDescData
+}
+
+var file_gateway_middleware_basicauth_v1_basicauth_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_gateway_middleware_basicauth_v1_basicauth_proto_goTypes = []interface{}{
+	(*BasicAuth)(nil), // 0: gateway.middleware.basicauth.v1.BasicAuth
+	nil,               // 1: gateway.middleware.basicauth.v1.BasicAuth.UsersEntry
+}
+var file_gateway_middleware_basicauth_v1_basicauth_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.basicauth.v1.BasicAuth.users:type_name -> gateway.middleware.basicauth.v1.BasicAuth.UsersEntry
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_basicauth_v1_basicauth_proto_init() }
+func file_gateway_middleware_basicauth_v1_basicauth_proto_init() {
+	if File_gateway_middleware_basicauth_v1_basicauth_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_basicauth_v1_basicauth_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BasicAuth); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_basicauth_v1_basicauth_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_basicauth_v1_basicauth_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_basicauth_v1_basicauth_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_basicauth_v1_basicauth_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_basicauth_v1_basicauth_proto = out.File
+	file_gateway_middleware_basicauth_v1_basicauth_proto_rawDesc = nil
+	file_gateway_middleware_basicauth_v1_basicauth_proto_goTypes = nil
+	file_gateway_middleware_basicauth_v1_basicauth_proto_depIdxs = nil
+}
@@ -0,0 +1,305 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/bbr/v1/bbr.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BBR_Algorithm int32
+
+const (
+	// the in-flight requests are limited by the max passed requests and
+	// the min latency of the window once the CPU usage exceeds the
+	// threshold, e.g. Kratos BBR
+	BBR_CPU BBR_Algorithm = 0
+	// the in-flight requests are limited by the limit adjusted by the
+	// gradient of the long-term and the recent latencies of the upstream,
+	// e.g. Netflix concurrency-limits
+	BBR_GRADIENT BBR_Algorithm = 1
+)
+
+// Enum value maps for BBR_Algorithm.
+var (
+	BBR_Algorithm_name = map[int32]string{
+		0: "CPU",
+		1: "GRADIENT",
+	}
+	BBR_Algorithm_value = map[string]int32{
+		"CPU":      0,
+		"GRADIENT": 1,
+	}
+)
+
+func (x BBR_Algorithm) Enum() *BBR_Algorithm {
+	p := new(BBR_Algorithm)
+	*p = x
+	return p
+}
+
+func (x BBR_Algorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BBR_Algorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_bbr_v1_bbr_proto_enumTypes[0].Descriptor()
+}
+
+func (BBR_Algorithm) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_bbr_v1_bbr_proto_enumTypes[0]
+}
+
+func (x BBR_Algorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BBR_Algorithm.Descriptor instead.
+func (BBR_Algorithm) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_bbr_v1_bbr_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// BBR middleware config, the in-flight requests are limited adaptively by the
+// limiter of the endpoint, and the shed ones are responded with 429.
+type BBR struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Algorithm BBR_Algorithm `protobuf:"varint,1,opt,name=algorithm,proto3,enum=gateway.middleware.bbr.v1.BBR_Algorithm" json:"algorithm,omitempty"`
+	// the window of the CPU algorithm stats, default is 10s
+	Window *durationpb.Duration `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`
+	// the buckets of the window, default is 100
+	Bucket uint32 `protobuf:"varint,3,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	// the CPU usage in permille, default is 800
+	CpuThreshold uint32 `protobuf:"varint,4,opt,name=cpu_threshold,json=cpuThreshold,proto3" json:"cpu_threshold,omitempty"`
+	// the CPU quota of the container, the CPU usage is of the host if 0
+	CpuQuota float64 `protobuf:"fixed64,5,opt,name=cpu_quota,json=cpuQuota,proto3" json:"cpu_quota,omitempty"`
+	// the initial limit of the GRADIENT algorithm, default is 20
+	InitialLimit uint32 `protobuf:"varint,6,opt,name=initial_limit,json=initialLimit,proto3" json:"initial_limit,omitempty"`
+	// default is 1
+	MinLimit uint32 `protobuf:"varint,7,opt,name=min_limit,json=minLimit,proto3" json:"min_limit,omitempty"`
+	// default is 1000
+	MaxLimit uint32 `protobuf:"varint,8,opt,name=max_limit,json=maxLimit,proto3" json:"max_limit,omitempty"`
+	// the limit is decreased once the recent latency is over the long-term
+	// latency by the ratio, default is 1.5
+	Tolerance float64 `protobuf:"fixed64,9,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+}
+
+func (x *BBR) Reset() {
+	*x = BBR{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_bbr_v1_bbr_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BBR) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BBR) ProtoMessage() {}
+
+func (x *BBR) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_bbr_v1_bbr_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BBR.ProtoReflect.Descriptor instead.
+func (*BBR) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_bbr_v1_bbr_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BBR) GetAlgorithm() BBR_Algorithm {
+	if x != nil {
+		return x.Algorithm
+	}
+	return BBR_CPU
+}
+
+func (x *BBR) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *BBR) GetBucket() uint32 {
+	if x != nil {
+		return x.Bucket
+	}
+	return 0
+}
+
+func (x *BBR) GetCpuThreshold() uint32 {
+	if x != nil {
+		return x.CpuThreshold
+	}
+	return 0
+}
+
+func (x *BBR) GetCpuQuota() float64 {
+	if x != nil {
+		return x.CpuQuota
+	}
+	return 0
+}
+
+func (x *BBR) GetInitialLimit() uint32 {
+	if x != nil {
+		return x.InitialLimit
+	}
+	return 0
+}
+
+func (x *BBR) GetMinLimit() uint32 {
+	if x != nil {
+		return x.MinLimit
+	}
+	return 0
+}
+
+func (x *BBR) GetMaxLimit() uint32 {
+	if x != nil {
+		return x.MaxLimit
+	}
+	return 0
+}
+
+func (x *BBR) GetTolerance() float64 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+var File_gateway_middleware_bbr_v1_bbr_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_bbr_v1_bbr_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x62, 0x62, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x62, 0x62, 0x72, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x62, 0x62, 0x72, 0x2e, 0x76, 0x31,
+	0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0xfb, 0x02, 0x0a, 0x03, 0x42, 0x42, 0x52, 0x12, 0x46, 0x0a, 0x09, 0x61, 0x6c, 0x67, 0x6f,
+	0x72, 0x69, 0x74, 0x68, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x28, 0x2e, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65,
+	0x2e, 0x62, 0x62, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x42, 0x52, 0x2e, 0x41, 0x6c, 0x67, 0x6f,
+	0x72, 0x69, 0x74, 0x68, 0x6d, 0x52, 0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d,
+	0x12, 0x31, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x77, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x63,
+	0x70, 0x75, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0c, 0x63, 0x70, 0x75, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64,
+	0x12, 0x1b, 0x0a, 0x09, 0x63, 0x70, 0x75, 0x5f, 0x71, 0x75, 0x6f, 0x74, 0x61, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x08, 0x63, 0x70, 0x75, 0x51, 0x75, 0x6f, 0x74, 0x61, 0x12, 0x23, 0x0a,
+	0x0d, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x4c, 0x69, 0x6d,
+	0x69, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x69, 0x6e, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6d, 0x69, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12,
+	0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x1c, 0x0a, 0x09,
+	0x74, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x09, 0x74, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x22, 0x0a, 0x09, 0x41, 0x6c,
+	0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x12, 0x07, 0x0a, 0x03, 0x43, 0x50, 0x55, 0x10, 0x00,
+	0x12, 0x0c, 0x0a, 0x08, 0x47, 0x52, 0x41, 0x44, 0x49, 0x45, 0x4e, 0x54, 0x10, 0x01, 0x42, 0x3c,
+	0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d,
+	0x6b, 0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61,
+	0x70, 0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c,
+	0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x62, 0x62, 0x72, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_bbr_v1_bbr_proto_rawDescOnce sync.Once
+	file_gateway_middleware_bbr_v1_bbr_proto_rawDescData = file_gateway_middleware_bbr_v1_bbr_proto_rawDesc
+)
+
+func file_gateway_middleware_bbr_v1_bbr_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_bbr_v1_bbr_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_bbr_v1_bbr_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_bbr_v1_bbr_proto_rawDescData)
+	})
+	return file_gateway_middleware_bbr_v1_bbr_proto_rawDescData
+}
+
+var file_gateway_middleware_bbr_v1_bbr_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gateway_middleware_bbr_v1_bbr_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_bbr_v1_bbr_proto_goTypes = []interface{}{
+	(BBR_Algorithm)(0),          // 0: gateway.middleware.bbr.v1.BBR.Algorithm
+	(*BBR)(nil),                 // 1: gateway.middleware.bbr.v1.BBR
+	(*durationpb.Duration)(nil), // 2: google.protobuf.Duration
+}
+var file_gateway_middleware_bbr_v1_bbr_proto_depIdxs = []int32{
+	0, // 0: gateway.middleware.bbr.v1.BBR.algorithm:type_name -> gateway.middleware.bbr.v1.BBR.Algorithm
+	2, // 1: gateway.middleware.bbr.v1.BBR.window:type_name -> google.protobuf.Duration
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_bbr_v1_bbr_proto_init() }
+func file_gateway_middleware_bbr_v1_bbr_proto_init() {
+	if File_gateway_middleware_bbr_v1_bbr_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_bbr_v1_bbr_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BBR); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_bbr_v1_bbr_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_bbr_v1_bbr_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_bbr_v1_bbr_proto_depIdxs,
+		EnumInfos:         file_gateway_middleware_bbr_v1_bbr_proto_enumTypes,
+		MessageInfos:      file_gateway_middleware_bbr_v1_bbr_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_bbr_v1_bbr_proto = out.File
+	file_gateway_middleware_bbr_v1_bbr_proto_rawDesc = nil
+	file_gateway_middleware_bbr_v1_bbr_proto_goTypes = nil
+	file_gateway_middleware_bbr_v1_bbr_proto_depIdxs = nil
+}
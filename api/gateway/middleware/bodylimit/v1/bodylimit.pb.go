@@ -0,0 +1,184 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/bodylimit/v1/bodylimit.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// BodyLimit middleware config, the request bodies are limited while they're
+// read by the proxy before buffered, the requests over the size are responded
+// with 413, and the ones uploaded slower than the rate are responded with 408.
+type BodyLimit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the max bytes of the request body, unlimited if 0
+	MaxBytes int64 `protobuf:"varint,1,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	// the min average rate of the upload after the grace period, unlimited if
+	// 0, the stalled uploads are bounded by the read timeout of the server
+	MinBytesPerSecond int64 `protobuf:"varint,2,opt,name=min_bytes_per_second,json=minBytesPerSecond,proto3" json:"min_bytes_per_second,omitempty"`
+	// default is 1s
+	GracePeriod *durationpb.Duration `protobuf:"bytes,3,opt,name=grace_period,json=gracePeriod,proto3" json:"grace_period,omitempty"`
+}
+
+func (x *BodyLimit) Reset() {
+	*x = BodyLimit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_bodylimit_v1_bodylimit_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BodyLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BodyLimit) ProtoMessage() {}
+
+func (x *BodyLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_bodylimit_v1_bodylimit_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BodyLimit.ProtoReflect.Descriptor instead.
+func (*BodyLimit) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BodyLimit) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *BodyLimit) GetMinBytesPerSecond() int64 {
+	if x != nil {
+		return x.MinBytesPerSecond
+	}
+	return 0
+}
+
+func (x *BodyLimit) GetGracePeriod() *durationpb.Duration {
+	if x != nil {
+		return x.GracePeriod
+	}
+	return nil
+}
+
+var File_gateway_middleware_bodylimit_v1_bodylimit_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDesc = []byte{
+	0x0a, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x62, 0x6f, 0x64, 0x79, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x2f, 0x76,
+	0x31, 0x2f, 0x62, 0x6f, 0x64, 0x79, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x1f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c,
+	0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x62, 0x6f, 0x64, 0x79, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x2e,
+	0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0x97, 0x01, 0x0a, 0x09, 0x42, 0x6f, 0x64, 0x79, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2f, 0x0a,
+	0x14, 0x6d, 0x69, 0x6e, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x6d, 0x69, 0x6e,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x3c,
+	0x0a, 0x0c, 0x67, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x0b, 0x67, 0x72, 0x61, 0x63, 0x65, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x42, 0x42, 0x5a, 0x40,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72,
+	0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77,
+	0x61, 0x72, 0x65, 0x2f, 0x62, 0x6f, 0x64, 0x79, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x2f, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescOnce sync.Once
+	file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescData = file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDesc
+)
+
+func file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescData)
+	})
+	return file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDescData
+}
+
+var file_gateway_middleware_bodylimit_v1_bodylimit_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_bodylimit_v1_bodylimit_proto_goTypes = []interface{}{
+	(*BodyLimit)(nil),           // 0: gateway.middleware.bodylimit.v1.BodyLimit
+	(*durationpb.Duration)(nil), // 1: google.protobuf.Duration
+}
+var file_gateway_middleware_bodylimit_v1_bodylimit_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.bodylimit.v1.BodyLimit.grace_period:type_name -> google.protobuf.Duration
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_bodylimit_v1_bodylimit_proto_init() }
+func file_gateway_middleware_bodylimit_v1_bodylimit_proto_init() {
+	if File_gateway_middleware_bodylimit_v1_bodylimit_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_bodylimit_v1_bodylimit_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BodyLimit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_bodylimit_v1_bodylimit_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_bodylimit_v1_bodylimit_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_bodylimit_v1_bodylimit_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_bodylimit_v1_bodylimit_proto = out.File
+	file_gateway_middleware_bodylimit_v1_bodylimit_proto_rawDesc = nil
+	file_gateway_middleware_bodylimit_v1_bodylimit_proto_goTypes = nil
+	file_gateway_middleware_bodylimit_v1_bodylimit_proto_depIdxs = nil
+}
@@ -0,0 +1,201 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/cel/v1/cel.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CEL middleware config, the expressions of the Common Expression Language are
+// evaluated against the attributes of the request:
+//
+//	request.method, request.host, request.path, request.query (the first
+//	values), request.headers (the lowercase names, the values joined by comma),
+//	request.route, request.path_vars, claims (of the verified token, empty if
+//	none), source.ip and consumer (the name, empty if none).
+//
+// e.g. request.method == "GET" || "admin" in claims.roles, the requests are
+// denied if any expression fails to evaluate, e.g. of the missing keys, which
+// can be tested by has(claims.roles).
+type CEL struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the requests matched by any of the expressions are denied
+	Deny []string `protobuf:"bytes,1,rep,name=deny,proto3" json:"deny,omitempty"`
+	// the requests not denied are allowed only if matched by any of the
+	// expressions, they're all allowed if empty
+	Allow []string `protobuf:"bytes,2,rep,name=allow,proto3" json:"allow,omitempty"`
+	// default is 403
+	DenyStatus int32 `protobuf:"varint,3,opt,name=deny_status,json=denyStatus,proto3" json:"deny_status,omitempty"`
+	// the headers of the upstream request set to the string results of the
+	// expressions, not set if empty, e.g. X-Tier: claims.tier
+	Headers map[string]string `protobuf:"bytes,4,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *CEL) Reset() {
+	*x = CEL{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_cel_v1_cel_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CEL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CEL) ProtoMessage() {}
+
+func (x *CEL) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_cel_v1_cel_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CEL.ProtoReflect.Descriptor instead.
+func (*CEL) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_cel_v1_cel_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CEL) GetDeny() []string {
+	if x != nil {
+		return x.Deny
+	}
+	return nil
+}
+
+func (x *CEL) GetAllow() []string {
+	if x != nil {
+		return x.Allow
+	}
+	return nil
+}
+
+func (x *CEL) GetDenyStatus() int32 {
+	if x != nil {
+		return x.DenyStatus
+	}
+	return 0
+}
+
+func (x *CEL) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+var File_gateway_middleware_cel_v1_cel_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_cel_v1_cel_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x63, 0x65, 0x6c, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x6c, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x65, 0x6c, 0x2e, 0x76, 0x31,
+	0x22, 0xd3, 0x01, 0x0a, 0x03, 0x43, 0x45, 0x4c, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x6e, 0x79,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x6e, 0x79, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x65, 0x6e, 0x79, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x45, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x65, 0x6c, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x45, 0x4c, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x3c, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x63, 0x65,
+	0x6c, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_cel_v1_cel_proto_rawDescOnce sync.Once
+	file_gateway_middleware_cel_v1_cel_proto_rawDescData = file_gateway_middleware_cel_v1_cel_proto_rawDesc
+)
+
+func file_gateway_middleware_cel_v1_cel_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_cel_v1_cel_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_cel_v1_cel_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_cel_v1_cel_proto_rawDescData)
+	})
+	return file_gateway_middleware_cel_v1_cel_proto_rawDescData
+}
+
+var file_gateway_middleware_cel_v1_cel_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_gateway_middleware_cel_v1_cel_proto_goTypes = []interface{}{
+	(*CEL)(nil), // 0: gateway.middleware.cel.v1.CEL
+	nil,         // 1: gateway.middleware.cel.v1.CEL.HeadersEntry
+}
+var file_gateway_middleware_cel_v1_cel_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.cel.v1.CEL.headers:type_name -> gateway.middleware.cel.v1.CEL.HeadersEntry
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_cel_v1_cel_proto_init() }
+func file_gateway_middleware_cel_v1_cel_proto_init() {
+	if File_gateway_middleware_cel_v1_cel_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_cel_v1_cel_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CEL); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_cel_v1_cel_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_cel_v1_cel_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_cel_v1_cel_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_cel_v1_cel_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_cel_v1_cel_proto = out.File
+	file_gateway_middleware_cel_v1_cel_proto_rawDesc = nil
+	file_gateway_middleware_cel_v1_cel_proto_goTypes = nil
+	file_gateway_middleware_cel_v1_cel_proto_depIdxs = nil
+}
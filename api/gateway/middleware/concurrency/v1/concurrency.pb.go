@@ -0,0 +1,264 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/concurrency/v1/concurrency.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Concurrency_Key int32
+
+const (
+	// the endpoint of the request
+	Concurrency_ROUTE Concurrency_Key = 0
+	// the backends or the clusters of the endpoint, shared by the
+	// endpoints of the same upstream
+	Concurrency_UPSTREAM Concurrency_Key = 1
+)
+
+// Enum value maps for Concurrency_Key.
+var (
+	Concurrency_Key_name = map[int32]string{
+		0: "ROUTE",
+		1: "UPSTREAM",
+	}
+	Concurrency_Key_value = map[string]int32{
+		"ROUTE":    0,
+		"UPSTREAM": 1,
+	}
+)
+
+func (x Concurrency_Key) Enum() *Concurrency_Key {
+	p := new(Concurrency_Key)
+	*p = x
+	return p
+}
+
+func (x Concurrency_Key) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Concurrency_Key) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_concurrency_v1_concurrency_proto_enumTypes[0].Descriptor()
+}
+
+func (Concurrency_Key) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_concurrency_v1_concurrency_proto_enumTypes[0]
+}
+
+func (x Concurrency_Key) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Concurrency_Key.Descriptor instead.
+func (Concurrency_Key) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// Concurrency middleware config, the in-flight requests are limited by the
+// key until the response bodies are closed, the requests over the limit wait
+// in the queue up to the depth and the timeout, and they're responded with 503
+// and the Retry-After header if saturated.
+type Concurrency struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key         Concurrency_Key `protobuf:"varint,1,opt,name=key,proto3,enum=gateway.middleware.concurrency.v1.Concurrency_Key" json:"key,omitempty"`
+	MaxRequests uint32          `protobuf:"varint,2,opt,name=max_requests,json=maxRequests,proto3" json:"max_requests,omitempty"`
+	// the requests are not queued if 0
+	MaxQueue uint32 `protobuf:"varint,3,opt,name=max_queue,json=maxQueue,proto3" json:"max_queue,omitempty"`
+	// default is 1s
+	QueueTimeout *durationpb.Duration `protobuf:"bytes,4,opt,name=queue_timeout,json=queueTimeout,proto3" json:"queue_timeout,omitempty"`
+	// default is 1s
+	RetryAfter *durationpb.Duration `protobuf:"bytes,5,opt,name=retry_after,json=retryAfter,proto3" json:"retry_after,omitempty"`
+}
+
+func (x *Concurrency) Reset() {
+	*x = Concurrency{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_concurrency_v1_concurrency_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Concurrency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Concurrency) ProtoMessage() {}
+
+func (x *Concurrency) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_concurrency_v1_concurrency_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Concurrency.ProtoReflect.Descriptor instead.
+func (*Concurrency) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Concurrency) GetKey() Concurrency_Key {
+	if x != nil {
+		return x.Key
+	}
+	return Concurrency_ROUTE
+}
+
+func (x *Concurrency) GetMaxRequests() uint32 {
+	if x != nil {
+		return x.MaxRequests
+	}
+	return 0
+}
+
+func (x *Concurrency) GetMaxQueue() uint32 {
+	if x != nil {
+		return x.MaxQueue
+	}
+	return 0
+}
+
+func (x *Concurrency) GetQueueTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.QueueTimeout
+	}
+	return nil
+}
+
+func (x *Concurrency) GetRetryAfter() *durationpb.Duration {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return nil
+}
+
+var File_gateway_middleware_concurrency_v1_concurrency_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_concurrency_v1_concurrency_proto_rawDesc = []byte{
+	0x0a, 0x33, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79,
+	0x2f, 0x76, 0x31, 0x2f, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x21, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xaf, 0x02, 0x0a, 0x0b, 0x43, 0x6f, 0x6e,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x44, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x32, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e,
+	0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6e, 0x63, 0x75,
+	0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x21,
+	0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x51, 0x75, 0x65, 0x75, 0x65, 0x12, 0x3e,
+	0x0a, 0x0d, 0x71, 0x75, 0x65, 0x75, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0c, 0x71, 0x75, 0x65, 0x75, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x3a,
+	0x0a, 0x0b, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a,
+	0x72, 0x65, 0x74, 0x72, 0x79, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0x1e, 0x0a, 0x03, 0x4b, 0x65,
+	0x79, 0x12, 0x09, 0x0a, 0x05, 0x52, 0x4f, 0x55, 0x54, 0x45, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08,
+	0x55, 0x50, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x10, 0x01, 0x42, 0x44, 0x5a, 0x42, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74,
+	0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72,
+	0x65, 0x2f, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x2f, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescOnce sync.Once
+	file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescData = file_gateway_middleware_concurrency_v1_concurrency_proto_rawDesc
+)
+
+func file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescData)
+	})
+	return file_gateway_middleware_concurrency_v1_concurrency_proto_rawDescData
+}
+
+var file_gateway_middleware_concurrency_v1_concurrency_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gateway_middleware_concurrency_v1_concurrency_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_concurrency_v1_concurrency_proto_goTypes = []interface{}{
+	(Concurrency_Key)(0),        // 0: gateway.middleware.concurrency.v1.Concurrency.Key
+	(*Concurrency)(nil),         // 1: gateway.middleware.concurrency.v1.Concurrency
+	(*durationpb.Duration)(nil), // 2: google.protobuf.Duration
+}
+var file_gateway_middleware_concurrency_v1_concurrency_proto_depIdxs = []int32{
+	0, // 0: gateway.middleware.concurrency.v1.Concurrency.key:type_name -> gateway.middleware.concurrency.v1.Concurrency.Key
+	2, // 1: gateway.middleware.concurrency.v1.Concurrency.queue_timeout:type_name -> google.protobuf.Duration
+	2, // 2: gateway.middleware.concurrency.v1.Concurrency.retry_after:type_name -> google.protobuf.Duration
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_concurrency_v1_concurrency_proto_init() }
+func file_gateway_middleware_concurrency_v1_concurrency_proto_init() {
+	if File_gateway_middleware_concurrency_v1_concurrency_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_concurrency_v1_concurrency_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Concurrency); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_concurrency_v1_concurrency_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_concurrency_v1_concurrency_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_concurrency_v1_concurrency_proto_depIdxs,
+		EnumInfos:         file_gateway_middleware_concurrency_v1_concurrency_proto_enumTypes,
+		MessageInfos:      file_gateway_middleware_concurrency_v1_concurrency_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_concurrency_v1_concurrency_proto = out.File
+	file_gateway_middleware_concurrency_v1_concurrency_proto_rawDesc = nil
+	file_gateway_middleware_concurrency_v1_concurrency_proto_goTypes = nil
+	file_gateway_middleware_concurrency_v1_concurrency_proto_depIdxs = nil
+}
@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/csrf/v1/csrf.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CSRF middleware config, the requests of the unsafe methods are responded
+// with 403 unless the Origin or the Referer is trusted, and the token of the
+// header or the form field matches the one of the cookie by the double submit.
+// The token cookie is readable by the scripts, and the token is sent in the
+// header of the responses of the safe methods for the single page apps.
+type CSRF struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the origins trusted besides the one of the host, e.g.
+	// https://app.example.com, or *.example.com to match the subdomains
+	TrustedOrigins []string `protobuf:"bytes,1,rep,name=trusted_origins,json=trustedOrigins,proto3" json:"trusted_origins,omitempty"`
+	// checks the origins only, the tokens are neither issued nor checked
+	OriginOnly bool `protobuf:"varint,2,opt,name=origin_only,json=originOnly,proto3" json:"origin_only,omitempty"`
+	// default is csrf_token
+	CookieName string `protobuf:"bytes,3,opt,name=cookie_name,json=cookieName,proto3" json:"cookie_name,omitempty"`
+	// default is X-CSRF-Token
+	HeaderName string `protobuf:"bytes,4,opt,name=header_name,json=headerName,proto3" json:"header_name,omitempty"`
+	// the field of the token in the application/x-www-form-urlencoded
+	// bodies, it's checked if the header is empty
+	FormField string `protobuf:"bytes,5,opt,name=form_field,json=formField,proto3" json:"form_field,omitempty"`
+	// the secret to sign the tokens, the cookies set by the other sites of the
+	// parent domain are rejected if signed, at least 16 bytes
+	Secret string `protobuf:"bytes,6,opt,name=secret,proto3" json:"secret,omitempty"`
+	// default is /
+	CookiePath   string `protobuf:"bytes,7,opt,name=cookie_path,json=cookiePath,proto3" json:"cookie_path,omitempty"`
+	CookieDomain string `protobuf:"bytes,8,opt,name=cookie_domain,json=cookieDomain,proto3" json:"cookie_domain,omitempty"`
+	// Strict, Lax or None, default is Lax
+	SameSite string `protobuf:"bytes,9,opt,name=same_site,json=sameSite,proto3" json:"same_site,omitempty"`
+	// the max age of the cookie, it's a session cookie if 0
+	MaxAge *durationpb.Duration `protobuf:"bytes,10,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	// default are GET, HEAD, OPTIONS and TRACE
+	SafeMethods []string `protobuf:"bytes,11,rep,name=safe_methods,json=safeMethods,proto3" json:"safe_methods,omitempty"`
+	// the paths not protected, the path ends with * for the prefix, e.g. the
+	// webhooks of the other sites
+	ExemptPaths []string `protobuf:"bytes,12,rep,name=exempt_paths,json=exemptPaths,proto3" json:"exempt_paths,omitempty"`
+}
+
+func (x *CSRF) Reset() {
+	*x = CSRF{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_csrf_v1_csrf_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSRF) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSRF) ProtoMessage() {}
+
+func (x *CSRF) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_csrf_v1_csrf_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSRF.ProtoReflect.Descriptor instead.
+func (*CSRF) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_csrf_v1_csrf_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CSRF) GetTrustedOrigins() []string {
+	if x != nil {
+		return x.TrustedOrigins
+	}
+	return nil
+}
+
+func (x *CSRF) GetOriginOnly() bool {
+	if x != nil {
+		return x.OriginOnly
+	}
+	return false
+}
+
+func (x *CSRF) GetCookieName() string {
+	if x != nil {
+		return x.CookieName
+	}
+	return ""
+}
+
+func (x *CSRF) GetHeaderName() string {
+	if x != nil {
+		return x.HeaderName
+	}
+	return ""
+}
+
+func (x *CSRF) GetFormField() string {
+	if x != nil {
+		return x.FormField
+	}
+	return ""
+}
+
+func (x *CSRF) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *CSRF) GetCookiePath() string {
+	if x != nil {
+		return x.CookiePath
+	}
+	return ""
+}
+
+func (x *CSRF) GetCookieDomain() string {
+	if x != nil {
+		return x.CookieDomain
+	}
+	return ""
+}
+
+func (x *CSRF) GetSameSite() string {
+	if x != nil {
+		return x.SameSite
+	}
+	return ""
+}
+
+func (x *CSRF) GetMaxAge() *durationpb.Duration {
+	if x != nil {
+		return x.MaxAge
+	}
+	return nil
+}
+
+func (x *CSRF) GetSafeMethods() []string {
+	if x != nil {
+		return x.SafeMethods
+	}
+	return nil
+}
+
+func (x *CSRF) GetExemptPaths() []string {
+	if x != nil {
+		return x.ExemptPaths
+	}
+	return nil
+}
+
+var File_gateway_middleware_csrf_v1_csrf_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_csrf_v1_csrf_proto_rawDesc = []byte{
+	0x0a, 0x25, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x63, 0x73, 0x72, 0x66, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x73, 0x72,
+	0x66, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1a, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x73, 0x72, 0x66,
+	0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xa6, 0x03, 0x0a, 0x04, 0x43, 0x53, 0x52, 0x46, 0x12, 0x27, 0x0a, 0x0f,
+	0x74, 0x72, 0x75, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x74, 0x72, 0x75, 0x73, 0x74, 0x65, 0x64, 0x4f, 0x72,
+	0x69, 0x67, 0x69, 0x6e, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x5f,
+	0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6f, 0x72, 0x69, 0x67,
+	0x69, 0x6e, 0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6f,
+	0x6b, 0x69, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x68, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x6f, 0x72, 0x6d,
+	0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x6f,
+	0x72, 0x6d, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12,
+	0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x50, 0x61, 0x74, 0x68,
+	0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x5f, 0x64, 0x6f, 0x6d, 0x61, 0x69,
+	0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65, 0x44,
+	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x61, 0x6d, 0x65, 0x5f, 0x73, 0x69,
+	0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x61, 0x6d, 0x65, 0x53, 0x69,
+	0x74, 0x65, 0x12, 0x32, 0x0a, 0x07, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x67, 0x65, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06,
+	0x6d, 0x61, 0x78, 0x41, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x61, 0x66, 0x65, 0x5f, 0x6d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x61,
+	0x66, 0x65, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x65, 0x78, 0x65,
+	0x6d, 0x70, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0b, 0x65, 0x78, 0x65, 0x6d, 0x70, 0x74, 0x50, 0x61, 0x74, 0x68, 0x73, 0x42, 0x3d, 0x5a, 0x3b,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72,
+	0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77,
+	0x61, 0x72, 0x65, 0x2f, 0x63, 0x73, 0x72, 0x66, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_csrf_v1_csrf_proto_rawDescOnce sync.Once
+	file_gateway_middleware_csrf_v1_csrf_proto_rawDescData = file_gateway_middleware_csrf_v1_csrf_proto_rawDesc
+)
+
+func file_gateway_middleware_csrf_v1_csrf_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_csrf_v1_csrf_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_csrf_v1_csrf_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_csrf_v1_csrf_proto_rawDescData)
+	})
+	return file_gateway_middleware_csrf_v1_csrf_proto_rawDescData
+}
+
+var file_gateway_middleware_csrf_v1_csrf_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_csrf_v1_csrf_proto_goTypes = []interface{}{
+	(*CSRF)(nil),                // 0: gateway.middleware.csrf.v1.CSRF
+	(*durationpb.Duration)(nil), // 1: google.protobuf.Duration
+}
+var file_gateway_middleware_csrf_v1_csrf_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.csrf.v1.CSRF.max_age:type_name -> google.protobuf.Duration
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_csrf_v1_csrf_proto_init() }
+func file_gateway_middleware_csrf_v1_csrf_proto_init() {
+	if File_gateway_middleware_csrf_v1_csrf_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_csrf_v1_csrf_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSRF); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_csrf_v1_csrf_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_csrf_v1_csrf_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_csrf_v1_csrf_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_csrf_v1_csrf_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_csrf_v1_csrf_proto = out.File
+	file_gateway_middleware_csrf_v1_csrf_proto_rawDesc = nil
+	file_gateway_middleware_csrf_v1_csrf_proto_goTypes = nil
+	file_gateway_middleware_csrf_v1_csrf_proto_depIdxs = nil
+}
@@ -0,0 +1,395 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/extauthz/v1/extauthz.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ExtAuthz middleware config, the requests are checked by the external
+// authorization service before sent to the upstream.
+type ExtAuthz struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Service:
+	//	*ExtAuthz_Http
+	//	*ExtAuthz_Grpc
+	Service isExtAuthz_Service `protobuf_oneof:"service"`
+	// default is 1s
+	Timeout *durationpb.Duration `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// allows the requests if failed to call the authorization service, the
+	// requests are denied with 403 by default
+	FailureOpen bool `protobuf:"varint,4,opt,name=failure_open,json=failureOpen,proto3" json:"failure_open,omitempty"`
+	// the request headers sent to the authorization service, all the headers
+	// are sent if empty
+	AllowedHeaders []string `protobuf:"bytes,5,rep,name=allowed_headers,json=allowedHeaders,proto3" json:"allowed_headers,omitempty"`
+	// sends the body up to the bytes, the body is not sent if 0
+	MaxRequestBytes uint32 `protobuf:"varint,6,opt,name=max_request_bytes,json=maxRequestBytes,proto3" json:"max_request_bytes,omitempty"`
+}
+
+func (x *ExtAuthz) Reset() {
+	*x = ExtAuthz{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtAuthz) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtAuthz) ProtoMessage() {}
+
+func (x *ExtAuthz) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtAuthz.ProtoReflect.Descriptor instead.
+func (*ExtAuthz) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *ExtAuthz) GetService() isExtAuthz_Service {
+	if m != nil {
+		return m.Service
+	}
+	return nil
+}
+
+func (x *ExtAuthz) GetHttp() *HTTPService {
+	if x, ok := x.GetService().(*ExtAuthz_Http); ok {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *ExtAuthz) GetGrpc() *GRPCService {
+	if x, ok := x.GetService().(*ExtAuthz_Grpc); ok {
+		return x.Grpc
+	}
+	return nil
+}
+
+func (x *ExtAuthz) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *ExtAuthz) GetFailureOpen() bool {
+	if x != nil {
+		return x.FailureOpen
+	}
+	return false
+}
+
+func (x *ExtAuthz) GetAllowedHeaders() []string {
+	if x != nil {
+		return x.AllowedHeaders
+	}
+	return nil
+}
+
+func (x *ExtAuthz) GetMaxRequestBytes() uint32 {
+	if x != nil {
+		return x.MaxRequestBytes
+	}
+	return 0
+}
+
+type isExtAuthz_Service interface {
+	isExtAuthz_Service()
+}
+
+type ExtAuthz_Http struct {
+	Http *HTTPService `protobuf:"bytes,1,opt,name=http,proto3,oneof"`
+}
+
+type ExtAuthz_Grpc struct {
+	Grpc *GRPCService `protobuf:"bytes,2,opt,name=grpc,proto3,oneof"`
+}
+
+func (*ExtAuthz_Http) isExtAuthz_Service() {}
+
+func (*ExtAuthz_Grpc) isExtAuthz_Service() {}
+
+// The request is sent to the URL appended by the path of the original request
+// with the same method, the request is allowed by the 2xx responses, and the
+// other responses are sent to the client as the denied response.
+type HTTPService struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// e.g. http://authz.example.com/check
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// the headers of the allowed response set to the upstream request, e.g. X-User-Id
+	UpstreamHeaders []string `protobuf:"bytes,2,rep,name=upstream_headers,json=upstreamHeaders,proto3" json:"upstream_headers,omitempty"`
+}
+
+func (x *HTTPService) Reset() {
+	*x = HTTPService{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HTTPService) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HTTPService) ProtoMessage() {}
+
+func (x *HTTPService) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HTTPService.ProtoReflect.Descriptor instead.
+func (*HTTPService) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HTTPService) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *HTTPService) GetUpstreamHeaders() []string {
+	if x != nil {
+		return x.UpstreamHeaders
+	}
+	return nil
+}
+
+// The request is checked by the envoy.service.auth.v3.Authorization service,
+// the header mutations of the OK response are applied to the upstream request,
+// and the denied response is sent to the client, default is 403.
+type GRPCService struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// e.g. authz.example.com:9000
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+}
+
+func (x *GRPCService) Reset() {
+	*x = GRPCService{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GRPCService) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GRPCService) ProtoMessage() {}
+
+func (x *GRPCService) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GRPCService.ProtoReflect.Descriptor instead.
+func (*GRPCService) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GRPCService) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+var File_gateway_middleware_extauthz_v1_extauthz_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_extauthz_v1_extauthz_proto_rawDesc = []byte{
+	0x0a, 0x2d, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x65, 0x78, 0x74, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2f, 0x76, 0x31,
+	0x2f, 0x65, 0x78, 0x74, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x1e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77,
+	0x61, 0x72, 0x65, 0x2e, 0x65, 0x78, 0x74, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2e, 0x76, 0x31, 0x1a,
+	0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xc8, 0x02, 0x0a, 0x08, 0x45, 0x78, 0x74, 0x41, 0x75, 0x74, 0x68, 0x7a, 0x12, 0x41, 0x0a, 0x04,
+	0x68, 0x74, 0x74, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x67, 0x61, 0x74,
+	0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e,
+	0x65, 0x78, 0x74, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x54, 0x54, 0x50,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x48, 0x00, 0x52, 0x04, 0x68, 0x74, 0x74, 0x70, 0x12,
+	0x41, 0x0a, 0x04, 0x67, 0x72, 0x70, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2b, 0x2e,
+	0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61,
+	0x72, 0x65, 0x2e, 0x65, 0x78, 0x74, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x52, 0x50, 0x43, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x48, 0x00, 0x52, 0x04, 0x67, 0x72,
+	0x70, 0x63, 0x12, 0x33, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07,
+	0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x66, 0x61, 0x69, 0x6c, 0x75,
+	0x72, 0x65, 0x5f, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x66,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x4f, 0x70, 0x65, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x6c,
+	0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f,
+	0x6d, 0x61, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x42,
+	0x09, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x22, 0x4a, 0x0a, 0x0b, 0x48, 0x54,
+	0x54, 0x50, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x29, 0x0a, 0x10, 0x75,
+	0x70, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x75, 0x70, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x48,
+	0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x22, 0x25, 0x0a, 0x0b, 0x47, 0x52, 0x50, 0x43, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x42, 0x41, 0x5a,
+	0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b,
+	0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70,
+	0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x65, 0x78, 0x74, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x2f, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescOnce sync.Once
+	file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescData = file_gateway_middleware_extauthz_v1_extauthz_proto_rawDesc
+)
+
+func file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescData)
+	})
+	return file_gateway_middleware_extauthz_v1_extauthz_proto_rawDescData
+}
+
+var file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_gateway_middleware_extauthz_v1_extauthz_proto_goTypes = []interface{}{
+	(*ExtAuthz)(nil),            // 0: gateway.middleware.extauthz.v1.ExtAuthz
+	(*HTTPService)(nil),         // 1: gateway.middleware.extauthz.v1.HTTPService
+	(*GRPCService)(nil),         // 2: gateway.middleware.extauthz.v1.GRPCService
+	(*durationpb.Duration)(nil), // 3: google.protobuf.Duration
+}
+var file_gateway_middleware_extauthz_v1_extauthz_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.extauthz.v1.ExtAuthz.http:type_name -> gateway.middleware.extauthz.v1.HTTPService
+	2, // 1: gateway.middleware.extauthz.v1.ExtAuthz.grpc:type_name -> gateway.middleware.extauthz.v1.GRPCService
+	3, // 2: gateway.middleware.extauthz.v1.ExtAuthz.timeout:type_name -> google.protobuf.Duration
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_extauthz_v1_extauthz_proto_init() }
+func file_gateway_middleware_extauthz_v1_extauthz_proto_init() {
+	if File_gateway_middleware_extauthz_v1_extauthz_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtAuthz); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HTTPService); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GRPCService); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*ExtAuthz_Http)(nil),
+		(*ExtAuthz_Grpc)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_extauthz_v1_extauthz_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_extauthz_v1_extauthz_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_extauthz_v1_extauthz_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_extauthz_v1_extauthz_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_extauthz_v1_extauthz_proto = out.File
+	file_gateway_middleware_extauthz_v1_extauthz_proto_rawDesc = nil
+	file_gateway_middleware_extauthz_v1_extauthz_proto_goTypes = nil
+	file_gateway_middleware_extauthz_v1_extauthz_proto_depIdxs = nil
+}
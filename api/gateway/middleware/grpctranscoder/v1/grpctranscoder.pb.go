@@ -0,0 +1,211 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/grpctranscoder/v1/grpctranscoder.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// GRPCTranscoder middleware config, the HTTP/JSON requests are transcoded to
+// the methods of the gRPC backends by the google.api.http annotations, the
+// endpoint protocol must be GRPC. The native gRPC requests are passed as is.
+//
+// The messages of the server streaming methods are sent as the server-sent
+// events if the client accepts text/event-stream, or as the newline-delimited
+// JSON otherwise, the error is sent as the error event or the line of
+// {"error": status}. The body of the client streaming methods is the
+// sequence of the JSON messages.
+type GRPCTranscoder struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the file of the FileDescriptorSet, e.g. generated by protoc
+	// --include_imports --descriptor_set_out, the imports of the well-known
+	// types and google.api can be left out
+	DescriptorSet string `protobuf:"bytes,1,opt,name=descriptor_set,json=descriptorSet,proto3" json:"descriptor_set,omitempty"`
+	// loads the descriptors from the backends by the gRPC server reflection
+	// instead of the descriptor set, they're reloaded at most once a minute on
+	// the unknown routes
+	Reflection bool `protobuf:"varint,2,opt,name=reflection,proto3" json:"reflection,omitempty"`
+	// the full names of the services transcoded, default is all of the
+	// descriptors, e.g. helloworld.Greeter
+	Services []string `protobuf:"bytes,3,rep,name=services,proto3" json:"services,omitempty"`
+	// the JSON fields are the proto names instead of the lowerCamelCase ones
+	UseProtoNames bool `protobuf:"varint,4,opt,name=use_proto_names,json=useProtoNames,proto3" json:"use_proto_names,omitempty"`
+	// the JSON fields of the default values are emitted
+	EmitUnpopulated bool `protobuf:"varint,5,opt,name=emit_unpopulated,json=emitUnpopulated,proto3" json:"emit_unpopulated,omitempty"`
+}
+
+func (x *GRPCTranscoder) Reset() {
+	*x = GRPCTranscoder{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GRPCTranscoder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GRPCTranscoder) ProtoMessage() {}
+
+func (x *GRPCTranscoder) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GRPCTranscoder.ProtoReflect.Descriptor instead.
+func (*GRPCTranscoder) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GRPCTranscoder) GetDescriptorSet() string {
+	if x != nil {
+		return x.DescriptorSet
+	}
+	return ""
+}
+
+func (x *GRPCTranscoder) GetReflection() bool {
+	if x != nil {
+		return x.Reflection
+	}
+	return false
+}
+
+func (x *GRPCTranscoder) GetServices() []string {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *GRPCTranscoder) GetUseProtoNames() bool {
+	if x != nil {
+		return x.UseProtoNames
+	}
+	return false
+}
+
+func (x *GRPCTranscoder) GetEmitUnpopulated() bool {
+	if x != nil {
+		return x.EmitUnpopulated
+	}
+	return false
+}
+
+var File_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDesc = []byte{
+	0x0a, 0x39, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f,
+	0x64, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x24, 0x67, 0x61, 0x74,
+	0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e,
+	0x67, 0x72, 0x70, 0x63, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x22, 0xc6, 0x01, 0x0a, 0x0e, 0x47, 0x52, 0x50, 0x43, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x63,
+	0x6f, 0x64, 0x65, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x6f, 0x72, 0x5f, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x72,
+	0x65, 0x66, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0a, 0x72, 0x65, 0x66, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x75, 0x73, 0x65, 0x5f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0d, 0x75, 0x73, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12,
+	0x29, 0x0a, 0x10, 0x65, 0x6d, 0x69, 0x74, 0x5f, 0x75, 0x6e, 0x70, 0x6f, 0x70, 0x75, 0x6c, 0x61,
+	0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x65, 0x6d, 0x69, 0x74, 0x55,
+	0x6e, 0x70, 0x6f, 0x70, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x64, 0x42, 0x47, 0x5a, 0x45, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74,
+	0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72,
+	0x65, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x63, 0x6f, 0x64, 0x65, 0x72,
+	0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescOnce sync.Once
+	file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescData = file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDesc
+)
+
+func file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescData)
+	})
+	return file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDescData
+}
+
+var file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_goTypes = []interface{}{
+	(*GRPCTranscoder)(nil), // 0: gateway.middleware.grpctranscoder.v1.GRPCTranscoder
+}
+var file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_init() }
+func file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_init() {
+	if File_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GRPCTranscoder); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto = out.File
+	file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_rawDesc = nil
+	file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_goTypes = nil
+	file_gateway_middleware_grpctranscoder_v1_grpctranscoder_proto_depIdxs = nil
+}
@@ -0,0 +1,398 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/hmac/v1/hmac.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// the canonical request signed by the client
+type HMAC_Canonicalization int32
+
+const (
+	// the lines of the method, the escaped path, the sorted query, the
+	// timestamp, the nonce, the signed headers of lowercase name:value,
+	// and the hex SHA256 of the body, e.g.
+	// POST\n/v1/orders\na=1&b=2\n1700000000\nabc\ncontent-type:application/json\n{sha256}
+	HMAC_REQUEST HMAC_Canonicalization = 0
+	// the timestamp and the body joined by a dot, e.g. 1700000000.{body}
+	HMAC_TIMESTAMP_BODY HMAC_Canonicalization = 1
+)
+
+// Enum value maps for HMAC_Canonicalization.
+var (
+	HMAC_Canonicalization_name = map[int32]string{
+		0: "REQUEST",
+		1: "TIMESTAMP_BODY",
+	}
+	HMAC_Canonicalization_value = map[string]int32{
+		"REQUEST":        0,
+		"TIMESTAMP_BODY": 1,
+	}
+)
+
+func (x HMAC_Canonicalization) Enum() *HMAC_Canonicalization {
+	p := new(HMAC_Canonicalization)
+	*p = x
+	return p
+}
+
+func (x HMAC_Canonicalization) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HMAC_Canonicalization) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_hmac_v1_hmac_proto_enumTypes[0].Descriptor()
+}
+
+func (HMAC_Canonicalization) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_hmac_v1_hmac_proto_enumTypes[0]
+}
+
+func (x HMAC_Canonicalization) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HMAC_Canonicalization.Descriptor instead.
+func (HMAC_Canonicalization) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_hmac_v1_hmac_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// HMAC middleware config, the requests are verified by the hex HMAC of the
+// canonical request signed by the secret of the key id, the requests with an
+// invalid signature, a skewed timestamp or a replayed nonce are rejected with
+// 401. The nonce is the signature itself if the nonce header is not sent.
+type HMAC struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Credentials      []*Credential         `protobuf:"bytes,1,rep,name=credentials,proto3" json:"credentials,omitempty"`
+	Canonicalization HMAC_Canonicalization `protobuf:"varint,2,opt,name=canonicalization,proto3,enum=gateway.middleware.hmac.v1.HMAC_Canonicalization" json:"canonicalization,omitempty"`
+	// the headers signed in order of the REQUEST canonicalization, e.g. Host, Content-Type
+	SignedHeaders []string `protobuf:"bytes,3,rep,name=signed_headers,json=signedHeaders,proto3" json:"signed_headers,omitempty"`
+	// SHA256 or SHA512, default is SHA256
+	Algorithm string `protobuf:"bytes,4,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	// default is X-Key-Id
+	KeyIdHeader string `protobuf:"bytes,5,opt,name=key_id_header,json=keyIdHeader,proto3" json:"key_id_header,omitempty"`
+	// default is X-Signature
+	SignatureHeader string `protobuf:"bytes,6,opt,name=signature_header,json=signatureHeader,proto3" json:"signature_header,omitempty"`
+	// the unix seconds of the request, default is X-Timestamp
+	TimestampHeader string `protobuf:"bytes,7,opt,name=timestamp_header,json=timestampHeader,proto3" json:"timestamp_header,omitempty"`
+	// default is X-Nonce
+	NonceHeader string `protobuf:"bytes,8,opt,name=nonce_header,json=nonceHeader,proto3" json:"nonce_header,omitempty"`
+	// the max skew of the timestamp from now, default is 5m, the nonces are
+	// remembered for twice of it
+	MaxSkew *durationpb.Duration `protobuf:"bytes,9,opt,name=max_skew,json=maxSkew,proto3" json:"max_skew,omitempty"`
+}
+
+func (x *HMAC) Reset() {
+	*x = HMAC{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_hmac_v1_hmac_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HMAC) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HMAC) ProtoMessage() {}
+
+func (x *HMAC) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_hmac_v1_hmac_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HMAC.ProtoReflect.Descriptor instead.
+func (*HMAC) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_hmac_v1_hmac_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HMAC) GetCredentials() []*Credential {
+	if x != nil {
+		return x.Credentials
+	}
+	return nil
+}
+
+func (x *HMAC) GetCanonicalization() HMAC_Canonicalization {
+	if x != nil {
+		return x.Canonicalization
+	}
+	return HMAC_REQUEST
+}
+
+func (x *HMAC) GetSignedHeaders() []string {
+	if x != nil {
+		return x.SignedHeaders
+	}
+	return nil
+}
+
+func (x *HMAC) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *HMAC) GetKeyIdHeader() string {
+	if x != nil {
+		return x.KeyIdHeader
+	}
+	return ""
+}
+
+func (x *HMAC) GetSignatureHeader() string {
+	if x != nil {
+		return x.SignatureHeader
+	}
+	return ""
+}
+
+func (x *HMAC) GetTimestampHeader() string {
+	if x != nil {
+		return x.TimestampHeader
+	}
+	return ""
+}
+
+func (x *HMAC) GetNonceHeader() string {
+	if x != nil {
+		return x.NonceHeader
+	}
+	return ""
+}
+
+func (x *HMAC) GetMaxSkew() *durationpb.Duration {
+	if x != nil {
+		return x.MaxSkew
+	}
+	return nil
+}
+
+type Credential struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	KeyId  string `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	Secret string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	// the name of the consumer identified by the key id, optional
+	Consumer string `protobuf:"bytes,3,opt,name=consumer,proto3" json:"consumer,omitempty"`
+}
+
+func (x *Credential) Reset() {
+	*x = Credential{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_hmac_v1_hmac_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Credential) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Credential) ProtoMessage() {}
+
+func (x *Credential) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_hmac_v1_hmac_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Credential.ProtoReflect.Descriptor instead.
+func (*Credential) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_hmac_v1_hmac_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Credential) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Credential) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *Credential) GetConsumer() string {
+	if x != nil {
+		return x.Consumer
+	}
+	return ""
+}
+
+var File_gateway_middleware_hmac_v1_hmac_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_hmac_v1_hmac_proto_rawDesc = []byte{
+	0x0a, 0x25, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x68, 0x6d, 0x61, 0x63, 0x2f, 0x76, 0x31, 0x2f, 0x68, 0x6d, 0x61,
+	0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1a, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x68, 0x6d, 0x61, 0x63,
+	0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xfc, 0x03, 0x0a, 0x04, 0x48, 0x4d, 0x41, 0x43, 0x12, 0x48, 0x0a, 0x0b,
+	0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x26, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64,
+	0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x68, 0x6d, 0x61, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x52, 0x0b, 0x63, 0x72, 0x65, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x12, 0x5d, 0x0a, 0x10, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69,
+	0x63, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x31, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c,
+	0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x68, 0x6d, 0x61, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x4d,
+	0x41, 0x43, 0x2e, 0x43, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x10, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f,
+	0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x73,
+	0x69, 0x67, 0x6e, 0x65, 0x64, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x1c, 0x0a, 0x09,
+	0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x12, 0x22, 0x0a, 0x0d, 0x6b, 0x65,
+	0x79, 0x5f, 0x69, 0x64, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x29,
+	0x0a, 0x10, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x5f, 0x68, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x34, 0x0a, 0x08, 0x6d, 0x61, 0x78, 0x5f, 0x73,
+	0x6b, 0x65, 0x77, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x6d, 0x61, 0x78, 0x53, 0x6b, 0x65, 0x77, 0x22, 0x33, 0x0a,
+	0x10, 0x43, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x00, 0x12, 0x12,
+	0x0a, 0x0e, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f, 0x42, 0x4f, 0x44, 0x59,
+	0x10, 0x01, 0x22, 0x57, 0x0a, 0x0a, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x12, 0x15, 0x0a, 0x06, 0x6b, 0x65, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6d, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x63, 0x6f, 0x6e, 0x73, 0x75, 0x6d, 0x65, 0x72, 0x42, 0x3d, 0x5a, 0x3b, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61,
+	0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f,
+	0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61,
+	0x72, 0x65, 0x2f, 0x68, 0x6d, 0x61, 0x63, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_hmac_v1_hmac_proto_rawDescOnce sync.Once
+	file_gateway_middleware_hmac_v1_hmac_proto_rawDescData = file_gateway_middleware_hmac_v1_hmac_proto_rawDesc
+)
+
+func file_gateway_middleware_hmac_v1_hmac_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_hmac_v1_hmac_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_hmac_v1_hmac_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_hmac_v1_hmac_proto_rawDescData)
+	})
+	return file_gateway_middleware_hmac_v1_hmac_proto_rawDescData
+}
+
+var file_gateway_middleware_hmac_v1_hmac_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gateway_middleware_hmac_v1_hmac_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_gateway_middleware_hmac_v1_hmac_proto_goTypes = []interface{}{
+	(HMAC_Canonicalization)(0),  // 0: gateway.middleware.hmac.v1.HMAC.Canonicalization
+	(*HMAC)(nil),                // 1: gateway.middleware.hmac.v1.HMAC
+	(*Credential)(nil),          // 2: gateway.middleware.hmac.v1.Credential
+	(*durationpb.Duration)(nil), // 3: google.protobuf.Duration
+}
+var file_gateway_middleware_hmac_v1_hmac_proto_depIdxs = []int32{
+	2, // 0: gateway.middleware.hmac.v1.HMAC.credentials:type_name -> gateway.middleware.hmac.v1.Credential
+	0, // 1: gateway.middleware.hmac.v1.HMAC.canonicalization:type_name -> gateway.middleware.hmac.v1.HMAC.Canonicalization
+	3, // 2: gateway.middleware.hmac.v1.HMAC.max_skew:type_name -> google.protobuf.Duration
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_hmac_v1_hmac_proto_init() }
+func file_gateway_middleware_hmac_v1_hmac_proto_init() {
+	if File_gateway_middleware_hmac_v1_hmac_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_hmac_v1_hmac_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HMAC); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_hmac_v1_hmac_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Credential); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_hmac_v1_hmac_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_hmac_v1_hmac_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_hmac_v1_hmac_proto_depIdxs,
+		EnumInfos:         file_gateway_middleware_hmac_v1_hmac_proto_enumTypes,
+		MessageInfos:      file_gateway_middleware_hmac_v1_hmac_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_hmac_v1_hmac_proto = out.File
+	file_gateway_middleware_hmac_v1_hmac_proto_rawDesc = nil
+	file_gateway_middleware_hmac_v1_hmac_proto_goTypes = nil
+	file_gateway_middleware_hmac_v1_hmac_proto_depIdxs = nil
+}
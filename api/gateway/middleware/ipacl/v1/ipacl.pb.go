@@ -0,0 +1,197 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/ipacl/v1/ipacl.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// IPACL middleware config, the requests of the client IPs in the deny list are
+// rejected with 403, and so are the ones not in the allow list if it's not
+// empty, the deny list takes precedence.
+type IPACL struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the CIDRs or the single IPs allowed, e.g. 10.0.0.0/8
+	Allow []string `protobuf:"bytes,1,rep,name=allow,proto3" json:"allow,omitempty"`
+	// the CIDRs or the single IPs denied
+	Deny []string `protobuf:"bytes,2,rep,name=deny,proto3" json:"deny,omitempty"`
+	// the files of the allowed CIDRs, one per line and # for the comments,
+	// they're reloaded once modified
+	AllowFiles []string `protobuf:"bytes,3,rep,name=allow_files,json=allowFiles,proto3" json:"allow_files,omitempty"`
+	// the files of the denied CIDRs
+	DenyFiles []string `protobuf:"bytes,4,rep,name=deny_files,json=denyFiles,proto3" json:"deny_files,omitempty"`
+	// the proxies trusted to resolve the client IP by X-Forwarded-For, default
+	// is the trustedProxies of the gateway
+	TrustedProxies []string `protobuf:"bytes,5,rep,name=trusted_proxies,json=trustedProxies,proto3" json:"trusted_proxies,omitempty"`
+}
+
+func (x *IPACL) Reset() {
+	*x = IPACL{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_ipacl_v1_ipacl_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IPACL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPACL) ProtoMessage() {}
+
+func (x *IPACL) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_ipacl_v1_ipacl_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPACL.ProtoReflect.Descriptor instead.
+func (*IPACL) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *IPACL) GetAllow() []string {
+	if x != nil {
+		return x.Allow
+	}
+	return nil
+}
+
+func (x *IPACL) GetDeny() []string {
+	if x != nil {
+		return x.Deny
+	}
+	return nil
+}
+
+func (x *IPACL) GetAllowFiles() []string {
+	if x != nil {
+		return x.AllowFiles
+	}
+	return nil
+}
+
+func (x *IPACL) GetDenyFiles() []string {
+	if x != nil {
+		return x.DenyFiles
+	}
+	return nil
+}
+
+func (x *IPACL) GetTrustedProxies() []string {
+	if x != nil {
+		return x.TrustedProxies
+	}
+	return nil
+}
+
+var File_gateway_middleware_ipacl_v1_ipacl_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_ipacl_v1_ipacl_proto_rawDesc = []byte{
+	0x0a, 0x27, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x69, 0x70, 0x61, 0x63, 0x6c, 0x2f, 0x76, 0x31, 0x2f, 0x69, 0x70,
+	0x61, 0x63, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1b, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x69, 0x70,
+	0x61, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x22, 0x9a, 0x01, 0x0a, 0x05, 0x49, 0x50, 0x41, 0x43, 0x4c,
+	0x12, 0x14, 0x0a, 0x05, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x05, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x6c,
+	0x6c, 0x6f, 0x77, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0a, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x64,
+	0x65, 0x6e, 0x79, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x09, 0x64, 0x65, 0x6e, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x74, 0x72,
+	0x75, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0e, 0x74, 0x72, 0x75, 0x73, 0x74, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x78,
+	0x69, 0x65, 0x73, 0x42, 0x3e, 0x5a, 0x3c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65,
+	0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f,
+	0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x69, 0x70, 0x61, 0x63, 0x6c,
+	0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescOnce sync.Once
+	file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescData = file_gateway_middleware_ipacl_v1_ipacl_proto_rawDesc
+)
+
+func file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescData)
+	})
+	return file_gateway_middleware_ipacl_v1_ipacl_proto_rawDescData
+}
+
+var file_gateway_middleware_ipacl_v1_ipacl_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_ipacl_v1_ipacl_proto_goTypes = []interface{}{
+	(*IPACL)(nil), // 0: gateway.middleware.ipacl.v1.IPACL
+}
+var file_gateway_middleware_ipacl_v1_ipacl_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_ipacl_v1_ipacl_proto_init() }
+func file_gateway_middleware_ipacl_v1_ipacl_proto_init() {
+	if File_gateway_middleware_ipacl_v1_ipacl_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_ipacl_v1_ipacl_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IPACL); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_ipacl_v1_ipacl_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_ipacl_v1_ipacl_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_ipacl_v1_ipacl_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_ipacl_v1_ipacl_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_ipacl_v1_ipacl_proto = out.File
+	file_gateway_middleware_ipacl_v1_ipacl_proto_rawDesc = nil
+	file_gateway_middleware_ipacl_v1_ipacl_proto_goTypes = nil
+	file_gateway_middleware_ipacl_v1_ipacl_proto_depIdxs = nil
+}
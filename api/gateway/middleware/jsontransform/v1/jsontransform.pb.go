@@ -0,0 +1,286 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/jsontransform/v1/jsontransform.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// JSONTransform middleware config, the JSON bodies of the requests and the
+// responses are reshaped, the others and the invalid ones are passed as is.
+type JSONTransform struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Request  *BodyTransform `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Response *BodyTransform `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	// the max bytes of the bodies transformed, the larger ones are passed as
+	// is, default is 1MB
+	MaxBodyBytes int64 `protobuf:"varint,3,opt,name=max_body_bytes,json=maxBodyBytes,proto3" json:"max_body_bytes,omitempty"`
+}
+
+func (x *JSONTransform) Reset() {
+	*x = JSONTransform{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JSONTransform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JSONTransform) ProtoMessage() {}
+
+func (x *JSONTransform) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JSONTransform.ProtoReflect.Descriptor instead.
+func (*JSONTransform) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *JSONTransform) GetRequest() *BodyTransform {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *JSONTransform) GetResponse() *BodyTransform {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *JSONTransform) GetMaxBodyBytes() int64 {
+	if x != nil {
+		return x.MaxBodyBytes
+	}
+	return 0
+}
+
+// The body is renamed, removed and then rendered by the template in order.
+type BodyTransform struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the fields renamed by the dotted paths of the objects, e.g. userName:
+	// user.name moves the userName field into the user object
+	Rename map[string]string `protobuf:"bytes,1,rep,name=rename,proto3" json:"rename,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the fields removed by the dotted paths, e.g. user.password
+	Remove []string `protobuf:"bytes,2,rep,name=remove,proto3" json:"remove,omitempty"`
+	// the JSON template of the body, the strings of the paths are replaced by
+	// the values of the body, the fields of the missing ones are omitted:
+	//
+	//   $ (the body), $.name (the field), $.items[0] (the element), $.items[*].id
+	//   (the fields of all elements), $$ at the beginning for the literal $
+	//
+	// e.g. {"code": 0, "data": "$"} wraps the body in the envelope, and "$.data"
+	// unwraps it.
+	Template string `protobuf:"bytes,3,opt,name=template,proto3" json:"template,omitempty"`
+}
+
+func (x *BodyTransform) Reset() {
+	*x = BodyTransform{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BodyTransform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BodyTransform) ProtoMessage() {}
+
+func (x *BodyTransform) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BodyTransform.ProtoReflect.Descriptor instead.
+func (*BodyTransform) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BodyTransform) GetRename() map[string]string {
+	if x != nil {
+		return x.Rename
+	}
+	return nil
+}
+
+func (x *BodyTransform) GetRemove() []string {
+	if x != nil {
+		return x.Remove
+	}
+	return nil
+}
+
+func (x *BodyTransform) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+var File_gateway_middleware_jsontransform_v1_jsontransform_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDesc = []byte{
+	0x0a, 0x37, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x6a, 0x73, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f,
+	0x72, 0x6d, 0x2f, 0x76, 0x31, 0x2f, 0x6a, 0x73, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x6f, 0x72, 0x6d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x23, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x6a, 0x73,
+	0x6f, 0x6e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x2e, 0x76, 0x31, 0x22, 0xd3,
+	0x01, 0x0a, 0x0d, 0x4a, 0x53, 0x4f, 0x4e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d,
+	0x12, 0x4c, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x32, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64,
+	0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x6a, 0x73, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x66, 0x6f, 0x72, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x66, 0x6f, 0x72, 0x6d, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x4e,
+	0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x32, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c,
+	0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x6a, 0x73, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x6f, 0x72, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x66, 0x6f, 0x72, 0x6d, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24,
+	0x0a, 0x0e, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x42, 0x6f, 0x64, 0x79, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x22, 0xd6, 0x01, 0x0a, 0x0d, 0x42, 0x6f, 0x64, 0x79, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x56, 0x0a, 0x06, 0x72, 0x65, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x3e, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x6a, 0x73, 0x6f, 0x6e,
+	0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f, 0x64,
+	0x79, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x72, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x1a, 0x39, 0x0a, 0x0b, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x46, 0x5a,
+	0x44, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b,
+	0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70,
+	0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x6a, 0x73, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f,
+	0x72, 0x6d, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescOnce sync.Once
+	file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescData = file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDesc
+)
+
+func file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescData)
+	})
+	return file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDescData
+}
+
+var file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_gateway_middleware_jsontransform_v1_jsontransform_proto_goTypes = []interface{}{
+	(*JSONTransform)(nil), // 0: gateway.middleware.jsontransform.v1.JSONTransform
+	(*BodyTransform)(nil), // 1: gateway.middleware.jsontransform.v1.BodyTransform
+	nil,                   // 2: gateway.middleware.jsontransform.v1.BodyTransform.RenameEntry
+}
+var file_gateway_middleware_jsontransform_v1_jsontransform_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.jsontransform.v1.JSONTransform.request:type_name -> gateway.middleware.jsontransform.v1.BodyTransform
+	1, // 1: gateway.middleware.jsontransform.v1.JSONTransform.response:type_name -> gateway.middleware.jsontransform.v1.BodyTransform
+	2, // 2: gateway.middleware.jsontransform.v1.BodyTransform.rename:type_name -> gateway.middleware.jsontransform.v1.BodyTransform.RenameEntry
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_jsontransform_v1_jsontransform_proto_init() }
+func file_gateway_middleware_jsontransform_v1_jsontransform_proto_init() {
+	if File_gateway_middleware_jsontransform_v1_jsontransform_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JSONTransform); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BodyTransform); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_jsontransform_v1_jsontransform_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_jsontransform_v1_jsontransform_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_jsontransform_v1_jsontransform_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_jsontransform_v1_jsontransform_proto = out.File
+	file_gateway_middleware_jsontransform_v1_jsontransform_proto_rawDesc = nil
+	file_gateway_middleware_jsontransform_v1_jsontransform_proto_goTypes = nil
+	file_gateway_middleware_jsontransform_v1_jsontransform_proto_depIdxs = nil
+}
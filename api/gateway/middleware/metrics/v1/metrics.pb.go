@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/metrics/v1/metrics.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Metrics middleware config.
+type Metrics struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the buckets of the request durations in seconds, default is 5ms to 10s.
+	DurationBuckets []float64 `protobuf:"fixed64,1,rep,packed,name=duration_buckets,json=durationBuckets,proto3" json:"duration_buckets,omitempty"`
+	// the buckets of the request and response sizes in bytes, default is 64B to 16MB by the factor of 4.
+	SizeBuckets []float64 `protobuf:"fixed64,2,rep,packed,name=size_buckets,json=sizeBuckets,proto3" json:"size_buckets,omitempty"`
+	// the max distinct values of the route and the cluster labels, the values
+	// beyond are labeled as other, default is 1000.
+	MaxLabelValues int32 `protobuf:"varint,3,opt,name=max_label_values,json=maxLabelValues,proto3" json:"max_label_values,omitempty"`
+}
+
+func (x *Metrics) Reset() {
+	*x = Metrics{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_metrics_v1_metrics_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Metrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metrics) ProtoMessage() {}
+
+func (x *Metrics) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_metrics_v1_metrics_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metrics.ProtoReflect.Descriptor instead.
+func (*Metrics) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_metrics_v1_metrics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Metrics) GetDurationBuckets() []float64 {
+	if x != nil {
+		return x.DurationBuckets
+	}
+	return nil
+}
+
+func (x *Metrics) GetSizeBuckets() []float64 {
+	if x != nil {
+		return x.SizeBuckets
+	}
+	return nil
+}
+
+func (x *Metrics) GetMaxLabelValues() int32 {
+	if x != nil {
+		return x.MaxLabelValues
+	}
+	return 0
+}
+
+var File_gateway_middleware_metrics_v1_metrics_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_metrics_v1_metrics_proto_rawDesc = []byte{
+	0x0a, 0x2b, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x76, 0x31, 0x2f,
+	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1d, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72,
+	0x65, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x81, 0x01, 0x0a,
+	0x07, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x01, 0x52, 0x0f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x75, 0x63, 0x6b,
+	0x65, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x69, 0x7a, 0x65, 0x5f, 0x62, 0x75, 0x63, 0x6b,
+	0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52, 0x0b, 0x73, 0x69, 0x7a, 0x65, 0x42,
+	0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x6d, 0x61, 0x78, 0x5f, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0e, 0x6d, 0x61, 0x78, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73,
+	0x42, 0x40, 0x5a, 0x3e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67,
+	0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64,
+	0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f,
+	0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_metrics_v1_metrics_proto_rawDescOnce sync.Once
+	file_gateway_middleware_metrics_v1_metrics_proto_rawDescData = file_gateway_middleware_metrics_v1_metrics_proto_rawDesc
+)
+
+func file_gateway_middleware_metrics_v1_metrics_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_metrics_v1_metrics_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_metrics_v1_metrics_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_metrics_v1_metrics_proto_rawDescData)
+	})
+	return file_gateway_middleware_metrics_v1_metrics_proto_rawDescData
+}
+
+var file_gateway_middleware_metrics_v1_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_metrics_v1_metrics_proto_goTypes = []interface{}{
+	(*Metrics)(nil), // 0: gateway.middleware.metrics.v1.Metrics
+}
+var file_gateway_middleware_metrics_v1_metrics_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_metrics_v1_metrics_proto_init() }
+func file_gateway_middleware_metrics_v1_metrics_proto_init() {
+	if File_gateway_middleware_metrics_v1_metrics_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_metrics_v1_metrics_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Metrics); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_metrics_v1_metrics_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_metrics_v1_metrics_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_metrics_v1_metrics_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_metrics_v1_metrics_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_metrics_v1_metrics_proto = out.File
+	file_gateway_middleware_metrics_v1_metrics_proto_rawDesc = nil
+	file_gateway_middleware_metrics_v1_metrics_proto_goTypes = nil
+	file_gateway_middleware_metrics_v1_metrics_proto_depIdxs = nil
+}
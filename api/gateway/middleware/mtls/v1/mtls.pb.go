@@ -0,0 +1,210 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/mtls/v1/mtls.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MTLS middleware config, the client certificates are verified by the client
+// auth of the TLS listeners, the requests without the certificates or not
+// allowed are rejected with 403. The request is tagged with the consumer of
+// the same name as the subject common name if any.
+type MTLS struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the allowed subject common names, any is allowed if empty
+	CommonNames []string `protobuf:"bytes,1,rep,name=common_names,json=commonNames,proto3" json:"common_names,omitempty"`
+	// the allowed DNS, email or URI SANs, any is allowed if empty
+	Sans []string `protobuf:"bytes,2,rep,name=sans,proto3" json:"sans,omitempty"`
+	// the allowed SHA-256 fingerprints of the certificates in hex, any is
+	// allowed if empty
+	Fingerprints []string `protobuf:"bytes,3,rep,name=fingerprints,proto3" json:"fingerprints,omitempty"`
+	// the headers of the certificate attributes to the upstream, not set if
+	// empty, e.g. X-Client-Subject
+	SubjectHeader string `protobuf:"bytes,4,opt,name=subject_header,json=subjectHeader,proto3" json:"subject_header,omitempty"`
+	// the SANs are joined by the comma
+	SanHeader         string `protobuf:"bytes,5,opt,name=san_header,json=sanHeader,proto3" json:"san_header,omitempty"`
+	FingerprintHeader string `protobuf:"bytes,6,opt,name=fingerprint_header,json=fingerprintHeader,proto3" json:"fingerprint_header,omitempty"`
+}
+
+func (x *MTLS) Reset() {
+	*x = MTLS{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_mtls_v1_mtls_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MTLS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MTLS) ProtoMessage() {}
+
+func (x *MTLS) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_mtls_v1_mtls_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MTLS.ProtoReflect.Descriptor instead.
+func (*MTLS) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_mtls_v1_mtls_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MTLS) GetCommonNames() []string {
+	if x != nil {
+		return x.CommonNames
+	}
+	return nil
+}
+
+func (x *MTLS) GetSans() []string {
+	if x != nil {
+		return x.Sans
+	}
+	return nil
+}
+
+func (x *MTLS) GetFingerprints() []string {
+	if x != nil {
+		return x.Fingerprints
+	}
+	return nil
+}
+
+func (x *MTLS) GetSubjectHeader() string {
+	if x != nil {
+		return x.SubjectHeader
+	}
+	return ""
+}
+
+func (x *MTLS) GetSanHeader() string {
+	if x != nil {
+		return x.SanHeader
+	}
+	return ""
+}
+
+func (x *MTLS) GetFingerprintHeader() string {
+	if x != nil {
+		return x.FingerprintHeader
+	}
+	return ""
+}
+
+var File_gateway_middleware_mtls_v1_mtls_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_mtls_v1_mtls_proto_rawDesc = []byte{
+	0x0a, 0x25, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x6d, 0x74, 0x6c, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x6d, 0x74, 0x6c,
+	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1a, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x6d, 0x74, 0x6c, 0x73,
+	0x2e, 0x76, 0x31, 0x22, 0xd6, 0x01, 0x0a, 0x04, 0x4d, 0x54, 0x4c, 0x53, 0x12, 0x21, 0x0a, 0x0c,
+	0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x73, 0x61, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x73,
+	0x61, 0x6e, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69,
+	0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x66, 0x69, 0x6e, 0x67, 0x65,
+	0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x75, 0x62, 0x6a, 0x65,
+	0x63, 0x74, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0d, 0x73, 0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x61, 0x6e, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x73, 0x61, 0x6e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x2d, 0x0a,
+	0x12, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x5f, 0x68, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x66, 0x69, 0x6e, 0x67, 0x65,
+	0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x42, 0x3d, 0x5a, 0x3b,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72,
+	0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77,
+	0x61, 0x72, 0x65, 0x2f, 0x6d, 0x74, 0x6c, 0x73, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_mtls_v1_mtls_proto_rawDescOnce sync.Once
+	file_gateway_middleware_mtls_v1_mtls_proto_rawDescData = file_gateway_middleware_mtls_v1_mtls_proto_rawDesc
+)
+
+func file_gateway_middleware_mtls_v1_mtls_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_mtls_v1_mtls_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_mtls_v1_mtls_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_mtls_v1_mtls_proto_rawDescData)
+	})
+	return file_gateway_middleware_mtls_v1_mtls_proto_rawDescData
+}
+
+var file_gateway_middleware_mtls_v1_mtls_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_mtls_v1_mtls_proto_goTypes = []interface{}{
+	(*MTLS)(nil), // 0: gateway.middleware.mtls.v1.MTLS
+}
+var file_gateway_middleware_mtls_v1_mtls_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_mtls_v1_mtls_proto_init() }
+func file_gateway_middleware_mtls_v1_mtls_proto_init() {
+	if File_gateway_middleware_mtls_v1_mtls_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_mtls_v1_mtls_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MTLS); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_mtls_v1_mtls_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_mtls_v1_mtls_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_mtls_v1_mtls_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_mtls_v1_mtls_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_mtls_v1_mtls_proto = out.File
+	file_gateway_middleware_mtls_v1_mtls_proto_rawDesc = nil
+	file_gateway_middleware_mtls_v1_mtls_proto_goTypes = nil
+	file_gateway_middleware_mtls_v1_mtls_proto_depIdxs = nil
+}
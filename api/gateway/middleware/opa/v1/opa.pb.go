@@ -0,0 +1,214 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/opa/v1/opa.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// OPA middleware config, the requests are authorized by the policy decisions
+// of the Open Policy Agent server, the Rego policies are hot-reloaded by the
+// bundles of the server. The input of the decision is the attributes of the
+// request:
+//
+//	{"method": "GET", "host": "api.example.com", "path": "/orders/1",
+//	 "query": {"page": ["1"]}, "headers": {"x-user": "alice"},
+//	 "route": "/orders/{id}", "path_vars": {"id": "1"},
+//	 "consumer": "alice", "source_ip": "10.0.0.1", "body": "..."}
+//
+// The result of the decision is a boolean or an object of
+// {"allow": true, "headers": {"X-User-Id": "1"}, "status": 401}, the headers
+// are set to the upstream request of the allowed one, and the denied request
+// is responded with the status, default is 403.
+type OPA struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the data API of the decision, e.g. http://127.0.0.1:8181/v1/data/gateway/authz
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// default is 1s
+	Timeout *durationpb.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// allows the requests if failed to query the decision, the requests are
+	// denied with 403 by default
+	FailureOpen bool `protobuf:"varint,3,opt,name=failure_open,json=failureOpen,proto3" json:"failure_open,omitempty"`
+	// the request headers of the input, all the headers are input if empty
+	AllowedHeaders []string `protobuf:"bytes,4,rep,name=allowed_headers,json=allowedHeaders,proto3" json:"allowed_headers,omitempty"`
+	// inputs the body up to the bytes, the body is not input if 0
+	MaxRequestBytes uint32 `protobuf:"varint,5,opt,name=max_request_bytes,json=maxRequestBytes,proto3" json:"max_request_bytes,omitempty"`
+}
+
+func (x *OPA) Reset() {
+	*x = OPA{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_opa_v1_opa_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OPA) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OPA) ProtoMessage() {}
+
+func (x *OPA) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_opa_v1_opa_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OPA.ProtoReflect.Descriptor instead.
+func (*OPA) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_opa_v1_opa_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OPA) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *OPA) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *OPA) GetFailureOpen() bool {
+	if x != nil {
+		return x.FailureOpen
+	}
+	return false
+}
+
+func (x *OPA) GetAllowedHeaders() []string {
+	if x != nil {
+		return x.AllowedHeaders
+	}
+	return nil
+}
+
+func (x *OPA) GetMaxRequestBytes() uint32 {
+	if x != nil {
+		return x.MaxRequestBytes
+	}
+	return 0
+}
+
+var File_gateway_middleware_opa_v1_opa_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_opa_v1_opa_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x6f, 0x70, 0x61, 0x2f, 0x76, 0x31, 0x2f, 0x6f, 0x70, 0x61, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x6f, 0x70, 0x61, 0x2e, 0x76, 0x31,
+	0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0xc4, 0x01, 0x0a, 0x03, 0x4f, 0x50, 0x41, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x33, 0x0a, 0x07, 0x74, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12,
+	0x21, 0x0a, 0x0c, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x5f, 0x6f, 0x70, 0x65, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x4f, 0x70,
+	0x65, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x68, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x65, 0x64, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6d,
+	0x61, 0x78, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x6d, 0x61, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x42, 0x79, 0x74, 0x65, 0x73, 0x42, 0x3c, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f,
+	0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x74, 0x65,
+	0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x6f,
+	0x70, 0x61, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_opa_v1_opa_proto_rawDescOnce sync.Once
+	file_gateway_middleware_opa_v1_opa_proto_rawDescData = file_gateway_middleware_opa_v1_opa_proto_rawDesc
+)
+
+func file_gateway_middleware_opa_v1_opa_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_opa_v1_opa_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_opa_v1_opa_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_opa_v1_opa_proto_rawDescData)
+	})
+	return file_gateway_middleware_opa_v1_opa_proto_rawDescData
+}
+
+var file_gateway_middleware_opa_v1_opa_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_opa_v1_opa_proto_goTypes = []interface{}{
+	(*OPA)(nil),                 // 0: gateway.middleware.opa.v1.OPA
+	(*durationpb.Duration)(nil), // 1: google.protobuf.Duration
+}
+var file_gateway_middleware_opa_v1_opa_proto_depIdxs = []int32{
+	1, // 0: gateway.middleware.opa.v1.OPA.timeout:type_name -> google.protobuf.Duration
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_opa_v1_opa_proto_init() }
+func file_gateway_middleware_opa_v1_opa_proto_init() {
+	if File_gateway_middleware_opa_v1_opa_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_opa_v1_opa_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OPA); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_opa_v1_opa_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_opa_v1_opa_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_opa_v1_opa_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_opa_v1_opa_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_opa_v1_opa_proto = out.File
+	file_gateway_middleware_opa_v1_opa_proto_rawDesc = nil
+	file_gateway_middleware_opa_v1_opa_proto_goTypes = nil
+	file_gateway_middleware_opa_v1_opa_proto_depIdxs = nil
+}
@@ -0,0 +1,202 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/openapi/v1/openapi.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// OpenAPI middleware config, the path, query and header parameters and the
+// JSON bodies of the requests are validated by the operations of the OpenAPI 3
+// document, the invalid requests are responded with the RFC 7807 problem
+// details in application/problem+json.
+type OpenAPI struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the OpenAPI 3 document in JSON or YAML
+	Spec string `protobuf:"bytes,1,opt,name=spec,proto3" json:"spec,omitempty"`
+	// the file of the document, it's used if the spec is empty
+	SpecFile string `protobuf:"bytes,2,opt,name=spec_file,json=specFile,proto3" json:"spec_file,omitempty"`
+	// the prefix trimmed from the request paths to match the paths of the
+	// document, e.g. /api/v1, default is the path of the first server url
+	PathPrefix string `protobuf:"bytes,3,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"`
+	// forwards the requests of the operations not in the document instead of
+	// responding them with 404 or 405
+	AllowUnknownOperations bool `protobuf:"varint,4,opt,name=allow_unknown_operations,json=allowUnknownOperations,proto3" json:"allow_unknown_operations,omitempty"`
+	// the max bytes of the request body validated, the larger ones are
+	// responded with 413, default is 1MB
+	MaxBodyBytes int64 `protobuf:"varint,5,opt,name=max_body_bytes,json=maxBodyBytes,proto3" json:"max_body_bytes,omitempty"`
+}
+
+func (x *OpenAPI) Reset() {
+	*x = OpenAPI{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_openapi_v1_openapi_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OpenAPI) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenAPI) ProtoMessage() {}
+
+func (x *OpenAPI) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_openapi_v1_openapi_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenAPI.ProtoReflect.Descriptor instead.
+func (*OpenAPI) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_openapi_v1_openapi_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OpenAPI) GetSpec() string {
+	if x != nil {
+		return x.Spec
+	}
+	return ""
+}
+
+func (x *OpenAPI) GetSpecFile() string {
+	if x != nil {
+		return x.SpecFile
+	}
+	return ""
+}
+
+func (x *OpenAPI) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *OpenAPI) GetAllowUnknownOperations() bool {
+	if x != nil {
+		return x.AllowUnknownOperations
+	}
+	return false
+}
+
+func (x *OpenAPI) GetMaxBodyBytes() int64 {
+	if x != nil {
+		return x.MaxBodyBytes
+	}
+	return 0
+}
+
+var File_gateway_middleware_openapi_v1_openapi_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_openapi_v1_openapi_proto_rawDesc = []byte{
+	0x0a, 0x2b, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f,
+	0x6f, 0x70, 0x65, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1d, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72,
+	0x65, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x22, 0xbb, 0x01, 0x0a,
+	0x07, 0x4f, 0x70, 0x65, 0x6e, 0x41, 0x50, 0x49, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x70, 0x65, 0x63,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x70, 0x65, 0x63, 0x12, 0x1b, 0x0a, 0x09,
+	0x73, 0x70, 0x65, 0x63, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x73, 0x70, 0x65, 0x63, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x61, 0x74,
+	0x68, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x70, 0x61, 0x74, 0x68, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x38, 0x0a, 0x18, 0x61, 0x6c,
+	0x6c, 0x6f, 0x77, 0x5f, 0x75, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x5f, 0x6f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x16, 0x61, 0x6c,
+	0x6c, 0x6f, 0x77, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x6f, 0x64, 0x79,
+	0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6d, 0x61,
+	0x78, 0x42, 0x6f, 0x64, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73, 0x42, 0x40, 0x5a, 0x3e, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74,
+	0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72,
+	0x65, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_openapi_v1_openapi_proto_rawDescOnce sync.Once
+	file_gateway_middleware_openapi_v1_openapi_proto_rawDescData = file_gateway_middleware_openapi_v1_openapi_proto_rawDesc
+)
+
+func file_gateway_middleware_openapi_v1_openapi_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_openapi_v1_openapi_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_openapi_v1_openapi_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_openapi_v1_openapi_proto_rawDescData)
+	})
+	return file_gateway_middleware_openapi_v1_openapi_proto_rawDescData
+}
+
+var file_gateway_middleware_openapi_v1_openapi_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_gateway_middleware_openapi_v1_openapi_proto_goTypes = []interface{}{
+	(*OpenAPI)(nil), // 0: gateway.middleware.openapi.v1.OpenAPI
+}
+var file_gateway_middleware_openapi_v1_openapi_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_openapi_v1_openapi_proto_init() }
+func file_gateway_middleware_openapi_v1_openapi_proto_init() {
+	if File_gateway_middleware_openapi_v1_openapi_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_openapi_v1_openapi_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OpenAPI); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_openapi_v1_openapi_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_openapi_v1_openapi_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_openapi_v1_openapi_proto_depIdxs,
+		MessageInfos:      file_gateway_middleware_openapi_v1_openapi_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_openapi_v1_openapi_proto = out.File
+	file_gateway_middleware_openapi_v1_openapi_proto_rawDesc = nil
+	file_gateway_middleware_openapi_v1_openapi_proto_goTypes = nil
+	file_gateway_middleware_openapi_v1_openapi_proto_depIdxs = nil
+}
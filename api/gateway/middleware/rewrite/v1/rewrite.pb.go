@@ -84,6 +84,9 @@ func (x *HeadersPolicy) GetRemove() []string {
 	return nil
 }
 
+// The path rewrite, the prefix replacement and the header values can refer to
+// the variables of the path template, e.g. path_rewrite: /orders/{order_id}
+// of the endpoint path /users/{id}/orders/{order_id}.
 type Rewrite struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -94,6 +97,13 @@ type Rewrite struct {
 	ResponseHeadersRewrite *HeadersPolicy `protobuf:"bytes,3,opt,name=response_headers_rewrite,json=responseHeadersRewrite,proto3" json:"response_headers_rewrite,omitempty"`
 	StripPrefix            *string        `protobuf:"bytes,4,opt,name=strip_prefix,json=stripPrefix,proto3,oneof" json:"strip_prefix,omitempty"`
 	HostRewrite            *string        `protobuf:"bytes,5,opt,name=host_rewrite,json=hostRewrite,proto3,oneof" json:"host_rewrite,omitempty"`
+	// applied after the strip prefix
+	PrefixReplace *PrefixReplace `protobuf:"bytes,6,opt,name=prefix_replace,json=prefixReplace,proto3" json:"prefix_replace,omitempty"`
+	// applied after the prefix replace
+	RegexRewrite *RegexRewrite `protobuf:"bytes,7,opt,name=regex_rewrite,json=regexRewrite,proto3" json:"regex_rewrite,omitempty"`
+	// sends the address of the picked upstream node as the host instead of
+	// the original host, the host_rewrite takes precedence
+	AutoHostRewrite bool `protobuf:"varint,8,opt,name=auto_host_rewrite,json=autoHostRewrite,proto3" json:"auto_host_rewrite,omitempty"`
 }
 
 func (x *Rewrite) Reset() {
@@ -163,6 +173,140 @@ func (x *Rewrite) GetHostRewrite() string {
 	return ""
 }
 
+func (x *Rewrite) GetPrefixReplace() *PrefixReplace {
+	if x != nil {
+		return x.PrefixReplace
+	}
+	return nil
+}
+
+func (x *Rewrite) GetRegexRewrite() *RegexRewrite {
+	if x != nil {
+		return x.RegexRewrite
+	}
+	return nil
+}
+
+func (x *Rewrite) GetAutoHostRewrite() bool {
+	if x != nil {
+		return x.AutoHostRewrite
+	}
+	return false
+}
+
+// Replaces the path prefix, e.g. /api/v1/ to /v1/.
+type PrefixReplace struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prefix      string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Replacement string `protobuf:"bytes,2,opt,name=replacement,proto3" json:"replacement,omitempty"`
+}
+
+func (x *PrefixReplace) Reset() {
+	*x = PrefixReplace{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PrefixReplace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrefixReplace) ProtoMessage() {}
+
+func (x *PrefixReplace) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrefixReplace.ProtoReflect.Descriptor instead.
+func (*PrefixReplace) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_rewrite_v1_rewrite_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PrefixReplace) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *PrefixReplace) GetReplacement() string {
+	if x != nil {
+		return x.Replacement
+	}
+	return ""
+}
+
+// Rewrites the path matched by the pattern, the substitution can refer
+// to the capture groups, e.g. pattern: ^/users/(\w+)/posts$, substitution: /posts/${1}.
+type RegexRewrite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pattern      string `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Substitution string `protobuf:"bytes,2,opt,name=substitution,proto3" json:"substitution,omitempty"`
+}
+
+func (x *RegexRewrite) Reset() {
+	*x = RegexRewrite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegexRewrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegexRewrite) ProtoMessage() {}
+
+func (x *RegexRewrite) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegexRewrite.ProtoReflect.Descriptor instead.
+func (*RegexRewrite) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_rewrite_v1_rewrite_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RegexRewrite) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *RegexRewrite) GetSubstitution() string {
+	if x != nil {
+		return x.Substitution
+	}
+	return ""
+}
+
 var File_gateway_middleware_rewrite_v1_rewrite_proto protoreflect.FileDescriptor
 
 var file_gateway_middleware_rewrite_v1_rewrite_proto_rawDesc = []byte{
@@ -189,7 +333,7 @@ var file_gateway_middleware_rewrite_v1_rewrite_proto_rawDesc = []byte{
 	0x1a, 0x36, 0x0a, 0x08, 0x41, 0x64, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
 	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
 	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x82, 0x03, 0x0a, 0x07, 0x52, 0x65, 0x77,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xd5, 0x04, 0x0a, 0x07, 0x52, 0x65, 0x77,
 	0x72, 0x69, 0x74, 0x65, 0x12, 0x26, 0x0a, 0x0c, 0x70, 0x61, 0x74, 0x68, 0x5f, 0x72, 0x65, 0x77,
 	0x72, 0x69, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x70, 0x61,
 	0x74, 0x68, 0x52, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x64, 0x0a, 0x17,
@@ -210,15 +354,38 @@ var file_gateway_middleware_rewrite_v1_rewrite_proto_rawDesc = []byte{
 	0x48, 0x01, 0x52, 0x0b, 0x73, 0x74, 0x72, 0x69, 0x70, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x88,
 	0x01, 0x01, 0x12, 0x26, 0x0a, 0x0c, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x77, 0x72, 0x69,
 	0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x0b, 0x68, 0x6f, 0x73, 0x74,
-	0x52, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x70,
-	0x61, 0x74, 0x68, 0x5f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x42, 0x0f, 0x0a, 0x0d, 0x5f,
-	0x73, 0x74, 0x72, 0x69, 0x70, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x42, 0x0f, 0x0a, 0x0d,
-	0x5f, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x42, 0x40, 0x5a,
-	0x3e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b,
-	0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70,
-	0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
-	0x77, 0x61, 0x72, 0x65, 0x2f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x2f, 0x76, 0x31, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x53, 0x0a, 0x0e, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x5f, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64,
+	0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65,
+	0x52, 0x0d, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x12,
+	0x50, 0x0a, 0x0d, 0x72, 0x65, 0x67, 0x65, 0x78, 0x5f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x72, 0x65, 0x77, 0x72,
+	0x69, 0x74, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x67, 0x65, 0x78, 0x52, 0x65, 0x77, 0x72,
+	0x69, 0x74, 0x65, 0x52, 0x0c, 0x72, 0x65, 0x67, 0x65, 0x78, 0x52, 0x65, 0x77, 0x72, 0x69, 0x74,
+	0x65, 0x12, 0x2a, 0x0a, 0x11, 0x61, 0x75, 0x74, 0x6f, 0x5f, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x72,
+	0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x61, 0x75,
+	0x74, 0x6f, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x42, 0x0f, 0x0a,
+	0x0d, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x5f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x42, 0x0f,
+	0x0a, 0x0d, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x70, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x42,
+	0x0f, 0x0a, 0x0d, 0x5f, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65,
+	0x22, 0x49, 0x0a, 0x0d, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65, 0x70,
+	0x6c, 0x61, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x4c, 0x0a, 0x0c, 0x52,
+	0x65, 0x67, 0x65, 0x78, 0x52, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70,
+	0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61,
+	0x74, 0x74, 0x65, 0x72, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x75, 0x62, 0x73, 0x74, 0x69, 0x74,
+	0x75, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x75, 0x62,
+	0x73, 0x74, 0x69, 0x74, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x40, 0x5a, 0x3e, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74, 0x6f,
+	0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65,
+	0x2f, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -233,23 +400,27 @@ func file_gateway_middleware_rewrite_v1_rewrite_proto_rawDescGZIP() []byte {
 	return file_gateway_middleware_rewrite_v1_rewrite_proto_rawDescData
 }
 
-var file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_gateway_middleware_rewrite_v1_rewrite_proto_goTypes = []interface{}{
 	(*HeadersPolicy)(nil), // 0: gateway.middleware.rewrite.v1.HeadersPolicy
 	(*Rewrite)(nil),       // 1: gateway.middleware.rewrite.v1.Rewrite
-	nil,                   // 2: gateway.middleware.rewrite.v1.HeadersPolicy.SetEntry
-	nil,                   // 3: gateway.middleware.rewrite.v1.HeadersPolicy.AddEntry
+	(*PrefixReplace)(nil), // 2: gateway.middleware.rewrite.v1.PrefixReplace
+	(*RegexRewrite)(nil),  // 3: gateway.middleware.rewrite.v1.RegexRewrite
+	nil,                   // 4: gateway.middleware.rewrite.v1.HeadersPolicy.SetEntry
+	nil,                   // 5: gateway.middleware.rewrite.v1.HeadersPolicy.AddEntry
 }
 var file_gateway_middleware_rewrite_v1_rewrite_proto_depIdxs = []int32{
-	2, // 0: gateway.middleware.rewrite.v1.HeadersPolicy.set:type_name -> gateway.middleware.rewrite.v1.HeadersPolicy.SetEntry
-	3, // 1: gateway.middleware.rewrite.v1.HeadersPolicy.add:type_name -> gateway.middleware.rewrite.v1.HeadersPolicy.AddEntry
+	4, // 0: gateway.middleware.rewrite.v1.HeadersPolicy.set:type_name -> gateway.middleware.rewrite.v1.HeadersPolicy.SetEntry
+	5, // 1: gateway.middleware.rewrite.v1.HeadersPolicy.add:type_name -> gateway.middleware.rewrite.v1.HeadersPolicy.AddEntry
 	0, // 2: gateway.middleware.rewrite.v1.Rewrite.request_headers_rewrite:type_name -> gateway.middleware.rewrite.v1.HeadersPolicy
 	0, // 3: gateway.middleware.rewrite.v1.Rewrite.response_headers_rewrite:type_name -> gateway.middleware.rewrite.v1.HeadersPolicy
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	2, // 4: gateway.middleware.rewrite.v1.Rewrite.prefix_replace:type_name -> gateway.middleware.rewrite.v1.PrefixReplace
+	3, // 5: gateway.middleware.rewrite.v1.Rewrite.regex_rewrite:type_name -> gateway.middleware.rewrite.v1.RegexRewrite
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_gateway_middleware_rewrite_v1_rewrite_proto_init() }
@@ -282,6 +453,30 @@ func file_gateway_middleware_rewrite_v1_rewrite_proto_init() {
 				return nil
 			}
 		}
+		file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PrefixReplace); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegexRewrite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_gateway_middleware_rewrite_v1_rewrite_proto_msgTypes[1].OneofWrappers = []interface{}{}
 	type x struct{}
@@ -290,7 +485,7 @@ func file_gateway_middleware_rewrite_v1_rewrite_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_gateway_middleware_rewrite_v1_rewrite_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
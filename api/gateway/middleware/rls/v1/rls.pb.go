@@ -0,0 +1,435 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/rls/v1/rls.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Entry_Source int32
+
+const (
+	// the static value, e.g. generic_key: api
+	Entry_VALUE Entry_Source = 0
+	// the value of the header of the name
+	Entry_HEADER    Entry_Source = 1
+	Entry_CLIENT_IP Entry_Source = 2
+	// the name of the consumer
+	Entry_CONSUMER Entry_Source = 3
+	// the endpoint path of the request
+	Entry_ROUTE  Entry_Source = 4
+	Entry_METHOD Entry_Source = 5
+	// the path variable of the name, e.g. the id of /users/{id}
+	Entry_PATH_VAR Entry_Source = 6
+)
+
+// Enum value maps for Entry_Source.
+var (
+	Entry_Source_name = map[int32]string{
+		0: "VALUE",
+		1: "HEADER",
+		2: "CLIENT_IP",
+		3: "CONSUMER",
+		4: "ROUTE",
+		5: "METHOD",
+		6: "PATH_VAR",
+	}
+	Entry_Source_value = map[string]int32{
+		"VALUE":     0,
+		"HEADER":    1,
+		"CLIENT_IP": 2,
+		"CONSUMER":  3,
+		"ROUTE":     4,
+		"METHOD":    5,
+		"PATH_VAR":  6,
+	}
+)
+
+func (x Entry_Source) Enum() *Entry_Source {
+	p := new(Entry_Source)
+	*p = x
+	return p
+}
+
+func (x Entry_Source) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Entry_Source) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_rls_v1_rls_proto_enumTypes[0].Descriptor()
+}
+
+func (Entry_Source) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_rls_v1_rls_proto_enumTypes[0]
+}
+
+func (x Entry_Source) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Entry_Source.Descriptor instead.
+func (Entry_Source) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_rls_v1_rls_proto_rawDescGZIP(), []int{2, 0}
+}
+
+// RLS middleware config, the requests are limited by the external rate limit
+// service of envoy.service.ratelimit.v3.RateLimitService, e.g. lyft/ratelimit.
+// The requests over the limit are responded with 429 and the Retry-After
+// header, the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+// of the closest limit are set to the responses. The retries of a request are
+// limited once.
+type RLS struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// e.g. ratelimit.example.com:8081
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// the descriptors missing any of the values are not sent, the request
+	// isn't limited if no descriptors are sent
+	Descriptors []*Descriptor `protobuf:"bytes,3,rep,name=descriptors,proto3" json:"descriptors,omitempty"`
+	// default is 100ms
+	Timeout *durationpb.Duration `protobuf:"bytes,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// denies the requests with 500 if failed to call the service, the
+	// requests are allowed by default
+	FailureModeDeny bool `protobuf:"varint,5,opt,name=failure_mode_deny,json=failureModeDeny,proto3" json:"failure_mode_deny,omitempty"`
+}
+
+func (x *RLS) Reset() {
+	*x = RLS{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_rls_v1_rls_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RLS) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RLS) ProtoMessage() {}
+
+func (x *RLS) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_rls_v1_rls_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RLS.ProtoReflect.Descriptor instead.
+func (*RLS) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_rls_v1_rls_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RLS) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *RLS) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *RLS) GetDescriptors() []*Descriptor {
+	if x != nil {
+		return x.Descriptors
+	}
+	return nil
+}
+
+func (x *RLS) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *RLS) GetFailureModeDeny() bool {
+	if x != nil {
+		return x.FailureModeDeny
+	}
+	return false
+}
+
+type Descriptor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entries []*Entry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *Descriptor) Reset() {
+	*x = Descriptor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_rls_v1_rls_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Descriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Descriptor) ProtoMessage() {}
+
+func (x *Descriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_rls_v1_rls_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Descriptor.ProtoReflect.Descriptor instead.
+func (*Descriptor) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_rls_v1_rls_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Descriptor) GetEntries() []*Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type Entry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key    string       `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Source Entry_Source `protobuf:"varint,2,opt,name=source,proto3,enum=gateway.middleware.rls.v1.Entry_Source" json:"source,omitempty"`
+	// the static value, the header or the path variable name
+	Value string `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_rls_v1_rls_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_rls_v1_rls_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_rls_v1_rls_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Entry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Entry) GetSource() Entry_Source {
+	if x != nil {
+		return x.Source
+	}
+	return Entry_VALUE
+}
+
+func (x *Entry) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+var File_gateway_middleware_rls_v1_rls_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_rls_v1_rls_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x72, 0x6c, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x72, 0x6c, 0x73, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x72, 0x6c, 0x73, 0x2e, 0x76, 0x31,
+	0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0xdf, 0x01, 0x0a, 0x03, 0x52, 0x4c, 0x53, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x47, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x25, 0x2e,
+	0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61,
+	0x72, 0x65, 0x2e, 0x72, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x6f, 0x72, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72,
+	0x73, 0x12, 0x33, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x74,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72,
+	0x65, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x5f, 0x64, 0x65, 0x6e, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0f, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x44, 0x65,
+	0x6e, 0x79, 0x22, 0x48, 0x0a, 0x0a, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72,
+	0x12, 0x3a, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64,
+	0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x72, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0xd3, 0x01, 0x0a,
+	0x05, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x3f, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x27, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x72, 0x6c,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x61, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x56, 0x41, 0x4c,
+	0x55, 0x45, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x45, 0x41, 0x44, 0x45, 0x52, 0x10, 0x01,
+	0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4c, 0x49, 0x45, 0x4e, 0x54, 0x5f, 0x49, 0x50, 0x10, 0x02, 0x12,
+	0x0c, 0x0a, 0x08, 0x43, 0x4f, 0x4e, 0x53, 0x55, 0x4d, 0x45, 0x52, 0x10, 0x03, 0x12, 0x09, 0x0a,
+	0x05, 0x52, 0x4f, 0x55, 0x54, 0x45, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06, 0x4d, 0x45, 0x54, 0x48,
+	0x4f, 0x44, 0x10, 0x05, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x41, 0x54, 0x48, 0x5f, 0x56, 0x41, 0x52,
+	0x10, 0x06, 0x42, 0x3c, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72, 0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2f, 0x72, 0x6c, 0x73, 0x2f, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_rls_v1_rls_proto_rawDescOnce sync.Once
+	file_gateway_middleware_rls_v1_rls_proto_rawDescData = file_gateway_middleware_rls_v1_rls_proto_rawDesc
+)
+
+func file_gateway_middleware_rls_v1_rls_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_rls_v1_rls_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_rls_v1_rls_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_rls_v1_rls_proto_rawDescData)
+	})
+	return file_gateway_middleware_rls_v1_rls_proto_rawDescData
+}
+
+var file_gateway_middleware_rls_v1_rls_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gateway_middleware_rls_v1_rls_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_gateway_middleware_rls_v1_rls_proto_goTypes = []interface{}{
+	(Entry_Source)(0),           // 0: gateway.middleware.rls.v1.Entry.Source
+	(*RLS)(nil),                 // 1: gateway.middleware.rls.v1.RLS
+	(*Descriptor)(nil),          // 2: gateway.middleware.rls.v1.Descriptor
+	(*Entry)(nil),               // 3: gateway.middleware.rls.v1.Entry
+	(*durationpb.Duration)(nil), // 4: google.protobuf.Duration
+}
+var file_gateway_middleware_rls_v1_rls_proto_depIdxs = []int32{
+	2, // 0: gateway.middleware.rls.v1.RLS.descriptors:type_name -> gateway.middleware.rls.v1.Descriptor
+	4, // 1: gateway.middleware.rls.v1.RLS.timeout:type_name -> google.protobuf.Duration
+	3, // 2: gateway.middleware.rls.v1.Descriptor.entries:type_name -> gateway.middleware.rls.v1.Entry
+	0, // 3: gateway.middleware.rls.v1.Entry.source:type_name -> gateway.middleware.rls.v1.Entry.Source
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_rls_v1_rls_proto_init() }
+func file_gateway_middleware_rls_v1_rls_proto_init() {
+	if File_gateway_middleware_rls_v1_rls_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_rls_v1_rls_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RLS); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_rls_v1_rls_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Descriptor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_rls_v1_rls_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Entry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_rls_v1_rls_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_rls_v1_rls_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_rls_v1_rls_proto_depIdxs,
+		EnumInfos:         file_gateway_middleware_rls_v1_rls_proto_enumTypes,
+		MessageInfos:      file_gateway_middleware_rls_v1_rls_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_rls_v1_rls_proto = out.File
+	file_gateway_middleware_rls_v1_rls_proto_rawDesc = nil
+	file_gateway_middleware_rls_v1_rls_proto_goTypes = nil
+	file_gateway_middleware_rls_v1_rls_proto_depIdxs = nil
+}
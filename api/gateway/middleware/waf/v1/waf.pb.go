@@ -0,0 +1,569 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.8
+// source: gateway/middleware/waf/v1/waf.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Variable int32
+
+const (
+	// the names and the values of the query and the form body arguments
+	Variable_ARGS       Variable = 0
+	Variable_ARGS_NAMES Variable = 1
+	// the path and the raw query
+	Variable_REQUEST_URI           Variable = 2
+	Variable_REQUEST_HEADERS       Variable = 3
+	Variable_REQUEST_HEADERS_NAMES Variable = 4
+	Variable_REQUEST_COOKIES       Variable = 5
+	Variable_REQUEST_BODY          Variable = 6
+	Variable_REQUEST_METHOD        Variable = 7
+)
+
+// Enum value maps for Variable.
+var (
+	Variable_name = map[int32]string{
+		0: "ARGS",
+		1: "ARGS_NAMES",
+		2: "REQUEST_URI",
+		3: "REQUEST_HEADERS",
+		4: "REQUEST_HEADERS_NAMES",
+		5: "REQUEST_COOKIES",
+		6: "REQUEST_BODY",
+		7: "REQUEST_METHOD",
+	}
+	Variable_value = map[string]int32{
+		"ARGS":                  0,
+		"ARGS_NAMES":            1,
+		"REQUEST_URI":           2,
+		"REQUEST_HEADERS":       3,
+		"REQUEST_HEADERS_NAMES": 4,
+		"REQUEST_COOKIES":       5,
+		"REQUEST_BODY":          6,
+		"REQUEST_METHOD":        7,
+	}
+)
+
+func (x Variable) Enum() *Variable {
+	p := new(Variable)
+	*p = x
+	return p
+}
+
+func (x Variable) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Variable) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_waf_v1_waf_proto_enumTypes[0].Descriptor()
+}
+
+func (Variable) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_waf_v1_waf_proto_enumTypes[0]
+}
+
+func (x Variable) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Variable.Descriptor instead.
+func (Variable) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_waf_v1_waf_proto_rawDescGZIP(), []int{0}
+}
+
+type Severity int32
+
+const (
+	// default is critical, the scores are 5, 4, 3 and 2
+	Severity_CRITICAL Severity = 0
+	Severity_ERROR    Severity = 1
+	Severity_WARNING  Severity = 2
+	Severity_NOTICE   Severity = 3
+)
+
+// Enum value maps for Severity.
+var (
+	Severity_name = map[int32]string{
+		0: "CRITICAL",
+		1: "ERROR",
+		2: "WARNING",
+		3: "NOTICE",
+	}
+	Severity_value = map[string]int32{
+		"CRITICAL": 0,
+		"ERROR":    1,
+		"WARNING":  2,
+		"NOTICE":   3,
+	}
+)
+
+func (x Severity) Enum() *Severity {
+	p := new(Severity)
+	*p = x
+	return p
+}
+
+func (x Severity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Severity) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_waf_v1_waf_proto_enumTypes[1].Descriptor()
+}
+
+func (Severity) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_waf_v1_waf_proto_enumTypes[1]
+}
+
+func (x Severity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Severity.Descriptor instead.
+func (Severity) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_waf_v1_waf_proto_rawDescGZIP(), []int{1}
+}
+
+type Transform int32
+
+const (
+	Transform_LOWERCASE           Transform = 0
+	Transform_URL_DECODE          Transform = 1
+	Transform_HTML_ENTITY_DECODE  Transform = 2
+	Transform_COMPRESS_WHITESPACE Transform = 3
+	Transform_REMOVE_NULLS        Transform = 4
+)
+
+// Enum value maps for Transform.
+var (
+	Transform_name = map[int32]string{
+		0: "LOWERCASE",
+		1: "URL_DECODE",
+		2: "HTML_ENTITY_DECODE",
+		3: "COMPRESS_WHITESPACE",
+		4: "REMOVE_NULLS",
+	}
+	Transform_value = map[string]int32{
+		"LOWERCASE":           0,
+		"URL_DECODE":          1,
+		"HTML_ENTITY_DECODE":  2,
+		"COMPRESS_WHITESPACE": 3,
+		"REMOVE_NULLS":        4,
+	}
+)
+
+func (x Transform) Enum() *Transform {
+	p := new(Transform)
+	*p = x
+	return p
+}
+
+func (x Transform) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Transform) Descriptor() protoreflect.EnumDescriptor {
+	return file_gateway_middleware_waf_v1_waf_proto_enumTypes[2].Descriptor()
+}
+
+func (Transform) Type() protoreflect.EnumType {
+	return &file_gateway_middleware_waf_v1_waf_proto_enumTypes[2]
+}
+
+func (x Transform) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Transform.Descriptor instead.
+func (Transform) EnumDescriptor() ([]byte, []int) {
+	return file_gateway_middleware_waf_v1_waf_proto_rawDescGZIP(), []int{2}
+}
+
+// WAF middleware config, a basic filter of the regular expression rules, the
+// rules are matched against the request, the scores of the matched rules are
+// summed up and the request is blocked once the anomaly threshold is reached.
+// The blocked requests are logged as the audit events of the source waf.
+//
+// The built-in rules borrow the ids and the paranoia levels of the OWASP Core
+// Rule Set with the simplified patterns, they're not the CRS. The CRS and the
+// SecLang rule files can't be loaded, it requires a WAF engine, e.g. coraza.
+//
+// The rules of the endpoint can be switched by the runtime flags:
+//
+//	waf.{path}.disabled: true
+//	waf.{path}.detection_only: true
+type WAF struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the built-in rule sets, sqli, xss, lfi, rce and protocol, default is all
+	RuleSets []string `protobuf:"bytes,1,rep,name=rule_sets,json=ruleSets,proto3" json:"rule_sets,omitempty"`
+	// the rule sets of the paranoia levels up to the value are enabled, more
+	// rules of the higher levels with more false positives, default is 1
+	ParanoiaLevel int32 `protobuf:"varint,2,opt,name=paranoia_level,json=paranoiaLevel,proto3" json:"paranoia_level,omitempty"`
+	// the custom rules
+	Rules []*Rule `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`
+	// the ids of the rules disabled, e.g. 942100
+	DisabledRules []string `protobuf:"bytes,4,rep,name=disabled_rules,json=disabledRules,proto3" json:"disabled_rules,omitempty"`
+	// the total score of the matched rules blocking the request, default is 5
+	AnomalyThreshold int32 `protobuf:"varint,5,opt,name=anomaly_threshold,json=anomalyThreshold,proto3" json:"anomaly_threshold,omitempty"`
+	// the requests are logged but not blocked once the threshold is reached
+	DetectionOnly bool `protobuf:"varint,6,opt,name=detection_only,json=detectionOnly,proto3" json:"detection_only,omitempty"`
+	// default is 403
+	DenyStatus int32 `protobuf:"varint,7,opt,name=deny_status,json=denyStatus,proto3" json:"deny_status,omitempty"`
+	// the max bytes of the request body inspected, the body isn't inspected if
+	// negative, default is 128KB
+	MaxBodyBytes int64 `protobuf:"varint,8,opt,name=max_body_bytes,json=maxBodyBytes,proto3" json:"max_body_bytes,omitempty"`
+}
+
+func (x *WAF) Reset() {
+	*x = WAF{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_waf_v1_waf_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WAF) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WAF) ProtoMessage() {}
+
+func (x *WAF) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_waf_v1_waf_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WAF.ProtoReflect.Descriptor instead.
+func (*WAF) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_waf_v1_waf_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WAF) GetRuleSets() []string {
+	if x != nil {
+		return x.RuleSets
+	}
+	return nil
+}
+
+func (x *WAF) GetParanoiaLevel() int32 {
+	if x != nil {
+		return x.ParanoiaLevel
+	}
+	return 0
+}
+
+func (x *WAF) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *WAF) GetDisabledRules() []string {
+	if x != nil {
+		return x.DisabledRules
+	}
+	return nil
+}
+
+func (x *WAF) GetAnomalyThreshold() int32 {
+	if x != nil {
+		return x.AnomalyThreshold
+	}
+	return 0
+}
+
+func (x *WAF) GetDetectionOnly() bool {
+	if x != nil {
+		return x.DetectionOnly
+	}
+	return false
+}
+
+func (x *WAF) GetDenyStatus() int32 {
+	if x != nil {
+		return x.DenyStatus
+	}
+	return 0
+}
+
+func (x *WAF) GetMaxBodyBytes() int64 {
+	if x != nil {
+		return x.MaxBodyBytes
+	}
+	return 0
+}
+
+type Rule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// the variables matched, default is ARGS
+	Variables []Variable `protobuf:"varint,3,rep,packed,name=variables,proto3,enum=gateway.middleware.waf.v1.Variable" json:"variables,omitempty"`
+	// the regular expression of RE2 syntax
+	Pattern  string   `protobuf:"bytes,4,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Severity Severity `protobuf:"varint,5,opt,name=severity,proto3,enum=gateway.middleware.waf.v1.Severity" json:"severity,omitempty"`
+	// the transforms applied to the values in order before matching
+	Transforms []Transform `protobuf:"varint,6,rep,packed,name=transforms,proto3,enum=gateway.middleware.waf.v1.Transform" json:"transforms,omitempty"`
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gateway_middleware_waf_v1_waf_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_gateway_middleware_waf_v1_waf_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_gateway_middleware_waf_v1_waf_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Rule) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Rule) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Rule) GetVariables() []Variable {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+func (x *Rule) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *Rule) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_CRITICAL
+}
+
+func (x *Rule) GetTransforms() []Transform {
+	if x != nil {
+		return x.Transforms
+	}
+	return nil
+}
+
+var File_gateway_middleware_waf_v1_waf_proto protoreflect.FileDescriptor
+
+var file_gateway_middleware_waf_v1_waf_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65,
+	0x77, 0x61, 0x72, 0x65, 0x2f, 0x77, 0x61, 0x66, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x61, 0x66, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d,
+	0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x77, 0x61, 0x66, 0x2e, 0x76, 0x31,
+	0x22, 0xc2, 0x02, 0x0a, 0x03, 0x57, 0x41, 0x46, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x75, 0x6c, 0x65,
+	0x5f, 0x73, 0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x72, 0x75, 0x6c,
+	0x65, 0x53, 0x65, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x61, 0x72, 0x61, 0x6e, 0x6f, 0x69,
+	0x61, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x70,
+	0x61, 0x72, 0x61, 0x6e, 0x6f, 0x69, 0x61, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x35, 0x0a, 0x05,
+	0x72, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65,
+	0x2e, 0x77, 0x61, 0x66, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72, 0x75,
+	0x6c, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x5f,
+	0x72, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x64, 0x69, 0x73,
+	0x61, 0x62, 0x6c, 0x65, 0x64, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x61, 0x6e,
+	0x6f, 0x6d, 0x61, 0x6c, 0x79, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x61, 0x6e, 0x6f, 0x6d, 0x61, 0x6c, 0x79, 0x54, 0x68,
+	0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x65, 0x74, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0d, 0x64, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x1f,
+	0x0a, 0x0b, 0x64, 0x65, 0x6e, 0x79, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x65, 0x6e, 0x79, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x24, 0x0a, 0x0e, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x42, 0x6f, 0x64, 0x79,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x94, 0x02, 0x0a, 0x04, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x41, 0x0a, 0x09, 0x76, 0x61, 0x72, 0x69,
+	0x61, 0x62, 0x6c, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x67, 0x61,
+	0x74, 0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65,
+	0x2e, 0x77, 0x61, 0x66, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x72, 0x69, 0x61, 0x62, 0x6c, 0x65,
+	0x52, 0x09, 0x76, 0x61, 0x72, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x70,
+	0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61,
+	0x74, 0x74, 0x65, 0x72, 0x6e, 0x12, 0x3f, 0x0a, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74,
+	0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61,
+	0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e, 0x77, 0x61, 0x66,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x08, 0x73, 0x65,
+	0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x44, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x6f, 0x72, 0x6d, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x24, 0x2e, 0x67, 0x61, 0x74,
+	0x65, 0x77, 0x61, 0x79, 0x2e, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77, 0x61, 0x72, 0x65, 0x2e,
+	0x77, 0x61, 0x66, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d,
+	0x52, 0x0a, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x2a, 0xa0, 0x01, 0x0a,
+	0x08, 0x56, 0x61, 0x72, 0x69, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x41, 0x52, 0x47,
+	0x53, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x41, 0x52, 0x47, 0x53, 0x5f, 0x4e, 0x41, 0x4d, 0x45,
+	0x53, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x5f, 0x55,
+	0x52, 0x49, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x5f,
+	0x48, 0x45, 0x41, 0x44, 0x45, 0x52, 0x53, 0x10, 0x03, 0x12, 0x19, 0x0a, 0x15, 0x52, 0x45, 0x51,
+	0x55, 0x45, 0x53, 0x54, 0x5f, 0x48, 0x45, 0x41, 0x44, 0x45, 0x52, 0x53, 0x5f, 0x4e, 0x41, 0x4d,
+	0x45, 0x53, 0x10, 0x04, 0x12, 0x13, 0x0a, 0x0f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x5f,
+	0x43, 0x4f, 0x4f, 0x4b, 0x49, 0x45, 0x53, 0x10, 0x05, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x45, 0x51,
+	0x55, 0x45, 0x53, 0x54, 0x5f, 0x42, 0x4f, 0x44, 0x59, 0x10, 0x06, 0x12, 0x12, 0x0a, 0x0e, 0x52,
+	0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x5f, 0x4d, 0x45, 0x54, 0x48, 0x4f, 0x44, 0x10, 0x07, 0x2a,
+	0x3c, 0x0a, 0x08, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x0c, 0x0a, 0x08, 0x43,
+	0x52, 0x49, 0x54, 0x49, 0x43, 0x41, 0x4c, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52,
+	0x4f, 0x52, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x57, 0x41, 0x52, 0x4e, 0x49, 0x4e, 0x47, 0x10,
+	0x02, 0x12, 0x0a, 0x0a, 0x06, 0x4e, 0x4f, 0x54, 0x49, 0x43, 0x45, 0x10, 0x03, 0x2a, 0x6d, 0x0a,
+	0x09, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x0d, 0x0a, 0x09, 0x4c, 0x4f,
+	0x57, 0x45, 0x52, 0x43, 0x41, 0x53, 0x45, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x55, 0x52, 0x4c,
+	0x5f, 0x44, 0x45, 0x43, 0x4f, 0x44, 0x45, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x48, 0x54, 0x4d,
+	0x4c, 0x5f, 0x45, 0x4e, 0x54, 0x49, 0x54, 0x59, 0x5f, 0x44, 0x45, 0x43, 0x4f, 0x44, 0x45, 0x10,
+	0x02, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x4f, 0x4d, 0x50, 0x52, 0x45, 0x53, 0x53, 0x5f, 0x57, 0x48,
+	0x49, 0x54, 0x45, 0x53, 0x50, 0x41, 0x43, 0x45, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x45,
+	0x4d, 0x4f, 0x56, 0x45, 0x5f, 0x4e, 0x55, 0x4c, 0x4c, 0x53, 0x10, 0x04, 0x42, 0x3c, 0x5a, 0x3a,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x2d, 0x6b, 0x72,
+	0x61, 0x74, 0x6f, 0x73, 0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x2f, 0x6d, 0x69, 0x64, 0x64, 0x6c, 0x65, 0x77,
+	0x61, 0x72, 0x65, 0x2f, 0x77, 0x61, 0x66, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_gateway_middleware_waf_v1_waf_proto_rawDescOnce sync.Once
+	file_gateway_middleware_waf_v1_waf_proto_rawDescData = file_gateway_middleware_waf_v1_waf_proto_rawDesc
+)
+
+func file_gateway_middleware_waf_v1_waf_proto_rawDescGZIP() []byte {
+	file_gateway_middleware_waf_v1_waf_proto_rawDescOnce.Do(func() {
+		file_gateway_middleware_waf_v1_waf_proto_rawDescData = protoimpl.X.CompressGZIP(file_gateway_middleware_waf_v1_waf_proto_rawDescData)
+	})
+	return file_gateway_middleware_waf_v1_waf_proto_rawDescData
+}
+
+var file_gateway_middleware_waf_v1_waf_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_gateway_middleware_waf_v1_waf_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_gateway_middleware_waf_v1_waf_proto_goTypes = []interface{}{
+	(Variable)(0),  // 0: gateway.middleware.waf.v1.Variable
+	(Severity)(0),  // 1: gateway.middleware.waf.v1.Severity
+	(Transform)(0), // 2: gateway.middleware.waf.v1.Transform
+	(*WAF)(nil),    // 3: gateway.middleware.waf.v1.WAF
+	(*Rule)(nil),   // 4: gateway.middleware.waf.v1.Rule
+}
+var file_gateway_middleware_waf_v1_waf_proto_depIdxs = []int32{
+	4, // 0: gateway.middleware.waf.v1.WAF.rules:type_name -> gateway.middleware.waf.v1.Rule
+	0, // 1: gateway.middleware.waf.v1.Rule.variables:type_name -> gateway.middleware.waf.v1.Variable
+	1, // 2: gateway.middleware.waf.v1.Rule.severity:type_name -> gateway.middleware.waf.v1.Severity
+	2, // 3: gateway.middleware.waf.v1.Rule.transforms:type_name -> gateway.middleware.waf.v1.Transform
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_gateway_middleware_waf_v1_waf_proto_init() }
+func file_gateway_middleware_waf_v1_waf_proto_init() {
+	if File_gateway_middleware_waf_v1_waf_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gateway_middleware_waf_v1_waf_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WAF); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gateway_middleware_waf_v1_waf_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Rule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gateway_middleware_waf_v1_waf_proto_rawDesc,
+			NumEnums:      3,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gateway_middleware_waf_v1_waf_proto_goTypes,
+		DependencyIndexes: file_gateway_middleware_waf_v1_waf_proto_depIdxs,
+		EnumInfos:         file_gateway_middleware_waf_v1_waf_proto_enumTypes,
+		MessageInfos:      file_gateway_middleware_waf_v1_waf_proto_msgTypes,
+	}.Build()
+	File_gateway_middleware_waf_v1_waf_proto = out.File
+	file_gateway_middleware_waf_v1_waf_proto_rawDesc = nil
+	file_gateway_middleware_waf_v1_waf_proto_goTypes = nil
+	file_gateway_middleware_waf_v1_waf_proto_depIdxs = nil
+}
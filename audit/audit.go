@@ -0,0 +1,261 @@
+// Package audit records the audit events of the authentication failures, the
+// policy denials and the admin API mutations. The events are chained by the
+// sequence numbers and the hashes of the previous events, so the events
+// removed, reordered or modified are detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The types of the audit events.
+const (
+	TypeAuthenticationFailure = "authentication_failure"
+	TypePolicyDenial          = "policy_denial"
+	TypeAdminMutation         = "admin_mutation"
+)
+
+const _bufferSize = 10000
+
+var _metricDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "go",
+	Subsystem: "gateway",
+	Name:      "audit_events_dropped_total",
+	Help:      "The total number of the audit events dropped by the sinks",
+}, []string{"type"})
+
+func init() {
+	prometheus.MustRegister(_metricDroppedTotal)
+}
+
+// Event is the audit event, the hash is the hex SHA-256 of the event in JSON
+// without the hash, which contains the hash of the previous event.
+type Event struct {
+	Seq        uint64    `json:"seq"`
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Source     string    `json:"source"`
+	Actor      string    `json:"actor,omitempty"`
+	ClientIP   string    `json:"client_ip,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	Query      string    `json:"query,omitempty"`
+	Route      string    `json:"route,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	PrevHash   string    `json:"prev_hash,omitempty"`
+	Hash       string    `json:"hash"`
+}
+
+func (e *Event) sum() (string, error) {
+	c := *e
+	c.Hash = ""
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Sink writes the audit events in the order of the sequence numbers.
+type Sink interface {
+	Write(event *Event) error
+}
+
+type sinkWriter struct {
+	sink   Sink
+	events chan *Event
+}
+
+func (w *sinkWriter) run() {
+	for event := range w.events {
+		if err := w.sink.Write(event); err != nil {
+			_metricDroppedTotal.WithLabelValues(event.Type).Inc()
+			log.Errorf("Failed to write the audit event of seq %d: %+v", event.Seq, err)
+		}
+	}
+}
+
+type auditor struct {
+	lock    sync.Mutex
+	seq     uint64
+	hash    string
+	writers []*sinkWriter
+}
+
+var _auditor = &auditor{}
+
+// SetSinks sets the sinks of the audit events, the events are written by the
+// sinks in the background and dropped once the buffers are full. The chain
+// continues from the last event of the file sinks, or starts from the
+// sequence number 1 otherwise.
+func SetSinks(sinks ...Sink) {
+	a := _auditor
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, w := range a.writers {
+		close(w.events)
+	}
+	a.writers = nil
+	for _, sink := range sinks {
+		if f, ok := sink.(*fileSink); ok && f.last != nil && f.last.Seq > a.seq {
+			a.seq, a.hash = f.last.Seq, f.last.Hash
+		}
+		w := &sinkWriter{sink: sink, events: make(chan *Event, _bufferSize)}
+		go w.run()
+		a.writers = append(a.writers, w)
+	}
+}
+
+func (a *auditor) emit(event *Event) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if len(a.writers) == 0 {
+		return
+	}
+	a.seq++
+	event.Seq = a.seq
+	event.PrevHash = a.hash
+	hash, err := event.sum()
+	if err != nil {
+		log.Errorf("Failed to hash the audit event of seq %d: %+v", event.Seq, err)
+		return
+	}
+	event.Hash = hash
+	a.hash = hash
+	for _, w := range a.writers {
+		select {
+		case w.events <- event:
+		default:
+			_metricDroppedTotal.WithLabelValues(event.Type).Inc()
+		}
+	}
+}
+
+func newEvent(req *http.Request, typ, source, actor string, statusCode int, reason string) *Event {
+	event := &Event{
+		Time:       time.Now(),
+		Type:       typ,
+		Source:     source,
+		Actor:      actor,
+		Host:       req.Host,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: statusCode,
+		Reason:     reason,
+	}
+	if ip := clientip.FromRequest(req); ip != nil {
+		event.ClientIP = ip.String()
+	}
+	if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+		event.Route = reqOpt.Endpoint.GetPath()
+		event.RequestID = reqOpt.RequestID
+		if event.Actor == "" && reqOpt.Consumer != nil {
+			event.Actor = reqOpt.Consumer.Name
+		}
+	}
+	return event
+}
+
+// AuthenticationFailed records the request failed to be authenticated by the
+// source middleware.
+func AuthenticationFailed(req *http.Request, source string, statusCode int, reason string) {
+	_auditor.emit(newEvent(req, TypeAuthenticationFailure, source, "", statusCode, reason))
+}
+
+// PolicyDenied records the request denied by the policy of the source
+// middleware, the actor is the consumer of the request if it's empty.
+func PolicyDenied(req *http.Request, source, actor string, statusCode int, reason string) {
+	_auditor.emit(newEvent(req, TypePolicyDenial, source, actor, statusCode, reason))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Handler records the mutations of the admin API served by the handler, the
+// requests except GET, HEAD and OPTIONS are recorded with the actor of the
+// X-Gateway-Actor header, or the remote address if it's empty.
+func Handler(source string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			handler.ServeHTTP(rw, req)
+			return
+		}
+		w := &statusRecorder{ResponseWriter: rw}
+		handler.ServeHTTP(w, req)
+		if w.statusCode == 0 {
+			w.statusCode = http.StatusOK
+		}
+		actor := req.Header.Get("X-Gateway-Actor")
+		if actor == "" {
+			actor = req.RemoteAddr
+		}
+		event := newEvent(req, TypeAdminMutation, source, actor, w.statusCode, "")
+		event.Query = req.URL.RawQuery
+		_auditor.emit(event)
+	})
+}
+
+// Verify verifies the chain of the audit events in JSON lines, the error is
+// returned on the first event missed or tampered.
+func Verify(r io.Reader) error {
+	var prev *Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		event := &Event{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			return fmt.Errorf("invalid audit event of line %d: %v", line, err)
+		}
+		hash, err := event.sum()
+		if err != nil {
+			return err
+		}
+		if hash != event.Hash {
+			return fmt.Errorf("audit event of seq %d is tampered", event.Seq)
+		}
+		// the chain starts over from the sequence number 1 on the restarts
+		// without the file sinks.
+		if prev != nil && event.Seq != 1 && (event.Seq != prev.Seq+1 || event.PrevHash != prev.Hash) {
+			return fmt.Errorf("audit events are missed between seq %d and %d", prev.Seq, event.Seq)
+		}
+		prev = event
+	}
+	return scanner.Err()
+}
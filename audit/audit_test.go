@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+)
+
+// waitLines waits for the n lines written to the file by the sink.
+func waitLines(t *testing.T, path string, n int) []byte {
+	var data []byte
+	for i := 0; i < 100; i++ {
+		var err error
+		if data, err = ioutil.ReadFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Count(data, []byte("\n")) >= n {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return data
+}
+
+func TestEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetSinks(sink)
+	defer SetSinks()
+	// the chain starts from the sequence number 1 of the empty file.
+	_auditor.lock.Lock()
+	_auditor.seq, _auditor.hash = 0, ""
+	_auditor.lock.Unlock()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	reqOpts := middleware.NewRequestOptions(&config.Endpoint{Path: "/users/*"})
+	reqOpts.RequestID = "req-1"
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpts))
+	AuthenticationFailed(req, "jwt", http.StatusUnauthorized, "token is required")
+	PolicyDenied(req, "rbac", "alice", http.StatusForbidden, "no policy allows the request")
+
+	admin := Handler("admin", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	// the reads of the admin API are not recorded.
+	admin.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/routes", nil))
+	mutation := httptest.NewRequest(http.MethodDelete, "/admin/cache?zone=default", nil)
+	mutation.Header.Set("X-Gateway-Actor", "bob")
+	admin.ServeHTTP(httptest.NewRecorder(), mutation)
+
+	data := waitLines(t, path, 3)
+	for _, want := range []string{
+		`"seq":1,`, `"type":"authentication_failure","source":"jwt"`, `"route":"/users/*","request_id":"req-1","status_code":401`,
+		`"seq":2,`, `"source":"rbac","actor":"alice"`,
+		`"seq":3,`, `"type":"admin_mutation","source":"admin","actor":"bob"`, `"query":"zone=default"`, `"status_code":202`,
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("want %s in the events but got %s", want, data)
+		}
+	}
+	if err := Verify(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chain continues from the last event of the file.
+	if sink, err = NewFileSink(path); err != nil {
+		t.Fatal(err)
+	}
+	SetSinks(sink)
+	AuthenticationFailed(req, "apikey", http.StatusUnauthorized, "api key is invalid")
+	data = waitLines(t, path, 4)
+	if !bytes.Contains(data, []byte(`"seq":4,`)) {
+		t.Fatalf("want the chain continued but got %s", data)
+	}
+	if err := Verify(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var lines []string
+	var prev string
+	for seq := uint64(1); seq <= 3; seq++ {
+		e := &Event{Seq: seq, Time: time.Unix(int64(seq), 0).UTC(), Type: TypePolicyDenial, Source: "ipacl", PrevHash: prev}
+		hash, err := e.sum()
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Hash, prev = hash, hash
+		lines = append(lines, string(mustJSON(t, e)))
+	}
+	if err := Verify(strings.NewReader(strings.Join(lines, "\n"))); err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(lines[1], "ipacl", "rbac", 1)
+	if err := Verify(strings.NewReader(strings.Join([]string{lines[0], tampered, lines[2]}, "\n"))); err == nil {
+		t.Fatal("want the error of the tampered event")
+	}
+	if err := Verify(strings.NewReader(strings.Join([]string{lines[0], lines[2]}, "\n"))); err == nil {
+		t.Fatal("want the error of the missed event")
+	}
+}
+
+func mustJSON(t *testing.T, e *Event) []byte {
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
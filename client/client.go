@@ -11,12 +11,14 @@ import (
 type client struct {
 	applier  *nodeApplier
 	selector selector.Selector
+	hedge    *hedgePolicy
 }
 
 func newClient(applier *nodeApplier, selector selector.Selector) *client {
 	return &client{
 		applier:  applier,
 		selector: selector,
+		hedge:    newHedgePolicy(applier.endpoint.Hedge),
 	}
 }
 
@@ -26,6 +28,9 @@ func (c *client) Close() error {
 }
 
 func (c *client) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	if c.hedge != nil && isIdempotent(req.Method) {
+		return c.hedgedRoundTrip(req)
+	}
 	ctx := req.Context()
 	reqOpt, _ := middleware.FromRequestContext(ctx)
 	filter, _ := middleware.SelectorFiltersFromContext(ctx)
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/flags"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+	"google.golang.org/protobuf/proto"
+)
+
+type weightedCluster struct {
+	name   string
+	weight int64
+	client *client
+}
+
+// clusterClient splits the requests across the clusters of the endpoint by
+// weight, the retries of a request are sent to the same cluster.
+type clusterClient struct {
+	clusters   []*weightedCluster
+	total      int64
+	hash       *config.ClusterHash
+	activeFlag string
+	// the clusters of the retry priorities tried in order by the attempts.
+	priorities []*weightedCluster
+}
+
+// _failoverKey is the metadata key of the cluster tried by the last attempt.
+const _failoverKey = "failover.cluster"
+
+// activeClusterFlag returns the runtime flag key of the active cluster, all
+// the requests are sent to it instead of picking by weight, e.g. the blue/green
+// switch by `route./helloworld/*.cluster: green`.
+func activeClusterFlag(e *config.Endpoint) string {
+	return "route." + e.Path + ".cluster"
+}
+
+func newClusterClient(endpoint *config.Endpoint, build func(*config.Endpoint) (*client, error)) (*clusterClient, error) {
+	c := &clusterClient{hash: endpoint.ClusterHash, activeFlag: activeClusterFlag(endpoint)}
+	for _, cluster := range endpoint.Clusters {
+		e := proto.Clone(endpoint).(*config.Endpoint)
+		e.Clusters = nil
+		e.Backends = cluster.Backends
+		client, err := build(e)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.clusters = append(c.clusters, &weightedCluster{
+			name:   cluster.Name,
+			weight: int64(cluster.Weight),
+			client: client,
+		})
+		c.total += int64(cluster.Weight)
+	}
+	for _, name := range endpoint.Retry.GetPriorities() {
+		if cluster, ok := c.get(name); ok {
+			c.priorities = append(c.priorities, cluster)
+		}
+	}
+	return c, nil
+}
+
+func (c *clusterClient) get(name string) (*weightedCluster, bool) {
+	for _, cluster := range c.clusters {
+		if cluster.name == name {
+			return cluster, true
+		}
+	}
+	return nil, false
+}
+
+// hashKey returns the key of the request to pick the cluster by hash.
+func (c *clusterClient) hashKey(req *http.Request) (string, bool) {
+	switch key := c.hash.GetKey().(type) {
+	case *config.ClusterHash_Header:
+		v := req.Header.Get(key.Header)
+		return v, v != ""
+	case *config.ClusterHash_Cookie:
+		cookie, err := req.Cookie(key.Cookie)
+		if err != nil || cookie.Value == "" {
+			return "", false
+		}
+		return cookie.Value, true
+	case *config.ClusterHash_ClientIp:
+		if ip := clientip.FromRequest(req); key.ClientIp && ip != nil {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+func (c *clusterClient) pick(req *http.Request) *weightedCluster {
+	if c.total <= 0 {
+		return c.clusters[0]
+	}
+	var n int64
+	if key, ok := c.hashKey(req); ok {
+		// the same key is in the same bucket as long as the weights are not changed.
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(c.hash.Seed))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(key))
+		n = int64(h.Sum64() % uint64(c.total))
+	} else {
+		n = rand.Int63n(c.total)
+	}
+	for _, cluster := range c.clusters {
+		if n < cluster.weight {
+			return cluster
+		}
+		n -= cluster.weight
+	}
+	return c.clusters[len(c.clusters)-1]
+}
+
+func (c *clusterClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqOpt, _ := middleware.FromRequestContext(req.Context())
+	if len(c.priorities) > 0 {
+		return c.failover(req, reqOpt)
+	}
+	cluster := c.choose(req, reqOpt.Cluster)
+	reqOpt.Cluster = cluster.name
+	return cluster.client.RoundTrip(req)
+}
+
+// failover sends the request to the next cluster of the priorities after the
+// one of the last attempt, the clusters without available nodes are skipped
+// in the same attempt. The last cluster keeps serving the rest attempts.
+func (c *clusterClient) failover(req *http.Request, reqOpt *middleware.RequestOptions) (*http.Response, error) {
+	next := 0
+	if last, ok := reqOpt.Metadata[_failoverKey]; ok {
+		for i, cluster := range c.priorities {
+			if cluster.name == last {
+				next = i + 1
+			}
+		}
+		if next >= len(c.priorities) {
+			next = len(c.priorities) - 1
+		}
+	}
+	for i := next; ; i++ {
+		cluster := c.priorities[i]
+		reqOpt.Cluster = cluster.name
+		reqOpt.Metadata[_failoverKey] = cluster.name
+		resp, err := cluster.client.RoundTrip(req)
+		if errors.Is(err, selector.ErrNoAvailable) && i+1 < len(c.priorities) {
+			continue
+		}
+		return resp, err
+	}
+}
+
+// choose returns the cluster of the name picked by the previous attempt or
+// the middlewares, otherwise the active cluster of the runtime flag, or the
+// cluster picked by weight.
+func (c *clusterClient) choose(req *http.Request, name string) *weightedCluster {
+	if cluster, ok := c.get(name); ok {
+		return cluster
+	}
+	if active, ok := flags.Get(c.activeFlag); ok {
+		if cluster, ok := c.get(active); ok {
+			return cluster
+		}
+	}
+	return c.pick(req)
+}
+
+func (c *clusterClient) Close() error {
+	for _, cluster := range c.clusters {
+		cluster.client.Close()
+	}
+	return nil
+}
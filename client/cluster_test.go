@@ -0,0 +1,198 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/flags"
+	"github.com/go-kratos/gateway/middleware"
+)
+
+func TestClusterClient(t *testing.T) {
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	stable, canary := newServer("stable"), newServer("canary")
+	defer stable.Close()
+	defer canary.Close()
+	endpoint := &config.Endpoint{
+		Path:     "/api/*",
+		Protocol: config.Protocol_HTTP,
+		Clusters: []*config.Cluster{
+			{Name: "stable", Weight: 90, Backends: []*config.Backend{{Target: strings.TrimPrefix(stable.URL, "http://")}}},
+			{Name: "canary", Weight: 10, Backends: []*config.Backend{{Target: strings.TrimPrefix(canary.URL, "http://")}}},
+			{Name: "disabled", Backends: []*config.Backend{{Target: strings.TrimPrefix(canary.URL, "http://")}}},
+		},
+	}
+	rt, err := NewFactory(nil)(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.(interface{ Close() error }).Close()
+
+	do := func(cluster string) (string, string) {
+		reqOpt := middleware.NewRequestOptions(endpoint)
+		reqOpt.Cluster = cluster
+		req := httptest.NewRequest("GET", "/api/hello", nil)
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body), reqOpt.Cluster
+	}
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		body, cluster := do("")
+		if body != cluster {
+			t.Fatalf("cluster %s got response of %s", cluster, body)
+		}
+		counts[cluster]++
+	}
+	if counts["disabled"] != 0 || counts["canary"] < 50 || counts["canary"] > 150 {
+		t.Fatalf("unexpected split: %v", counts)
+	}
+	// the cluster picked by the previous attempt is kept.
+	for i := 0; i < 10; i++ {
+		if body, cluster := do("canary"); body != "canary" || cluster != "canary" {
+			t.Fatalf("want canary but got: %s %s", body, cluster)
+		}
+	}
+}
+
+func TestClusterFailover(t *testing.T) {
+	newServer := func(name string, code int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(name))
+		}))
+	}
+	primary, secondary := newServer("primary", http.StatusServiceUnavailable), newServer("secondary", http.StatusOK)
+	defer primary.Close()
+	defer secondary.Close()
+	endpoint := &config.Endpoint{
+		Path:     "/api/*",
+		Protocol: config.Protocol_HTTP,
+		Clusters: []*config.Cluster{
+			// no nodes are available without the backends.
+			{Name: "unhealthy", Weight: 100},
+			{Name: "primary", Backends: []*config.Backend{{Target: strings.TrimPrefix(primary.URL, "http://")}}},
+			{Name: "secondary", Backends: []*config.Backend{{Target: strings.TrimPrefix(secondary.URL, "http://")}}},
+		},
+		Retry: &config.Retry{Priorities: []string{"unhealthy", "primary", "secondary"}},
+	}
+	rt, err := NewFactory(nil)(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.(interface{ Close() error }).Close()
+
+	reqOpt := middleware.NewRequestOptions(endpoint)
+	do := func() string {
+		req := httptest.NewRequest("GET", "/api/hello", nil)
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != reqOpt.Cluster {
+			t.Fatalf("cluster %s got response of %s", reqOpt.Cluster, body)
+		}
+		return reqOpt.Cluster
+	}
+	// the cluster without available nodes is skipped in the same attempt.
+	if cluster := do(); cluster != "primary" {
+		t.Fatalf("want primary but got %s", cluster)
+	}
+	// the retries go to the next cluster and stay at the last one.
+	for i := 0; i < 3; i++ {
+		if cluster := do(); cluster != "secondary" {
+			t.Fatalf("want secondary but got %s", cluster)
+		}
+	}
+}
+
+func TestClusterHash(t *testing.T) {
+	c := &clusterClient{
+		clusters: []*weightedCluster{{name: "stable", weight: 90}, {name: "canary", weight: 10}},
+		total:    100,
+		hash:     &config.ClusterHash{Key: &config.ClusterHash_Header{Header: "X-User"}, Seed: "exp-1"},
+	}
+	pick := func(user string) string {
+		req := httptest.NewRequest("GET", "/api/hello", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		return c.pick(req).name
+	}
+	counts := map[string]int{}
+	picked := map[string]string{}
+	for i := 0; i < 1000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		picked[user] = pick(user)
+		counts[picked[user]]++
+	}
+	if counts["canary"] < 50 || counts["canary"] > 150 {
+		t.Fatalf("unexpected split: %v", counts)
+	}
+	for user, cluster := range picked {
+		if got := pick(user); got != cluster {
+			t.Fatalf("%s: want %s but got %s", user, cluster, got)
+		}
+	}
+	// the users are reshuffled by the seed.
+	c.hash.Seed = "exp-2"
+	var moved int
+	for user, cluster := range picked {
+		if pick(user) != cluster {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Fatal("want the users reshuffled by the seed")
+	}
+	// the cluster is picked randomly without the key.
+	counts = map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[pick("")]++
+	}
+	if counts["canary"] == 0 || counts["stable"] == 0 {
+		t.Fatalf("unexpected split without key: %v", counts)
+	}
+}
+
+func TestClusterActiveFlag(t *testing.T) {
+	endpoint := &config.Endpoint{Path: "/blue-green/*"}
+	c := &clusterClient{
+		clusters:   []*weightedCluster{{name: "blue", weight: 100}, {name: "green"}},
+		total:      100,
+		activeFlag: activeClusterFlag(endpoint),
+	}
+	pick := func() string {
+		return c.choose(httptest.NewRequest("GET", "/blue-green/hello", nil), "").name
+	}
+	defer flags.Default().Delete("route./blue-green/*.cluster")
+	for value, want := range map[string]string{"green": "green", "blue": "blue", "unknown": "blue"} {
+		flags.Default().Set("route./blue-green/*.cluster", value)
+		if got := pick(); got != want {
+			t.Errorf("flag %s: want %s but got %s", value, want, got)
+		}
+	}
+}
@@ -37,7 +37,7 @@ func NewFactory(r registry.Discovery, opts ...Option) Factory {
 	for _, opt := range opts {
 		opt(o)
 	}
-	return func(endpoint *config.Endpoint) (http.RoundTripper, error) {
+	build := func(endpoint *config.Endpoint) (*client, error) {
 		picker := o.pickerBuilder.Build()
 		ctx, cancel := context.WithCancel(context.Background())
 		applier := &nodeApplier{
@@ -46,10 +46,17 @@ func NewFactory(r registry.Discovery, opts ...Option) Factory {
 			registry: r,
 		}
 		if err := applier.apply(ctx, picker); err != nil {
+			cancel()
 			return nil, err
 		}
 		return newClient(applier, picker), nil
 	}
+	return func(endpoint *config.Endpoint) (http.RoundTripper, error) {
+		if len(endpoint.Clusters) > 0 {
+			return newClusterClient(endpoint, build)
+		}
+		return build(endpoint)
+	}
 }
 
 type nodeApplier struct {
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+const (
+	_defaultHedgeDelay       = 100 * time.Millisecond
+	_defaultHedgeMaxRequests = 2
+)
+
+type hedgePolicy struct {
+	delay       time.Duration
+	maxRequests int
+}
+
+func newHedgePolicy(in *config.Hedge) *hedgePolicy {
+	if in == nil {
+		return nil
+	}
+	p := &hedgePolicy{delay: _defaultHedgeDelay, maxRequests: _defaultHedgeMaxRequests}
+	if in.Delay != nil && in.Delay.AsDuration() > 0 {
+		p.delay = in.Delay.AsDuration()
+	}
+	if in.MaxRequests > 0 {
+		p.maxRequests = int(in.MaxRequests)
+	}
+	return p
+}
+
+// isIdempotent reports whether the requests of the method are safe to hedge.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+type hedgedResult struct {
+	index   int
+	resp    *http.Response
+	err     error
+	elapsed time.Duration
+}
+
+type hedgedRequest struct {
+	done   selector.DoneFunc
+	cancel context.CancelFunc
+	result *hedgedResult
+}
+
+// cancelBody cancels the request once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.cancel)
+	return err
+}
+
+// hedgedRoundTrip sends the duplicate requests to the other nodes once the
+// responses are not received in the delay, the first response is used and
+// the others are canceled. The nodes of the sent requests are excluded from
+// the selection of the duplicate requests unless all the nodes are sent.
+func (c *client) hedgedRoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	reqOpt, _ := middleware.FromRequestContext(ctx)
+	sent := make(map[string]struct{}, c.hedge.maxRequests)
+	filters := []selector.NodeFilter{func(_ context.Context, nodes []selector.Node) []selector.Node {
+		var unsent []selector.Node
+		for _, n := range nodes {
+			if _, ok := sent[n.Address()]; !ok {
+				unsent = append(unsent, n)
+			}
+		}
+		if len(unsent) == 0 {
+			return nodes
+		}
+		return unsent
+	}}
+	if reqFilters, ok := middleware.SelectorFiltersFromContext(ctx); ok {
+		filters = append(filters, reqFilters...)
+	}
+	results := make(chan *hedgedResult, c.hedge.maxRequests)
+	var requests []*hedgedRequest
+	send := func() error {
+		n, done, err := c.selector.Select(ctx, selector.WithNodeFilter(filters...))
+		if err != nil {
+			return err
+		}
+		addr := n.Address()
+		sent[addr] = struct{}{}
+		reqOpt.Backends = append(reqOpt.Backends, addr)
+		hedgeCtx, cancel := context.WithCancel(ctx)
+		r := req.Clone(hedgeCtx)
+		if req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				cancel()
+				done(ctx, selector.DoneInfo{Err: err})
+				return err
+			}
+		}
+		r.URL.Host = addr
+		r.URL.Scheme = "http"
+		r.RequestURI = ""
+		index := len(requests)
+		requests = append(requests, &hedgedRequest{done: done, cancel: cancel})
+		go func() {
+			startAt := time.Now()
+			resp, err := n.(*node).client.Do(r)
+			results <- &hedgedResult{index: index, resp: resp, err: err, elapsed: time.Since(startAt)}
+		}()
+		return nil
+	}
+	if err := send(); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(c.hedge.delay)
+	defer timer.Stop()
+	var (
+		winner  *hedgedResult
+		lastErr error
+		pending = 1
+	)
+	for winner == nil && pending > 0 {
+		select {
+		case result := <-results:
+			pending--
+			requests[result.index].result = result
+			if result.err == nil {
+				winner = result
+				continue
+			}
+			lastErr = result.err
+			requests[result.index].done(ctx, selector.DoneInfo{Err: result.err})
+		case <-timer.C:
+			if len(requests) < c.hedge.maxRequests && send() == nil {
+				pending++
+				timer.Reset(c.hedge.delay)
+			}
+		}
+	}
+	// the others are canceled and drained in background.
+	for i, r := range requests {
+		if winner != nil && i == winner.index {
+			continue
+		}
+		r.cancel()
+	}
+	if pending > 0 {
+		go func(left int) {
+			for ; left > 0; left-- {
+				result := <-results
+				if result.err == nil {
+					result.resp.Body.Close()
+				}
+				requests[result.index].done(ctx, selector.DoneInfo{Err: context.Canceled})
+			}
+		}(pending)
+	}
+	for _, r := range requests {
+		switch {
+		case r.result == nil:
+			reqOpt.UpstreamStatusCode = append(reqOpt.UpstreamStatusCode, 0)
+			reqOpt.UpstreamResponseTime = append(reqOpt.UpstreamResponseTime, 0)
+		case r.result.err != nil:
+			reqOpt.UpstreamStatusCode = append(reqOpt.UpstreamStatusCode, 0)
+			reqOpt.UpstreamResponseTime = append(reqOpt.UpstreamResponseTime, r.result.elapsed.Seconds())
+		default:
+			reqOpt.UpstreamStatusCode = append(reqOpt.UpstreamStatusCode, r.result.resp.StatusCode)
+			reqOpt.UpstreamResponseTime = append(reqOpt.UpstreamResponseTime, r.result.elapsed.Seconds())
+		}
+	}
+	if winner == nil {
+		return nil, lastErr
+	}
+	w := requests[winner.index]
+	winner.resp.Body = &cancelBody{ReadCloser: winner.resp.Body, cancel: w.cancel}
+	reqOpt.DoneFunc = w.done
+	return winner.resp, nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestHedge(t *testing.T) {
+	canceled := make(chan struct{}, 100)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			return
+		}
+		select {
+		case <-time.After(time.Second):
+			_, _ = w.Write([]byte("slow"))
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+		}
+	}))
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer slow.Close()
+	defer fast.Close()
+	endpoint := &config.Endpoint{
+		Path:     "/api/*",
+		Protocol: config.Protocol_HTTP,
+		Backends: []*config.Backend{
+			{Target: strings.TrimPrefix(slow.URL, "http://")},
+			{Target: strings.TrimPrefix(fast.URL, "http://")},
+		},
+		Hedge: &config.Hedge{Delay: durationpb.New(20 * time.Millisecond)},
+	}
+	rt, err := NewFactory(nil)(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.(interface{ Close() error }).Close()
+
+	do := func(method string, filters ...selector.NodeFilter) (string, *middleware.RequestOptions) {
+		reqOpt := middleware.NewRequestOptions(endpoint)
+		if filters != nil {
+			reqOpt.Filters = filters
+		}
+		req := httptest.NewRequest(method, "/api/hello", nil)
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body), reqOpt
+	}
+	// the sent nodes are excluded without the retry filter of the request
+	// options, even if the slow node is preferred.
+	preferSlow := func(_ context.Context, nodes []selector.Node) []selector.Node {
+		for _, n := range nodes {
+			if n.Address() == endpoint.Backends[0].Target {
+				return []selector.Node{n}
+			}
+		}
+		return nodes
+	}
+	var hedged int
+	for i := 0; i < 40; i++ {
+		startAt := time.Now()
+		var filters []selector.NodeFilter
+		if i%2 == 1 {
+			filters = []selector.NodeFilter{preferSlow}
+		}
+		body, reqOpt := do("GET", filters...)
+		if body != "fast" || time.Since(startAt) > 500*time.Millisecond {
+			t.Fatalf("want the fast response but got %s in %s", body, time.Since(startAt))
+		}
+		if len(reqOpt.Backends) != len(reqOpt.UpstreamStatusCode) || len(reqOpt.Backends) != len(reqOpt.UpstreamResponseTime) {
+			t.Fatalf("want the upstream stats of the backends but got %v %v", reqOpt.Backends, reqOpt.UpstreamStatusCode)
+		}
+		if len(reqOpt.Backends) == 2 {
+			hedged++
+			if reqOpt.Backends[0] == reqOpt.Backends[1] || reqOpt.UpstreamStatusCode[0] != 0 || reqOpt.UpstreamStatusCode[1] != http.StatusOK {
+				t.Fatalf("want hedged to the other node but got %v %v", reqOpt.Backends, reqOpt.UpstreamStatusCode)
+			}
+			select {
+			case <-canceled:
+			case <-time.After(time.Second):
+				t.Fatal("want the slow request canceled")
+			}
+		}
+	}
+	if hedged == 0 {
+		t.Fatal("want the slow requests hedged")
+	}
+	// the non-idempotent requests are not hedged.
+	for i := 0; i < 5; i++ {
+		if _, reqOpt := do("POST"); len(reqOpt.Backends) != 1 {
+			t.Fatalf("want the POST request not hedged but got %v", reqOpt.Backends)
+		}
+	}
+}
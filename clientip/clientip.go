@@ -0,0 +1,107 @@
+// Package clientip resolves the client IP of the request behind the trusted proxies.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+var trustedProxies atomic.Value
+
+func init() {
+	trustedProxies.Store([]*net.IPNet(nil))
+}
+
+// ParseCIDR parses the CIDR or the single IP, e.g. 10.0.0.0/8 or 10.0.0.1.
+func ParseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP: %s", s)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+	return ipNet, nil
+}
+
+// ParseCIDRs parses the CIDRs or the single IPs.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	out := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		ipNet, err := ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ipNet)
+	}
+	return out, nil
+}
+
+// Contains reports whether the IP is in any of the CIDRs.
+func Contains(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTrustedProxies replaces the trusted proxies, it's called by the proxy on the config update.
+func SetTrustedProxies(cidrs []string) error {
+	proxies, err := ParseCIDRs(cidrs)
+	if err != nil {
+		return err
+	}
+	trustedProxies.Store(proxies)
+	return nil
+}
+
+// FromRequest returns the client IP of the request. The X-Forwarded-For is
+// respected only if the peer is a trusted proxy, the addresses are walked from
+// the right and the first one not trusted is the client. The peer address is
+// the one of the PROXY protocol header if the server accepts it.
+func FromRequest(req *http.Request) net.IP {
+	return FromRequestWithProxies(req, trustedProxies.Load().([]*net.IPNet))
+}
+
+// FromRequestWithProxies returns the client IP of the request behind the proxies
+// instead of the trusted proxies of the gateway.
+func FromRequestWithProxies(req *http.Request, proxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	if !Contains(proxies, ip) {
+		return ip
+	}
+	values := req.Header.Values("X-Forwarded-For")
+	for i := len(values) - 1; i >= 0; i-- {
+		addrs := strings.Split(values[i], ",")
+		for j := len(addrs) - 1; j >= 0; j-- {
+			next := net.ParseIP(strings.TrimSpace(addrs[j]))
+			if next == nil {
+				return ip
+			}
+			ip = next
+			if !Contains(proxies, ip) {
+				return ip
+			}
+		}
+	}
+	return ip
+}
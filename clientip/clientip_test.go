@@ -0,0 +1,62 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromRequest(t *testing.T) {
+	if err := SetTrustedProxies([]string{"10.0.0.0/8", "::1"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTrustedProxies(nil)
+	testCases := []struct {
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"1.1.1.1:80", nil, "1.1.1.1"},
+		{"1.1.1.1:80", []string{"2.2.2.2"}, "1.1.1.1"},
+		{"10.0.0.1:80", nil, "10.0.0.1"},
+		{"10.0.0.1:80", []string{"2.2.2.2"}, "2.2.2.2"},
+		{"10.0.0.1:80", []string{"3.3.3.3, 2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		{"10.0.0.1:80", []string{"3.3.3.3", "10.0.0.3, 10.0.0.2"}, "3.3.3.3"},
+		{"[::1]:80", []string{"10.0.0.2"}, "10.0.0.2"},
+		{"10.0.0.1:80", []string{"unknown, 10.0.0.2"}, "10.0.0.2"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		for _, v := range tc.xff {
+			req.Header.Add("X-Forwarded-For", v)
+		}
+		if got := FromRequest(req); got.String() != tc.want {
+			t.Errorf("%s %v: want %s but got %s", tc.remoteAddr, tc.xff, tc.want, got)
+		}
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	for in, want := range map[string]string{
+		"10.0.0.1":   "10.0.0.1/32",
+		"10.1.0.0/8": "10.0.0.0/8",
+		"::1":        "::1/128",
+		"fd00::/8":   "fd00::/8",
+	} {
+		cidr, err := ParseCIDR(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cidr.String() != want {
+			t.Errorf("%s: want %s but got %s", in, want, cidr)
+		}
+	}
+	for _, in := range []string{"", "10.0.0", "10.0.0.0/33"} {
+		if _, err := ParseCIDR(in); err == nil {
+			t.Errorf("want error of %q", in)
+		}
+	}
+	if err := SetTrustedProxies([]string{"invalid"}); err == nil {
+		t.Error("want error of the invalid trusted proxy")
+	}
+}
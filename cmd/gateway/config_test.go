@@ -95,6 +95,22 @@ func equalTo() *configv1.Gateway {
 					},
 				},
 			},
+			{
+				Path:     "/helloworld.Greeter/SayHello",
+				Method:   "POST",
+				Protocol: configv1.Protocol_GRPC,
+				Timeout:  &durationpb.Duration{Seconds: 1},
+				Backends: []*configv1.Backend{
+					{
+						Target: "127.0.0.1:9000",
+					},
+				},
+				Retry: &configv1.Retry{
+					Attempts:           3,
+					PerTryTimeout:      &durationpb.Duration{Nanos: 100000000},
+					RetriableGrpcCodes: []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+				},
+			},
 		},
 		Middlewares: []*configv1.Middleware{
 			{
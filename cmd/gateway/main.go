@@ -2,36 +2,95 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"syscall"
+	"time"
 
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/audit"
 	"github.com/go-kratos/gateway/client"
 	"github.com/go-kratos/gateway/config"
 	configLoader "github.com/go-kratos/gateway/config/config-loader"
+	"github.com/go-kratos/gateway/config/kms"
+	"github.com/go-kratos/gateway/config/rollout"
+	"github.com/go-kratos/gateway/config/vault"
 	"github.com/go-kratos/gateway/discovery"
+	"github.com/go-kratos/gateway/flags"
 	"github.com/go-kratos/gateway/middleware"
 	"github.com/go-kratos/gateway/proxy"
 	"github.com/go-kratos/gateway/proxy/debug"
+	"github.com/go-kratos/gateway/router/matcher"
 	"github.com/go-kratos/gateway/server"
 
 	_ "net/http/pprof"
 
+	_ "github.com/go-kratos/gateway/config/apollo"
+	_ "github.com/go-kratos/gateway/config/consul"
+	_ "github.com/go-kratos/gateway/config/etcd"
+	_ "github.com/go-kratos/gateway/config/git"
+	_ "github.com/go-kratos/gateway/config/kubernetes"
+	_ "github.com/go-kratos/gateway/config/nacos"
+	_ "github.com/go-kratos/gateway/config/objectstore"
+	_ "github.com/go-kratos/gateway/config/remote"
+	_ "github.com/go-kratos/gateway/config/stream"
+	_ "github.com/go-kratos/gateway/config/xds"
+	_ "github.com/go-kratos/gateway/config/zookeeper"
 	_ "github.com/go-kratos/gateway/discovery/consul"
+	_ "github.com/go-kratos/gateway/middleware/apikey"
+	_ "github.com/go-kratos/gateway/middleware/basicauth"
 	_ "github.com/go-kratos/gateway/middleware/bbr"
+	_ "github.com/go-kratos/gateway/middleware/bodylimit"
+	_ "github.com/go-kratos/gateway/middleware/bot"
+	"github.com/go-kratos/gateway/middleware/cache"
+	_ "github.com/go-kratos/gateway/middleware/canary"
+	_ "github.com/go-kratos/gateway/middleware/cel"
 	"github.com/go-kratos/gateway/middleware/circuitbreaker"
+	_ "github.com/go-kratos/gateway/middleware/compression"
+	_ "github.com/go-kratos/gateway/middleware/concurrency"
 	_ "github.com/go-kratos/gateway/middleware/cors"
+	_ "github.com/go-kratos/gateway/middleware/csrf"
+	_ "github.com/go-kratos/gateway/middleware/experiment"
+	_ "github.com/go-kratos/gateway/middleware/extauthz"
+	_ "github.com/go-kratos/gateway/middleware/fault"
+	_ "github.com/go-kratos/gateway/middleware/graphql"
+	_ "github.com/go-kratos/gateway/middleware/grpctranscoder"
+	_ "github.com/go-kratos/gateway/middleware/grpcweb"
+	_ "github.com/go-kratos/gateway/middleware/headers"
+	_ "github.com/go-kratos/gateway/middleware/hmac"
+	_ "github.com/go-kratos/gateway/middleware/introspection"
+	_ "github.com/go-kratos/gateway/middleware/ipacl"
+	_ "github.com/go-kratos/gateway/middleware/jsontransform"
+	_ "github.com/go-kratos/gateway/middleware/jwt"
 	_ "github.com/go-kratos/gateway/middleware/logging"
+	_ "github.com/go-kratos/gateway/middleware/metrics"
+	"github.com/go-kratos/gateway/middleware/mirror"
+	_ "github.com/go-kratos/gateway/middleware/mtls"
+	_ "github.com/go-kratos/gateway/middleware/oidc"
+	_ "github.com/go-kratos/gateway/middleware/opa"
+	_ "github.com/go-kratos/gateway/middleware/openapi"
+	_ "github.com/go-kratos/gateway/middleware/ratelimit"
+	_ "github.com/go-kratos/gateway/middleware/rbac"
+	_ "github.com/go-kratos/gateway/middleware/requestid"
 	_ "github.com/go-kratos/gateway/middleware/rewrite"
+	_ "github.com/go-kratos/gateway/middleware/rls"
 	_ "github.com/go-kratos/gateway/middleware/tracing"
 	_ "github.com/go-kratos/gateway/middleware/transcoder"
+	_ "github.com/go-kratos/gateway/middleware/waf"
 	_ "go.uber.org/automaxprocs"
 
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 var (
@@ -39,8 +98,31 @@ var (
 	ctrlService  string
 	discoveryDSN string
 	proxyAddrs   = newSliceVar(":8080")
+	tlsAddrs     = newSliceVar()
 	proxyConfig  string
+	confOverlays = newSliceVar()
 	withDebug    bool
+	checkConfig  bool
+	printSchema  bool
+
+	confPollInterval time.Duration
+	confMaxBackoff   time.Duration
+	confKeyFile      string
+	confAgeFile      string
+	confGCPKMSKey    string
+	vaultAddr        string
+	auditFile        string
+	auditWebhook     string
+	eventsFile       string
+	eventsWebhook    string
+
+	rolloutStore   string
+	rolloutReplica string
+	rolloutQuorum  int
+	rolloutTimeout time.Duration
+
+	runtimeFile string
+	adminAddr   string
 )
 
 type sliceVar struct {
@@ -65,13 +147,125 @@ func (s *sliceVar) String() string { return fmt.Sprintf("%+v", *s) }
 
 func init() {
 	flag.BoolVar(&withDebug, "debug", false, "enable debug handlers")
+	flag.BoolVar(&checkConfig, "check-config", false, "check the config and print it in JSON without starting the gateway")
+	flag.BoolVar(&printSchema, "print-schema", false, "print the JSON Schema of the config without starting the gateway")
 	flag.Var(&proxyAddrs, "addr", "proxy address, eg: -addr 0.0.0.0:8080")
-	flag.StringVar(&proxyConfig, "conf", "config.yaml", "config path, eg: -conf config.yaml")
+	flag.Var(&tlsAddrs, "addr.tls", "TLS proxy address serving the certificates of the config, eg: -addr.tls 0.0.0.0:8443")
+	flag.StringVar(&proxyConfig, "conf", "config.yaml", "config path or uri of the registered config loaders, eg: -conf config.yaml, -conf etcd://127.0.0.1:2379/gateway/config")
+	flag.Var(&confOverlays, "conf.overlay", "config overlay file applied in order, eg: -conf.overlay prod.yaml")
+	flag.DurationVar(&confPollInterval, "conf.interval", 0, "config file checksum poll interval, eg: -conf.interval 5s")
+	flag.DurationVar(&confMaxBackoff, "conf.backoff", 0, "max backoff to retry on config watch errors, eg: -conf.backoff 1m")
+	flag.StringVar(&confKeyFile, "conf.key", "", "base64 encoded AES key file to decrypt the ENC[aesgcm,...] config values, eg: -conf.key /etc/gateway/key")
+	flag.StringVar(&confAgeFile, "conf.age", "", "age identity file to decrypt the ENC[age,...] config values, eg: -conf.age /etc/gateway/age.key")
+	flag.StringVar(&confGCPKMSKey, "conf.gcpkms", "", "GCP KMS key to decrypt the ENC[gcpkms,...] config values, eg: -conf.gcpkms projects/p/locations/global/keyRings/r/cryptoKeys/k")
+	flag.StringVar(&vaultAddr, "vault.addr", os.Getenv("VAULT_ADDR"), "vault address to resolve the vault:path#field config values with the VAULT_TOKEN, eg: https://127.0.0.1:8200")
+	flag.StringVar(&auditFile, "audit.file", "", "file to append the audit records of the applied config changes, eg: -audit.file /var/log/gateway/audit.log")
+	flag.StringVar(&auditWebhook, "audit.webhook", "", "webhook to post the audit records of the applied config changes, eg: -audit.webhook https://audit.example.com/gateway")
+	flag.StringVar(&eventsFile, "audit.events.file", "", "file to append the audit events of the authentication failures, policy denials and admin API mutations, eg: -audit.events.file /var/log/gateway/events.log")
+	flag.StringVar(&eventsWebhook, "audit.events.webhook", "", "webhook to post the audit events of the authentication failures, policy denials and admin API mutations, eg: -audit.events.webhook https://audit.example.com/events")
+	flag.StringVar(&rolloutStore, "rollout.store", "", "store to coordinate the config rollout of the replicas, eg: etcd://127.0.0.1:2379/gateway/rollout")
+	flag.StringVar(&rolloutReplica, "rollout.replica", defaultReplica(), "replica name in the config rollout, eg: gateway-0")
+	flag.IntVar(&rolloutQuorum, "rollout.quorum", 0, "number of the replicas required to validate a config, the default is the majority")
+	flag.DurationVar(&rolloutTimeout, "rollout.timeout", time.Minute, "timeout to wait for the quorum of a config, eg: -rollout.timeout 1m")
+	flag.StringVar(&adminAddr, "admin.addr", "", "admin API address to add and remove the routes and purge the cache, eg: -admin.addr 127.0.0.1:9090")
+	flag.StringVar(&runtimeFile, "runtime.file", "", "runtime flags file watched for the toggles and overrides, eg: -runtime.file runtime.yaml")
 	flag.StringVar(&ctrlName, "ctrl.name", os.Getenv("ADVERTISE_NAME"), "control gateway name, eg: gateway")
 	flag.StringVar(&ctrlService, "ctrl.service", "", "control service host, eg: http://127.0.0.1:8000")
 	flag.StringVar(&discoveryDSN, "discovery.dsn", "", "discovery dsn, eg: consul://127.0.0.1:7070?token=secret&datacenter=prod")
 }
 
+func defaultReplica() string {
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+func registerDecrypter() {
+	// the AWS KMS ciphertext blobs identify their keys, the credentials are
+	// read from the AWS environments.
+	config.RegisterDecrypter(kms.AWSProvider, kms.NewAWS())
+	if confGCPKMSKey != "" {
+		config.RegisterDecrypter(kms.GCPProvider, kms.NewGCP(confGCPKMSKey))
+	}
+	if confAgeFile != "" {
+		data, err := ioutil.ReadFile(confAgeFile)
+		if err != nil {
+			log.Fatalf("failed to read age identity: %v", err)
+		}
+		decrypter, err := kms.NewAge(data)
+		if err != nil {
+			log.Fatalf("failed to create age decrypter: %v", err)
+		}
+		config.RegisterDecrypter(kms.AgeProvider, decrypter)
+	}
+	if confKeyFile == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(confKeyFile)
+	if err != nil {
+		log.Fatalf("failed to read config key: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		log.Fatalf("failed to decode config key: %v", err)
+	}
+	decrypter, err := config.NewAESGCMDecrypter(key)
+	if err != nil {
+		log.Fatalf("failed to create config decrypter: %v", err)
+	}
+	config.RegisterDecrypter("aesgcm", decrypter)
+}
+
+func registerSecretResolver() {
+	if vaultAddr == "" {
+		return
+	}
+	vault.Register(vaultAddr, os.Getenv("VAULT_TOKEN"), vault.WithNamespace(os.Getenv("VAULT_NAMESPACE")))
+}
+
+func reloaderOptions() []config.ReloaderOption {
+	opts := []config.ReloaderOption{config.WithSource(proxyConfig)}
+	if auditFile != "" {
+		sink, err := config.NewFileAuditSink(auditFile)
+		if err != nil {
+			log.Fatalf("failed to open audit file: %v", err)
+		}
+		opts = append(opts, config.WithAuditSink(sink))
+	}
+	if auditWebhook != "" {
+		opts = append(opts, config.WithAuditSink(config.NewWebhookAuditSink(auditWebhook, nil)))
+	}
+	return opts
+}
+
+func setAuditSinks() {
+	var sinks []audit.Sink
+	if eventsFile != "" {
+		sink, err := audit.NewFileSink(eventsFile)
+		if err != nil {
+			log.Fatalf("failed to open audit events file: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if eventsWebhook != "" {
+		sinks = append(sinks, audit.NewWebhookSink(eventsWebhook, nil))
+	}
+	audit.SetSinks(sinks...)
+}
+
+func makeRolloutCoordinator() *rollout.Coordinator {
+	if rolloutStore == "" {
+		return nil
+	}
+	store, err := rollout.NewStoreFromURI(rolloutStore)
+	if err != nil {
+		log.Fatalf("failed to create rollout store: %v", err)
+	}
+	return rollout.New(store, rolloutReplica,
+		rollout.WithQuorum(rolloutQuorum),
+		rollout.WithQuorumTimeout(rolloutTimeout),
+	)
+}
+
 func makeDiscovery() registry.Discovery {
 	if discoveryDSN == "" {
 		return nil
@@ -83,8 +277,83 @@ func makeDiscovery() registry.Discovery {
 	return d
 }
 
+// confFilePath returns the file path of the config, it's empty if the
+// config is not loaded from the file.
+func confFilePath() string {
+	u, err := url.Parse(proxyConfig)
+	if err != nil || (u.Scheme != "" && u.Scheme != "file") {
+		return ""
+	}
+	return config.FilePath(u)
+}
+
+// newFileLoader creates the file config loader with the conf flags.
+func newFileLoader(uri *url.URL) (config.ConfigLoader, error) {
+	return config.NewFileLoader(config.FilePath(uri),
+		config.WithPollInterval(confPollInterval),
+		config.WithErrorBackoff(0, confMaxBackoff),
+		config.WithReloadSignals(syscall.SIGHUP),
+		config.WithOverlays(confOverlays.Get()...),
+	)
+}
+
+func checkConfigURI() ([]byte, error) {
+	if path := confFilePath(); path != "" {
+		return config.Check(path, confOverlays.Get()...)
+	}
+	loader, err := config.Create(proxyConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(c)
+}
+
+// checkConfigAndExit validates the config and prints the normalized config,
+// it exits with a non-zero code if the config is invalid.
+func checkConfigAndExit() {
+	out, err := checkConfigURI()
+	if err != nil {
+		var errs config.ValidationErrors
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				fmt.Fprintln(os.Stderr, e.Error())
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+	c := &configv1.Gateway{}
+	if err := protojson.Unmarshal(out, c); err == nil {
+		for _, s := range matcher.Shadowed(c.Endpoints) {
+			fmt.Fprintf(os.Stderr, "warning: endpoint %s is shadowed by %s\n", config.EndpointKey(s.Endpoint), config.EndpointKey(s.By))
+		}
+	}
+	fmt.Println(string(out))
+	os.Exit(0)
+}
+
+// printSchemaAndExit prints the JSON Schema of the config with the options
+// of the compiled in middlewares.
+func printSchemaAndExit() {
+	out, err := config.JSONSchema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+	os.Exit(0)
+}
+
 func main() {
 	flag.Parse()
+	registerDecrypter()
+	registerSecretResolver()
 
 	clientFactory := client.NewFactory(makeDiscovery())
 	p, err := proxy.New(clientFactory, middleware.Create)
@@ -92,50 +361,77 @@ func main() {
 		log.Fatalf("failed to new proxy: %v", err)
 	}
 	circuitbreaker.Init(clientFactory)
+	mirror.Init(clientFactory)
+	if printSchema {
+		printSchemaAndExit()
+	}
+	if checkConfig {
+		checkConfigAndExit()
+	}
 
+	config.Register("file", newFileLoader)
+	setAuditSinks()
 	ctx := context.Background()
+	if runtimeFile != "" {
+		if err := flags.Default().WatchFile(ctx, runtimeFile, 0); err != nil {
+			log.Fatalf("failed to watch runtime flags file: %v", err)
+		}
+	}
 	var ctrlLoader *configLoader.CtrlConfigLoader
 	if ctrlService != "" {
+		confPath := confFilePath()
+		if confPath == "" {
+			log.Fatalf("the control service requires a config file, but got: %s", proxyConfig)
+		}
 		log.Infof("setup control service to: %q", ctrlService)
-		ctrlLoader = configLoader.New(ctrlName, ctrlService, proxyConfig)
+		ctrlLoader = configLoader.New(ctrlName, ctrlService, confPath)
 		if err := ctrlLoader.Load(ctx); err != nil {
 			log.Errorf("failed to do initial load from control service: %v, using local config instead", err)
 		}
 		go ctrlLoader.Run(ctx)
 	}
 
-	confLoader, err := config.NewFileLoader(proxyConfig)
+	confLoader, err := config.Create(proxyConfig)
 	if err != nil {
-		log.Fatalf("failed to create config file loader: %v", err)
+		log.Fatalf("failed to create config loader: %v", err)
 	}
 	defer confLoader.Close()
-	bc, err := confLoader.Load(context.Background())
-	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+	apply := p.Update
+	certs := server.NewCertificates()
+	if len(tlsAddrs.Get()) > 0 {
+		// the certificates are loaded before the routes of the server names.
+		apply = func(c *configv1.Gateway) error {
+			if err := certs.Update(c); err != nil {
+				return err
+			}
+			return p.Update(c)
+		}
 	}
-
-	if err := p.Update(bc); err != nil {
-		log.Fatalf("failed to update service config: %v", err)
+	coordinator := makeRolloutCoordinator()
+	if coordinator != nil {
+		defer coordinator.Close()
+		apply = coordinator.Apply(apply)
 	}
-	reloader := func() error {
-		bc, err := confLoader.Load(context.Background())
-		if err != nil {
-			log.Errorf("failed to load config: %v", err)
-			return err
-		}
-		if err := p.Update(bc); err != nil {
-			log.Errorf("failed to update service config: %v", err)
-			return err
-		}
-		log.Infof("config reloaded")
-		return nil
+	reloader := config.NewReloader(confLoader, apply, reloaderOptions()...)
+	defer reloader.Close()
+	if err := reloader.Reload(); err != nil {
+		log.Fatalf("failed to load config: %v", err)
 	}
-	confLoader.Watch(reloader)
+	bc, _ := reloader.Applied()
+	confLoader.Watch(reloader.Reload)
 
 	var serverHandler http.Handler = p
 	if withDebug {
 		debug.Register("proxy", p)
-		debug.Register("config", confLoader)
+		if d, ok := confLoader.(debug.Debuggable); ok {
+			debug.Register("config", d)
+		}
+		debug.Register("reloader", reloader)
+		debug.Handle("/debug/config", reloader.ConfigHandler())
+		debug.Register("flags", flags.Default())
+		if coordinator != nil {
+			debug.Register("rollout", coordinator)
+		}
 		if ctrlLoader != nil {
 			debug.Register("ctrl", ctrlLoader)
 		}
@@ -145,6 +441,15 @@ func main() {
 	for _, addr := range proxyAddrs.Get() {
 		servers = append(servers, server.NewProxy(serverHandler, addr))
 	}
+	for _, addr := range tlsAddrs.Get() {
+		servers = append(servers, server.NewTLSProxy(serverHandler, addr, certs.TLSConfig()))
+	}
+	if adminAddr != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/admin/routes", reloader.AdminHandler())
+		adminMux.Handle("/admin/cache", cache.AdminHandler())
+		servers = append(servers, server.NewProxy(audit.Handler("admin", adminMux), adminAddr))
+	}
 	app := kratos.New(
 		kratos.Name(bc.Name),
 		kratos.Context(ctx),
@@ -0,0 +1,319 @@
+package apollo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	_defaultCluster   = "default"
+	_defaultNamespace = "gateway.yaml"
+	// the whole content of the yaml, yml and json namespaces is under the key `content`.
+	_defaultKey = "content"
+	// the notifications API holds the request for 60s if there is no change.
+	_notificationTimeout = time.Second * 90
+)
+
+var _ config.ConfigLoader = (*apolloLoader)(nil)
+
+func init() {
+	config.Register("apollo", NewFromURI)
+}
+
+type apolloLoader struct {
+	configServer string
+	appID        string
+	cluster      string
+	namespace    string
+	key          string
+	secret       string
+	client       *http.Client
+
+	notificationID int64
+	confSHA256     string
+	watchCancel    context.CancelFunc
+	handlers       config.Notifier
+}
+
+// Option is an apollo loader option.
+type Option func(*apolloLoader)
+
+// WithCluster sets the cluster name, the default is `default`.
+func WithCluster(cluster string) Option {
+	return func(l *apolloLoader) {
+		l.cluster = cluster
+	}
+}
+
+// WithNamespace sets the namespace name, the default is `gateway.yaml`.
+func WithNamespace(namespace string) Option {
+	return func(l *apolloLoader) {
+		l.namespace = namespace
+	}
+}
+
+// WithKey sets the key of the gateway config in the namespace, the default
+// is `content` which is the whole content of a yaml or json namespace.
+func WithKey(key string) Option {
+	return func(l *apolloLoader) {
+		l.key = key
+	}
+}
+
+// WithSecret sets the access key secret to sign the requests.
+func WithSecret(secret string) Option {
+	return func(l *apolloLoader) {
+		l.secret = secret
+	}
+}
+
+// WithHTTPClient sets the http client to request the apollo config service.
+func WithHTTPClient(client *http.Client) Option {
+	return func(l *apolloLoader) {
+		l.client = client
+	}
+}
+
+// NewFromURI returns a config loader from the uri, e.g.
+// apollo://127.0.0.1:8080/gateway?cluster=prod&namespace=gateway.yaml&key=content&secret=secret,
+// the `tls=true` query connects the config service by https.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	query := uri.Query()
+	scheme := "http"
+	if query.Get("tls") == "true" {
+		scheme = "https"
+	}
+	var opts []Option
+	if cluster := query.Get("cluster"); cluster != "" {
+		opts = append(opts, WithCluster(cluster))
+	}
+	if namespace := query.Get("namespace"); namespace != "" {
+		opts = append(opts, WithNamespace(namespace))
+	}
+	if key := query.Get("key"); key != "" {
+		opts = append(opts, WithKey(key))
+	}
+	if secret := query.Get("secret"); secret != "" {
+		opts = append(opts, WithSecret(secret))
+	}
+	return New(scheme+"://"+uri.Host, strings.TrimPrefix(uri.Path, "/"), opts...)
+}
+
+// New returns a config loader which loads the gateway config from the apollo
+// namespace of `appID` on the config service, e.g. http://127.0.0.1:8080.
+// The OnChange handlers are triggered by the notifications API.
+func New(configServer, appID string, opts ...Option) (config.ConfigLoader, error) {
+	l := &apolloLoader{
+		configServer:   strings.TrimSuffix(configServer, "/"),
+		appID:          appID,
+		cluster:        _defaultCluster,
+		namespace:      _defaultNamespace,
+		key:            _defaultKey,
+		client:         http.DefaultClient,
+		notificationID: -1,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *apolloLoader) initialize() error {
+	data, err := l.get(context.Background())
+	if err != nil {
+		return err
+	}
+	l.confSHA256 = sha256sum(data)
+	log.Infof("the initial apollo config appId: %s, namespace: %s, sha256: %s", l.appID, l.namespace, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+// sign signs the request with the access key secret,
+// see https://www.apolloconfig.com/#/en/usage/other-language-client-user-guide
+func (l *apolloLoader) sign(req *http.Request) {
+	if l.secret == "" {
+		return
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	mac := hmac.New(sha1.New, []byte(l.secret))
+	mac.Write([]byte(timestamp + "\n" + req.URL.RequestURI()))
+	req.Header.Set("Authorization", fmt.Sprintf("Apollo %s:%s", l.appID, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	req.Header.Set("Timestamp", timestamp)
+}
+
+// do sends the request, the body is nil if the response status is 304.
+func (l *apolloLoader) do(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	l.sign(req)
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("apollo namespace: %q of appId: %q is not found", l.namespace, l.appID)
+	default:
+		return nil, fmt.Errorf("unexpected apollo response status: %d: %s", resp.StatusCode, body)
+	}
+}
+
+func (l *apolloLoader) get(ctx context.Context) ([]byte, error) {
+	rawURL := fmt.Sprintf("%s/configs/%s/%s/%s", l.configServer,
+		url.PathEscape(l.appID), url.PathEscape(l.cluster), url.PathEscape(l.namespace))
+	body, err := l.do(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		Configurations map[string]string `json:"configurations"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return nil, fmt.Errorf("invalid apollo config response: %w", err)
+	}
+	content, ok := reply.Configurations[l.key]
+	if !ok {
+		return nil, fmt.Errorf("key %q is not found in apollo namespace: %q", l.key, l.namespace)
+	}
+	return []byte(content), nil
+}
+
+type notification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int64  `json:"notificationId"`
+}
+
+// notifications blocks until the namespace is changed or the request is timeout,
+// it reports whether the namespace is changed.
+func (l *apolloLoader) notifications(ctx context.Context) (bool, error) {
+	ns, err := json.Marshal([]notification{{NamespaceName: l.namespace, NotificationID: l.notificationID}})
+	if err != nil {
+		return false, err
+	}
+	q := url.Values{
+		"appId":         {l.appID},
+		"cluster":       {l.cluster},
+		"notifications": {string(ns)},
+	}
+	ctx, cancel := context.WithTimeout(ctx, _notificationTimeout)
+	defer cancel()
+	body, err := l.do(ctx, l.configServer+"/notifications/v2?"+q.Encode())
+	if err != nil || body == nil {
+		return false, err
+	}
+	var reply []notification
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return false, fmt.Errorf("invalid apollo notifications response: %w", err)
+	}
+	for _, n := range reply {
+		if n.NamespaceName == l.namespace && n.NotificationID != l.notificationID {
+			l.notificationID = n.NotificationID
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (l *apolloLoader) Load(ctx context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from apollo appId: %s, namespace: %s", l.appID, l.namespace)
+	data, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := config.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *apolloLoader) Watch(fn config.OnChange) {
+	log.Info("add apollo config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *apolloLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch apollo config appId: %s, namespace: %s", l.appID, l.namespace)
+	for {
+		changed, err := l.notifications(ctx)
+		if err == nil && changed {
+			err = l.onChange(ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("watch apollo config appId: %s, namespace: %s error: %+v", l.appID, l.namespace, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (l *apolloLoader) onChange(ctx context.Context) error {
+	data, err := l.get(ctx)
+	if err != nil {
+		return err
+	}
+	sha256hex := sha256sum(data)
+	if sha256hex == l.confSHA256 {
+		return nil
+	}
+	log.Infof("apollo config changed, reload config, notificationId: %d, last sha256: %s, new sha256: %s", l.notificationID, l.confSHA256, sha256hex)
+	if err := l.handlers.Notify(); err != nil {
+		log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+		return nil
+	}
+	l.confSHA256 = sha256hex
+	return nil
+}
+
+func (l *apolloLoader) Close() {
+	l.watchCancel()
+}
@@ -0,0 +1,131 @@
+package apollo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+type fakeApollo struct {
+	lock           sync.Mutex
+	content        string
+	notificationID int64
+	changed        chan struct{}
+}
+
+func (f *fakeApollo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Apollo gateway:") || r.Header.Get("Timestamp") == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/configs/gateway/default/gateway.yaml":
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"appId":          "gateway",
+			"namespaceName":  "gateway.yaml",
+			"configurations": map[string]string{"content": f.content},
+		})
+	case r.URL.Path == "/notifications/v2":
+		var ns []notification
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("notifications")), &ns); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.lock.Lock()
+		id := f.notificationID
+		f.lock.Unlock()
+		if ns[0].NotificationID == id {
+			select {
+			case <-f.changed:
+			case <-time.After(time.Millisecond * 100):
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		json.NewEncoder(w).Encode([]notification{{NamespaceName: "gateway.yaml", NotificationID: f.notificationID}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeApollo) set(content string) {
+	f.lock.Lock()
+	f.content = content
+	f.notificationID++
+	f.lock.Unlock()
+	f.changed <- struct{}{}
+}
+
+func TestApolloLoader(t *testing.T) {
+	apollo := &fakeApollo{content: _config, notificationID: 1, changed: make(chan struct{}, 1)}
+	srv := httptest.NewServer(apollo)
+	defer srv.Close()
+
+	if _, err := New(srv.URL, "gateway", WithNamespace("application"), WithSecret("secret")); err == nil {
+		t.Fatal("want an error of the unknown namespace")
+	}
+	loader, err := New(srv.URL, "gateway", WithSecret("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 1 || len(c.Middlewares) != 1 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	apollo.set(_changedConfig)
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+	c, err = loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %s", c.Version)
+	}
+}
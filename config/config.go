@@ -2,19 +2,20 @@ package config
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
 	"github.com/go-kratos/kratos/v2/log"
 	"google.golang.org/protobuf/encoding/protojson"
-	"sigs.k8s.io/yaml"
 )
 
 type OnChange func() error
@@ -26,19 +27,124 @@ type ConfigLoader interface {
 }
 
 type FileLoader struct {
-	confPath         string
-	confSHA256       string
-	watchCancel      context.CancelFunc
-	lock             sync.RWMutex
-	onChangeHandlers []OnChange
+	confPath        string
+	confSHA256      string
+	watchCancel     context.CancelFunc
+	handlers        Notifier
+	watcher         *fsnotify.Watcher
+	files           map[string]struct{}
+	pollInterval    time.Duration
+	errorBackoff    time.Duration
+	maxErrorBackoff time.Duration
+	reloadSignals   []os.Signal
+	overlays        []string
+}
+
+// FileLoaderOption is a file loader option.
+type FileLoaderOption func(*FileLoader)
+
+// WithPollInterval sets the interval to recompute the config file checksum,
+// the default is 5s, or 1m when the fsnotify watcher is available.
+func WithPollInterval(interval time.Duration) FileLoaderOption {
+	return func(f *FileLoader) {
+		f.pollInterval = interval
+	}
+}
+
+// WithErrorBackoff sets the exponential backoff to retry on the watch errors,
+// the delay starts from `base` and is doubled on each failure until `max`.
+// The default base is the poll interval and the default max is 1m.
+func WithErrorBackoff(base, max time.Duration) FileLoaderOption {
+	return func(f *FileLoader) {
+		f.errorBackoff = base
+		f.maxErrorBackoff = max
+	}
+}
+
+// WithReloadSignals sets the signals to reload the config immediately, e.g. SIGHUP,
+// the handlers are executed even if the config files are not changed.
+func WithReloadSignals(sigs ...os.Signal) FileLoaderOption {
+	return func(f *FileLoader) {
+		f.reloadSignals = sigs
+	}
+}
+
+// WithOverlays sets the overlay files applied to the config in order,
+// e.g. the environment specific overrides, see applyOverlays.
+func WithOverlays(paths ...string) FileLoaderOption {
+	return func(f *FileLoader) {
+		f.overlays = paths
+	}
 }
 
 var _jsonOptions = &protojson.UnmarshalOptions{DiscardUnknown: true}
 
-func NewFileLoader(confPath string) (*FileLoader, error) {
+// Unmarshal parses the YAML or JSON encoded gateway config, the endpoint
+// templates are expanded, the ENC[provider,ciphertext] values are decrypted by the registered decrypters
+// and the `scheme:ref` secret references are resolved by the registered resolvers.
+// The defaults are not applied, since the config may be merged or overlaid
+// later, call ApplyDefaults on the final config instead.
+func Unmarshal(data []byte) (*configv1.Gateway, error) {
+	jsonData, err := toJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if jsonData, err = expandTemplates(jsonData); err != nil {
+		return nil, err
+	}
+	if jsonData, err = resolveSecrets(jsonData); err != nil {
+		return nil, err
+	}
+	out := &configv1.Gateway{}
+	if err := _jsonOptions.Unmarshal(jsonData, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Notifier is a set of OnChange handlers, it is shared by the config loaders
+// to broadcast the config change event.
+type Notifier struct {
+	lock     sync.RWMutex
+	handlers []OnChange
+}
+
+// Add adds an OnChange handler.
+func (n *Notifier) Add(fn OnChange) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.handlers = append(n.handlers, fn)
+}
+
+// Len returns the number of handlers.
+func (n *Notifier) Len() int {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return len(n.handlers)
+}
+
+// Notify executes all handlers, the last error will be returned.
+func (n *Notifier) Notify() error {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	var chainedError error
+	for _, fn := range n.handlers {
+		if err := fn(); err != nil {
+			log.Errorf("execute config loader error on handler: %+v: %+v", fn, err)
+			chainedError = errors.New(err.Error())
+		}
+	}
+	return chainedError
+}
+
+func NewFileLoader(confPath string, opts ...FileLoaderOption) (*FileLoader, error) {
 	fl := &FileLoader{
 		confPath: confPath,
 	}
+	for _, opt := range opts {
+		opt(fl)
+	}
 	if err := fl.initialize(); err != nil {
 		return nil, err
 	}
@@ -59,85 +165,248 @@ func (f *FileLoader) initialize() error {
 	return nil
 }
 
-func sha256sum(in []byte) string {
-	sum := sha256.Sum256(in)
-	return hex.EncodeToString(sum[:])
+// readFiles reads the config files followed by the overlay files.
+func (f *FileLoader) readFiles() ([]*configFile, []*configFile, error) {
+	files, err := readConfigFiles(f.confPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	overlays, err := readOverlayFiles(f.overlays)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, overlays, nil
 }
 
 func (f *FileLoader) configSHA256() (string, error) {
-	configData, err := ioutil.ReadFile(f.confPath)
+	files, overlays, err := f.readFiles()
 	if err != nil {
 		return "", err
 	}
-	return sha256sum(configData), nil
+	files = append(files, overlays...)
+	f.watchFiles(files)
+	return configFilesSHA256(files), nil
 }
 
 func (f *FileLoader) Load(_ context.Context) (*configv1.Gateway, error) {
 	log.Infof("loading config file: %s", f.confPath)
 
-	configData, err := ioutil.ReadFile(f.confPath)
+	out, err := f.load()
 	if err != nil {
 		return nil, err
 	}
+	if err := validate(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-	jsonData, err := yaml.YAMLToJSON(configData)
+func (f *FileLoader) load() (*configv1.Gateway, error) {
+	files, overlays, err := f.readFiles()
 	if err != nil {
 		return nil, err
 	}
-	out := &configv1.Gateway{}
-	if err := _jsonOptions.Unmarshal(jsonData, out); err != nil {
+	out, err := mergeConfigFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	if out, err = applyOverlays(out, overlays); err != nil {
 		return nil, err
 	}
+	// the defaults of the merged config are applied to the endpoints of all files.
+	ApplyDefaults(out)
 	return out, nil
 }
 
+// LoadFile loads the config files of confPath with the overlays without
+// watching and validating them.
+func LoadFile(confPath string, overlays ...string) (*configv1.Gateway, error) {
+	return (&FileLoader{confPath: confPath, overlays: overlays}).load()
+}
+
 func (f *FileLoader) Watch(fn OnChange) {
 	log.Info("add config file change event handler")
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	f.onChangeHandlers = append(f.onChangeHandlers, fn)
+	f.handlers.Add(fn)
 }
 
 func (f *FileLoader) executeLoader() error {
 	log.Info("execute config loader")
-	f.lock.RLock()
-	defer f.lock.RUnlock()
+	return f.handlers.Notify()
+}
 
-	var chainedError error
-	for _, fn := range f.onChangeHandlers {
-		if err := fn(); err != nil {
-			log.Errorf("execute config loader error on handler: %+v: %+v", fn, err)
-			chainedError = errors.New(err.Error())
+const (
+	_pollInterval         = time.Second * 5
+	_fsnotifyPollInterval = time.Minute
+	_maxErrorBackoff      = time.Minute
+	_debounceDelay        = time.Millisecond * 100
+)
+
+func (f *FileLoader) newFsWatcher() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the parent directory, since the editors and kubernetes configmap
+	// replace the file by renaming instead of writing in place.
+	dir := f.confPath
+	if stat, err := os.Stat(f.confPath); err != nil || !stat.IsDir() {
+		dir = filepath.Dir(f.confPath)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// watchFiles records the config files and watches the directories of the included files.
+func (f *FileLoader) watchFiles(files []*configFile) {
+	f.files = make(map[string]struct{}, len(files))
+	for _, file := range files {
+		f.files[file.path] = struct{}{}
+		if f.watcher != nil {
+			if err := f.watcher.Add(filepath.Dir(file.path)); err != nil {
+				log.Warnf("failed to watch the directory of config file %s: %+v", file.path, err)
+			}
 		}
 	}
-	return chainedError
+}
+
+func (f *FileLoader) isConfigEvent(ev fsnotify.Event) bool {
+	name := filepath.Clean(ev.Name)
+	if _, ok := f.files[name]; ok {
+		return true
+	}
+	// new files in the config directory
+	if filepath.Dir(name) == filepath.Clean(f.confPath) && isConfigFile(name) {
+		return true
+	}
+	// kubernetes configmap volume swaps the `..data` symlink on update
+	return filepath.Base(name) == "..data"
 }
 
 func (f *FileLoader) watchproc(ctx context.Context) {
 	log.Info("start watch config file")
+	pollInterval := _pollInterval
+	var (
+		fsEvents <-chan fsnotify.Event
+		fsErrors <-chan error
+	)
+	watcher, err := f.newFsWatcher()
+	if err != nil {
+		log.Warnf("failed to watch config file with fsnotify: %+v, fallback to poll the checksum", err)
+	} else {
+		defer watcher.Close()
+		f.watcher = watcher
+		fsEvents, fsErrors = watcher.Events, watcher.Errors
+		pollInterval = _fsnotifyPollInterval
+		if _, err := f.configSHA256(); err != nil {
+			log.Warnf("failed to watch the included config files: %+v", err)
+		}
+	}
+	if f.pollInterval > 0 {
+		pollInterval = f.pollInterval
+	}
+	var signals chan os.Signal
+	if len(f.reloadSignals) > 0 {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, f.reloadSignals...)
+		defer signal.Stop(signals)
+	}
+	backoff := newBackoff(pollInterval, f.errorBackoff, f.maxErrorBackoff)
+	poll := time.NewTimer(pollInterval)
+	defer poll.Stop()
+	// the editors may write the file multiple times in a short period,
+	// debounce the events to reload once.
+	debounce := time.NewTimer(_debounceDelay)
+	debounce.Stop()
+	defer debounce.Stop()
 	for {
+		force := false
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(time.Second * 5):
-		}
-		func() {
-			sha256hex, err := f.configSHA256()
-			if err != nil {
-				log.Errorf("watch config file error: %+v", err)
-				return
+		case sig := <-signals:
+			log.Infof("received signal: %s, reload config", sig)
+			force = true
+		case ev := <-fsEvents:
+			if f.isConfigEvent(ev) {
+				debounce.Reset(_debounceDelay)
 			}
-			if sha256hex != f.confSHA256 {
-				log.Infof("config file changed, reload config, last sha256: %s, new sha256: %s", f.confSHA256, sha256hex)
-				if err := f.executeLoader(); err != nil {
-					log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
-					return
-				}
-				f.confSHA256 = sha256hex
-				return
+			continue
+		case err := <-fsErrors:
+			log.Errorf("watch config file with fsnotify error: %+v", err)
+			continue
+		case <-debounce.C:
+		case <-poll.C:
+		}
+		if !poll.Stop() {
+			select {
+			case <-poll.C:
+			default:
 			}
-		}()
+		}
+		if err := f.checkChange(force); err != nil {
+			delay := backoff.next()
+			log.Errorf("%+v, retry after %s", err, delay)
+			poll.Reset(delay)
+			continue
+		}
+		backoff.reset()
+		poll.Reset(pollInterval)
+	}
+}
+
+// checkChange executes the handlers if the config files are changed or it's forced.
+func (f *FileLoader) checkChange(force bool) error {
+	sha256hex, err := f.configSHA256()
+	if err != nil {
+		return fmt.Errorf("watch config file error: %+v", err)
+	}
+	if sha256hex != f.confSHA256 || force {
+		log.Infof("config file changed, reload config, last sha256: %s, new sha256: %s", f.confSHA256, sha256hex)
+		if err := f.executeLoader(); err != nil {
+			return fmt.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+		}
+		f.confSHA256 = sha256hex
+	}
+	return nil
+}
+
+// backoff is an exponential backoff for the watch errors.
+type backoff struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func newBackoff(pollInterval, base, max time.Duration) *backoff {
+	if base <= 0 {
+		base = pollInterval
+	}
+	if max <= 0 {
+		max = _maxErrorBackoff
+	}
+	if max < base {
+		max = base
+	}
+	return &backoff{base: base, max: max}
+}
+
+func (b *backoff) next() time.Duration {
+	if b.current == 0 {
+		b.current = b.base
+		return b.current
 	}
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+	return b.current
+}
+
+func (b *backoff) reset() {
+	b.current = 0
 }
 
 func (f *FileLoader) Close() {
@@ -156,7 +425,7 @@ func (f *FileLoader) DebugHandler() http.Handler {
 		out := &InspectFileLoader{
 			ConfPath:         f.confPath,
 			ConfSHA256:       f.confSHA256,
-			OnChangeHandlers: int64(len(f.onChangeHandlers)),
+			OnChangeHandlers: int64(f.handlers.Len()),
 		}
 		rw.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(rw).Encode(out)
@@ -172,6 +441,23 @@ func (f *FileLoader) DebugHandler() http.Handler {
 		b, _ := protojson.Marshal(out)
 		_, _ = rw.Write(b)
 	})
+	debugMux.HandleFunc("/debug/config/validate", func(rw http.ResponseWriter, r *http.Request) {
+		out, err := f.load()
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte(err.Error()))
+			return
+		}
+		errs := Validate(out)
+		if errs == nil {
+			errs = []ValidationError{}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"valid":  len(errs) == 0,
+			"errors": errs,
+		})
+	})
 	debugMux.HandleFunc("/debug/config/version", func(rw http.ResponseWriter, r *http.Request) {
 		out, err := f.Load(context.Background())
 		if err != nil {
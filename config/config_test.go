@@ -2,11 +2,19 @@ package config
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 
 	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
 	corsv1 "github.com/go-kratos/gateway/api/gateway/middleware/cors/v1"
 	tracingv1 "github.com/go-kratos/gateway/api/gateway/middleware/tracing/v1"
+	_ "github.com/go-kratos/gateway/middleware/cors"
+	_ "github.com/go-kratos/gateway/middleware/logging"
+	_ "github.com/go-kratos/gateway/middleware/tracing"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -104,3 +112,122 @@ func TestFileLoader(t *testing.T) {
 		t.Errorf("inconsistent gateway config")
 	}
 }
+
+func TestFileLoaderFormats(t *testing.T) {
+	for _, confPath := range []string{"./fixtures/config.json", "./fixtures/config.toml"} {
+		fl := &FileLoader{
+			confPath: confPath,
+		}
+		cfg, err := fl.Load(context.TODO())
+		if err != nil {
+			t.Fatalf("%s: %v", confPath, err)
+		}
+		if !proto.Equal(cfg, equalTo()) {
+			t.Errorf("inconsistent gateway config of %s: %v", confPath, cfg)
+		}
+	}
+}
+
+func TestFileLoaderWatch(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(confPath, []byte("name: helloworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fl, err := NewFileLoader(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fl.Close()
+	changed := make(chan struct{}, 1)
+	fl.Watch(func() error {
+		changed <- struct{}{}
+		return nil
+	})
+	// wait for the watcher to start
+	time.Sleep(time.Millisecond * 100)
+	if err := ioutil.WriteFile(confPath, []byte("name: helloworld\nversion: v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(_pollInterval):
+		t.Fatal("the config change is not notified by fsnotify")
+	}
+}
+
+func TestFileLoaderReloadSignal(t *testing.T) {
+	confPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(confPath, []byte("name: helloworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fl, err := NewFileLoader(confPath, WithReloadSignals(syscall.SIGHUP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fl.Close()
+	changed := make(chan struct{}, 1)
+	fl.Watch(func() error {
+		changed <- struct{}{}
+		return nil
+	})
+	// wait for the watcher to start
+	time.Sleep(time.Millisecond * 100)
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("failed to send SIGHUP: %v", err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(_pollInterval):
+		t.Fatal("the reload is not triggered by SIGHUP")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(time.Second, 0, time.Second*5)
+	for _, want := range []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5} {
+		if got := b.next(); got != want {
+			t.Fatalf("want %s but got %s", want, got)
+		}
+	}
+	b.reset()
+	if got := b.next(); got != time.Second {
+		t.Fatalf("want %s after reset but got %s", time.Second, got)
+	}
+}
+
+func TestFileLoaderIncludes(t *testing.T) {
+	want := &configv1.Gateway{
+		Name:    "helloworld",
+		Version: "v2",
+		Middlewares: []*configv1.Middleware{
+			{Name: "logging"},
+		},
+		Endpoints: []*configv1.Endpoint{
+			{
+				Path:     "/helloworld.Greeter/*",
+				Method:   "POST",
+				Protocol: configv1.Protocol_GRPC,
+				Backends: []*configv1.Backend{{Target: "127.0.0.1:9000"}},
+			},
+			{
+				Path:     "/helloworld/*",
+				Protocol: configv1.Protocol_HTTP,
+				Backends: []*configv1.Backend{{Target: "127.0.0.1:8000"}},
+			},
+		},
+	}
+	for _, confPath := range []string{"./fixtures/includes/gateway.yaml", "./fixtures/includes/routes"} {
+		fl := &FileLoader{confPath: confPath}
+		cfg, err := fl.Load(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(cfg, want) {
+			t.Errorf("inconsistent gateway config of %s: %+v", confPath, cfg)
+		}
+	}
+}
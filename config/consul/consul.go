@@ -0,0 +1,223 @@
+package consul
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	_waitTime        = time.Minute * 5
+	_maxEditAttempts = 5
+)
+
+var (
+	_ config.ConfigLoader = (*consulLoader)(nil)
+	_ config.RouteWriter  = (*consulLoader)(nil)
+)
+
+func init() {
+	config.Register("consul", NewFromURI)
+}
+
+type consulLoader struct {
+	client      *api.Client
+	key         string
+	confSHA256  string
+	lastIndex   uint64
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// NewConsulLoader returns a config loader which loads the gateway config from
+// the consul KV key on the agent addr.
+func NewConsulLoader(addr, key string) (config.ConfigLoader, error) {
+	c := api.DefaultConfig()
+	c.Address = addr
+	client, err := api.NewClient(c)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, key)
+}
+
+// NewFromURI returns a config loader from the uri, e.g.
+// consul://127.0.0.1:8500/gateway/config?token=secret&datacenter=prod.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	c := api.DefaultConfig()
+	c.Address = uri.Host
+	if token := uri.Query().Get("token"); token != "" {
+		c.Token = token
+	}
+	if datacenter := uri.Query().Get("datacenter"); datacenter != "" {
+		c.Datacenter = datacenter
+	}
+	client, err := api.NewClient(c)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, strings.TrimPrefix(uri.Path, "/"))
+}
+
+// New returns a config loader which loads the gateway config from the consul KV key,
+// the OnChange handlers are triggered by the blocking queries.
+func New(client *api.Client, key string) (config.ConfigLoader, error) {
+	l := &consulLoader{
+		client: client,
+		key:    key,
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *consulLoader) initialize() error {
+	data, index, err := l.get(context.Background(), 0)
+	if err != nil {
+		return err
+	}
+	l.confSHA256 = sha256sum(data)
+	l.lastIndex = index
+	log.Infof("the initial consul config key: %s, index: %d, sha256: %s", l.key, index, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+// get reads the key, it blocks until the key index is greater than waitIndex
+// when the waitIndex is not zero.
+func (l *consulLoader) get(ctx context.Context, waitIndex uint64) ([]byte, uint64, error) {
+	opts := &api.QueryOptions{}
+	if waitIndex > 0 {
+		opts.WaitIndex = waitIndex
+		opts.WaitTime = _waitTime
+	}
+	pair, meta, err := l.client.KV().Get(l.key, opts.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	if pair == nil {
+		return nil, meta.LastIndex, fmt.Errorf("config key %q is not found in consul", l.key)
+	}
+	return pair.Value, meta.LastIndex, nil
+}
+
+func (l *consulLoader) Load(ctx context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from consul key: %s", l.key)
+	data, _, err := l.get(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	out, err := config.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *consulLoader) Watch(fn config.OnChange) {
+	log.Info("add consul config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *consulLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch consul config key: %s", l.key)
+	for {
+		data, index, err := l.get(ctx, l.lastIndex)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("watch consul config key: %s error: %+v", l.key, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		// see https://www.consul.io/api-docs/features/blocking#implementation-details
+		if index < l.lastIndex {
+			index = 0
+		}
+		l.lastIndex = index
+		sha256hex := sha256sum(data)
+		if sha256hex == l.confSHA256 {
+			continue
+		}
+		log.Infof("consul config changed, reload config, index: %d, last sha256: %s, new sha256: %s", index, l.confSHA256, sha256hex)
+		if err := l.handlers.Notify(); err != nil {
+			log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+			continue
+		}
+		l.confSHA256 = sha256hex
+	}
+}
+
+// AddEndpoint adds the endpoint to the config of the consul key, see
+// config.AddEndpointData.
+func (l *consulLoader) AddEndpoint(ctx context.Context, e *configv1.Endpoint) error {
+	return l.edit(ctx, func(data []byte) ([]byte, error) {
+		return config.AddEndpointData(data, e)
+	})
+}
+
+// RemoveEndpoint removes the endpoint of the key from the config of the consul key.
+func (l *consulLoader) RemoveEndpoint(ctx context.Context, key string) error {
+	return l.edit(ctx, func(data []byte) ([]byte, error) {
+		return config.RemoveEndpointData(data, key)
+	})
+}
+
+// edit rewrites the consul key by the check-and-set of its modify index, the
+// edit is retried on the concurrent modifications.
+func (l *consulLoader) edit(ctx context.Context, edit func([]byte) ([]byte, error)) error {
+	kv := l.client.KV()
+	for i := 0; i < _maxEditAttempts; i++ {
+		pair, _, err := kv.Get(l.key, (&api.QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if pair == nil {
+			return fmt.Errorf("config key %q is not found in consul", l.key)
+		}
+		data, err := edit(pair.Value)
+		if err != nil {
+			return err
+		}
+		ok, _, err := kv.CAS(&api.KVPair{Key: l.key, Flags: pair.Flags, Value: data, ModifyIndex: pair.ModifyIndex}, (&api.WriteOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		log.Warnf("consul config key: %s is modified concurrently, edit again", l.key)
+	}
+	return fmt.Errorf("consul config key %q is modified concurrently", l.key)
+}
+
+func (l *consulLoader) Close() {
+	l.watchCancel()
+}
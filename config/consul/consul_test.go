@@ -0,0 +1,287 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/hashicorp/consul/api"
+
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_key    = "gateway/config"
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_restoredConfig = `
+name: helloworld
+version: v3
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+// fakeConsul is the consul KV of the blocking queries, the query of an index
+// blocks until the index of the KV is changed.
+type fakeConsul struct {
+	lock    sync.Mutex
+	index   uint64
+	pairs   map[string]*api.KVPair
+	changed chan struct{}
+}
+
+func newFakeConsul(t *testing.T) (*fakeConsul, *api.Client) {
+	f := &fakeConsul{index: 1, pairs: map[string]*api.KVPair{}, changed: make(chan struct{})}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c := api.DefaultConfig()
+	c.Address = srv.URL
+	client, err := api.NewClient(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+// set sets the value of the key at the index, the index is increased if it's zero.
+func (f *fakeConsul) set(key, value string, index uint64) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.setLocked(key, value, index)
+}
+
+func (f *fakeConsul) setLocked(key, value string, index uint64) {
+	if index == 0 {
+		index = f.index + 1
+	}
+	f.index = index
+	if value == "" {
+		delete(f.pairs, key)
+	} else {
+		f.pairs[key] = &api.KVPair{Key: key, Value: []byte(value), ModifyIndex: index}
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		f.put(w, r, key)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	for {
+		f.lock.Lock()
+		index, pair, changed := f.index, f.pairs[key], f.changed
+		f.lock.Unlock()
+		// see https://www.consul.io/api-docs/features/blocking
+		if waitIndex == 0 || index != waitIndex {
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+			w.Header().Set("X-Consul-LastContact", "0")
+			w.Header().Set("X-Consul-KnownLeader", "true")
+			if pair == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]*api.KVPair{pair})
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// put sets the key by the check-and-set of the modify index if cas is set.
+func (f *fakeConsul) put(w http.ResponseWriter, r *http.Request, key string) {
+	value, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if cas := r.URL.Query().Get("cas"); cas != "" {
+		var index uint64
+		if pair, ok := f.pairs[key]; ok {
+			index = pair.ModifyIndex
+		}
+		if cas != strconv.FormatUint(index, 10) {
+			_, _ = w.Write([]byte("false"))
+			return
+		}
+	}
+	f.setLocked(key, string(value), 0)
+	_, _ = w.Write([]byte("true"))
+}
+
+func waitNotified(t *testing.T, notified chan struct{}) {
+	t.Helper()
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+}
+
+func TestConsulLoader(t *testing.T) {
+	f, client := newFakeConsul(t)
+	if _, err := New(client, _key); err == nil {
+		t.Fatal("want the error of the key not found")
+	}
+	f.set(_key, _config, 0)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 1 || len(c.Middlewares) != 1 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	// the change of the other keys and the same config are not notified.
+	f.set("other", "value", 0)
+	f.set(_key, _config, 0)
+	select {
+	case <-notified:
+		t.Fatal("the unchanged config must not be notified")
+	case <-time.After(time.Millisecond * 100):
+	}
+	f.set(_key, _changedConfig, 0)
+	waitNotified(t, notified)
+	if c, err = loader.Load(context.Background()); err != nil || c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %+v %v", c, err)
+	}
+}
+
+func TestConsulLoaderIndexReset(t *testing.T) {
+	f, client := newFakeConsul(t)
+	f.set(_key, _config, 100)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+
+	// the index goes backwards, e.g. the KV is restored from a snapshot, the
+	// watch is reset instead of blocking on the last index.
+	f.set(_key, _restoredConfig, 10)
+	waitNotified(t, notified)
+	c, err := loader.Load(context.Background())
+	if err != nil || c.Version != "v3" {
+		t.Fatalf("want version v3 but got: %+v %v", c, err)
+	}
+	f.set(_key, _changedConfig, 0)
+	waitNotified(t, notified)
+	if c, err = loader.Load(context.Background()); err != nil || c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %+v %v", c, err)
+	}
+}
+
+func TestConsulRouteWriter(t *testing.T) {
+	f, client := newFakeConsul(t)
+	f.set(_key, _config, 0)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	r := config.NewReloader(loader, func(c *configv1.Gateway) error { return nil })
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	stored := func() *configv1.Gateway {
+		pair, _, err := client.KV().Get(_key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Unmarshal(pair.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	endpoint := &configv1.Endpoint{Path: "/portal/*", Method: "GET", Backends: []*configv1.Backend{{Target: "127.0.0.1:9000"}}}
+	persisted, err := r.AddEndpoint(endpoint, "portal")
+	if err != nil || !persisted {
+		t.Fatalf("want the endpoint persisted but got: %t %v", persisted, err)
+	}
+	if c := stored(); len(c.Endpoints) != 2 || c.Endpoints[1].Path != "/portal/*" || c.Name != "helloworld" || len(c.Middlewares) != 1 {
+		t.Fatalf("want the endpoint added to the consul key but got: %v", c)
+	}
+	if persisted, err = r.RemoveEndpoint(config.EndpointKey(endpoint), "portal"); err != nil || !persisted {
+		t.Fatalf("want the removal persisted but got: %t %v", persisted, err)
+	}
+	if c := stored(); len(c.Endpoints) != 1 || c.Endpoints[0].Path != "/helloworld/*" {
+		t.Fatalf("want the endpoint removed from the consul key but got: %v", c)
+	}
+
+	// the key is modified between the read and the write of the first attempt.
+	var attempts int
+	err = loader.(*consulLoader).edit(context.Background(), func(data []byte) ([]byte, error) {
+		attempts++
+		if attempts == 1 {
+			f.set(_key, _changedConfig, 0)
+		}
+		return append(data, "# edited\n"...), nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("want the edit retried but got: %d %v", attempts, err)
+	}
+	if pair, _, err := client.KV().Get(_key, nil); err != nil || string(pair.Value) != _changedConfig+"# edited\n" {
+		t.Fatalf("want the edit of the changed config but got: %v %v", pair, err)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// GRPCPath returns the path of the gRPC route, e.g. /helloworld.Greeter/SayHello.
+func GRPCPath(r *configv1.GrpcRoute) string {
+	if r.Method == "" {
+		return "/" + r.Service + "/*"
+	}
+	return "/" + r.Service + "/" + r.Method
+}
+
+// ApplyDefaults sets the unset fields of the endpoints by the defaults of the
+// gateway config, the endpoints without middlewares inherit the default ones.
+// The paths and the protocol of the gRPC routes are set before the defaults,
+// and the fallback endpoint inherits the defaults either.
+func ApplyDefaults(c *configv1.Gateway) {
+	if c.Fallback != nil && c.Fallback.Path == "" {
+		c.Fallback.Path = "/*"
+	}
+	for _, e := range c.Endpoints {
+		if e.Grpc == nil {
+			continue
+		}
+		if e.Path == "" {
+			e.Path = GRPCPath(e.Grpc)
+		}
+		if e.Protocol == configv1.Protocol_UNSPECIFIED {
+			e.Protocol = configv1.Protocol_GRPC
+		}
+	}
+	d := c.Defaults
+	if d == nil {
+		return
+	}
+	endpoints := c.Endpoints
+	if c.Fallback != nil {
+		endpoints = append(endpoints[:len(endpoints):len(endpoints)], c.Fallback)
+	}
+	for _, e := range endpoints {
+		if e.Protocol == configv1.Protocol_UNSPECIFIED {
+			e.Protocol = d.Protocol
+		}
+		if e.Timeout == nil && d.Timeout != nil {
+			e.Timeout = proto.Clone(d.Timeout).(*durationpb.Duration)
+		}
+		if e.ResponseHeaderTimeout == nil && d.ResponseHeaderTimeout != nil {
+			e.ResponseHeaderTimeout = proto.Clone(d.ResponseHeaderTimeout).(*durationpb.Duration)
+		}
+		if e.IdleTimeout == nil && d.IdleTimeout != nil {
+			e.IdleTimeout = proto.Clone(d.IdleTimeout).(*durationpb.Duration)
+		}
+		if e.Retry == nil && d.Retry != nil {
+			e.Retry = proto.Clone(d.Retry).(*configv1.Retry)
+		}
+		if len(e.Middlewares) == 0 && len(d.Middlewares) > 0 {
+			e.Middlewares = make([]*configv1.Middleware, 0, len(d.Middlewares))
+			for _, m := range d.Middlewares {
+				e.Middlewares = append(e.Middlewares, proto.Clone(m).(*configv1.Middleware))
+			}
+		}
+		for k, v := range d.Metadata {
+			if _, ok := e.Metadata[k]; ok {
+				continue
+			}
+			if e.Metadata == nil {
+				e.Metadata = make(map[string]string, len(d.Metadata))
+			}
+			e.Metadata[k] = v
+		}
+	}
+}
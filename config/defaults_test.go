@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("routes.yaml", `
+endpoints:
+  - path: /included/*
+    backends:
+      - target: 127.0.0.1:8002
+`)
+	confPath := write("config.yaml", `
+name: gateway
+includes:
+  - routes.yaml
+defaults:
+  protocol: HTTP
+  timeout: 1s
+  retry:
+    attempts: 3
+  middlewares:
+    - name: logging
+  metadata:
+    team: gateway
+    tier: web
+endpoints:
+  - path: /inherited/*
+    backends:
+      - target: 127.0.0.1:8000
+  - path: /overridden/*
+    protocol: GRPC
+    timeout: 5s
+    middlewares: []
+    metadata:
+      tier: api
+    backends:
+      - target: 127.0.0.1:8001
+`)
+	c, err := LoadFile(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Endpoints) != 3 {
+		t.Fatalf("want 3 endpoints but got %d", len(c.Endpoints))
+	}
+	for _, e := range []*configv1.Endpoint{c.Endpoints[0], c.Endpoints[2]} {
+		if e.Protocol != configv1.Protocol_HTTP || e.Timeout.AsDuration() != time.Second ||
+			e.Retry.GetAttempts() != 3 || len(e.Middlewares) != 1 || e.Metadata["tier"] != "web" {
+			t.Errorf("want the defaults to be inherited by %s but got %+v", e.Path, e)
+		}
+	}
+	overridden := c.Endpoints[1]
+	if overridden.Protocol != configv1.Protocol_GRPC || overridden.Timeout.AsDuration() != 5*time.Second ||
+		overridden.Metadata["tier"] != "api" || overridden.Metadata["team"] != "gateway" {
+		t.Errorf("want the defaults to be overridden but got %+v", overridden)
+	}
+	// the inherited fields are not shared between the endpoints.
+	c.Endpoints[0].Retry.Attempts = 1
+	if c.Endpoints[2].Retry.Attempts != 3 {
+		t.Errorf("want the retry of each endpoint to be copied")
+	}
+
+	loader, err := NewFileLoader(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	if _, err := loader.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyDefaultsGRPC(t *testing.T) {
+	c, err := Unmarshal([]byte(`
+defaults:
+  protocol: HTTP
+  timeout: 1s
+endpoints:
+  - grpc:
+      service: helloworld.Greeter
+    backends:
+      - target: 127.0.0.1:9000
+  - grpc:
+      service: helloworld.Greeter
+      method: SayHello
+    timeout: 0.1s
+    retry:
+      attempts: 3
+    backends:
+      - target: 127.0.0.1:9000
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ApplyDefaults(c)
+	service, method := c.Endpoints[0], c.Endpoints[1]
+	if service.Path != "/helloworld.Greeter/*" || service.Protocol != configv1.Protocol_GRPC || service.Timeout.AsDuration() != time.Second {
+		t.Errorf("unexpected service route: %+v", service)
+	}
+	if method.Path != "/helloworld.Greeter/SayHello" || method.Protocol != configv1.Protocol_GRPC || method.Timeout.AsDuration() != 100*time.Millisecond {
+		t.Errorf("unexpected method route: %+v", method)
+	}
+	if errs := Validate(c); len(errs) > 0 {
+		t.Errorf("want valid config but got: %v", errs)
+	}
+	method.Path = "/helloworld.Greeter/*"
+	if errs := Validate(c); len(errs) != 2 || errs[0].Field != "endpoints[1].path" {
+		t.Errorf("want the path conflict but got: %v", errs)
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/router/matcher"
+	"google.golang.org/protobuf/proto"
+)
+
+// OnChangeV2 is called with the previous and the current config with their diff
+// after the current config is applied, the previous config is nil on the first load.
+type OnChangeV2 func(old, current *configv1.Gateway, diff *Diff) error
+
+// Diff is the difference between two gateway configs, the endpoints are matched by EndpointKey.
+type Diff struct {
+	// Added is the endpoints only in the current config.
+	Added []*configv1.Endpoint
+	// Removed is the endpoints only in the old config.
+	Removed []*configv1.Endpoint
+	// Updated is the endpoints changed in the current config.
+	Updated []*configv1.Endpoint
+	// Middlewares reports whether the global middlewares are changed,
+	// all the endpoints should be rebuilt if it's true.
+	Middlewares bool
+	// Metadata reports whether the name, version or hosts are changed.
+	Metadata bool
+	// Settings reports whether the fallback endpoint, the TLS, the trusted
+	// proxies or the consumers are changed.
+	Settings bool
+}
+
+// EndpointKey returns the identity of an endpoint in the router.
+func EndpointKey(e *configv1.Endpoint) string {
+	key := strings.Join([]string{e.Host, strings.ToUpper(e.Method), e.Path}, " ")
+	if m := matcher.Key(e); m != "" {
+		key += " " + m
+	}
+	return key
+}
+
+// ComputeDiff returns the diff from the old to the current config, the old config may be nil.
+func ComputeDiff(old, current *configv1.Gateway) *Diff {
+	if old == nil {
+		old = &configv1.Gateway{}
+	}
+	d := &Diff{
+		Metadata: old.Name != current.Name || old.Version != current.Version ||
+			!equalStrings(old.Hosts, current.Hosts), //nolint:staticcheck
+		Middlewares: !equalMiddlewares(old.Middlewares, current.Middlewares),
+		Settings: !proto.Equal(old.Fallback, current.Fallback) || !proto.Equal(old.Tls, current.Tls) || !proto.Equal(old.Tracing, current.Tracing) ||
+			!equalStrings(old.TrustedProxies, current.TrustedProxies) || !equalConsumers(old.Consumers, current.Consumers),
+	}
+	olds := make(map[string]*configv1.Endpoint, len(old.Endpoints))
+	for _, e := range old.Endpoints {
+		olds[EndpointKey(e)] = e
+	}
+	for _, e := range current.Endpoints {
+		key := EndpointKey(e)
+		o, ok := olds[key]
+		if !ok {
+			d.Added = append(d.Added, e)
+			continue
+		}
+		delete(olds, key)
+		if !proto.Equal(o, e) {
+			d.Updated = append(d.Updated, e)
+		}
+	}
+	// keep the removed endpoints in the order of the old config.
+	for _, e := range old.Endpoints {
+		if _, ok := olds[EndpointKey(e)]; ok {
+			d.Removed = append(d.Removed, e)
+		}
+	}
+	return d
+}
+
+// Empty reports whether there is no difference.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0 && !d.Middlewares && !d.Metadata && !d.Settings
+}
+
+func (d *Diff) String() string {
+	return fmt.Sprintf("added: %d, removed: %d, updated: %d, middlewares changed: %t, metadata changed: %t, settings changed: %t",
+		len(d.Added), len(d.Removed), len(d.Updated), d.Middlewares, d.Metadata, d.Settings)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMiddlewares(a, b []*configv1.Middleware) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalConsumers(a, b []*configv1.Consumer) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
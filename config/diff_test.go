@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+func TestComputeDiff(t *testing.T) {
+	old := &configv1.Gateway{
+		Name: "helloworld",
+		Endpoints: []*configv1.Endpoint{
+			{Path: "/foo", Method: "GET"},
+			{Path: "/bar", Method: "GET"},
+			{Path: "/baz"},
+		},
+		Middlewares: []*configv1.Middleware{{Name: "logging"}},
+	}
+	if d := ComputeDiff(old, old); !d.Empty() {
+		t.Fatalf("want empty diff but got: %s", d)
+	}
+
+	current := &configv1.Gateway{
+		Name: "helloworld",
+		Endpoints: []*configv1.Endpoint{
+			{Path: "/baz", Description: "changed"},
+			{Path: "/foo", Method: "get"},
+			{Path: "/qux"},
+		},
+		Middlewares: []*configv1.Middleware{{Name: "logging"}},
+	}
+	d := ComputeDiff(old, current)
+	if d.Middlewares || d.Metadata || d.Settings {
+		t.Fatalf("unexpected diff: %s", d)
+	}
+	if len(d.Added) != 1 || d.Added[0].Path != "/qux" {
+		t.Fatalf("unexpected added: %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Path != "/bar" {
+		t.Fatalf("unexpected removed: %+v", d.Removed)
+	}
+	// the method is matched case-insensitively but the endpoint is changed.
+	if len(d.Updated) != 2 || d.Updated[0].Path != "/baz" || d.Updated[1].Path != "/foo" {
+		t.Fatalf("unexpected updated: %+v", d.Updated)
+	}
+
+	current.Middlewares = nil
+	current.Version = "v2"
+	d = ComputeDiff(old, current)
+	if !d.Middlewares || !d.Metadata {
+		t.Fatalf("unexpected diff: %s", d)
+	}
+
+	settings := &configv1.Gateway{Name: "helloworld", Endpoints: old.Endpoints, Middlewares: old.Middlewares}
+	settings.Fallback = &configv1.Endpoint{DirectResponse: &configv1.DirectResponse{StatusCode: 404}}
+	if d = ComputeDiff(old, settings); !d.Settings || d.Empty() {
+		t.Fatalf("want the fallback change in the diff but got: %s", d)
+	}
+	settings.Fallback = nil
+	settings.TrustedProxies = []string{"10.0.0.0/8"}
+	if d = ComputeDiff(old, settings); !d.Settings || d.Empty() {
+		t.Fatalf("want the trusted proxies change in the diff but got: %s", d)
+	}
+
+	d = ComputeDiff(nil, old)
+	if len(d.Added) != 3 || !d.Middlewares || !d.Metadata {
+		t.Fatalf("unexpected diff from nil: %s", d)
+	}
+}
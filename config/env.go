@@ -0,0 +1,25 @@
+package config
+
+import (
+	"os"
+	"regexp"
+)
+
+// _envPattern matches `${NAME}` and `${NAME:-default}`, `$${...}` is escaped.
+var _envPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces `${NAME}` with the value of the environment variable NAME,
+// and `${NAME:-default}` with `default` when NAME is unset or empty.
+func expandEnv(in []byte) []byte {
+	return _envPattern.ReplaceAllFunc(in, func(match []byte) []byte {
+		if match[1] == '$' {
+			// $${NAME} -> ${NAME}
+			return match[1:]
+		}
+		parts := _envPattern.FindSubmatch(match)
+		if v := os.Getenv(string(parts[1])); v != "" {
+			return []byte(v)
+		}
+		return parts[3]
+	})
+}
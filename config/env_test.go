@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("GATEWAY_TEST_HOST", "127.0.0.1")
+	os.Setenv("GATEWAY_TEST_EMPTY", "")
+	defer os.Unsetenv("GATEWAY_TEST_HOST")
+	defer os.Unsetenv("GATEWAY_TEST_EMPTY")
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "target: '${GATEWAY_TEST_HOST}:8000'", want: "target: '127.0.0.1:8000'"},
+		{in: "target: '${GATEWAY_TEST_UNSET}'", want: "target: ''"},
+		{in: "target: '${GATEWAY_TEST_UNSET:-localhost}'", want: "target: 'localhost'"},
+		{in: "target: '${GATEWAY_TEST_EMPTY:-localhost}'", want: "target: 'localhost'"},
+		{in: "target: '${GATEWAY_TEST_HOST:-localhost}'", want: "target: '127.0.0.1'"},
+		{in: "path: '$${GATEWAY_TEST_HOST}'", want: "path: '${GATEWAY_TEST_HOST}'"},
+		{in: "path: '/api/$version'", want: "path: '/api/$version'"},
+	}
+	for _, test := range tests {
+		if got := string(expandEnv([]byte(test.in))); got != test.want {
+			t.Errorf("expand %q: want %q but got %q", test.in, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package etcd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const _maxEditAttempts = 5
+
+var (
+	_ config.ConfigLoader = (*etcdLoader)(nil)
+	_ config.RouteWriter  = (*etcdLoader)(nil)
+)
+
+func init() {
+	config.Register("etcd", NewFromURI)
+}
+
+type etcdLoader struct {
+	client      *clientv3.Client
+	key         string
+	confSHA256  string
+	revision    int64
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// New returns a config loader which loads the gateway config from the etcd key,
+// the OnChange handlers are triggered by the etcd watch events.
+func New(client *clientv3.Client, key string) (config.ConfigLoader, error) {
+	l := &etcdLoader{
+		client: client,
+		key:    key,
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// NewFromURI returns a config loader from the uri, e.g.
+// etcd://127.0.0.1:2379,127.0.0.2:2379/gateway/config?username=root&password=secret.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	c := clientv3.Config{
+		Endpoints:   strings.Split(uri.Host, ","),
+		DialTimeout: 5 * time.Second,
+		Username:    uri.Query().Get("username"),
+		Password:    uri.Query().Get("password"),
+	}
+	client, err := clientv3.New(c)
+	if err != nil {
+		return nil, err
+	}
+	l, err := New(client, uri.Path)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *etcdLoader) initialize() error {
+	data, revision, err := l.get(context.Background())
+	if err != nil {
+		return err
+	}
+	l.confSHA256 = sha256sum(data)
+	l.revision = revision
+	log.Infof("the initial etcd config key: %s, revision: %d, sha256: %s", l.key, revision, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+func (l *etcdLoader) get(ctx context.Context) ([]byte, int64, error) {
+	resp, err := l.client.Get(ctx, l.key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, fmt.Errorf("config key %q is not found in etcd", l.key)
+	}
+	return resp.Kvs[0].Value, resp.Header.Revision, nil
+}
+
+func (l *etcdLoader) Load(ctx context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from etcd key: %s", l.key)
+	data, _, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := config.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *etcdLoader) Watch(fn config.OnChange) {
+	log.Info("add etcd config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *etcdLoader) onChange(value []byte, revision int64) {
+	sha256hex := sha256sum(value)
+	if sha256hex == l.confSHA256 {
+		return
+	}
+	log.Infof("etcd config changed, reload config, revision: %d, last sha256: %s, new sha256: %s", revision, l.confSHA256, sha256hex)
+	if err := l.handlers.Notify(); err != nil {
+		log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+		return
+	}
+	l.confSHA256 = sha256hex
+}
+
+func (l *etcdLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch etcd config key: %s", l.key)
+	for {
+		wch := l.client.Watch(clientv3.WithRequireLeader(ctx), l.key, clientv3.WithRev(l.revision+1))
+		for resp := range wch {
+			if resp.CompactRevision > 0 {
+				// the events between the last seen and the compacted revision are lost,
+				// compare the current value instead.
+				log.Warnf("watch etcd config key: %s has been compacted at revision: %d", l.key, resp.CompactRevision)
+				data, revision, err := l.get(ctx)
+				if err != nil {
+					log.Errorf("failed to get etcd config key: %s after compaction: %+v", l.key, err)
+					continue
+				}
+				l.revision = revision
+				l.onChange(data, revision)
+				continue
+			}
+			if err := resp.Err(); err != nil {
+				log.Errorf("watch etcd config key: %s error: %+v", l.key, err)
+				continue
+			}
+			for _, ev := range resp.Events {
+				l.revision = ev.Kv.ModRevision
+				if ev.Type != mvccpb.PUT {
+					log.Warnf("etcd config key: %s has been deleted, keep the current config", l.key)
+					continue
+				}
+				l.onChange(ev.Kv.Value, ev.Kv.ModRevision)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+			log.Warnf("the etcd watch channel on key: %s is closed, the watch process will attempt again", l.key)
+		}
+	}
+}
+
+// AddEndpoint adds the endpoint to the config of the etcd key, see
+// config.AddEndpointData.
+func (l *etcdLoader) AddEndpoint(ctx context.Context, e *configv1.Endpoint) error {
+	return l.edit(ctx, func(data []byte) ([]byte, error) {
+		return config.AddEndpointData(data, e)
+	})
+}
+
+// RemoveEndpoint removes the endpoint of the key from the config of the etcd key.
+func (l *etcdLoader) RemoveEndpoint(ctx context.Context, key string) error {
+	return l.edit(ctx, func(data []byte) ([]byte, error) {
+		return config.RemoveEndpointData(data, key)
+	})
+}
+
+// edit rewrites the etcd key if it's not modified since read, the edit is
+// retried on the concurrent modifications.
+func (l *etcdLoader) edit(ctx context.Context, edit func([]byte) ([]byte, error)) error {
+	for i := 0; i < _maxEditAttempts; i++ {
+		resp, err := l.client.Get(ctx, l.key)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			return fmt.Errorf("config key %q is not found in etcd", l.key)
+		}
+		kv := resp.Kvs[0]
+		data, err := edit(kv.Value)
+		if err != nil {
+			return err
+		}
+		txn, err := l.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(l.key), "=", kv.ModRevision)).
+			Then(clientv3.OpPut(l.key, string(data))).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if txn.Succeeded {
+			return nil
+		}
+		log.Warnf("etcd config key: %s is modified concurrently, edit again", l.key)
+	}
+	return fmt.Errorf("etcd config key %q is modified concurrently", l.key)
+}
+
+func (l *etcdLoader) Close() {
+	l.watchCancel()
+}
@@ -0,0 +1,479 @@
+package etcd
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_key    = "/gateway/config"
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_compactedConfig = `
+name: helloworld
+version: v3
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+// fakeEtcd is the etcd server of the KV and Watch services of a single
+// member, the revisions before the compacted one are not watched.
+type fakeEtcd struct {
+	pb.UnimplementedKVServer
+	pb.UnimplementedWatchServer
+
+	lock      sync.Mutex
+	revision  int64
+	compacted int64
+	kvs       map[string]*mvccpb.KeyValue
+	events    []*mvccpb.Event
+	// changed is closed and replaced on each revision.
+	changed chan struct{}
+	// down rejects the watch streams, the connected streams are closed.
+	down     bool
+	streamID int
+	streams  map[int]context.CancelFunc
+}
+
+func newFakeEtcd(t *testing.T) (*fakeEtcd, *clientv3.Client) {
+	f := &fakeEtcd{
+		revision: 1,
+		kvs:      map[string]*mvccpb.KeyValue{},
+		changed:  make(chan struct{}),
+		streams:  map[int]context.CancelFunc{},
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterKVServer(srv, f)
+	pb.RegisterWatchServer(srv, f)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{lis.Addr().String()}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return f, client
+}
+
+func (f *fakeEtcd) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{ClusterId: 1, MemberId: 1, Revision: f.revision}
+}
+
+func (f *fakeEtcd) put(key string, value []byte) {
+	f.revision++
+	kv := &mvccpb.KeyValue{Key: []byte(key), Value: value, CreateRevision: f.revision, ModRevision: f.revision, Version: 1}
+	if old, ok := f.kvs[key]; ok {
+		kv.CreateRevision = old.CreateRevision
+		kv.Version = old.Version + 1
+	}
+	f.kvs[key] = kv
+	f.events = append(f.events, &mvccpb.Event{Type: mvccpb.PUT, Kv: kv})
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeEtcd) delete(key string) int64 {
+	if _, ok := f.kvs[key]; !ok {
+		return 0
+	}
+	f.revision++
+	delete(f.kvs, key)
+	f.events = append(f.events, &mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: f.revision}})
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return 1
+}
+
+func (f *fakeEtcd) Range(_ context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	resp := &pb.RangeResponse{Header: f.header()}
+	if kv, ok := f.kvs[string(req.Key)]; ok {
+		resp.Kvs = []*mvccpb.KeyValue{kv}
+		resp.Count = 1
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Put(_ context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.put(string(req.Key), req.Value)
+	return &pb.PutResponse{Header: f.header()}, nil
+}
+
+func (f *fakeEtcd) DeleteRange(_ context.Context, req *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	deleted := f.delete(string(req.Key))
+	return &pb.DeleteRangeResponse{Header: f.header(), Deleted: deleted}, nil
+}
+
+// Txn supports the compares of the mod revisions and the puts.
+func (f *fakeEtcd) Txn(_ context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	succeeded := true
+	for _, c := range req.Compare {
+		if c.Target != pb.Compare_MOD || c.Result != pb.Compare_EQUAL {
+			return nil, status.Error(codes.Unimplemented, "unsupported compare")
+		}
+		var revision int64
+		if kv, ok := f.kvs[string(c.Key)]; ok {
+			revision = kv.ModRevision
+		}
+		succeeded = succeeded && revision == c.GetModRevision()
+	}
+	ops := req.Success
+	if !succeeded {
+		ops = req.Failure
+	}
+	resp := &pb.TxnResponse{Succeeded: succeeded}
+	for _, op := range ops {
+		put := op.GetRequestPut()
+		if put == nil {
+			return nil, status.Error(codes.Unimplemented, "unsupported txn op")
+		}
+		f.put(string(put.Key), put.Value)
+		resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponsePut{ResponsePut: &pb.PutResponse{Header: f.header()}}})
+	}
+	resp.Header = f.header()
+	return resp, nil
+}
+
+func (f *fakeEtcd) Compact(_ context.Context, req *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.compacted = req.Revision
+	var events []*mvccpb.Event
+	for _, ev := range f.events {
+		if ev.Kv.ModRevision >= req.Revision {
+			events = append(events, ev)
+		}
+	}
+	f.events = events
+	return &pb.CompactionResponse{Header: f.header()}, nil
+}
+
+// setDown closes the watch streams and rejects the new ones until it's up.
+func (f *fakeEtcd) setDown(down bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.down = down
+	if down {
+		for _, cancel := range f.streams {
+			cancel()
+		}
+	}
+}
+
+func (f *fakeEtcd) Watch(stream pb.Watch_WatchServer) error {
+	f.lock.Lock()
+	if f.down {
+		f.lock.Unlock()
+		return status.Error(codes.Unavailable, "etcd is down")
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	f.streamID++
+	id := f.streamID
+	f.streams[id] = cancel
+	f.lock.Unlock()
+	defer func() {
+		f.lock.Lock()
+		delete(f.streams, id)
+		f.lock.Unlock()
+		cancel()
+	}()
+
+	var (
+		sendLock sync.Mutex
+		watchID  int64
+		wg       sync.WaitGroup
+		closed   bool
+	)
+	send := func(resp *pb.WatchResponse) error {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		return stream.Send(resp)
+	}
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				cancel()
+				return
+			}
+			create := req.GetCreateRequest()
+			if create == nil {
+				continue
+			}
+			watchID++
+			f.lock.Lock()
+			header := f.header()
+			f.lock.Unlock()
+			if err := send(&pb.WatchResponse{Header: header, WatchId: watchID, Created: true}); err != nil {
+				return
+			}
+			sendLock.Lock()
+			if closed {
+				sendLock.Unlock()
+				return
+			}
+			wg.Add(1)
+			sendLock.Unlock()
+			go func(id int64) {
+				defer wg.Done()
+				f.watch(ctx, id, create, send)
+			}(watchID)
+		}
+	}()
+	<-ctx.Done()
+	// the watches must not send after the stream is closed.
+	sendLock.Lock()
+	closed = true
+	sendLock.Unlock()
+	wg.Wait()
+	if stream.Context().Err() == nil {
+		return status.Error(codes.Unavailable, "etcd is down")
+	}
+	return nil
+}
+
+func (f *fakeEtcd) watch(ctx context.Context, id int64, req *pb.WatchCreateRequest, send func(*pb.WatchResponse) error) {
+	next := req.StartRevision
+	for {
+		f.lock.Lock()
+		if next == 0 {
+			next = f.revision + 1
+		}
+		if next < f.compacted {
+			resp := &pb.WatchResponse{Header: f.header(), WatchId: id, CompactRevision: f.compacted, Canceled: true}
+			f.lock.Unlock()
+			_ = send(resp)
+			return
+		}
+		resp := &pb.WatchResponse{Header: f.header(), WatchId: id}
+		for _, ev := range f.events {
+			if ev.Kv.ModRevision >= next && string(ev.Kv.Key) == string(req.Key) {
+				resp.Events = append(resp.Events, ev)
+			}
+		}
+		next = f.revision + 1
+		changed := f.changed
+		f.lock.Unlock()
+		if len(resp.Events) > 0 {
+			if err := send(resp); err != nil {
+				return
+			}
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *fakeEtcd) set(key, value string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.put(key, []byte(value))
+}
+
+func waitNotified(t *testing.T, notified chan struct{}) {
+	t.Helper()
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+}
+
+func TestEtcdLoader(t *testing.T) {
+	f, client := newFakeEtcd(t)
+	if _, err := New(client, _key); err == nil {
+		t.Fatal("want the error of the key not found")
+	}
+	f.set(_key, _config)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 1 || len(c.Middlewares) != 1 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	// the same config and the deletion are not notified.
+	f.set(_key, _config)
+	f.lock.Lock()
+	f.delete(_key)
+	f.lock.Unlock()
+	select {
+	case <-notified:
+		t.Fatal("the unchanged config must not be notified")
+	case <-time.After(time.Millisecond * 100):
+	}
+	f.set(_key, _changedConfig)
+	waitNotified(t, notified)
+	if c, err = loader.Load(context.Background()); err != nil || c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %+v %v", c, err)
+	}
+}
+
+func TestEtcdLoaderCompacted(t *testing.T) {
+	f, client := newFakeEtcd(t)
+	f.set(_key, _config)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+
+	// the changes are compacted while the watch is disconnected, the watch
+	// resumes from the compacted revision and the current value is loaded.
+	f.setDown(true)
+	f.set(_key, _changedConfig)
+	f.set(_key, _compactedConfig)
+	f.lock.Lock()
+	revision := f.revision
+	f.lock.Unlock()
+	if _, err := client.Compact(context.Background(), revision); err != nil {
+		t.Fatal(err)
+	}
+	f.setDown(false)
+	waitNotified(t, notified)
+	c, err := loader.Load(context.Background())
+	if err != nil || c.Version != "v3" {
+		t.Fatalf("want version v3 but got: %+v %v", c, err)
+	}
+
+	// the watch is recreated from the current revision.
+	f.set(_key, _changedConfig)
+	waitNotified(t, notified)
+	if c, err = loader.Load(context.Background()); err != nil || c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %+v %v", c, err)
+	}
+}
+
+func TestEtcdRouteWriter(t *testing.T) {
+	f, client := newFakeEtcd(t)
+	f.set(_key, _config)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	r := config.NewReloader(loader, func(c *configv1.Gateway) error { return nil })
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	stored := func() *configv1.Gateway {
+		resp, err := client.Get(context.Background(), _key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Unmarshal(resp.Kvs[0].Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	endpoint := &configv1.Endpoint{Path: "/portal/*", Method: "GET", Backends: []*configv1.Backend{{Target: "127.0.0.1:9000"}}}
+	persisted, err := r.AddEndpoint(endpoint, "portal")
+	if err != nil || !persisted {
+		t.Fatalf("want the endpoint persisted but got: %t %v", persisted, err)
+	}
+	if c := stored(); len(c.Endpoints) != 2 || c.Endpoints[1].Path != "/portal/*" || c.Name != "helloworld" || len(c.Middlewares) != 1 {
+		t.Fatalf("want the endpoint added to the etcd key but got: %v", c)
+	}
+	if persisted, err = r.RemoveEndpoint(config.EndpointKey(endpoint), "portal"); err != nil || !persisted {
+		t.Fatalf("want the removal persisted but got: %t %v", persisted, err)
+	}
+	if c := stored(); len(c.Endpoints) != 1 || c.Endpoints[0].Path != "/helloworld/*" {
+		t.Fatalf("want the endpoint removed from the etcd key but got: %v", c)
+	}
+}
+
+func TestEtcdEditConflict(t *testing.T) {
+	f, client := newFakeEtcd(t)
+	f.set(_key, _config)
+	loader, err := New(client, _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	// the key is modified between the read and the write of the first attempt.
+	var attempts int
+	err = loader.(*etcdLoader).edit(context.Background(), func(data []byte) ([]byte, error) {
+		attempts++
+		if attempts == 1 {
+			f.set(_key, _changedConfig)
+		}
+		return append(data, "# edited\n"...), nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("want the edit retried but got: %d %v", attempts, err)
+	}
+	resp, err := client.Get(context.Background(), _key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Kvs[0].Value) != _changedConfig+"# edited\n" {
+		t.Fatalf("want the edit of the changed config but got: %s", resp.Kvs[0].Value)
+	}
+}
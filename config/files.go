@@ -0,0 +1,165 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+var _configExts = map[string]struct{}{
+	".yaml": {},
+	".yml":  {},
+	".json": {},
+	".toml": {},
+}
+
+type configFile struct {
+	path string
+	data []byte
+}
+
+// readConfigFiles reads the config files in merging order. When confPath is a
+// directory, all config files in it are read in lexical order. Each file may
+// declare `includes: [glob...]` relative to its own directory, the included
+// files are read right after the file which includes them.
+func readConfigFiles(confPath string) ([]*configFile, error) {
+	r := &filesReader{visited: map[string]struct{}{}}
+	stat, err := os.Stat(confPath)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		if err := r.read(confPath); err != nil {
+			return nil, err
+		}
+		return r.files, nil
+	}
+	paths, err := listConfigFiles(confPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no config file is found in directory: %s", confPath)
+	}
+	for _, path := range paths {
+		if err := r.read(path); err != nil {
+			return nil, err
+		}
+	}
+	return r.files, nil
+}
+
+func isConfigFile(path string) bool {
+	_, ok := _configExts[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+func listConfigFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isConfigFile(entry.Name()) {
+			continue
+		}
+		out = append(out, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+type filesReader struct {
+	visited map[string]struct{}
+	files   []*configFile
+}
+
+func (r *filesReader) read(path string) error {
+	path = filepath.Clean(path)
+	if _, ok := r.visited[path]; ok {
+		// included more than once, or an include cycle
+		return nil
+	}
+	r.visited[path] = struct{}{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if data, err = fileToJSON(path, expandEnv(data)); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	r.files = append(r.files, &configFile{path: path, data: data})
+	includes, err := parseIncludes(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse includes of %s: %w", path, err)
+	}
+	for _, pattern := range includes {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q in %s: %w", pattern, path, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("include %q in %s matches no file", pattern, path)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err := r.read(match); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseIncludes(data []byte) ([]string, error) {
+	jsonData, err := toJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var directives struct {
+		Includes []string `json:"includes"`
+	}
+	if err := json.Unmarshal(jsonData, &directives); err != nil {
+		return nil, err
+	}
+	return directives.Includes, nil
+}
+
+// mergeConfigFiles deep-merges the config files in order, the singular fields
+// set in the later files override the earlier ones and the repeated fields
+// such as endpoints and middlewares are appended.
+func mergeConfigFiles(files []*configFile) (*configv1.Gateway, error) {
+	out := &configv1.Gateway{}
+	for _, file := range files {
+		c, err := Unmarshal(file.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", file.path, err)
+		}
+		proto.Merge(out, c)
+	}
+	return out, nil
+}
+
+func configFilesSHA256(files []*configFile) string {
+	h := sha256.New()
+	for _, file := range files {
+		h.Write([]byte(file.path))
+		h.Write([]byte{0})
+		h.Write(file.data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
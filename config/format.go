@@ -0,0 +1,33 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"sigs.k8s.io/yaml"
+)
+
+// toJSON converts the config to JSON, the JSON content is returned as is
+// without the YAML conversion.
+func toJSON(data []byte) ([]byte, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed) {
+		return trimmed, nil
+	}
+	return yaml.YAMLToJSON(data)
+}
+
+// fileToJSON converts the config file to JSON by the file extension,
+// the YAML is converted when the config is unmarshaled.
+func fileToJSON(path string, data []byte) ([]byte, error) {
+	if strings.ToLower(filepath.Ext(path)) != ".toml" {
+		return data, nil
+	}
+	var v map[string]interface{}
+	if _, err := toml.Decode(string(data), &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,267 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	_defaultRef   = "HEAD"
+	_pullInterval = time.Minute
+	_gitTimeout   = time.Minute
+)
+
+var _ config.ConfigLoader = (*gitLoader)(nil)
+
+func init() {
+	for _, scheme := range []string{"git+https", "git+http", "git+ssh", "git+file"} {
+		config.Register(scheme, NewFromURI)
+	}
+}
+
+type gitLoader struct {
+	repo         string
+	ref          string
+	path         string
+	dir          string
+	removeDir    bool
+	pullInterval time.Duration
+
+	lock   sync.Mutex
+	commit string
+
+	confSHA256  string
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// Option is a git loader option.
+type Option func(*gitLoader)
+
+// WithRef pins the branch, tag or commit to read the config from, the default
+// is the HEAD of the remote repository.
+func WithRef(ref string) Option {
+	return func(l *gitLoader) {
+		l.ref = ref
+	}
+}
+
+// WithDir sets the local directory to clone the repository into, the default
+// is a temporary directory which is removed on close.
+func WithDir(dir string) Option {
+	return func(l *gitLoader) {
+		l.dir = dir
+	}
+}
+
+// WithPullInterval sets the interval to pull the repository, the default is 1m.
+func WithPullInterval(interval time.Duration) Option {
+	return func(l *gitLoader) {
+		l.pullInterval = interval
+	}
+}
+
+// NewFromURI returns a config loader from the uri, the `git+` prefix is
+// stripped from the repository url, e.g.
+// git+https://github.com/org/gateway-config.git?ref=v1.2.0&path=gateway/config.yaml&interval=1m.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	query := uri.Query()
+	var opts []Option
+	if ref := query.Get("ref"); ref != "" {
+		opts = append(opts, WithRef(ref))
+	}
+	if interval := query.Get("interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %s", err)
+		}
+		opts = append(opts, WithPullInterval(d))
+	}
+	path := query.Get("path")
+	if path == "" {
+		path = "config.yaml"
+	}
+	repo := *uri
+	repo.Scheme = strings.TrimPrefix(uri.Scheme, "git+")
+	repo.RawQuery = ""
+	return New(repo.String(), path, opts...)
+}
+
+// New returns a config loader which loads the gateway config from the path
+// of the git repository, the path may be a config file or directory with
+// the includes. The repository is pulled at the interval by the git command,
+// and the config is read from the commit of the pinned ref.
+func New(repo, path string, opts ...Option) (config.ConfigLoader, error) {
+	l := &gitLoader{
+		repo:         repo,
+		ref:          _defaultRef,
+		path:         path,
+		pullInterval: _pullInterval,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if err := l.initialize(); err != nil {
+		if l.removeDir {
+			os.RemoveAll(l.dir)
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *gitLoader) initialize() error {
+	if l.dir == "" {
+		dir, err := ioutil.TempDir("", "gateway-config-")
+		if err != nil {
+			return err
+		}
+		l.dir = dir
+		l.removeDir = true
+	}
+	ctx := context.Background()
+	if _, err := os.Stat(filepath.Join(l.dir, ".git")); os.IsNotExist(err) {
+		if _, err := l.git(ctx, "init", "--quiet"); err != nil {
+			return err
+		}
+		if _, err := l.git(ctx, "remote", "add", "origin", l.repo); err != nil {
+			return err
+		}
+	}
+	if err := l.pull(ctx); err != nil {
+		return err
+	}
+	out, err := l.Load(ctx)
+	if err != nil {
+		return err
+	}
+	l.confSHA256 = digest(out)
+	log.Infof("the initial git config: %s@%s: %s, commit: %s, sha256: %s", l.repo, l.ref, l.path, l.commit, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+func digest(in *configv1.Gateway) string {
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(in)
+	return sha256sum(b)
+}
+
+func (l *gitLoader) git(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, _gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = l.dir
+	// never prompt for the credentials.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// pull fetches the pinned ref and checks out its commit.
+func (l *gitLoader) pull(ctx context.Context) error {
+	if _, err := l.git(ctx, "fetch", "--quiet", "--depth=1", "origin", l.ref); err != nil {
+		return err
+	}
+	commit, err := l.git(ctx, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if commit == l.commit {
+		return nil
+	}
+	if _, err := l.git(ctx, "checkout", "--quiet", "--force", "--detach", commit); err != nil {
+		return err
+	}
+	log.Infof("checked out git config: %s@%s, commit: %s, last commit: %s", l.repo, l.ref, commit, l.commit)
+	l.commit = commit
+	return nil
+}
+
+func (l *gitLoader) Load(_ context.Context) (*configv1.Gateway, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	log.Infof("loading config from git: %s@%s: %s, commit: %s", l.repo, l.ref, l.path, l.commit)
+	out, err := config.LoadFile(filepath.Join(l.dir, l.path))
+	if err != nil {
+		return nil, err
+	}
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *gitLoader) Watch(fn config.OnChange) {
+	log.Info("add git config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *gitLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch git config: %s@%s, pull interval: %s", l.repo, l.ref, l.pullInterval)
+	ticker := time.NewTicker(l.pullInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := l.pull(ctx); err != nil {
+			log.Errorf("watch git config: %s@%s error: %+v", l.repo, l.ref, err)
+			continue
+		}
+		out, err := l.Load(ctx)
+		if err != nil {
+			log.Errorf("watch git config: %s@%s error: %+v", l.repo, l.ref, err)
+			continue
+		}
+		sha256hex := digest(out)
+		if sha256hex == l.confSHA256 {
+			continue
+		}
+		log.Infof("git config changed, reload config, commit: %s, last sha256: %s, new sha256: %s", l.commit, l.confSHA256, sha256hex)
+		if err := l.handlers.Notify(); err != nil {
+			log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+			continue
+		}
+		l.confSHA256 = sha256hex
+	}
+}
+
+func (l *gitLoader) Close() {
+	l.watchCancel()
+	if l.removeDir {
+		os.RemoveAll(l.dir)
+	}
+}
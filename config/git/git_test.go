@@ -0,0 +1,109 @@
+package git
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+type upstream struct {
+	t   *testing.T
+	dir string
+}
+
+func (u *upstream) git(args ...string) {
+	args = append([]string{"-c", "user.name=gateway", "-c", "user.email=gateway@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = u.dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		u.t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func (u *upstream) commit(content string) {
+	if err := ioutil.WriteFile(filepath.Join(u.dir, "gateway", "config.yaml"), []byte(content), 0644); err != nil {
+		u.t.Fatal(err)
+	}
+	u.git("add", "-A")
+	u.git("commit", "--quiet", "-m", "update config")
+}
+
+func TestGitLoader(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "gateway-upstream-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "gateway"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	u := &upstream{t: t, dir: dir}
+	u.git("init", "--quiet", "--initial-branch=main")
+	u.commit(_config)
+	u.git("checkout", "--quiet", "-b", "canary")
+	u.commit(_changedConfig)
+	u.git("checkout", "--quiet", "main")
+
+	loader, err := New("file://"+dir, "gateway/config.yaml", WithRef("main"), WithPullInterval(time.Millisecond*50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || c.Version != "" || len(c.Middlewares) != 1 {
+		t.Fatalf("want the config of the pinned branch but got: %+v", c)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	u.commit(_changedConfig)
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+	c, err = loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %s", c.Version)
+	}
+}
@@ -0,0 +1,228 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-kratos/gateway/config"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// AgeProvider is the provider of the age encrypted values, e.g. ENC[age,base64 file].
+const AgeProvider = "age"
+
+const (
+	_ageIntro        = "age-encryption.org/v1"
+	_ageX25519Label  = "age-encryption.org/v1/X25519"
+	_ageSecretKeyHRP = "age-secret-key-"
+	_ageFileKeySize  = 16
+	_ageNonceSize    = 16
+	_ageChunkSize    = 64 * 1024
+	_ageColumns      = 64
+)
+
+// the age header is encoded by the standard base64 without the padding.
+var _ageBase64 = base64.RawStdEncoding.Strict()
+
+type ageIdentity struct {
+	secret []byte
+	public []byte
+}
+
+type ageStanza struct {
+	typ  string
+	args []string
+	body []byte
+}
+
+type ageHeader struct {
+	stanzas []*ageStanza
+	// signed is the header up to and including the "---" of the mac line.
+	signed  []byte
+	mac     []byte
+	payload []byte
+}
+
+// NewAge returns a decrypter of the age encrypted files by the X25519 identities,
+// e.g. the key file of age-keygen, the ciphertext is the binary encrypted file,
+// e.g. ENC[age,$(printf secret | age -r age1... | base64 -w0)].
+func NewAge(identities []byte) (config.Decrypter, error) {
+	var ids []*ageIdentity
+	for _, line := range strings.Split(string(identities), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hrp, secret, err := bech32Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity: %w", err)
+		}
+		if hrp != _ageSecretKeyHRP || len(secret) != curve25519.ScalarSize {
+			return nil, errors.New("invalid age identity: not a X25519 identity")
+		}
+		public, err := curve25519.X25519(secret, curve25519.Basepoint)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, &ageIdentity{secret: secret, public: public})
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no age identity is found")
+	}
+	return config.DecrypterFunc(func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		return ageDecrypt(ids, ciphertext)
+	}), nil
+}
+
+func ageKey(ikm, salt []byte, info string) []byte {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte(info)), key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// unwrap returns the file key of the X25519 recipient stanza.
+func (id *ageIdentity) unwrap(s *ageStanza) ([]byte, error) {
+	if s.typ != "X25519" || len(s.args) != 1 {
+		return nil, errors.New("not a X25519 stanza")
+	}
+	share, err := _ageBase64.DecodeString(s.args[0])
+	if err != nil || len(share) != curve25519.PointSize {
+		return nil, errors.New("invalid X25519 share")
+	}
+	shared, err := curve25519.X25519(id.secret, share)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte{}, share...), id.public...)
+	aead, err := chacha20poly1305.New(ageKey(shared, salt, _ageX25519Label))
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), s.body, nil)
+}
+
+func parseAgeHeader(data []byte) (*ageHeader, error) {
+	offset := 0
+	readLine := func() (string, error) {
+		i := bytes.IndexByte(data[offset:], '\n')
+		if i < 0 {
+			return "", errors.New("unexpected end of the age header")
+		}
+		line := string(data[offset : offset+i])
+		offset += i + 1
+		return line, nil
+	}
+	if line, err := readLine(); err != nil || line != _ageIntro {
+		return nil, errors.New("not an age encrypted file")
+	}
+	h := &ageHeader{}
+	for {
+		start := offset
+		line, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "--- ") {
+			if h.mac, err = _ageBase64.DecodeString(line[4:]); err != nil {
+				return nil, errors.New("invalid age header mac")
+			}
+			h.signed = data[:start+3]
+			h.payload = data[offset:]
+			return h, nil
+		}
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, fmt.Errorf("invalid age header line: %q", line)
+		}
+		args := strings.Split(line[3:], " ")
+		s := &ageStanza{typ: args[0], args: args[1:]}
+		// the body is wrapped at 64 columns, the last line is shorter.
+		for {
+			line, err := readLine()
+			if err != nil {
+				return nil, err
+			}
+			b, err := _ageBase64.DecodeString(line)
+			if err != nil || len(line) > _ageColumns {
+				return nil, fmt.Errorf("invalid age stanza body of %s", s.typ)
+			}
+			s.body = append(s.body, b...)
+			if len(line) < _ageColumns {
+				break
+			}
+		}
+		h.stanzas = append(h.stanzas, s)
+	}
+}
+
+// ageDecrypt decrypts the age file of the v1 format, see https://age-encryption.org/v1.
+func ageDecrypt(ids []*ageIdentity, data []byte) ([]byte, error) {
+	h, err := parseAgeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	var fileKey []byte
+	for _, s := range h.stanzas {
+		for _, id := range ids {
+			if key, err := id.unwrap(s); err == nil && len(key) == _ageFileKeySize {
+				fileKey = key
+				break
+			}
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return nil, errors.New("no age identity matched the recipients")
+	}
+	mac := hmac.New(sha256.New, ageKey(fileKey, nil, "header"))
+	_, _ = mac.Write(h.signed)
+	if !hmac.Equal(mac.Sum(nil), h.mac) {
+		return nil, errors.New("age header mac mismatched")
+	}
+	if len(h.payload) < _ageNonceSize {
+		return nil, errors.New("age payload is too short")
+	}
+	nonce, payload := h.payload[:_ageNonceSize], h.payload[_ageNonceSize:]
+	aead, err := chacha20poly1305.New(ageKey(fileKey, nonce, "payload"))
+	if err != nil {
+		return nil, err
+	}
+	// the payload is sealed by the chunks of 64 KiB, the chunk nonce is the
+	// 11 bytes big endian counter followed by the flag of the last chunk.
+	var plaintext []byte
+	chunkNonce := make([]byte, chacha20poly1305.NonceSize)
+	for counter := uint64(0); ; counter++ {
+		size := _ageChunkSize + aead.Overhead()
+		last := len(payload) <= size
+		if last {
+			size = len(payload)
+			chunkNonce[len(chunkNonce)-1] = 1
+		}
+		binary.BigEndian.PutUint64(chunkNonce[3:11], counter)
+		chunk, err := aead.Open(nil, chunkNonce, payload[:size], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt age payload chunk %d", counter)
+		}
+		if last && len(chunk) == 0 && counter > 0 {
+			return nil, errors.New("age payload ends with an empty chunk")
+		}
+		plaintext = append(plaintext, chunk...)
+		payload = payload[size:]
+		if last {
+			return plaintext, nil
+		}
+	}
+}
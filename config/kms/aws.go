@@ -0,0 +1,82 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/gateway/config/objectstore"
+)
+
+const (
+	// AWSProvider is the provider of the AWS KMS encrypted values, e.g.
+	// ENC[awskms,base64 ciphertext blob].
+	AWSProvider = "awskms"
+
+	_defaultAWSRegion = "us-east-1"
+)
+
+// NewAWS returns a decrypter of the ciphertext blobs encrypted by the AWS KMS
+// symmetric keys, the keys are identified by the blobs, e.g. the output of
+// `aws kms encrypt --key-id alias/gateway --plaintext fileb://secret`.
+func NewAWS(opts ...Option) config.Decrypter {
+	o := newOptions(opts)
+	if o.region == "" {
+		o.region = os.Getenv("AWS_REGION")
+	}
+	if o.region == "" {
+		o.region = _defaultAWSRegion
+	}
+	if o.credentials == nil {
+		o.credentials = objectstore.EnvCredentials()
+	}
+	endpoint := fmt.Sprintf("https://kms.%s.amazonaws.com", o.region)
+	if o.endpoint != "" {
+		endpoint = strings.TrimSuffix(o.endpoint, "/")
+	}
+	return config.DecrypterFunc(func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+		if o.credentials == nil {
+			return nil, errors.New("AWS credentials are not found")
+		}
+		body, err := json.Marshal(struct {
+			CiphertextBlob []byte `json:"CiphertextBlob"`
+		}{CiphertextBlob: ciphertext})
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+		objectstore.SignV4(req, body, o.credentials, o.region, "kms", time.Now())
+		resp, err := o.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected AWS KMS response status: %d: %s", resp.StatusCode, data)
+		}
+		out := struct {
+			Plaintext []byte `json:"Plaintext"`
+		}{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, err
+		}
+		return out.Plaintext, nil
+	})
+}
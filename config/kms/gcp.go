@@ -0,0 +1,75 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/gateway/config/objectstore"
+)
+
+const (
+	// GCPProvider is the provider of the GCP KMS encrypted values, e.g.
+	// ENC[gcpkms,base64 ciphertext].
+	GCPProvider = "gcpkms"
+
+	_gcpEndpoint = "https://cloudkms.googleapis.com"
+)
+
+// NewGCP returns a decrypter of the ciphertexts encrypted by the GCP KMS
+// symmetric key, e.g. projects/p/locations/global/keyRings/r/cryptoKeys/k,
+// the ciphertexts are the output of `gcloud kms encrypt`.
+func NewGCP(key string, opts ...Option) config.Decrypter {
+	o := newOptions(opts)
+	if o.tokenSource == nil {
+		o.tokenSource = objectstore.MetadataToken(o.client)
+	}
+	endpoint := _gcpEndpoint
+	if o.endpoint != "" {
+		endpoint = strings.TrimSuffix(o.endpoint, "/")
+	}
+	decryptURL := fmt.Sprintf("%s/v1/%s:decrypt", endpoint, strings.Trim(key, "/"))
+	token := objectstore.CachedToken(o.tokenSource)
+	return config.DecrypterFunc(func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+		t, _, err := token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the GCP KMS access token: %w", err)
+		}
+		body, err := json.Marshal(struct {
+			Ciphertext []byte `json:"ciphertext"`
+		}{Ciphertext: ciphertext})
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, decryptURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+t)
+		resp, err := o.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected GCP KMS response status of %s: %d: %s", key, resp.StatusCode, data)
+		}
+		out := struct {
+			Plaintext []byte `json:"plaintext"`
+		}{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, err
+		}
+		return out.Plaintext, nil
+	})
+}
@@ -0,0 +1,69 @@
+// Package kms provides the decrypters of the ENC[provider,ciphertext] config
+// values by the AWS KMS, GCP KMS and the age X25519 identities. The SOPS
+// encrypted files are not supported, decrypt them by sops before loading.
+package kms
+
+import (
+	"net/http"
+
+	"github.com/go-kratos/gateway/config/objectstore"
+)
+
+type options struct {
+	endpoint    string
+	region      string
+	credentials *objectstore.Credentials
+	tokenSource objectstore.TokenSource
+	client      *http.Client
+}
+
+// Option is a KMS decrypter option.
+type Option func(*options)
+
+// WithEndpoint sets the endpoint of the KMS API, e.g. http://127.0.0.1:4566
+// for the localstack.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithRegion sets the AWS region, the default is $AWS_REGION or us-east-1.
+func WithRegion(region string) Option {
+	return func(o *options) {
+		o.region = region
+	}
+}
+
+// WithCredentials sets the AWS credentials, the default is read from the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environments.
+func WithCredentials(c *objectstore.Credentials) Option {
+	return func(o *options) {
+		o.credentials = c
+	}
+}
+
+// WithTokenSource sets the OAuth2 access token source of GCP KMS, the default
+// is the token of the default service account from the GCE metadata server.
+func WithTokenSource(ts objectstore.TokenSource) Option {
+	return func(o *options) {
+		o.tokenSource = ts
+	}
+}
+
+// WithHTTPClient sets the http client, e.g. with the custom TLS config.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
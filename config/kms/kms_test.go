@@ -0,0 +1,230 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/gateway/config/objectstore"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+func bech32Encode(hrp string, data []byte) string {
+	var values []byte
+	var acc uint32
+	var bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			values = append(values, byte(acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(acc<<(5-bits))&31)
+	}
+	chk := bech32Polymod(append(append(bech32HRPExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+	for i := 0; i < 6; i++ {
+		values = append(values, byte(chk>>uint(5*(5-i)))&31)
+	}
+	var b strings.Builder
+	b.WriteString(hrp + "1")
+	for _, v := range values {
+		b.WriteByte(_bech32Charset[v])
+	}
+	return b.String()
+}
+
+func newAgeIdentity(t *testing.T) (identity string, public []byte) {
+	secret := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatal(err)
+	}
+	public, err := curve25519.X25519(secret, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.ToUpper(bech32Encode(_ageSecretKeyHRP, secret)), public
+}
+
+// ageEncrypt encrypts the plaintext to the X25519 recipient like `age -r`.
+func ageEncrypt(t *testing.T, recipient []byte, plaintext []byte) []byte {
+	fileKey := make([]byte, _ageFileKeySize)
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	nonce := make([]byte, _ageNonceSize)
+	for _, b := range [][]byte{fileKey, ephemeral, nonce} {
+		if _, err := rand.Read(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	share, _ := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	shared, _ := curve25519.X25519(ephemeral, recipient)
+	aead, _ := chacha20poly1305.New(ageKey(shared, append(append([]byte{}, share...), recipient...), _ageX25519Label))
+	body := aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil)
+
+	var buf bytes.Buffer
+	buf.WriteString(_ageIntro + "\n")
+	buf.WriteString("-> X25519 " + _ageBase64.EncodeToString(share) + "\n")
+	buf.WriteString(_ageBase64.EncodeToString(body) + "\n")
+	buf.WriteString("---")
+	mac := hmac.New(sha256.New, ageKey(fileKey, nil, "header"))
+	mac.Write(buf.Bytes())
+	buf.WriteString(" " + _ageBase64.EncodeToString(mac.Sum(nil)) + "\n")
+	buf.Write(nonce)
+
+	aead, _ = chacha20poly1305.New(ageKey(fileKey, nonce, "payload"))
+	chunkNonce := make([]byte, chacha20poly1305.NonceSize)
+	for counter := uint64(0); ; counter++ {
+		size := _ageChunkSize
+		last := len(plaintext) <= size
+		if last {
+			size = len(plaintext)
+			chunkNonce[len(chunkNonce)-1] = 1
+		}
+		binary.BigEndian.PutUint64(chunkNonce[3:11], counter)
+		buf.Write(aead.Seal(nil, chunkNonce, plaintext[:size], nil))
+		plaintext = plaintext[size:]
+		if last {
+			return buf.Bytes()
+		}
+	}
+}
+
+func TestBech32(t *testing.T) {
+	// see the test vectors of BIP 173.
+	hrp, data, err := bech32Decode("A12UEL5L")
+	if err != nil || hrp != "a" || len(data) != 0 {
+		t.Fatalf("want the empty data of a but got %s %x %v", hrp, data, err)
+	}
+	hrp, data, err = bech32Decode("abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw")
+	if err != nil || hrp != "abcdef" || hex.EncodeToString(data) != "00443214c74254b635cf84653a56d7c675be77df" {
+		t.Fatalf("want the data of abcdef but got %s %x %v", hrp, data, err)
+	}
+	for _, s := range []string{"a12uel5m", "A12uEL5L", "1pzry9x8gf2tvdw0s3jn54khce6mua7l", "a1b2c3"} {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Fatalf("want the error of %s", s)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	identity, public := newAgeIdentity(t)
+	other, otherPublic := newAgeIdentity(t)
+	decrypter, err := NewAge([]byte("# created: 2022-06-01T00:00:00Z\n# public key: age1...\n" + identity + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := bytes.Repeat([]byte("x"), _ageChunkSize*2+10)
+	for _, plaintext := range [][]byte{[]byte("helloworld"), {}, large[:_ageChunkSize], large} {
+		out, err := decrypter.Decrypt(context.Background(), ageEncrypt(t, public, plaintext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, plaintext) {
+			t.Fatalf("want the plaintext of %d bytes but got %d bytes", len(plaintext), len(out))
+		}
+	}
+
+	ciphertext := ageEncrypt(t, public, []byte("helloworld"))
+	tampered := append([]byte{}, ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	truncated := ageEncrypt(t, public, large)[:len(ciphertext)+_ageChunkSize]
+	for _, c := range [][]byte{ageEncrypt(t, otherPublic, []byte("helloworld")), tampered, truncated, []byte("helloworld")} {
+		if _, err := decrypter.Decrypt(context.Background(), c); err == nil {
+			t.Fatal("want the error of the invalid ciphertext")
+		}
+	}
+	corrupted := other[:len(other)-1] + "Q"
+	if strings.HasSuffix(other, "Q") {
+		corrupted = other[:len(other)-1] + "P"
+	}
+	for _, identity := range []string{corrupted, bech32Encode("age", otherPublic)} {
+		if _, err := NewAge([]byte(identity)); err == nil {
+			t.Fatalf("want the error of the invalid identity %s", identity)
+		}
+	}
+	if _, err := NewAge([]byte("# no identity\n")); err == nil {
+		t.Fatal("want the error of no identity")
+	}
+}
+
+func TestAWS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "TrentService.Decrypt" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/us-west-2/kms/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var in struct {
+			CiphertextBlob []byte
+		}
+		body := new(bytes.Buffer)
+		body.ReadFrom(r.Body)
+		if sum := sha256.Sum256(body.Bytes()); r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if err := json.Unmarshal(body.Bytes(), &in); err != nil || string(in.CiphertextBlob) != "blob" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"InvalidCiphertextException"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"KeyId": "arn:aws:kms:us-west-2:111122223333:key/1", "Plaintext": []byte("helloworld")})
+	}))
+	defer srv.Close()
+
+	decrypter := NewAWS(WithEndpoint(srv.URL), WithRegion("us-west-2"), WithCredentials(&objectstore.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}))
+	out, err := decrypter.Decrypt(context.Background(), []byte("blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "helloworld" {
+		t.Fatalf("want helloworld but got %s", out)
+	}
+	if _, err := decrypter.Decrypt(context.Background(), []byte("other")); err == nil || !strings.Contains(err.Error(), "InvalidCiphertextException") {
+		t.Fatalf("want the KMS error but got %v", err)
+	}
+}
+
+func TestGCP(t *testing.T) {
+	const key = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/"+key+":decrypt" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var in struct {
+			Ciphertext []byte `json:"ciphertext"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"plaintext":"` + base64.StdEncoding.EncodeToString(bytes.ToUpper(in.Ciphertext)) + `"}`))
+	}))
+	defer srv.Close()
+
+	out, err := NewGCP(key, WithEndpoint(srv.URL), WithTokenSource(objectstore.StaticToken("token"))).Decrypt(context.Background(), []byte("helloworld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "HELLOWORLD" {
+		t.Fatalf("want HELLOWORLD but got %s", out)
+	}
+	if _, err := NewGCP(key, WithEndpoint(srv.URL), WithTokenSource(objectstore.StaticToken("other"))).Decrypt(context.Background(), []byte("helloworld")); err == nil {
+		t.Fatal("want the error of the unauthorized token")
+	}
+}
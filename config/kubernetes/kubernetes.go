@@ -0,0 +1,294 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// Group is the API group of the gateway custom resources.
+	Group = "gateway.go-kratos.dev"
+	// Version is the API version of the gateway custom resources.
+	Version = "v1"
+	// GatewayLabel is the label to bind the Route and Middleware resources to a Gateway.
+	GatewayLabel = Group + "/gateway"
+)
+
+var (
+	gatewayResource    = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "gateways"}
+	routeResource      = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "routes"}
+	middlewareResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "middlewares"}
+
+	_jsonOptions = &protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+var _ config.ConfigLoader = (*kubernetesLoader)(nil)
+
+func init() {
+	config.Register("kubernetes", NewFromURI)
+}
+
+type kubernetesLoader struct {
+	namespace   string
+	name        string
+	confSHA256  string
+	factory     dynamicinformer.DynamicSharedInformerFactory
+	gateways    cache.GenericLister
+	routes      cache.GenericLister
+	middlewares cache.GenericLister
+	changed     chan struct{}
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// NewInCluster returns a kubernetes config loader using the in-cluster service account.
+func NewInCluster(namespace, name string) (config.ConfigLoader, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, namespace, name)
+}
+
+// NewFromURI returns a config loader of the Gateway `name` in `namespace` from
+// the uri, e.g. kubernetes://default/gateway, the in-cluster service account
+// is used unless the `kubeconfig` query is set, e.g. ?kubeconfig=/root/.kube/config.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	namespace, name := uri.Host, strings.TrimPrefix(uri.Path, "/")
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("the namespace and name of the gateway are required: %s", uri)
+	}
+	kubeconfig := uri.Query().Get("kubeconfig")
+	if kubeconfig == "" {
+		return NewInCluster(namespace, name)
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, namespace, name)
+}
+
+// New returns a config loader which assembles the gateway config from the
+// Gateway custom resource `name` in `namespace`, together with the Route and
+// Middleware custom resources labeled by `gateway.go-kratos.dev/gateway: name`.
+// The resources are watched by the informers.
+func New(client dynamic.Interface, namespace, name string) (config.ConfigLoader, error) {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, 0, namespace, nil)
+	l := &kubernetesLoader{
+		namespace:   namespace,
+		name:        name,
+		factory:     factory,
+		gateways:    factory.ForResource(gatewayResource).Lister(),
+		routes:      factory.ForResource(routeResource).Lister(),
+		middlewares: factory.ForResource(middlewareResource).Lister(),
+		changed:     make(chan struct{}, 1),
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *kubernetesLoader) initialize() error {
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	l.factory.Start(watchCtx.Done())
+	for gvr, synced := range l.factory.WaitForCacheSync(watchCtx.Done()) {
+		if !synced {
+			cancel()
+			return fmt.Errorf("failed to sync the informer cache of %s", gvr)
+		}
+	}
+	out, err := l.Load(watchCtx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	sha256hex, err := l.digest(out)
+	if err != nil {
+		cancel()
+		return err
+	}
+	l.confSHA256 = sha256hex
+	log.Infof("the initial kubernetes config: %s/%s, sha256: %s", l.namespace, l.name, l.confSHA256)
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { l.markChanged() },
+		UpdateFunc: func(interface{}, interface{}) { l.markChanged() },
+		DeleteFunc: func(interface{}) { l.markChanged() },
+	}
+	for _, gvr := range []schema.GroupVersionResource{gatewayResource, routeResource, middlewareResource} {
+		l.factory.ForResource(gvr).Informer().AddEventHandler(handler)
+	}
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+// markChanged coalesces the informer events, the config is assembled once
+// for a burst of changes.
+func (l *kubernetesLoader) markChanged() {
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (l *kubernetesLoader) digest(in *configv1.Gateway) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	return sha256sum(b), nil
+}
+
+func unmarshalSpec(obj runtime.Object, out proto.Message) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object type: %T", obj)
+	}
+	spec, ok := u.Object["spec"]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if err := _jsonOptions.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("invalid spec of %s %s/%s: %w", u.GetKind(), u.GetNamespace(), u.GetName(), err)
+	}
+	return nil
+}
+
+// listBound returns the objects bound to the gateway, sorted by name to keep
+// the order of routes and middlewares stable.
+func (l *kubernetesLoader) listBound(lister cache.GenericLister) ([]runtime.Object, error) {
+	selector := labels.SelectorFromSet(labels.Set{GatewayLabel: l.name})
+	objs, err := lister.ByNamespace(l.namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].(*unstructured.Unstructured).GetName() < objs[j].(*unstructured.Unstructured).GetName()
+	})
+	return objs, nil
+}
+
+func (l *kubernetesLoader) Load(_ context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from kubernetes gateway: %s/%s", l.namespace, l.name)
+	obj, err := l.gateways.ByNamespace(l.namespace).Get(l.name)
+	if err != nil {
+		return nil, err
+	}
+	out := &configv1.Gateway{}
+	if err := unmarshalSpec(obj, out); err != nil {
+		return nil, err
+	}
+	if out.Name == "" {
+		out.Name = l.name
+	}
+	routes, err := l.listBound(l.routes)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		endpoint := &configv1.Endpoint{}
+		if err := unmarshalSpec(route, endpoint); err != nil {
+			return nil, err
+		}
+		out.Endpoints = append(out.Endpoints, endpoint)
+	}
+	middlewares, err := l.listBound(l.middlewares)
+	if err != nil {
+		return nil, err
+	}
+	for _, middleware := range middlewares {
+		m := &configv1.Middleware{}
+		if err := unmarshalSpec(middleware, m); err != nil {
+			return nil, err
+		}
+		if m.Name == "" {
+			m.Name = middleware.(*unstructured.Unstructured).GetName()
+		}
+		out.Middlewares = append(out.Middlewares, m)
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *kubernetesLoader) Watch(fn config.OnChange) {
+	log.Info("add kubernetes config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *kubernetesLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch kubernetes gateway: %s/%s", l.namespace, l.name)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.changed:
+		}
+		out, err := l.Load(ctx)
+		if err != nil {
+			log.Errorf("watch kubernetes gateway: %s/%s error: %+v", l.namespace, l.name, err)
+			continue
+		}
+		sha256hex, err := l.digest(out)
+		if err != nil {
+			log.Errorf("watch kubernetes gateway: %s/%s error: %+v", l.namespace, l.name, err)
+			continue
+		}
+		if sha256hex == l.confSHA256 {
+			continue
+		}
+		log.Infof("kubernetes config changed, reload config, last sha256: %s, new sha256: %s", l.confSHA256, sha256hex)
+		if err := l.handlers.Notify(); err != nil {
+			log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+			continue
+		}
+		l.confSHA256 = sha256hex
+	}
+}
+
+func (l *kubernetesLoader) Close() {
+	l.watchCancel()
+}
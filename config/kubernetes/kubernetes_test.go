@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	_ "github.com/go-kratos/gateway/middleware/logging"
+	"google.golang.org/protobuf/proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newObject(kind, name string, labels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Group + "/" + Version,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"spec": spec,
+	}}
+	u.SetLabels(labels)
+	return u
+}
+
+func TestKubernetesLoader(t *testing.T) {
+	bound := map[string]string{GatewayLabel: "helloworld"}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gatewayResource:    "GatewayList",
+		routeResource:      "RouteList",
+		middlewareResource: "MiddlewareList",
+	})
+	// the fake tracker guesses the resource of Gateway as "gatewaies",
+	// so the objects are created with the explicit resources.
+	for gvr, objs := range map[schema.GroupVersionResource][]*unstructured.Unstructured{
+		gatewayResource: {
+			newObject("Gateway", "helloworld", nil, map[string]interface{}{"version": "v1"}),
+		},
+		routeResource: {
+			newObject("Route", "b-greeter", bound, map[string]interface{}{
+				"path":     "/helloworld.Greeter/*",
+				"protocol": "GRPC",
+				"backends": []interface{}{map[string]interface{}{"target": "127.0.0.1:9000"}},
+			}),
+			newObject("Route", "a-helloworld", bound, map[string]interface{}{
+				"path":     "/helloworld/*",
+				"protocol": "HTTP",
+				"backends": []interface{}{map[string]interface{}{"target": "127.0.0.1:8000"}},
+			}),
+			newObject("Route", "unbound", nil, map[string]interface{}{"path": "/unbound"}),
+		},
+		middlewareResource: {
+			newObject("Middleware", "logging", bound, map[string]interface{}{}),
+		},
+	} {
+		for _, obj := range objs {
+			if _, err := client.Resource(gvr).Namespace("default").Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	l, err := New(client, "default", "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	cfg, err := l.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &configv1.Gateway{
+		Name:    "helloworld",
+		Version: "v1",
+		Endpoints: []*configv1.Endpoint{
+			{
+				Path:     "/helloworld/*",
+				Protocol: configv1.Protocol_HTTP,
+				Backends: []*configv1.Backend{{Target: "127.0.0.1:8000"}},
+			},
+			{
+				Path:     "/helloworld.Greeter/*",
+				Protocol: configv1.Protocol_GRPC,
+				Backends: []*configv1.Backend{{Target: "127.0.0.1:9000"}},
+			},
+		},
+		Middlewares: []*configv1.Middleware{{Name: "logging"}},
+	}
+	if !proto.Equal(cfg, want) {
+		t.Errorf("inconsistent gateway config: %+v", cfg)
+	}
+
+	changed := make(chan struct{}, 1)
+	l.Watch(func() error {
+		changed <- struct{}{}
+		return nil
+	})
+	route := newObject("Route", "c-echo", bound, map[string]interface{}{
+		"path":     "/echo",
+		"backends": []interface{}{map[string]interface{}{"target": "127.0.0.1:8000"}},
+	})
+	if _, err := client.Resource(routeResource).Namespace("default").Create(context.Background(), route, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of routes is not notified")
+	}
+}
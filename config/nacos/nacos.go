@@ -0,0 +1,344 @@
+package nacos
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	_defaultGroup       = "DEFAULT_GROUP"
+	_defaultContextPath = "/nacos"
+	_longPollingTimeout = time.Second * 30
+	// see https://nacos.io/en-us/docs/open-api.html
+	_wordSeparator = "\x02"
+	_lineSeparator = "\x01"
+)
+
+var _ config.ConfigLoader = (*nacosLoader)(nil)
+
+func init() {
+	config.Register("nacos", NewFromURI)
+}
+
+type nacosLoader struct {
+	endpoint    string
+	contextPath string
+	dataID      string
+	group       string
+	namespace   string
+	username    string
+	password    string
+	client      *http.Client
+
+	tokenLock   sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+
+	contentMD5  string
+	confSHA256  string
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// Option is a nacos loader option.
+type Option func(*nacosLoader)
+
+// WithGroup sets the config group, the default is DEFAULT_GROUP.
+func WithGroup(group string) Option {
+	return func(l *nacosLoader) {
+		l.group = group
+	}
+}
+
+// WithNamespace sets the namespace (tenant) id of the config.
+func WithNamespace(namespace string) Option {
+	return func(l *nacosLoader) {
+		l.namespace = namespace
+	}
+}
+
+// WithContextPath sets the context path of the nacos server, the default is /nacos.
+func WithContextPath(contextPath string) Option {
+	return func(l *nacosLoader) {
+		l.contextPath = contextPath
+	}
+}
+
+// WithAuth sets the username and password to login when the nacos auth is enabled.
+func WithAuth(username, password string) Option {
+	return func(l *nacosLoader) {
+		l.username = username
+		l.password = password
+	}
+}
+
+// WithHTTPClient sets the http client to request the nacos server.
+func WithHTTPClient(client *http.Client) Option {
+	return func(l *nacosLoader) {
+		l.client = client
+	}
+}
+
+// New returns a config loader which loads the gateway config from the nacos
+// config `dataID` on the server endpoint, e.g. http://127.0.0.1:8848.
+// The OnChange handlers are triggered by the long-polling listener.
+func New(endpoint, dataID string, opts ...Option) (config.ConfigLoader, error) {
+	l := &nacosLoader{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		contextPath: _defaultContextPath,
+		dataID:      dataID,
+		group:       _defaultGroup,
+		client:      http.DefaultClient,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// NewFromURI returns a config loader from the uri, e.g.
+// nacos://127.0.0.1:8848/gateway.yaml?group=GATEWAY&namespace=prod&username=nacos&password=secret,
+// the `tls=true` query connects the server by https.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	query := uri.Query()
+	scheme := "http"
+	if query.Get("tls") == "true" {
+		scheme = "https"
+	}
+	var opts []Option
+	if group := query.Get("group"); group != "" {
+		opts = append(opts, WithGroup(group))
+	}
+	if namespace := query.Get("namespace"); namespace != "" {
+		opts = append(opts, WithNamespace(namespace))
+	}
+	if contextPath := query.Get("context_path"); contextPath != "" {
+		opts = append(opts, WithContextPath(contextPath))
+	}
+	if username := query.Get("username"); username != "" {
+		opts = append(opts, WithAuth(username, query.Get("password")))
+	}
+	return New(scheme+"://"+uri.Host, strings.TrimPrefix(uri.Path, "/"), opts...)
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func md5sum(in []byte) string {
+	sum := md5.Sum(in) //nolint:gosec
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *nacosLoader) initialize() error {
+	data, err := l.get(context.Background())
+	if err != nil {
+		return err
+	}
+	l.contentMD5 = md5sum(data)
+	l.confSHA256 = sha256sum(data)
+	log.Infof("the initial nacos config dataId: %s, group: %s, sha256: %s", l.dataID, l.group, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+func (l *nacosLoader) url(path string) string {
+	return l.endpoint + l.contextPath + path
+}
+
+// token returns the access token, it logins again when the token is expired.
+func (l *nacosLoader) token(ctx context.Context) (string, error) {
+	if l.username == "" {
+		return "", nil
+	}
+	l.tokenLock.Lock()
+	defer l.tokenLock.Unlock()
+	if l.accessToken != "" && time.Now().Before(l.tokenExpiry) {
+		return l.accessToken, nil
+	}
+	form := url.Values{"username": {l.username}, "password": {l.password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url("/v1/auth/login"), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, err := l.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to login nacos: %w", err)
+	}
+	var reply struct {
+		AccessToken string `json:"accessToken"`
+		TokenTTL    int64  `json:"tokenTtl"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return "", fmt.Errorf("failed to login nacos: %w", err)
+	}
+	l.accessToken = reply.AccessToken
+	// refresh the token before it's expired.
+	l.tokenExpiry = time.Now().Add(time.Duration(reply.TokenTTL) * time.Second * 9 / 10)
+	return l.accessToken, nil
+}
+
+func (l *nacosLoader) query(ctx context.Context) (url.Values, error) {
+	token, err := l.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	if token != "" {
+		q.Set("accessToken", token)
+	}
+	return q, nil
+}
+
+func (l *nacosLoader) do(req *http.Request) ([]byte, error) {
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("config dataId: %q of group: %q is not found in nacos", l.dataID, l.group)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected nacos response status: %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+func (l *nacosLoader) get(ctx context.Context) ([]byte, error) {
+	q, err := l.query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q.Set("dataId", l.dataID)
+	q.Set("group", l.group)
+	if l.namespace != "" {
+		q.Set("tenant", l.namespace)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url("/v1/cs/configs")+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return l.do(req)
+}
+
+// listen blocks until the config is changed or the long-polling is timeout,
+// it reports whether the config is changed.
+func (l *nacosLoader) listen(ctx context.Context) (bool, error) {
+	q, err := l.query(ctx)
+	if err != nil {
+		return false, err
+	}
+	listening := []string{l.dataID, l.group, l.contentMD5}
+	if l.namespace != "" {
+		listening = append(listening, l.namespace)
+	}
+	form := url.Values{"Listening-Configs": {strings.Join(listening, _wordSeparator) + _lineSeparator}}
+	ctx, cancel := context.WithTimeout(ctx, _longPollingTimeout+time.Second*10)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url("/v1/cs/configs/listener")+"?"+q.Encode(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Long-Pulling-Timeout", strconv.FormatInt(_longPollingTimeout.Milliseconds(), 10))
+	body, err := l.do(req)
+	if err != nil {
+		return false, err
+	}
+	// the changed configs are returned, it's empty when the long-polling is timeout.
+	return len(strings.TrimSpace(string(body))) > 0, nil
+}
+
+func (l *nacosLoader) Load(ctx context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from nacos dataId: %s, group: %s", l.dataID, l.group)
+	data, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := config.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *nacosLoader) Watch(fn config.OnChange) {
+	log.Info("add nacos config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *nacosLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch nacos config dataId: %s, group: %s", l.dataID, l.group)
+	for {
+		changed, err := l.listen(ctx)
+		if err == nil && changed {
+			err = l.onChange(ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("watch nacos config dataId: %s, group: %s error: %+v", l.dataID, l.group, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (l *nacosLoader) onChange(ctx context.Context) error {
+	data, err := l.get(ctx)
+	if err != nil {
+		return err
+	}
+	l.contentMD5 = md5sum(data)
+	sha256hex := sha256sum(data)
+	if sha256hex == l.confSHA256 {
+		return nil
+	}
+	log.Infof("nacos config changed, reload config, last sha256: %s, new sha256: %s", l.confSHA256, sha256hex)
+	if err := l.handlers.Notify(); err != nil {
+		log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+		return nil
+	}
+	l.confSHA256 = sha256hex
+	return nil
+}
+
+func (l *nacosLoader) Close() {
+	l.watchCancel()
+}
@@ -0,0 +1,150 @@
+package nacos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+type fakeNacos struct {
+	lock    sync.Mutex
+	content string
+	changed chan struct{}
+}
+
+func (f *fakeNacos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/nacos/v1/auth/login":
+		if r.FormValue("username") != "nacos" || r.FormValue("password") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"accessToken":"token","tokenTtl":18000}`))
+		return
+	}
+	if r.URL.Query().Get("accessToken") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case "/nacos/v1/cs/configs":
+		if r.URL.Query().Get("dataId") != "gateway.yaml" || r.URL.Query().Get("group") != "GATEWAY" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		w.Write([]byte(f.content))
+	case "/nacos/v1/cs/configs/listener":
+		listening := strings.Split(strings.TrimSuffix(r.FormValue("Listening-Configs"), _lineSeparator), _wordSeparator)
+		f.lock.Lock()
+		changed := listening[2] != md5sum([]byte(f.content))
+		f.lock.Unlock()
+		if !changed {
+			select {
+			case <-f.changed:
+			case <-time.After(time.Millisecond * 100):
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte("gateway.yaml%02GATEWAY%01"))
+	}
+}
+
+func (f *fakeNacos) set(content string) {
+	f.lock.Lock()
+	f.content = content
+	f.lock.Unlock()
+	f.changed <- struct{}{}
+}
+
+func TestNacosLoader(t *testing.T) {
+	nacos := &fakeNacos{content: _config, changed: make(chan struct{}, 1)}
+	srv := httptest.NewServer(nacos)
+	defer srv.Close()
+
+	if _, err := New(srv.URL, "gateway.yaml"); err == nil {
+		t.Fatal("want an error without auth")
+	}
+	loader, err := New(srv.URL, "gateway.yaml", WithGroup("GATEWAY"), WithAuth("nacos", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 1 || len(c.Middlewares) != 1 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	nacos.set(_changedConfig)
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+	c, err = loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %s", c.Version)
+	}
+}
+
+func TestNacosLoaderFromURI(t *testing.T) {
+	nacos := &fakeNacos{content: _config, changed: make(chan struct{}, 1)}
+	srv := httptest.NewServer(nacos)
+	defer srv.Close()
+
+	loader, err := config.Create("nacos://" + strings.TrimPrefix(srv.URL, "http://") + "/gateway.yaml?group=GATEWAY&username=nacos&password=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+}
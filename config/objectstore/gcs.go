@@ -0,0 +1,131 @@
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+)
+
+const (
+	_gcsEndpoint      = "https://storage.googleapis.com"
+	_metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// TokenSource returns the OAuth2 access token and its expiry.
+type TokenSource func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// StaticToken returns the token source of the static access token.
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, time.Time, error) {
+		return token, time.Time{}, nil
+	}
+}
+
+// MetadataToken returns the token source of the default service account from
+// the GCE metadata server.
+func MetadataToken(client *http.Client) TokenSource {
+	return func(ctx context.Context) (string, time.Time, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, _metadataTokenURL, nil)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", time.Time{}, fmt.Errorf("unexpected response status of metadata token: %d: %s", resp.StatusCode, body)
+		}
+		out := struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}{}
+		if err := json.Unmarshal(body, &out); err != nil {
+			return "", time.Time{}, err
+		}
+		return out.AccessToken, time.Now().Add(time.Duration(out.ExpiresIn) * time.Second), nil
+	}
+}
+
+// CachedToken returns the token source which caches the token of the source
+// until a minute before its expiry.
+func CachedToken(source TokenSource) TokenSource {
+	return (&cachedToken{source: source}).get
+}
+
+// cachedToken caches the token until a minute before its expiry.
+type cachedToken struct {
+	source TokenSource
+
+	lock   sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (c *cachedToken) get(ctx context.Context) (string, time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Before(c.expiry.Add(-time.Minute))) {
+		return c.token, c.expiry, nil
+	}
+	token, expiry, err := c.source(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	c.token, c.expiry = token, expiry
+	return token, expiry, nil
+}
+
+// NewGCS returns a config loader which loads the gateway config from the GCS
+// object, the requests are authorized by the OAuth2 access token.
+func NewGCS(bucket, object string, opts ...Option) (config.ConfigLoader, error) {
+	o := newOptions(opts)
+	if o.tokenSource == nil {
+		o.tokenSource = MetadataToken(o.client)
+	}
+	endpoint := _gcsEndpoint
+	if o.endpoint != "" {
+		endpoint = strings.TrimSuffix(o.endpoint, "/")
+	}
+	objectURL := fmt.Sprintf("%s/%s/%s", endpoint, bucket, awsURIEncode(strings.TrimPrefix(object, "/"), false))
+	token := CachedToken(o.tokenSource)
+	return o.newLoader(objectURL, func(base http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			t, _, err := token(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the GCS access token: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+t)
+			return base.RoundTrip(req)
+		})
+	})
+}
+
+// NewGCSFromURI returns a config loader from the uri, e.g. gs://bucket/gateway/config.yaml,
+// the token is from the GCE metadata server.
+func NewGCSFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	var opts []Option
+	if interval := uri.Query().Get("interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %s", err)
+		}
+		opts = append(opts, WithRefreshInterval(d))
+	}
+	return NewGCS(uri.Host, uri.Path, opts...)
+}
@@ -0,0 +1,106 @@
+// Package objectstore provides the config loaders to load the gateway config
+// from the S3 or GCS objects, the objects are refreshed by the conditional
+// requests of the remote loader.
+package objectstore
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/gateway/config/remote"
+)
+
+func init() {
+	config.Register("s3", NewS3FromURI)
+	config.Register("gs", NewGCSFromURI)
+}
+
+type options struct {
+	endpoint        string
+	region          string
+	credentials     *Credentials
+	tokenSource     TokenSource
+	refreshInterval time.Duration
+	client          *http.Client
+}
+
+// Option is an object store loader option.
+type Option func(*options)
+
+// WithEndpoint sets the endpoint of the object store, e.g. http://127.0.0.1:9000
+// for the S3 compatible MinIO, the objects are requested in path-style.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithRegion sets the S3 region, the default is $AWS_REGION or us-east-1.
+func WithRegion(region string) Option {
+	return func(o *options) {
+		o.region = region
+	}
+}
+
+// WithCredentials sets the S3 credentials, the default is read from the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environments.
+func WithCredentials(c *Credentials) Option {
+	return func(o *options) {
+		o.credentials = c
+	}
+}
+
+// WithTokenSource sets the OAuth2 access token source of GCS, the default is
+// the token of the default service account from the GCE metadata server.
+func WithTokenSource(ts TokenSource) Option {
+	return func(o *options) {
+		o.tokenSource = ts
+	}
+}
+
+// WithRefreshInterval sets the interval to refresh the object, the default is 10s.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.refreshInterval = interval
+	}
+}
+
+// WithHTTPClient sets the http client, e.g. with the custom TLS config.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// newLoader returns the remote loader of the url, the requests are
+// authorized by the transport.
+func (o *options) newLoader(url string, newTransport func(base http.RoundTripper) http.RoundTripper) (config.ConfigLoader, error) {
+	base := o.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client := *o.client
+	client.Transport = newTransport(base)
+	opts := []remote.Option{remote.WithHTTPClient(&client)}
+	if o.refreshInterval > 0 {
+		opts = append(opts, remote.WithRefreshInterval(o.refreshInterval))
+	}
+	return remote.New(url, opts...)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
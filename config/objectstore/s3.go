@@ -0,0 +1,199 @@
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+)
+
+const (
+	_defaultRegion = "us-east-1"
+	_amzDateFormat = "20060102T150405Z"
+)
+
+// Credentials is the AWS credentials to sign the S3 requests.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// EnvCredentials returns the credentials of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// and AWS_SESSION_TOKEN environments, nil is returned if they're not set.
+func EnvCredentials() *Credentials {
+	c := &Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil
+	}
+	return c
+}
+
+// NewS3 returns a config loader which loads the gateway config from the S3
+// object, the requests are signed by the AWS signature version 4, and the
+// anonymous requests are sent without the credentials.
+func NewS3(bucket, key string, opts ...Option) (config.ConfigLoader, error) {
+	o := newOptions(opts)
+	if o.region == "" {
+		o.region = os.Getenv("AWS_REGION")
+	}
+	if o.region == "" {
+		o.region = _defaultRegion
+	}
+	if o.credentials == nil {
+		o.credentials = EnvCredentials()
+	}
+	key = strings.TrimPrefix(key, "/")
+	objectURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, o.region, awsURIEncode(key, false))
+	if o.endpoint != "" {
+		objectURL = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(o.endpoint, "/"), bucket, awsURIEncode(key, false))
+	}
+	return o.newLoader(objectURL, func(base http.RoundTripper) http.RoundTripper {
+		if o.credentials == nil {
+			return base
+		}
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			SignV4(req, nil, o.credentials, o.region, "s3", time.Now())
+			return base.RoundTrip(req)
+		})
+	})
+}
+
+// NewS3FromURI returns a config loader from the uri, e.g.
+// s3://bucket/gateway/config.yaml?region=us-west-2&endpoint=http://127.0.0.1:9000.
+func NewS3FromURI(uri *url.URL) (config.ConfigLoader, error) {
+	var opts []Option
+	if region := uri.Query().Get("region"); region != "" {
+		opts = append(opts, WithRegion(region))
+	}
+	if endpoint := uri.Query().Get("endpoint"); endpoint != "" {
+		opts = append(opts, WithEndpoint(endpoint))
+	}
+	if interval := uri.Query().Get("interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %s", err)
+		}
+		opts = append(opts, WithRefreshInterval(d))
+	}
+	return NewS3(uri.Host, uri.Path, opts...)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// awsURIEncode encodes the string except the unreserved characters, the '/'
+// is kept unless encodeSlash.
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// SignV4 signs the request and its payload by the AWS signature version 4, all
+// of the request headers are signed, the payload is nil if it's empty.
+func SignV4(req *http.Request, payload []byte, c *Credentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(_amzDateFormat)
+	payloadHash := sha256hex(string(payload))
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{amzDate[:8], region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256hex(canonicalRequest),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// _mergeKeys is the keys to match the list items in the strategic merge.
+var _mergeKeys = map[string]func(map[string]interface{}) string{
+	"endpoints": func(e map[string]interface{}) string {
+		host, _ := e["host"].(string)
+		method, _ := e["method"].(string)
+		path, _ := e["path"].(string)
+		if grpc, ok := e["grpc"].(map[string]interface{}); ok && path == "" {
+			service, _ := grpc["service"].(string)
+			method, _ := grpc["method"].(string)
+			path = GRPCPath(&configv1.GrpcRoute{Service: service, Method: method})
+		}
+		return strings.Join([]string{host, strings.ToUpper(method), path}, " ")
+	},
+	"middlewares": func(m map[string]interface{}) string {
+		name, _ := m["name"].(string)
+		return name
+	},
+	"backends": func(b map[string]interface{}) string {
+		target, _ := b["target"].(string)
+		return target
+	},
+}
+
+// readOverlayFiles reads the overlay files in order, the includes are not supported.
+func readOverlayFiles(paths []string) ([]*configFile, error) {
+	files := make([]*configFile, 0, len(paths))
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = fileToJSON(path, expandEnv(data)); err != nil {
+			return nil, fmt.Errorf("failed to parse overlay %s: %w", path, err)
+		}
+		files = append(files, &configFile{path: path, data: data})
+	}
+	return files, nil
+}
+
+// applyOverlays applies the overlay files to the config in order. An overlay
+// is either a strategic merge patch, or a JSON patch (RFC 6902) if it's a list
+// of operations. In the strategic merge patch, the endpoints are matched by
+// host, method and path, the middlewares by name and the backends by target,
+// and the matched item is merged, or deleted with `$patch: delete`. The other
+// lists are replaced, the null value deletes the field. The fields are in the
+// JSON names, e.g. perTryTimeout.
+func applyOverlays(c *configv1.Gateway, overlays []*configFile) (*configv1.Gateway, error) {
+	if len(overlays) == 0 {
+		return c, nil
+	}
+	data, err := protojson.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		jsonData, err := toJSON(overlay.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse overlay %s: %w", overlay.path, err)
+		}
+		patch, err := decodeJSON(jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse overlay %s: %w", overlay.path, err)
+		}
+		if ops, ok := patch.([]interface{}); ok {
+			doc, err = applyJSONPatch(doc, ops)
+		} else {
+			doc, err = strategicMerge("", doc, patch)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply overlay %s: %w", overlay.path, err)
+		}
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+	out := &configv1.Gateway{}
+	if err := _jsonOptions.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("invalid config after applying overlays: %w", err)
+	}
+	return out, nil
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func strategicMerge(field string, dst, src interface{}) (interface{}, error) {
+	switch patch := src.(type) {
+	case map[string]interface{}:
+		obj, ok := dst.(map[string]interface{})
+		if !ok {
+			obj = map[string]interface{}{}
+		}
+		for key, value := range patch {
+			if value == nil {
+				delete(obj, key)
+				continue
+			}
+			merged, err := strategicMerge(key, obj[key], value)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%w", key, err)
+			}
+			obj[key] = merged
+		}
+		return obj, nil
+	case []interface{}:
+		keyOf, ok := _mergeKeys[field]
+		list, isList := dst.([]interface{})
+		if !ok || !isList {
+			return patch, nil
+		}
+		for i, item := range patch {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[%d]: the item of %s should be an object", i, field)
+			}
+			directive, _ := obj["$patch"].(string)
+			delete(obj, "$patch")
+			key := keyOf(obj)
+			matched := -1
+			for j, existing := range list {
+				if e, ok := existing.(map[string]interface{}); ok && keyOf(e) == key {
+					matched = j
+					break
+				}
+			}
+			switch {
+			case directive == "delete":
+				if matched >= 0 {
+					list = append(list[:matched], list[matched+1:]...)
+				}
+			case directive != "":
+				return nil, fmt.Errorf("[%d]: unknown patch directive: %s", i, directive)
+			case matched >= 0:
+				merged, err := strategicMerge("", list[matched], obj)
+				if err != nil {
+					return nil, fmt.Errorf("[%d]%w", i, err)
+				}
+				list[matched] = merged
+			default:
+				list = append(list, obj)
+			}
+		}
+		return list, nil
+	default:
+		return src, nil
+	}
+}
+
+// applyJSONPatch applies the add, remove, replace and test operations of JSON patch.
+func applyJSONPatch(doc interface{}, ops []interface{}) (interface{}, error) {
+	for i, item := range ops {
+		op, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation [%d] should be an object", i)
+		}
+		name, _ := op["op"].(string)
+		path, _ := op["path"].(string)
+		tokens, err := parsePointer(path)
+		if err != nil {
+			return nil, fmt.Errorf("operation [%d]: %w", i, err)
+		}
+		switch name {
+		case "add", "replace", "remove", "test":
+		default:
+			return nil, fmt.Errorf("operation [%d]: unsupported op: %q", i, name)
+		}
+		if name == "test" {
+			value, err := lookupPointer(doc, tokens)
+			if err != nil {
+				return nil, fmt.Errorf("operation [%d]: %w", i, err)
+			}
+			if !reflect.DeepEqual(value, op["value"]) {
+				return nil, fmt.Errorf("operation [%d]: test failed on path: %s", i, path)
+			}
+			continue
+		}
+		if doc, err = patchPointer(doc, tokens, name, op["value"]); err != nil {
+			return nil, fmt.Errorf("operation [%d]: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer: %q", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func lookupPointer(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("path is not found: %s", token)
+			}
+			doc = value
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("invalid index: %s", token)
+			}
+			doc = v[idx]
+		default:
+			return nil, fmt.Errorf("path is not found: %s", token)
+		}
+	}
+	return doc, nil
+}
+
+// patchPointer applies the operation on the parent of the last token.
+func patchPointer(doc interface{}, tokens []string, op string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op == "remove" {
+			return nil, fmt.Errorf("the whole document can not be removed")
+		}
+		return value, nil
+	}
+	token := tokens[0]
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if len(tokens) > 1 {
+			child, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("path is not found: %s", token)
+			}
+			patched, err := patchPointer(child, tokens[1:], op, value)
+			if err != nil {
+				return nil, err
+			}
+			v[token] = patched
+			return v, nil
+		}
+		if _, ok := v[token]; !ok && op != "add" {
+			return nil, fmt.Errorf("path is not found: %s", token)
+		}
+		if op == "remove" {
+			delete(v, token)
+		} else {
+			v[token] = value
+		}
+		return v, nil
+	case []interface{}:
+		idx := len(v)
+		if token != "-" {
+			var err error
+			if idx, err = strconv.Atoi(token); err != nil || idx < 0 || idx > len(v) {
+				return nil, fmt.Errorf("invalid index: %s", token)
+			}
+		}
+		if len(tokens) > 1 || op != "add" {
+			if idx >= len(v) {
+				return nil, fmt.Errorf("invalid index: %s", token)
+			}
+		}
+		if len(tokens) > 1 {
+			patched, err := patchPointer(v[idx], tokens[1:], op, value)
+			if err != nil {
+				return nil, err
+			}
+			v[idx] = patched
+			return v, nil
+		}
+		switch op {
+		case "add":
+			v = append(v, nil)
+			copy(v[idx+1:], v[idx:])
+			v[idx] = value
+		case "remove":
+			v = append(v[:idx], v[idx+1:]...)
+		default:
+			v[idx] = value
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("path is not found: %s", token)
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileLoaderOverlays(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	merge := write("prod.yaml", `
+version: prod
+hosts: null
+middlewares:
+  - name: tracing
+    $patch: delete
+endpoints:
+  - path: /helloworld/*
+    timeout: 3s
+    backends:
+      - target: 127.0.0.1:8000
+        weight: 10
+      - target: 127.0.0.1:8001
+  - path: /helloworld.Greeter/*
+    method: POST
+    retry:
+      attempts: 5
+  - path: /canary/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8002
+`)
+	patch := write("patch.json", `[
+  {"op": "test", "path": "/version", "value": "prod"},
+  {"op": "replace", "path": "/endpoints/1/retry/perTryTimeout", "value": "0.2s"},
+  {"op": "remove", "path": "/endpoints/1/retry/conditions/1"},
+  {"op": "add", "path": "/endpoints/2/metadata", "value": {"canary": "true"}}
+]`)
+	fl := &FileLoader{
+		confPath: "./fixtures/config.yaml",
+		overlays: []string{merge, patch},
+	}
+	c, err := fl.Load(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "prod" || len(c.Hosts) != 0 || len(c.Middlewares) != 1 || c.Middlewares[0].Name != "cors" { //nolint:staticcheck
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	if len(c.Endpoints) != 3 {
+		t.Fatalf("unexpected endpoints: %+v", c.Endpoints)
+	}
+	e := c.Endpoints[0]
+	if e.Timeout.AsDuration() != time.Second*3 || e.Protocol.String() != "HTTP" || len(e.Backends) != 2 || e.Backends[0].GetWeight() != 10 {
+		t.Fatalf("unexpected endpoint: %+v", e)
+	}
+	e = c.Endpoints[1]
+	if e.Retry.Attempts != 5 || e.Retry.PerTryTimeout.AsDuration() != time.Millisecond*200 || len(e.Retry.Conditions) != 1 {
+		t.Fatalf("unexpected endpoint: %+v", e)
+	}
+	if e = c.Endpoints[2]; e.Path != "/canary/*" || e.Metadata["canary"] != "true" {
+		t.Fatalf("unexpected endpoint: %+v", e)
+	}
+
+	fl.overlays = []string{write("invalid.json", `[{"op": "test", "path": "/version", "value": "dev"}]`)}
+	if _, err := fl.Load(context.TODO()); err == nil {
+		t.Fatal("want the error of the failed test operation")
+	}
+}
+
+func TestOverlayGRPCMergeKey(t *testing.T) {
+	keyOf := _mergeKeys["endpoints"]
+	grpc := map[string]interface{}{"grpc": map[string]interface{}{"service": "helloworld.Greeter", "method": "SayHello"}}
+	path := map[string]interface{}{"path": "/helloworld.Greeter/SayHello"}
+	if keyOf(grpc) != keyOf(path) {
+		t.Errorf("want the gRPC route merged with its path: %q %q", keyOf(grpc), keyOf(path))
+	}
+}
+
+func TestOverlayDefaults(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("base.yaml", `
+defaults:
+  protocol: HTTP
+  timeout: 1s
+endpoints:
+  - path: /inherited/*
+    backends:
+      - target: 127.0.0.1:8000
+  - path: /overridden/*
+    timeout: 5s
+    backends:
+      - target: 127.0.0.1:8001
+`)
+	prod := write("prod.yaml", `
+defaults:
+  timeout: 9s
+`)
+	// the defaults of the overlays are applied to the endpoints of the base.
+	for _, check := range []bool{false, true} {
+		c, err := LoadFile(base, prod)
+		if check {
+			var data []byte
+			if data, err = Check(base, prod); err == nil {
+				c, err = Unmarshal(data)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := c.Endpoints[0]; e.Timeout.AsDuration() != 9*time.Second || e.Protocol.String() != "HTTP" {
+			t.Fatalf("want the defaults of the overlay inherited but got %+v", e)
+		}
+		if e := c.Endpoints[1]; e.Timeout.AsDuration() != 5*time.Second {
+			t.Fatalf("want the timeout of the endpoint kept but got %+v", e)
+		}
+	}
+}
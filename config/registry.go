@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var globalLoaders = NewLoaderRegistry()
+
+func init() {
+	Register("file", newFileLoaderFromURI)
+}
+
+// LoaderFactory creates a config loader from the config uri.
+type LoaderFactory func(uri *url.URL) (ConfigLoader, error)
+
+// LoaderRegistry is the interface for callers to get registered config loaders.
+type LoaderRegistry interface {
+	Register(scheme string, factory LoaderFactory)
+	Create(uri string) (ConfigLoader, error)
+}
+
+type loaderRegistry struct {
+	lock      sync.RWMutex
+	factories map[string]LoaderFactory
+}
+
+// NewLoaderRegistry returns a new config loader registry.
+func NewLoaderRegistry() LoaderRegistry {
+	return &loaderRegistry{
+		factories: map[string]LoaderFactory{},
+	}
+}
+
+func (r *loaderRegistry) Register(scheme string, factory LoaderFactory) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.factories[scheme] = factory
+}
+
+// Create creates the config loader by the scheme of the uri, the uri without
+// a scheme is a file path.
+func (r *loaderRegistry) Create(uri string) (ConfigLoader, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("config uri is empty")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("parse config uri error: %s", err)
+	}
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "file"
+	}
+	r.lock.RLock()
+	factory, ok := r.factories[scheme]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("config loader %s has not been registered", scheme)
+	}
+	loader, err := factory(u)
+	if err != nil {
+		return nil, fmt.Errorf("create config loader error: %w", err)
+	}
+	return loader, nil
+}
+
+// FilePath returns the file path of the file uri, e.g. file:///etc/gateway/config.yaml.
+func FilePath(uri *url.URL) string {
+	return uri.Host + uri.Path
+}
+
+// newFileLoaderFromURI creates the file loader, the poll interval is set by
+// the `interval` query, e.g. file:///etc/gateway/config.yaml?interval=5s.
+func newFileLoaderFromURI(uri *url.URL) (ConfigLoader, error) {
+	var opts []FileLoaderOption
+	if v := uri.Query().Get("interval"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %s", err)
+		}
+		opts = append(opts, WithPollInterval(interval))
+	}
+	return NewFileLoader(FilePath(uri), opts...)
+}
+
+// Register registers the config loader factory of the uri scheme.
+func Register(scheme string, factory LoaderFactory) {
+	globalLoaders.Register(scheme, factory)
+}
+
+// Create instantiates a config loader based on the scheme of `uri`.
+func Create(uri string) (ConfigLoader, error) {
+	return globalLoaders.Create(uri)
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestLoaderRegistry(t *testing.T) {
+	r := NewLoaderRegistry()
+	r.Register("mock", func(uri *url.URL) (ConfigLoader, error) {
+		if uri.Host == "" {
+			return nil, errors.New("host is required")
+		}
+		return &mockLoader{}, nil
+	})
+	if _, err := r.Create("mock://127.0.0.1/gateway"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Create("mock:///gateway"); err == nil {
+		t.Fatal("want factory error")
+	}
+	if _, err := r.Create("unknown://127.0.0.1/gateway"); err == nil {
+		t.Fatal("want unregistered error")
+	}
+	if _, err := r.Create(""); err == nil {
+		t.Fatal("want empty uri error")
+	}
+}
+
+func TestCreateFileLoader(t *testing.T) {
+	for _, uri := range []string{"fixtures/config.yaml", "file://fixtures/config.yaml?interval=1s"} {
+		loader, err := Create(uri)
+		if err != nil {
+			t.Fatalf("%s: %+v", uri, err)
+		}
+		if _, err := loader.Load(context.Background()); err != nil {
+			t.Fatalf("%s: %+v", uri, err)
+		}
+		loader.Close()
+	}
+}
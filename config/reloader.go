@@ -0,0 +1,424 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_metricConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "config_reloads_total",
+		Help:      "The total number of config reloads",
+	}, []string{"result"})
+	_metricConfigGeneration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "config_generation",
+		Help:      "The generation of the last applied and the last failed config",
+	}, []string{"state"})
+	_metricConfigLastReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "config_last_reload_successful",
+		Help:      "Whether the last config reload is successful",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(_metricConfigReloads)
+	prometheus.MustRegister(_metricConfigGeneration)
+	prometheus.MustRegister(_metricConfigLastReloadSuccess)
+}
+
+// ApplyFunc applies the gateway config, it should be atomic,
+// the current config must be kept serving when an error is returned.
+type ApplyFunc func(*configv1.Gateway) error
+
+const (
+	_historySize = 10
+	// the retry interval to resolve the expired secrets again on failure.
+	_secretRetryInterval = time.Second * 30
+)
+
+// Snapshot is an applied config generation.
+type Snapshot struct {
+	Generation int64     `json:"generation"`
+	SHA256     string    `json:"sha256"`
+	Version    string    `json:"version"`
+	AppliedAt  time.Time `json:"applied_at"`
+	// RollbackOf is the generation rolled back to, it's zero if it's not a rollback.
+	RollbackOf int64 `json:"rollback_of,omitempty"`
+
+	config *configv1.Gateway
+}
+
+// Config returns the config of the snapshot.
+func (s *Snapshot) Config() *configv1.Gateway {
+	return s.config
+}
+
+// Reloader loads and applies the config on change, the last known good
+// config keeps serving when the new generation fails to be loaded or applied.
+// The last applied snapshots are kept in history to be rolled back to.
+type Reloader struct {
+	loader      ConfigLoader
+	apply       ApplyFunc
+	handlers    []OnChangeV2
+	historySize int
+	source      string
+	auditSinks  []AuditSink
+
+	// applyLock serializes the config changes, the lock of the state is not
+	// held while the config is applied, e.g. waiting for the rollout quorum.
+	applyLock        sync.Mutex
+	lock             sync.RWMutex
+	generation       int64
+	history          []*Snapshot
+	failedGeneration int64
+	lastError        error
+	secretTimer      *time.Timer
+}
+
+// ReloaderOption is a reloader option.
+type ReloaderOption func(*Reloader)
+
+// WithHistorySize sets the number of the applied snapshots to keep, the default is 10.
+func WithHistorySize(size int) ReloaderOption {
+	return func(r *Reloader) {
+		r.historySize = size
+	}
+}
+
+// WithSource sets the config source recorded in the audit records, e.g. the config uri,
+// the userinfo and the secret query parameters of the uri are not recorded.
+func WithSource(source string) ReloaderOption {
+	return func(r *Reloader) {
+		r.source = redactSource(source)
+	}
+}
+
+// WithAuditSink adds the sink to record the applied config changes.
+func WithAuditSink(sink AuditSink) ReloaderOption {
+	return func(r *Reloader) {
+		r.auditSinks = append(r.auditSinks, sink)
+	}
+}
+
+// NewReloader new a config reloader.
+func NewReloader(loader ConfigLoader, apply ApplyFunc, opts ...ReloaderOption) *Reloader {
+	r := &Reloader{
+		loader:      loader,
+		apply:       apply,
+		historySize: _historySize,
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	if r.historySize < 1 {
+		r.historySize = 1
+	}
+	return r
+}
+
+func digest(c *configv1.Gateway) string {
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(c)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// current returns the last applied snapshot, it's nil before the first load.
+func (r *Reloader) current() *Snapshot {
+	if len(r.history) == 0 {
+		return nil
+	}
+	return r.history[len(r.history)-1]
+}
+
+// Reload loads and applies the config, it's an OnChange handler.
+func (r *Reloader) Reload() error {
+	r.applyLock.Lock()
+	defer r.applyLock.Unlock()
+
+	r.lock.Lock()
+	r.generation++
+	r.lock.Unlock()
+	resetSecretExpiry()
+	c, err := r.loader.Load(context.Background())
+	r.lock.Lock()
+	if err != nil {
+		r.fail(err)
+		r.lock.Unlock()
+		return err
+	}
+	r.refreshSecrets(resetSecretExpiry())
+	r.lock.Unlock()
+	return r.update(c, 0, "")
+}
+
+// refreshSecrets reloads the config to resolve the secrets again when they're expired.
+func (r *Reloader) refreshSecrets(expiry time.Time) {
+	if r.secretTimer != nil {
+		r.secretTimer.Stop()
+		r.secretTimer = nil
+	}
+	if expiry.IsZero() {
+		return
+	}
+	log.Infof("the resolved secrets of config are expired at: %s", expiry)
+	r.secretTimer = time.AfterFunc(time.Until(expiry), func() {
+		if err := r.Reload(); err != nil {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.refreshSecrets(time.Now().Add(_secretRetryInterval))
+		}
+	})
+}
+
+// Rollback applies the config of the generation in history again as a new generation.
+// The rolled back config keeps serving until the next config change of the loader.
+func (r *Reloader) Rollback(generation int64) error {
+	return r.rollback(generation, "")
+}
+
+// rollback rolls back to the generation, the actor is recorded in the audit records.
+func (r *Reloader) rollback(generation int64, actor string) error {
+	r.applyLock.Lock()
+	defer r.applyLock.Unlock()
+
+	var target *Snapshot
+	for _, s := range r.history {
+		if s.Generation == generation {
+			target = s
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("config generation: %d is not found in history", generation)
+	}
+	r.lock.Lock()
+	r.generation++
+	r.lock.Unlock()
+	log.Infof("rollback config to generation: %d as generation: %d", generation, r.generation)
+	return r.update(target.config, generation, actor)
+}
+
+func (r *Reloader) fail(err error) {
+	var applied int64
+	if current := r.current(); current != nil {
+		applied = current.Generation
+	}
+	log.Errorf("failed to reload config generation: %d: %v, keep serving with the last known good generation: %d", r.generation, err, applied)
+	r.failedGeneration = r.generation
+	r.lastError = err
+	_metricConfigReloads.WithLabelValues("failure").Inc()
+	_metricConfigGeneration.WithLabelValues("failed").Set(float64(r.generation))
+	_metricConfigLastReloadSuccess.Set(0)
+}
+
+// update applies the config as the current generation, the caller holds the
+// apply lock, and the state is locked after the config is applied only.
+func (r *Reloader) update(c *configv1.Gateway, rollbackOf int64, actor string) error {
+	generation := r.generation
+	var old *configv1.Gateway
+	previous := r.current()
+	if previous != nil {
+		old = previous.config
+	}
+	diff := ComputeDiff(old, c)
+	if old != nil && diff.Empty() {
+		log.Infof("config generation: %d is not changed, skip applying", generation)
+		// the config is reverted to the applied one after a failure, e.g. the
+		// bad change is rolled back in the source, the reload is recovered.
+		r.lock.Lock()
+		r.failedGeneration = 0
+		r.lastError = nil
+		r.lock.Unlock()
+		_metricConfigReloads.WithLabelValues("unchanged").Inc()
+		_metricConfigLastReloadSuccess.Set(1)
+		return nil
+	}
+	err := r.apply(c)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err != nil {
+		r.fail(err)
+		return err
+	}
+	if len(r.history) == r.historySize {
+		copy(r.history, r.history[1:])
+		r.history = r.history[:r.historySize-1]
+	}
+	snapshot := &Snapshot{
+		Generation: generation,
+		SHA256:     digest(c),
+		Version:    c.Version,
+		AppliedAt:  time.Now(),
+		RollbackOf: rollbackOf,
+		config:     c,
+	}
+	r.history = append(r.history, snapshot)
+	r.failedGeneration = 0
+	r.lastError = nil
+	_metricConfigReloads.WithLabelValues("success").Inc()
+	_metricConfigGeneration.WithLabelValues("applied").Set(float64(generation))
+	_metricConfigLastReloadSuccess.Set(1)
+	log.Infof("config generation: %d reloaded, %s", generation, diff)
+	r.audit(previous, snapshot, diff, actor)
+	var lastErr error
+	for _, fn := range r.handlers {
+		if err := fn(old, c, diff); err != nil {
+			log.Errorf("failed to execute config change handler of generation: %d: %v", generation, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// audit writes the audit record of the applied change to the sinks, the
+// failures of the sinks are logged only.
+func (r *Reloader) audit(old, current *Snapshot, diff *Diff, actor string) {
+	if len(r.auditSinks) == 0 {
+		return
+	}
+	record := newAuditRecord(old, current, diff)
+	record.Source = r.source
+	record.Actor = actor
+	ctx, cancel := context.WithTimeout(context.Background(), _auditTimeout)
+	defer cancel()
+	for _, sink := range r.auditSinks {
+		if err := sink.Write(ctx, record); err != nil {
+			log.Errorf("failed to write the audit record of config generation: %d: %v", current.Generation, err)
+		}
+	}
+}
+
+// Close stops refreshing the expired secrets.
+func (r *Reloader) Close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.refreshSecrets(time.Time{})
+}
+
+// Watch adds the handler called with the previous and the new config
+// with their diff after a new generation is applied.
+func (r *Reloader) Watch(fn OnChangeV2) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// Applied returns the last known good config and its generation.
+func (r *Reloader) Applied() (*configv1.Gateway, int64) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	current := r.current()
+	if current == nil {
+		return nil, 0
+	}
+	return current.config, current.Generation
+}
+
+// History returns the applied snapshots from the oldest to the latest.
+func (r *Reloader) History() []*Snapshot {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	out := make([]*Snapshot, len(r.history))
+	copy(out, r.history)
+	return out
+}
+
+// LastError returns the error of the last reload, nil if it's succeeded.
+func (r *Reloader) LastError() error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.lastError
+}
+
+// AppliedConfig is the dump of the applied config.
+type AppliedConfig struct {
+	*Snapshot
+	Config json.RawMessage `json:"config"`
+}
+
+// ConfigHandler returns the handler to dump the applied config as JSON together
+// with its generation, sha256 and apply time.
+func (r *Reloader) ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		r.lock.RLock()
+		current := r.current()
+		r.lock.RUnlock()
+		if current == nil {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = rw.Write([]byte("no config has been applied"))
+			return
+		}
+		b, err := protojson.Marshal(current.config)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte(err.Error()))
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(&AppliedConfig{Snapshot: current, Config: b})
+	})
+}
+
+// DebugHandler implemented debug handler.
+func (r *Reloader) DebugHandler() http.Handler {
+	debugMux := http.NewServeMux()
+	debugMux.HandleFunc("/debug/reloader/versions", func(rw http.ResponseWriter, req *http.Request) {
+		r.lock.RLock()
+		out := map[string]interface{}{
+			"generation":        r.generation,
+			"failed_generation": r.failedGeneration,
+			"versions":          r.history,
+		}
+		if r.lastError != nil {
+			out["last_error"] = r.lastError.Error()
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(out)
+		r.lock.RUnlock()
+	})
+	debugMux.HandleFunc("/debug/reloader/rollback", func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		generation, err := strconv.ParseInt(req.URL.Query().Get("generation"), 10, 64)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, _ = rw.Write([]byte(err.Error()))
+			return
+		}
+		actor := req.Header.Get("X-Gateway-Actor")
+		if actor == "" {
+			actor = req.RemoteAddr
+		}
+		if err := r.rollback(generation, actor); err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte(err.Error()))
+			return
+		}
+		_, generation = r.Applied()
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"generation": generation,
+		})
+	})
+	return debugMux
+}
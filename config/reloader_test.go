@@ -0,0 +1,182 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type mockLoader struct {
+	c   *configv1.Gateway
+	err error
+}
+
+func (m *mockLoader) Load(context.Context) (*configv1.Gateway, error) { return m.c, m.err }
+func (m *mockLoader) Watch(OnChange)                                  {}
+func (m *mockLoader) Close()                                          {}
+
+func TestReloader(t *testing.T) {
+	loader := &mockLoader{c: &configv1.Gateway{Version: "v1"}}
+	var applyErr error
+	var serving *configv1.Gateway
+	r := NewReloader(loader, func(c *configv1.Gateway) error {
+		if applyErr != nil {
+			return applyErr
+		}
+		serving = c
+		return nil
+	})
+	var changes []*Diff
+	r.Watch(func(old, current *configv1.Gateway, diff *Diff) error {
+		if old != nil && old.Version == current.Version {
+			t.Fatalf("unexpected change from %s to %s", old.Version, current.Version)
+		}
+		changes = append(changes, diff)
+		return nil
+	})
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	loader.err = errors.New("parse error")
+	if err := r.Reload(); err == nil {
+		t.Fatal("want load error")
+	}
+	loader.c, loader.err = &configv1.Gateway{Version: "v3"}, nil
+	applyErr = errors.New("apply error")
+	if err := r.Reload(); err == nil {
+		t.Fatal("want apply error")
+	}
+	c, generation := r.Applied()
+	if c.Version != "v1" || generation != 1 || serving.Version != "v1" {
+		t.Fatalf("want the last known good generation 1 but got: %d %+v", generation, c)
+	}
+	if r.LastError() != applyErr {
+		t.Fatalf("want last error %v but got %v", applyErr, r.LastError())
+	}
+
+	applyErr = nil
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if c, generation := r.Applied(); c.Version != "v3" || generation != 4 {
+		t.Fatalf("want generation 4 but got: %d %+v", generation, c)
+	}
+	// the unchanged config is not applied again.
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, generation := r.Applied(); generation != 4 {
+		t.Fatalf("want generation 4 but got: %d", generation)
+	}
+	if len(changes) != 2 || !changes[1].Metadata {
+		t.Fatalf("want 2 changes but got: %v", changes)
+	}
+}
+
+func TestReloaderRevertAfterFailure(t *testing.T) {
+	loader := &mockLoader{c: &configv1.Gateway{Version: "v1"}}
+	applyErr := errors.New("apply error")
+	r := NewReloader(loader, func(c *configv1.Gateway) error {
+		if c.Version == "v2" {
+			return applyErr
+		}
+		return nil
+	})
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	loader.c = &configv1.Gateway{Version: "v2"}
+	if err := r.Reload(); err != applyErr {
+		t.Fatalf("want apply error but got %v", err)
+	}
+	if r.LastError() == nil || r.failedGeneration != 2 || testutil.ToFloat64(_metricConfigLastReloadSuccess) != 0 {
+		t.Fatalf("want the failure recorded but got: %v %d", r.LastError(), r.failedGeneration)
+	}
+	// the config is reverted to the applied one.
+	loader.c = &configv1.Gateway{Version: "v1"}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if r.LastError() != nil || r.failedGeneration != 0 || testutil.ToFloat64(_metricConfigLastReloadSuccess) != 1 {
+		t.Fatalf("want the failure cleared but got: %v %d", r.LastError(), r.failedGeneration)
+	}
+	if _, generation := r.Applied(); generation != 1 {
+		t.Fatalf("want generation 1 but got: %d", generation)
+	}
+}
+
+func TestReloaderRollback(t *testing.T) {
+	loader := &mockLoader{}
+	var serving *configv1.Gateway
+	r := NewReloader(loader, func(c *configv1.Gateway) error {
+		serving = c
+		return nil
+	}, WithHistorySize(2))
+	for _, version := range []string{"v1", "v2", "v3"} {
+		loader.c = &configv1.Gateway{Version: version}
+		if err := r.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history := r.History()
+	if len(history) != 2 || history[0].Generation != 2 || history[1].Version != "v3" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if err := r.Rollback(1); err == nil {
+		t.Fatal("want an error of the evicted generation")
+	}
+	if err := r.Rollback(2); err != nil {
+		t.Fatal(err)
+	}
+	c, generation := r.Applied()
+	if c.Version != "v2" || generation != 4 || serving.Version != "v2" {
+		t.Fatalf("want the rolled back generation 4 but got: %d %+v", generation, c)
+	}
+	if rollback := r.History()[1]; rollback.RollbackOf != 2 || rollback.SHA256 != history[0].SHA256 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
+
+func TestReloaderConfigHandler(t *testing.T) {
+	loader := &mockLoader{c: &configv1.Gateway{Name: "gateway", Version: "v1"}}
+	r := NewReloader(loader, func(*configv1.Gateway) error { return nil })
+	w := httptest.NewRecorder()
+	r.ConfigHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/config", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want unavailable before the first load but got: %d", w.Code)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	r.ConfigHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want ok but got: %d %s", w.Code, w.Body.String())
+	}
+	out := struct {
+		Generation int64           `json:"generation"`
+		SHA256     string          `json:"sha256"`
+		AppliedAt  time.Time       `json:"applied_at"`
+		Config     json.RawMessage `json:"config"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	applied := &configv1.Gateway{}
+	if err := protojson.Unmarshal(out.Config, applied); err != nil {
+		t.Fatal(err)
+	}
+	if out.Generation != 1 || out.SHA256 != digest(loader.c) || out.AppliedAt.IsZero() || !proto.Equal(applied, loader.c) {
+		t.Fatalf("unexpected applied config: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,224 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const _refreshInterval = time.Second * 10
+
+var _ config.ConfigLoader = (*remoteLoader)(nil)
+
+func init() {
+	config.Register("http", NewFromURI)
+	config.Register("https", NewFromURI)
+}
+
+type remoteLoader struct {
+	url             string
+	bearerToken     string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	lock         sync.Mutex
+	etag         string
+	lastModified string
+	content      []byte
+
+	confSHA256  string
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// Option is a remote loader option.
+type Option func(*remoteLoader)
+
+// WithRefreshInterval sets the interval to refresh the remote config, the default is 10s.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(l *remoteLoader) {
+		l.refreshInterval = interval
+	}
+}
+
+// WithBearerToken sets the bearer token of the Authorization header.
+func WithBearerToken(token string) Option {
+	return func(l *remoteLoader) {
+		l.bearerToken = token
+	}
+}
+
+// WithHTTPClient sets the http client, e.g. with the custom TLS config.
+func WithHTTPClient(client *http.Client) Option {
+	return func(l *remoteLoader) {
+		l.client = client
+	}
+}
+
+// NewFromURI returns a config loader of the url, the `interval` and `token`
+// queries are stripped from the url to set the refresh interval and the
+// bearer token, e.g. https://config.example.com/gateway.yaml?interval=30s&token=secret.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	query := uri.Query()
+	var opts []Option
+	if interval := query.Get("interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %s", err)
+		}
+		opts = append(opts, WithRefreshInterval(d))
+	}
+	if token := query.Get("token"); token != "" {
+		opts = append(opts, WithBearerToken(token))
+	}
+	query.Del("interval")
+	query.Del("token")
+	remote := *uri
+	remote.RawQuery = query.Encode()
+	return New(remote.String(), opts...)
+}
+
+// New returns a config loader which fetches the gateway config from the url,
+// the config is refreshed by the conditional requests with the ETag and
+// Last-Modified validators of the last response.
+func New(url string, opts ...Option) (config.ConfigLoader, error) {
+	l := &remoteLoader{
+		url:             url,
+		refreshInterval: _refreshInterval,
+		client:          http.DefaultClient,
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *remoteLoader) initialize() error {
+	data, err := l.fetch(context.Background())
+	if err != nil {
+		return err
+	}
+	l.confSHA256 = sha256sum(data)
+	log.Infof("the initial remote config url: %s, sha256: %s", l.url, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+// fetch requests the config, the cached content is returned if it's not modified.
+func (l *remoteLoader) fetch(ctx context.Context) ([]byte, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if l.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+l.bearerToken)
+	}
+	if l.content != nil {
+		if l.etag != "" {
+			req.Header.Set("If-None-Match", l.etag)
+		}
+		if l.lastModified != "" {
+			req.Header.Set("If-Modified-Since", l.lastModified)
+		}
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if l.content != nil {
+			return l.content, nil
+		}
+		fallthrough
+	default:
+		return nil, fmt.Errorf("unexpected response status of remote config url: %s: %d: %s", l.url, resp.StatusCode, body)
+	}
+	l.etag = resp.Header.Get("ETag")
+	l.lastModified = resp.Header.Get("Last-Modified")
+	l.content = body
+	return body, nil
+}
+
+func (l *remoteLoader) Load(ctx context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from remote url: %s", l.url)
+	data, err := l.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := config.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *remoteLoader) Watch(fn config.OnChange) {
+	log.Info("add remote config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *remoteLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch remote config url: %s, refresh interval: %s", l.url, l.refreshInterval)
+	ticker := time.NewTicker(l.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		data, err := l.fetch(ctx)
+		if err != nil {
+			log.Errorf("watch remote config url: %s error: %+v", l.url, err)
+			continue
+		}
+		sha256hex := sha256sum(data)
+		if sha256hex == l.confSHA256 {
+			continue
+		}
+		log.Infof("remote config changed, reload config, last sha256: %s, new sha256: %s", l.confSHA256, sha256hex)
+		if err := l.handlers.Notify(); err != nil {
+			log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+			continue
+		}
+		l.confSHA256 = sha256hex
+	}
+}
+
+func (l *remoteLoader) Close() {
+	l.watchCancel()
+}
@@ -0,0 +1,141 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+type fakeServer struct {
+	lock        sync.Mutex
+	content     string
+	etag        string
+	notModified int64
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.Header.Get("If-None-Match") == f.etag {
+		atomic.AddInt64(&f.notModified, 1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Write([]byte(f.content))
+}
+
+func (f *fakeServer) set(content, etag string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.content = content
+	f.etag = etag
+}
+
+func TestRemoteLoader(t *testing.T) {
+	remote := &fakeServer{content: _config, etag: `"v1"`}
+	srv := httptest.NewServer(remote)
+	defer srv.Close()
+
+	if _, err := New(srv.URL); err == nil {
+		t.Fatal("want an error without the bearer token")
+	}
+	loader, err := New(srv.URL, WithBearerToken("token"), WithRefreshInterval(time.Millisecond*10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 1 || len(c.Middlewares) != 1 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	if atomic.LoadInt64(&remote.notModified) == 0 {
+		t.Fatal("want the cached config to be loaded")
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		select {
+		case notified <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	remote.set(_changedConfig, `"v2"`)
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+	c, err = loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %s", c.Version)
+	}
+}
+
+func TestNewFromURI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the queries of the loader are not sent to the server.
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.RawQuery != "env=prod" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(_config))
+	}))
+	defer srv.Close()
+
+	loader, err := config.Create(srv.URL + "/gateway.yaml?env=prod&token=token&interval=1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	if l := loader.(*remoteLoader); l.refreshInterval != time.Minute {
+		t.Fatalf("want the refresh interval 1m but got %s", l.refreshInterval)
+	}
+	if c, err := loader.Load(context.Background()); err != nil || c.Name != "helloworld" {
+		t.Fatalf("unexpected config: %+v %v", c, err)
+	}
+	if _, err := config.Create(srv.URL + "?interval=invalid"); err == nil {
+		t.Fatal("want the error of the invalid interval")
+	}
+}
@@ -0,0 +1,307 @@
+// Package rollout coordinates the config rollout of the gateway replicas,
+// a new config is applied only after a quorum of the replicas validated it.
+package rollout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	_heartbeatInterval = time.Second * 10
+	_pollInterval      = time.Second
+	_quorumTimeout     = time.Minute
+	_storeTimeout      = time.Second * 5
+)
+
+// The states of the replica.
+const (
+	StateApplied = "applied"
+	StateWaiting = "waiting"
+	StateFailed  = "failed"
+)
+
+// Store is the shared store of the replica status.
+type Store interface {
+	// Put sets the status of the replica.
+	Put(ctx context.Context, replica string, value []byte) error
+	// List returns the status of all replicas.
+	List(ctx context.Context) (map[string][]byte, error)
+}
+
+// Status is the rollout status of a replica.
+type Status struct {
+	Replica        string    `json:"replica"`
+	State          string    `json:"state"`
+	AppliedSHA256  string    `json:"applied_sha256,omitempty"`
+	AppliedVersion string    `json:"applied_version,omitempty"`
+	PendingSHA256  string    `json:"pending_sha256,omitempty"`
+	PendingVersion string    `json:"pending_version,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Heartbeat      time.Time `json:"heartbeat"`
+}
+
+// has reports whether the replica validated or applied the config.
+func (s *Status) has(sha256hex string) bool {
+	return s.PendingSHA256 == sha256hex || s.AppliedSHA256 == sha256hex
+}
+
+// Coordinator coordinates the config rollout, the replicas publish the
+// validated and applied configs to the store, and a new config is applied
+// only after a quorum of the alive replicas validated it.
+type Coordinator struct {
+	store             Store
+	replica           string
+	quorum            int
+	quorumTimeout     time.Duration
+	heartbeatInterval time.Duration
+	pollInterval      time.Duration
+
+	lock   sync.Mutex
+	status Status
+	cancel context.CancelFunc
+}
+
+// Option is a coordinator option.
+type Option func(*Coordinator)
+
+// WithQuorum sets the number of the replicas required to validate a config,
+// the default is the majority of the alive replicas.
+func WithQuorum(quorum int) Option {
+	return func(c *Coordinator) {
+		c.quorum = quorum
+	}
+}
+
+// WithQuorumTimeout sets the timeout to wait for the quorum, the default is 1m.
+func WithQuorumTimeout(timeout time.Duration) Option {
+	return func(c *Coordinator) {
+		c.quorumTimeout = timeout
+	}
+}
+
+// WithHeartbeatInterval sets the interval to publish the status, the replicas
+// missing 3 heartbeats are considered dead, the default is 10s.
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(c *Coordinator) {
+		c.heartbeatInterval = interval
+	}
+}
+
+// WithPollInterval sets the interval to check the quorum, the default is 1s.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Coordinator) {
+		c.pollInterval = interval
+	}
+}
+
+// New returns a rollout coordinator of the replica, the status is published
+// at the heartbeat interval until it's closed.
+func New(store Store, replica string, opts ...Option) *Coordinator {
+	c := &Coordinator{
+		store:             store,
+		replica:           replica,
+		quorumTimeout:     _quorumTimeout,
+		heartbeatInterval: _heartbeatInterval,
+		pollInterval:      _pollInterval,
+		status:            Status{Replica: replica, State: StateWaiting},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.heartbeat(ctx)
+	return c
+}
+
+func digest(c *configv1.Gateway) string {
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(c)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Coordinator) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.publish(ctx, nil); err != nil {
+			log.Errorf("failed to publish the rollout status of replica: %s: %v", c.replica, err)
+		}
+	}
+}
+
+// publish updates the status by fn and puts it to the store.
+func (c *Coordinator) publish(ctx context.Context, fn func(*Status)) error {
+	c.lock.Lock()
+	if fn != nil {
+		fn(&c.status)
+	}
+	c.status.Heartbeat = time.Now()
+	b, err := json.Marshal(&c.status)
+	c.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, _storeTimeout)
+	defer cancel()
+	return c.store.Put(ctx, c.replica, b)
+}
+
+// Replicas returns the status of the replicas sorted by name.
+func (c *Coordinator) Replicas(ctx context.Context) ([]*Status, error) {
+	values, err := c.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Status, 0, len(values))
+	for replica, value := range values {
+		s := &Status{}
+		if err := json.Unmarshal(value, s); err != nil {
+			log.Warnf("invalid rollout status of replica: %s: %v", replica, err)
+			continue
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Replica < out[j].Replica
+	})
+	return out, nil
+}
+
+// validated returns the number of the alive replicas which validated the
+// config, and the quorum required.
+func (c *Coordinator) validated(ctx context.Context, sha256hex string) (int, int, error) {
+	replicas, err := c.Replicas(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	deadline := time.Now().Add(-3 * c.heartbeatInterval)
+	var alive, validated int
+	for _, s := range replicas {
+		if s.Replica != c.replica && s.Heartbeat.Before(deadline) {
+			continue
+		}
+		alive++
+		if s.has(sha256hex) {
+			validated++
+		}
+	}
+	quorum := c.quorum
+	if quorum <= 0 {
+		quorum = alive/2 + 1
+	}
+	return validated, quorum, nil
+}
+
+// waitQuorum waits until a quorum of the replicas validated the config.
+func (c *Coordinator) waitQuorum(sha256hex string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.quorumTimeout)
+	defer cancel()
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		validated, quorum, err := c.validated(ctx, sha256hex)
+		if err != nil {
+			log.Errorf("failed to list the rollout status of replicas: %v", err)
+		} else if validated >= quorum {
+			log.Infof("config sha256: %s is validated by %d replicas, quorum: %d", sha256hex, validated, quorum)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("config sha256: %s is validated by %d replicas without the quorum %d in %s", sha256hex, validated, quorum, c.quorumTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Apply returns the ApplyFunc which publishes the config as validated and
+// applies it by next after the quorum, the current config keeps serving if
+// the quorum is not reached in time. The first config is applied anyway
+// to start serving. The reloader doesn't lock the applied config while
+// waiting for the quorum, the next config changes wait for the apply.
+func (c *Coordinator) Apply(next config.ApplyFunc) config.ApplyFunc {
+	return func(gw *configv1.Gateway) error {
+		sha256hex := digest(gw)
+		if err := c.publish(context.Background(), func(s *Status) {
+			s.State = StateWaiting
+			s.PendingSHA256 = sha256hex
+			s.PendingVersion = gw.Version
+			s.Error = ""
+		}); err != nil {
+			return fmt.Errorf("failed to publish the rollout status: %w", err)
+		}
+		if err := c.waitQuorum(sha256hex); err != nil {
+			c.lock.Lock()
+			first := c.status.AppliedSHA256 == ""
+			c.lock.Unlock()
+			if !first {
+				c.fail(err)
+				return err
+			}
+			log.Warnf("apply the first config without the quorum: %v", err)
+		}
+		if err := next(gw); err != nil {
+			c.fail(err)
+			return err
+		}
+		return c.publish(context.Background(), func(s *Status) {
+			s.State = StateApplied
+			s.AppliedSHA256 = sha256hex
+			s.AppliedVersion = gw.Version
+			s.PendingSHA256 = ""
+			s.PendingVersion = ""
+		})
+	}
+}
+
+func (c *Coordinator) fail(err error) {
+	if perr := c.publish(context.Background(), func(s *Status) {
+		s.State = StateFailed
+		s.Error = err.Error()
+	}); perr != nil {
+		log.Errorf("failed to publish the rollout status of replica: %s: %v", c.replica, perr)
+	}
+}
+
+// DebugHandler implemented debug handler.
+func (c *Coordinator) DebugHandler() http.Handler {
+	debugMux := http.NewServeMux()
+	debugMux.HandleFunc("/debug/rollout/replicas", func(rw http.ResponseWriter, req *http.Request) {
+		replicas, err := c.Replicas(req.Context())
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			_, _ = rw.Write([]byte(err.Error()))
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"replica":  c.replica,
+			"replicas": replicas,
+		})
+	})
+	return debugMux
+}
+
+// Close stops publishing the status.
+func (c *Coordinator) Close() {
+	c.cancel()
+}
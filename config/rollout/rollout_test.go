@@ -0,0 +1,189 @@
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+)
+
+type memoryStore struct {
+	lock   sync.Mutex
+	values map[string][]byte
+}
+
+func (s *memoryStore) Put(_ context.Context, replica string, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[replica] = value
+	return nil
+}
+
+func (s *memoryStore) List(context.Context) (map[string][]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	out := make(map[string][]byte, len(s.values))
+	for k, v := range s.values {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func newTestCoordinator(store Store, replica string) *Coordinator {
+	return New(store, replica,
+		WithQuorumTimeout(time.Millisecond*300),
+		WithPollInterval(time.Millisecond*10),
+		WithHeartbeatInterval(time.Millisecond*50),
+	)
+}
+
+func TestCoordinator(t *testing.T) {
+	store := &memoryStore{values: map[string][]byte{}}
+	a := newTestCoordinator(store, "a")
+	defer a.Close()
+	b := newTestCoordinator(store, "b")
+	defer b.Close()
+	c := newTestCoordinator(store, "c")
+	defer c.Close()
+
+	var lock sync.Mutex
+	applied := map[string]string{}
+	applyOf := func(replica string) func(*configv1.Gateway) error {
+		return func(gw *configv1.Gateway) error {
+			lock.Lock()
+			defer lock.Unlock()
+			applied[replica] = gw.Version
+			return nil
+		}
+	}
+	applyA, applyB, applyC := a.Apply(applyOf("a")), b.Apply(applyOf("b")), c.Apply(applyOf("c"))
+
+	// the first config is applied without the quorum.
+	v1 := &configv1.Gateway{Version: "v1"}
+	if err := applyA(v1); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyB(v1); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyC(v1); err != nil {
+		t.Fatal(err)
+	}
+
+	// a single replica can't apply v2 without the quorum of 3 replicas.
+	v2 := &configv1.Gateway{Version: "v2"}
+	if err := applyA(v2); err == nil {
+		t.Fatal("want the quorum error")
+	}
+	if applied["a"] != "v1" {
+		t.Fatalf("want v1 to keep serving but got: %s", applied["a"])
+	}
+
+	// a and b validated v2 concurrently, the majority is reached.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, apply := range []func(*configv1.Gateway) error{applyA, applyB} {
+		wg.Add(1)
+		go func(i int, apply func(*configv1.Gateway) error) {
+			defer wg.Done()
+			errs[i] = apply(v2)
+		}(i, apply)
+	}
+	wg.Wait()
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if applied["a"] != "v2" || applied["b"] != "v2" || applied["c"] != "v1" {
+		t.Fatalf("unexpected applied configs: %v", applied)
+	}
+
+	w := httptest.NewRecorder()
+	a.DebugHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/rollout/replicas", nil))
+	out := struct {
+		Replicas []*Status `json:"replicas"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Replicas) != 3 || out.Replicas[0].AppliedVersion != "v2" || out.Replicas[2].AppliedVersion != "v1" ||
+		out.Replicas[2].State != StateApplied {
+		t.Fatalf("unexpected replicas: %s", w.Body.String())
+	}
+}
+
+type staticLoader struct {
+	lock sync.Mutex
+	c    *configv1.Gateway
+}
+
+func (l *staticLoader) Load(context.Context) (*configv1.Gateway, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.c, nil
+}
+
+func (l *staticLoader) set(c *configv1.Gateway) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.c = c
+}
+
+func (l *staticLoader) Watch(config.OnChange) {}
+func (l *staticLoader) Close()                {}
+
+func TestApplyNotBlockingReloader(t *testing.T) {
+	store := &memoryStore{values: map[string][]byte{}}
+	a := New(store, "a",
+		WithQuorum(2),
+		WithQuorumTimeout(time.Second),
+		WithPollInterval(time.Millisecond*10),
+		WithHeartbeatInterval(time.Millisecond*50),
+	)
+	defer a.Close()
+	loader := &staticLoader{c: &configv1.Gateway{Version: "v1"}}
+	r := config.NewReloader(loader, a.Apply(func(*configv1.Gateway) error { return nil }))
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the reloader keeps serving the applied config while waiting for the quorum.
+	loader.set(&configv1.Gateway{Version: "v2"})
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- r.Reload()
+	}()
+	for deadline := time.Now().Add(time.Millisecond * 500); ; time.Sleep(time.Millisecond * 10) {
+		replicas, _ := a.Replicas(context.Background())
+		if len(replicas) == 1 && replicas[0].PendingVersion == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("want v2 waiting for the quorum")
+		}
+	}
+	done := make(chan string, 1)
+	go func() {
+		c, _ := r.Applied()
+		done <- c.Version
+	}()
+	select {
+	case version := <-done:
+		if version != "v1" {
+			t.Fatalf("want v1 to keep serving but got: %s", version)
+		}
+	case err := <-reloaded:
+		t.Fatalf("want the applied config read while waiting for the quorum but the reload returned: %v", err)
+	}
+	if err := <-reloaded; err == nil {
+		t.Fatal("want the quorum error")
+	}
+	if c, generation := r.Applied(); c.Version != "v1" || generation != 1 {
+		t.Fatalf("want v1 of generation 1 but got: %s %d", c.Version, generation)
+	}
+}
@@ -0,0 +1,100 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type etcdStore struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcdStore returns the store of the etcd keys under the prefix.
+func NewEtcdStore(client *clientv3.Client, prefix string) Store {
+	return &etcdStore{client: client, prefix: strings.TrimSuffix(prefix, "/") + "/"}
+}
+
+func (s *etcdStore) Put(ctx context.Context, replica string, value []byte) error {
+	_, err := s.client.Put(ctx, s.prefix+replica, string(value))
+	return err
+}
+
+func (s *etcdStore) List(ctx context.Context) (map[string][]byte, error) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		out[strings.TrimPrefix(string(kv.Key), s.prefix)] = kv.Value
+	}
+	return out, nil
+}
+
+type consulStore struct {
+	client *api.Client
+	prefix string
+}
+
+// NewConsulStore returns the store of the consul KV keys under the prefix.
+func NewConsulStore(client *api.Client, prefix string) Store {
+	return &consulStore{client: client, prefix: strings.Trim(prefix, "/") + "/"}
+}
+
+func (s *consulStore) Put(ctx context.Context, replica string, value []byte) error {
+	opts := &api.WriteOptions{}
+	_, err := s.client.KV().Put(&api.KVPair{Key: s.prefix + replica, Value: value}, opts.WithContext(ctx))
+	return err
+}
+
+func (s *consulStore) List(ctx context.Context) (map[string][]byte, error) {
+	opts := &api.QueryOptions{}
+	pairs, _, err := s.client.KV().List(s.prefix, opts.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(pairs))
+	for _, pair := range pairs {
+		out[strings.TrimPrefix(pair.Key, s.prefix)] = pair.Value
+	}
+	return out, nil
+}
+
+// NewStoreFromURI returns the store from the uri, e.g.
+// etcd://127.0.0.1:2379/gateway/rollout or consul://127.0.0.1:8500/gateway/rollout?token=secret.
+func NewStoreFromURI(uri string) (Store, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("parse rollout store uri error: %s", err)
+	}
+	switch u.Scheme {
+	case "etcd":
+		client, err := clientv3.New(clientv3.Config{
+			Endpoints:   strings.Split(u.Host, ","),
+			DialTimeout: 5 * time.Second,
+			Username:    u.Query().Get("username"),
+			Password:    u.Query().Get("password"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewEtcdStore(client, u.Path), nil
+	case "consul":
+		c := api.DefaultConfig()
+		c.Address = u.Host
+		c.Token = u.Query().Get("token")
+		client, err := api.NewClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return NewConsulStore(client, u.Path), nil
+	}
+	return nil, fmt.Errorf("unknown rollout store: %s", u.Scheme)
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// ErrEndpointNotFound is returned if the endpoint to remove is not found.
+	ErrEndpointNotFound = errors.New("endpoint is not found")
+	// ErrNotApplied is returned if the routes are changed before the config is applied.
+	ErrNotApplied = errors.New("no config has been applied")
+)
+
+// RouteWriter is implemented by the config loaders which persist the routes
+// changed by the admin API, e.g. the file, etcd and consul loaders, the
+// changes of the other loaders are kept until the next config change of the
+// loader.
+type RouteWriter interface {
+	// AddEndpoint adds the endpoint or replaces the endpoint of the same key.
+	AddEndpoint(ctx context.Context, e *configv1.Endpoint) error
+	// RemoveEndpoint removes the endpoint of the key, see EndpointKey.
+	RemoveEndpoint(ctx context.Context, key string) error
+}
+
+// AddEndpoint adds the endpoint, or replaces the endpoint of the same key, to
+// the applied config as a new generation, the change is persisted by the
+// loader if it's a RouteWriter. It reports whether the change is persisted.
+func (r *Reloader) AddEndpoint(e *configv1.Endpoint, actor string) (bool, error) {
+	key := EndpointKey(e)
+	return r.updateEndpoints(actor, func(c *configv1.Gateway) error {
+		endpoint := proto.Clone(e).(*configv1.Endpoint)
+		ApplyDefaults(&configv1.Gateway{Defaults: c.Defaults, Endpoints: []*configv1.Endpoint{endpoint}})
+		for i, old := range c.Endpoints {
+			if EndpointKey(old) == key {
+				c.Endpoints[i] = endpoint
+				return nil
+			}
+		}
+		c.Endpoints = append(c.Endpoints, endpoint)
+		return nil
+	}, func(w RouteWriter) error {
+		return w.AddEndpoint(context.Background(), e)
+	})
+}
+
+// RemoveEndpoint removes the endpoint of the key from the applied config as a
+// new generation, the change is persisted by the loader if it's a RouteWriter.
+// It reports whether the change is persisted.
+func (r *Reloader) RemoveEndpoint(key string, actor string) (bool, error) {
+	return r.updateEndpoints(actor, func(c *configv1.Gateway) error {
+		for i, e := range c.Endpoints {
+			if EndpointKey(e) == key {
+				c.Endpoints = append(c.Endpoints[:i], c.Endpoints[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, key)
+	}, func(w RouteWriter) error {
+		return w.RemoveEndpoint(context.Background(), key)
+	})
+}
+
+func (r *Reloader) updateEndpoints(actor string, edit func(*configv1.Gateway) error, persist func(RouteWriter) error) (bool, error) {
+	r.applyLock.Lock()
+	defer r.applyLock.Unlock()
+
+	current := r.current()
+	if current == nil {
+		return false, ErrNotApplied
+	}
+	c := proto.Clone(current.config).(*configv1.Gateway)
+	if err := edit(c); err != nil {
+		return false, err
+	}
+	if err := validate(c); err != nil {
+		return false, err
+	}
+	r.lock.Lock()
+	r.generation++
+	r.lock.Unlock()
+	if err := r.update(c, 0, actor); err != nil {
+		return false, err
+	}
+	w, ok := r.loader.(RouteWriter)
+	if !ok {
+		return false, nil
+	}
+	if err := persist(w); err != nil {
+		return false, fmt.Errorf("the route is applied but failed to be persisted: %w", err)
+	}
+	return true, nil
+}
+
+// AdminHandler returns the handler of the admin API to add the endpoint by
+// `POST /admin/routes` with the endpoint in JSON, and remove the endpoint by
+// `DELETE /admin/routes` with the endpoint in JSON or the host, method and
+// path in query, the endpoints of the applied config are listed by GET.
+func (r *Reloader) AdminHandler() http.Handler {
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc("/admin/routes", func(rw http.ResponseWriter, req *http.Request) {
+		actor := req.Header.Get("X-Gateway-Actor")
+		if actor == "" {
+			actor = req.RemoteAddr
+		}
+		var (
+			persisted bool
+			err       error
+		)
+		switch req.Method {
+		case http.MethodGet:
+			c, _ := r.Applied()
+			if c == nil {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = rw.Write([]byte("no config has been applied"))
+				return
+			}
+			b, err := protojson.Marshal(&configv1.Gateway{Endpoints: c.Endpoints})
+			if err != nil {
+				rw.WriteHeader(http.StatusInternalServerError)
+				_, _ = rw.Write([]byte(err.Error()))
+				return
+			}
+			rw.Header().Set("Content-Type", "application/json")
+			_, _ = rw.Write(b)
+			return
+		case http.MethodPost:
+			e, perr := readEndpoint(req)
+			if perr != nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				_, _ = rw.Write([]byte(perr.Error()))
+				return
+			}
+			persisted, err = r.AddEndpoint(e, actor)
+		case http.MethodDelete:
+			e := &configv1.Endpoint{
+				Host:   req.URL.Query().Get("host"),
+				Method: req.URL.Query().Get("method"),
+				Path:   req.URL.Query().Get("path"),
+			}
+			if e.Path == "" {
+				var perr error
+				if e, perr = readEndpoint(req); perr != nil {
+					rw.WriteHeader(http.StatusBadRequest)
+					_, _ = rw.Write([]byte(perr.Error()))
+					return
+				}
+			}
+			persisted, err = r.RemoveEndpoint(EndpointKey(e), actor)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			var errs ValidationErrors
+			switch {
+			case errors.Is(err, ErrEndpointNotFound):
+				rw.WriteHeader(http.StatusNotFound)
+			case errors.Is(err, ErrNotApplied):
+				rw.WriteHeader(http.StatusServiceUnavailable)
+			case errors.As(err, &errs):
+				rw.WriteHeader(http.StatusBadRequest)
+			default:
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+			_, _ = rw.Write([]byte(err.Error()))
+			return
+		}
+		_, generation := r.Applied()
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"generation": generation,
+			"persisted":  persisted,
+		})
+	})
+	return adminMux
+}
+
+func readEndpoint(req *http.Request) (*configv1.Endpoint, error) {
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	e := &configv1.Endpoint{}
+	if err := _jsonOptions.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if e.Path == "" {
+		return nil, errors.New("endpoint path is required")
+	}
+	return e, nil
+}
+
+// AddEndpoint adds the endpoint to the config file, the templates, the comments
+// and the order of the fields in the file are not kept. The endpoints of the
+// included files and the overlays are not changed.
+func (f *FileLoader) AddEndpoint(_ context.Context, e *configv1.Endpoint) error {
+	return f.editFile(func(data []byte) ([]byte, error) {
+		return AddEndpointData(data, e)
+	})
+}
+
+// RemoveEndpoint removes the endpoint of the key from the config file.
+func (f *FileLoader) RemoveEndpoint(_ context.Context, key string) error {
+	return f.editFile(func(data []byte) ([]byte, error) {
+		return RemoveEndpointData(data, key)
+	})
+}
+
+// AddEndpointData adds the endpoint, or replaces the endpoint of the same key,
+// to the JSON or YAML config data, it's shared by the RouteWriters of the
+// config loaders. The templates, the comments and the order of the fields are
+// not kept.
+func AddEndpointData(data []byte, e *configv1.Endpoint) ([]byte, error) {
+	raw, err := protojson.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	item, err := decodeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	key := EndpointKey(e)
+	return editEndpoints(data, func(endpoints []interface{}) ([]interface{}, error) {
+		for i, old := range endpoints {
+			if rawEndpointKey(old) == key {
+				endpoints[i] = item
+				return endpoints, nil
+			}
+		}
+		return append(endpoints, item), nil
+	})
+}
+
+// RemoveEndpointData removes the endpoint of the key from the JSON or YAML
+// config data.
+func RemoveEndpointData(data []byte, key string) ([]byte, error) {
+	return editEndpoints(data, func(endpoints []interface{}) ([]interface{}, error) {
+		for i, e := range endpoints {
+			if rawEndpointKey(e) == key {
+				return append(endpoints[:i], endpoints[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w in the config: %s", ErrEndpointNotFound, key)
+	})
+}
+
+// rawEndpointKey returns the key of the endpoint in the config file, it's
+// empty if the endpoint can't be parsed, e.g. the endpoint templates.
+func rawEndpointKey(item interface{}) string {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return ""
+	}
+	e := &configv1.Endpoint{}
+	if err := _jsonOptions.Unmarshal(data, e); err != nil {
+		return ""
+	}
+	return EndpointKey(e)
+}
+
+// editEndpoints rewrites the endpoints of the config data in its format.
+func editEndpoints(data []byte, edit func([]interface{}) ([]interface{}, error)) ([]byte, error) {
+	jsonData, err := toJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	isJSON := len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed)
+	doc, err := decodeJSON(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid config: not an object")
+	}
+	endpoints, _ := obj["endpoints"].([]interface{})
+	if endpoints, err = edit(endpoints); err != nil {
+		return nil, err
+	}
+	obj["endpoints"] = endpoints
+	if data, err = json.MarshalIndent(obj, "", "  "); err != nil {
+		return nil, err
+	}
+	if !isJSON {
+		return yaml.JSONToYAML(data)
+	}
+	return data, nil
+}
+
+// editFile rewrites the config file by the edit of its data.
+func (f *FileLoader) editFile(edit func([]byte) ([]byte, error)) error {
+	path := f.confPath
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() || strings.ToLower(filepath.Ext(path)) == ".toml" {
+		return fmt.Errorf("the routes can't be written to the config: %s", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if data, err = edit(data); err != nil {
+		return fmt.Errorf("%w: %s", err, path)
+	}
+	// the file is replaced by renaming to be reloaded at once.
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := ioutil.WriteFile(tmp, data, stat.Mode()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
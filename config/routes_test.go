@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+const routesConfig = `name: routes
+defaults:
+  protocol: HTTP
+endpoints:
+  - path: /api/*
+    backends:
+      - target: 127.0.0.1:8000
+`
+
+func adminRequest(t *testing.T, h http.Handler, method, target, body string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	out := map[string]interface{}{}
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, out
+}
+
+func TestAdminRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(routesConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loader, err := NewFileLoader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	var serving *configv1.Gateway
+	r := NewReloader(loader, func(c *configv1.Gateway) error {
+		serving = c
+		return nil
+	})
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	h := r.AdminHandler()
+
+	code, out := adminRequest(t, h, "POST", "/admin/routes", `{"path": "/portal/*", "method": "GET", "backends": [{"target": "127.0.0.1:9000"}]}`)
+	if code != http.StatusOK || out["persisted"] != true {
+		t.Fatalf("want the route added but got: %d %v", code, out)
+	}
+	if len(serving.Endpoints) != 2 || serving.Endpoints[1].Protocol != configv1.Protocol_HTTP {
+		t.Fatalf("want the endpoint applied with the defaults but got: %v", serving.Endpoints)
+	}
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Endpoints) != 2 || c.Endpoints[1].Path != "/portal/*" || c.Name != "routes" {
+		t.Fatalf("want the endpoint persisted but got: %v", c)
+	}
+
+	if code, _ := adminRequest(t, h, "POST", "/admin/routes", `{"path": "portal"}`); code != http.StatusBadRequest {
+		t.Fatalf("want the invalid endpoint rejected but got: %d", code)
+	}
+	if code, _ := adminRequest(t, h, "DELETE", "/admin/routes?path=/not-found", ""); code != http.StatusNotFound {
+		t.Fatalf("want not found but got: %d", code)
+	}
+	code, out = adminRequest(t, h, "DELETE", "/admin/routes?path=/portal/*&method=GET", "")
+	if code != http.StatusOK || out["persisted"] != true {
+		t.Fatalf("want the route removed but got: %d %v", code, out)
+	}
+	if len(serving.Endpoints) != 1 {
+		t.Fatalf("want the endpoint removed but got: %v", serving.Endpoints)
+	}
+	if c, err = LoadFile(path); err != nil || len(c.Endpoints) != 1 {
+		t.Fatalf("want the removal persisted but got: %v %v", c, err)
+	}
+}
+
+func TestAdminRoutesNotPersisted(t *testing.T) {
+	loader := &mockLoader{c: &configv1.Gateway{Name: "routes"}}
+	r := NewReloader(loader, func(c *configv1.Gateway) error { return nil })
+	if code, _ := adminRequest(t, r.AdminHandler(), "POST", "/admin/routes", `{"path": "/api/*", "backends": [{"target": "127.0.0.1:8000"}]}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("want unavailable before the config is applied but got: %d", code)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	persisted, err := r.AddEndpoint(&configv1.Endpoint{Path: "/api/*", Backends: []*configv1.Backend{{Target: "127.0.0.1:8000"}}}, "portal")
+	if err != nil || persisted {
+		t.Fatalf("want the endpoint applied only but got: %t %v", persisted, err)
+	}
+	if c, generation := r.Applied(); len(c.Endpoints) != 1 || generation != 2 {
+		t.Fatalf("want generation 2 but got: %d %v", generation, c)
+	}
+}
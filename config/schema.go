@@ -0,0 +1,296 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	_schemaDraft = "http://json-schema.org/draft-07/schema#"
+	// the message types of the middleware options in google.protobuf.Any.
+	_middlewarePackagePrefix = "gateway.middleware."
+)
+
+// _extraGatewayFields are the top-level fields of the config files which are
+// not the fields of the gateway config.
+var _extraGatewayFields = map[string]interface{}{
+	"includes": map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string"},
+	},
+	_templatesKey: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"values": map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"type": "object"},
+				},
+				"endpoints": map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"$ref": definitionRef(configv1.File_gateway_config_v1_gateway_proto.Messages().ByName("Endpoint"))},
+				},
+			},
+		},
+	},
+}
+
+// JSONSchema returns the JSON Schema of the gateway config files derived from
+// the proto descriptors. Both the proto and JSON field names are accepted, and
+// the middleware options are described by the registered option message types.
+func JSONSchema() ([]byte, error) {
+	g := &schemaGenerator{definitions: map[string]interface{}{}}
+	root := g.message((&configv1.Gateway{}).ProtoReflect().Descriptor())
+	properties := root["properties"].(map[string]interface{})
+	for name, schema := range _extraGatewayFields {
+		properties[name] = schema
+	}
+	root["$schema"] = _schemaDraft
+	root["title"] = "gateway config"
+	root["definitions"] = g.definitions
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func definitionRef(md protoreflect.MessageDescriptor) string {
+	return "#/definitions/" + string(md.FullName())
+}
+
+type schemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+// middlewareOptions returns the registered middleware option message types.
+func middlewareOptions() []protoreflect.MessageType {
+	var out []protoreflect.MessageType
+	protoregistry.GlobalTypes.RangeMessages(func(mt protoreflect.MessageType) bool {
+		if strings.HasPrefix(string(mt.Descriptor().FullName()), _middlewarePackagePrefix) {
+			out = append(out, mt)
+		}
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Descriptor().FullName() < out[j].Descriptor().FullName()
+	})
+	return out
+}
+
+// message returns the object schema of the message fields.
+func (g *schemaGenerator) message(md protoreflect.MessageDescriptor) map[string]interface{} {
+	properties := map[string]interface{}{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		schema := g.field(fd)
+		properties[string(fd.Name())] = schema
+		if fd.JSONName() != string(fd.Name()) {
+			properties[fd.JSONName()] = schema
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func (g *schemaGenerator) field(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	if fd.IsMap() {
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.singular(fd.MapValue()),
+		}
+	}
+	if fd.IsList() {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.singular(fd),
+		}
+	}
+	return g.singular(fd)
+}
+
+func (g *schemaGenerator) singular(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.StringKind:
+		return map[string]interface{}{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// the 64-bit integers are encoded as strings by protojson.
+		return map[string]interface{}{"type": []string{"integer", "string"}}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]interface{}, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]interface{}{"enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageRef(fd.Message())
+	}
+	return map[string]interface{}{"type": "integer"}
+}
+
+func (g *schemaGenerator) messageRef(md protoreflect.MessageDescriptor) map[string]interface{} {
+	switch md.FullName() {
+	case "google.protobuf.Duration":
+		return map[string]interface{}{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?s$`}
+	case "google.protobuf.Timestamp":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case "google.protobuf.Struct":
+		return map[string]interface{}{"type": "object"}
+	case "google.protobuf.Value":
+		return map[string]interface{}{}
+	case "google.protobuf.Any":
+		return g.any()
+	}
+	name := string(md.FullName())
+	if _, ok := g.definitions[name]; !ok {
+		// reserve the definition for the recursive messages.
+		g.definitions[name] = nil
+		g.definitions[name] = g.message(md)
+	}
+	return map[string]interface{}{"$ref": definitionRef(md)}
+}
+
+// any returns the schema of google.protobuf.Any, the fields are checked by
+// the message type of `@type`.
+func (g *schemaGenerator) any() map[string]interface{} {
+	var rules []interface{}
+	for _, mt := range middlewareOptions() {
+		md := mt.Descriptor()
+		then := g.message(md)
+		properties := then["properties"].(map[string]interface{})
+		properties["@type"] = map[string]interface{}{"type": "string"}
+		rules = append(rules, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"@type": map[string]interface{}{"const": "type.googleapis.com/" + string(md.FullName())},
+				},
+			},
+			"then": then,
+		})
+	}
+	out := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"@type"},
+		"properties": map[string]interface{}{
+			"@type": map[string]interface{}{"type": "string"},
+		},
+	}
+	if len(rules) > 0 {
+		out["allOf"] = rules
+	}
+	return out
+}
+
+// unknownFields reports the fields of the JSON config which are not defined by
+// the proto, they are discarded silently when the config is unmarshaled.
+func unknownFields(jsonData []byte) ([]ValidationError, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal(jsonData, &v); err != nil {
+		return nil, err
+	}
+	for name := range _extraGatewayFields {
+		delete(v, name)
+	}
+	c := &unknownFieldsChecker{}
+	c.message("", (&configv1.Gateway{}).ProtoReflect().Descriptor(), v)
+	return c.errors, nil
+}
+
+type unknownFieldsChecker struct {
+	validator
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func (c *unknownFieldsChecker) message(field string, md protoreflect.MessageDescriptor, value interface{}) {
+	switch md.FullName() {
+	case "google.protobuf.Any":
+		c.any(field, value)
+		return
+	case "google.protobuf.Duration", "google.protobuf.Timestamp",
+		"google.protobuf.Struct", "google.protobuf.Value":
+		return
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := md.Fields()
+	for _, name := range names {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+		if fd == nil {
+			c.addf(joinField(field, name), "unknown field of %s", md.FullName())
+			continue
+		}
+		c.field(joinField(field, name), fd, obj[name])
+	}
+}
+
+func (c *unknownFieldsChecker) field(field string, fd protoreflect.FieldDescriptor, value interface{}) {
+	switch {
+	case fd.IsMap():
+		if fd.MapValue().Kind() != protoreflect.MessageKind {
+			return
+		}
+		obj, _ := value.(map[string]interface{})
+		for key, v := range obj {
+			c.message(fmt.Sprintf("%s[%s]", field, key), fd.MapValue().Message(), v)
+		}
+	case fd.Kind() != protoreflect.MessageKind:
+	case fd.IsList():
+		list, _ := value.([]interface{})
+		for i, v := range list {
+			c.message(fmt.Sprintf("%s[%d]", field, i), fd.Message(), v)
+		}
+	default:
+		c.message(field, fd.Message(), value)
+	}
+}
+
+func (c *unknownFieldsChecker) any(field string, value interface{}) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	typeURL, _ := obj["@type"].(string)
+	mt, err := protoregistry.GlobalTypes.FindMessageByURL(typeURL)
+	if err != nil {
+		// the unknown types are reported by the unmarshaling.
+		return
+	}
+	fields := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if k != "@type" {
+			fields[k] = v
+		}
+	}
+	c.message(field, mt.Descriptor(), fields)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	b, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties  map[string]interface{} `json:"properties"`
+		Definitions map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"name", "endpoints", "middlewares", "includes", _templatesKey} {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("want the property %s of the gateway config", name)
+		}
+	}
+	endpoint, ok := schema.Definitions["gateway.config.v1.Endpoint"]
+	if !ok {
+		t.Fatal("want the definition of the endpoint")
+	}
+	if _, ok := endpoint.Properties["timeout"]; !ok {
+		t.Errorf("want the property timeout of the endpoint")
+	}
+	retry := schema.Definitions["gateway.config.v1.Retry"]
+	if !reflect.DeepEqual(retry.Properties["per_try_timeout"], retry.Properties["perTryTimeout"]) {
+		t.Errorf("want both the proto and JSON names of the field")
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	errs, err := unknownFields([]byte(`{
+		"name": "gateway",
+		"includes": ["routes/*.yaml"],
+		"endpoints": [{
+			"path": "/foo",
+			"timout": "1s",
+			"retry": {"perTryTimeout": "1s", "attempt": 3},
+			"metadata": {"key": "value"}
+		}],
+		"middlewares": [{"name": "logging", "options": {"@type": "type.googleapis.com/unknown.Type", "foo": 1}}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	want := []string{"endpoints[0].retry.attempt", "endpoints[0].timout"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("want unknown fields %v but got %v", want, fields)
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// _resolveTimeout bounds the decryption and resolution of the secrets of a config.
+const _resolveTimeout = time.Second * 30
+
+// _encPattern matches the encrypted values, e.g. ENC[aesgcm,base64 ciphertext].
+var _encPattern = regexp.MustCompile(`^ENC\[([A-Za-z0-9_-]+),([A-Za-z0-9+/=]*)\]$`)
+
+// Decrypter decrypts the ciphertext of the ENC[provider,ciphertext] values in
+// the config, e.g. by the AWS KMS, GCP KMS or age decrypters of the config/kms
+// package, or the local AES key of NewAESGCMDecrypter.
+type Decrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// DecrypterFunc is an adapter to allow the use of ordinary functions as Decrypter.
+type DecrypterFunc func(ctx context.Context, ciphertext []byte) ([]byte, error)
+
+// Decrypt calls f(ctx, ciphertext).
+func (f DecrypterFunc) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return f(ctx, ciphertext)
+}
+
+var globalDecrypters = &decrypters{
+	providers: map[string]Decrypter{},
+}
+
+type decrypters struct {
+	lock      sync.RWMutex
+	providers map[string]Decrypter
+}
+
+// RegisterDecrypter registers the decrypter of the provider in ENC[provider,ciphertext].
+func RegisterDecrypter(provider string, d Decrypter) {
+	globalDecrypters.lock.Lock()
+	defer globalDecrypters.lock.Unlock()
+	globalDecrypters.providers[provider] = d
+}
+
+func (d *decrypters) get(provider string) (Decrypter, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	decrypter, ok := d.providers[provider]
+	return decrypter, ok
+}
+
+// SecretResolver resolves the secret references of the scheme in the config,
+// e.g. vault:secret/data/gateway#tls_key. The ttl is the lease duration of the
+// secret, the config is reloaded to resolve it again when it's expired, zero
+// means the secret never expires.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (value string, ttl time.Duration, err error)
+}
+
+// SecretResolverFunc is an adapter to allow the use of ordinary functions as SecretResolver.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, time.Duration, error)
+
+// Resolve calls f(ctx, ref).
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, time.Duration, error) {
+	return f(ctx, ref)
+}
+
+var globalResolvers = &resolvers{
+	schemes: map[string]SecretResolver{},
+}
+
+type resolvers struct {
+	lock    sync.RWMutex
+	schemes map[string]SecretResolver
+	// expiry is the earliest expiry of the secrets resolved since the last reset.
+	expiry time.Time
+}
+
+// RegisterSecretResolver registers the resolver of the secret references `scheme:ref`.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	globalResolvers.lock.Lock()
+	defer globalResolvers.lock.Unlock()
+	globalResolvers.schemes[scheme] = r
+}
+
+func (r *resolvers) get(value string) (SecretResolver, string, bool) {
+	i := strings.IndexByte(value, ':')
+	if i <= 0 {
+		return nil, "", false
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	resolver, ok := r.schemes[value[:i]]
+	return resolver, value[i+1:], ok
+}
+
+func (r *resolvers) contains(data []byte) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for scheme := range r.schemes {
+		if bytes.Contains(data, []byte(`"`+scheme+":")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resolvers) observe(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	expiry := time.Now().Add(ttl)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.expiry.IsZero() || expiry.Before(r.expiry) {
+		r.expiry = expiry
+	}
+}
+
+// resetSecretExpiry resets and returns the earliest expiry of the resolved secrets.
+func resetSecretExpiry() time.Time {
+	globalResolvers.lock.Lock()
+	defer globalResolvers.lock.Unlock()
+	expiry := globalResolvers.expiry
+	globalResolvers.expiry = time.Time{}
+	return expiry
+}
+
+// resolveSecrets decrypts the encrypted string values and resolves the secret
+// references of the JSON config, the values must be the whole ENC[provider,ciphertext]
+// or `scheme:ref` string.
+func resolveSecrets(jsonData []byte) ([]byte, error) {
+	if !bytes.Contains(jsonData, []byte("ENC[")) && !globalResolvers.contains(jsonData) {
+		return jsonData, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), _resolveTimeout)
+	defer cancel()
+	v, err := resolveValue(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func resolveValue(ctx context.Context, in interface{}) (interface{}, error) {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			out, err := resolveValue(ctx, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			v[key] = out
+		}
+	case []interface{}:
+		for i, value := range v {
+			out, err := resolveValue(ctx, value)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			v[i] = out
+		}
+	case string:
+		if resolver, ref, ok := globalResolvers.get(v); ok {
+			value, ttl, err := resolver.Resolve(ctx, ref)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve secret %s: %w", v, err)
+			}
+			globalResolvers.observe(ttl)
+			return value, nil
+		}
+		matches := _encPattern.FindStringSubmatch(v)
+		if matches == nil {
+			return v, nil
+		}
+		decrypter, ok := globalDecrypters.get(matches[1])
+		if !ok {
+			return nil, fmt.Errorf("unknown decrypter provider: %s", matches[1])
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(matches[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ciphertext of provider %s: %w", matches[1], err)
+		}
+		plaintext, err := decrypter.Decrypt(ctx, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt by provider %s: %w", matches[1], err)
+		}
+		return string(plaintext), nil
+	}
+	return in, nil
+}
+
+// NewAESGCMDecrypter returns a decrypter with the local AES key of 16, 24 or 32 bytes,
+// the ciphertext is the nonce of 12 bytes followed by the AES-GCM sealed data.
+func NewAESGCMDecrypter(key []byte) (Decrypter, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return DecrypterFunc(func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		if len(ciphertext) < aead.NonceSize() {
+			return nil, errors.New("ciphertext is too short")
+		}
+		nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+		return aead.Open(nil, nonce, sealed, nil)
+	}), nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+func encrypt(t *testing.T, key []byte, plaintext string) string {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestDecryptValues(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	decrypter, err := NewAESGCMDecrypter(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterDecrypter("aesgcm", decrypter)
+	RegisterDecrypter("reverse", DecrypterFunc(func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		out := make([]byte, len(ciphertext))
+		for i, b := range ciphertext {
+			out[len(ciphertext)-1-i] = b
+		}
+		return out, nil
+	}))
+
+	c, err := Unmarshal([]byte(`
+name: ENC[aesgcm,` + encrypt(t, key, "helloworld") + `]
+version: ENC[reverse,` + base64.StdEncoding.EncodeToString([]byte("1v")) + `]
+endpoints:
+  - path: /helloworld/*
+    metadata:
+      token: ENC[aesgcm,` + encrypt(t, key, "multi\nline: secret") + `]
+      plain: not ENC[aesgcm,value]
+    retry:
+      attempts: 3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || c.Version != "v1" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	e := c.Endpoints[0]
+	if e.Metadata["token"] != "multi\nline: secret" || e.Metadata["plain"] != "not ENC[aesgcm,value]" || e.Retry.Attempts != 3 {
+		t.Fatalf("unexpected endpoint: %+v", e)
+	}
+
+	for _, data := range []string{
+		"name: ENC[unknown,aGVsbG8=]",
+		"name: ENC[aesgcm,aGVsbG8=]",
+		"name: ENC[aesgcm," + encrypt(t, make([]byte, 32), "helloworld") + "]",
+	} {
+		if _, err := Unmarshal([]byte(data)); err == nil || !strings.HasPrefix(err.Error(), "name: ") {
+			t.Fatalf("want the decrypt error of %s but got: %v", data, err)
+		}
+	}
+}
+
+type unmarshalLoader struct {
+	mockLoader
+	data string
+}
+
+func (l *unmarshalLoader) Load(context.Context) (*configv1.Gateway, error) {
+	return Unmarshal([]byte(l.data))
+}
+
+func TestResolveSecrets(t *testing.T) {
+	var resolved int64
+	RegisterSecretResolver("fake", SecretResolverFunc(func(ctx context.Context, ref string) (string, time.Duration, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return "", 0, errors.New("the secret is resolved without a deadline")
+		}
+		if ref != "gateway#version" {
+			return "", 0, errors.New("not found")
+		}
+		return fmt.Sprintf("v%d", atomic.AddInt64(&resolved, 1)), time.Millisecond * 50, nil
+	}))
+	defer func() {
+		globalResolvers.lock.Lock()
+		delete(globalResolvers.schemes, "fake")
+		globalResolvers.lock.Unlock()
+	}()
+
+	if _, err := Unmarshal([]byte("version: fake:unknown")); err == nil {
+		t.Fatal("want the resolve error")
+	}
+	c, err := Unmarshal([]byte("name: fakename:gateway\nversion: fake:gateway#version"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "fakename:gateway" || c.Version != "v1" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	r := NewReloader(&unmarshalLoader{data: "version: fake:gateway#version"}, func(*configv1.Gateway) error { return nil })
+	defer r.Close()
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	// the config is reloaded when the secret is expired.
+	time.Sleep(time.Millisecond * 200)
+	c, generation := r.Applied()
+	if generation < 2 || c.Version == "v2" {
+		t.Fatalf("the expired secret is not resolved again: %d %+v", generation, c)
+	}
+}
@@ -0,0 +1,260 @@
+package stream
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+const _initialTimeout = time.Second * 30
+
+var _ config.ConfigLoader = (*streamLoader)(nil)
+
+func init() {
+	config.Register("stream", NewFromURI)
+}
+
+type snapshot struct {
+	version string
+	config  *configv1.Gateway
+}
+
+type streamLoader struct {
+	client configv1.ConfigServiceClient
+	node   *configv1.Node
+	// the connection dialed by NewFromURI, it's closed with the loader.
+	conn *grpc.ClientConn
+
+	lock    sync.RWMutex
+	current snapshot
+
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// NewFromURI returns a config loader from the uri of the control plane, e.g.
+// stream://127.0.0.1:9000?name=gateway&id=gateway-0, the node id is the
+// hostname by default, and the `tls=true` query connects the control plane by TLS.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	query := uri.Query()
+	id := query.Get("id")
+	if id == "" {
+		id, _ = os.Hostname()
+	}
+	creds := insecure.NewCredentials()
+	if query.Get("tls") == "true" {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.Dial(uri.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	l, err := New(conn, &configv1.Node{Id: id, Name: query.Get("name")})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	l.(*streamLoader).conn = conn
+	return l, nil
+}
+
+// New returns a config loader which subscribes the gateway config snapshots
+// pushed by the control plane via ConfigService.StreamConfig. Every snapshot
+// is ACKed after it's applied by the OnChange handlers, or NACKed with the
+// error detail if it's invalid or failed to apply, and the last applied
+// snapshot keeps serving.
+func New(conn grpc.ClientConnInterface, node *configv1.Node) (config.ConfigLoader, error) {
+	l := &streamLoader{
+		client: configv1.NewConfigServiceClient(conn),
+		node:   node,
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *streamLoader) initialize() error {
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	stream, err := l.client.StreamConfig(watchCtx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := stream.Send(&configv1.ConfigRequest{Node: l.node}); err != nil {
+		cancel()
+		return err
+	}
+	type result struct {
+		resp *configv1.ConfigResponse
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := stream.Recv()
+		ch <- result{resp: resp, err: err}
+	}()
+	var r result
+	select {
+	case r = <-ch:
+	case <-time.After(_initialTimeout):
+		r.err = fmt.Errorf("no config snapshot is received from the control plane in %s", _initialTimeout)
+	}
+	if r.err != nil {
+		cancel()
+		return r.err
+	}
+	if err := validate(r.resp.Config); err != nil {
+		l.nack(stream, r.resp, err)
+		cancel()
+		return err
+	}
+	l.current = snapshot{version: r.resp.VersionInfo, config: r.resp.Config}
+	if err := l.ack(stream, r.resp); err != nil {
+		cancel()
+		return err
+	}
+	log.Infof("the initial config snapshot of the control plane, version: %s", r.resp.VersionInfo)
+	go l.watchproc(watchCtx, stream)
+	return nil
+}
+
+func validate(c *configv1.Gateway) error {
+	if c == nil {
+		return fmt.Errorf("the config of snapshot is missing")
+	}
+	if errs := config.Validate(c); len(errs) > 0 {
+		return config.ValidationErrors(errs)
+	}
+	return nil
+}
+
+func (l *streamLoader) ack(stream configv1.ConfigService_StreamConfigClient, resp *configv1.ConfigResponse) error {
+	return stream.Send(&configv1.ConfigRequest{
+		Node:          l.node,
+		VersionInfo:   resp.VersionInfo,
+		ResponseNonce: resp.Nonce,
+	})
+}
+
+func (l *streamLoader) nack(stream configv1.ConfigService_StreamConfigClient, resp *configv1.ConfigResponse, cause error) error {
+	log.Errorf("reject the config snapshot version: %s: %v, keep the version: %s", resp.VersionInfo, cause, l.version())
+	return stream.Send(&configv1.ConfigRequest{
+		Node:          l.node,
+		VersionInfo:   l.version(),
+		ResponseNonce: resp.Nonce,
+		ErrorDetail:   cause.Error(),
+	})
+}
+
+func (l *streamLoader) version() string {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.current.version
+}
+
+func (l *streamLoader) Load(_ context.Context) (*configv1.Gateway, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	log.Infof("loading config snapshot version: %s", l.current.version)
+	return proto.Clone(l.current.config).(*configv1.Gateway), nil
+}
+
+func (l *streamLoader) Watch(fn config.OnChange) {
+	log.Info("add config stream change event handler")
+	l.handlers.Add(fn)
+}
+
+// apply applies the snapshot by the handlers, it's rolled back on failure.
+func (l *streamLoader) apply(resp *configv1.ConfigResponse) error {
+	if err := validate(resp.Config); err != nil {
+		return err
+	}
+	l.lock.Lock()
+	last := l.current
+	if proto.Equal(last.config, resp.Config) {
+		l.current.version = resp.VersionInfo
+		l.lock.Unlock()
+		return nil
+	}
+	l.current = snapshot{version: resp.VersionInfo, config: resp.Config}
+	l.lock.Unlock()
+	log.Infof("config snapshot changed, reload config, last version: %s, new version: %s", last.version, resp.VersionInfo)
+	if err := l.handlers.Notify(); err != nil {
+		l.lock.Lock()
+		l.current = last
+		l.lock.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *streamLoader) watchproc(ctx context.Context, stream configv1.ConfigService_StreamConfigClient) {
+	log.Info("start watch config stream")
+	for {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Errorf("receive config stream error: %+v", err)
+				break
+			}
+			if err := l.apply(resp); err != nil {
+				err = l.nack(stream, resp, err)
+			} else {
+				err = l.ack(stream, resp)
+			}
+			if err != nil {
+				log.Errorf("send config stream error: %+v", err)
+				break
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+				log.Warn("the config stream is closed, the watch process will attempt again")
+			}
+			var err error
+			if stream, err = l.resubscribe(ctx); err == nil {
+				break
+			}
+			log.Errorf("subscribe config stream error: %+v", err)
+		}
+	}
+}
+
+// resubscribe opens a new stream with the last applied version.
+func (l *streamLoader) resubscribe(ctx context.Context) (configv1.ConfigService_StreamConfigClient, error) {
+	stream, err := l.client.StreamConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&configv1.ConfigRequest{Node: l.node, VersionInfo: l.version()}); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (l *streamLoader) Close() {
+	l.watchCancel()
+	if l.conn != nil {
+		l.conn.Close()
+	}
+}
@@ -0,0 +1,152 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type controlPlane struct {
+	configv1.UnimplementedConfigServiceServer
+	push     chan *configv1.ConfigResponse
+	requests chan *configv1.ConfigRequest
+}
+
+func (c *controlPlane) StreamConfig(stream configv1.ConfigService_StreamConfigServer) error {
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			c.requests <- req
+		}
+	}()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case resp := <-c.push:
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newSnapshot(version string, backends bool) *configv1.ConfigResponse {
+	e := &configv1.Endpoint{Path: "/helloworld/*", Protocol: configv1.Protocol_HTTP}
+	if backends {
+		e.Backends = []*configv1.Backend{{Target: "127.0.0.1:8000"}}
+	}
+	return &configv1.ConfigResponse{
+		VersionInfo: version,
+		Nonce:       "nonce-" + version,
+		Config:      &configv1.Gateway{Name: "helloworld", Version: version, Endpoints: []*configv1.Endpoint{e}},
+	}
+}
+
+func TestStreamLoader(t *testing.T) {
+	cp := &controlPlane{
+		push:     make(chan *configv1.ConfigResponse, 1),
+		requests: make(chan *configv1.ConfigRequest, 16),
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	configv1.RegisterConfigServiceServer(srv, cp)
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	expectRequest := func(version, nonce, errorDetail string) {
+		t.Helper()
+		select {
+		case req := <-cp.requests:
+			if req.Node.GetId() != "gateway-0" || req.VersionInfo != version || req.ResponseNonce != nonce || (req.ErrorDetail != "") != (errorDetail != "") {
+				t.Fatalf("unexpected request: %+v", req)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatal("the request is not received")
+		}
+	}
+
+	cp.push <- newSnapshot("v1", true)
+	loader, err := New(conn, &configv1.Node{Id: "gateway-0", Name: "helloworld"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	expectRequest("", "", "")
+	expectRequest("v1", "nonce-v1", "")
+
+	var applyErr error
+	loader.Watch(func() error {
+		c, err := loader.Load(context.Background())
+		if err != nil {
+			return err
+		}
+		if c.Version == "v4" {
+			return applyErr
+		}
+		return nil
+	})
+	// invalid snapshot without backends.
+	cp.push <- newSnapshot("v2", false)
+	expectRequest("v1", "nonce-v2", "invalid")
+	cp.push <- newSnapshot("v3", true)
+	expectRequest("v3", "nonce-v3", "")
+
+	applyErr = errors.New("apply error")
+	cp.push <- newSnapshot("v4", true)
+	expectRequest("v3", "nonce-v4", "apply error")
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v3" {
+		t.Fatalf("want the last applied version v3 but got: %s", c.Version)
+	}
+}
+
+func TestNewFromURI(t *testing.T) {
+	cp := &controlPlane{
+		push:     make(chan *configv1.ConfigResponse, 1),
+		requests: make(chan *configv1.ConfigRequest, 16),
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	configv1.RegisterConfigServiceServer(srv, cp)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	cp.push <- newSnapshot("v1", true)
+	loader, err := config.Create("stream://" + lis.Addr().String() + "?id=gateway-0&name=helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	if req := <-cp.requests; req.Node.GetId() != "gateway-0" || req.Node.GetName() != "helloworld" {
+		t.Fatalf("unexpected node: %+v", req.Node)
+	}
+	if c, err := loader.Load(context.Background()); err != nil || c.Version != "v1" {
+		t.Fatalf("want version v1 but got: %+v %v", c, err)
+	}
+}
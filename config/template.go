@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// _templatesKey is the top-level key of the endpoint templates, it's not a
+// field of the gateway config and is removed after the expansion.
+const _templatesKey = "endpoint_templates"
+
+// endpointTemplate expands the endpoints for each of its values, e.g.
+//
+//	endpoint_templates:
+//	  - values:
+//	      - {name: user, port: 8000, prefix: /user}
+//	      - {name: order, port: 8001, prefix: /order}
+//	    endpoints:
+//	      - path: "{{.prefix}}/*"
+//	        protocol: HTTP
+//	        backends:
+//	          - target: "{{.name}}.svc:{{.port}}"
+type endpointTemplate struct {
+	Values    []map[string]interface{} `json:"values"`
+	Endpoints []interface{}            `json:"endpoints"`
+}
+
+// expandTemplates appends the endpoints expanded from the endpoint templates
+// to the endpoints of the JSON config. The string values of the template
+// endpoints are evaluated by text/template with each of the values.
+func expandTemplates(jsonData []byte) ([]byte, error) {
+	if !bytes.Contains(jsonData, []byte(`"`+_templatesKey+`"`)) {
+		return jsonData, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var v map[string]interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	raw, ok := v[_templatesKey]
+	if !ok {
+		return jsonData, nil
+	}
+	delete(v, _templatesKey)
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	decoder = json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var templates []*endpointTemplate
+	if err := decoder.Decode(&templates); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", _templatesKey, err)
+	}
+	endpoints, _ := v["endpoints"].([]interface{})
+	for i, t := range templates {
+		for j, values := range t.Values {
+			for k, e := range t.Endpoints {
+				out, err := renderValue(e, values)
+				if err != nil {
+					return nil, fmt.Errorf("%s[%d].endpoints[%d] with values[%d]: %w", _templatesKey, i, k, j, err)
+				}
+				endpoints = append(endpoints, out)
+			}
+		}
+	}
+	v["endpoints"] = endpoints
+	return json.Marshal(v)
+}
+
+// renderValue returns a copy of the JSON value with its string values rendered.
+func renderValue(in interface{}, data map[string]interface{}) (interface{}, error) {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			rendered, err := renderValue(value, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			out[key] = rendered
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			rendered, err := renderValue(value, data)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New("").Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	}
+	return in, nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestExpandTemplates(t *testing.T) {
+	c, err := Unmarshal([]byte(`
+name: gateway
+endpoints:
+  - path: /static/*
+    protocol: HTTP
+endpoint_templates:
+  - values:
+      - {name: user, port: 8000, prefix: /user}
+      - {name: order, port: 8001, prefix: /order}
+    endpoints:
+      - path: "{{.prefix}}/*"
+        protocol: HTTP
+        metadata:
+          service: "{{.name}}"
+        backends:
+          - target: "{{.name}}.svc:{{.port}}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Endpoints) != 3 {
+		t.Fatalf("want 3 endpoints but got: %d", len(c.Endpoints))
+	}
+	for i, want := range []struct{ path, target, service string }{
+		{path: "/user/*", target: "user.svc:8000", service: "user"},
+		{path: "/order/*", target: "order.svc:8001", service: "order"},
+	} {
+		e := c.Endpoints[i+1]
+		if e.Path != want.path || e.Backends[0].Target != want.target || e.Metadata["service"] != want.service {
+			t.Errorf("want %+v but got %+v", want, e)
+		}
+	}
+
+	_, err = Unmarshal([]byte(`
+endpoint_templates:
+  - values:
+      - {name: user}
+    endpoints:
+      - path: "{{.prefix}}/*"
+`))
+	if err == nil {
+		t.Fatal("want missing key error")
+	}
+}
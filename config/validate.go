@@ -0,0 +1,475 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/gateway/proxy/condition"
+	"github.com/go-kratos/gateway/router"
+	"github.com/go-kratos/gateway/router/matcher"
+	"github.com/go-kratos/gateway/router/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ValidationError is a config validation error on the field.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is returned by the loaders when the config is invalid.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+type validator struct {
+	errors []ValidationError
+}
+
+func (v *validator) addf(field string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the gateway config and returns all the validation errors.
+func Validate(c *configv1.Gateway) []ValidationError {
+	v := &validator{}
+	v.validateMiddlewares("middlewares", c.Middlewares)
+	v.validateCIDRs("trustedProxies", c.TrustedProxies)
+	v.validateTLS("tls", c.Tls)
+	v.validateTracing("tracing", c.Tracing)
+	r := mux.NewRouter(http.NotFoundHandler(), http.NotFoundHandler())
+	routes := make(map[string]int, len(c.Endpoints))
+	for i, e := range c.Endpoints {
+		field := fmt.Sprintf("endpoints[%d]", i)
+		v.validateEndpoint(field, e)
+		key := EndpointKey(e)
+		if j, ok := routes[key]; ok {
+			v.addf(field, "duplicate route of endpoints[%d]: %s %s %s", j, e.Host, e.Method, e.Path)
+			continue
+		}
+		routes[key] = i
+		if !strings.HasPrefix(e.Path, "/") {
+			continue
+		}
+		if _, err := router.ParseMethods(e.Method); err != nil {
+			// reported by validateEndpoint
+			continue
+		}
+		matchers, err := matcher.New(e)
+		if err != nil {
+			// reported by validateEndpoint
+			continue
+		}
+		if err := r.Handle(e.Path, e.Method, e.Host, http.NotFoundHandler(), matchers...); err != nil {
+			v.addf(field+".path", "invalid route: %s", err)
+		}
+	}
+	if c.Fallback != nil {
+		fallback := c.Fallback
+		if fallback.Path == "" {
+			fallback = proto.Clone(fallback).(*configv1.Endpoint)
+			fallback.Path = "/*"
+		}
+		v.validateEndpoint("fallback", fallback)
+	}
+	v.validateConsumers("consumers", c.Consumers, c.Endpoints)
+	return v.errors
+}
+
+// validate returns the ValidationErrors if the config is invalid.
+func validate(c *configv1.Gateway) error {
+	if errs := Validate(c); len(errs) > 0 {
+		return ValidationErrors(errs)
+	}
+	return nil
+}
+
+// Check loads and validates the config files of confPath with the overlays
+// without watching them, the normalized config is returned in JSON.
+// The ValidationErrors is returned if the config is invalid, including the
+// unknown fields of the config files which are discarded by the loaders.
+func Check(confPath string, overlays ...string) ([]byte, error) {
+	files, err := readConfigFiles(confPath)
+	if err != nil {
+		return nil, err
+	}
+	var errs []ValidationError
+	for _, file := range files {
+		jsonData, err := toJSON(file.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", file.path, err)
+		}
+		fieldErrs, err := unknownFields(jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", file.path, err)
+		}
+		for _, e := range fieldErrs {
+			e.Message += " in " + file.path
+			errs = append(errs, e)
+		}
+	}
+	out, err := LoadFile(confPath, overlays...)
+	if err != nil {
+		return nil, err
+	}
+	if errs = append(errs, Validate(out)...); len(errs) > 0 {
+		return nil, ValidationErrors(errs)
+	}
+	return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(out)
+}
+
+func (v *validator) validateMiddlewares(field string, ms []*configv1.Middleware) {
+	for i, m := range ms {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		if m.Name == "" {
+			v.addf(field+".name", "middleware name is required")
+			continue
+		}
+		if !middleware.Exists(m.Name) {
+			v.addf(field+".name", "unknown middleware: %s", m.Name)
+		}
+		if _, ok := configv1.Middleware_Stage_name[int32(m.Stage)]; !ok {
+			v.addf(field+".stage", "unknown middleware stage: %d", m.Stage)
+		}
+	}
+}
+
+func (v *validator) validateCIDRs(field string, cidrs []string) {
+	for i, cidr := range cidrs {
+		if _, err := clientip.ParseCIDR(cidr); err != nil {
+			v.addf(fmt.Sprintf("%s[%d]", field, i), "invalid CIDR: %s", err)
+		}
+	}
+}
+
+// the propagators of the tracing middleware.
+var _tracingPropagators = map[string]bool{"tracecontext": true, "baggage": true, "b3": true, "b3multi": true}
+
+func (v *validator) validateTracing(field string, c *configv1.Tracing) {
+	if c == nil {
+		return
+	}
+	if c.Endpoint == "" {
+		v.addf(field+".endpoint", "collector endpoint is required")
+	}
+	if c.SampleRatio != nil && (*c.SampleRatio < 0 || *c.SampleRatio > 1) {
+		v.addf(field+".sampleRatio", "sample ratio should be in [0, 1]: %v", *c.SampleRatio)
+	}
+	for i, name := range c.Propagators {
+		if !_tracingPropagators[name] {
+			v.addf(fmt.Sprintf("%s.propagators[%d]", field, i), "invalid propagator: %s", name)
+		}
+	}
+}
+
+func (v *validator) validateTLS(field string, c *configv1.TLS) {
+	names := make(map[string]int)
+	def := -1
+	for i, cert := range c.GetCertificates() {
+		field := fmt.Sprintf("%s.certificates[%d]", field, i)
+		if cert.CertFile == "" {
+			v.addf(field+".certFile", "certificate file is required")
+		}
+		if cert.KeyFile == "" {
+			v.addf(field+".keyFile", "key file is required")
+		}
+		for k, name := range cert.ServerNames {
+			name = strings.ToLower(name)
+			nameField := fmt.Sprintf("%s.serverNames[%d]", field, k)
+			if name == "" || strings.Contains(strings.TrimPrefix(name, "*."), "*") {
+				v.addf(nameField, "invalid server name: %q", name)
+			} else if j, ok := names[name]; ok {
+				v.addf(nameField, "duplicate server name of certificates[%d]: %s", j, name)
+			} else {
+				names[name] = i
+			}
+		}
+		if cert.Default {
+			if def >= 0 {
+				v.addf(field+".default", "duplicate default certificate of certificates[%d]", def)
+			}
+			def = i
+		}
+	}
+	if ca := c.GetClientAuth(); ca != nil && len(ca.CaFiles) == 0 {
+		v.addf(field+".clientAuth.caFiles", "client CA files are required")
+	}
+}
+
+func (v *validator) validateDuration(field string, d *durationpb.Duration) {
+	if d == nil {
+		return
+	}
+	if err := d.CheckValid(); err != nil {
+		v.addf(field, "invalid duration: %s", err)
+		return
+	}
+	if d.AsDuration() <= 0 {
+		v.addf(field, "duration should be greater than 0")
+	}
+}
+
+func (v *validator) validateEndpoint(field string, e *configv1.Endpoint) {
+	if e.Path == "" {
+		v.addf(field+".path", "path is required")
+	} else if !strings.HasPrefix(e.Path, "/") {
+		v.addf(field+".path", "path should start with '/': %s", e.Path)
+	}
+	if e.Method != "" && e.Method != "*" && strings.ToUpper(e.Method) != e.Method {
+		v.addf(field+".method", "method should be upper case: %s", e.Method)
+	} else if _, err := router.ParseMethods(e.Method); err != nil {
+		v.addf(field+".method", "%s", err)
+	}
+	v.validateDuration(field+".timeout", e.Timeout)
+	v.validateDuration(field+".responseHeaderTimeout", e.ResponseHeaderTimeout)
+	v.validateDuration(field+".idleTimeout", e.IdleTimeout)
+	if e.Hedge != nil {
+		v.validateDuration(field+".hedge.delay", e.Hedge.Delay)
+	}
+	if e.Timeout != nil && e.ResponseHeaderTimeout != nil && e.Timeout.CheckValid() == nil && e.ResponseHeaderTimeout.CheckValid() == nil &&
+		e.ResponseHeaderTimeout.AsDuration() > e.Timeout.AsDuration() {
+		v.addf(field+".responseHeaderTimeout", "response header timeout %s should not be greater than timeout %s", e.ResponseHeaderTimeout.AsDuration(), e.Timeout.AsDuration())
+	}
+	v.validateMiddlewares(field+".middlewares", e.Middlewares)
+	for i, h := range e.Headers {
+		if _, err := matcher.Header(h); err != nil {
+			v.addf(fmt.Sprintf("%s.headers[%d]", field, i), "invalid header matcher: %s", err)
+		}
+	}
+	for i, q := range e.Queries {
+		if _, err := matcher.Query(q); err != nil {
+			v.addf(fmt.Sprintf("%s.queries[%d]", field, i), "invalid query matcher: %s", err)
+		}
+	}
+	v.validateCIDRs(field+".sourceRanges", e.SourceRanges)
+	if e.Grpc != nil {
+		switch {
+		case e.Grpc.Service == "" || strings.ContainsAny(e.Grpc.Service, "/*{}"):
+			v.addf(field+".grpc.service", "invalid gRPC service: %q", e.Grpc.Service)
+		case strings.ContainsAny(e.Grpc.Method, "/*{}"):
+			v.addf(field+".grpc.method", "invalid gRPC method: %q", e.Grpc.Method)
+		case e.Path != GRPCPath(e.Grpc):
+			v.addf(field+".path", "path should not be set with the gRPC route: %s", e.Path)
+		}
+	}
+	if e.Redirect != nil && e.DirectResponse != nil {
+		v.addf(field+".directResponse", "direct response should not be set with redirect")
+	}
+	if e.DirectResponse != nil {
+		if code := e.DirectResponse.StatusCode; code != 0 && (code < 100 || code > 599) {
+			v.addf(field+".directResponse.statusCode", "invalid direct response status code: %d", code)
+		}
+		if file := e.DirectResponse.GetFile(); file != "" {
+			if _, err := os.Stat(file); err != nil {
+				v.addf(field+".directResponse.file", "invalid direct response file: %s", err)
+			}
+		}
+	} else if e.Redirect != nil {
+		switch e.Redirect.StatusCode {
+		case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+			http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			v.addf(field+".redirect.statusCode", "invalid redirect status code: %d", e.Redirect.StatusCode)
+		}
+	} else if len(e.Clusters) > 0 {
+		if len(e.Backends) > 0 {
+			v.addf(field+".backends", "backends should not be set with clusters")
+		}
+		v.validateClusters(field+".clusters", e.Clusters)
+	} else {
+		v.validateBackends(field+".backends", e.Backends)
+	}
+	if e.ClusterHash != nil {
+		switch key := e.ClusterHash.Key.(type) {
+		case *configv1.ClusterHash_Header:
+			if key.Header == "" {
+				v.addf(field+".clusterHash.header", "header name is required")
+			}
+		case *configv1.ClusterHash_Cookie:
+			if key.Cookie == "" {
+				v.addf(field+".clusterHash.cookie", "cookie name is required")
+			}
+		case *configv1.ClusterHash_ClientIp:
+			if !key.ClientIp {
+				v.addf(field+".clusterHash.clientIp", "client IP should be true")
+			}
+		default:
+			v.addf(field+".clusterHash", "cluster hash key is required")
+		}
+		if len(e.Clusters) == 0 {
+			v.addf(field+".clusterHash", "cluster hash should be set with clusters")
+		}
+	}
+	if e.Retry != nil {
+		v.validateDuration(field+".retry.perTryTimeout", e.Retry.PerTryTimeout)
+		if e.Timeout != nil && e.Retry.PerTryTimeout != nil && e.Timeout.CheckValid() == nil && e.Retry.PerTryTimeout.CheckValid() == nil &&
+			e.Retry.PerTryTimeout.AsDuration() > e.Timeout.AsDuration() {
+			v.addf(field+".retry.perTryTimeout", "per try timeout %s should not be greater than timeout %s", e.Retry.PerTryTimeout.AsDuration(), e.Timeout.AsDuration())
+		}
+		if _, err := condition.ParseConditon(e.Retry.Conditions...); err != nil {
+			v.addf(field+".retry.conditions", "invalid condition: %s", err)
+		}
+		v.validatePriorities(field+".retry.priorities", e.Retry.Priorities, e.Clusters)
+		for i, name := range e.Retry.RetriableGrpcCodes {
+			if !validGRPCCode(name) {
+				v.addf(fmt.Sprintf("%s.retry.retriableGrpcCodes[%d]", field, i), "invalid grpc code: %s", name)
+			}
+		}
+		if b := e.Retry.Backoff; b != nil {
+			v.validateDuration(field+".retry.backoff.baseInterval", b.BaseInterval)
+			v.validateDuration(field+".retry.backoff.maxInterval", b.MaxInterval)
+			if b.BaseInterval != nil && b.MaxInterval != nil && b.BaseInterval.CheckValid() == nil && b.MaxInterval.CheckValid() == nil &&
+				b.MaxInterval.AsDuration() < b.BaseInterval.AsDuration() {
+				v.addf(field+".retry.backoff.maxInterval", "max interval %s should not be less than base interval %s", b.MaxInterval.AsDuration(), b.BaseInterval.AsDuration())
+			}
+		}
+		if b := e.Retry.Budget; b != nil && (b.BudgetPercent < 0 || b.BudgetPercent > 100) {
+			v.addf(field+".retry.budget.budgetPercent", "budget percent should be in [0, 100]")
+		}
+	}
+}
+
+// validGRPCCode reports whether the name is the name or the number of a gRPC
+// status code, e.g. UNAVAILABLE or 14.
+func validGRPCCode(name string) bool {
+	var code codes.Code
+	if code.UnmarshalJSON([]byte(strconv.Quote(name))) == nil {
+		return true
+	}
+	n, err := strconv.ParseUint(name, 10, 32)
+	return err == nil && n <= uint64(codes.Unauthenticated)
+}
+
+func (v *validator) validatePriorities(field string, priorities []string, clusters []*configv1.Cluster) {
+	if len(priorities) > 0 && len(clusters) == 0 {
+		v.addf(field, "priorities should be set with clusters")
+		return
+	}
+	seen := make(map[string]bool, len(priorities))
+	for i, name := range priorities {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		if seen[name] {
+			v.addf(field, "duplicate cluster of priorities: %s", name)
+			continue
+		}
+		seen[name] = true
+		found := false
+		for _, c := range clusters {
+			if c.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.addf(field, "unknown cluster: %s", name)
+		}
+	}
+}
+
+func (v *validator) validateBackends(field string, backends []*configv1.Backend) {
+	if len(backends) == 0 {
+		v.addf(field, "at least one backend is required")
+	}
+	for i, b := range backends {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		if b.Target == "" {
+			v.addf(field+".target", "target is required")
+			continue
+		}
+		if idx := strings.Index(b.Target, "://"); idx >= 0 {
+			if scheme := b.Target[:idx]; scheme != "direct" && scheme != "discovery" {
+				v.addf(field+".target", "unknown target scheme: %s", scheme)
+			}
+		}
+		if b.Weight != nil && *b.Weight < 0 {
+			v.addf(field+".weight", "weight should not be negative: %d", *b.Weight)
+		}
+	}
+}
+
+func (v *validator) validateClusters(field string, clusters []*configv1.Cluster) {
+	names := make(map[string]int, len(clusters))
+	var total uint32
+	for i, c := range clusters {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		if c.Name == "" {
+			v.addf(field+".name", "cluster name is required")
+		} else if j, ok := names[c.Name]; ok {
+			v.addf(field+".name", "duplicate cluster name of clusters[%d]: %s", j, c.Name)
+		} else {
+			names[c.Name] = i
+		}
+		v.validateBackends(field+".backends", c.Backends)
+		total += c.Weight
+	}
+	if total == 0 {
+		v.addf(field, "the total weight of clusters should be greater than 0")
+	}
+}
+
+func (v *validator) validateConsumers(field string, consumers []*configv1.Consumer, endpoints []*configv1.Endpoint) {
+	paths := make(map[string]struct{}, len(endpoints))
+	for _, e := range endpoints {
+		paths[e.Path] = struct{}{}
+	}
+	names := make(map[string]int, len(consumers))
+	apiKeys := make(map[string]int)
+	subjects := make(map[string]int)
+	for i, c := range consumers {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		if c.Name == "" {
+			v.addf(field+".name", "consumer name is required")
+		} else if j, ok := names[c.Name]; ok {
+			v.addf(field+".name", "duplicate consumer name of consumers[%d]: %s", j, c.Name)
+		} else {
+			names[c.Name] = i
+		}
+		for k, key := range c.ApiKeys {
+			field := fmt.Sprintf("%s.apiKeys[%d]", field, k)
+			if key == "" {
+				v.addf(field, "api key should not be empty")
+			} else if j, ok := apiKeys[key]; ok && j != i {
+				v.addf(field, "api key is used by consumers[%d]", j)
+			} else {
+				apiKeys[key] = i
+			}
+		}
+		for k, sub := range c.JwtSubjects {
+			field := fmt.Sprintf("%s.jwtSubjects[%d]", field, k)
+			if sub == "" {
+				v.addf(field, "jwt subject should not be empty")
+			} else if j, ok := subjects[sub]; ok && j != i {
+				v.addf(field, "jwt subject is used by consumers[%d]", j)
+			} else {
+				subjects[sub] = i
+			}
+		}
+		for k, path := range c.AllowedRoutes {
+			if _, ok := paths[path]; !ok {
+				v.addf(fmt.Sprintf("%s.allowedRoutes[%d]", field, k), "unknown endpoint path: %s", path)
+			}
+		}
+		if c.RateLimit != nil && c.RateLimit.RequestsPerSecond <= 0 {
+			v.addf(field+".rateLimit.requestsPerSecond", "requests per second should be greater than 0")
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestValidate(t *testing.T) {
+	c := &configv1.Gateway{
+		Name:           "helloworld",
+		TrustedProxies: []string{"10.0.0.0/8", "proxy"},
+		Tls: &configv1.TLS{Certificates: []*configv1.Certificate{
+			{ServerNames: []string{"api.example.com", "*.example.com"}, CertFile: "api.pem", KeyFile: "api-key.pem", Default: true},
+			{ServerNames: []string{"API.example.com", "a.*.com"}, Default: true},
+		}, ClientAuth: &configv1.ClientAuth{Required: true}},
+		Tracing: &configv1.Tracing{SampleRatio: proto.Float32(2), Propagators: []string{"b3", "jaeger"}},
+		Middlewares: []*configv1.Middleware{
+			{Name: "logging"},
+			{Name: "not-exists"},
+			{Name: "logging", Stage: 10},
+		},
+		Endpoints: []*configv1.Endpoint{
+			{
+				Path:     "/helloworld/*",
+				Method:   "GET",
+				Backends: []*configv1.Backend{{Target: "127.0.0.1:8000"}},
+			},
+			{
+				Path:     "/helloworld/*",
+				Method:   "GET",
+				Backends: []*configv1.Backend{{Target: "127.0.0.1:8001"}},
+			},
+			{
+				Path:         "helloworld",
+				Timeout:      &durationpb.Duration{Seconds: -1},
+				IdleTimeout:  &durationpb.Duration{},
+				Hedge:        &configv1.Hedge{Delay: &durationpb.Duration{}},
+				Headers:      []*configv1.HeaderMatcher{{Name: "X-Api-Version", Match: &configv1.HeaderMatcher_Regex{Regex: "v[2-"}}},
+				Queries:      []*configv1.QueryMatcher{{Name: "beta"}},
+				SourceRanges: []string{"10.0.0.0/33"},
+			},
+			{
+				Path:     "/redirect",
+				Method:   "!GET,",
+				Redirect: &configv1.Redirect{StatusCode: 200},
+			},
+			{
+				Path:           "/robots.txt",
+				DirectResponse: &configv1.DirectResponse{StatusCode: 99, Content: &configv1.DirectResponse_File{File: "not-exists.txt"}},
+			},
+			{
+				Path:        "/clusters",
+				Backends:    []*configv1.Backend{{Target: "127.0.0.1:8000"}},
+				ClusterHash: &configv1.ClusterHash{},
+				Clusters: []*configv1.Cluster{
+					{Name: "stable", Backends: []*configv1.Backend{{Target: "127.0.0.1:8000"}}},
+					{Name: "stable"},
+				},
+				Retry: &configv1.Retry{Priorities: []string{"stable", "stable", "secondary"}},
+			},
+			{
+				Path:                  "/helloworld.Greeter/*",
+				Timeout:               &durationpb.Duration{Seconds: 1},
+				ResponseHeaderTimeout: &durationpb.Duration{Seconds: 2},
+				Backends:              []*configv1.Backend{{Target: "unknown:///helloworld"}},
+				Retry: &configv1.Retry{
+					PerTryTimeout: &durationpb.Duration{Seconds: 2},
+					Conditions: []*configv1.Condition{
+						{Condition: &configv1.Condition_ByStatusCode{ByStatusCode: "5xx"}},
+					},
+					Priorities:         []string{"primary"},
+					RetriableGrpcCodes: []string{"UNAVAILABLE", "14", "UNKNOWN_CODE"},
+					Backoff:            &configv1.Backoff{BaseInterval: &durationpb.Duration{Seconds: 1}, MaxInterval: &durationpb.Duration{Nanos: 1e8}},
+					Budget:             &configv1.RetryBudget{BudgetPercent: 120},
+				},
+			},
+		},
+		Fallback: &configv1.Endpoint{},
+		Consumers: []*configv1.Consumer{
+			{Name: "alice", ApiKeys: []string{"key-1"}, AllowedRoutes: []string{"/helloworld/*"}},
+			{Name: "alice", ApiKeys: []string{"key-1", ""}, JwtSubjects: []string{"bob"}},
+			{
+				JwtSubjects:   []string{"bob"},
+				AllowedRoutes: []string{"/unknown"},
+				RateLimit:     &configv1.ConsumerRateLimit{},
+			},
+		},
+	}
+	want := []string{
+		"middlewares[1].name",
+		"middlewares[2].stage",
+		"trustedProxies[1]",
+		"tls.certificates[1].certFile",
+		"tls.certificates[1].keyFile",
+		"tls.certificates[1].serverNames[0]",
+		"tls.certificates[1].serverNames[1]",
+		"tls.certificates[1].default",
+		"tls.clientAuth.caFiles",
+		"tracing.endpoint",
+		"tracing.sampleRatio",
+		"tracing.propagators[1]",
+		"endpoints[1]",
+		"endpoints[2].path",
+		"endpoints[2].timeout",
+		"endpoints[2].idleTimeout",
+		"endpoints[2].hedge.delay",
+		"endpoints[2].headers[0]",
+		"endpoints[2].queries[0]",
+		"endpoints[2].sourceRanges[0]",
+		"endpoints[2].backends",
+		"endpoints[3].method",
+		"endpoints[3].redirect.statusCode",
+		"endpoints[4].directResponse.statusCode",
+		"endpoints[4].directResponse.file",
+		"endpoints[5].backends",
+		"endpoints[5].clusters[1].name",
+		"endpoints[5].clusters[1].backends",
+		"endpoints[5].clusters",
+		"endpoints[5].clusterHash",
+		"endpoints[5].retry.priorities[1]",
+		"endpoints[5].retry.priorities[2]",
+		"endpoints[6].responseHeaderTimeout",
+		"endpoints[6].backends[0].target",
+		"endpoints[6].retry.perTryTimeout",
+		"endpoints[6].retry.conditions",
+		"endpoints[6].retry.priorities",
+		"endpoints[6].retry.retriableGrpcCodes[2]",
+		"endpoints[6].retry.backoff.maxInterval",
+		"endpoints[6].retry.budget.budgetPercent",
+		"fallback.backends",
+		"consumers[1].name",
+		"consumers[1].apiKeys[0]",
+		"consumers[1].apiKeys[1]",
+		"consumers[2].name",
+		"consumers[2].jwtSubjects[0]",
+		"consumers[2].allowedRoutes[0]",
+		"consumers[2].rateLimit.requestsPerSecond",
+	}
+	var got []string
+	for _, err := range Validate(c) {
+		got = append(got, err.Field)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want fields %v but got %v: %v", want, got, Validate(c))
+	}
+	if errs := Validate(equalTo()); len(errs) > 0 {
+		t.Errorf("want valid config but got: %v", errs)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	out, err := Check("./fixtures/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Unmarshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(c, equalTo()) {
+		t.Fatalf("unexpected normalized config: %s", out)
+	}
+
+	dir := t.TempDir()
+	confPath := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(confPath, []byte("endpoints:\n  - path: helloworld\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Check(confPath)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
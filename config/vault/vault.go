@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/gateway/config"
+)
+
+const (
+	// Scheme is the scheme of the vault secret references, e.g. vault:secret/data/gateway#tls_key.
+	Scheme = "vault"
+
+	_defaultTimeout = time.Second * 10
+)
+
+var _ config.SecretResolver = (*Resolver)(nil)
+
+// Resolver resolves the secret references by the vault HTTP API.
+type Resolver struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// Option is a vault resolver option.
+type Option func(*Resolver)
+
+// WithNamespace sets the vault enterprise namespace.
+func WithNamespace(namespace string) Option {
+	return func(r *Resolver) {
+		r.namespace = namespace
+	}
+}
+
+// WithHTTPClient sets the http client, e.g. with the custom TLS config.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Resolver) {
+		r.client = client
+	}
+}
+
+// New returns a vault secret resolver of the server addr, e.g. https://127.0.0.1:8200.
+func New(addr, token string, opts ...Option) *Resolver {
+	r := &Resolver{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: _defaultTimeout},
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Register registers the vault resolver of the scheme `vault`.
+func Register(addr, token string, opts ...Option) {
+	config.RegisterSecretResolver(Scheme, New(addr, token, opts...))
+}
+
+// Resolve reads the field of the secret path in `path#field`, both the KV v1, v2
+// and the dynamic secrets engines are supported.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, time.Duration, error) {
+	i := strings.LastIndexByte(ref, '#')
+	if i <= 0 || i == len(ref)-1 {
+		return "", 0, fmt.Errorf("invalid vault secret reference: %s, the format is path#field", ref)
+	}
+	path, field := strings.TrimPrefix(ref[:i], "/"), ref[i+1:]
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("X-Vault-Token", r.token)
+	if r.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.namespace)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("unexpected vault response status of %s: %d: %s", path, resp.StatusCode, body)
+	}
+	var secret struct {
+		LeaseDuration int64                  `json:"lease_duration"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", 0, err
+	}
+	data := secret.Data
+	// the data of KV v2 is nested with the metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", 0, fmt.Errorf("field %s is not found in vault secret: %s", field, path)
+	}
+	ttl := time.Duration(secret.LeaseDuration) * time.Second
+	switch v := value.(type) {
+	case string:
+		return v, ttl, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", 0, err
+		}
+		return string(b), ttl, nil
+	}
+}
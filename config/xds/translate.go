@@ -0,0 +1,266 @@
+package xds
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+)
+
+const _httpProtocolOptions = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+
+// errIncomplete is returned if the referenced resources are not received yet.
+var errIncomplete = errors.New("xds resources are incomplete")
+
+type resources struct {
+	routes    map[string]*routev3.RouteConfiguration
+	clusters  map[string]*clusterv3.Cluster
+	endpoints map[string]*endpointv3.ClusterLoadAssignment
+}
+
+func newResources() *resources {
+	return &resources{
+		routes:    make(map[string]*routev3.RouteConfiguration),
+		clusters:  make(map[string]*clusterv3.Cluster),
+		endpoints: make(map[string]*endpointv3.ClusterLoadAssignment),
+	}
+}
+
+// clusterNames returns the clusters referenced by the route configurations.
+func (r *resources) clusterNames(routeNames []string) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	add := func(name string) {
+		if _, ok := seen[name]; ok || name == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	for _, name := range routeNames {
+		for _, vh := range r.routes[name].GetVirtualHosts() {
+			for _, route := range vh.Routes {
+				action := route.GetRoute()
+				add(action.GetCluster())
+				for _, wc := range action.GetWeightedClusters().GetClusters() {
+					add(wc.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// edsName returns the EDS service name of the cluster, it's empty if the
+// endpoints are not discovered by EDS.
+func edsName(c *clusterv3.Cluster) string {
+	if c.GetType() != clusterv3.Cluster_EDS {
+		return ""
+	}
+	if name := c.GetEdsClusterConfig().GetServiceName(); name != "" {
+		return name
+	}
+	return c.Name
+}
+
+// edsNames returns the EDS service names of the clusters.
+func (r *resources) edsNames(clusterNames []string) []string {
+	var names []string
+	for _, name := range clusterNames {
+		if c, ok := r.clusters[name]; ok {
+			if eds := edsName(c); eds != "" {
+				names = append(names, eds)
+			}
+		}
+	}
+	return names
+}
+
+// translate translates the route configurations with the referenced clusters
+// and endpoints into the gateway config based on the base config.
+func (r *resources) translate(base *configv1.Gateway, routeNames []string) (*configv1.Gateway, error) {
+	out := proto.Clone(base).(*configv1.Gateway)
+	for _, name := range routeNames {
+		rc, ok := r.routes[name]
+		if !ok {
+			return nil, errIncomplete
+		}
+		for _, vh := range rc.VirtualHosts {
+			for _, route := range vh.Routes {
+				endpoints, err := r.translateRoute(vh, route)
+				if err != nil {
+					return nil, err
+				}
+				out.Endpoints = append(out.Endpoints, endpoints...)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *resources) translateRoute(vh *routev3.VirtualHost, route *routev3.Route) ([]*configv1.Endpoint, error) {
+	action := route.GetRoute()
+	if action == nil {
+		log.Warnf("xds route: %s of virtual host: %s is skipped, only the route action is supported", route.Name, vh.Name)
+		return nil, nil
+	}
+	e := &configv1.Endpoint{
+		Description: route.Name,
+		Protocol:    configv1.Protocol_HTTP,
+		Timeout:     action.Timeout,
+	}
+	match := route.GetMatch()
+	switch {
+	case match.GetPath() != "":
+		e.Path = match.GetPath()
+	case match.GetPrefix() != "":
+		e.Path = match.GetPrefix() + "*"
+	default:
+		log.Warnf("xds route: %s of virtual host: %s is skipped, only the path and prefix matches are supported", route.Name, vh.Name)
+		return nil, nil
+	}
+	for _, h := range match.GetHeaders() {
+		if h.Name == ":method" {
+			e.Method = h.GetStringMatch().GetExact()
+		}
+	}
+	if match.GetGrpc() != nil {
+		e.Protocol = configv1.Protocol_GRPC
+	}
+	if policy := action.GetRetryPolicy(); policy != nil {
+		e.Retry = translateRetry(policy)
+	}
+
+	type weighted struct {
+		name   string
+		weight int64
+	}
+	var clusters []weighted
+	if name := action.GetCluster(); name != "" {
+		clusters = append(clusters, weighted{name: name, weight: 1})
+	}
+	for _, wc := range action.GetWeightedClusters().GetClusters() {
+		clusters = append(clusters, weighted{name: wc.Name, weight: int64(wc.GetWeight().GetValue())})
+	}
+	if len(clusters) == 0 {
+		log.Warnf("xds route: %s of virtual host: %s is skipped, only the cluster and weighted clusters are supported", route.Name, vh.Name)
+		return nil, nil
+	}
+	for _, wc := range clusters {
+		c, ok := r.clusters[wc.name]
+		if !ok {
+			return nil, errIncomplete
+		}
+		if isHTTP2(c) {
+			e.Protocol = configv1.Protocol_GRPC
+		}
+		cla := c.LoadAssignment
+		if eds := edsName(c); eds != "" {
+			if cla, ok = r.endpoints[eds]; !ok {
+				return nil, errIncomplete
+			}
+		}
+		backends, err := translateEndpoints(cla, wc.weight, len(clusters) > 1)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xds cluster: %s: %w", c.Name, err)
+		}
+		e.Backends = append(e.Backends, backends...)
+	}
+
+	// the gateway endpoint is bound to only one host.
+	var out []*configv1.Endpoint
+	for _, domain := range vh.Domains {
+		if domain == "*" {
+			domain = ""
+		} else if strings.Contains(domain, "*") {
+			log.Warnf("xds domain: %s of virtual host: %s is skipped, the wildcard domains are not supported", domain, vh.Name)
+			continue
+		}
+		host := proto.Clone(e).(*configv1.Endpoint)
+		host.Host = domain
+		out = append(out, host)
+	}
+	return out, nil
+}
+
+func translateRetry(policy *routev3.RetryPolicy) *configv1.Retry {
+	out := &configv1.Retry{
+		Attempts:      1,
+		PerTryTimeout: policy.PerTryTimeout,
+	}
+	if policy.NumRetries != nil {
+		out.Attempts += policy.NumRetries.Value
+	}
+	for _, on := range strings.Split(policy.RetryOn, ",") {
+		switch strings.TrimSpace(on) {
+		case "5xx", "gateway-error":
+			out.Conditions = append(out.Conditions, &configv1.Condition{
+				Condition: &configv1.Condition_ByStatusCode{ByStatusCode: "500-599"},
+			})
+		case "retriable-status-codes":
+			for _, code := range policy.RetriableStatusCodes {
+				out.Conditions = append(out.Conditions, &configv1.Condition{
+					Condition: &configv1.Condition_ByStatusCode{ByStatusCode: strconv.FormatUint(uint64(code), 10)},
+				})
+			}
+		case "unavailable":
+			out.Conditions = append(out.Conditions, &configv1.Condition{
+				Condition: &configv1.Condition_ByHeader{ByHeader: &configv1.ConditionHeader{Name: "grpc-status", Value: "14"}},
+			})
+		case "cancelled", "deadline-exceeded", "internal", "resource-exhausted":
+			out.RetriableGrpcCodes = append(out.RetriableGrpcCodes, strings.ToUpper(strings.Replace(strings.TrimSpace(on), "-", "_", -1)))
+		}
+	}
+	if backoff := policy.RetryBackOff; backoff != nil {
+		out.Backoff = &configv1.Backoff{BaseInterval: backoff.BaseInterval, MaxInterval: backoff.MaxInterval}
+	}
+	return out
+}
+
+func isHTTP2(c *clusterv3.Cluster) bool {
+	if c.Http2ProtocolOptions != nil { //nolint:staticcheck
+		return true
+	}
+	any, ok := c.TypedExtensionProtocolOptions[_httpProtocolOptions]
+	if !ok {
+		return false
+	}
+	opts := &httpv3.HttpProtocolOptions{}
+	if err := any.UnmarshalTo(opts); err != nil {
+		return false
+	}
+	return opts.GetExplicitHttpConfig().GetHttp2ProtocolOptions() != nil
+}
+
+func translateEndpoints(cla *endpointv3.ClusterLoadAssignment, clusterWeight int64, weighted bool) ([]*configv1.Backend, error) {
+	var out []*configv1.Backend
+	for _, locality := range cla.GetEndpoints() {
+		for _, lb := range locality.LbEndpoints {
+			addr := lb.GetEndpoint().GetAddress().GetSocketAddress()
+			if addr == nil {
+				return nil, fmt.Errorf("only the socket address is supported")
+			}
+			backend := &configv1.Backend{
+				Target: net.JoinHostPort(addr.Address, strconv.FormatUint(uint64(addr.GetPortValue()), 10)),
+			}
+			if weighted || lb.LoadBalancingWeight != nil {
+				weight := clusterWeight
+				if lb.LoadBalancingWeight != nil {
+					weight *= int64(lb.LoadBalancingWeight.Value)
+				}
+				backend.Weight = &weight
+			}
+			out = append(out, backend)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,341 @@
+package xds
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	routeType    = "type.googleapis.com/envoy.config.route.v3.RouteConfiguration"
+	clusterType  = "type.googleapis.com/envoy.config.cluster.v3.Cluster"
+	endpointType = "type.googleapis.com/envoy.config.endpoint.v3.ClusterLoadAssignment"
+
+	_initialTimeout = time.Second * 30
+)
+
+var _ config.ConfigLoader = (*xdsLoader)(nil)
+
+func init() {
+	config.Register("xds", NewFromURI)
+}
+
+type xdsLoader struct {
+	client     discoveryv3.AggregatedDiscoveryServiceClient
+	node       *corev3.Node
+	routeNames []string
+	base       *configv1.Gateway
+	// the connection dialed by NewFromURI, it's closed with the loader.
+	conn *grpc.ClientConn
+
+	// the state of the ADS stream, it's only accessed by the watch process.
+	resources   *resources
+	versions    map[string]string
+	subscribed  map[string][]string
+	initialized chan struct{}
+
+	lock    sync.RWMutex
+	current *configv1.Gateway
+
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// Option is a xds loader option.
+type Option func(*xdsLoader)
+
+// WithNode sets the node identifier sent to the management server.
+func WithNode(node *corev3.Node) Option {
+	return func(l *xdsLoader) {
+		l.node = node
+	}
+}
+
+// WithBase sets the base gateway config, e.g. the name and the global
+// middlewares, the endpoints translated from xds are appended to it.
+func WithBase(base *configv1.Gateway) Option {
+	return func(l *xdsLoader) {
+		l.base = base
+	}
+}
+
+// NewFromURI returns a config loader of the comma separated route names from
+// the uri of the management server, e.g. xds://127.0.0.1:18000/route-a,route-b?node=gateway-0,
+// the `tls=true` query connects the management server by TLS.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	query := uri.Query()
+	routeNames := strings.Split(strings.TrimPrefix(uri.Path, "/"), ",")
+	if routeNames[0] == "" {
+		return nil, fmt.Errorf("the route names are required: %s", uri)
+	}
+	var opts []Option
+	if node := query.Get("node"); node != "" {
+		opts = append(opts, WithNode(&corev3.Node{Id: node}))
+	}
+	creds := insecure.NewCredentials()
+	if query.Get("tls") == "true" {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.Dial(uri.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	l, err := New(conn, routeNames, opts...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	l.(*xdsLoader).conn = conn
+	return l, nil
+}
+
+// New returns a config loader which subscribes the route configurations
+// `routeNames` with the referenced clusters and endpoints from the xds
+// management server by the aggregated discovery service (ADS), and translates
+// them into the gateway config. Only a subset of RDS, CDS and EDS is supported:
+// the path and prefix route matches with the cluster or weighted clusters
+// actions, and the endpoints of socket addresses.
+func New(conn grpc.ClientConnInterface, routeNames []string, opts ...Option) (config.ConfigLoader, error) {
+	l := &xdsLoader{
+		client:      discoveryv3.NewAggregatedDiscoveryServiceClient(conn),
+		node:        &corev3.Node{Id: "gateway"},
+		routeNames:  routeNames,
+		base:        &configv1.Gateway{},
+		initialized: make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *xdsLoader) initialize() error {
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	select {
+	case <-l.initialized:
+	case <-time.After(_initialTimeout):
+		cancel()
+		return fmt.Errorf("the xds resources of routes: %v are not received in %s", l.routeNames, _initialTimeout)
+	}
+	log.Infof("the initial xds config of routes: %v", l.routeNames)
+	return nil
+}
+
+func (l *xdsLoader) Load(_ context.Context) (*configv1.Gateway, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	log.Infof("loading config from xds routes: %v", l.routeNames)
+	return proto.Clone(l.current).(*configv1.Gateway), nil
+}
+
+func (l *xdsLoader) Watch(fn config.OnChange) {
+	log.Info("add xds config change event handler")
+	l.handlers.Add(fn)
+}
+
+func (l *xdsLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch xds routes: %v", l.routeNames)
+	for {
+		if err := l.stream(ctx); err != nil {
+			log.Errorf("watch xds routes: %v error: %+v", l.routeNames, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+			log.Warnf("the xds stream is closed, the watch process will attempt again")
+		}
+	}
+}
+
+// stream runs an ADS stream until it's broken, the resources are
+// subscribed again with the last accepted versions.
+func (l *xdsLoader) stream(ctx context.Context) error {
+	stream, err := l.client.StreamAggregatedResources(ctx)
+	if err != nil {
+		return err
+	}
+	if l.resources == nil {
+		l.resources = newResources()
+		l.versions = make(map[string]string)
+	}
+	l.subscribed = make(map[string][]string)
+	if err := l.subscribe(stream, routeType, l.routeNames); err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := l.onResponse(stream, resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *xdsLoader) subscribe(stream discoveryv3.AggregatedDiscoveryService_StreamAggregatedResourcesClient, typeURL string, names []string) error {
+	if equalNames(l.subscribed[typeURL], names) {
+		return nil
+	}
+	l.subscribed[typeURL] = names
+	return stream.Send(&discoveryv3.DiscoveryRequest{
+		Node:          l.node,
+		TypeUrl:       typeURL,
+		ResourceNames: names,
+		VersionInfo:   l.versions[typeURL],
+	})
+}
+
+func (l *xdsLoader) onResponse(stream discoveryv3.AggregatedDiscoveryService_StreamAggregatedResourcesClient, resp *discoveryv3.DiscoveryResponse) error {
+	req := &discoveryv3.DiscoveryRequest{
+		Node:          l.node,
+		TypeUrl:       resp.TypeUrl,
+		ResourceNames: l.subscribed[resp.TypeUrl],
+		ResponseNonce: resp.Nonce,
+	}
+	if err := l.update(resp); err != nil {
+		log.Errorf("reject the xds resources of type: %s, version: %s: %v", resp.TypeUrl, resp.VersionInfo, err)
+		req.VersionInfo = l.versions[resp.TypeUrl]
+		req.ErrorDetail = &status.Status{Code: int32(codes.InvalidArgument), Message: err.Error()}
+		return stream.Send(req)
+	}
+	l.versions[resp.TypeUrl] = resp.VersionInfo
+	req.VersionInfo = resp.VersionInfo
+	if err := stream.Send(req); err != nil {
+		return err
+	}
+
+	// subscribe the referenced resources.
+	clusterNames := l.resources.clusterNames(l.routeNames)
+	if err := l.subscribe(stream, clusterType, clusterNames); err != nil {
+		return err
+	}
+	if err := l.subscribe(stream, endpointType, l.resources.edsNames(clusterNames)); err != nil {
+		return err
+	}
+	l.publish()
+	return nil
+}
+
+// update decodes the state-of-the-world resources of the response.
+func (l *xdsLoader) update(resp *discoveryv3.DiscoveryResponse) error {
+	switch resp.TypeUrl {
+	case routeType:
+		routes := make(map[string]*routev3.RouteConfiguration, len(resp.Resources))
+		for _, any := range resp.Resources {
+			rc := &routev3.RouteConfiguration{}
+			if err := any.UnmarshalTo(rc); err != nil {
+				return err
+			}
+			routes[rc.Name] = rc
+		}
+		l.resources.routes = routes
+	case clusterType:
+		clusters := make(map[string]*clusterv3.Cluster, len(resp.Resources))
+		for _, any := range resp.Resources {
+			c := &clusterv3.Cluster{}
+			if err := any.UnmarshalTo(c); err != nil {
+				return err
+			}
+			clusters[c.Name] = c
+		}
+		l.resources.clusters = clusters
+	case endpointType:
+		endpoints := make(map[string]*endpointv3.ClusterLoadAssignment, len(resp.Resources))
+		for _, any := range resp.Resources {
+			cla := &endpointv3.ClusterLoadAssignment{}
+			if err := any.UnmarshalTo(cla); err != nil {
+				return err
+			}
+			endpoints[cla.ClusterName] = cla
+		}
+		l.resources.endpoints = endpoints
+	default:
+		return fmt.Errorf("unsupported xds resource type: %s", resp.TypeUrl)
+	}
+	return nil
+}
+
+// publish translates the resources and notifies the handlers if it's changed,
+// the incomplete or invalid config is not published.
+func (l *xdsLoader) publish() {
+	out, err := l.resources.translate(l.base, l.routeNames)
+	if err == errIncomplete {
+		return
+	}
+	if err == nil {
+		if errs := config.Validate(out); len(errs) > 0 {
+			err = config.ValidationErrors(errs)
+		}
+	}
+	if err != nil {
+		log.Errorf("failed to translate the xds resources of routes: %v: %v", l.routeNames, err)
+		return
+	}
+	l.lock.Lock()
+	last := l.current
+	if proto.Equal(last, out) {
+		l.lock.Unlock()
+		return
+	}
+	l.current = out
+	l.lock.Unlock()
+	if last == nil {
+		close(l.initialized)
+		return
+	}
+	log.Infof("xds config changed, reload config, versions: %v", l.versions)
+	if err := l.handlers.Notify(); err != nil {
+		log.Errorf("execute config loader error with xds versions: %v: %+v, rollback to the previous config", l.versions, err)
+		l.lock.Lock()
+		l.current = last
+		l.lock.Unlock()
+	}
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *xdsLoader) Close() {
+	l.watchCancel()
+	if l.conn != nil {
+		l.conn.Close()
+	}
+}
@@ -0,0 +1,198 @@
+package xds
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type managementServer struct {
+	discoveryv3.UnimplementedAggregatedDiscoveryServiceServer
+
+	lock      sync.Mutex
+	version   string
+	resources map[string][]proto.Message
+	streams   []discoveryv3.AggregatedDiscoveryService_StreamAggregatedResourcesServer
+	nacks     chan *discoveryv3.DiscoveryRequest
+}
+
+func (m *managementServer) response(typeURL string) *discoveryv3.DiscoveryResponse {
+	resp := &discoveryv3.DiscoveryResponse{TypeUrl: typeURL, VersionInfo: m.version, Nonce: m.version + typeURL}
+	for _, r := range m.resources[typeURL] {
+		any, _ := anypb.New(r)
+		resp.Resources = append(resp.Resources, any)
+	}
+	return resp
+}
+
+func (m *managementServer) StreamAggregatedResources(stream discoveryv3.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	m.lock.Lock()
+	m.streams = append(m.streams, stream)
+	m.lock.Unlock()
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if req.ErrorDetail != nil {
+			m.nacks <- req
+			continue
+		}
+		if req.ResponseNonce != "" {
+			continue
+		}
+		m.lock.Lock()
+		err = stream.Send(m.response(req.TypeUrl))
+		m.lock.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (m *managementServer) push(version, typeURL string, resources ...proto.Message) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.version = version
+	m.resources[typeURL] = resources
+	for _, stream := range m.streams {
+		stream.Send(m.response(typeURL))
+	}
+}
+
+func newCluster(name string, weight uint32) *endpointv3.ClusterLoadAssignment {
+	return &endpointv3.ClusterLoadAssignment{
+		ClusterName: name,
+		Endpoints: []*endpointv3.LocalityLbEndpoints{{
+			LbEndpoints: []*endpointv3.LbEndpoint{{
+				HostIdentifier: &endpointv3.LbEndpoint_Endpoint{Endpoint: &endpointv3.Endpoint{
+					Address: &corev3.Address{Address: &corev3.Address_SocketAddress{SocketAddress: &corev3.SocketAddress{
+						Address:       "127.0.0.1",
+						PortSpecifier: &corev3.SocketAddress_PortValue{PortValue: 8000},
+					}}},
+				}},
+				LoadBalancingWeight: wrapperspb.UInt32(weight),
+			}},
+		}},
+	}
+}
+
+func TestXDSLoader(t *testing.T) {
+	routes := &routev3.RouteConfiguration{
+		Name: "gateway",
+		VirtualHosts: []*routev3.VirtualHost{{
+			Name:    "default",
+			Domains: []string{"*", "example.com"},
+			Routes: []*routev3.Route{{
+				Name:  "helloworld",
+				Match: &routev3.RouteMatch{PathSpecifier: &routev3.RouteMatch_Prefix{Prefix: "/helloworld/"}},
+				Action: &routev3.Route_Route{Route: &routev3.RouteAction{
+					ClusterSpecifier: &routev3.RouteAction_Cluster{Cluster: "helloworld"},
+					Timeout:          durationpb.New(time.Second),
+					RetryPolicy: &routev3.RetryPolicy{
+						RetryOn:      "5xx,resource-exhausted",
+						NumRetries:   wrapperspb.UInt32(2),
+						RetryBackOff: &routev3.RetryPolicy_RetryBackOff{BaseInterval: durationpb.New(10 * time.Millisecond)},
+					},
+				}},
+			}},
+		}},
+	}
+	cluster := &clusterv3.Cluster{
+		Name:                 "helloworld",
+		ClusterDiscoveryType: &clusterv3.Cluster_Type{Type: clusterv3.Cluster_EDS},
+		EdsClusterConfig:     &clusterv3.Cluster_EdsClusterConfig{ServiceName: "helloworld-eds"},
+	}
+	ms := &managementServer{
+		resources: map[string][]proto.Message{
+			routeType:    {routes},
+			clusterType:  {cluster},
+			endpointType: {newCluster("helloworld-eds", 10)},
+		},
+		nacks: make(chan *discoveryv3.DiscoveryRequest, 1),
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	discoveryv3.RegisterAggregatedDiscoveryServiceServer(srv, ms)
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	loader, err := New(conn, []string{"gateway"}, WithBase(&configv1.Gateway{Name: "helloworld"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 2 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	e := c.Endpoints[1]
+	if e.Host != "example.com" || e.Path != "/helloworld/*" || e.Protocol != configv1.Protocol_HTTP ||
+		e.Timeout.AsDuration() != time.Second || e.Retry.Attempts != 3 || len(e.Retry.Conditions) != 1 {
+		t.Fatalf("unexpected endpoint: %+v", e)
+	}
+	if len(e.Retry.RetriableGrpcCodes) != 1 || e.Retry.RetriableGrpcCodes[0] != "RESOURCE_EXHAUSTED" ||
+		e.Retry.Backoff.BaseInterval.AsDuration() != 10*time.Millisecond {
+		t.Fatalf("unexpected retry: %+v", e.Retry)
+	}
+	if len(e.Backends) != 1 || e.Backends[0].Target != "127.0.0.1:8000" || e.Backends[0].GetWeight() != 10 {
+		t.Fatalf("unexpected backends: %+v", e.Backends)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	ms.push("v2", endpointType, newCluster("helloworld-eds", 20))
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of endpoints is not notified")
+	}
+	c, err = loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoints[0].Backends[0].GetWeight() != 20 {
+		t.Fatalf("unexpected backends: %+v", c.Endpoints[0].Backends)
+	}
+
+	// the resources of unexpected type are rejected.
+	ms.push("v3", "type.googleapis.com/envoy.config.listener.v3.Listener")
+	select {
+	case nack := <-ms.nacks:
+		if nack.VersionInfo != "" {
+			t.Fatalf("unexpected version of NACK: %s", nack.VersionInfo)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("the NACK is not received")
+	}
+}
@@ -0,0 +1,189 @@
+package zookeeper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	configv1 "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-zookeeper/zk"
+)
+
+const _sessionTimeout = time.Second * 10
+
+var _ config.ConfigLoader = (*zookeeperLoader)(nil)
+
+func init() {
+	config.Register("zookeeper", NewFromURI)
+}
+
+// Conn is the zookeeper connection used by the loader, it's implemented by *zk.Conn.
+type Conn interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+}
+
+type zookeeperLoader struct {
+	conn        Conn
+	path        string
+	confSHA256  string
+	closeConn   func()
+	watchCancel context.CancelFunc
+	handlers    config.Notifier
+}
+
+// NewFromURI returns a config loader from the uri, e.g.
+// zookeeper://127.0.0.1:2181,127.0.0.2:2181/gateway/config?session_timeout=10s.
+func NewFromURI(uri *url.URL) (config.ConfigLoader, error) {
+	sessionTimeout := _sessionTimeout
+	if v := uri.Query().Get("session_timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session_timeout: %s", err)
+		}
+		sessionTimeout = d
+	}
+	conn, _, err := zk.Connect(strings.Split(uri.Host, ","), sessionTimeout, zk.WithLogInfo(false))
+	if err != nil {
+		return nil, err
+	}
+	l, err := newLoader(conn, uri.Path, conn.Close)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// New returns a config loader which loads the gateway config from the znode path,
+// the OnChange handlers are triggered by the data watches of the znode.
+func New(conn Conn, path string) (config.ConfigLoader, error) {
+	return newLoader(conn, path, nil)
+}
+
+func newLoader(conn Conn, path string, closeConn func()) (*zookeeperLoader, error) {
+	l := &zookeeperLoader{
+		conn:      conn,
+		path:      path,
+		closeConn: closeConn,
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func sha256sum(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *zookeeperLoader) initialize() error {
+	data, stat, err := l.conn.Get(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to get zookeeper znode %q: %w", l.path, err)
+	}
+	l.confSHA256 = sha256sum(data)
+	log.Infof("the initial zookeeper config znode: %s, version: %d, sha256: %s", l.path, stat.Version, l.confSHA256)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	l.watchCancel = cancel
+	go l.watchproc(watchCtx)
+	return nil
+}
+
+func (l *zookeeperLoader) Load(_ context.Context) (*configv1.Gateway, error) {
+	log.Infof("loading config from zookeeper znode: %s", l.path)
+	data, _, err := l.conn.Get(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zookeeper znode %q: %w", l.path, err)
+	}
+	out, err := config.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyDefaults(out)
+	if errs := config.Validate(out); len(errs) > 0 {
+		return nil, config.ValidationErrors(errs)
+	}
+	return out, nil
+}
+
+func (l *zookeeperLoader) Watch(fn config.OnChange) {
+	log.Info("add zookeeper config change event handler")
+	l.handlers.Add(fn)
+}
+
+// getW reads the znode and sets the data watch, the existence watch is set
+// instead if the znode is deleted to wait for it to be created again.
+func (l *zookeeperLoader) getW() ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	data, stat, ch, err := l.conn.GetW(l.path)
+	if !errors.Is(err, zk.ErrNoNode) {
+		return data, stat, ch, err
+	}
+	log.Warnf("zookeeper config znode: %s has been deleted, keep the current config", l.path)
+	exists, _, ch, err := l.conn.ExistsW(l.path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if exists {
+		// created between the calls, read it again.
+		return l.conn.GetW(l.path)
+	}
+	return nil, nil, ch, nil
+}
+
+func (l *zookeeperLoader) watchproc(ctx context.Context) {
+	log.Infof("start watch zookeeper config znode: %s", l.path)
+	for {
+		data, stat, ch, err := l.getW()
+		if err != nil {
+			log.Errorf("watch zookeeper config znode: %s error: %+v", l.path, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		if stat != nil {
+			l.onChange(data, stat.Version)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-ch:
+			if ev.Err != nil {
+				log.Warnf("the zookeeper watch on znode: %s is closed: %+v, the watch process will attempt again", l.path, ev.Err)
+			}
+		}
+	}
+}
+
+func (l *zookeeperLoader) onChange(data []byte, version int32) {
+	sha256hex := sha256sum(data)
+	if sha256hex == l.confSHA256 {
+		return
+	}
+	log.Infof("zookeeper config changed, reload config, version: %d, last sha256: %s, new sha256: %s", version, l.confSHA256, sha256hex)
+	if err := l.handlers.Notify(); err != nil {
+		log.Errorf("execute config loader error with new sha256: %s: %+v, config digest will not be changed until all loaders are succeeded", sha256hex, err)
+		return
+	}
+	l.confSHA256 = sha256hex
+}
+
+func (l *zookeeperLoader) Close() {
+	l.watchCancel()
+	if l.closeConn != nil {
+		l.closeConn()
+	}
+}
@@ -0,0 +1,127 @@
+package zookeeper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+
+	_ "github.com/go-kratos/gateway/middleware/logging"
+)
+
+const (
+	_config = `
+name: helloworld
+middlewares:
+  - name: logging
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+	_changedConfig = `
+name: helloworld
+version: v2
+endpoints:
+  - path: /helloworld/*
+    protocol: HTTP
+    backends:
+      - target: 127.0.0.1:8000
+`
+)
+
+type fakeConn struct {
+	lock     sync.Mutex
+	data     []byte
+	version  int32
+	deleted  bool
+	watchers []chan zk.Event
+}
+
+func (f *fakeConn) Get(path string) ([]byte, *zk.Stat, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.deleted {
+		return nil, nil, zk.ErrNoNode
+	}
+	return f.data, &zk.Stat{Version: f.version}, nil
+}
+
+func (f *fakeConn) watch() <-chan zk.Event {
+	ch := make(chan zk.Event, 1)
+	f.watchers = append(f.watchers, ch)
+	return ch
+}
+
+func (f *fakeConn) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.deleted {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return f.data, &zk.Stat{Version: f.version}, f.watch(), nil
+}
+
+func (f *fakeConn) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return !f.deleted, nil, f.watch(), nil
+}
+
+func (f *fakeConn) set(data string, deleted bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.data = []byte(data)
+	f.deleted = deleted
+	f.version++
+	for _, ch := range f.watchers {
+		ch <- zk.Event{Type: zk.EventNodeDataChanged}
+		close(ch)
+	}
+	f.watchers = nil
+}
+
+func TestZookeeperLoader(t *testing.T) {
+	conn := &fakeConn{data: []byte(_config)}
+	loader, err := New(conn, "/gateway/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+	c, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "helloworld" || len(c.Endpoints) != 1 || len(c.Middlewares) != 1 {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+
+	notified := make(chan struct{}, 1)
+	loader.Watch(func() error {
+		notified <- struct{}{}
+		return nil
+	})
+	// the config is kept while the znode is deleted.
+	conn.set("", true)
+	select {
+	case <-notified:
+		t.Fatal("the deleted znode must not be notified")
+	case <-time.After(time.Millisecond * 100):
+	}
+	conn.set(_changedConfig, false)
+	select {
+	case <-notified:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the change of config is not notified")
+	}
+	c, err = loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "v2" {
+		t.Fatalf("want version v2 but got: %s", c.Version)
+	}
+}
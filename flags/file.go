@@ -0,0 +1,95 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"sigs.k8s.io/yaml"
+)
+
+const _pollInterval = time.Second * 5
+
+// parseFile parses the YAML or JSON values, the nested keys are joined by dot.
+func parseFile(data []byte) (map[string]string, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var v map[string]interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	flatten("", v, out)
+	return out, nil
+}
+
+func flatten(prefix string, in map[string]interface{}, out map[string]string) {
+	for k, v := range in {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(k, v, out)
+		case nil:
+		case string:
+			out[k] = v
+		default:
+			out[k] = fmt.Sprint(v)
+		}
+	}
+}
+
+// WatchFile loads the file values from the path and reloads them on change
+// until the ctx is done, the values are kept if the file becomes invalid.
+func (f *Flags) WatchFile(ctx context.Context, path string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = _pollInterval
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values, err := parseFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse runtime flags file %s: %w", path, err)
+	}
+	f.SetFile(values)
+	log.Infof("loaded %d runtime flags from file: %s", len(values), path)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := ioutil.ReadFile(path)
+			if err != nil {
+				log.Errorf("failed to read runtime flags file %s: %+v", path, err)
+				continue
+			}
+			if bytes.Equal(current, data) {
+				continue
+			}
+			data = current
+			values, err := parseFile(current)
+			if err != nil {
+				log.Errorf("failed to parse runtime flags file %s: %+v, keep the current values", path, err)
+				continue
+			}
+			f.SetFile(values)
+			log.Infof("reloaded %d runtime flags from file: %s", len(values), path)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package flags is the runtime key-value layer consulted by the router and
+// middlewares for the toggles and overrides without a config push, e.g. the
+// kill switch of a route. The values are set by the admin API over the
+// values of the watched file.
+package flags
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+var globalFlags = New()
+
+// Flags is the runtime key-value layers, the admin values override the file values.
+type Flags struct {
+	lock   sync.Mutex
+	file   map[string]string
+	admin  map[string]string
+	merged atomic.Value
+}
+
+// New returns the empty runtime flags.
+func New() *Flags {
+	f := &Flags{
+		file:  map[string]string{},
+		admin: map[string]string{},
+	}
+	f.merged.Store(map[string]string{})
+	return f
+}
+
+// merge must be called with the lock held.
+func (f *Flags) merge() {
+	merged := make(map[string]string, len(f.file)+len(f.admin))
+	for k, v := range f.file {
+		merged[k] = v
+	}
+	for k, v := range f.admin {
+		merged[k] = v
+	}
+	f.merged.Store(merged)
+}
+
+// Get returns the value of the key.
+func (f *Flags) Get(key string) (string, bool) {
+	v, ok := f.merged.Load().(map[string]string)[key]
+	return v, ok
+}
+
+// Bool returns the boolean value of the key, def is returned if it's unset or invalid.
+func (f *Flags) Bool(key string, def bool) bool {
+	if v, ok := f.Get(key); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// Int returns the integer value of the key, def is returned if it's unset or invalid.
+func (f *Flags) Int(key string, def int64) int64 {
+	if v, ok := f.Get(key); ok {
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+// Float returns the float value of the key, def is returned if it's unset or invalid.
+func (f *Flags) Float(key string, def float64) float64 {
+	if v, ok := f.Get(key); ok {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Set sets the admin value of the key.
+func (f *Flags) Set(key, value string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.admin[key] = value
+	f.merge()
+}
+
+// Delete deletes the admin value of the key, the file value takes effect again.
+func (f *Flags) Delete(key string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.admin, key)
+	f.merge()
+}
+
+// SetFile replaces the file values.
+func (f *Flags) SetFile(values map[string]string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.file = values
+	f.merge()
+}
+
+// Values returns the effective values.
+func (f *Flags) Values() map[string]string {
+	merged := f.merged.Load().(map[string]string)
+	out := make(map[string]string, len(merged))
+	for k, v := range merged {
+		out[k] = v
+	}
+	return out
+}
+
+// DebugHandler implemented debug handler, the values are listed by GET, set by
+// POST with the `key` and `value` queries and deleted by DELETE with the `key` query.
+func (f *Flags) DebugHandler() http.Handler {
+	debugMux := http.NewServeMux()
+	debugMux.HandleFunc("/debug/flags", func(rw http.ResponseWriter, req *http.Request) {
+		key := req.URL.Query().Get("key")
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			if key == "" {
+				rw.WriteHeader(http.StatusBadRequest)
+				_, _ = rw.Write([]byte("key is required"))
+				return
+			}
+			f.Set(key, req.URL.Query().Get("value"))
+		case http.MethodDelete:
+			f.Delete(key)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		f.lock.Lock()
+		out := map[string]interface{}{
+			"values": f.Values(),
+			"file":   f.file,
+			"admin":  f.admin,
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(out)
+		f.lock.Unlock()
+	})
+	return debugMux
+}
+
+// Default returns the global runtime flags.
+func Default() *Flags {
+	return globalFlags
+}
+
+// Get returns the value of the key of the global runtime flags.
+func Get(key string) (string, bool) {
+	return globalFlags.Get(key)
+}
+
+// Bool returns the boolean value of the key of the global runtime flags.
+func Bool(key string, def bool) bool {
+	return globalFlags.Bool(key, def)
+}
+
+// Int returns the integer value of the key of the global runtime flags.
+func Int(key string, def int64) int64 {
+	return globalFlags.Int(key, def)
+}
+
+// Float returns the float value of the key of the global runtime flags.
+func Float(key string, def float64) float64 {
+	return globalFlags.Float(key, def)
+}
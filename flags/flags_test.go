@@ -0,0 +1,132 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFlags(t *testing.T) {
+	f := New()
+	if f.Bool("route./foo.disabled", false) {
+		t.Fatal("want the default value")
+	}
+	f.SetFile(map[string]string{"a": "1", "b": "true", "c": "0.5"})
+	f.Set("a", "2")
+	if v := f.Int("a", 0); v != 2 {
+		t.Fatalf("want the admin value to override the file value but got: %d", v)
+	}
+	if !f.Bool("b", false) {
+		t.Fatal("want b to be true")
+	}
+	if v := f.Float("c", 0); v != 0.5 {
+		t.Fatalf("want 0.5 but got: %v", v)
+	}
+	if v := f.Int("b", 7); v != 7 {
+		t.Fatalf("want the default value of the invalid value but got: %d", v)
+	}
+	f.Delete("a")
+	if v := f.Int("a", 0); v != 1 {
+		t.Fatalf("want the file value after deleting the admin value but got: %d", v)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	values, err := parseFile([]byte(`
+route:
+  /foo:
+    disabled: true
+ratelimit.qps: 100
+ratio: 0.25
+empty: null
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"route./foo.disabled": "true",
+		"ratelimit.qps":       "100",
+		"ratio":               "0.25",
+	}
+	if len(values) != len(want) {
+		t.Fatalf("want %v but got: %v", want, values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Fatalf("want %s=%s but got: %v", k, v, values)
+		}
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runtime.yaml")
+	if err := ioutil.WriteFile(path, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := New()
+	if err := f.WatchFile(ctx, path, time.Millisecond*10); err != nil {
+		t.Fatal(err)
+	}
+	if v := f.Int("a", 0); v != 1 {
+		t.Fatalf("want 1 but got: %d", v)
+	}
+	if err := ioutil.WriteFile(path, []byte("a: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if v := f.Int("a", 0); v != 1 {
+		t.Fatalf("want the values to be kept on the invalid file but got: %d", v)
+	}
+	if err := ioutil.WriteFile(path, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for f.Int("a", 0) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("want the file values to be reloaded")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestDebugHandler(t *testing.T) {
+	f := New()
+	f.SetFile(map[string]string{"a": "1"})
+	h := f.DebugHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/flags?key=a&value=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want ok but got: %d", w.Code)
+	}
+	out := struct {
+		Values map[string]string `json:"values"`
+		File   map[string]string `json:"file"`
+		Admin  map[string]string `json:"admin"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Values["a"] != "2" || out.File["a"] != "1" || out.Admin["a"] != "2" {
+		t.Fatalf("unexpected flags: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/flags", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want bad request but got: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/debug/flags?key=a", nil))
+	if f.Int("a", 0) != 1 {
+		t.Fatalf("want the file value after delete but got: %s", w.Body.String())
+	}
+}
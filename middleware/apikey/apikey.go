@@ -0,0 +1,155 @@
+package apikey
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/apikey/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultHeader = "X-API-Key"
+	// the keys file is checked at most once in the interval.
+	_checkInterval = 5 * time.Second
+)
+
+func init() {
+	middleware.Register("apikey", Middleware)
+}
+
+// keysFile is the keys loaded from the file, it's reloaded once the
+// modification time is changed, the last keys are kept if failed to reload.
+type keysFile struct {
+	path string
+
+	mu        sync.Mutex
+	keys      map[string]string
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (f *keysFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	keys := map[string]string{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	f.keys = keys
+	f.modTime = info.ModTime()
+	return nil
+}
+
+func (f *keysFile) get(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.Sub(f.checkedAt) >= _checkInterval {
+		f.checkedAt = now
+		if err := f.load(); err != nil {
+			log.Errorf("Failed to load api keys from %s: %+v", f.path, err)
+		}
+	}
+	name, ok := f.keys[key]
+	return name, ok
+}
+
+type authenticator struct {
+	keys map[string]string
+	file *keysFile
+}
+
+// consumer returns the consumer of the key.
+func (a *authenticator) consumer(key string) (*config.Consumer, bool) {
+	consumers := middleware.GetConsumers()
+	if consumer, ok := consumers.ByAPIKey(key); ok {
+		return consumer, true
+	}
+	name, ok := a.keys[key]
+	if !ok && a.file != nil {
+		name, ok = a.file.get(key)
+	}
+	if !ok {
+		return nil, false
+	}
+	return consumers.ByName(name)
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware authenticates the requests by the API keys.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.APIKey{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	a := &authenticator{keys: options.Keys}
+	if options.KeysFile != "" {
+		a.file = &keysFile{path: options.KeysFile, checkedAt: time.Now()}
+		if err := a.file.load(); err != nil {
+			return nil, err
+		}
+	}
+	header := options.Header
+	if header == "" {
+		header = _defaultHeader
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			key := req.Header.Get(header)
+			query := req.URL.Query()
+			if key == "" && options.Query != "" {
+				key = query.Get(options.Query)
+			}
+			if key == "" {
+				audit.AuthenticationFailed(req, "apikey", http.StatusUnauthorized, "api key is required")
+				return newResponse(http.StatusUnauthorized)
+			}
+			consumer, ok := a.consumer(key)
+			if !ok {
+				audit.AuthenticationFailed(req, "apikey", http.StatusUnauthorized, "api key is invalid")
+				return newResponse(http.StatusUnauthorized)
+			}
+			if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+				audit.PolicyDenied(req, "apikey", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+				return newResponse(http.StatusForbidden)
+			}
+			middleware.WithConsumer(req.Context(), consumer)
+			if !options.Forward {
+				req.Header.Del(header)
+				if options.Query != "" && query.Get(options.Query) != "" {
+					query.Del(options.Query)
+					req.URL.RawQuery = query.Encode()
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,108 @@
+package apikey
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/apikey/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestAPIKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := ioutil.WriteFile(path, []byte(`{"key-file": "carol"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	middleware.SetConsumers([]*config.Consumer{
+		{Name: "alice", ApiKeys: []string{"key-alice"}},
+		{Name: "bob", AllowedRoutes: []string{"/admin/*"}},
+		{Name: "carol"},
+	})
+	defer middleware.SetConsumers(nil)
+	any, err := anypb.New(&v1.APIKey{
+		Query:    "api_key",
+		Keys:     map[string]string{"key-bob": "bob", "key-nobody": "nobody"},
+		KeysFile: path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "apikey", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream *http.Request
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	do := func(target, key string) (int, string) {
+		req := httptest.NewRequest("GET", target, nil)
+		if key != "" {
+			req.Header.Set(_defaultHeader, key)
+		}
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/api/*"})
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		consumer := ""
+		if reqOpt.Consumer != nil {
+			consumer = reqOpt.Consumer.Name
+		}
+		return resp.StatusCode, consumer
+	}
+
+	tests := []struct {
+		target   string
+		key      string
+		code     int
+		consumer string
+	}{
+		{target: "/api/orders", key: "key-alice", code: http.StatusOK, consumer: "alice"},
+		{target: "/api/orders?api_key=key-alice&page=2", code: http.StatusOK, consumer: "alice"},
+		{target: "/api/orders?api_key=key-file", code: http.StatusOK, consumer: "carol"},
+		{target: "/api/orders", key: "key-bob", code: http.StatusForbidden},
+		{target: "/api/orders", key: "key-nobody", code: http.StatusUnauthorized},
+		{target: "/api/orders", key: "key-unknown", code: http.StatusUnauthorized},
+		{target: "/api/orders", code: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		code, consumer := do(test.target, test.key)
+		if code != test.code || consumer != test.consumer {
+			t.Errorf("%s %s: want %d %q but got %d %q", test.target, test.key, test.code, test.consumer, code, consumer)
+		}
+	}
+	do("/api/orders?api_key=key-alice&page=2", "")
+	if upstream.URL.RawQuery != "page=2" {
+		t.Fatalf("want the key removed from the query but got %q", upstream.URL.RawQuery)
+	}
+
+	// the keys file is reloaded once modified.
+	a := &authenticator{file: &keysFile{path: path}}
+	if _, ok := a.consumer("key-file"); !ok {
+		t.Fatal("want the key of the file")
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"key-file-2": "carol"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	a.file.checkedAt = a.file.checkedAt.Add(-_checkInterval)
+	if _, ok := a.consumer("key-file-2"); !ok {
+		t.Fatal("want the key of the reloaded file")
+	}
+	if _, ok := a.consumer("key-file"); ok {
+		t.Fatal("want the key removed from the file")
+	}
+}
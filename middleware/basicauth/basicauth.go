@@ -0,0 +1,190 @@
+package basicauth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/basicauth/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultRealm = "Restricted"
+	// the htpasswd file is checked at most once in the interval.
+	_checkInterval = 5 * time.Second
+	// the max number of the verified credentials cached to skip the slow
+	// bcrypt comparisons of the following requests.
+	_maxVerified = 1024
+)
+
+func init() {
+	middleware.Register("basicauth", Middleware)
+}
+
+func parseHtpasswd(data []byte) (map[string]string, error) {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i <= 0 || !supported(line[i+1:]) {
+			return nil, fmt.Errorf("invalid htpasswd line %d", n)
+		}
+		users[line[:i]] = line[i+1:]
+	}
+	return users, scanner.Err()
+}
+
+// htpasswdFile is the users loaded from the file, it's reloaded once the
+// modification time is changed, the last users are kept if failed to reload.
+type htpasswdFile struct {
+	path string
+
+	mu        sync.Mutex
+	users     map[string]string
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (f *htpasswdFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	users, err := parseHtpasswd(data)
+	if err != nil {
+		return err
+	}
+	f.users = users
+	f.modTime = info.ModTime()
+	return nil
+}
+
+func (f *htpasswdFile) get(user string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.Sub(f.checkedAt) >= _checkInterval {
+		f.checkedAt = now
+		if err := f.load(); err != nil {
+			log.Errorf("Failed to load htpasswd from %s: %+v", f.path, err)
+		}
+	}
+	hash, ok := f.users[user]
+	return hash, ok
+}
+
+type authenticator struct {
+	users map[string]string
+	file  *htpasswdFile
+
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]struct{}
+}
+
+func (a *authenticator) authenticate(user, password string) bool {
+	hash, ok := a.users[user]
+	if !ok && a.file != nil {
+		hash, ok = a.file.get(user)
+	}
+	if !ok {
+		return false
+	}
+	// the hash is a part of the key to invalidate the changed passwords.
+	key := sha256.Sum256([]byte(user + "\x00" + password + "\x00" + hash))
+	a.mu.Lock()
+	_, ok = a.verified[key]
+	a.mu.Unlock()
+	if ok {
+		return true
+	}
+	if !verify(hash, password) {
+		return false
+	}
+	a.mu.Lock()
+	if len(a.verified) >= _maxVerified {
+		a.verified = make(map[[sha256.Size]byte]struct{})
+	}
+	a.verified[key] = struct{}{}
+	a.mu.Unlock()
+	return true
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware authenticates the requests by the basic authentication.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.BasicAuth{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	for user, hash := range options.Users {
+		if !supported(hash) {
+			return nil, fmt.Errorf("unsupported password hash of basicauth user: %s", user)
+		}
+	}
+	a := &authenticator{users: options.Users, verified: make(map[[sha256.Size]byte]struct{})}
+	if options.HtpasswdFile != "" {
+		a.file = &htpasswdFile{path: options.HtpasswdFile, checkedAt: time.Now()}
+		if err := a.file.load(); err != nil {
+			return nil, err
+		}
+	}
+	realm := options.Realm
+	if realm == "" {
+		realm = _defaultRealm
+	}
+	challenge := fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", realm)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			user, password, ok := req.BasicAuth()
+			if !ok || !a.authenticate(user, password) {
+				audit.AuthenticationFailed(req, "basicauth", http.StatusUnauthorized, "credentials are invalid")
+				resp, err := newResponse(http.StatusUnauthorized)
+				resp.Header.Set("WWW-Authenticate", challenge)
+				return resp, err
+			}
+			if consumer, ok := middleware.GetConsumers().ByName(user); ok {
+				if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+					audit.PolicyDenied(req, "basicauth", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+					return newResponse(http.StatusForbidden)
+				}
+				middleware.WithConsumer(req.Context(), consumer)
+			}
+			if !options.Forward {
+				req.Header.Del("Authorization")
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
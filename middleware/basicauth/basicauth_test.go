@@ -0,0 +1,108 @@
+package basicauth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/basicauth/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestVerify(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := []string{
+		string(bcryptHash),
+		// openssl passwd -apr1 -salt saltsalt secret
+		"$apr1$saltsalt$LrttParrLPdxvgutaSXWJ0",
+		// htpasswd -nbs user secret
+		"{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=",
+	}
+	for _, hash := range hashes {
+		if !verify(hash, "secret") {
+			t.Errorf("want the password matched with %s", hash)
+		}
+		if verify(hash, "wrong") {
+			t.Errorf("want the wrong password not matched with %s", hash)
+		}
+	}
+	if verify("secret", "secret") {
+		t.Error("want the plain text not supported")
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	data := "# staging users\nbob:$apr1$saltsalt$LrttParrLPdxvgutaSXWJ0\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	aliceHash, err := bcrypt.GenerateFromPassword([]byte("alice-secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	middleware.SetConsumers([]*config.Consumer{{Name: "bob"}})
+	defer middleware.SetConsumers(nil)
+	any, err := anypb.New(&v1.BasicAuth{
+		Users:        map[string]string{"alice": string(aliceHash)},
+		HtpasswdFile: path,
+		Realm:        "staging",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "basicauth", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func(user, password string) (*http.Response, *middleware.RequestOptions) {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/admin"})
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, reqOpt
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, _ := do("alice", "alice-secret"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("want 200 but got %d", resp.StatusCode)
+		}
+	}
+	if upstream.Get("Authorization") != "" {
+		t.Fatal("want the credentials removed")
+	}
+	resp, reqOpt := do("bob", "secret")
+	if resp.StatusCode != http.StatusOK || reqOpt.Consumer == nil || reqOpt.Consumer.Name != "bob" {
+		t.Fatalf("want bob of the htpasswd file but got %d %v", resp.StatusCode, reqOpt.Consumer)
+	}
+	for _, c := range [][2]string{{"alice", "wrong"}, {"bob", "alice-secret"}, {"carol", "secret"}, {"", ""}} {
+		resp, _ := do(c[0], c[1])
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") != `Basic realm="staging", charset="UTF-8"` {
+			t.Fatalf("%s: want 401 but got %d %v", c[0], resp.StatusCode, resp.Header)
+		}
+	}
+
+	if _, err := parseHtpasswd([]byte("carol:plain")); err == nil {
+		t.Fatal("want the unsupported hash rejected")
+	}
+}
@@ -0,0 +1,97 @@
+package basicauth
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	_apr1Magic = "$apr1$"
+	_shaPrefix = "{SHA}"
+	_itoa64    = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// supported reports whether the hash is in the supported formats.
+func supported(hash string) bool {
+	return strings.HasPrefix(hash, "$2") || strings.HasPrefix(hash, _apr1Magic) || strings.HasPrefix(hash, _shaPrefix)
+}
+
+// verify reports whether the password matches with the hash of htpasswd.
+func verify(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, _apr1Magic):
+		salt := strings.TrimPrefix(hash, _apr1Magic)
+		if i := strings.IndexByte(salt, '$'); i >= 0 {
+			salt = salt[:i]
+		}
+		return subtle.ConstantTimeCompare([]byte(apr1(password, salt)), []byte(hash)) == 1
+	case strings.HasPrefix(hash, _shaPrefix):
+		sum := sha1.Sum([]byte(password))
+		return subtle.ConstantTimeCompare([]byte(base64.StdEncoding.EncodeToString(sum[:])), []byte(hash[len(_shaPrefix):])) == 1
+	}
+	return false
+}
+
+// apr1 returns the Apache variant of the MD5 crypt of the password.
+func apr1(password, salt string) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+	alt := md5.Sum([]byte(password + salt + password))
+	ctx := []byte(password + _apr1Magic + salt)
+	for i := len(password); i > 0; i -= 16 {
+		n := i
+		if n > 16 {
+			n = 16
+		}
+		ctx = append(ctx, alt[:n]...)
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			ctx = append(ctx, 0)
+		} else {
+			ctx = append(ctx, password[0])
+		}
+	}
+	final := md5.Sum(ctx)
+	for i := 0; i < 1000; i++ {
+		var c []byte
+		if i&1 != 0 {
+			c = append(c, password...)
+		} else {
+			c = append(c, final[:]...)
+		}
+		if i%3 != 0 {
+			c = append(c, salt...)
+		}
+		if i%7 != 0 {
+			c = append(c, password...)
+		}
+		if i&1 != 0 {
+			c = append(c, final[:]...)
+		} else {
+			c = append(c, password...)
+		}
+		final = md5.Sum(c)
+	}
+	var b strings.Builder
+	b.WriteString(_apr1Magic + salt + "$")
+	to64 := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			b.WriteByte(_itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, g := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		to64(uint32(final[g[0]])<<16|uint32(final[g[1]])<<8|uint32(final[g[2]]), 4)
+	}
+	to64(uint32(final[11]), 2)
+	return b.String()
+}
@@ -2,35 +2,134 @@ package bbr
 
 import (
 	"bytes"
-	"io"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/go-kratos/aegis/ratelimit"
 	"github.com/go-kratos/aegis/ratelimit/bbr"
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/bbr/v1"
 	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
-var _nopBody = io.NopCloser(&bytes.Buffer{})
+const (
+	_defaultInitialLimit = 20
+	_defaultMinLimit     = 1
+	_defaultMaxLimit     = 1000
+	_defaultTolerance    = 1.5
+)
 
 func init() {
 	middleware.Register("bbr", Middleware)
 }
 
+// limiter sheds the requests adaptively, done is called once the request is
+// done if allowed.
+type limiter interface {
+	allow() (done func(error), ok bool)
+}
+
+type cpuLimiter struct {
+	*bbr.BBR
+}
+
+func (l cpuLimiter) allow() (func(error), bool) {
+	done, err := l.Allow()
+	if err != nil {
+		return nil, false
+	}
+	return func(err error) { done(ratelimit.DoneInfo{Err: err}) }, true
+}
+
+func newLimiterFunc(options *v1.BBR) func() limiter {
+	if options.Algorithm == v1.BBR_GRADIENT {
+		initial, min, max := float64(_defaultInitialLimit), float64(_defaultMinLimit), float64(_defaultMaxLimit)
+		if options.InitialLimit > 0 {
+			initial = float64(options.InitialLimit)
+		}
+		if options.MinLimit > 0 {
+			min = float64(options.MinLimit)
+		}
+		if options.MaxLimit > 0 {
+			max = float64(options.MaxLimit)
+		}
+		tolerance := options.Tolerance
+		if tolerance < 1 {
+			tolerance = _defaultTolerance
+		}
+		return func() limiter { return newGradient(initial, min, max, tolerance) }
+	}
+	var opts []bbr.Option
+	if options.Window != nil {
+		opts = append(opts, bbr.WithWindow(options.Window.AsDuration()))
+	}
+	if options.Bucket > 0 {
+		opts = append(opts, bbr.WithBucket(int(options.Bucket)))
+	}
+	if options.CpuThreshold > 0 {
+		opts = append(opts, bbr.WithCPUThreshold(int64(options.CpuThreshold)))
+	}
+	if options.CpuQuota > 0 {
+		opts = append(opts, bbr.WithCPUQuota(options.CpuQuota))
+	}
+	return func() limiter { return cpuLimiter{bbr.NewLimiter(opts...)} }
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware sheds the requests of the endpoints adaptively by the CPU usage
+// or the latencies.
 func Middleware(c *config.Middleware) (middleware.Middleware, error) {
-	limiter := bbr.NewLimiter() //use default settings
+	options := &v1.BBR{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Window != nil && options.Window.AsDuration() < time.Millisecond {
+		return nil, errors.New("bbr window should be at least 1ms")
+	}
+	if options.Bucket > 1000 {
+		return nil, errors.New("bbr bucket should be at most 1000")
+	}
+	newLimiter := newLimiterFunc(options)
+	var (
+		mu       sync.Mutex
+		limiters = map[string]limiter{}
+	)
+	get := func(req *http.Request) limiter {
+		var key string
+		if e, ok := middleware.EndpointFromContext(req.Context()); ok {
+			key = e.Host + " " + e.Method + " " + e.Path
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		l, ok := limiters[key]
+		if !ok {
+			l = newLimiter()
+			limiters[key] = l
+		}
+		return l
+	}
 	return func(next http.RoundTripper) http.RoundTripper {
 		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
-			done, err := limiter.Allow()
-			if err != nil {
-				return &http.Response{
-					Status:     http.StatusText(http.StatusTooManyRequests),
-					StatusCode: http.StatusTooManyRequests,
-					Body:       _nopBody,
-				}, nil
+			done, ok := get(req).allow()
+			if !ok {
+				return newResponse(http.StatusTooManyRequests)
 			}
 			resp, err := next.RoundTrip(req)
-			done(ratelimit.DoneInfo{Err: err})
+			done(err)
 			return resp, err
 		})
 	}, nil
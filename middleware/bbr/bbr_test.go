@@ -0,0 +1,100 @@
+package bbr
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/bbr/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestGradient(t *testing.T) {
+	g := newGradient(10, 1, 100, 1.5)
+	load := func(rtt time.Duration) {
+		var inFlights []int
+		for {
+			inFlight, ok := g.acquire()
+			if !ok {
+				break
+			}
+			inFlights = append(inFlights, inFlight)
+		}
+		for _, inFlight := range inFlights {
+			g.release(inFlight, rtt, true)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		load(10 * time.Millisecond)
+	}
+	if g.limit != 100 {
+		t.Fatalf("want the limit increased up to the max with the stable latency but got %f", g.limit)
+	}
+	for i := 0; i < 10; i++ {
+		load(100 * time.Millisecond)
+	}
+	if g.limit >= 50 {
+		t.Fatalf("want the limit decreased with the increasing latency but got %f", g.limit)
+	}
+
+	// the limit is kept if the upstream is not loaded enough.
+	g = newGradient(10, 1, 100, 1.5)
+	inFlight, _ := g.acquire()
+	g.release(inFlight, time.Second, true)
+	if g.limit != 10 || g.inFlight != 0 {
+		t.Fatalf("want the limit kept but got %f", g.limit)
+	}
+}
+
+func TestBBR(t *testing.T) {
+	any, err := anypb.New(&v1.BBR{Algorithm: v1.BBR_GRADIENT, InitialLimit: 1, MaxLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "bbr", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var next http.RoundTripper
+	next = m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/orders" {
+			// the nested request of the same endpoint is over the limit.
+			if resp := do(t, next, "/orders"); resp.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("want 429 but got %d", resp.StatusCode)
+			}
+			// the limiters are of the endpoints.
+			if resp := do(t, next, "/users"); resp.StatusCode != http.StatusOK {
+				t.Errorf("want the other endpoint allowed but got %d", resp.StatusCode)
+			}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	for i := 0; i < 2; i++ {
+		if resp := do(t, next, "/orders"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("want 200 but got %d", resp.StatusCode)
+		}
+	}
+
+	any, err = anypb.New(&v1.BBR{Bucket: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Middleware(&config.Middleware{Name: "bbr", Options: any}); err == nil {
+		t.Fatal("want the invalid bucket rejected")
+	}
+}
+
+func do(t *testing.T, next http.RoundTripper, path string) *http.Response {
+	req := httptest.NewRequest("GET", path, nil)
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(&config.Endpoint{Path: path})))
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
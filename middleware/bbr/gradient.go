@@ -0,0 +1,89 @@
+package bbr
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// the smoothing factor of the long-term latency, about the last 600 samples.
+	_longAlpha = 2.0 / 601
+	// the smoothing factor of the limit changes.
+	_smoothing = 0.2
+)
+
+// gradient limits the in-flight requests by the limit adjusted by the ratio
+// of the long-term latency to the recent latency, the limit is increased by
+// the queue size of sqrt(limit) if the latency is stable and decreased up to
+// half if the latency is increasing.
+type gradient struct {
+	minLimit  float64
+	maxLimit  float64
+	tolerance float64
+
+	mu       sync.Mutex
+	limit    float64
+	longRTT  float64
+	inFlight int
+}
+
+func newGradient(initial, min, max, tolerance float64) *gradient {
+	return &gradient{limit: initial, minLimit: min, maxLimit: max, tolerance: tolerance}
+}
+
+// acquire reports whether the request is allowed, and returns the in-flight
+// requests including the request.
+func (g *gradient) acquire() (int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inFlight >= int(g.limit) {
+		return g.inFlight, false
+	}
+	g.inFlight++
+	return g.inFlight, true
+}
+
+// release releases the request, the limit is updated by the latency if the
+// request is sampled.
+func (g *gradient) release(inFlight int, rtt time.Duration, sampled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+	if !sampled || rtt <= 0 {
+		return
+	}
+	short := float64(rtt)
+	if g.longRTT == 0 {
+		g.longRTT = short
+	} else {
+		g.longRTT = g.longRTT*(1-_longAlpha) + short*_longAlpha
+	}
+	// the long-term latency recovers faster once the recent latency drops.
+	if g.longRTT/short > 2 {
+		g.longRTT *= 0.95
+	}
+	// the limit is not increased if the upstream is not loaded enough.
+	if float64(inFlight) < g.limit/2 {
+		return
+	}
+	ratio := math.Max(0.5, math.Min(1, g.tolerance*g.longRTT/short))
+	limit := g.limit*ratio + math.Sqrt(g.limit)
+	limit = g.limit*(1-_smoothing) + limit*_smoothing
+	g.limit = math.Max(g.minLimit, math.Min(g.maxLimit, limit))
+}
+
+func (g *gradient) allow() (func(error), bool) {
+	inFlight, ok := g.acquire()
+	if !ok {
+		return nil, false
+	}
+	start := time.Now()
+	return func(err error) {
+		// the canceled requests are not sampled since the latencies are of
+		// the clients.
+		g.release(inFlight, time.Since(start), !errors.Is(err, context.Canceled))
+	}, true
+}
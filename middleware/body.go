@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+)
+
+// RequestBodyFilter is implemented by the RoundTrippers of the middlewares
+// filtering the request body before it's buffered by the proxy for the
+// retries, e.g. to limit the size of the body.
+type RequestBodyFilter interface {
+	// FilterRequestBody returns the body read by the proxy instead, the
+	// request is responded with the status code of the StatusError.
+	FilterRequestBody(req *http.Request, body io.ReadCloser) (io.ReadCloser, error)
+}
+
+// StatusError is the error responded with the status code by the proxy.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,101 @@
+package bodylimit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/bodylimit/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultGracePeriod = time.Second
+
+var (
+	errTooLarge = &middleware.StatusError{StatusCode: http.StatusRequestEntityTooLarge, Message: "request body too large"}
+	errTooSlow  = &middleware.StatusError{StatusCode: http.StatusRequestTimeout, Message: "request body too slow"}
+)
+
+func init() {
+	middleware.Register("bodylimit", Middleware)
+}
+
+// limitedBody fails once the body exceeds the max bytes or it's read slower
+// than the min rate after the grace period.
+type limitedBody struct {
+	io.ReadCloser
+	maxBytes int64
+	minRate  int64
+	grace    time.Duration
+	start    time.Time
+	read     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.maxBytes > 0 && b.read > b.maxBytes {
+		return 0, errTooLarge
+	}
+	if b.maxBytes > 0 && int64(len(p)) > b.maxBytes-b.read+1 {
+		// read one more byte to tell the body of the max bytes from the
+		// larger ones.
+		p = p[:b.maxBytes-b.read+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.maxBytes > 0 && b.read > b.maxBytes {
+		return n, errTooLarge
+	}
+	if b.minRate > 0 {
+		if elapsed := time.Since(b.start); elapsed > b.grace && float64(b.read) < float64(b.minRate)*elapsed.Seconds() && err != io.EOF {
+			return n, errTooSlow
+		}
+	}
+	return n, err
+}
+
+type bodyLimit struct {
+	next     http.RoundTripper
+	maxBytes int64
+	minRate  int64
+	grace    time.Duration
+}
+
+func (l *bodyLimit) RoundTrip(req *http.Request) (*http.Response, error) {
+	return l.next.RoundTrip(req)
+}
+
+// FilterRequestBody rejects the request of the larger content length before
+// the body is read.
+func (l *bodyLimit) FilterRequestBody(req *http.Request, body io.ReadCloser) (io.ReadCloser, error) {
+	if l.maxBytes > 0 && req.ContentLength > l.maxBytes {
+		return nil, errTooLarge
+	}
+	if body == nil || body == http.NoBody {
+		return body, nil
+	}
+	return &limitedBody{ReadCloser: body, maxBytes: l.maxBytes, minRate: l.minRate, grace: l.grace, start: time.Now()}, nil
+}
+
+// Middleware limits the size and the upload rate of the request bodies.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.BodyLimit{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.MaxBytes < 0 || options.MinBytesPerSecond < 0 {
+		return nil, errors.New("bodylimit max bytes and min bytes per second should not be negative")
+	}
+	grace := _defaultGracePeriod
+	if options.GracePeriod != nil {
+		grace = options.GracePeriod.AsDuration()
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &bodyLimit{next: next, maxBytes: options.MaxBytes, minRate: options.MinBytesPerSecond, grace: grace}
+	}, nil
+}
@@ -0,0 +1,81 @@
+package bodylimit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/bodylimit/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// slowReader returns a byte per read after the delay.
+type slowReader struct {
+	data  []byte
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+func TestBodyLimit(t *testing.T) {
+	any, err := anypb.New(&v1.BodyLimit{MaxBytes: 8, MinBytesPerSecond: 100, GracePeriod: durationpb.New(20 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "bodylimit", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, ok := m(http.DefaultTransport).(middleware.RequestBodyFilter)
+	if !ok {
+		t.Fatal("want the request body filter")
+	}
+	read := func(req *http.Request) ([]byte, error) {
+		body, err := filter.FilterRequestBody(req, req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(body)
+	}
+	statusCode := func(err error) int {
+		var statusError *middleware.StatusError
+		if errors.As(err, &statusError) {
+			return statusError.StatusCode
+		}
+		return 0
+	}
+
+	if data, err := read(httptest.NewRequest("POST", "/upload", strings.NewReader("12345678"))); err != nil || string(data) != "12345678" {
+		t.Fatalf("want the body of the max bytes but got %s %v", data, err)
+	}
+	if _, err := read(httptest.NewRequest("POST", "/upload", strings.NewReader("123456789"))); statusCode(err) != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413 by the content length but got %v", err)
+	}
+	req := httptest.NewRequest("POST", "/upload", ioutil.NopCloser(bytes.NewReader([]byte("123456789"))))
+	req.ContentLength = -1
+	if _, err := read(req); statusCode(err) != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413 of the chunked body but got %v", err)
+	}
+	req = httptest.NewRequest("POST", "/upload", &slowReader{data: []byte("1234"), delay: 15 * time.Millisecond})
+	req.ContentLength = -1
+	if _, err := read(req); statusCode(err) != http.StatusRequestTimeout {
+		t.Fatalf("want 408 of the slow body but got %v", err)
+	}
+}
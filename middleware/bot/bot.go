@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/bot/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultHeader          = "X-Bot"
+	_defaultDelay           = time.Second
+	_defaultWindow          = time.Second
+	_defaultMaxFingerprints = 65536
+)
+
+const (
+	classGood  = "good"
+	classBad   = "bad"
+	classHuman = "human"
+)
+
+var _metricBotsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "go",
+	Subsystem: "gateway",
+	Name:      "requests_bot_total",
+	Help:      "The total number of the requests classified as the bots",
+}, []string{"protocol", "method", "path", "service", "basePath", "class", "reason"})
+
+func init() {
+	middleware.Register("bot", Middleware)
+	prometheus.MustRegister(_metricBotsTotal)
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bot user agent pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprint returns the hash of the client IP and the headers varied by the
+// clients, the requests of the same fingerprint are likely of the same client.
+func fingerprint(req *http.Request) uint64 {
+	h := fnv.New64a()
+	if ip := clientip.FromRequest(req); ip != nil {
+		h.Write(ip)
+	}
+	for _, name := range []string{"User-Agent", "Accept", "Accept-Language", "Accept-Encoding"} {
+		h.Write([]byte{0})
+		h.Write([]byte(req.Header.Get(name)))
+	}
+	return h.Sum64()
+}
+
+type counter struct {
+	key   uint64
+	start time.Time
+	count int64
+}
+
+// counters is the fixed window counters of the fingerprints, the least
+// recently used ones are evicted if it's full.
+type counters struct {
+	limit  int64
+	window time.Duration
+	size   int
+
+	mu       sync.Mutex
+	lru      *list.List
+	counters map[uint64]*list.Element
+}
+
+func newCounters(r *v1.Rate) *counters {
+	c := &counters{
+		limit:    r.Requests,
+		window:   _defaultWindow,
+		size:     _defaultMaxFingerprints,
+		lru:      list.New(),
+		counters: make(map[uint64]*list.Element),
+	}
+	if r.Window != nil {
+		c.window = r.Window.AsDuration()
+	}
+	if r.MaxFingerprints > 0 {
+		c.size = int(r.MaxFingerprints)
+	}
+	return c
+}
+
+// exceeded counts the request of the fingerprint and reports whether it's
+// over the rate.
+func (c *counters) exceeded(key uint64, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.counters[key]
+	if !ok {
+		if c.lru.Len() >= c.size {
+			back := c.lru.Back()
+			c.lru.Remove(back)
+			delete(c.counters, back.Value.(*counter).key)
+		}
+		e = c.lru.PushFront(&counter{key: key, start: now})
+		c.counters[key] = e
+	} else {
+		c.lru.MoveToFront(e)
+	}
+	ct := e.Value.(*counter)
+	if now.Sub(ct.start) >= c.window {
+		ct.start = now
+		ct.count = 0
+	}
+	ct.count++
+	return ct.count > c.limit
+}
+
+type detector struct {
+	allow    []*regexp.Regexp
+	deny     []*regexp.Regexp
+	required []string
+	counters *counters
+}
+
+// classify returns the class of the request and the reason of the bad bots.
+func (d *detector) classify(req *http.Request) (string, string) {
+	ua := req.Header.Get("User-Agent")
+	if ua != "" && matchAny(d.allow, ua) {
+		return classGood, ""
+	}
+	if matchAny(d.deny, ua) {
+		return classBad, "user_agent"
+	}
+	for _, name := range d.required {
+		if req.Header.Get(name) == "" {
+			return classBad, "missing_header"
+		}
+	}
+	if d.counters != nil && d.counters.exceeded(fingerprint(req), time.Now()) {
+		return classBad, "rate"
+	}
+	return classHuman, ""
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+func botsIncr(req *http.Request, class, reason string) {
+	if labels, ok := middleware.MetricsLabelsFromContext(req.Context()); ok {
+		_metricBotsTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), class, reason).Inc()
+	}
+}
+
+// Middleware classifies the requests of the bots, the bad ones are blocked,
+// deprioritized or tagged by the action.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Bot{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	allow, err := compileAll(options.AllowUserAgents)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := compileAll(options.DenyUserAgents)
+	if err != nil {
+		return nil, err
+	}
+	d := &detector{allow: allow, deny: deny, required: options.RequiredHeaders}
+	if r := options.Rate; r != nil && r.Requests > 0 {
+		d.counters = newCounters(r)
+		if d.counters.window <= 0 {
+			return nil, fmt.Errorf("invalid bot rate window: %s", d.counters.window)
+		}
+	}
+	header := _defaultHeader
+	if options.Header != "" {
+		header = options.Header
+	}
+	delay := _defaultDelay
+	if options.Delay != nil {
+		delay = options.Delay.AsDuration()
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			class, reason := d.classify(req)
+			// the header is always overwritten to be trusted by the upstream.
+			req.Header.Set(header, class)
+			if class == classHuman {
+				return next.RoundTrip(req)
+			}
+			botsIncr(req, class, reason)
+			if class == classGood {
+				return next.RoundTrip(req)
+			}
+			switch options.Action {
+			case v1.Action_BLOCK:
+				return newResponse(http.StatusForbidden)
+			case v1.Action_DEPRIORITIZE:
+				if delay > 0 {
+					timer := time.NewTimer(delay)
+					select {
+					case <-timer.C:
+					case <-req.Context().Done():
+						timer.Stop()
+						return nil, req.Context().Err()
+					}
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
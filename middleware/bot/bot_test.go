@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/bot/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func newBot(t *testing.T, options *v1.Bot, upstream *http.Header) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "bot", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		*upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+}
+
+func TestBot(t *testing.T) {
+	var upstream http.Header
+	next := newBot(t, &v1.Bot{
+		AllowUserAgents: []string{`(?i)googlebot`},
+		DenyUserAgents:  []string{`(?i)curl|python-requests`},
+		RequiredHeaders: []string{"User-Agent", "Accept-Language"},
+	}, &upstream)
+	testCases := []struct {
+		headers map[string]string
+		status  int
+		class   string
+	}{
+		{map[string]string{"User-Agent": "Mozilla/5.0", "Accept-Language": "en"}, http.StatusOK, classHuman},
+		{map[string]string{"User-Agent": "Mozilla/5.0 (compatible; Googlebot/2.1)"}, http.StatusOK, classGood},
+		{map[string]string{"User-Agent": "curl/7.79.1", "Accept-Language": "en"}, http.StatusForbidden, ""},
+		{map[string]string{"User-Agent": "Mozilla/5.0"}, http.StatusForbidden, ""},
+		{map[string]string{"Accept-Language": "en"}, http.StatusForbidden, ""},
+		{map[string]string{"User-Agent": "Mozilla/5.0", "Accept-Language": "en", "X-Bot": "good"}, http.StatusOK, classHuman},
+	}
+	for _, tc := range testCases {
+		upstream = nil
+		req := httptest.NewRequest("GET", "/", nil)
+		for name, value := range tc.headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.status || (tc.class != "" && upstream.Get("X-Bot") != tc.class) {
+			t.Errorf("%v: want %d %s but got %d %s", tc.headers, tc.status, tc.class, resp.StatusCode, upstream.Get("X-Bot"))
+		}
+	}
+}
+
+func TestBotRate(t *testing.T) {
+	var upstream http.Header
+	next := newBot(t, &v1.Bot{
+		Rate:   &v1.Rate{Requests: 2, Window: durationpb.New(time.Minute)},
+		Action: v1.Action_DEPRIORITIZE,
+		Delay:  durationpb.New(10 * time.Millisecond),
+		Header: "X-Client-Class",
+	}, &upstream)
+	do := func(remoteAddr string) time.Duration {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("want 200 but got %v %v", resp, err)
+		}
+		return time.Since(start)
+	}
+	for i := 0; i < 2; i++ {
+		do("1.1.1.1:80")
+		if class := upstream.Get("X-Client-Class"); class != classHuman {
+			t.Fatalf("want human under the rate but got %s", class)
+		}
+	}
+	if d := do("1.1.1.1:80"); d < 10*time.Millisecond || upstream.Get("X-Client-Class") != classBad {
+		t.Fatalf("want the bot deprioritized but got %s %s", d, upstream.Get("X-Client-Class"))
+	}
+	do("2.2.2.2:80")
+	if class := upstream.Get("X-Client-Class"); class != classHuman {
+		t.Fatalf("want human of another fingerprint but got %s", class)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	c := newCounters(&v1.Rate{Requests: 1, Window: durationpb.New(time.Second), MaxFingerprints: 2})
+	now := time.Now()
+	if c.exceeded(1, now) || !c.exceeded(1, now) {
+		t.Fatal("want the second request of the window exceeded")
+	}
+	if c.exceeded(1, now.Add(time.Second)) {
+		t.Fatal("want the counter reset in the next window")
+	}
+	c.exceeded(2, now)
+	c.exceeded(3, now)
+	if len(c.counters) != 2 || c.counters[1] != nil {
+		t.Fatalf("want the least recently used evicted but got %d", len(c.counters))
+	}
+}
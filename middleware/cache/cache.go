@@ -0,0 +1,565 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cache/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultZone          = "default"
+	_defaultMaxBytes      = 64 << 20
+	_defaultMaxEntryBytes = 1 << 20
+	// the timeout of the revalidation if the endpoint has no timeout.
+	_revalidateTimeout      = 10 * time.Second
+	_defaultCoalesceTimeout = 5 * time.Second
+	// the header of the cache result sent to the client.
+	_resultHeader = "X-Cache"
+)
+
+const (
+	resultHit    = "HIT"
+	resultMiss   = "MISS"
+	resultStale  = "STALE"
+	resultBypass = "BYPASS"
+)
+
+// the status codes cacheable by default, RFC 9110 section 15.1.
+var _cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// the hop-by-hop headers not cached.
+var _skippedHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+var _metricCacheTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "go",
+	Subsystem: "gateway",
+	Name:      "requests_cache_total",
+	Help:      "The total number of the requests by the cache results",
+}, []string{"protocol", "method", "path", "service", "basePath", "result"})
+
+func init() {
+	middleware.Register("cache", Middleware)
+	prometheus.MustRegister(_metricCacheTotal)
+}
+
+func cacheIncr(req *http.Request, result string) {
+	labels, ok := middleware.MetricsLabelsFromContext(req.Context())
+	if !ok {
+		return
+	}
+	_metricCacheTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), strings.ToLower(result)).Inc()
+}
+
+// cacheControl is the directives of the Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			name, arg := d, ""
+			if i := strings.IndexByte(d, '='); i >= 0 {
+				name, arg = d[:i], strings.Trim(d[i+1:], `"`)
+			}
+			cc[strings.ToLower(name)] = arg
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	arg, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// primaryKey returns the key of the request, the host and the path are
+// separated to purge the entries.
+func primaryKey(req *http.Request) string {
+	return req.Host + "\x01" + req.URL.EscapedPath() + "?" + req.URL.RawQuery
+}
+
+func splitKey(key string) (host, path, rest string) {
+	i := strings.IndexByte(key, '\x01')
+	if i < 0 {
+		return "", key, ""
+	}
+	host, path = key[:i], key[i+1:]
+	if j := strings.IndexByte(path, '?'); j >= 0 {
+		path, rest = path[:j], path[j:]
+	}
+	return host, path, rest
+}
+
+// parseVary returns the sorted Vary header names, not ok if it's *.
+func parseVary(header http.Header) ([]string, bool) {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+type cache struct {
+	store           backend
+	maxEntryBytes   int64
+	ttl             time.Duration
+	swr             time.Duration
+	overrideSWR     bool
+	coalesceTimeout time.Duration
+
+	mu         sync.Mutex
+	revalidate map[string]bool
+	// the done channels of the misses of the keys being sent.
+	calls map[string]chan struct{}
+}
+
+// freshness returns the freshness lifetime and the stale-while-revalidate
+// window of the response, not ok if it's not cacheable.
+func (c *cache) freshness(req *http.Request, resp *http.Response, now time.Time) (time.Duration, time.Duration, bool) {
+	if !_cacheableStatus[resp.StatusCode] || resp.Header.Get("Set-Cookie") != "" {
+		return 0, 0, false
+	}
+	cc := parseCacheControl(resp.Header)
+	if cc.has("no-store") || cc.has("private") || cc.has("no-cache") {
+		return 0, 0, false
+	}
+	if req.Header.Get("Authorization") != "" && !cc.has("public") && !cc.has("s-maxage") && !cc.has("must-revalidate") {
+		return 0, 0, false
+	}
+	ttl, ok := cc.seconds("s-maxage")
+	if !ok {
+		ttl, ok = cc.seconds("max-age")
+	}
+	if !ok {
+		if expires := resp.Header.Get("Expires"); expires != "" {
+			ok = true
+			if t, err := http.ParseTime(expires); err == nil {
+				date := now
+				if d, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+					date = d
+				}
+				ttl = t.Sub(date)
+			}
+		}
+	}
+	if c.ttl > 0 {
+		ttl, ok = c.ttl, true
+	}
+	if !ok || ttl <= 0 {
+		return 0, 0, false
+	}
+	swr, _ := cc.seconds("stale-while-revalidate")
+	if c.overrideSWR {
+		swr = c.swr
+	}
+	return ttl, swr, true
+}
+
+// readBody reads the body up to the max bytes, the body is restored and not ok
+// if it's larger, it's closed otherwise.
+func readBody(body io.ReadCloser, maxBytes int64) ([]byte, io.ReadCloser, bool, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		body.Close()
+		return nil, nil, false, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), body), body}, false, nil
+	}
+	body.Close()
+	return data, nil, true, nil
+}
+
+// save stores the response if it's cacheable, the body of the response is
+// replaced by the data read.
+func (c *cache) save(req *http.Request, primary string, resp *http.Response) error {
+	// the streaming responses are not read.
+	if resp.ContentLength > c.maxEntryBytes || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return nil
+	}
+	now := time.Now()
+	ttl, swr, ok := c.freshness(req, resp, now)
+	if !ok {
+		return nil
+	}
+	vary, ok := parseVary(resp.Header)
+	if !ok {
+		return nil
+	}
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+	data, rest, ok, err := readBody(resp.Body, c.maxEntryBytes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		resp.Body = rest
+		return nil
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	header := resp.Header.Clone()
+	for _, name := range _skippedHeaders {
+		header.Del(name)
+	}
+	header.Del(_resultHeader)
+	c.store.set(req.Context(), primary, req.Header, &entry{
+		statusCode: resp.StatusCode,
+		header:     header,
+		body:       data,
+		vary:       vary,
+		storedAt:   now,
+		ttl:        ttl,
+		swr:        swr,
+	})
+	return nil
+}
+
+func (c *cache) response(req *http.Request, e *entry, result string) *http.Response {
+	header := e.header.Clone()
+	header.Set("Age", strconv.Itoa(int(e.age(time.Now())/time.Second)))
+	header.Set(_resultHeader, result)
+	resp := &http.Response{
+		StatusCode:    e.statusCode,
+		Header:        header,
+		ContentLength: int64(len(e.body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+	}
+	if req.Method == http.MethodHead {
+		resp.Body = http.NoBody
+	}
+	return resp
+}
+
+// detachedContext keeps the values of the request but not the cancellation to
+// revalidate the entry after the request is done.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// revalidateAsync refreshes the stale entry in the background, at most one
+// revalidation of the key is in flight.
+func (c *cache) revalidateAsync(next http.RoundTripper, req *http.Request, primary string, e *entry) {
+	c.mu.Lock()
+	if c.revalidate[e.key] {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidate[e.key] = true
+	c.mu.Unlock()
+	unlock := func() {}
+	if rs, ok := c.store.(*redisStore); ok {
+		// the entry is being revalidated by the other gateway.
+		var locked bool
+		if unlock, locked = rs.lock(req.Context(), primary, c.coalesceTimeout); !locked {
+			c.mu.Lock()
+			delete(c.revalidate, e.key)
+			c.mu.Unlock()
+			return
+		}
+	}
+	timeout := _revalidateTimeout
+	var ctx context.Context = detachedContext{req.Context()}
+	if endpoint, ok := middleware.EndpointFromContext(ctx); ok {
+		if endpoint.Timeout != nil {
+			timeout = endpoint.Timeout.AsDuration()
+		}
+		ctx = middleware.NewRequestContext(ctx, middleware.NewRequestOptions(endpoint))
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	upstream := req.Clone(ctx)
+	go func() {
+		defer cancel()
+		defer unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidate, e.key)
+			c.mu.Unlock()
+		}()
+		resp, err := c.fetch(next, upstream, primary, e)
+		if err != nil {
+			log.Errorf("Failed to revalidate the cached response of %s: %+v", req.URL.Path, err)
+			return
+		}
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// fetch sends the request conditionally by the validators of the stale
+// entry, the entry is refreshed by the 304 response.
+func (c *cache) fetch(next http.RoundTripper, req *http.Request, primary string, stale *entry) (*http.Response, error) {
+	conditional := false
+	if stale != nil && req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+		if etag := stale.header.Get("Etag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+			conditional = true
+		} else if lastModified := stale.header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+			conditional = true
+		}
+	}
+	resp, err := next.RoundTrip(req)
+	if conditional {
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		refreshed := *stale
+		refreshed.header = stale.header.Clone()
+		for name, values := range resp.Header {
+			refreshed.header[name] = values
+		}
+		now := time.Now()
+		ttl, swr, ok := c.freshness(req, &http.Response{StatusCode: stale.statusCode, Header: refreshed.header}, now)
+		if !ok {
+			ttl, swr = stale.ttl, stale.swr
+		}
+		refreshed.storedAt, refreshed.ttl, refreshed.swr = now, ttl, swr
+		c.store.set(req.Context(), primary, req.Header, &refreshed)
+		return c.response(req, &refreshed, resultMiss), nil
+	}
+	if err := c.save(req, primary, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// lookup returns the result of the entry by the age and the directives of
+// the request.
+func lookup(cc cacheControl, e *entry, now time.Time) string {
+	maxAge, limited := cc.seconds("max-age")
+	switch age := e.age(now); {
+	case cc.has("no-cache"):
+	case age < e.ttl && (!limited || age <= maxAge):
+		return resultHit
+	case age < e.ttl+e.swr && !limited:
+		return resultStale
+	}
+	return resultMiss
+}
+
+// cached returns the fresh entry of the request.
+func (c *cache) cached(req *http.Request, primary string) (*entry, bool) {
+	e, ok := c.store.get(req.Context(), primary, req.Header)
+	if !ok || e.age(time.Now()) >= e.ttl {
+		return nil, false
+	}
+	return e, true
+}
+
+// wait waits for the miss of the key being sent locally or by the other
+// gateways, and returns the entry cached by it.
+func (c *cache) wait(req *http.Request, primary string, done chan struct{}) (*entry, bool, error) {
+	timer := time.NewTimer(c.coalesceTimeout)
+	defer timer.Stop()
+	if done != nil {
+		select {
+		case <-done:
+			e, ok := c.cached(req, primary)
+			return e, ok, nil
+		case <-timer.C:
+			return nil, false, nil
+		case <-req.Context().Done():
+			return nil, false, req.Context().Err()
+		}
+	}
+	rs := c.store.(*redisStore)
+	ticker := time.NewTicker(_pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if e, ok := c.cached(req, primary); ok {
+				return e, true, nil
+			}
+			if !rs.locked(req.Context(), primary) {
+				return nil, false, nil
+			}
+		case <-timer.C:
+			return nil, false, nil
+		case <-req.Context().Done():
+			return nil, false, req.Context().Err()
+		}
+	}
+}
+
+// coalesce sends the request of the miss, the requests of the same key wait
+// for the one being sent, and they're served by the entry cached by it or
+// sent if it's not cacheable.
+func (c *cache) coalesce(next http.RoundTripper, req *http.Request, primary string, stale *entry) (*http.Response, string, error) {
+	c.mu.Lock()
+	if done, ok := c.calls[primary]; ok {
+		c.mu.Unlock()
+		e, ok, err := c.wait(req, primary, done)
+		if err != nil {
+			return nil, "", err
+		}
+		if ok {
+			return c.response(req, e, resultHit), resultHit, nil
+		}
+		resp, err := c.fetch(next, req, primary, stale)
+		return resp, resultMiss, err
+	}
+	done := make(chan struct{})
+	c.calls[primary] = done
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, primary)
+		c.mu.Unlock()
+		close(done)
+	}()
+	if rs, ok := c.store.(*redisStore); ok {
+		unlock, locked := rs.lock(req.Context(), primary, c.coalesceTimeout)
+		if !locked {
+			e, ok, err := c.wait(req, primary, nil)
+			if err != nil {
+				return nil, "", err
+			}
+			if ok {
+				return c.response(req, e, resultHit), resultHit, nil
+			}
+		} else {
+			defer unlock()
+		}
+	}
+	resp, err := c.fetch(next, req, primary, stale)
+	return resp, resultMiss, err
+}
+
+// Middleware caches the responses by the Cache-Control semantics.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Cache{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	zone := options.Zone
+	if zone == "" {
+		zone = _defaultZone
+	}
+	maxBytes := int64(_defaultMaxBytes)
+	if options.MaxBytes > 0 {
+		maxBytes = options.MaxBytes
+	}
+	ca := &cache{
+		store:           zoneOf(zone, maxBytes, options.Redis),
+		maxEntryBytes:   _defaultMaxEntryBytes,
+		coalesceTimeout: _defaultCoalesceTimeout,
+		revalidate:      map[string]bool{},
+		calls:           map[string]chan struct{}{},
+	}
+	if options.MaxEntryBytes > 0 {
+		ca.maxEntryBytes = options.MaxEntryBytes
+	}
+	if options.Ttl != nil {
+		ca.ttl = options.Ttl.AsDuration()
+	}
+	if options.StaleWhileRevalidate != nil {
+		ca.swr, ca.overrideSWR = options.StaleWhileRevalidate.AsDuration(), true
+	}
+	if options.CoalesceTimeout != nil {
+		ca.coalesceTimeout = options.CoalesceTimeout.AsDuration()
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next.RoundTrip(req)
+			}
+			cc := parseCacheControl(req.Header)
+			if cc.has("no-store") || req.Header.Get("Range") != "" {
+				cacheIncr(req, resultBypass)
+				return next.RoundTrip(req)
+			}
+			primary := primaryKey(req)
+			e, ok := ca.store.get(req.Context(), primary, req.Header)
+			if ok {
+				switch result := lookup(cc, e, time.Now()); result {
+				case resultHit:
+					cacheIncr(req, resultHit)
+					return ca.response(req, e, resultHit), nil
+				case resultStale:
+					cacheIncr(req, resultStale)
+					ca.revalidateAsync(next, req, primary, e)
+					return ca.response(req, e, resultStale), nil
+				}
+			}
+			if req.Method == http.MethodHead {
+				cacheIncr(req, resultMiss)
+				return next.RoundTrip(req)
+			}
+			resp, result, err := ca.coalesce(next, req, primary, e)
+			if err != nil {
+				return nil, err
+			}
+			cacheIncr(req, result)
+			resp.Header.Set(_resultHeader, result)
+			return resp, nil
+		})
+	}, nil
+}
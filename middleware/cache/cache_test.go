@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cache/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type upstream struct {
+	requests int64
+	handler  func(req *http.Request) *http.Response
+}
+
+func (u *upstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&u.requests, 1)
+	return u.handler(req), nil
+}
+
+func (u *upstream) count() int64 {
+	return atomic.LoadInt64(&u.requests)
+}
+
+func newResponse(statusCode int, header http.Header, body string) *http.Response {
+	return &http.Response{StatusCode: statusCode, Header: header, Body: ioutil.NopCloser(strings.NewReader(body))}
+}
+
+func newCache(t *testing.T, options *v1.Cache, u *upstream) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "cache", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(u)
+}
+
+func get(t *testing.T, next http.RoundTripper, target string, header http.Header) (*http.Response, string) {
+	req := httptest.NewRequest("GET", target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestCache(t *testing.T) {
+	u := &upstream{handler: func(req *http.Request) *http.Response {
+		header := http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}}
+		switch req.URL.Path {
+		case "/private":
+			header.Set("Cache-Control", "private, max-age=60")
+		case "/cookie":
+			header.Set("Set-Cookie", "session=1")
+		case "/plain":
+			header.Del("Cache-Control")
+		}
+		return newResponse(http.StatusOK, header, "hello "+req.Header.Get("Accept-Language"))
+	}}
+	next := newCache(t, &v1.Cache{Zone: "TestCache"}, u)
+	zoneOf("TestCache", _defaultMaxBytes, nil).purge(func(string) bool { return true })
+
+	en := http.Header{"Accept-Language": {"en"}}
+	if resp, body := get(t, next, "/items?page=1", en); resp.Header.Get(_resultHeader) != resultMiss || body != "hello en" {
+		t.Fatalf("want miss but got %v %s", resp.Header, body)
+	}
+	resp, body := get(t, next, "/items?page=1", en)
+	if resp.Header.Get(_resultHeader) != resultHit || resp.Header.Get("Age") != "0" || body != "hello en" || u.count() != 1 {
+		t.Fatalf("want hit but got %v %s %d", resp.Header, body, u.count())
+	}
+	if _, body := get(t, next, "/items?page=1", http.Header{"Accept-Language": {"fr"}}); body != "hello fr" || u.count() != 2 {
+		t.Fatalf("want the variant of the Vary header but got %s %d", body, u.count())
+	}
+	if resp, _ := get(t, next, "/items?page=1", http.Header{"Accept-Language": {"en"}, "Cache-Control": {"no-cache"}}); resp.Header.Get(_resultHeader) != resultMiss {
+		t.Fatalf("want the no-cache request sent to the upstream but got %v", resp.Header)
+	}
+	for _, path := range []string{"/private", "/cookie", "/plain"} {
+		get(t, next, path, en)
+		if resp, _ := get(t, next, path, en); resp.Header.Get(_resultHeader) != resultMiss {
+			t.Errorf("%s: want not cached but got %v", path, resp.Header)
+		}
+	}
+	if resp, _ := get(t, next, "/items?page=1", http.Header{"Accept-Language": {"en"}, "Authorization": {"Bearer token"}}); resp.Header.Get(_resultHeader) != resultHit {
+		t.Fatalf("want the cached response of the shared cache but got %v", resp.Header)
+	}
+	get(t, next, "/users", http.Header{"Authorization": {"Bearer token"}})
+	if resp, _ := get(t, next, "/users", http.Header{"Authorization": {"Bearer token"}}); resp.Header.Get(_resultHeader) != resultMiss {
+		t.Fatalf("want the responses of the authorized requests not cached but got %v", resp.Header)
+	}
+
+	// purges the entries by the admin API.
+	rec := httptest.NewRecorder()
+	AdminHandler().ServeHTTP(rec, httptest.NewRequest("DELETE", "/admin/cache?zone=TestCache&path=/it*", nil))
+	var out map[string]int
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || out["purged"] != 2 {
+		t.Fatalf("want 2 entries purged but got %s", rec.Body)
+	}
+	if resp, _ := get(t, next, "/items?page=1", en); resp.Header.Get(_resultHeader) != resultMiss {
+		t.Fatalf("want the purged entry missed but got %v", resp.Header)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	u := &upstream{handler: func(req *http.Request) *http.Response {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return newResponse(http.StatusNotModified, http.Header{"Cache-Control": {"max-age=60"}}, "")
+		}
+		return newResponse(http.StatusOK, http.Header{"Etag": {`"v1"`}}, "v1")
+	}}
+	next := newCache(t, &v1.Cache{
+		Zone:                 "TestStaleWhileRevalidate",
+		Ttl:                  durationpb.New(time.Minute),
+		StaleWhileRevalidate: durationpb.New(time.Minute),
+	}, u)
+	s := zoneOf("TestStaleWhileRevalidate", _defaultMaxBytes, nil).(*store)
+	s.purge(func(string) bool { return true })
+	age := func(d time.Duration) {
+		s.mu.Lock()
+		for _, elem := range s.entries {
+			aged := *elem.Value.(*entry)
+			aged.storedAt = time.Now().Add(-d)
+			elem.Value = &aged
+		}
+		s.mu.Unlock()
+	}
+
+	get(t, next, "/items", nil)
+	age(90 * time.Second)
+	if resp, body := get(t, next, "/items", nil); resp.Header.Get(_resultHeader) != resultStale || body != "v1" {
+		t.Fatalf("want stale but got %v %s", resp.Header, body)
+	}
+	for i := 0; i < 100; i++ {
+		if e, ok := s.get(context.Background(), primaryKey(httptest.NewRequest("GET", "/items", nil)), http.Header{}); ok && e.age(time.Now()) < time.Minute {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if resp, body := get(t, next, "/items", nil); resp.Header.Get(_resultHeader) != resultHit || body != "v1" || u.count() != 2 {
+		t.Fatalf("want the revalidated hit but got %v %s %d", resp.Header, body, u.count())
+	}
+
+	age(3 * time.Minute)
+	if resp, body := get(t, next, "/items", nil); resp.Header.Get(_resultHeader) != resultMiss || body != "v1" || u.count() != 3 {
+		t.Fatalf("want the conditional miss but got %v %s %d", resp.Header, body, u.count())
+	}
+	if resp, _ := get(t, next, "/items", nil); resp.Header.Get(_resultHeader) != resultHit {
+		t.Fatalf("want the refreshed hit but got %v", resp.Header)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := newStore(100)
+	for _, key := range []string{"a", "b", "c"} {
+		s.set(context.Background(), key, http.Header{}, &entry{body: make([]byte, 40)})
+	}
+	if _, ok := s.get(context.Background(), "a", http.Header{}); ok {
+		t.Fatal("want the least recently used entry evicted")
+	}
+	if _, ok := s.get(context.Background(), "c", http.Header{}); !ok || s.stats()["entries"] != 2 {
+		t.Fatalf("want 2 entries but got %v", s.stats())
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	u := &upstream{handler: func(req *http.Request) *http.Response {
+		time.Sleep(50 * time.Millisecond)
+		return newResponse(http.StatusOK, http.Header{"Cache-Control": {"max-age=60"}}, "hello")
+	}}
+	next := newCache(t, &v1.Cache{Zone: "TestCoalesce"}, u)
+	zoneOf("TestCoalesce", _defaultMaxBytes, nil).purge(func(string) bool { return true })
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, body := get(t, next, "/items", nil); body != "hello" {
+				t.Errorf("want hello but got %s", body)
+			}
+		}()
+	}
+	wg.Wait()
+	if u.count() != 1 {
+		t.Fatalf("want 1 request sent to the upstream but got %d", u.count())
+	}
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cache/v1"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	_defaultKeyPrefix    = "gateway:cache:"
+	_defaultRedisTimeout = 100 * time.Millisecond
+	// the interval of polling the entry fetched by the other gateway.
+	_pollInterval = 50 * time.Millisecond
+)
+
+// the lock is released only by the owner.
+var _unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var (
+	_clientsMu sync.Mutex
+	// the clients are shared by the middlewares of the same redis, as the
+	// middlewares are rebuilt on the config updates.
+	_clients = map[string]redis.UniversalClient{}
+)
+
+func newClient(c *v1.Redis) redis.UniversalClient {
+	key := fmt.Sprintf("%s/%d/%s", strings.Join(c.Addrs, ","), c.Db, c.Password)
+	_clientsMu.Lock()
+	defer _clientsMu.Unlock()
+	if client, ok := _clients[key]; ok {
+		return client
+	}
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    c.Addrs,
+		Password: c.Password,
+		DB:       int(c.Db),
+	})
+	_clients[key] = client
+	return client
+}
+
+// record is the entry encoded in the redis.
+type record struct {
+	StatusCode int           `json:"statusCode"`
+	Header     http.Header   `json:"header"`
+	Body       []byte        `json:"body"`
+	Vary       []string      `json:"vary,omitempty"`
+	StoredAt   time.Time     `json:"storedAt"`
+	TTL        time.Duration `json:"ttl"`
+	SWR        time.Duration `json:"swr,omitempty"`
+}
+
+// redisStore shares the entries by the redis, the keys of the entries, the
+// Vary header names and the locks are in the namespaces of the prefix, the
+// entries expire after another freshness lifetime to be revalidated.
+type redisStore struct {
+	client  redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+	// failed is 1 if the redis is unreachable, it's logged once failed.
+	failed int32
+}
+
+func newRedisStore(c *v1.Redis) *redisStore {
+	s := &redisStore{client: newClient(c), prefix: c.KeyPrefix, timeout: _defaultRedisTimeout}
+	if s.prefix == "" {
+		s.prefix = _defaultKeyPrefix
+	}
+	if c.Timeout != nil {
+		s.timeout = c.Timeout.AsDuration()
+	}
+	return s
+}
+
+// check logs the error once the redis is failed or recovered.
+func (s *redisStore) check(err error) {
+	if err == redis.Nil {
+		err = nil
+	}
+	if err != nil {
+		if atomic.CompareAndSwapInt32(&s.failed, 0, 1) {
+			log.Errorf("Failed to access the cache by the redis, sent to the upstream: %+v", err)
+		}
+		return
+	}
+	if atomic.CompareAndSwapInt32(&s.failed, 1, 0) {
+		log.Info("The redis of the cache is recovered")
+	}
+}
+
+func (s *redisStore) get(ctx context.Context, primary string, header http.Header) (*entry, bool) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	vary, err := s.client.Get(ctx, s.prefix+"vary:"+primary).Result()
+	if err != nil {
+		s.check(err)
+		return nil, false
+	}
+	var names []string
+	if vary != "" {
+		names = strings.Split(vary, ",")
+	}
+	data, err := s.client.Get(ctx, s.prefix+"entry:"+variantKey(primary, names, header)).Bytes()
+	s.check(err)
+	if err != nil {
+		return nil, false
+	}
+	r := &record{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, false
+	}
+	return &entry{
+		key:        variantKey(primary, names, header),
+		statusCode: r.StatusCode,
+		header:     r.Header,
+		body:       r.Body,
+		vary:       r.Vary,
+		storedAt:   r.StoredAt,
+		ttl:        r.TTL,
+		swr:        r.SWR,
+	}, true
+}
+
+func (s *redisStore) set(ctx context.Context, primary string, header http.Header, e *entry) {
+	e.key = variantKey(primary, e.vary, header)
+	data, err := json.Marshal(&record{
+		StatusCode: e.statusCode,
+		Header:     e.header,
+		Body:       e.body,
+		Vary:       e.vary,
+		StoredAt:   e.storedAt,
+		TTL:        e.ttl,
+		SWR:        e.swr,
+	})
+	if err != nil {
+		return
+	}
+	expiration := 2*e.ttl + e.swr
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	_, err = s.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, s.prefix+"vary:"+primary, strings.Join(e.vary, ","), expiration)
+		p.Set(ctx, s.prefix+"entry:"+e.key, data, expiration)
+		return nil
+	})
+	s.check(err)
+}
+
+// scan scans the keys of the prefix of all the masters of the cluster.
+func (s *redisStore) scan(ctx context.Context, fn func(client redis.UniversalClient, key string)) error {
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, s.prefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			fn(client, iter.Val())
+		}
+		return iter.Err()
+	}
+	if cluster, ok := s.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	}
+	return scan(ctx, s.client)
+}
+
+func (s *redisStore) purge(match func(key string) bool) int {
+	ctx := context.Background()
+	n := 0
+	err := s.scan(ctx, func(client redis.UniversalClient, key string) {
+		name := strings.TrimPrefix(key, s.prefix)
+		i := strings.IndexByte(name, ':')
+		if i < 0 || name[:i] == "lock" || !match(name[i+1:]) {
+			return
+		}
+		if err := client.Del(ctx, key).Err(); err != nil {
+			log.Errorf("Failed to purge the cache of %s: %+v", key, err)
+			return
+		}
+		if name[:i] == "entry" {
+			n++
+		}
+	})
+	if err != nil {
+		log.Errorf("Failed to purge the cache by the redis: %+v", err)
+	}
+	return n
+}
+
+func (s *redisStore) stats() map[string]interface{} {
+	return map[string]interface{}{"backend": "redis", "prefix": s.prefix}
+}
+
+// lock locks the key to be fetched by the gateway only, the lock expires
+// after the timeout, it's not locked if the redis is unreachable.
+func (s *redisStore) lock(ctx context.Context, primary string, timeout time.Duration) (func(), bool) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+	key := s.prefix + "lock:" + primary
+	rctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	ok, err := s.client.SetNX(rctx, key, token, timeout).Result()
+	s.check(err)
+	if err != nil || ok {
+		return func() {
+			if err != nil {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+			defer cancel()
+			_ = _unlockScript.Run(ctx, s.client, []string{key}, token).Err()
+		}, true
+	}
+	return nil, false
+}
+
+// locked reports whether the key is locked by the other gateway.
+func (s *redisStore) locked(ctx context.Context, primary string) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	n, err := s.client.Exists(ctx, s.prefix+"lock:"+primary).Result()
+	s.check(err)
+	return err == nil && n > 0
+}
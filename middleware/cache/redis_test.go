@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cache/v1"
+)
+
+func TestRedisCache(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	u := &upstream{handler: func(req *http.Request) *http.Response {
+		time.Sleep(50 * time.Millisecond)
+		return newResponse(http.StatusOK, http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}}, "hello "+req.Header.Get("Accept"))
+	}}
+	// the gateways share the entries of the redis.
+	gateways := []http.RoundTripper{
+		newCache(t, &v1.Cache{Zone: "TestRedisCache1", Redis: &v1.Redis{Addrs: []string{mr.Addr()}}}, u),
+		newCache(t, &v1.Cache{Zone: "TestRedisCache2", Redis: &v1.Redis{Addrs: []string{mr.Addr()}}}, u),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(next http.RoundTripper) {
+			defer wg.Done()
+			if _, body := get(t, next, "/items", http.Header{"Accept": {"text/plain"}}); body != "hello text/plain" {
+				t.Errorf("want hello but got %s", body)
+			}
+		}(gateways[i%2])
+	}
+	wg.Wait()
+	if u.count() != 1 {
+		t.Fatalf("want 1 request sent to the upstream but got %d", u.count())
+	}
+	resp, body := get(t, gateways[1], "/items", http.Header{"Accept": {"text/plain"}})
+	if resp.Header.Get(_resultHeader) != resultHit || body != "hello text/plain" {
+		t.Fatalf("want the shared hit but got %v %s", resp.Header, body)
+	}
+	if _, body := get(t, gateways[0], "/items", http.Header{"Accept": {"text/html"}}); body != "hello text/html" || u.count() != 2 {
+		t.Fatalf("want the variant of the Vary header but got %s %d", body, u.count())
+	}
+
+	if n := zoneOf("TestRedisCache1", 0, &v1.Redis{Addrs: []string{mr.Addr()}}).purge(keyMatcher("", "/items")); n != 2 {
+		t.Fatalf("want 2 entries purged but got %d", n)
+	}
+	if resp, _ := get(t, gateways[1], "/items", http.Header{"Accept": {"text/plain"}}); resp.Header.Get(_resultHeader) != resultMiss {
+		t.Fatalf("want the purged entry missed but got %v", resp.Header)
+	}
+
+	// the requests are sent to the upstream if the redis is unreachable.
+	mr.Close()
+	if resp, body := get(t, gateways[0], "/items", nil); resp.Header.Get(_resultHeader) != resultMiss || body != "hello " {
+		t.Fatalf("want the miss of the unreachable redis but got %v %s", resp.Header, body)
+	}
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cache/v1"
+)
+
+// entry is the cached response.
+type entry struct {
+	key        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	// the Vary header names of the response.
+	vary     []string
+	storedAt time.Time
+	// the freshness lifetime and the stale-while-revalidate window.
+	ttl time.Duration
+	swr time.Duration
+}
+
+func (e *entry) size() int64 {
+	n := len(e.key) + len(e.body)
+	for name, values := range e.header {
+		n += len(name)
+		for _, v := range values {
+			n += len(v)
+		}
+	}
+	return int64(n)
+}
+
+func (e *entry) age(now time.Time) time.Duration {
+	return now.Sub(e.storedAt)
+}
+
+// backend stores the entries keyed by the primary key and the values of the
+// Vary headers.
+type backend interface {
+	get(ctx context.Context, primary string, header http.Header) (*entry, bool)
+	set(ctx context.Context, primary string, header http.Header, e *entry)
+	// purge removes the entries of the keys matched, and returns the number.
+	purge(match func(key string) bool) int
+	stats() map[string]interface{}
+}
+
+// store is the LRU of the entries bounded by the bytes.
+type store struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	ll       *list.List
+	entries  map[string]*list.Element
+	// the Vary header names of the primary keys.
+	vary map[string][]string
+}
+
+func newStore(maxBytes int64) *store {
+	return &store{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		entries:  map[string]*list.Element{},
+		vary:     map[string][]string{},
+	}
+}
+
+// variantKey returns the key of the request by the Vary header names.
+func variantKey(primary string, vary []string, header http.Header) string {
+	if len(vary) == 0 {
+		return primary
+	}
+	var b strings.Builder
+	b.WriteString(primary)
+	for _, name := range vary {
+		b.WriteString("\x00" + strings.Join(header.Values(name), ","))
+	}
+	return b.String()
+}
+
+func (s *store) get(_ context.Context, primary string, header http.Header) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[variantKey(primary, s.vary[primary], header)]
+	if !ok {
+		return nil, false
+	}
+	s.ll.MoveToFront(elem)
+	return elem.Value.(*entry), true
+}
+
+func (s *store) set(_ context.Context, primary string, header http.Header, e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vary := s.vary[primary]; strings.Join(vary, ",") != strings.Join(e.vary, ",") {
+		// the variants of the previous Vary headers are not reachable.
+		s.removeLocked(func(key string) bool { return key == primary || strings.HasPrefix(key, primary+"\x00") })
+	}
+	s.vary[primary] = e.vary
+	e.key = variantKey(primary, e.vary, header)
+	if elem, ok := s.entries[e.key]; ok {
+		s.bytes -= elem.Value.(*entry).size()
+		elem.Value = e
+		s.ll.MoveToFront(elem)
+	} else {
+		s.entries[e.key] = s.ll.PushFront(e)
+	}
+	s.bytes += e.size()
+	for s.bytes > s.maxBytes && s.ll.Len() > 0 {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+func (s *store) removeElement(elem *list.Element) {
+	e := elem.Value.(*entry)
+	s.ll.Remove(elem)
+	delete(s.entries, e.key)
+	s.bytes -= e.size()
+}
+
+func (s *store) removeLocked(match func(key string) bool) int {
+	n := 0
+	for key, elem := range s.entries {
+		if match(key) {
+			s.removeElement(elem)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *store) purge(match func(key string) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.removeLocked(match)
+	for primary := range s.vary {
+		if match(primary) {
+			delete(s.vary, primary)
+		}
+	}
+	return n
+}
+
+func (s *store) resize(maxBytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxBytes = maxBytes
+	for s.bytes > s.maxBytes && s.ll.Len() > 0 {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+func (s *store) stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]interface{}{"backend": "memory", "entries": s.ll.Len(), "bytes": s.bytes, "maxBytes": s.maxBytes}
+}
+
+var (
+	_zonesMu sync.Mutex
+	_zones   = map[string]backend{}
+)
+
+// zoneOf returns the backend of the zone, the store is resized by the latest
+// config, and the backend is replaced once the redis is changed.
+func zoneOf(name string, maxBytes int64, r *v1.Redis) backend {
+	_zonesMu.Lock()
+	defer _zonesMu.Unlock()
+	if r != nil {
+		rs := newRedisStore(r)
+		if b, ok := _zones[name].(*redisStore); ok && b.client == rs.client && b.prefix == rs.prefix && b.timeout == rs.timeout {
+			return b
+		}
+		_zones[name] = rs
+		return rs
+	}
+	if s, ok := _zones[name].(*store); ok {
+		s.resize(maxBytes)
+		return s
+	}
+	s := newStore(maxBytes)
+	_zones[name] = s
+	return s
+}
+
+// AdminHandler returns the handler of the admin API to purge the entries by
+// `DELETE /admin/cache` with the zone, host and path queries, the path ends
+// with * for the prefix, all the entries are purged without the queries, and
+// the stats of the zones are listed by GET.
+func AdminHandler() http.Handler {
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc("/admin/cache", func(rw http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		zone := query.Get("zone")
+		_zonesMu.Lock()
+		zones := make(map[string]backend, len(_zones))
+		for name, s := range _zones {
+			if zone == "" || name == zone {
+				zones[name] = s
+			}
+		}
+		_zonesMu.Unlock()
+		var out interface{}
+		switch req.Method {
+		case http.MethodGet:
+			stats := make(map[string]interface{}, len(zones))
+			for name, s := range zones {
+				stats[name] = s.stats()
+			}
+			out = stats
+		case http.MethodDelete:
+			match := keyMatcher(query.Get("host"), query.Get("path"))
+			purged := 0
+			for _, s := range zones {
+				purged += s.purge(match)
+			}
+			out = map[string]int{"purged": purged}
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(out)
+	})
+	return adminMux
+}
+
+// keyMatcher returns the matcher of the keys by the host and the path.
+func keyMatcher(host, path string) func(key string) bool {
+	prefix := strings.HasSuffix(path, "*")
+	path = strings.TrimSuffix(path, "*")
+	return func(key string) bool {
+		h, p, _ := splitKey(key)
+		if host != "" && h != host {
+			return false
+		}
+		if prefix {
+			return strings.HasPrefix(p, path)
+		}
+		return path == "" || p == path
+	}
+}
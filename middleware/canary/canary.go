@@ -0,0 +1,70 @@
+package canary
+
+import (
+	"errors"
+	"net/http"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/canary/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func init() {
+	middleware.Register("canary", Middleware)
+}
+
+func matchRule(rule *v1.Rule, req *http.Request) bool {
+	switch m := rule.Match.(type) {
+	case *v1.Rule_Header:
+		values := req.Header.Values(m.Header.Name)
+		if m.Header.Value == "" {
+			return len(values) > 0
+		}
+		for _, v := range values {
+			if v == m.Header.Value {
+				return true
+			}
+		}
+	case *v1.Rule_Cookie:
+		cookie, err := req.Cookie(m.Cookie.Name)
+		if err != nil {
+			return false
+		}
+		return m.Cookie.Value == "" || cookie.Value == m.Cookie.Value
+	}
+	return false
+}
+
+// Middleware routes the requests matched by the rules to the canary cluster
+// of the endpoint regardless of the cluster weights.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Canary{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range options.Rules {
+		if rule.Cluster == "" {
+			return nil, errors.New("canary cluster is required")
+		}
+		if rule.GetHeader().GetName() == "" && rule.GetCookie().GetName() == "" {
+			return nil, errors.New("canary header or cookie name is required")
+		}
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for _, rule := range options.Rules {
+				if matchRule(rule, req) {
+					if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+						reqOpt.Cluster = rule.Cluster
+					}
+					break
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,67 @@
+package canary
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/canary/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestCanary(t *testing.T) {
+	options, err := anypb.New(&v1.Canary{
+		Rules: []*v1.Rule{
+			{Cluster: "canary", Match: &v1.Rule_Header{Header: &v1.Match{Name: "X-Canary", Value: "internal"}}},
+			{Cluster: "beta", Match: &v1.Rule_Cookie{Cookie: &v1.Match{Name: "beta"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "canary", Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cluster string
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqOpt, _ := middleware.FromRequestContext(req.Context())
+		cluster = reqOpt.Cluster
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+	testCases := []struct {
+		header http.Header
+		want   string
+	}{
+		{http.Header{}, ""},
+		{http.Header{"X-Canary": {"external"}}, ""},
+		{http.Header{"X-Canary": {"internal"}}, "canary"},
+		{http.Header{"Cookie": {"beta=1"}}, "beta"},
+		{http.Header{"X-Canary": {"internal"}, "Cookie": {"beta=1"}}, "canary"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/api/hello", nil)
+		req.Header = tc.header
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(&config.Endpoint{})))
+		if _, err := next.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if cluster != tc.want {
+			t.Errorf("%v: want cluster %q but got %q", tc.header, tc.want, cluster)
+		}
+	}
+}
+
+func TestCanaryInvalid(t *testing.T) {
+	for _, rule := range []*v1.Rule{
+		{Match: &v1.Rule_Header{Header: &v1.Match{Name: "X-Canary"}}},
+		{Cluster: "canary"},
+	} {
+		options, _ := anypb.New(&v1.Canary{Rules: []*v1.Rule{rule}})
+		if _, err := Middleware(&config.Middleware{Name: "canary", Options: options}); err == nil {
+			t.Errorf("want error of the rule: %v", rule)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cel
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cel/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	gocel "github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func init() {
+	middleware.Register("cel", Middleware)
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+func newEnv() (*gocel.Env, error) {
+	return gocel.NewEnv(gocel.Declarations(
+		decls.NewVar("request", decls.NewMapType(decls.String, decls.Dyn)),
+		decls.NewVar("claims", decls.NewMapType(decls.String, decls.Dyn)),
+		decls.NewVar("source", decls.NewMapType(decls.String, decls.Dyn)),
+		decls.NewVar("consumer", decls.String),
+	))
+}
+
+// compile compiles the expression of the result type, the dynamic results
+// are checked at the evaluation.
+func compile(env *gocel.Env, expr string, want *exprpb.Type) (gocel.Program, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expr, issues.Err())
+	}
+	if t := ast.ResultType(); !proto.Equal(t, want) && !proto.Equal(t, decls.Dyn) {
+		return nil, fmt.Errorf("invalid expression %q: want the result of %s", expr, want)
+	}
+	return env.Program(ast)
+}
+
+func compileAll(env *gocel.Env, exprs []string) ([]gocel.Program, error) {
+	programs := make([]gocel.Program, 0, len(exprs))
+	for _, expr := range exprs {
+		p, err := compile(env, expr, decls.Bool)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, p)
+	}
+	return programs, nil
+}
+
+// activation returns the attributes of the request to the expressions.
+func activation(req *http.Request) map[string]interface{} {
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	query := map[string]string{}
+	for name, values := range req.URL.Query() {
+		query[name] = values[0]
+	}
+	request := map[string]interface{}{
+		"method":    req.Method,
+		"host":      req.Host,
+		"path":      req.URL.Path,
+		"query":     query,
+		"headers":   headers,
+		"route":     "",
+		"path_vars": map[string]string{},
+	}
+	claims := map[string]interface{}{}
+	consumer := ""
+	if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+		request["route"] = reqOpt.Endpoint.GetPath()
+		if reqOpt.PathVars != nil {
+			request["path_vars"] = reqOpt.PathVars
+		}
+		if reqOpt.Claims != nil {
+			claims = reqOpt.Claims
+		}
+		if reqOpt.Consumer != nil {
+			consumer = reqOpt.Consumer.Name
+		}
+	}
+	source := map[string]interface{}{"ip": ""}
+	if ip := clientip.FromRequest(req); ip != nil {
+		source["ip"] = ip.String()
+	}
+	return map[string]interface{}{
+		"request":  request,
+		"claims":   claims,
+		"source":   source,
+		"consumer": consumer,
+	}
+}
+
+// match reports whether any of the programs is evaluated to true.
+func match(programs []gocel.Program, vars map[string]interface{}) (bool, error) {
+	for _, p := range programs {
+		out, _, err := p.Eval(vars)
+		if err != nil {
+			return false, err
+		}
+		v, ok := out.Value().(bool)
+		if !ok {
+			return false, fmt.Errorf("unexpected result of %s", out.Type().TypeName())
+		}
+		if v {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Middleware evaluates the CEL expressions to allow or deny the requests.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.CEL{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+	deny, err := compileAll(env, options.Deny)
+	if err != nil {
+		return nil, err
+	}
+	allow, err := compileAll(env, options.Allow)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]gocel.Program, len(options.Headers))
+	for name, expr := range options.Headers {
+		p, err := compile(env, expr, decls.String)
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = p
+	}
+	denyStatus := http.StatusForbidden
+	if options.DenyStatus != 0 {
+		denyStatus = int(options.DenyStatus)
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			vars := activation(req)
+			denied, err := match(deny, vars)
+			if err == nil && !denied && len(allow) > 0 {
+				var allowed bool
+				allowed, err = match(allow, vars)
+				denied = !allowed
+			}
+			if err != nil {
+				log.Errorf("Failed to evaluate the expressions of %s: %+v", req.URL.Path, err)
+				audit.PolicyDenied(req, "cel", "", denyStatus, "expressions failed to be evaluated")
+				return newResponse(denyStatus)
+			}
+			if denied {
+				audit.PolicyDenied(req, "cel", "", denyStatus, "denied by the expressions")
+				return newResponse(denyStatus)
+			}
+			for name, p := range headers {
+				out, _, err := p.Eval(vars)
+				if err != nil {
+					log.Errorf("Failed to evaluate the header %s of %s: %+v", name, req.URL.Path, err)
+					return newResponse(denyStatus)
+				}
+				if v, ok := out.Value().(string); ok && v != "" {
+					req.Header.Set(name, v)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,81 @@
+package cel
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/cel/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestCEL(t *testing.T) {
+	any, err := anypb.New(&v1.CEL{
+		Deny:    []string{`"x-debug" in request.headers && request.headers["x-debug"] == "1"`, `source.ip.startsWith("10.")`},
+		Allow:   []string{`request.method == "GET" && request.path_vars.id == "1"`, `"admin" in claims.roles`},
+		Headers: map[string]string{"X-Tier": `has(claims.tier) ? claims.tier : ""`, "X-Consumer": "consumer"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "cel", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+
+	testCases := []struct {
+		name, method, ip string
+		header           http.Header
+		claims           map[string]interface{}
+		want             int
+	}{
+		{"get", "GET", "192.168.0.1", nil, nil, http.StatusOK},
+		{"post", "POST", "192.168.0.1", nil, nil, http.StatusForbidden},
+		{"admin", "POST", "192.168.0.1", nil, map[string]interface{}{"roles": []interface{}{"admin"}, "tier": "gold"}, http.StatusOK},
+		{"debug", "GET", "192.168.0.1", http.Header{"X-Debug": []string{"1"}}, nil, http.StatusForbidden},
+		{"internal", "GET", "10.0.0.1", nil, nil, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, "/orders/1", nil)
+		req.RemoteAddr = tc.ip + ":1234"
+		for name, values := range tc.header {
+			req.Header[name] = values
+		}
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/orders/{id}"})
+		reqOpt.PathVars = map[string]string{"id": "1"}
+		reqOpt.Claims = tc.claims
+		reqOpt.Consumer = &config.Consumer{Name: "alice"}
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: want %d but got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+	if upstream.Get("X-Tier") != "gold" || upstream.Get("X-Consumer") != "alice" {
+		t.Fatalf("unexpected upstream headers: %v", upstream)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	env, err := newEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expr := range []string{`request.method ==`, `request.path + "/"`, `unknown == 1`} {
+		if _, err := compileAll(env, []string{expr}); err == nil {
+			t.Errorf("want the invalid expression %q rejected", expr)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"math/rand"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/go-kratos/gateway/middleware"
 	"github.com/go-kratos/gateway/proxy/condition"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/selector"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -23,7 +25,7 @@ import (
 func Init(clientFactory client.Factory) {
 	breakerFactory := New(clientFactory)
 	middleware.Register("circuitbreaker", breakerFactory)
-	prometheus.MustRegister(_metricDeniedTotal)
+	prometheus.MustRegister(_metricDeniedTotal, _metricState)
 }
 
 var (
@@ -33,6 +35,12 @@ var (
 		Name:      "requests_circuit_breaker_denied_total",
 		Help:      "The total number of denied requests",
 	}, []string{"protocol", "method", "path", "service", "basePath"})
+	_metricState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "circuit_breaker_state",
+		Help:      "The state of the circuit breakers, 0 is closed, 1 is open and 2 is half-open",
+	}, []string{"protocol", "method", "path", "service", "basePath", "node"})
 )
 
 type ratioTrigger struct {
@@ -65,7 +73,7 @@ func (nopTrigger) Allow() error { return nil }
 func (nopTrigger) MarkSuccess() {}
 func (nopTrigger) MarkFailed()  {}
 
-func makeBreakerTrigger(in *v1.CircuitBreaker) circuitbreaker.CircuitBreaker {
+func makeBreakerTrigger(in *v1.CircuitBreaker, onChange func(state int32)) circuitbreaker.CircuitBreaker {
 	switch trigger := in.Trigger.(type) {
 	case *v1.CircuitBreaker_SuccessRatio:
 		var opts []sre.Option
@@ -84,6 +92,10 @@ func makeBreakerTrigger(in *v1.CircuitBreaker) circuitbreaker.CircuitBreaker {
 		return sre.NewBreaker(opts...)
 	case *v1.CircuitBreaker_Ratio:
 		return newRatioTrigger(trigger)
+	case *v1.CircuitBreaker_ErrorRate:
+		return newStateBreaker(newErrorRate(trigger.ErrorRate), in, onChange)
+	case *v1.CircuitBreaker_ConsecutiveFailures:
+		return newStateBreaker(newConsecutiveFailures(trigger.ConsecutiveFailures), in, onChange)
 	default:
 		log.Warnf("Unrecoginzed circuit breaker trigger: %+v", trigger)
 		return nopTrigger{}
@@ -129,6 +141,23 @@ func isSuccessResponse(conditions []condition.Condition, resp *http.Response) bo
 	return condition.JudgeConditons(conditions, resp, true)
 }
 
+// markRejected counts the rejected request as failed to let the drop ratio
+// of the sre breaker higher, the state breakers only count the results of
+// the allowed requests.
+func markRejected(breaker circuitbreaker.CircuitBreaker) {
+	if _, ok := breaker.(*stateBreaker); !ok {
+		breaker.MarkFailed()
+	}
+}
+
+func markResult(breaker circuitbreaker.CircuitBreaker, conditions []condition.Condition, resp *http.Response, err error) {
+	if err != nil || !isSuccessResponse(conditions, resp) {
+		breaker.MarkFailed()
+		return
+	}
+	breaker.MarkSuccess()
+}
+
 func deniedRequestIncr(req *http.Request) {
 	labels, ok := middleware.MetricsLabelsFromContext(req.Context())
 	if ok {
@@ -137,6 +166,34 @@ func deniedRequestIncr(req *http.Request) {
 	}
 }
 
+// breakers is the breakers of the scope keys, they're created by the labels
+// of the first requests.
+type breakers struct {
+	options *v1.CircuitBreaker
+
+	mu       sync.Mutex
+	breakers map[string]circuitbreaker.CircuitBreaker
+}
+
+func (b *breakers) get(key string, labels middleware.MetricsLabels, node string) circuitbreaker.CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breaker, ok := b.breakers[key]
+	if !ok {
+		var values []string
+		if labels != nil {
+			values = []string{labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), node}
+		} else {
+			values = []string{"", "", "", "", "", node}
+		}
+		breaker = makeBreakerTrigger(b.options, func(state int32) {
+			_metricState.WithLabelValues(values...).Set(float64(state))
+		})
+		b.breakers[key] = breaker
+	}
+	return breaker
+}
+
 func New(factory client.Factory) middleware.Factory {
 	return func(c *config.Middleware) (middleware.Middleware, error) {
 		options := &v1.CircuitBreaker{}
@@ -145,7 +202,7 @@ func New(factory client.Factory) middleware.Factory {
 				return nil, err
 			}
 		}
-		breaker := makeBreakerTrigger(options)
+		bs := &breakers{options: options, breakers: map[string]circuitbreaker.CircuitBreaker{}}
 		onBreakHandler, err := makeOnBreakHandler(options, factory)
 		if err != nil {
 			return nil, err
@@ -156,26 +213,77 @@ func New(factory client.Factory) middleware.Factory {
 		}
 		return func(next http.RoundTripper) http.RoundTripper {
 			return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				labels, _ := middleware.MetricsLabelsFromContext(req.Context())
+				reqOpt, ok := middleware.FromRequestContext(req.Context())
+				if options.Scope == v1.CircuitBreaker_NODE && ok {
+					return roundTripNodes(bs, labels, reqOpt, next, onBreakHandler, assertCondtions, req)
+				}
+				var key string
+				if e, ok := middleware.EndpointFromContext(req.Context()); ok && options.Scope == v1.CircuitBreaker_ENDPOINT {
+					key = e.Host + " " + e.Method + " " + e.Path
+				}
+				breaker := bs.get(key, labels, "")
 				if err := breaker.Allow(); err != nil {
 					// rejected
 					// NOTE: when client reject requets locally,
 					// continue add counter let the drop ratio higher.
-					breaker.MarkFailed()
+					markRejected(breaker)
 					deniedRequestIncr(req)
 					return onBreakHandler.RoundTrip(req)
 				}
 				resp, err := next.RoundTrip(req)
+				markResult(breaker, assertCondtions, resp, err)
 				if err != nil {
-					breaker.MarkFailed()
 					return nil, err
 				}
-				if !isSuccessResponse(assertCondtions, resp) {
-					breaker.MarkFailed()
-					return resp, nil
-				}
-				breaker.MarkSuccess()
 				return resp, nil
 			})
 		}, nil
 	}
 }
+
+// roundTripNodes filters out the nodes of the open breakers, the request is
+// broken if none of the nodes are allowed.
+func roundTripNodes(bs *breakers, labels middleware.MetricsLabels, reqOpt *middleware.RequestOptions, next, onBreakHandler http.RoundTripper, conditions []condition.Condition, req *http.Request) (*http.Response, error) {
+	var (
+		allowed  = map[string]circuitbreaker.CircuitBreaker{}
+		rejected bool
+	)
+	filters, backends := len(reqOpt.Filters), len(reqOpt.Backends)
+	reqOpt.Filters = append(reqOpt.Filters, func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		newNodes := nodes[:0]
+		for _, node := range nodes {
+			breaker := bs.get(node.Address(), labels, node.Address())
+			if err := breaker.Allow(); err != nil {
+				markRejected(breaker)
+				continue
+			}
+			allowed[node.Address()] = breaker
+			newNodes = append(newNodes, node)
+		}
+		rejected = len(newNodes) == 0
+		return newNodes
+	})
+	resp, err := next.RoundTrip(req)
+	reqOpt.Filters = reqOpt.Filters[:filters]
+	var selected string
+	if len(reqOpt.Backends) > backends {
+		selected = reqOpt.Backends[len(reqOpt.Backends)-1]
+	}
+	for addr, breaker := range allowed {
+		if b, ok := breaker.(*stateBreaker); ok && addr != selected {
+			b.cancel()
+		}
+	}
+	if rejected && selected == "" {
+		deniedRequestIncr(req)
+		return onBreakHandler.RoundTrip(req)
+	}
+	if breaker, ok := allowed[selected]; ok {
+		markResult(breaker, conditions, resp, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
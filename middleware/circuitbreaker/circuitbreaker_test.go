@@ -0,0 +1,147 @@
+package circuitbreaker
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/circuitbreaker/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/selector"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestStateBreaker(t *testing.T) {
+	var states []int32
+	b := newStateBreaker(newConsecutiveFailures(&v1.ConsecutiveFailures{Failures: 2}), &v1.CircuitBreaker{
+		OpenTimeout: durationpb.New(20 * time.Millisecond),
+	}, func(state int32) { states = append(states, state) })
+	b.MarkFailed()
+	b.MarkSuccess()
+	b.MarkFailed()
+	if b.Allow() != nil {
+		t.Fatal("want closed since the failures are not consecutive")
+	}
+	b.MarkFailed()
+	if b.Allow() != circuitbreaker.ErrNotAllowed {
+		t.Fatal("want opened by the consecutive failures")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if b.Allow() != nil {
+		t.Fatal("want the probe allowed in the half-open state")
+	}
+	if b.Allow() == nil {
+		t.Fatal("want the requests over the probes rejected")
+	}
+	b.MarkFailed()
+	if b.Allow() == nil {
+		t.Fatal("want opened again by the failed probe")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if b.Allow() != nil {
+		t.Fatal("want the probe allowed in the half-open state")
+	}
+	b.MarkSuccess()
+	if b.Allow() != nil || b.state != _stateClosed {
+		t.Fatal("want closed by the succeeded probe")
+	}
+	want := []int32{_stateClosed, _stateOpen, _stateHalfOpen, _stateOpen, _stateHalfOpen, _stateClosed}
+	if len(states) != len(want) {
+		t.Fatalf("want the states %v but got %v", want, states)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("want the states %v but got %v", want, states)
+		}
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	p := newErrorRate(&v1.ErrorRate{Rate: 0.5, Request: 4})
+	p.markSuccess()
+	if p.markFailed() {
+		t.Fatal("want not opened under the min requests")
+	}
+	p.markSuccess()
+	if !p.markFailed() {
+		t.Fatal("want opened by the error rate")
+	}
+	p.reset()
+	if p.markFailed() {
+		t.Fatal("want the stats reset")
+	}
+}
+
+func TestNodeScope(t *testing.T) {
+	any, err := anypb.New(&v1.CircuitBreaker{
+		Trigger: &v1.CircuitBreaker_ConsecutiveFailures{ConsecutiveFailures: &v1.ConsecutiveFailures{Failures: 1}},
+		Action: &v1.CircuitBreaker_ResponseData{ResponseData: &v1.ResponseData{
+			StatusCode: http.StatusServiceUnavailable,
+		}},
+		AssertCondtions: []*config.Condition{{Condition: &config.Condition_ByStatusCode{ByStatusCode: "200-299"}}},
+		Scope:           v1.CircuitBreaker_NODE,
+		OpenTimeout:     durationpb.New(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(nil)(&config.Middleware{Name: "circuitbreaker", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]int{"10.0.0.1:80": http.StatusInternalServerError, "10.0.0.2:80": http.StatusOK}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// pick the first node of the filtered nodes like the client.
+		nodes := []selector.Node{
+			selector.NewNode("http", "10.0.0.1:80", nil),
+			selector.NewNode("http", "10.0.0.2:80", nil),
+		}
+		filters, _ := middleware.SelectorFiltersFromContext(req.Context())
+		for _, filter := range filters {
+			nodes = filter(req.Context(), nodes)
+		}
+		if len(nodes) == 0 {
+			return nil, selector.ErrNoAvailable
+		}
+		addr := nodes[0].Address()
+		middleware.WithRequestBackends(req.Context(), addr)
+		return &http.Response{StatusCode: statuses[addr], Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func() (*http.Response, *middleware.RequestOptions) {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/orders"})
+		// the retry filter is not to exclude the selected nodes.
+		reqOpt.Filters = nil
+		req = req.WithContext(middleware.NewRequestContext(context.Background(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, reqOpt
+	}
+
+	if resp, _ := do(); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("want 500 of the first node but got %d", resp.StatusCode)
+	}
+	for i := 0; i < 2; i++ {
+		resp, reqOpt := do()
+		if resp.StatusCode != http.StatusOK || reqOpt.Backends[0] != "10.0.0.2:80" {
+			t.Fatalf("want the broken node skipped but got %d %v", resp.StatusCode, reqOpt.Backends)
+		}
+		if len(reqOpt.Filters) != 0 {
+			t.Fatal("want the filter removed after the request")
+		}
+	}
+	statuses["10.0.0.2:80"] = http.StatusBadGateway
+	do()
+	if resp, _ := do(); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("want broken once all the nodes are broken but got %d", resp.StatusCode)
+	}
+}
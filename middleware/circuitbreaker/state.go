@@ -0,0 +1,205 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/pkg/window"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/circuitbreaker/v1"
+)
+
+const (
+	_stateClosed int32 = iota
+	_stateOpen
+	_stateHalfOpen
+)
+
+const (
+	_defaultOpenTimeout         = 5 * time.Second
+	_defaultHalfOpenRequests    = 1
+	_defaultErrorRateRequest    = 20
+	_defaultErrorRateBucket     = 10
+	_defaultErrorRateWindow     = 10 * time.Second
+	_defaultConsecutiveFailures = 5
+)
+
+// policy reports whether the closed breaker should be opened by the results.
+type policy interface {
+	markSuccess()
+	// markFailed reports whether the breaker should be opened.
+	markFailed() bool
+	reset()
+}
+
+type errorRate struct {
+	rate    float64
+	request int64
+	opts    window.RollingCounterOpts
+	stat    window.RollingCounter
+}
+
+func newErrorRate(in *v1.ErrorRate) *errorRate {
+	request, bucket, size := int64(_defaultErrorRateRequest), _defaultErrorRateBucket, _defaultErrorRateWindow
+	if in.Request > 0 {
+		request = int64(in.Request)
+	}
+	if in.Bucket > 0 {
+		bucket = int(in.Bucket)
+	}
+	if in.Window != nil && in.Window.AsDuration() > 0 {
+		size = in.Window.AsDuration()
+	}
+	p := &errorRate{
+		rate:    in.Rate,
+		request: request,
+		opts:    window.RollingCounterOpts{Size: bucket, BucketDuration: size / time.Duration(bucket)},
+	}
+	p.reset()
+	return p
+}
+
+func (p *errorRate) markSuccess() { p.stat.Add(1) }
+
+func (p *errorRate) markFailed() bool {
+	p.stat.Add(0)
+	var success, total int64
+	p.stat.Reduce(func(iterator window.Iterator) float64 {
+		for iterator.Next() {
+			bucket := iterator.Bucket()
+			total += bucket.Count
+			for _, p := range bucket.Points {
+				success += int64(p)
+			}
+		}
+		return 0
+	})
+	return total >= p.request && float64(total-success) >= p.rate*float64(total)
+}
+
+func (p *errorRate) reset() { p.stat = window.NewRollingCounter(p.opts) }
+
+type consecutiveFailures struct {
+	failures int
+	count    int
+}
+
+func newConsecutiveFailures(in *v1.ConsecutiveFailures) *consecutiveFailures {
+	failures := _defaultConsecutiveFailures
+	if in.Failures > 0 {
+		failures = int(in.Failures)
+	}
+	return &consecutiveFailures{failures: failures}
+}
+
+func (p *consecutiveFailures) markSuccess() { p.count = 0 }
+
+func (p *consecutiveFailures) markFailed() bool {
+	p.count++
+	return p.count >= p.failures
+}
+
+func (p *consecutiveFailures) reset() { p.count = 0 }
+
+// stateBreaker is the breaker of the closed, open and half-open states, it's
+// opened by the policy, half-open after the open timeout, and closed again
+// once the probes of the half-open state are all succeeded.
+type stateBreaker struct {
+	policy           policy
+	openTimeout      time.Duration
+	halfOpenRequests int
+	onChange         func(state int32)
+
+	mu        sync.Mutex
+	state     int32
+	openedAt  time.Time
+	probes    int
+	succeeded int
+}
+
+var _ circuitbreaker.CircuitBreaker = &stateBreaker{}
+
+func newStateBreaker(policy policy, in *v1.CircuitBreaker, onChange func(int32)) *stateBreaker {
+	b := &stateBreaker{
+		policy:           policy,
+		openTimeout:      _defaultOpenTimeout,
+		halfOpenRequests: _defaultHalfOpenRequests,
+		onChange:         onChange,
+	}
+	if in.OpenTimeout != nil {
+		b.openTimeout = in.OpenTimeout.AsDuration()
+	}
+	if in.HalfOpenRequests > 0 {
+		b.halfOpenRequests = int(in.HalfOpenRequests)
+	}
+	onChange(_stateClosed)
+	return b
+}
+
+func (b *stateBreaker) setState(state int32) {
+	b.state = state
+	switch state {
+	case _stateOpen:
+		b.openedAt = time.Now()
+	case _stateHalfOpen:
+		b.probes, b.succeeded = 0, 0
+	case _stateClosed:
+		b.policy.reset()
+	}
+	b.onChange(state)
+}
+
+func (b *stateBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case _stateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return circuitbreaker.ErrNotAllowed
+		}
+		b.setState(_stateHalfOpen)
+		fallthrough
+	case _stateHalfOpen:
+		if b.probes >= b.halfOpenRequests {
+			return circuitbreaker.ErrNotAllowed
+		}
+		b.probes++
+	}
+	return nil
+}
+
+func (b *stateBreaker) MarkSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case _stateClosed:
+		b.policy.markSuccess()
+	case _stateHalfOpen:
+		if b.succeeded++; b.succeeded >= b.halfOpenRequests {
+			b.setState(_stateClosed)
+		}
+	}
+}
+
+func (b *stateBreaker) MarkFailed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case _stateClosed:
+		if b.policy.markFailed() {
+			b.setState(_stateOpen)
+		}
+	case _stateHalfOpen:
+		b.setState(_stateOpen)
+	}
+}
+
+// cancel releases the probe of the allowed request which is not sent, e.g.
+// the node is not selected.
+func (b *stateBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == _stateHalfOpen && b.probes > b.succeeded {
+		b.probes--
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// LookupClaim returns the claim of the name, the nested claims are referred
+// by the dots if the name is not a claim, e.g. realm_access.roles.
+func LookupClaim(claims map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := claims[name]; ok {
+		return v, true
+	}
+	var v interface{} = claims
+	for _, field := range strings.Split(name, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[field]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// FormatClaim formats the claim of the tokens as the header value, the strings
+// of an array are joined by comma, and the other values are formatted as JSON.
+func FormatClaim(claim interface{}) string {
+	switch v := claim.(type) {
+	case string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				values = nil
+				break
+			}
+			values = append(values, s)
+		}
+		if values != nil {
+			return strings.Join(values, ",")
+		}
+	}
+	data, _ := json.Marshal(claim)
+	return string(data)
+}
+
+// WithClaims tags the request with the claims of the verified token.
+func WithClaims(ctx context.Context, claims map[string]interface{}) context.Context {
+	o, ok := ctx.Value(contextKey{}).(*RequestOptions)
+	if ok {
+		o.Claims = claims
+	}
+	return ctx
+}
+
+// WithTrustedHeaders marks the request headers set by the auth middlewares,
+// the other headers of the same names are sent by the clients and not trusted
+// by the policy middlewares.
+func WithTrustedHeaders(ctx context.Context, names ...string) context.Context {
+	o, ok := ctx.Value(contextKey{}).(*RequestOptions)
+	if ok {
+		for _, name := range names {
+			o.TrustedHeaders = append(o.TrustedHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+	return ctx
+}
+
+// TrustedHeader reports whether the request header is set by the auth middlewares.
+func TrustedHeader(ctx context.Context, name string) bool {
+	o, ok := ctx.Value(contextKey{}).(*RequestOptions)
+	if !ok {
+		return false
+	}
+	name = http.CanonicalHeaderKey(name)
+	for _, trusted := range o.TrustedHeaders {
+		if trusted == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimsFromContext returns the claims of the request from context.
+func ClaimsFromContext(ctx context.Context) (map[string]interface{}, bool) {
+	o, ok := ctx.Value(contextKey{}).(*RequestOptions)
+	if ok && o.Claims != nil {
+		return o.Claims, true
+	}
+	return nil, false
+}
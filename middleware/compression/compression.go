@@ -0,0 +1,366 @@
+package compression
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/compression/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultMinSize         = 1024
+	_defaultMaxRequestBytes = 32 << 20
+)
+
+var (
+	_defaultEncodings = []string{"gzip", "deflate"}
+	_defaultMIMETypes = []string{
+		"text/*",
+		"application/json",
+		"application/javascript",
+		"application/xml",
+		"application/x-javascript",
+		"image/svg+xml",
+	}
+
+	errBadEncoding = &middleware.StatusError{StatusCode: http.StatusBadRequest, Message: "invalid request body encoding"}
+	errTooLarge    = &middleware.StatusError{StatusCode: http.StatusRequestEntityTooLarge, Message: "decompressed request body too large"}
+)
+
+func init() {
+	middleware.Register("compression", Middleware)
+}
+
+// parseCodings returns the lowercase content codings of the header in the
+// order applied, the identity ones are skipped.
+func parseCodings(header string) []string {
+	var codings []string
+	for _, coding := range strings.Split(header, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "" && coding != "identity" {
+			codings = append(codings, coding)
+		}
+	}
+	return codings
+}
+
+// negotiate returns the encoding of the highest q-value accepted by the
+// Accept-Encoding header, the earlier one of the encodings is preferred for
+// the same q-value, * accepts the encodings not listed, and q=0 rejects.
+func negotiate(header string, encodings []string) string {
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") || strings.HasPrefix(param, "Q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					v = 0
+				}
+				q = v
+			}
+		}
+		accepted[name] = q
+	}
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, name := range encodings {
+		q, ok := accepted[name]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// matchMIME reports whether the media type of the content type is matched by
+// the patterns, the patterns of type/* match the subtypes of the type.
+func matchMIME(contentType string, patterns []string) bool {
+	mediaType := contentType
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern == "*/*" || pattern == mediaType {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds the header name to the Vary header if it's not listed.
+func addVary(header http.Header, name string) {
+	for _, v := range header.Values("Vary") {
+		for _, n := range strings.Split(v, ",") {
+			if n = strings.TrimSpace(n); n == "*" || strings.EqualFold(n, name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// compressedBody is the body compressed in the background, the compressed
+// bytes are flushed once the chunk is written for the streaming responses.
+type compressedBody struct {
+	*io.PipeReader
+	body io.ReadCloser
+}
+
+func (b *compressedBody) Close() error {
+	_ = b.PipeReader.Close()
+	return b.body.Close()
+}
+
+func compress(body io.ReadCloser, e Encoding, level int, flush bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer body.Close()
+		w, err := e.NewWriter(pw, level)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := body.Read(buf)
+			if n > 0 {
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					pw.CloseWithError(werr)
+					return
+				}
+				if f, ok := w.(flusher); ok && flush {
+					if ferr := f.Flush(); ferr != nil {
+						pw.CloseWithError(ferr)
+						return
+					}
+				}
+			}
+			if err == io.EOF {
+				pw.CloseWithError(w.Close())
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return &compressedBody{PipeReader: pr, body: body}
+}
+
+// decompressedBody fails with the status errors once the body is invalid or
+// larger than the max bytes.
+type decompressedBody struct {
+	io.Reader
+	body     io.ReadCloser
+	maxBytes int64
+	read     int64
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.read > b.maxBytes {
+		return 0, errTooLarge
+	}
+	if int64(len(p)) > b.maxBytes-b.read+1 {
+		p = p[:b.maxBytes-b.read+1]
+	}
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	if b.read > b.maxBytes {
+		return n, errTooLarge
+	}
+	if err != nil && err != io.EOF {
+		return n, fmt.Errorf("%w: %v", errBadEncoding, err)
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	return b.body.Close()
+}
+
+type compression struct {
+	next            http.RoundTripper
+	encodings       []string
+	minSize         int64
+	mimeTypes       []string
+	level           int
+	decompress      bool
+	maxRequestBytes int64
+}
+
+// compressible reports whether the response can be compressed by the gateway
+// regardless of the encodings accepted by the client.
+func (c *compression) compressible(req *http.Request, resp *http.Response) bool {
+	if req.Method == http.MethodHead || resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+	switch {
+	case resp.StatusCode < http.StatusOK,
+		resp.StatusCode == http.StatusNoContent,
+		resp.StatusCode == http.StatusPartialContent,
+		resp.StatusCode == http.StatusNotModified:
+		return false
+	}
+	if len(parseCodings(resp.Header.Get("Content-Encoding"))) > 0 || resp.Header.Get("Content-Range") != "" {
+		return false
+	}
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength < c.minSize {
+		return false
+	}
+	return matchMIME(resp.Header.Get("Content-Type"), c.mimeTypes)
+}
+
+func (c *compression) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if !c.compressible(req, resp) {
+		return resp, nil
+	}
+	addVary(resp.Header, "Accept-Encoding")
+	name := negotiate(req.Header.Get("Accept-Encoding"), c.encodings)
+	if name == "" {
+		return resp, nil
+	}
+	e, ok := encodingOf(name)
+	if !ok {
+		return resp, nil
+	}
+	// the unknown length responses are flushed as streaming by the proxy.
+	flush := resp.ContentLength < 0
+	resp.Body = compress(resp.Body, e, c.level, flush)
+	resp.Header.Set("Content-Encoding", name)
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		// the compressed representation is not byte-for-byte identical.
+		resp.Header.Set("ETag", "W/"+etag)
+	}
+	return resp, nil
+}
+
+// FilterRequestBody decompresses the request body of the encodings before
+// it's buffered by the proxy, the body of any unknown coding is sent as is,
+// and it's sent to the upstream in chunks without the content length.
+func (c *compression) FilterRequestBody(req *http.Request, body io.ReadCloser) (io.ReadCloser, error) {
+	if !c.decompress || body == nil || body == http.NoBody {
+		return body, nil
+	}
+	codings := parseCodings(req.Header.Get("Content-Encoding"))
+	if len(codings) == 0 {
+		return body, nil
+	}
+	encodings := make([]Encoding, 0, len(codings))
+	for _, coding := range codings {
+		e, ok := c.encodingOf(coding)
+		if !ok {
+			return body, nil
+		}
+		encodings = append(encodings, e)
+	}
+	var r io.Reader = body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		dr, err := encodings[i].NewReader(r)
+		if err != nil {
+			body.Close()
+			return nil, errBadEncoding
+		}
+		r = dr
+	}
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	req.ContentLength = -1
+	return &decompressedBody{Reader: r, body: body, maxBytes: c.maxRequestBytes}, nil
+}
+
+// encodingOf returns the encoding configured of the content coding.
+func (c *compression) encodingOf(coding string) (Encoding, bool) {
+	for _, name := range c.encodings {
+		if name == coding {
+			return encodingOf(name)
+		}
+	}
+	return nil, false
+}
+
+// Middleware compresses the responses by the encodings accepted by the
+// clients, and decompresses the request bodies for the upstreams.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Compression{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	cm := &compression{
+		encodings:       _defaultEncodings,
+		minSize:         options.MinSize,
+		mimeTypes:       _defaultMIMETypes,
+		level:           int(options.Level),
+		decompress:      options.DecompressRequest,
+		maxRequestBytes: options.MaxRequestBytes,
+	}
+	if len(options.Encodings) > 0 {
+		cm.encodings = make([]string, 0, len(options.Encodings))
+		for _, name := range options.Encodings {
+			cm.encodings = append(cm.encodings, strings.ToLower(name))
+		}
+	}
+	for _, name := range cm.encodings {
+		e, ok := encodingOf(name)
+		if !ok {
+			return nil, fmt.Errorf("compression encoding is not registered: %s", name)
+		}
+		if err := validLevel(e, cm.level); err != nil {
+			return nil, fmt.Errorf("invalid compression level %d of %s: %v", cm.level, name, err)
+		}
+	}
+	if len(options.MimeTypes) > 0 {
+		cm.mimeTypes = make([]string, 0, len(options.MimeTypes))
+		for _, t := range options.MimeTypes {
+			cm.mimeTypes = append(cm.mimeTypes, strings.ToLower(t))
+		}
+	}
+	if cm.minSize <= 0 {
+		cm.minSize = _defaultMinSize
+	}
+	if cm.maxRequestBytes <= 0 {
+		cm.maxRequestBytes = _defaultMaxRequestBytes
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		m := *cm
+		m.next = next
+		return &m
+	}, nil
+}
@@ -0,0 +1,259 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/compression/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestNegotiate(t *testing.T) {
+	encodings := []string{"br", "gzip", "deflate"}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"GZIP;Q=0.5, deflate;q=0.5", "gzip"},
+		{"*", "br"},
+		{"br;q=0, *;q=0.1", "gzip"},
+		{"identity", ""},
+		{"gzip;q=0", ""},
+	}
+	for _, test := range tests {
+		if got := negotiate(test.header, encodings); got != test.want {
+			t.Errorf("want %q of %q but got %q", test.want, test.header, got)
+		}
+	}
+}
+
+func TestMatchMIME(t *testing.T) {
+	patterns := []string{"text/*", "application/json"}
+	for contentType, want := range map[string]bool{
+		"text/html; charset=utf-8": true,
+		"Application/JSON":         true,
+		"application/json+x":       false,
+		"image/png":                false,
+		"":                         false,
+	} {
+		if got := matchMIME(contentType, patterns); got != want {
+			t.Errorf("want %v of %q but got %v", want, contentType, got)
+		}
+	}
+}
+
+func newMiddleware(t *testing.T, options *v1.Compression, next http.RoundTripper) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "compression", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(next)
+}
+
+func TestCompression(t *testing.T) {
+	body := strings.Repeat("hello gateway ", 200)
+	next := middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/plain"}, "Etag": {`"v1"`}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+		switch req.URL.Path {
+		case "/small":
+			resp.Body = ioutil.NopCloser(strings.NewReader("hello"))
+			resp.ContentLength = 5
+		case "/image":
+			resp.Header.Set("Content-Type", "image/png")
+		case "/encoded":
+			resp.Header.Set("Content-Encoding", "gzip")
+		default:
+			resp.ContentLength = int64(len(body))
+		}
+		return resp, nil
+	})
+	tripper := newMiddleware(t, &v1.Compression{}, next)
+
+	req := httptest.NewRequest("GET", "/text", nil)
+	req.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip")
+	resp, err := tripper.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("Content-Encoding") != "gzip" || resp.ContentLength != -1 || resp.Header.Get("Vary") != "Accept-Encoding" || resp.Header.Get("ETag") != `W/"v1"` {
+		t.Fatalf("want the gzip response but got %v %d", resp.Header, resp.ContentLength)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadAll(zr); err != nil || string(data) != body {
+		t.Fatalf("want the body decompressed but got %d bytes %v", len(data), err)
+	}
+	resp.Body.Close()
+
+	req = httptest.NewRequest("GET", "/text", nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	if resp, err = tripper.RoundTrip(req); err != nil || resp.Header.Get("Content-Encoding") != "deflate" {
+		t.Fatalf("want the deflate response but got %v %v", resp.Header, err)
+	}
+	fr, err := zlib.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadAll(fr); err != nil || string(data) != body {
+		t.Fatalf("want the body decompressed but got %d bytes %v", len(data), err)
+	}
+
+	req = httptest.NewRequest("GET", "/text", nil)
+	if resp, err = tripper.RoundTrip(req); err != nil || resp.Header.Get("Content-Encoding") != "" || resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("want the identity response varied but got %v %v", resp.Header, err)
+	}
+	for _, path := range []string{"/small", "/image", "/encoded"} {
+		req = httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := tripper.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Get("Vary") != "" || (path != "/encoded" && resp.Header.Get("Content-Encoding") != "") {
+			t.Fatalf("want %s not compressed but got %v", path, resp.Header)
+		}
+	}
+
+	if _, err := Middleware(&config.Middleware{Name: "compression", Options: mustAny(t, &v1.Compression{Encodings: []string{"zstd"}})}); err == nil {
+		t.Fatal("want the error of the encoding not registered")
+	}
+	if _, err := Middleware(&config.Middleware{Name: "compression", Options: mustAny(t, &v1.Compression{Level: 12})}); err == nil {
+		t.Fatal("want the error of the invalid level")
+	}
+}
+
+func mustAny(t *testing.T, options *v1.Compression) *anypb.Any {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return any
+}
+
+// reverseEncoding is the content coding reversing the bytes, it's registered
+// like the ones not built in, e.g. br and zstd.
+type reverseEncoding struct{}
+
+type reverseWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (w *reverseWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *reverseWriter) Close() error {
+	_, err := w.w.Write(reverse(w.buf.Bytes()))
+	return err
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		out[len(b)-1-i] = c
+	}
+	return out
+}
+
+func (reverseEncoding) NewWriter(w io.Writer, _ int) (io.WriteCloser, error) {
+	return &reverseWriter{w: w}, nil
+}
+
+func (reverseEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(reverse(data))), nil
+}
+
+func TestRegisterEncoding(t *testing.T) {
+	RegisterEncoding("x-reverse", reverseEncoding{})
+	tripper := newMiddleware(t, &v1.Compression{Encodings: []string{"x-reverse", "gzip"}, MinSize: 1}, middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{"Content-Type": {"text/plain"}},
+			ContentLength: 10,
+			Body:          ioutil.NopCloser(strings.NewReader("helloworld")),
+		}, nil
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, x-reverse")
+	resp, err := tripper.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.Header.Get("Content-Encoding") != "x-reverse" || string(body) != "dlrowolleh" {
+		t.Fatalf("want the body of the registered encoding but got %v %s", resp.Header, body)
+	}
+}
+
+func TestDecompressRequest(t *testing.T) {
+	tripper := newMiddleware(t, &v1.Compression{DecompressRequest: true, MaxRequestBytes: 100}, http.DefaultTransport)
+	filter, ok := tripper.(middleware.RequestBodyFilter)
+	if !ok {
+		t.Fatal("want the request body filter")
+	}
+	gzipped := func(data string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write([]byte(data))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	read := func(encoding string, data []byte) (*http.Request, []byte, error) {
+		req := httptest.NewRequest("POST", "/upload", bytes.NewReader(data))
+		req.Header.Set("Content-Encoding", encoding)
+		body, err := filter.FilterRequestBody(req, req.Body)
+		if err != nil {
+			return req, nil, err
+		}
+		data, err = ioutil.ReadAll(body)
+		return req, data, err
+	}
+	statusCode := func(err error) int {
+		var statusError *middleware.StatusError
+		if errors.As(err, &statusError) {
+			return statusError.StatusCode
+		}
+		return 0
+	}
+
+	req, data, err := read("gzip", gzipped("hello"))
+	if err != nil || string(data) != "hello" || req.Header.Get("Content-Encoding") != "" || req.ContentLength != -1 {
+		t.Fatalf("want the body decompressed but got %q %v %v", data, req.Header, err)
+	}
+	if _, data, err := read("br", []byte("raw")); err != nil || string(data) != "raw" {
+		t.Fatalf("want the body of the unknown coding as is but got %q %v", data, err)
+	}
+	if _, _, err := read("gzip", []byte("invalid")); statusCode(err) != http.StatusBadRequest {
+		t.Fatalf("want 400 of the invalid body but got %v", err)
+	}
+	if _, _, err := read("gzip", gzipped(strings.Repeat("a", 101))); statusCode(err) != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413 of the large body but got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package compression
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Encoding is the content coding of the bodies.
+type Encoding interface {
+	// NewWriter returns the writer compressing to w by the level, the level
+	// is 0 for the default one of the encoding.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+	// NewReader returns the reader decompressing r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// flusher is implemented by the writers flushing the pending data.
+type flusher interface {
+	Flush() error
+}
+
+var (
+	_encodingsMu sync.RWMutex
+	_encodings   = map[string]Encoding{
+		"gzip":    gzipEncoding{},
+		"deflate": deflateEncoding{},
+	}
+)
+
+// RegisterEncoding registers the encoding of the content coding name, it
+// replaces the previous one of the same name. Only gzip and deflate are built
+// in, the others are registered by the importing packages, e.g. br or zstd by
+// the brotli or zstd libraries out of the gateway, before the config is loaded.
+func RegisterEncoding(name string, e Encoding) {
+	_encodingsMu.Lock()
+	defer _encodingsMu.Unlock()
+	_encodings[name] = e
+}
+
+func encodingOf(name string) (Encoding, bool) {
+	_encodingsMu.RLock()
+	defer _encodingsMu.RUnlock()
+	e, ok := _encodings[name]
+	return e, ok
+}
+
+type gzipEncoding struct{}
+
+func (gzipEncoding) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+func (gzipEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateEncoding is the zlib format of the deflate content coding, the raw
+// deflate streams sent by some clients are not supported.
+type deflateEncoding struct{}
+
+func (deflateEncoding) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = zlib.DefaultCompression
+	}
+	return zlib.NewWriterLevel(w, level)
+}
+
+func (deflateEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+// validLevel reports whether the level is valid for the encoding.
+func validLevel(e Encoding, level int) error {
+	w, err := e.NewWriter(ioutil.Discard, level)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,166 @@
+package concurrency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/concurrency/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultQueueTimeout = time.Second
+	_defaultRetryAfter   = time.Second
+)
+
+func init() {
+	middleware.Register("concurrency", Middleware)
+}
+
+// limiter limits the in-flight requests by the semaphore, the requests over
+// the limit wait in the queue.
+type limiter struct {
+	sem      chan struct{}
+	maxQueue int32
+	queued   int32
+}
+
+func newLimiter(maxRequests, maxQueue int) *limiter {
+	return &limiter{sem: make(chan struct{}, maxRequests), maxQueue: int32(maxQueue)}
+}
+
+// acquire reports whether the request is allowed in the timeout.
+func (l *limiter) acquire(ctx context.Context, timeout time.Duration) bool {
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+	}
+	if atomic.AddInt32(&l.queued, 1) > l.maxQueue {
+		atomic.AddInt32(&l.queued, -1)
+		return false
+	}
+	defer atomic.AddInt32(&l.queued, -1)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *limiter) release() {
+	<-l.sem
+}
+
+// releaseBody releases the limiter once the body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// upstream returns the backends or the clusters of the endpoint.
+func upstream(e *config.Endpoint) string {
+	var names []string
+	for _, b := range e.Backends {
+		names = append(names, b.Target)
+	}
+	for _, c := range e.Clusters {
+		names = append(names, "cluster:"+c.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func newResponse(retryAfter time.Duration) (*http.Response, error) {
+	seconds := int(retryAfter / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{strconv.Itoa(seconds)}},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware limits the in-flight requests by the route or the upstream.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Concurrency{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.MaxRequests == 0 {
+		return nil, errors.New("concurrency max requests should be greater than 0")
+	}
+	queueTimeout := _defaultQueueTimeout
+	if options.QueueTimeout != nil {
+		queueTimeout = options.QueueTimeout.AsDuration()
+	}
+	retryAfter := _defaultRetryAfter
+	if options.RetryAfter != nil {
+		retryAfter = options.RetryAfter.AsDuration()
+	}
+	var (
+		mu       sync.Mutex
+		limiters = map[string]*limiter{}
+	)
+	get := func(req *http.Request) *limiter {
+		var key string
+		if e, ok := middleware.EndpointFromContext(req.Context()); ok {
+			key = e.Host + " " + e.Method + " " + e.Path
+			if options.Key == v1.Concurrency_UPSTREAM {
+				key = upstream(e)
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		l, ok := limiters[key]
+		if !ok {
+			l = newLimiter(int(options.MaxRequests), int(options.MaxQueue))
+			limiters[key] = l
+		}
+		return l
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			l := get(req)
+			if !l.acquire(req.Context(), queueTimeout) {
+				return newResponse(retryAfter)
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.Body == nil {
+				l.release()
+				return resp, err
+			}
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: l.release}
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,86 @@
+package concurrency
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/concurrency/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(1, 1)
+	ctx := context.Background()
+	if !l.acquire(ctx, time.Second) {
+		t.Fatal("want acquired")
+	}
+	done := make(chan bool)
+	go func() { done <- l.acquire(ctx, time.Second) }()
+	for i := 0; atomic.LoadInt32(&l.queued) == 0 && i < 100; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if l.acquire(ctx, time.Second) {
+		t.Fatal("want rejected if the queue is full")
+	}
+	l.release()
+	if !<-done {
+		t.Fatal("want the queued acquired")
+	}
+	if l.acquire(ctx, 10*time.Millisecond) {
+		t.Fatal("want rejected in the queue timeout")
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	any, err := anypb.New(&v1.Concurrency{
+		Key:         v1.Concurrency_UPSTREAM,
+		MaxRequests: 1,
+		RetryAfter:  durationpb.New(2 * time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "concurrency", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func(path, target string) *http.Response {
+		req := httptest.NewRequest("GET", path, nil)
+		e := &config.Endpoint{Path: path, Backends: []*config.Backend{{Target: target}}}
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(e)))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := do("/orders", "127.0.0.1:8000")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %d", resp.StatusCode)
+	}
+	// the endpoints of the same upstream share the limit until the body is closed.
+	if resp := do("/users", "127.0.0.1:8000"); resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "2" {
+		t.Fatalf("want 503 but got %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp := do("/users", "127.0.0.1:9000"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want the other upstream allowed but got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if resp := do("/users", "127.0.0.1:8000"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 after the body closed but got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"sync/atomic"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+// Consumers is the index of the consumers in config.
+type Consumers struct {
+	byName    map[string]*config.Consumer
+	byAPIKey  map[string]*config.Consumer
+	bySubject map[string]*config.Consumer
+}
+
+// NewConsumers indexes the consumers by name, API key and JWT subject.
+func NewConsumers(consumers []*config.Consumer) *Consumers {
+	c := &Consumers{
+		byName:    make(map[string]*config.Consumer, len(consumers)),
+		byAPIKey:  make(map[string]*config.Consumer),
+		bySubject: make(map[string]*config.Consumer),
+	}
+	for _, consumer := range consumers {
+		c.byName[consumer.Name] = consumer
+		for _, key := range consumer.ApiKeys {
+			c.byAPIKey[key] = consumer
+		}
+		for _, sub := range consumer.JwtSubjects {
+			c.bySubject[sub] = consumer
+		}
+	}
+	return c
+}
+
+// ByName returns the consumer of the name.
+func (c *Consumers) ByName(name string) (*config.Consumer, bool) {
+	consumer, ok := c.byName[name]
+	return consumer, ok
+}
+
+// ByAPIKey returns the consumer of the API key.
+func (c *Consumers) ByAPIKey(key string) (*config.Consumer, bool) {
+	consumer, ok := c.byAPIKey[key]
+	return consumer, ok
+}
+
+// BySubject returns the consumer of the JWT subject.
+func (c *Consumers) BySubject(sub string) (*config.Consumer, bool) {
+	consumer, ok := c.bySubject[sub]
+	return consumer, ok
+}
+
+var globalConsumers atomic.Value
+
+func init() {
+	globalConsumers.Store(NewConsumers(nil))
+}
+
+// SetConsumers replaces the consumers referenced by the middlewares, it's
+// called by the proxy on the config update.
+func SetConsumers(consumers []*config.Consumer) {
+	globalConsumers.Store(NewConsumers(consumers))
+}
+
+// GetConsumers returns the consumers of the current config.
+func GetConsumers() *Consumers {
+	return globalConsumers.Load().(*Consumers)
+}
+
+// ConsumerAllowed reports whether the consumer is allowed to access the endpoint.
+func ConsumerAllowed(consumer *config.Consumer, e *config.Endpoint) bool {
+	if len(consumer.AllowedRoutes) == 0 {
+		return true
+	}
+	for _, path := range consumer.AllowedRoutes {
+		if path == e.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// WithConsumer tags the request with the consumer identified by the auth middlewares.
+func WithConsumer(ctx context.Context, consumer *config.Consumer) context.Context {
+	o, ok := ctx.Value(contextKey{}).(*RequestOptions)
+	if ok {
+		o.Consumer = consumer
+	}
+	return ctx
+}
+
+// ConsumerFromContext returns the consumer of the request from context.
+func ConsumerFromContext(ctx context.Context) (*config.Consumer, bool) {
+	o, ok := ctx.Value(contextKey{}).(*RequestOptions)
+	if ok && o.Consumer != nil {
+		return o.Consumer, true
+	}
+	return nil, false
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+func TestConsumers(t *testing.T) {
+	SetConsumers([]*config.Consumer{
+		{Name: "alice", ApiKeys: []string{"key-1", "key-2"}, AllowedRoutes: []string{"/foo"}},
+		{Name: "bob", JwtSubjects: []string{"bob@example.com"}},
+	})
+	defer SetConsumers(nil)
+	consumers := GetConsumers()
+	alice, ok := consumers.ByAPIKey("key-2")
+	if !ok || alice.Name != "alice" {
+		t.Fatalf("want alice but got: %v", alice)
+	}
+	if _, ok := consumers.ByAPIKey("key-3"); ok {
+		t.Fatal("want unknown api key")
+	}
+	bob, ok := consumers.BySubject("bob@example.com")
+	if !ok || bob.Name != "bob" {
+		t.Fatalf("want bob but got: %v", bob)
+	}
+	if _, ok := consumers.ByName("bob"); !ok {
+		t.Fatal("want bob by name")
+	}
+	if !ConsumerAllowed(alice, &config.Endpoint{Path: "/foo"}) || ConsumerAllowed(alice, &config.Endpoint{Path: "/bar"}) {
+		t.Fatal("want alice to be allowed only to /foo")
+	}
+	if !ConsumerAllowed(bob, &config.Endpoint{Path: "/bar"}) {
+		t.Fatal("want bob to be allowed to all endpoints")
+	}
+
+	ctx := NewRequestContext(context.Background(), NewRequestOptions(&config.Endpoint{Path: "/foo"}))
+	if _, ok := ConsumerFromContext(ctx); ok {
+		t.Fatal("want no consumer")
+	}
+	ctx = WithConsumer(ctx, alice)
+	if c, ok := ConsumerFromContext(ctx); !ok || c != alice {
+		t.Fatalf("want alice but got: %v", c)
+	}
+}
@@ -0,0 +1,275 @@
+package csrf
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/csrf/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultCookieName = "csrf_token"
+	_defaultHeaderName = "X-CSRF-Token"
+	_minSecretLength   = 16
+	_maxFormBytes      = 1 << 20
+)
+
+var _defaultSafeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace}
+
+func init() {
+	middleware.Register("csrf", Middleware)
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+type csrf struct {
+	options     *v1.CSRF
+	secret      []byte
+	cookieName  string
+	headerName  string
+	sameSite    http.SameSite
+	safeMethods map[string]bool
+}
+
+// newToken returns the random token, the signature of the secret is appended
+// if the secret is set.
+func (c *csrf) newToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if len(c.secret) > 0 {
+		token += "." + c.sign(token)
+	}
+	return token
+}
+
+func (c *csrf) sign(value string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	_, _ = mac.Write([]byte(c.cookieName + "=" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// valid reports whether the token is issued by the gateway.
+func (c *csrf) valid(token string) bool {
+	if token == "" {
+		return false
+	}
+	if len(c.secret) == 0 {
+		return true
+	}
+	i := strings.LastIndexByte(token, '.')
+	return i > 0 && hmac.Equal([]byte(c.sign(token[:i])), []byte(token[i+1:]))
+}
+
+func (c *csrf) cookie(req *http.Request, token string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:   c.cookieName,
+		Value:  token,
+		Path:   c.options.CookiePath,
+		Domain: c.options.CookieDomain,
+		Secure: req.TLS != nil || strings.EqualFold(req.Header.Get("X-Forwarded-Proto"), "https") || c.sameSite == http.SameSiteNoneMode,
+		// the cookie is read by the scripts to send the token in the header.
+		HttpOnly: false,
+		SameSite: c.sameSite,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if c.options.MaxAge != nil {
+		cookie.MaxAge = int(c.options.MaxAge.AsDuration().Seconds())
+	}
+	return cookie
+}
+
+// originOf returns the origin of the Origin header, or the one of the Referer
+// if it's empty.
+func originOf(req *http.Request) string {
+	if origin := req.Header.Get("Origin"); origin != "" {
+		return origin
+	}
+	referer := req.Header.Get("Referer")
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Host == "" {
+		return "null"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// trusted reports whether the origin is the one of the host or trusted.
+func (c *csrf) trusted(req *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, req.Host) {
+		return true
+	}
+	hostname := strings.ToLower(u.Hostname())
+	for _, trusted := range c.options.TrustedOrigins {
+		trusted = strings.ToLower(trusted)
+		if strings.HasPrefix(trusted, "*") {
+			if strings.HasSuffix(hostname, strings.TrimPrefix(trusted, "*")) {
+				return true
+			}
+			continue
+		}
+		if trusted == strings.ToLower(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// submitted returns the token of the header, or the one of the form field of
+// the urlencoded body.
+func (c *csrf) submitted(req *http.Request) string {
+	if token := req.Header.Get(c.headerName); token != "" {
+		return token
+	}
+	if c.options.FormField == "" || req.GetBody == nil {
+		return ""
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(body, _maxFormBytes))
+	if err != nil {
+		return ""
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return ""
+	}
+	return form.Get(c.options.FormField)
+}
+
+func exempted(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *csrf) deny(req *http.Request, reason string) (*http.Response, error) {
+	audit.PolicyDenied(req, "csrf", "", http.StatusForbidden, reason)
+	return newResponse(http.StatusForbidden)
+}
+
+// Middleware protects the routes of the browsers from the CSRF.
+func Middleware(cfg *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.CSRF{}
+	if cfg.Options != nil {
+		if err := anypb.UnmarshalTo(cfg.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Secret != "" && len(options.Secret) < _minSecretLength {
+		return nil, fmt.Errorf("csrf secret should be at least %d bytes", _minSecretLength)
+	}
+	c := &csrf{
+		options:     options,
+		secret:      []byte(options.Secret),
+		cookieName:  options.CookieName,
+		headerName:  options.HeaderName,
+		safeMethods: map[string]bool{},
+	}
+	if c.cookieName == "" {
+		c.cookieName = _defaultCookieName
+	}
+	if c.headerName == "" {
+		c.headerName = _defaultHeaderName
+	}
+	switch strings.ToLower(options.SameSite) {
+	case "", "lax":
+		c.sameSite = http.SameSiteLaxMode
+	case "strict":
+		c.sameSite = http.SameSiteStrictMode
+	case "none":
+		c.sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid csrf same site: %s", options.SameSite)
+	}
+	safeMethods := options.SafeMethods
+	if len(safeMethods) == 0 {
+		safeMethods = _defaultSafeMethods
+	}
+	for _, method := range safeMethods {
+		c.safeMethods[strings.ToUpper(method)] = true
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if exempted(options.ExemptPaths, req.URL.Path) {
+				return next.RoundTrip(req)
+			}
+			safe := c.safeMethods[req.Method]
+			if !safe {
+				// the requests without the origins are not sent by the
+				// browsers, they're checked by the tokens only.
+				if origin := originOf(req); origin != "" && !c.trusted(req, origin) {
+					return c.deny(req, "origin is not trusted: "+origin)
+				}
+			}
+			if options.OriginOnly {
+				return next.RoundTrip(req)
+			}
+			var token string
+			if cookie, err := req.Cookie(c.cookieName); err == nil && c.valid(cookie.Value) {
+				token = cookie.Value
+			}
+			if !safe {
+				submitted := c.submitted(req)
+				if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(submitted)) != 1 {
+					return c.deny(req, "csrf token is missing or mismatched")
+				}
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || !safe {
+				return resp, err
+			}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			if token == "" {
+				token = c.newToken()
+				resp.Header.Add("Set-Cookie", c.cookie(req, token).String())
+			}
+			resp.Header.Set(c.headerName, token)
+			resp.Header.Add("Vary", "Cookie")
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,119 @@
+package csrf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/csrf/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newTripper(t *testing.T, options *v1.CSRF) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "csrf", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+}
+
+func newRequest(method, path, body string, header map[string]string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	data := []byte(body)
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	tripper := newTripper(t, &v1.CSRF{Secret: "0123456789abcdef", FormField: "_csrf", ExemptPaths: []string{"/webhooks/*"}})
+	resp, err := tripper.RoundTrip(newRequest("GET", "/", "", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != _defaultCookieName || cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("want the token cookie but got %v", cookies)
+	}
+	token := cookies[0].Value
+	if got := resp.Header.Get(_defaultHeaderName); got != token {
+		t.Fatalf("want the token in the header but got %s", got)
+	}
+	// the token is not issued again once it's sent by the cookie.
+	resp, _ = tripper.RoundTrip(newRequest("GET", "/", "", map[string]string{"Cookie": "csrf_token=" + token}))
+	if len(resp.Cookies()) != 0 || resp.Header.Get(_defaultHeaderName) != token {
+		t.Fatalf("want the token kept but got %v", resp.Header)
+	}
+
+	forged := "forged." + strings.SplitN(token, ".", 2)[1]
+	tests := []struct {
+		method, path, body string
+		header             map[string]string
+		code               int
+	}{
+		{"POST", "/", "", map[string]string{"Cookie": "csrf_token=" + token, _defaultHeaderName: token}, 200},
+		{"POST", "/", "", map[string]string{"Cookie": "csrf_token=" + token, _defaultHeaderName: token, "Origin": "http://example.com"}, 200},
+		{"POST", "/", "_csrf=" + token, map[string]string{"Cookie": "csrf_token=" + token, "Content-Type": "application/x-www-form-urlencoded"}, 200},
+		{"POST", "/", "", map[string]string{"Cookie": "csrf_token=" + token}, 403},
+		{"POST", "/", "", map[string]string{_defaultHeaderName: token}, 403},
+		{"POST", "/", "", map[string]string{"Cookie": "csrf_token=" + forged, _defaultHeaderName: forged}, 403},
+		{"DELETE", "/", "", map[string]string{"Cookie": "csrf_token=" + token, _defaultHeaderName: token, "Origin": "https://evil.com"}, 403},
+		{"PUT", "/", "", map[string]string{"Cookie": "csrf_token=" + token, _defaultHeaderName: token, "Referer": "https://evil.com/page"}, 403},
+		{"POST", "/webhooks/github", "", nil, 200},
+	}
+	for _, test := range tests {
+		resp, err := tripper.RoundTrip(newRequest(test.method, test.path, test.body, test.header))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != test.code {
+			t.Errorf("want %d of %s %s %v but got %d", test.code, test.method, test.path, test.header, resp.StatusCode)
+		}
+	}
+}
+
+func TestOriginOnly(t *testing.T) {
+	tripper := newTripper(t, &v1.CSRF{OriginOnly: true, TrustedOrigins: []string{"https://app.example.org", "*.example.net"}})
+	for origin, code := range map[string]int{
+		"":                        200,
+		"http://example.com":      200,
+		"https://app.example.org": 200,
+		"https://a.example.net":   200,
+		"https://example.org":     403,
+		"null":                    403,
+	} {
+		resp, err := tripper.RoundTrip(newRequest("POST", "/", "", map[string]string{"Origin": origin}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != code {
+			t.Errorf("want %d of the origin %q but got %d", code, origin, resp.StatusCode)
+		}
+	}
+	if resp, _ := tripper.RoundTrip(newRequest("GET", "/", "", nil)); len(resp.Cookies()) != 0 {
+		t.Fatalf("want no token issued but got %v", resp.Cookies())
+	}
+
+	for _, options := range []*v1.CSRF{{Secret: "short"}, {SameSite: "loose"}} {
+		any, _ := anypb.New(options)
+		if _, err := Middleware(&config.Middleware{Name: "csrf", Options: any}); err == nil {
+			t.Fatalf("want the error of %v", options)
+		}
+	}
+}
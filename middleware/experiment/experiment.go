@@ -0,0 +1,177 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/experiment/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultHeader = "X-Experiment-Variant"
+
+var (
+	_metricRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "experiment_requests_code_total",
+		Help:      "The total number of processed requests of the experiment variants",
+	}, []string{"protocol", "method", "path", "service", "basePath", "experiment", "variant", "code"})
+	_metricRequestsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "experiment_requests_duration_seconds",
+		Help:      "Requests duration(sec) of the experiment variants.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.250, 0.5, 1},
+	}, []string{"protocol", "method", "path", "service", "basePath", "experiment", "variant"})
+)
+
+func init() {
+	middleware.Register("experiment", Middleware)
+	prometheus.MustRegister(_metricRequestsTotal)
+	prometheus.MustRegister(_metricRequestsDuration)
+}
+
+type experiment struct {
+	name     string
+	header   string
+	variants []*v1.Variant
+	total    int64
+	sticky   *v1.StickyKey
+}
+
+func (e *experiment) stickyKey(req *http.Request) (string, bool) {
+	switch key := e.sticky.GetKey().(type) {
+	case *v1.StickyKey_Header:
+		v := req.Header.Get(key.Header)
+		return v, v != ""
+	case *v1.StickyKey_Cookie:
+		cookie, err := req.Cookie(key.Cookie)
+		if err != nil || cookie.Value == "" {
+			return "", false
+		}
+		return cookie.Value, true
+	case *v1.StickyKey_ClientIp:
+		if ip := clientip.FromRequest(req); key.ClientIp && ip != nil {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// assign returns the variant of the request, the users of the same sticky key
+// are in the same variant as long as the allocation is not changed.
+func (e *experiment) assign(req *http.Request) *v1.Variant {
+	var n int64
+	if key, ok := e.stickyKey(req); ok {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(e.name))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(key))
+		n = int64(h.Sum64() % uint64(e.total))
+	} else {
+		n = rand.Int63n(e.total)
+	}
+	for _, v := range e.variants {
+		if n < int64(v.Weight) {
+			return v
+		}
+		n -= int64(v.Weight)
+	}
+	return e.variants[len(e.variants)-1]
+}
+
+func (e *experiment) variant(name string) (*v1.Variant, bool) {
+	for _, v := range e.variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func newExperiment(options *v1.Experiment) (*experiment, error) {
+	if options.Name == "" {
+		return nil, errors.New("experiment name is required")
+	}
+	e := &experiment{
+		name:     options.Name,
+		header:   options.Header,
+		variants: options.Variants,
+		sticky:   options.StickyKey,
+	}
+	if e.header == "" {
+		e.header = _defaultHeader
+	}
+	names := make(map[string]struct{}, len(options.Variants))
+	for _, v := range options.Variants {
+		if v.Name == "" {
+			return nil, errors.New("experiment variant name is required")
+		}
+		if _, ok := names[v.Name]; ok {
+			return nil, fmt.Errorf("duplicate experiment variant: %s", v.Name)
+		}
+		names[v.Name] = struct{}{}
+		e.total += int64(v.Weight)
+	}
+	if e.total <= 0 {
+		return nil, errors.New("the total weight of experiment variants should be greater than 0")
+	}
+	return e, nil
+}
+
+// Middleware assigns the requests to the variants of the experiment, the
+// variant is set to the request and response header and the metrics, and
+// the request is routed to the cluster of the variant if it's set.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Experiment{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	e, err := newExperiment(options)
+	if err != nil {
+		return nil, err
+	}
+	metadataKey := "experiment." + e.name
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpt, ok := middleware.FromRequestContext(req.Context())
+			if !ok {
+				return next.RoundTrip(req)
+			}
+			// the retries are kept in the assigned variant.
+			variant, ok := e.variant(reqOpt.Metadata[metadataKey])
+			if !ok {
+				variant = e.assign(req)
+				reqOpt.Metadata[metadataKey] = variant.Name
+			}
+			if variant.Cluster != "" {
+				reqOpt.Cluster = variant.Cluster
+			}
+			req.Header.Set(e.header, variant.Name)
+			labels := middleware.NewMetricsLabels(reqOpt.Endpoint)
+			startTime := time.Now()
+			resp, err := next.RoundTrip(req)
+			_metricRequestsDuration.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), e.name, variant.Name).Observe(time.Since(startTime).Seconds())
+			code := "error"
+			if err == nil {
+				code = strconv.Itoa(resp.StatusCode)
+				resp.Header.Set(e.header, variant.Name)
+			}
+			_metricRequestsTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), e.name, variant.Name, code).Inc()
+			return resp, err
+		})
+	}, nil
+}
@@ -0,0 +1,110 @@
+package experiment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/experiment/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newMiddleware(t *testing.T, options *v1.Experiment) middleware.Middleware {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "experiment", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestExperimentSticky(t *testing.T) {
+	m := newMiddleware(t, &v1.Experiment{
+		Name: "checkout",
+		Variants: []*v1.Variant{
+			{Name: "control", Weight: 50},
+			{Name: "treatment", Weight: 50, Cluster: "treatment"},
+		},
+		StickyKey: &v1.StickyKey{Key: &v1.StickyKey_Header{Header: "X-User-Id"}},
+	})
+	var variant, cluster string
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqOpt, _ := middleware.FromRequestContext(req.Context())
+		variant, cluster = req.Header.Get(_defaultHeader), reqOpt.Cluster
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		user := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		var first string
+		for j := 0; j < 3; j++ {
+			req := httptest.NewRequest("GET", "/checkout", nil)
+			req.Header.Set("X-User-Id", user)
+			req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(&config.Endpoint{})))
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Header.Get(_defaultHeader) != variant {
+				t.Fatalf("want the response variant %q but got %q", variant, resp.Header.Get(_defaultHeader))
+			}
+			if (variant == "treatment") != (cluster == "treatment") {
+				t.Fatalf("want the cluster of variant %q but got %q", variant, cluster)
+			}
+			if j == 0 {
+				first = variant
+			} else if variant != first {
+				t.Fatalf("want user %s to stick to %q but got %q", user, first, variant)
+			}
+		}
+		seen[variant] = true
+	}
+	if !seen["control"] || !seen["treatment"] {
+		t.Errorf("want both variants to be assigned but got %v", seen)
+	}
+}
+
+func TestExperimentRetry(t *testing.T) {
+	m := newMiddleware(t, &v1.Experiment{
+		Name:     "checkout",
+		Header:   "X-Variant",
+		Variants: []*v1.Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}},
+	})
+	var variants []string
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		variants = append(variants, req.Header.Get("X-Variant"))
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}, nil
+	}))
+	req := httptest.NewRequest("GET", "/checkout", nil)
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(&config.Endpoint{})))
+	for i := 0; i < 10; i++ {
+		if _, err := next.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, v := range variants {
+		if v != variants[0] {
+			t.Fatalf("want the retries in the same variant but got %v", variants)
+		}
+	}
+}
+
+func TestExperimentInvalid(t *testing.T) {
+	for _, options := range []*v1.Experiment{
+		{Variants: []*v1.Variant{{Name: "a", Weight: 1}}},
+		{Name: "checkout"},
+		{Name: "checkout", Variants: []*v1.Variant{{Name: "a"}}},
+		{Name: "checkout", Variants: []*v1.Variant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}},
+		{Name: "checkout", Variants: []*v1.Variant{{Weight: 1}}},
+	} {
+		any, _ := anypb.New(options)
+		if _, err := Middleware(&config.Middleware{Name: "experiment", Options: any}); err == nil {
+			t.Errorf("want error of the options: %v", options)
+		}
+	}
+}
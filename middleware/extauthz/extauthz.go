@@ -0,0 +1,158 @@
+package extauthz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/extauthz/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultTimeout = time.Second
+
+func init() {
+	middleware.Register("extauthz", Middleware)
+}
+
+// decision is the result of the authorization, the header mutations are
+// applied to the allowed request, and the response is sent to the client if
+// it's denied.
+type decision struct {
+	allowed  bool
+	set      http.Header
+	add      http.Header
+	remove   []string
+	response *http.Response
+}
+
+// checker checks the request by the authorization service.
+type checker interface {
+	check(ctx context.Context, req *http.Request, header http.Header, body []byte) (*decision, error)
+}
+
+func newResponse(statusCode int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// readBody reads the body up to the bytes without consuming it.
+func readBody(req *http.Request, max int64) ([]byte, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(io.LimitReader(body, max))
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if int64(len(data)) > max {
+		data = data[:max]
+	}
+	return data, nil
+}
+
+// Middleware checks the requests by the external authorization service.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.ExtAuthz{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	timeout := _defaultTimeout
+	if options.Timeout != nil {
+		timeout = options.Timeout.AsDuration()
+	}
+	var ck checker
+	switch s := options.Service.(type) {
+	case *v1.ExtAuthz_Http:
+		if s.Http.Url == "" {
+			return nil, errors.New("extauthz http url is required")
+		}
+		ck = newHTTPChecker(s.Http, timeout)
+	case *v1.ExtAuthz_Grpc:
+		if s.Grpc.Target == "" {
+			return nil, errors.New("extauthz grpc target is required")
+		}
+		conn, err := dial(s.Grpc.Target)
+		if err != nil {
+			return nil, err
+		}
+		ck = newGRPCChecker(conn)
+	default:
+		return nil, errors.New("extauthz http or grpc service is required")
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			header := req.Header
+			if len(options.AllowedHeaders) > 0 {
+				header = make(http.Header, len(options.AllowedHeaders))
+				for _, name := range options.AllowedHeaders {
+					if values := req.Header.Values(name); len(values) > 0 {
+						header[http.CanonicalHeaderKey(name)] = values
+					}
+				}
+			}
+			body, err := readBody(req, int64(options.MaxRequestBytes))
+			if err != nil {
+				return nil, err
+			}
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			d, err := ck.check(ctx, req, header, body)
+			cancel()
+			if err != nil {
+				log.Errorf("Failed to check the authorization of %s: %+v", req.URL.Path, err)
+				if options.FailureOpen {
+					return next.RoundTrip(req)
+				}
+				audit.PolicyDenied(req, "extauthz", "", http.StatusForbidden, "authorization check failed")
+				return newResponse(http.StatusForbidden, nil, nil), nil
+			}
+			if !d.allowed {
+				audit.PolicyDenied(req, "extauthz", "", d.response.StatusCode, "denied by the authorization service")
+				return d.response, nil
+			}
+			for _, name := range d.remove {
+				req.Header.Del(name)
+			}
+			for name, values := range d.set {
+				req.Header[name] = values
+				middleware.WithTrustedHeaders(req.Context(), name)
+			}
+			for name, values := range d.add {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+				middleware.WithTrustedHeaders(req.Context(), name)
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,190 @@
+package extauthz
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/extauthz/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newMiddleware(t *testing.T, options *v1.ExtAuthz) (http.RoundTripper, *http.Request) {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "extauthz", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &http.Request{}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		*upstream = *req
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	})), upstream
+}
+
+func do(t *testing.T, rt http.RoundTripper, user string) *http.Response {
+	req := httptest.NewRequest("POST", "/orders?page=1", bytes.NewBufferString("order"))
+	req.Header.Set("X-User", user)
+	req.Header.Set("X-Other", "other")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHTTPService(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != "/check/orders" || r.URL.RawQuery != "page=1" || string(body) != "ord" || r.Header.Get("X-Other") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("X-User") != "alice" {
+			w.Header().Set("WWW-Authenticate", "Basic")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("denied"))
+			return
+		}
+		w.Header().Set("X-User-Id", "1")
+		w.Header().Set("X-Internal", "secret")
+	}))
+	defer srv.Close()
+	rt, upstream := newMiddleware(t, &v1.ExtAuthz{
+		Service:         &v1.ExtAuthz_Http{Http: &v1.HTTPService{Url: srv.URL + "/check", UpstreamHeaders: []string{"X-User-Id"}}},
+		AllowedHeaders:  []string{"X-User"},
+		MaxRequestBytes: 3,
+	})
+
+	if resp := do(t, rt, "alice"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %d", resp.StatusCode)
+	}
+	if upstream.Header.Get("X-User-Id") != "1" || upstream.Header.Get("X-Internal") != "" {
+		t.Fatalf("unexpected upstream headers: %v", upstream.Header)
+	}
+	if body, _ := ioutil.ReadAll(upstream.Body); string(body) != "order" {
+		t.Fatalf("want the whole body sent to the upstream but got %q", body)
+	}
+	resp := do(t, rt, "bob")
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") != "Basic" || string(body) != "denied" {
+		t.Fatalf("want the denied response but got %d %v %q", resp.StatusCode, resp.Header, body)
+	}
+}
+
+func TestHTTPServiceRedirect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/login":
+			_, _ = w.Write([]byte("login"))
+		case r.Header.Get("X-User") != "alice":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+	rt, upstream := newMiddleware(t, &v1.ExtAuthz{
+		Service: &v1.ExtAuthz_Http{Http: &v1.HTTPService{Url: srv.URL + "/check", UpstreamHeaders: []string{"X-User-Roles"}}},
+	})
+
+	// the redirect to the login page is a denial.
+	resp := do(t, rt, "bob")
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/login" || upstream.Header != nil {
+		t.Fatalf("want the redirect sent to the client but got %d %v", resp.StatusCode, resp.Header)
+	}
+	// the upstream headers sent by the client are not forwarded.
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-User", "alice")
+	req.Header.Set("X-User-Roles", "admin")
+	if resp, err := rt.RoundTrip(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %v %v", resp, err)
+	}
+	if upstream.Header.Get("X-User-Roles") != "" {
+		t.Fatalf("want the spoofed upstream header removed but got %v", upstream.Header)
+	}
+}
+
+type authServer struct {
+	authv3.UnimplementedAuthorizationServer
+}
+
+func (authServer) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	attrs := req.Attributes.Request.Http
+	if attrs.Headers["x-user"] != "alice" || attrs.Path != "/orders?page=1" {
+		return &authv3.CheckResponse{
+			Status: &status.Status{Code: int32(codes.PermissionDenied)},
+			HttpResponse: &authv3.CheckResponse_DeniedResponse{DeniedResponse: &authv3.DeniedHttpResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_Unauthorized},
+				Headers: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "x-reason", Value: "unknown user"}}},
+				Body:    "denied",
+			}},
+		}, nil
+	}
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(codes.OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: &authv3.OkHttpResponse{
+			Headers: []*corev3.HeaderValueOption{
+				{Header: &corev3.HeaderValue{Key: "x-user-id", Value: "1"}},
+				{Header: &corev3.HeaderValue{Key: "x-user", Value: "admin"}, Append: wrapperspb.Bool(true)},
+			},
+			HeadersToRemove: []string{"x-other"},
+		}},
+	}, nil
+}
+
+func TestGRPCService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	authv3.RegisterAuthorizationServer(srv, authServer{})
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+	rt, upstream := newMiddleware(t, &v1.ExtAuthz{Service: &v1.ExtAuthz_Grpc{Grpc: &v1.GRPCService{Target: lis.Addr().String()}}})
+
+	if resp := do(t, rt, "alice"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %d", resp.StatusCode)
+	}
+	if upstream.Header.Get("X-User-Id") != "1" || len(upstream.Header.Values("X-User")) != 2 || upstream.Header.Get("X-Other") != "" {
+		t.Fatalf("unexpected upstream headers: %v", upstream.Header)
+	}
+	resp := do(t, rt, "bob")
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("X-Reason") != "unknown user" || string(body) != "denied" {
+		t.Fatalf("want the denied response but got %d %v %q", resp.StatusCode, resp.Header, body)
+	}
+}
+
+func TestFailurePolicy(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	for _, failureOpen := range []bool{false, true} {
+		rt, _ := newMiddleware(t, &v1.ExtAuthz{
+			Service:     &v1.ExtAuthz_Http{Http: &v1.HTTPService{Url: srv.URL}},
+			FailureOpen: failureOpen,
+		})
+		want := http.StatusForbidden
+		if failureOpen {
+			want = http.StatusOK
+		}
+		if resp := do(t, rt, "alice"); resp.StatusCode != want {
+			t.Fatalf("failure open %t: want %d but got %d", failureOpen, want, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package extauthz
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/go-kratos/gateway/clientip"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	// the connections are shared by the targets, they're kept across the
+	// rebuilds of the middlewares on the config updates.
+	_connsLock sync.Mutex
+	_conns     = map[string]*grpc.ClientConn{}
+)
+
+func dial(target string) (*grpc.ClientConn, error) {
+	_connsLock.Lock()
+	defer _connsLock.Unlock()
+	if conn, ok := _conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	_conns[target] = conn
+	return conn, nil
+}
+
+type grpcChecker struct {
+	client authv3.AuthorizationClient
+}
+
+func newGRPCChecker(conn grpc.ClientConnInterface) *grpcChecker {
+	return &grpcChecker{client: authv3.NewAuthorizationClient(conn)}
+}
+
+func (c *grpcChecker) check(ctx context.Context, req *http.Request, header http.Header, body []byte) (*decision, error) {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	attrs := &authv3.AttributeContext{
+		Request: &authv3.AttributeContext_Request{
+			Http: &authv3.AttributeContext_HttpRequest{
+				Method:   req.Method,
+				Path:     req.URL.RequestURI(),
+				Host:     req.Host,
+				Scheme:   scheme,
+				Query:    req.URL.RawQuery,
+				Headers:  headers,
+				Size:     req.ContentLength,
+				Protocol: req.Proto,
+				Body:     string(body),
+			},
+		},
+	}
+	if ip := clientip.FromRequest(req); ip != nil {
+		attrs.Source = &authv3.AttributeContext_Peer{Address: &corev3.Address{
+			Address: &corev3.Address_SocketAddress{SocketAddress: &corev3.SocketAddress{Address: ip.String()}},
+		}}
+	}
+	resp, err := c.client.Check(ctx, &authv3.CheckRequest{Attributes: attrs})
+	if err != nil {
+		return nil, err
+	}
+	if codes.Code(resp.GetStatus().GetCode()) == codes.OK {
+		d := &decision{allowed: true, set: http.Header{}, add: http.Header{}}
+		ok := resp.GetOkResponse()
+		for _, h := range ok.GetHeaders() {
+			name := http.CanonicalHeaderKey(h.GetHeader().GetKey())
+			if h.GetAppend().GetValue() {
+				d.add[name] = append(d.add[name], h.GetHeader().GetValue())
+			} else {
+				d.set[name] = []string{h.GetHeader().GetValue()}
+			}
+		}
+		d.remove = ok.GetHeadersToRemove()
+		return d, nil
+	}
+	denied := resp.GetDeniedResponse()
+	statusCode := http.StatusForbidden
+	if code := denied.GetStatus().GetCode(); code != 0 {
+		statusCode = int(code)
+	}
+	h := http.Header{}
+	for _, v := range denied.GetHeaders() {
+		if v.GetAppend().GetValue() {
+			h.Add(v.GetHeader().GetKey(), v.GetHeader().GetValue())
+		} else {
+			h.Set(v.GetHeader().GetKey(), v.GetHeader().GetValue())
+		}
+	}
+	return &decision{response: newResponse(statusCode, h, []byte(denied.GetBody()))}, nil
+}
@@ -0,0 +1,70 @@
+package extauthz
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/extauthz/v1"
+)
+
+// the headers of the denied response not sent to the client.
+var _skippedHeaders = []string{"Connection", "Content-Length", "Transfer-Encoding"}
+
+type httpChecker struct {
+	service *v1.HTTPService
+	client  *http.Client
+}
+
+func newHTTPChecker(service *v1.HTTPService, timeout time.Duration) *httpChecker {
+	return &httpChecker{service: service, client: &http.Client{
+		Timeout: timeout,
+		// the redirects of the authorization service are the denials, e.g. 302
+		// to the login page, they're sent to the client instead of followed.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (c *httpChecker) check(ctx context.Context, req *http.Request, header http.Header, body []byte) (*decision, error) {
+	target := strings.TrimSuffix(c.service.Url, "/") + req.URL.EscapedPath()
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	authReq, err := http.NewRequest(req.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	authReq.Header = header.Clone()
+	authReq.Header.Del("Content-Length")
+	authReq.Host = req.Host
+	resp, err := c.client.Do(authReq.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// the upstream headers sent by the client are removed, they're set by
+		// the authorization service only.
+		d := &decision{allowed: true, set: make(http.Header, len(c.service.UpstreamHeaders)), remove: c.service.UpstreamHeaders}
+		for _, name := range c.service.UpstreamHeaders {
+			if values := resp.Header.Values(name); len(values) > 0 {
+				d.set[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		return d, nil
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	h := resp.Header.Clone()
+	for _, name := range _skippedHeaders {
+		h.Del(name)
+	}
+	return &decision{response: newResponse(resp.StatusCode, h, data)}, nil
+}
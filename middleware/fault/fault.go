@@ -0,0 +1,180 @@
+package fault
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/fault/v1"
+	"github.com/go-kratos/gateway/flags"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultHTTPStatus = http.StatusServiceUnavailable
+
+var _metricFaultsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "go",
+	Subsystem: "gateway",
+	Name:      "requests_fault_injected_total",
+	Help:      "The total number of the requests injected with the faults",
+}, []string{"protocol", "method", "path", "service", "basePath", "fault"})
+
+func init() {
+	middleware.Register("fault", Middleware)
+	prometheus.MustRegister(_metricFaultsTotal)
+}
+
+// faults is the faults of the request, the config values are overridden by
+// the runtime flags.
+type faults struct {
+	delay        time.Duration
+	delayPercent float64
+	httpStatus   int
+	// the number of the gRPC code, it's empty if unset.
+	grpcStatus   string
+	abortPercent float64
+}
+
+// flagPrefix returns the runtime flag key prefix of the endpoint faults, e.g.
+// `fault./helloworld/*.`.
+func flagPrefix(e *config.Endpoint) string {
+	return "fault." + e.Path + "."
+}
+
+func (f faults) override(prefix string) faults {
+	if v, ok := flags.Get(prefix + "delay.duration"); ok {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			f.delay = d
+		}
+	}
+	f.delayPercent = flags.Float(prefix+"delay.percent", f.delayPercent)
+	if status := flags.Int(prefix+"abort.http_status", int64(f.httpStatus)); validHTTPStatus(status) {
+		f.httpStatus = int(status)
+	}
+	if v, ok := flags.Get(prefix + "abort.grpc_status"); ok {
+		if code, ok := parseGRPCCode(v); ok {
+			f.grpcStatus = code
+		}
+	}
+	f.abortPercent = flags.Float(prefix+"abort.percent", f.abortPercent)
+	return f
+}
+
+func validHTTPStatus(status int64) bool {
+	return status >= 200 && status <= 599
+}
+
+// parseGRPCCode returns the number of the gRPC code of the name or the number,
+// e.g. UNAVAILABLE or 14.
+func parseGRPCCode(name string) (string, bool) {
+	var code codes.Code
+	if code.UnmarshalJSON([]byte(strconv.Quote(name))) == nil {
+		return strconv.Itoa(int(code)), true
+	}
+	n, err := strconv.ParseUint(name, 10, 32)
+	if err != nil || n > uint64(codes.Unauthenticated) {
+		return "", false
+	}
+	return strconv.FormatUint(n, 10), true
+}
+
+// hit reports whether the request is in the percent.
+func hit(percent float64) bool {
+	return percent > 0 && rand.Float64()*100 < percent
+}
+
+func isGRPC(req *http.Request, e *config.Endpoint) bool {
+	return (e != nil && e.Protocol == config.Protocol_GRPC) || strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc")
+}
+
+func newResponse(statusCode int) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}
+}
+
+func faultsIncr(req *http.Request, fault string) {
+	if labels, ok := middleware.MetricsLabelsFromContext(req.Context()); ok {
+		_metricFaultsTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), fault).Inc()
+	}
+}
+
+// Middleware injects the delays and the aborts into the requests.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Fault{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	base := faults{httpStatus: _defaultHTTPStatus}
+	if d := options.Delay; d != nil {
+		if d.Duration != nil {
+			base.delay = d.Duration.AsDuration()
+		}
+		base.delayPercent = d.Percent
+	}
+	if a := options.Abort; a != nil {
+		if a.HttpStatus != 0 {
+			if !validHTTPStatus(int64(a.HttpStatus)) {
+				return nil, fmt.Errorf("invalid fault abort http status: %d", a.HttpStatus)
+			}
+			base.httpStatus = int(a.HttpStatus)
+		}
+		if a.GrpcStatus != "" {
+			code, ok := parseGRPCCode(a.GrpcStatus)
+			if !ok {
+				return nil, fmt.Errorf("invalid fault abort grpc status: %s", a.GrpcStatus)
+			}
+			base.grpcStatus = code
+		}
+		base.abortPercent = a.Percent
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			f := base
+			e, ok := middleware.EndpointFromContext(req.Context())
+			if ok {
+				prefix := flagPrefix(e)
+				if flags.Bool(prefix+"disabled", false) {
+					return next.RoundTrip(req)
+				}
+				f = f.override(prefix)
+			}
+			if f.delay > 0 && hit(f.delayPercent) {
+				faultsIncr(req, "delay")
+				timer := time.NewTimer(f.delay)
+				select {
+				case <-timer.C:
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				}
+			}
+			if hit(f.abortPercent) {
+				faultsIncr(req, "abort")
+				if f.grpcStatus != "" && isGRPC(req, e) {
+					resp := newResponse(http.StatusOK)
+					resp.Header.Set("Content-Type", "application/grpc")
+					resp.Header.Set("Grpc-Status", f.grpcStatus)
+					resp.Header.Set("Grpc-Message", "fault injected")
+					return resp, nil
+				}
+				return newResponse(f.httpStatus), nil
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
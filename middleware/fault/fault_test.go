@@ -0,0 +1,86 @@
+package fault
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/fault/v1"
+	"github.com/go-kratos/gateway/flags"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestFault(t *testing.T) {
+	any, err := anypb.New(&v1.Fault{
+		Delay: &v1.Fault_Delay{Duration: durationpb.New(50 * time.Millisecond), Percent: 100},
+		Abort: &v1.Fault_Abort{HttpStatus: http.StatusBadGateway, GrpcStatus: "UNAVAILABLE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "fault", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func(ctx context.Context, e *config.Endpoint) (*http.Response, time.Duration, error) {
+		req := httptest.NewRequest("POST", e.Path, nil)
+		req = req.WithContext(middleware.NewRequestContext(ctx, middleware.NewRequestOptions(e)))
+		startAt := time.Now()
+		resp, err := next.RoundTrip(req)
+		return resp, time.Since(startAt), err
+	}
+	e := &config.Endpoint{Path: "/orders"}
+
+	resp, elapsed, err := do(context.Background(), e)
+	if err != nil || resp.StatusCode != http.StatusOK || elapsed < 50*time.Millisecond {
+		t.Fatalf("want the delayed response but got %v %s", err, elapsed)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := do(ctx, e); err != context.DeadlineExceeded {
+		t.Fatalf("want the delay canceled but got %v", err)
+	}
+
+	// the faults are controlled by the runtime flags of the endpoint.
+	for k, v := range map[string]string{
+		"fault./orders.delay.duration": "1ms",
+		"fault./orders.abort.percent":  "100",
+	} {
+		flags.Default().Set(k, v)
+		defer flags.Default().Delete(k)
+	}
+	resp, elapsed, err = do(context.Background(), e)
+	if err != nil || resp.StatusCode != http.StatusBadGateway || elapsed >= 50*time.Millisecond {
+		t.Fatalf("want aborted by the flags but got %v %v %s", err, resp, elapsed)
+	}
+	resp, _, err = do(context.Background(), &config.Endpoint{Path: "/orders", Protocol: config.Protocol_GRPC})
+	if err != nil || resp.StatusCode != http.StatusOK || resp.Header.Get("Grpc-Status") != "14" {
+		t.Fatalf("want the gRPC status but got %v %v", err, resp)
+	}
+	flags.Default().Set("fault./orders.disabled", "true")
+	defer flags.Default().Delete("fault./orders.disabled")
+	resp, elapsed, err = do(context.Background(), e)
+	if err != nil || resp.StatusCode != http.StatusOK || elapsed >= 50*time.Millisecond {
+		t.Fatalf("want the faults disabled but got %v %v %s", err, resp, elapsed)
+	}
+
+	for _, abort := range []*v1.Fault_Abort{{HttpStatus: 99}, {GrpcStatus: "UNKNOWN_CODE"}} {
+		any, err := anypb.New(&v1.Fault{Abort: abort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Middleware(&config.Middleware{Name: "fault", Options: any}); err == nil {
+			t.Fatalf("want the invalid abort %v rejected", abort)
+		}
+	}
+}
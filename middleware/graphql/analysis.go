@@ -0,0 +1,287 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// the arguments of the sizes of the lists multiplying the complexity.
+var _listArguments = []string{"first", "last", "limit"}
+
+// operationOf returns the operation executed by the name.
+func (d *document) operationOf(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("the operation name is required")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %s", name)
+}
+
+type analyzer struct {
+	doc       *document
+	variables map[string]interface{}
+	defaults  map[string]string
+
+	visiting   map[string]bool
+	depths     map[string]int
+	complexity map[string]int64
+}
+
+func newAnalyzer(doc *document, op *operation, variables map[string]interface{}) *analyzer {
+	a := &analyzer{
+		doc:        doc,
+		variables:  variables,
+		defaults:   map[string]string{},
+		visiting:   map[string]bool{},
+		depths:     map[string]int{},
+		complexity: map[string]int64{},
+	}
+	for _, v := range op.variables {
+		if v.value != "" {
+			a.defaults[v.name] = v.value
+		}
+	}
+	return a
+}
+
+func (a *analyzer) fragment(name string) (*fragment, error) {
+	f, ok := a.doc.fragments[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fragment %s", name)
+	}
+	if a.visiting[name] {
+		return nil, fmt.Errorf("the fragment %s spreads itself", name)
+	}
+	return f, nil
+}
+
+// depth returns the max depth of the fields, the fragments are expanded.
+func (a *analyzer) depth(selections []*selection) (int, error) {
+	max := 0
+	for _, s := range selections {
+		var (
+			d   int
+			err error
+		)
+		switch s.kind {
+		case selectionField:
+			if d, err = a.depth(s.selections); err == nil {
+				d++
+			}
+		case selectionInline:
+			d, err = a.depth(s.selections)
+		case selectionSpread:
+			d, err = a.fragmentDepth(s.name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max, nil
+}
+
+func (a *analyzer) fragmentDepth(name string) (int, error) {
+	if d, ok := a.depths[name]; ok {
+		return d, nil
+	}
+	f, err := a.fragment(name)
+	if err != nil {
+		return 0, err
+	}
+	a.visiting[name] = true
+	d, err := a.depth(f.selections)
+	delete(a.visiting, name)
+	a.depths[name] = d
+	return d, err
+}
+
+// multiplier returns the size of the list argument of the field.
+func (a *analyzer) multiplier(s *selection) int64 {
+	for _, arg := range s.arguments {
+		for _, name := range _listArguments {
+			if arg.name != name {
+				continue
+			}
+			literal := arg.value
+			if arg.variable != "" {
+				literal = a.defaults[arg.variable]
+				if v, ok := a.variables[arg.variable]; ok {
+					literal = ""
+					if n, ok := v.(json.Number); ok {
+						literal = n.String()
+					}
+				}
+			}
+			if n, err := strconv.ParseInt(literal, 10, 64); err == nil && n > 1 {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+// saturated returns the value capped by the max int64.
+func saturated(v float64) int64 {
+	if v >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
+
+// cost returns the complexity of the selections.
+func (a *analyzer) cost(selections []*selection) (int64, error) {
+	var total int64
+	for _, s := range selections {
+		var (
+			c   int64
+			err error
+		)
+		switch s.kind {
+		case selectionField:
+			if c, err = a.cost(s.selections); err == nil {
+				c = saturated(1 + float64(a.multiplier(s))*float64(c))
+			}
+		case selectionInline:
+			c, err = a.cost(s.selections)
+		case selectionSpread:
+			c, err = a.fragmentCost(s.name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total = saturated(float64(total) + float64(c))
+	}
+	return total, nil
+}
+
+func (a *analyzer) fragmentCost(name string) (int64, error) {
+	if c, ok := a.complexity[name]; ok {
+		return c, nil
+	}
+	f, err := a.fragment(name)
+	if err != nil {
+		return 0, err
+	}
+	a.visiting[name] = true
+	c, err := a.cost(f.selections)
+	delete(a.visiting, name)
+	a.complexity[name] = c
+	return c, err
+}
+
+// rootFields returns the root fields of the selection, the fragments are
+// expanded.
+func (a *analyzer) rootFields(s *selection) ([]*selection, error) {
+	switch s.kind {
+	case selectionField:
+		return []*selection{s}, nil
+	case selectionSpread:
+		f, err := a.fragment(s.name)
+		if err != nil {
+			return nil, err
+		}
+		a.visiting[s.name] = true
+		defer delete(a.visiting, s.name)
+		return a.rootFieldsOf(f.selections)
+	}
+	return a.rootFieldsOf(s.selections)
+}
+
+func (a *analyzer) rootFieldsOf(selections []*selection) ([]*selection, error) {
+	var fields []*selection
+	for _, s := range selections {
+		f, err := a.rootFields(s)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f...)
+	}
+	return fields, nil
+}
+
+// usage is the variables and the fragments used by the selections.
+type usage struct {
+	variables map[string]bool
+	fragments []string
+}
+
+func (a *analyzer) use(u *usage, selections []*selection) {
+	for _, s := range selections {
+		for _, v := range s.variables {
+			u.variables[v] = true
+		}
+		if s.kind != selectionSpread {
+			a.use(u, s.selections)
+			continue
+		}
+		f, ok := a.doc.fragments[s.name]
+		if !ok || contains(u.fragments, s.name) {
+			continue
+		}
+		u.fragments = append(u.fragments, s.name)
+		for _, v := range f.variables {
+			u.variables[v] = true
+		}
+		a.use(u, f.selections)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// document returns the document of the operation of the selections only and
+// the variables defined, the variables and the fragments not used are removed.
+func (a *analyzer) document(op *operation, selections []*selection) (string, []string) {
+	u := &usage{variables: map[string]bool{}}
+	for _, v := range op.used {
+		u.variables[v] = true
+	}
+	a.use(u, selections)
+	var b strings.Builder
+	b.WriteString(op.typ)
+	if op.name != "" {
+		b.WriteString(" " + op.name)
+	}
+	var names, defs []string
+	for _, v := range op.variables {
+		if u.variables[v.name] {
+			names = append(names, v.name)
+			defs = append(defs, a.doc.src[v.start:v.end])
+		}
+	}
+	if len(defs) > 0 {
+		b.WriteString("(" + strings.Join(defs, ", ") + ")")
+	}
+	if op.directives != "" {
+		b.WriteString(" " + op.directives)
+	}
+	b.WriteString(" {")
+	for _, s := range selections {
+		b.WriteString(" " + a.doc.src[s.start:s.end])
+	}
+	b.WriteString(" }")
+	for _, name := range u.fragments {
+		f := a.doc.fragments[name]
+		b.WriteString("\n" + a.doc.src[f.start:f.end])
+	}
+	return b.String(), names
+}
@@ -0,0 +1,468 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/graphql/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultMaxBodyBytes = 1 << 20
+	// _maxFieldValues is the max distinct values of the field label of the
+	// fields not served by the stitched upstreams.
+	_maxFieldValues = 1000
+	_otherField     = "other"
+)
+
+var (
+	_metricFieldsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "requests_graphql_fields_total",
+		Help:      "The total number of the root fields of the GraphQL requests",
+	}, []string{"protocol", "method", "path", "service", "basePath", "operation", "field"})
+	_metricRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "requests_graphql_rejected_total",
+		Help:      "The total number of the GraphQL requests rejected",
+	}, []string{"protocol", "method", "path", "service", "basePath", "reason"})
+)
+
+func init() {
+	middleware.Register("graphql", Middleware)
+	prometheus.MustRegister(_metricFieldsTotal, _metricRejectedTotal)
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    json.RawMessage        `json:"extensions,omitempty"`
+}
+
+// persistedHash returns the hash of the persisted query in the extensions.
+func (r *request) persistedHash() string {
+	var ext struct {
+		PersistedQuery struct {
+			Sha256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	}
+	if len(r.Extensions) > 0 {
+		_ = json.Unmarshal(r.Extensions, &ext)
+	}
+	return ext.PersistedQuery.Sha256Hash
+}
+
+func unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// rejection is the GraphQL error responded with the status code.
+type rejection struct {
+	statusCode int
+	code       string
+	reason     string
+	message    string
+}
+
+func reject(statusCode int, code, reason, format string, args ...interface{}) *rejection {
+	return &rejection{statusCode: statusCode, code: code, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+func newResponse(statusCode int, data []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    statusCode,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+	}
+}
+
+func (r *rejection) response() *http.Response {
+	data, _ := json.Marshal(map[string]interface{}{
+		"errors": []interface{}{map[string]interface{}{
+			"message":    r.message,
+			"extensions": map[string]string{"code": r.code},
+		}},
+	})
+	return newResponse(r.statusCode, data)
+}
+
+// readRequest reads the GraphQL request of the GET or the POST method, the
+// body is restored for the upstream.
+func readRequest(req *http.Request, maxBytes int64) (*request, *rejection) {
+	r := &request{}
+	switch req.Method {
+	case http.MethodGet:
+		query := req.URL.Query()
+		r.Query = query.Get("query")
+		r.OperationName = query.Get("operationName")
+		if v := query.Get("variables"); v != "" {
+			if err := unmarshal([]byte(v), &r.Variables); err != nil {
+				return nil, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "invalid variables: %v", err)
+			}
+		}
+		if v := query.Get("extensions"); v != "" {
+			if !json.Valid([]byte(v)) {
+				return nil, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "invalid extensions")
+			}
+			r.Extensions = json.RawMessage(v)
+		}
+		return r, nil
+	case http.MethodPost:
+	default:
+		return nil, reject(http.StatusMethodNotAllowed, "BAD_REQUEST", "request", "method %s not allowed", req.Method)
+	}
+	var data []byte
+	if req.Body != nil {
+		var err error
+		if data, err = ioutil.ReadAll(io.LimitReader(req.Body, maxBytes+1)); err != nil {
+			return nil, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "failed to read the body: %v", err)
+		}
+		req.Body.Close()
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, reject(http.StatusRequestEntityTooLarge, "BAD_REQUEST", "request", "the body is larger than %d bytes", maxBytes)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/graphql":
+		r.Query = string(data)
+	case "application/json", "":
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			return nil, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "batched requests not supported")
+		}
+		if err := unmarshal(data, r); err != nil {
+			return nil, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "invalid body: %v", err)
+		}
+	default:
+		return nil, reject(http.StatusUnsupportedMediaType, "BAD_REQUEST", "request", "content type %s not supported", mediaType)
+	}
+	return r, nil
+}
+
+type gateway struct {
+	options   *v1.GraphQL
+	persisted *persisted
+	upstreams []*upstream
+}
+
+// resolve resolves the query of the persisted hash, the resolved reports
+// whether the query is not sent by the client.
+func (g *gateway) resolve(r *request) (resolved bool, rej *rejection) {
+	p := g.persisted
+	hash := r.persistedHash()
+	if p == nil {
+		if r.Query == "" {
+			return false, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "the query is required")
+		}
+		return false, nil
+	}
+	if r.Query == "" {
+		if hash == "" {
+			return false, reject(http.StatusBadRequest, "BAD_REQUEST", "request", "the query is required")
+		}
+		query, ok := p.get(hash)
+		if !ok {
+			return false, reject(http.StatusOK, "PERSISTED_QUERY_NOT_FOUND", "persisted", "PersistedQueryNotFound")
+		}
+		r.Query = query
+		return true, nil
+	}
+	if hash != "" && hash != hashQuery(r.Query) {
+		return false, reject(http.StatusBadRequest, "BAD_REQUEST", "persisted", "the hash of the persisted query mismatched")
+	}
+	if p.enforce {
+		if _, ok := p.get(hashQuery(r.Query)); !ok {
+			return false, reject(http.StatusForbidden, "PERSISTED_QUERY_NOT_ALLOWED", "persisted", "the query is not persisted")
+		}
+	}
+	return false, nil
+}
+
+// plan checks the operation by the limits and groups the root selections by
+// the upstreams serving them.
+func (g *gateway) plan(req *http.Request, r *request) (*analyzer, *operation, []*group, *rejection) {
+	doc, err := parse(r.Query)
+	if err != nil {
+		return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_PARSE_FAILED", "parse", "%v", err)
+	}
+	op, err := doc.operationOf(r.OperationName)
+	if err != nil {
+		return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "validation", "%v", err)
+	}
+	if req.Method == http.MethodGet && op.typ != "query" {
+		return nil, nil, nil, reject(http.StatusMethodNotAllowed, "BAD_REQUEST", "request", "%s not allowed by GET", op.typ)
+	}
+	a := newAnalyzer(doc, op, r.Variables)
+	depth, err := a.depth(op.selections)
+	if err != nil {
+		return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "validation", "%v", err)
+	}
+	if max := g.options.MaxDepth; max > 0 && depth > int(max) {
+		return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "depth", "the depth %d exceeds the limit %d", depth, max)
+	}
+	if max := g.options.MaxComplexity; max > 0 {
+		complexity, err := a.cost(op.selections)
+		if err != nil {
+			return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "validation", "%v", err)
+		}
+		if complexity > int64(max) {
+			return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "complexity", "the complexity %d exceeds the limit %d", complexity, max)
+		}
+	}
+	var groups []*group
+	for _, s := range op.selections {
+		fields, err := a.rootFields(s)
+		if err != nil {
+			return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "validation", "%v", err)
+		}
+		var owner *group
+		for _, f := range fields {
+			u := g.owner(op.typ, f.name)
+			if owner != nil && owner.upstream != u {
+				return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "validation", "the fields of the fragment are served by the different upstreams")
+			}
+			if owner == nil {
+				owner = groupOf(&groups, u)
+			}
+			if !contains(owner.keys, f.key()) {
+				owner.keys = append(owner.keys, f.key())
+			}
+		}
+		if owner != nil {
+			owner.selections = append(owner.selections, s)
+		}
+	}
+	if op.typ == "subscription" && (len(groups) > 1 || groups[0].upstream != nil) {
+		return nil, nil, nil, reject(http.StatusBadRequest, "GRAPHQL_VALIDATION_FAILED", "validation", "the subscriptions are served by the upstream of the endpoint only")
+	}
+	if labels, ok := middleware.MetricsLabelsFromContext(req.Context()); ok {
+		for _, f := range fieldsOf(a, op) {
+			// the fields are sent by the clients before they're validated by
+			// the upstreams, only the stitched ones are known.
+			if g.owner(op.typ, f) == nil {
+				f = _fieldGuard.value(f)
+			}
+			_metricFieldsTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), op.typ, f).Inc()
+		}
+	}
+	return a, op, groups, nil
+}
+
+// fieldGuard limits the distinct values of the field label, the values beyond
+// the max are counted as other.
+type fieldGuard struct {
+	mu        sync.Mutex
+	maxValues int
+	values    map[string]struct{}
+}
+
+var _fieldGuard = &fieldGuard{maxValues: _maxFieldValues, values: map[string]struct{}{}}
+
+func (g *fieldGuard) value(field string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.values[field]; ok {
+		return field
+	}
+	if len(g.values) >= g.maxValues {
+		return _otherField
+	}
+	g.values[field] = struct{}{}
+	return field
+}
+
+// fieldsOf returns the names of the root fields of the operation.
+func fieldsOf(a *analyzer, op *operation) []string {
+	fields, _ := a.rootFieldsOf(op.selections)
+	var names []string
+	for _, f := range fields {
+		if !contains(names, f.name) {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// owner returns the upstream of the root field, the upstream of the endpoint
+// serves the introspection and the fields not stitched.
+func (g *gateway) owner(typ, name string) *upstream {
+	if strings.HasPrefix(name, "__") {
+		return nil
+	}
+	for _, u := range g.upstreams {
+		if u.serves(typ, name) {
+			return u
+		}
+	}
+	return nil
+}
+
+func groupOf(groups *[]*group, u *upstream) *group {
+	for _, g := range *groups {
+		if g.upstream == u {
+			return g
+		}
+	}
+	g := &group{upstream: u}
+	*groups = append(*groups, g)
+	return g
+}
+
+// subRequest returns the request of the selections of the group.
+func subRequest(a *analyzer, op *operation, r *request, g *group) *request {
+	query, names := a.document(op, g.selections)
+	sub := &request{Query: query, OperationName: op.name, Extensions: r.Extensions}
+	for _, name := range names {
+		if v, ok := r.Variables[name]; ok {
+			if sub.Variables == nil {
+				sub.Variables = map[string]interface{}{}
+			}
+			sub.Variables[name] = v
+		}
+	}
+	return sub
+}
+
+// forward sends the GraphQL request to the upstream of the endpoint by POST.
+func forward(next http.RoundTripper, req *http.Request, r *request) (*http.Response, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	upstream := req.Clone(req.Context())
+	upstream.Method = http.MethodPost
+	upstream.URL.RawQuery = ""
+	upstream.Header.Set("Content-Type", "application/json")
+	upstream.Header.Del("Content-Length")
+	upstream.Header.Del("Content-Encoding")
+	upstream.ContentLength = int64(len(data))
+	upstream.Body = ioutil.NopCloser(bytes.NewReader(data))
+	upstream.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	return next.RoundTrip(upstream)
+}
+
+func (g *gateway) execute(next http.RoundTripper, req *http.Request, a *analyzer, op *operation, r *request, groups []*group) (*http.Response, error) {
+	header := req.Header.Clone()
+	for _, name := range _skippedHeaders {
+		header.Del(name)
+	}
+	results := make([]*result, len(groups))
+	run := func(i int) {
+		sub := subRequest(a, op, r, groups[i])
+		if u := groups[i].upstream; u != nil {
+			results[i] = u.do(req.Context(), header.Clone(), sub)
+			return
+		}
+		upstream := req.Clone(req.Context())
+		upstream.Header.Del("Accept-Encoding")
+		resp, err := forward(next, upstream, sub)
+		if err != nil {
+			results[i] = &result{err: err}
+			return
+		}
+		results[i] = decodeResult(resp)
+	}
+	if op.typ == "mutation" {
+		// the mutations are executed serially in the order.
+		for i := range groups {
+			run(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		for i := range groups {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+	data, err := merge(groups, results)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(http.StatusOK, data), nil
+}
+
+// Middleware serves the GraphQL requests checked by the limits, the root fields
+// of the stitched upstreams are sent to them.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.GraphQL{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	p, err := newPersisted(options.PersistedQueries)
+	if err != nil {
+		return nil, err
+	}
+	g := &gateway{options: options, persisted: p}
+	for _, u := range options.Upstreams {
+		upstream, err := newUpstream(u)
+		if err != nil {
+			return nil, err
+		}
+		g.upstreams = append(g.upstreams, upstream)
+	}
+	maxBytes := int64(_defaultMaxBodyBytes)
+	if options.MaxBodyBytes > 0 {
+		maxBytes = options.MaxBodyBytes
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// the WebSocket subscriptions are passed as is.
+			if req.Header.Get("Upgrade") != "" {
+				return next.RoundTrip(req)
+			}
+			r, rej := readRequest(req, maxBytes)
+			var resolved bool
+			if rej == nil {
+				resolved, rej = g.resolve(r)
+			}
+			var (
+				a      *analyzer
+				op     *operation
+				groups []*group
+			)
+			if rej == nil {
+				a, op, groups, rej = g.plan(req, r)
+			}
+			if rej != nil {
+				if labels, ok := middleware.MetricsLabelsFromContext(req.Context()); ok {
+					_metricRejectedTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), rej.reason).Inc()
+				}
+				return rej.response(), nil
+			}
+			if len(groups) == 1 && groups[0].upstream == nil {
+				if !resolved {
+					return next.RoundTrip(req)
+				}
+				return forward(next, req, r)
+			}
+			return g.execute(next, req, a, op, r, groups)
+		})
+	}, nil
+}
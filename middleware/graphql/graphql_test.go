@@ -0,0 +1,260 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/graphql/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _testQuery = `query Home($id: ID!, $first: Int = 10, $unused: String) {
+  me: user(id: $id) { ...userFields }
+  orders(first: $first) { id items { name } }
+  __typename
+}
+fragment userFields on User { id name }`
+
+func TestAnalyzer(t *testing.T) {
+	doc, err := parse(_testQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := doc.operationOf("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newAnalyzer(doc, op, map[string]interface{}{"first": json.Number("5")})
+	if depth, err := a.depth(op.selections); err != nil || depth != 3 {
+		t.Fatalf("want depth 3 but got %d %v", depth, err)
+	}
+	// me 1+2, orders 1+5*(1+1+1*1), __typename 1
+	if complexity, err := a.cost(op.selections); err != nil || complexity != 20 {
+		t.Fatalf("want complexity 20 but got %d %v", complexity, err)
+	}
+	query, names := a.document(op, op.selections[:1])
+	want := "query Home($id: ID!) { me: user(id: $id) { ...userFields } }\nfragment userFields on User { id name }"
+	if query != want || len(names) != 1 || names[0] != "id" {
+		t.Fatalf("want %q but got %q %v", want, query, names)
+	}
+	if _, err := parse(query); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{
+		"{ a { b }",
+		"query { }",
+		`{ a(s: "unterminated) }`,
+		"{ a(n: 1.) }",
+		"{ ...f } fragment f on T { ...f }",
+		"fragment f on T { a }",
+		strings.Repeat("{ a ", 300) + strings.Repeat("}", 300),
+	} {
+		doc, err := parse(query)
+		if err == nil {
+			op, _ := doc.operationOf("")
+			_, err = newAnalyzer(doc, op, nil).depth(op.selections)
+		}
+		if err == nil {
+			t.Errorf("want the invalid document rejected: %s", query)
+		}
+	}
+}
+
+type graphqlServer struct {
+	fields   []string
+	data     string
+	requests []*request
+}
+
+func (s *graphqlServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r := &request{}
+	if err := unmarshal(mustRead(req.Body), r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.Contains(r.Query, "__schema") {
+		var fields []string
+		for _, f := range s.fields {
+			fields = append(fields, `{"name":"`+f+`"}`)
+		}
+		w.Write([]byte(`{"data":{"__schema":{"queryType":{"fields":[` + strings.Join(fields, ",") + `]},"mutationType":null}}}`))
+		return
+	}
+	s.requests = append(s.requests, r)
+	w.Write([]byte(s.data))
+}
+
+func mustRead(body interface{ Read([]byte) (int, error) }) []byte {
+	data, _ := ioutil.ReadAll(body)
+	return data
+}
+
+func TestGraphQL(t *testing.T) {
+	users := &graphqlServer{fields: []string{"user"}, data: `{"data":{"me":{"id":"1","name":"alice"}}}`}
+	usersServer := httptest.NewServer(users)
+	defer usersServer.Close()
+	orders := &graphqlServer{data: `{"data":{"orders":[{"id":"o1","items":[]}]},"errors":[{"message":"partial"}]}`}
+	ordersServer := httptest.NewServer(orders)
+	defer ordersServer.Close()
+
+	persistedQuery := "{ __typename }"
+	any, err := anypb.New(&v1.GraphQL{
+		MaxDepth:         3,
+		MaxComplexity:    100,
+		PersistedQueries: &v1.PersistedQueries{Queries: []string{persistedQuery}},
+		Upstreams: []*v1.Upstream{
+			{Name: "users", Url: usersServer.URL},
+			{Name: "orders", Url: ordersServer.URL, Fields: []string{"query.orders"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "graphql", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream *request
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = &request{}
+		if err := unmarshal(mustRead(req.Body), upstream); err != nil {
+			t.Fatal(err)
+		}
+		return newResponse(http.StatusOK, []byte(`{"data":{"__typename":"Query"}}`)), nil
+	}))
+	do := func(method, target string, body interface{}) (int, string) {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, target, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(mustRead(resp.Body))
+	}
+
+	code, out := do("POST", "/graphql", map[string]interface{}{
+		"query":     _testQuery,
+		"variables": map[string]interface{}{"id": "1", "first": 5, "unused": "x"},
+	})
+	want := `{"data":{"me":{"id":"1","name":"alice"},"orders":[{"id":"o1","items":[]}],"__typename":"Query"},"errors":[{"message":"partial"}]}`
+	if code != http.StatusOK || out != want {
+		t.Fatalf("want the merged response but got %d %s", code, out)
+	}
+	if len(users.requests) != 1 || users.requests[0].Variables["id"] != "1" || len(users.requests[0].Variables) != 1 ||
+		!strings.Contains(users.requests[0].Query, "fragment userFields") || strings.Contains(users.requests[0].Query, "orders") {
+		t.Fatalf("want the user field sent to users but got %+v", users.requests)
+	}
+	if len(orders.requests) != 1 || !strings.HasPrefix(orders.requests[0].Query, "query Home($first: Int = 10) { orders") {
+		t.Fatalf("want the orders field sent to orders but got %+v", orders.requests)
+	}
+	if upstream == nil || upstream.Query != "query Home { __typename }" {
+		t.Fatalf("want the introspection sent to the endpoint but got %+v", upstream)
+	}
+
+	// the persisted query is resolved by the hash.
+	upstream = nil
+	code, _ = do("GET", "/graphql?extensions="+url.QueryEscape(`{"persistedQuery":{"version":1,"sha256Hash":"`+hashQuery(persistedQuery)+`"}}`), nil)
+	if code != http.StatusOK || upstream == nil || upstream.Query != persistedQuery {
+		t.Fatalf("want the persisted query forwarded but got %d %+v", code, upstream)
+	}
+	code, out = do("GET", "/graphql?extensions="+url.QueryEscape(`{"persistedQuery":{"version":1,"sha256Hash":"unknown"}}`), nil)
+	if code != http.StatusOK || !strings.Contains(out, "PERSISTED_QUERY_NOT_FOUND") {
+		t.Fatalf("want the persisted query not found but got %d %s", code, out)
+	}
+
+	for _, c := range []struct {
+		method string
+		body   interface{}
+		code   int
+		want   string
+	}{
+		{"POST", map[string]interface{}{"query": "{ a { b { c { d } } } }"}, http.StatusBadRequest, "the depth 4 exceeds the limit 3"},
+		{"POST", map[string]interface{}{"query": "{ a(first: 100) { b } }"}, http.StatusBadRequest, "the complexity 101 exceeds the limit 100"},
+		{"POST", map[string]interface{}{"query": "{ a"}, http.StatusBadRequest, "GRAPHQL_PARSE_FAILED"},
+		{"POST", []interface{}{map[string]interface{}{"query": "{ a }"}}, http.StatusBadRequest, "batched requests not supported"},
+		{"POST", map[string]interface{}{"query": "{ ...f } fragment f on Query { user orders }"}, http.StatusBadRequest, "different upstreams"},
+		{"GET", nil, http.StatusBadRequest, "the query is required"},
+		{"PUT", map[string]interface{}{"query": "{ a }"}, http.StatusMethodNotAllowed, "method PUT not allowed"},
+	} {
+		code, out := do(c.method, "/graphql", c.body)
+		if code != c.code || !strings.Contains(out, c.want) {
+			t.Errorf("want %d %s but got %d %s", c.code, c.want, code, out)
+		}
+	}
+	if code, _ := do("GET", "/graphql?query="+url.QueryEscape("mutation { a }"), nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("want the mutation by GET rejected but got %d", code)
+	}
+}
+
+func TestPersistedEnforce(t *testing.T) {
+	any, err := anypb.New(&v1.GraphQL{PersistedQueries: &v1.PersistedQueries{Queries: []string{"{ a }"}, Enforce: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "graphql", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return newResponse(http.StatusOK, []byte(`{"data":{}}`)), nil
+	}))
+	for query, code := range map[string]int{"{ a }": http.StatusOK, "{ b }": http.StatusForbidden} {
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(query))
+		req.Header.Set("Content-Type", "application/graphql")
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode != code {
+			t.Errorf("%s: want %d but got %v %v", query, code, resp, err)
+		}
+	}
+}
+
+func TestFieldMetrics(t *testing.T) {
+	orders := httptest.NewServer(&graphqlServer{data: `{"data":{"orders":[]}}`})
+	defer orders.Close()
+	guard := _fieldGuard
+	_fieldGuard = &fieldGuard{maxValues: 1, values: map[string]struct{}{}}
+	defer func() { _fieldGuard = guard }()
+
+	any, err := anypb.New(&v1.GraphQL{Upstreams: []*v1.Upstream{{Name: "orders", Url: orders.URL, Fields: []string{"query.orders"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "graphql", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return newResponse(http.StatusOK, []byte(`{"data":{}}`)), nil
+	}))
+	endpoint := &config.Endpoint{Path: "/fields", Method: "POST"}
+	for _, query := range []string{"{ a }", "{ b }", "{ c }", "{ orders }"} {
+		req := httptest.NewRequest("POST", "/fields", strings.NewReader(query))
+		req.Header.Set("Content-Type", "application/graphql")
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(endpoint)))
+		if _, err := next.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the fields beyond the max values are labeled as other but the stitched ones.
+	labels := middleware.NewMetricsLabels(endpoint)
+	for field, want := range map[string]float64{"a": 1, "b": 0, _otherField: 2, "orders": 1} {
+		got := testutil.ToFloat64(_metricFieldsTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), "query", field))
+		if got != want {
+			t.Errorf("want %v of the field %s but got %v", want, field, got)
+		}
+	}
+}
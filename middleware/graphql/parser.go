@@ -0,0 +1,643 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the max nesting of the selections, the values and the types parsed.
+const _maxNesting = 256
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind       tokenKind
+	value      string
+	start, end int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// next returns the next token, the white spaces, the commas and the comments
+// are ignored.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		default:
+			return l.scan()
+		}
+	}
+	return token{kind: tokenEOF, start: l.pos, end: l.pos}, nil
+}
+
+func (l *lexer) scan() (token, error) {
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunct, value: string(c), start: start, end: l.pos}, nil
+	case c == '.':
+		if !strings.HasPrefix(l.src[l.pos:], "...") {
+			return token{}, fmt.Errorf("unexpected character . at %d", start)
+		}
+		l.pos += 3
+		return token{kind: tokenPunct, value: "...", start: start, end: l.pos}, nil
+	case isNameStart(c):
+		for l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], start: start, end: l.pos}, nil
+	case c == '-' || isDigit(c):
+		return l.scanNumber()
+	case c == '"':
+		return l.scanString()
+	}
+	return token{}, fmt.Errorf("unexpected character %q at %d", c, start)
+}
+
+func (l *lexer) digits() int {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	return l.pos - start
+}
+
+func (l *lexer) scanNumber() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	if l.digits() == 0 {
+		return token{}, fmt.Errorf("invalid number at %d", start)
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		kind = tokenFloat
+		if l.digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at %d", start)
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		kind = tokenFloat
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if l.digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at %d", start)
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '.' || isNameStart(l.src[l.pos])) {
+		return token{}, fmt.Errorf("invalid number at %d", start)
+	}
+	return token{kind: kind, value: l.src[start:l.pos], start: start, end: l.pos}, nil
+}
+
+// scanString scans the string or the block string, the escapes are kept as is
+// since the values are not used but the source.
+func (l *lexer) scanString() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		l.pos += 3
+		for l.pos < len(l.src) {
+			switch {
+			case strings.HasPrefix(l.src[l.pos:], `\"""`):
+				l.pos += 4
+			case strings.HasPrefix(l.src[l.pos:], `"""`):
+				l.pos += 3
+				return token{kind: tokenString, value: l.src[start:l.pos], start: start, end: l.pos}, nil
+			default:
+				l.pos++
+			}
+		}
+		return token{}, fmt.Errorf("unterminated string at %d", start)
+	}
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+		case '"':
+			l.pos++
+			return token{kind: tokenString, value: l.src[start:l.pos], start: start, end: l.pos}, nil
+		case '\n', '\r':
+			return token{}, fmt.Errorf("unterminated string at %d", start)
+		default:
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at %d", start)
+}
+
+type selectionKind int
+
+const (
+	selectionField selectionKind = iota
+	selectionSpread
+	selectionInline
+)
+
+type argument struct {
+	name string
+	// the literal of the int values, and the name of the variables.
+	value    string
+	variable string
+}
+
+// selection is the field, the fragment spread or the inline fragment, the
+// source range is kept to build the documents of the selections.
+type selection struct {
+	kind       selectionKind
+	alias      string
+	name       string
+	arguments  []*argument
+	selections []*selection
+	// the variables used by the arguments and the directives.
+	variables  []string
+	start, end int
+}
+
+// key returns the key of the field in the response.
+func (s *selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type variableDefinition struct {
+	name string
+	// the literal of the int default value.
+	value      string
+	start, end int
+}
+
+type operation struct {
+	typ        string
+	name       string
+	variables  []*variableDefinition
+	directives string
+	// the variables used by the directives.
+	used       []string
+	selections []*selection
+}
+
+type fragment struct {
+	name       string
+	selections []*selection
+	variables  []string
+	start, end int
+}
+
+type document struct {
+	src        string
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type parser struct {
+	lex     *lexer
+	tok     token
+	last    int
+	nesting int
+	// the variables used by the current selection.
+	vars []string
+}
+
+func (p *parser) advance() error {
+	p.last = p.tok.end
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peek(value string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of the document")
+	}
+	return fmt.Errorf("unexpected %s at %d", p.tok.value, p.tok.start)
+}
+
+func (p *parser) expect(value string) error {
+	if !p.peek(value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) enter() error {
+	if p.nesting++; p.nesting > _maxNesting {
+		return fmt.Errorf("the document is nested too deeply")
+	}
+	return nil
+}
+
+// parse parses the executable document of the operations and the fragments.
+func parse(src string) (*document, error) {
+	p := &parser{lex: &lexer{src: src}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{src: src, fragments: map[string]*fragment{}}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			op := &operation{typ: "query"}
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.selections = selections
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("duplicate fragment %s", f.name)
+			}
+			doc.fragments[f.name] = f
+		case p.tok.kind == tokenName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("no operations in the document")
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{typ: p.tok.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			v, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, v)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	start := p.tok.start
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	if p.tok.start > start {
+		op.directives = p.lex.src[start:p.last]
+	}
+	op.used, p.vars = p.vars, nil
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) variableDefinition() (*variableDefinition, error) {
+	v := &variableDefinition{start: p.tok.start}
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	v.name = name
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if err := p.typ(); err != nil {
+		return nil, err
+	}
+	if p.peek("=") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenInt {
+			v.value = p.tok.value
+		}
+		if err := p.value(true); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	v.end = p.last
+	return v, nil
+}
+
+func (p *parser) typ() error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer func() { p.nesting-- }()
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.typ(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.peek("!") {
+		return p.advance()
+	}
+	return nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	f := &fragment{start: p.tok.start}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("invalid fragment name on")
+	}
+	f.name = name
+	if p.tok.kind != tokenName || p.tok.value != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	f.variables, p.vars = p.vars, nil
+	if f.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	f.end = p.last
+	return f, nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.nesting-- }()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []*selection
+	for !p.peek("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set at %d", p.tok.start)
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (*selection, error) {
+	s := &selection{start: p.tok.start}
+	vars := p.vars
+	p.vars = nil
+	defer func() { p.vars = vars }()
+	if p.peek("...") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		s.kind = selectionInline
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			s.kind = selectionSpread
+			s.name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		} else if p.tok.kind == tokenName {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.directives(); err != nil {
+			return nil, err
+		}
+		s.variables = p.vars
+		if s.kind == selectionInline {
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			s.selections = selections
+		}
+		s.end = p.last
+		return s, nil
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	s.name = name
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if s.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		s.alias = name
+	}
+	if p.peek("(") {
+		if s.arguments, err = p.arguments(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	s.variables = p.vars
+	if p.peek("{") {
+		if s.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	s.end = p.last
+	return s, nil
+}
+
+func (p *parser) arguments() ([]*argument, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		arg := &argument{name: name}
+		if p.tok.kind == tokenInt {
+			arg.value = p.tok.value
+		}
+		n, variable := len(p.vars), p.peek("$")
+		if err := p.value(false); err != nil {
+			return nil, err
+		}
+		if variable {
+			arg.variable = p.vars[n]
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, p.unexpected()
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() error {
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.peek("(") {
+			if _, err := p.arguments(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) value(constant bool) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer func() { p.nesting-- }()
+	switch {
+	case p.peek("$") && !constant:
+		if err := p.advance(); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.vars = append(p.vars, name)
+		return nil
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return err
+		}
+		for !p.peek("]") {
+			if err := p.value(constant); err != nil {
+				return err
+			}
+		}
+		return p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return err
+		}
+		for !p.peek("}") {
+			if _, err := p.name(); err != nil {
+				return err
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			if err := p.value(constant); err != nil {
+				return err
+			}
+		}
+		return p.advance()
+	case p.tok.kind == tokenInt || p.tok.kind == tokenFloat || p.tok.kind == tokenString || p.tok.kind == tokenName:
+		return p.advance()
+	}
+	return p.unexpected()
+}
@@ -0,0 +1,104 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/graphql/v1"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// the query files are checked at most once in the interval.
+const _checkInterval = 5 * time.Second
+
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// queryFile is the queries loaded from the file, it's reloaded once the
+// modification time is changed, the last queries are kept if failed to reload.
+type queryFile struct {
+	path string
+
+	mu        sync.Mutex
+	queries   map[string]string
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (f *queryFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	queries := map[string]string{}
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return err
+	}
+	f.queries = queries
+	f.modTime = info.ModTime()
+	return nil
+}
+
+func (f *queryFile) get(hash string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.Sub(f.checkedAt) >= _checkInterval {
+		f.checkedAt = now
+		if err := f.load(); err != nil {
+			log.Errorf("Failed to load persisted queries from %s: %+v", f.path, err)
+		}
+	}
+	query, ok := f.queries[hash]
+	return query, ok
+}
+
+// persisted is the persisted queries keyed by the hashes.
+type persisted struct {
+	queries map[string]string
+	files   []*queryFile
+	enforce bool
+}
+
+func newPersisted(c *v1.PersistedQueries) (*persisted, error) {
+	if c == nil {
+		return nil, nil
+	}
+	p := &persisted{queries: make(map[string]string, len(c.Queries)), enforce: c.Enforce}
+	for _, query := range c.Queries {
+		p.queries[hashQuery(query)] = query
+	}
+	for _, path := range c.Files {
+		f := &queryFile{path: path, checkedAt: time.Now()}
+		if err := f.load(); err != nil {
+			return nil, err
+		}
+		p.files = append(p.files, f)
+	}
+	return p, nil
+}
+
+func (p *persisted) get(hash string) (string, bool) {
+	if query, ok := p.queries[hash]; ok {
+		return query, true
+	}
+	for _, f := range p.files {
+		if query, ok := f.get(hash); ok {
+			return query, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,251 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/graphql/v1"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	_defaultTimeout = 3 * time.Second
+	// the root fields introspected are reloaded in the interval.
+	_reloadInterval = time.Minute
+	// the failed introspection is retried after the interval.
+	_retryInterval = 5 * time.Second
+	// the max bytes of the responses of the stitched upstreams.
+	_maxResponseBytes = 16 << 20
+
+	_introspectionQuery = "query { __schema { queryType { fields { name } } mutationType { fields { name } } subscriptionType { fields { name } } } }"
+)
+
+// the hop-by-hop and the body headers not sent to the stitched upstreams.
+var _skippedHeaders = []string{"Connection", "Content-Length", "Content-Type", "Content-Encoding", "Accept-Encoding", "Te", "Transfer-Encoding", "Upgrade"}
+
+// upstream serves the root fields configured or introspected.
+type upstream struct {
+	name    string
+	url     string
+	timeout time.Duration
+	client  *http.Client
+	fields  map[string]bool
+
+	mu         sync.Mutex
+	introspect bool
+	loading    bool
+	loaded     map[string]bool
+	loadedAt   time.Time
+}
+
+func newUpstream(c *v1.Upstream) (*upstream, error) {
+	if c.Url == "" {
+		return nil, fmt.Errorf("graphql upstream url is required: %s", c.Name)
+	}
+	u := &upstream{
+		name:       c.Name,
+		url:        c.Url,
+		timeout:    _defaultTimeout,
+		client:     &http.Client{},
+		fields:     make(map[string]bool, len(c.Fields)),
+		introspect: len(c.Fields) == 0,
+	}
+	if c.Timeout != nil {
+		u.timeout = c.Timeout.AsDuration()
+	}
+	for _, field := range c.Fields {
+		i := strings.IndexByte(field, '.')
+		if i <= 0 || i == len(field)-1 {
+			return nil, fmt.Errorf("invalid graphql upstream field: %s", field)
+		}
+		u.fields[field] = true
+	}
+	return u, nil
+}
+
+// serves reports whether the root field of the operation type is served, the
+// fields are introspected at the first time, and reloaded in the background.
+func (u *upstream) serves(typ, name string) bool {
+	if !u.introspect {
+		return u.fields[typ+"."+name]
+	}
+	u.mu.Lock()
+	elapsed := time.Since(u.loadedAt)
+	switch {
+	case u.loaded == nil && !u.loading && elapsed >= _retryInterval:
+		u.load()
+	case u.loaded != nil && !u.loading && elapsed >= _reloadInterval:
+		u.loading = true
+		go func() {
+			u.mu.Lock()
+			defer u.mu.Unlock()
+			u.load()
+		}()
+	}
+	served := u.loaded[typ+"."+name]
+	u.mu.Unlock()
+	return served
+}
+
+// load loads the root fields by the introspection with the lock held.
+func (u *upstream) load() {
+	u.loading = true
+	u.loadedAt = time.Now()
+	fields, err := u.introspectFields()
+	u.loading = false
+	if err != nil {
+		log.Errorf("Failed to introspect the root fields of graphql upstream %s: %+v", u.name, err)
+		return
+	}
+	u.loaded = fields
+}
+
+func (u *upstream) introspectFields() (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), u.timeout)
+	defer cancel()
+	r := u.do(ctx, http.Header{}, &request{Query: _introspectionQuery})
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.errors) > 0 {
+		return nil, fmt.Errorf("introspection errors: %s", r.errors[0])
+	}
+	var schema struct {
+		Schema map[string]*struct {
+			Fields []struct {
+				Name string `json:"name"`
+			} `json:"fields"`
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal(r.data, &schema); err != nil {
+		return nil, err
+	}
+	fields := map[string]bool{}
+	for _, typ := range []string{"query", "mutation", "subscription"} {
+		if t := schema.Schema[typ+"Type"]; t != nil {
+			for _, f := range t.Fields {
+				fields[typ+"."+f.Name] = true
+			}
+		}
+	}
+	return fields, nil
+}
+
+// result is the response of the group of the root fields.
+type result struct {
+	data   json.RawMessage
+	errors []json.RawMessage
+	err    error
+}
+
+func decodeResult(resp *http.Response) *result {
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, _maxResponseBytes+1))
+	if err != nil {
+		return &result{err: err}
+	}
+	if len(data) > _maxResponseBytes {
+		return &result{err: fmt.Errorf("the response is larger than %d bytes", _maxResponseBytes)}
+	}
+	var body struct {
+		Data   json.RawMessage   `json:"data"`
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || (body.Data == nil && body.Errors == nil) {
+		return &result{err: fmt.Errorf("unexpected response of status code %d", resp.StatusCode)}
+	}
+	return &result{data: body.Data, errors: body.Errors}
+}
+
+func (u *upstream) do(ctx context.Context, header http.Header, r *request) *result {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return &result{err: err}
+	}
+	req, err := http.NewRequest(http.MethodPost, u.url, bytes.NewReader(data))
+	if err != nil {
+		return &result{err: err}
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+	resp, err := u.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return &result{err: err}
+	}
+	return decodeResult(resp)
+}
+
+// group is the root fields sent to the same upstream, the upstream of the
+// endpoint if nil.
+type group struct {
+	upstream   *upstream
+	selections []*selection
+	keys       []string
+}
+
+func (g *group) name() string {
+	if g.upstream == nil {
+		return "default"
+	}
+	return g.upstream.name
+}
+
+// merge merges the data of the groups in the order of the keys, the keys of
+// the failed groups are null.
+func merge(groups []*group, results []*result) ([]byte, error) {
+	var (
+		data   bytes.Buffer
+		errors []json.RawMessage
+	)
+	data.WriteByte('{')
+	n := 0
+	for i, g := range groups {
+		r := results[i]
+		var fields map[string]json.RawMessage
+		if r.err != nil {
+			msg, _ := json.Marshal(map[string]interface{}{
+				"message":    fmt.Sprintf("failed to request graphql upstream %s: %v", g.name(), r.err),
+				"extensions": map[string]string{"code": "UPSTREAM_ERROR"},
+			})
+			errors = append(errors, msg)
+		} else if len(r.data) > 0 && string(r.data) != "null" {
+			if err := json.Unmarshal(r.data, &fields); err != nil {
+				return nil, err
+			}
+		}
+		errors = append(errors, r.errors...)
+		for _, key := range g.keys {
+			value, ok := fields[key]
+			if !ok {
+				if fields != nil {
+					continue
+				}
+				value = json.RawMessage("null")
+			}
+			if n > 0 {
+				data.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			data.Write(k)
+			data.WriteByte(':')
+			data.Write(value)
+			n++
+		}
+	}
+	data.WriteByte('}')
+	body := map[string]interface{}{"data": json.RawMessage(data.Bytes())}
+	if len(errors) > 0 {
+		body["errors"] = errors
+	}
+	return json.Marshal(body)
+}
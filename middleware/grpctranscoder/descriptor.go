@@ -0,0 +1,209 @@
+package grpctranscoder
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// route is the HTTP binding of the gRPC method.
+type route struct {
+	method     protoreflect.MethodDescriptor
+	httpMethod string
+	template   *pathTemplate
+	// the field of the request body, * for the whole message and empty for none.
+	body string
+	// the field of the response body, empty for the whole message.
+	responseBody string
+}
+
+// fullMethod returns the path of the gRPC method, e.g. /helloworld.Greeter/SayHello.
+func (r *route) fullMethod() string {
+	return "/" + string(r.method.Parent().FullName()) + "/" + string(r.method.Name())
+}
+
+// newFiles returns the files of the descriptor set, the dependencies not in
+// the set are resolved by the files linked in, e.g. the well-known types.
+func newFiles(set *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	pending := make(map[string]*descriptorpb.FileDescriptorProto, len(set.File))
+	for _, f := range set.File {
+		pending[f.GetName()] = f
+	}
+	files := &protoregistry.Files{}
+	var register func(name string, visiting map[string]bool) error
+	register = func(name string, visiting map[string]bool) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		f, ok := pending[name]
+		if !ok {
+			fd, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			if err != nil {
+				return fmt.Errorf("missing proto file %s: %w", name, err)
+			}
+			return files.RegisterFile(fd)
+		}
+		if visiting[name] {
+			return fmt.Errorf("import cycle of proto file %s", name)
+		}
+		visiting[name] = true
+		for _, dep := range f.Dependency {
+			if err := register(dep, visiting); err != nil {
+				return err
+			}
+		}
+		fd, err := protodesc.NewFile(f, files)
+		if err != nil {
+			return err
+		}
+		return files.RegisterFile(fd)
+	}
+	for _, f := range set.File {
+		if err := register(f.GetName(), map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func loadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	return newFiles(set)
+}
+
+func httpBindings(rule *annotations.HttpRule) (string, string) {
+	switch p := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+func newRoute(md protoreflect.MethodDescriptor, rule *annotations.HttpRule) (*route, error) {
+	httpMethod, path := httpBindings(rule)
+	if httpMethod == "" {
+		return nil, fmt.Errorf("missing http pattern of method %s", md.FullName())
+	}
+	t, err := parseTemplate(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http rule of method %s: %w", md.FullName(), err)
+	}
+	r := &route{method: md, httpMethod: httpMethod, template: t, body: rule.Body, responseBody: rule.ResponseBody}
+	if r.body != "" && r.body != "*" && findField(md.Input(), r.body) == nil {
+		return nil, fmt.Errorf("unknown body field %s of method %s", r.body, md.FullName())
+	}
+	if r.responseBody != "" && findField(md.Output(), r.responseBody) == nil {
+		return nil, fmt.Errorf("unknown response body field %s of method %s", r.responseBody, md.FullName())
+	}
+	return r, nil
+}
+
+// buildRoutes returns the routes of the methods annotated by google.api.http,
+// the routes are matched in the order of the files, the services and the
+// methods.
+func buildRoutes(files *protoregistry.Files, services []string) ([]*route, error) {
+	wanted := make(map[string]bool, len(services))
+	for _, s := range services {
+		wanted[s] = true
+	}
+	var (
+		routes []*route
+		err    error
+	)
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			if len(wanted) > 0 && !wanted[string(sd.FullName())] {
+				continue
+			}
+			for j := 0; j < sd.Methods().Len(); j++ {
+				md := sd.Methods().Get(j)
+				rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+				if !ok || rule == nil {
+					continue
+				}
+				for _, r := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+					var rt *route
+					if rt, err = newRoute(md, r); err != nil {
+						return false
+					}
+					routes = append(routes, rt)
+				}
+			}
+		}
+		return true
+	})
+	return routes, err
+}
+
+// resolver resolves the types of the files for the Any messages, e.g. the
+// details of the status.
+type resolver struct {
+	files *protoregistry.Files
+}
+
+func (r resolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		if md, ok := d.(protoreflect.MessageDescriptor); ok {
+			return dynamicpb.NewMessageType(md), nil
+		}
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+func (r resolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	name := url
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		name = url[i+1:]
+	}
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func (r resolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (r resolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// encodeFrames returns the length-prefixed messages of gRPC.
+func encodeFrames(msgs ...proto.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		data, err := proto.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(frameHeader(len(data)))
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
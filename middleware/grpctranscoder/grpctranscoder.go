@@ -0,0 +1,403 @@
+package grpctranscoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/grpctranscoder/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/http/status"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// the descriptors of the reflection are reloaded at most once in the
+	// interval on the unknown routes.
+	_reloadInterval = time.Minute
+	// the failed reflection is retried at most once in the interval.
+	_retryInterval = 5 * time.Second
+)
+
+// the request headers not forwarded to the backends as the metadata.
+var _skippedHeaders = []string{"Accept", "Accept-Encoding", "Connection", "Content-Length", "Content-Type", "Te", "Transfer-Encoding"}
+
+func init() {
+	middleware.Register("grpctranscoder", Middleware)
+}
+
+// descriptors is the routes of the files.
+type descriptors struct {
+	files     *protoregistry.Files
+	routes    []*route
+	marshal   protojson.MarshalOptions
+	unmarshal protojson.UnmarshalOptions
+}
+
+func (d *descriptors) match(req *http.Request) (*route, map[string]string) {
+	path := req.URL.EscapedPath()
+	for _, r := range d.routes {
+		if r.httpMethod != req.Method {
+			continue
+		}
+		if vars, ok := r.template.match(path); ok {
+			return r, vars
+		}
+	}
+	return nil, nil
+}
+
+type transcoder struct {
+	options *v1.GRPCTranscoder
+
+	mu       sync.Mutex
+	desc     *descriptors
+	err      error
+	loadedAt time.Time
+}
+
+func (t *transcoder) newDescriptors(files *protoregistry.Files) (*descriptors, error) {
+	routes, err := buildRoutes(files, t.options.Services)
+	if err != nil {
+		return nil, err
+	}
+	r := resolver{files: files}
+	return &descriptors{
+		files:  files,
+		routes: routes,
+		marshal: protojson.MarshalOptions{
+			UseProtoNames:   t.options.UseProtoNames,
+			EmitUnpopulated: t.options.EmitUnpopulated,
+			Resolver:        r,
+		},
+		unmarshal: protojson.UnmarshalOptions{Resolver: r},
+	}, nil
+}
+
+// load returns the descriptors, the ones of the reflection are loaded at the
+// first request, and reloaded if stale.
+func (t *transcoder) load(next http.RoundTripper, req *http.Request, stale bool) (*descriptors, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.options.Reflection {
+		return t.desc, nil
+	}
+	elapsed := time.Since(t.loadedAt)
+	switch {
+	case t.desc != nil && (!stale || elapsed < _reloadInterval):
+		return t.desc, nil
+	case t.desc == nil && t.err != nil && elapsed < _retryInterval:
+		return nil, t.err
+	}
+	t.loadedAt = time.Now()
+	files, err := loadReflection(req.Context(), next, req)
+	var desc *descriptors
+	if err == nil {
+		desc, err = t.newDescriptors(files)
+	}
+	if err != nil {
+		log.Errorf("Failed to load the descriptors by the server reflection of %s: %+v", req.URL.Path, err)
+		t.err = err
+		if t.desc != nil {
+			return t.desc, nil
+		}
+		return nil, err
+	}
+	t.desc, t.err = desc, nil
+	return desc, nil
+}
+
+func newResponse(statusCode int, contentType string, data []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    statusCode,
+		Header:        http.Header{"Content-Type": {contentType}},
+		ContentLength: int64(len(data)),
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+	}
+}
+
+func (d *descriptors) marshalStatus(st *spb.Status) []byte {
+	data, err := d.marshal.Marshal(st)
+	if err != nil {
+		// the details of the unknown types are left out.
+		data, _ = d.marshal.Marshal(&spb.Status{Code: st.Code, Message: st.Message})
+	}
+	return data
+}
+
+func (d *descriptors) errorResponse(st *spb.Status) *http.Response {
+	return newResponse(status.FromGRPCCode(codes.Code(st.Code)), "application/json", d.marshalStatus(st))
+}
+
+func errorStatus(code codes.Code, format string, args ...interface{}) *spb.Status {
+	return &spb.Status{Code: int32(code), Message: fmt.Sprintf(format, args...)}
+}
+
+// decodeRequest returns the messages of the request, the body of the client
+// streaming methods is the sequence of the JSON messages.
+func (d *descriptors) decodeRequest(req *http.Request, r *route, vars map[string]string) ([]proto.Message, *spb.Status) {
+	var body []byte
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, errorStatus(codes.InvalidArgument, "failed to read the request body: %v", err)
+		}
+		body = data
+	}
+	var bodies [][]byte
+	if r.method.IsStreamingClient() && r.body != "" {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, errorStatus(codes.InvalidArgument, "invalid request body: %v", err)
+			}
+			bodies = append(bodies, raw)
+		}
+	} else {
+		bodies = [][]byte{body}
+	}
+	msgs := make([]proto.Message, 0, len(bodies))
+	for _, b := range bodies {
+		msg := dynamicpb.NewMessage(r.method.Input())
+		if st := d.decodeMessage(req, r, vars, msg, b); st != nil {
+			return nil, st
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func (d *descriptors) decodeMessage(req *http.Request, r *route, vars map[string]string, msg *dynamicpb.Message, body []byte) *spb.Status {
+	if r.body != "" && len(bytes.TrimSpace(body)) > 0 {
+		if r.body != "*" {
+			// the body of the field is decoded as the message of the field.
+			name, _ := json.Marshal(findField(msg.Descriptor(), r.body).JSONName())
+			body = []byte(fmt.Sprintf("{%s:%s}", name, body))
+		}
+		if err := d.unmarshal.Unmarshal(body, msg); err != nil {
+			return errorStatus(codes.InvalidArgument, "invalid request body: %v", err)
+		}
+	}
+	for field, value := range vars {
+		if err := setField(msg, field, []string{value}); err != nil {
+			return errorStatus(codes.InvalidArgument, "invalid path variable: %v", err)
+		}
+	}
+	if r.body == "*" {
+		return nil
+	}
+	for key, values := range req.URL.Query() {
+		name := strings.SplitN(key, ".", 2)[0]
+		if _, ok := vars[key]; ok || findField(msg.Descriptor(), name) == nil {
+			continue
+		}
+		if r.body != "" && (key == r.body || strings.HasPrefix(key, r.body+".")) {
+			continue
+		}
+		if err := setField(msg, key, values); err != nil {
+			return errorStatus(codes.InvalidArgument, "invalid query parameter: %v", err)
+		}
+	}
+	return nil
+}
+
+// encodeResponse returns the JSON of the response message or the field of the
+// response body.
+func (d *descriptors) encodeResponse(r *route, data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(r.method.Output())
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	out, err := d.marshal.Marshal(msg)
+	if err != nil || r.responseBody == "" {
+		return out, err
+	}
+	fd := findField(msg.Descriptor(), r.responseBody)
+	name := fd.JSONName()
+	if d.marshal.UseProtoNames {
+		name = string(fd.Name())
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(out, &fields); err != nil {
+		return nil, err
+	}
+	if v, ok := fields[name]; ok {
+		return v, nil
+	}
+	// the field of the default value.
+	empty, err := protojson.MarshalOptions{UseProtoNames: d.marshal.UseProtoNames, EmitUnpopulated: true}.Marshal(dynamicpb.NewMessage(msg.Descriptor()))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(empty, &fields); err != nil {
+		return nil, err
+	}
+	return fields[name], nil
+}
+
+func (d *descriptors) roundTrip(next http.RoundTripper, req *http.Request, r *route, vars map[string]string) (*http.Response, error) {
+	msgs, st := d.decodeRequest(req, r, vars)
+	if st != nil {
+		return d.errorResponse(st), nil
+	}
+	data, err := encodeFrames(msgs...)
+	if err != nil {
+		return nil, err
+	}
+	upstream := newUpstreamRequest(req, r.fullMethod(), data)
+	for _, name := range _skippedHeaders {
+		upstream.Header.Del(name)
+	}
+	upstream.Header.Set("Content-Type", "application/grpc")
+	upstream.Header.Set("Te", "trailers")
+	resp, err := next.RoundTrip(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return d.errorResponse(errorStatus(status.ToGRPCCode(resp.StatusCode), "unexpected status code of the backend: %d", resp.StatusCode)), nil
+	}
+	frames := newFrameReader(resp)
+	if r.method.IsStreamingServer() {
+		return d.stream(req, r, resp, frames)
+	}
+	defer resp.Body.Close()
+	var reply []byte
+	for {
+		data, err := frames.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if reply == nil {
+			reply = data
+		}
+	}
+	if st := grpcStatus(resp); st.Code != int32(codes.OK) {
+		return d.errorResponse(st), nil
+	}
+	if reply == nil {
+		return d.errorResponse(errorStatus(codes.Internal, "missing response message")), nil
+	}
+	out, err := d.encodeResponse(r, reply)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(http.StatusOK, "application/json", out), nil
+}
+
+// stream sends the messages of the server streaming response, the error
+// before any message is responded as the unary ones.
+func (d *descriptors) stream(req *http.Request, r *route, resp *http.Response, frames *frameReader) (*http.Response, error) {
+	var w streamWriter = ndjsonWriter{}
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		w = sseWriter{}
+	}
+	first, err := frames.next()
+	if err != nil {
+		resp.Body.Close()
+		if err != io.EOF {
+			return nil, err
+		}
+		if st := grpcStatus(resp); st.Code != int32(codes.OK) {
+			return d.errorResponse(st), nil
+		}
+		return newResponse(http.StatusOK, w.contentType(), nil), nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer resp.Body.Close()
+		for data := first; ; {
+			out, err := d.encodeResponse(r, data)
+			if err != nil {
+				w.error(pw, d.marshalStatus(errorStatus(codes.Internal, "%v", err)))
+				pw.Close()
+				return
+			}
+			// the client is gone if failed to write.
+			if err := w.message(pw, out); err != nil {
+				return
+			}
+			if data, err = frames.next(); err != nil {
+				st := grpcStatus(resp)
+				if err != io.EOF {
+					st = errorStatus(codes.Unavailable, "%v", err)
+				}
+				if st.Code != int32(codes.OK) {
+					w.error(pw, d.marshalStatus(st))
+				}
+				pw.Close()
+				return
+			}
+		}
+	}()
+	header := http.Header{"Content-Type": {w.contentType()}, "Cache-Control": {"no-cache"}}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, ContentLength: -1, Body: pr}, nil
+}
+
+// Middleware transcodes the HTTP/JSON requests to the gRPC methods by the
+// google.api.http annotations.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.GRPCTranscoder{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if (options.DescriptorSet == "") == !options.Reflection {
+		return nil, fmt.Errorf("grpctranscoder requires either the descriptor set or the reflection")
+	}
+	t := &transcoder{options: options}
+	if options.DescriptorSet != "" {
+		files, err := loadDescriptorSet(options.DescriptorSet)
+		if err != nil {
+			return nil, err
+		}
+		if t.desc, err = t.newDescriptors(files); err != nil {
+			return nil, err
+		}
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+				return next.RoundTrip(req)
+			}
+			desc, err := t.load(next, req, false)
+			if err != nil {
+				data, _ := protojson.Marshal(errorStatus(codes.Unavailable, "failed to load the descriptors"))
+				return newResponse(http.StatusServiceUnavailable, "application/json", data), nil
+			}
+			r, vars := desc.match(req)
+			if r == nil && options.Reflection {
+				if reloaded, err := t.load(next, req, true); err == nil {
+					desc = reloaded
+					r, vars = desc.match(req)
+				}
+			}
+			if r == nil {
+				return desc.errorResponse(errorStatus(codes.NotFound, "no method of %s %s", req.Method, req.URL.Path)), nil
+			}
+			return desc.roundTrip(next, req, r, vars)
+		})
+	}, nil
+}
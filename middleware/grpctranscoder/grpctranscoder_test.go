@@ -0,0 +1,359 @@
+package grpctranscoder
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/grpctranscoder/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"golang.org/x/net/http2"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  label.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func method(name, input, output string, rule *annotations.HttpRule, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+	options := &descriptorpb.MethodOptions{}
+	proto.SetExtension(options, annotations.E_Http, rule)
+	return &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String(input),
+		OutputType:      proto.String(output),
+		Options:         options,
+		ClientStreaming: proto.Bool(clientStreaming),
+		ServerStreaming: proto.Bool(serverStreaming),
+	}
+}
+
+// libraryFile is the descriptor of:
+//
+//	message Book { string name = 1; int64 id = 2; repeated string tags = 3; }
+//	message GetBookRequest { string name = 1; bool full = 2; }
+//	message CreateBookRequest { string parent = 1; Book book = 2; }
+//	message ListBooksRequest { string parent = 1; int32 page_size = 2; }
+//	message ImportBooksResponse { int32 count = 1; repeated Book books = 2; }
+//	service Library { GetBook, CreateBook, ListBooks (server streaming),
+//	  ImportBooks (client streaming) }
+func libraryFile() *descriptorpb.FileDescriptorProto {
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	)
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/library.proto"),
+		Package:    proto.String("test.library"),
+		Dependency: []string{"google/api/annotations.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Book"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, str, optional, ""),
+				field("id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+				field("tags", 3, str, repeated, ""),
+			},
+		}, {
+			Name: proto.String("GetBookRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, str, optional, ""),
+				field("full", 2, descriptorpb.FieldDescriptorProto_TYPE_BOOL, optional, ""),
+			},
+		}, {
+			Name: proto.String("CreateBookRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("parent", 1, str, optional, ""),
+				field("book", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".test.library.Book"),
+			},
+		}, {
+			Name: proto.String("ListBooksRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("parent", 1, str, optional, ""),
+				field("page_size", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+			},
+		}, {
+			Name: proto.String("ImportBooksResponse"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("count", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+				field("books", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".test.library.Book"),
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Library"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetBook", ".test.library.GetBookRequest", ".test.library.Book", &annotations.HttpRule{
+					Pattern:            &annotations.HttpRule_Get{Get: "/v1/{name=shelves/*/books/*}"},
+					AdditionalBindings: []*annotations.HttpRule{{Pattern: &annotations.HttpRule_Get{Get: "/v1/books/{name}:get"}}},
+				}, false, false),
+				method("CreateBook", ".test.library.CreateBookRequest", ".test.library.Book", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Post{Post: "/v1/{parent=shelves/*}/books"},
+					Body:    "book",
+				}, false, false),
+				method("ListBooks", ".test.library.ListBooksRequest", ".test.library.Book", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/{parent=shelves/*}/books"},
+				}, false, true),
+				method("ImportBooks", ".test.library.Book", ".test.library.ImportBooksResponse", &annotations.HttpRule{
+					Pattern:      &annotations.HttpRule_Post{Post: "/v1/books:import"},
+					Body:         "*",
+					ResponseBody: "books",
+				}, true, false),
+			},
+		}},
+	}
+}
+
+func newLibraryFiles(t *testing.T) *protoregistry.Files {
+	files, err := newFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{libraryFile()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func messageDesc(t *testing.T, files *protoregistry.Files, name string) protoreflect.MessageDescriptor {
+	d, err := files.FindDescriptorByName(protoreflect.FullName("test.library." + name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.(protoreflect.MessageDescriptor)
+}
+
+func newMessage(t *testing.T, files *protoregistry.Files, name, json string) *dynamicpb.Message {
+	msg := dynamicpb.NewMessage(messageDesc(t, files, name))
+	if err := protojson.Unmarshal([]byte(json), msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// newLibraryServer serves the library of the dynamic messages with the server
+// reflection, it returns the transport of h2c to the server.
+func newLibraryServer(t *testing.T, files *protoregistry.Files) http.RoundTripper {
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.library.Library",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "GetBook",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := dynamicpb.NewMessage(messageDesc(t, files, "GetBookRequest"))
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				name := in.Get(in.Descriptor().Fields().ByName("name")).String()
+				if strings.HasSuffix(name, "/missing") {
+					return nil, status.Errorf(codes.NotFound, "book %s is not found", name)
+				}
+				full := in.Get(in.Descriptor().Fields().ByName("full")).Bool()
+				return newMessage(t, files, "Book", `{"name":"`+name+`","id":"1","tags":["`+map[bool]string{true: "full", false: "brief"}[full]+`"]}`), nil
+			},
+		}, {
+			MethodName: "CreateBook",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := dynamicpb.NewMessage(messageDesc(t, files, "CreateBookRequest"))
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				fields := in.Descriptor().Fields()
+				book := in.Get(fields.ByName("book")).Message()
+				name := in.Get(fields.ByName("parent")).String() + "/books/" + book.Get(book.Descriptor().Fields().ByName("name")).String()
+				book.Set(book.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString(name))
+				return book.Interface(), nil
+			},
+		}},
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "ListBooks",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := dynamicpb.NewMessage(messageDesc(t, files, "ListBooksRequest"))
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				n := int(in.Get(in.Descriptor().Fields().ByName("page_size")).Int())
+				for i := 0; i < n; i++ {
+					if err := stream.SendMsg(newMessage(t, files, "Book", `{"name":"book"}`)); err != nil {
+						return err
+					}
+				}
+				return status.Error(codes.ResourceExhausted, "no more books")
+			},
+		}, {
+			StreamName:    "ImportBooks",
+			ClientStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				out := newMessage(t, files, "ImportBooksResponse", `{}`)
+				books := out.Mutable(out.Descriptor().Fields().ByName("books")).List()
+				for {
+					in := dynamicpb.NewMessage(messageDesc(t, files, "Book"))
+					if err := stream.RecvMsg(in); err != nil {
+						break
+					}
+					books.Append(protoreflect.ValueOfMessage(in))
+				}
+				out.Set(out.Descriptor().Fields().ByName("count"), protoreflect.ValueOfInt32(int32(books.Len())))
+				return stream.SendMsg(out)
+			},
+		}},
+	}, struct{}{})
+	rpb.RegisterServerReflectionServer(s, reflection.NewServer(reflection.ServerOptions{Services: s, DescriptorResolver: files}))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	transport := &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+	return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme = "http"
+		req.URL.Host = lis.Addr().String()
+		return transport.RoundTrip(req)
+	})
+}
+
+func newTranscoder(t *testing.T, options *v1.GRPCTranscoder, next http.RoundTripper) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "grpctranscoder", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(next)
+}
+
+func do(t *testing.T, next http.RoundTripper, method, target, body string, header http.Header) (*http.Response, string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/v1/*", Protocol: config.Protocol_GRPC})
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, strings.Replace(string(data), " ", "", -1)
+}
+
+func TestTranscoder(t *testing.T) {
+	files := newLibraryFiles(t)
+	path := filepath.Join(t.TempDir(), "library.pb")
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{libraryFile()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := newLibraryServer(t, files)
+	for name, options := range map[string]*v1.GRPCTranscoder{
+		"descriptor set": {DescriptorSet: path},
+		"reflection":     {Reflection: true},
+	} {
+		next := newTranscoder(t, options, server)
+		testCases := []struct {
+			method, target, body string
+			header               http.Header
+			status               int
+			want                 string
+		}{
+			{"GET", "/v1/shelves/1/books/2?full=true", "", nil, http.StatusOK, `{"name":"shelves/1/books/2","id":"1","tags":["full"]}`},
+			{"GET", "/v1/books/a%2Fb:get", "", nil, http.StatusOK, `{"name":"a/b","id":"1","tags":["brief"]}`},
+			{"GET", "/v1/shelves/1/books/missing", "", nil, http.StatusNotFound, `{"code":5,"message":"bookshelves/1/books/missingisnotfound"}`},
+			{"GET", "/v1/shelves/1/books/2?full=maybe", "", nil, http.StatusBadRequest, ""},
+			{"POST", "/v1/shelves/1/books", `{"name":"go","tags":["dev"]}`, nil, http.StatusOK, `{"name":"shelves/1/books/go","tags":["dev"]}`},
+			{"POST", "/v1/shelves/1/books", `{"unknown":1}`, nil, http.StatusBadRequest, ""},
+			{"POST", "/v1/books:import", `{"name":"a"} {"name":"b"}`, nil, http.StatusOK, `[{"name":"a"},{"name":"b"}]`},
+			{"GET", "/v1/shelves/1/books?page_size=2", "", nil, http.StatusOK, `{"name":"book"}` + "\n" + `{"name":"book"}` + "\n" + `{"error":{"code":8,"message":"nomorebooks"}}` + "\n"},
+			{"GET", "/v1/shelves/1/books?pageSize=1", "", http.Header{"Accept": {"text/event-stream"}}, http.StatusOK, "data:{\"name\":\"book\"}\n\nevent:error\ndata:{\"code\":8,\"message\":\"nomorebooks\"}\n\n"},
+			{"GET", "/v1/shelves/1/books?page_size=0", "", nil, http.StatusTooManyRequests, `{"code":8,"message":"nomorebooks"}`},
+			{"DELETE", "/v1/shelves/1/books/2", "", nil, http.StatusNotFound, ""},
+		}
+		for _, tc := range testCases {
+			resp, body := do(t, next, tc.method, tc.target, tc.body, tc.header)
+			if resp.StatusCode != tc.status || (tc.want != "" && body != tc.want) {
+				t.Errorf("%s: %s %s: want %d %s but got %d %s", name, tc.method, tc.target, tc.status, tc.want, resp.StatusCode, body)
+			}
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		want     map[string]string
+	}{
+		{"/v1/books", "/v1/books", map[string]string{}},
+		{"/v1/books/{id}", "/v1/books/1", map[string]string{"id": "1"}},
+		{"/v1/books/{id}", "/v1/books/1/2", nil},
+		{"/v1/books/{id}", "/v1/books/", nil},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/1/books/2", map[string]string{"name": "shelves/1/books/2"}},
+		{"/v1/{name=files/**}", "/v1/files/a/b%2Fc", map[string]string{"name": "files/a/b%2Fc"}},
+		{"/v1/{book.name=books/*}:publish", "/v1/books/1:publish", map[string]string{"book.name": "books/1"}},
+		{"/v1/{book.name=books/*}:publish", "/v1/books/1", nil},
+		{"/v1/*/books", "/v1/any/books", map[string]string{}},
+	}
+	for _, tc := range testCases {
+		tmpl, err := parseTemplate(tc.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := tmpl.match(tc.path)
+		if ok != (tc.want != nil) || len(got) != len(tc.want) {
+			t.Errorf("%s %s: want %v but got %v", tc.template, tc.path, tc.want, got)
+			continue
+		}
+		for k, v := range tc.want {
+			if got[k] != v {
+				t.Errorf("%s %s: want %v but got %v", tc.template, tc.path, tc.want, got)
+			}
+		}
+	}
+	for _, template := range []string{"v1/books", "/v1/{id", "/v1//books", "/v1/{=books/*}", "/v1/**/books"} {
+		if _, err := parseTemplate(template); err == nil {
+			t.Errorf("%s: want error", template)
+		}
+	}
+}
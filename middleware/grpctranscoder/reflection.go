@@ -0,0 +1,136 @@
+package grpctranscoder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const _reflectionMethod = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+
+// reflect sends the requests in a stream of the server reflection, the
+// responses are in the same order.
+func reflect(ctx context.Context, next http.RoundTripper, req *http.Request, msgs []*rpb.ServerReflectionRequest) ([]*rpb.ServerReflectionResponse, error) {
+	in := make([]proto.Message, 0, len(msgs))
+	for _, m := range msgs {
+		in = append(in, m)
+	}
+	data, err := encodeFrames(in...)
+	if err != nil {
+		return nil, err
+	}
+	// the reflection is not a part of the request, e.g. the backends tried.
+	if e, ok := middleware.EndpointFromContext(ctx); ok {
+		ctx = middleware.NewRequestContext(ctx, middleware.NewRequestOptions(e))
+	}
+	upstream := newUpstreamRequest(req.WithContext(ctx), _reflectionMethod, data)
+	upstream.Header = http.Header{}
+	upstream.Header.Set("Content-Type", "application/grpc")
+	upstream.Header.Set("Te", "trailers")
+	resp, err := next.RoundTrip(upstream)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code of the server reflection: %d", resp.StatusCode)
+	}
+	frames := newFrameReader(resp)
+	var out []*rpb.ServerReflectionResponse
+	for {
+		data, err := frames.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		m := &rpb.ServerReflectionResponse{}
+		if err := proto.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	if st := grpcStatus(resp); st.Code != int32(codes.OK) {
+		return nil, fmt.Errorf("server reflection error: %s: %s", codes.Code(st.Code), st.Message)
+	}
+	if len(out) != len(msgs) {
+		return nil, fmt.Errorf("server reflection error: want %d responses but got %d", len(msgs), len(out))
+	}
+	return out, nil
+}
+
+// loadReflection loads the files of the services of the backend by the server
+// reflection.
+func loadReflection(ctx context.Context, next http.RoundTripper, req *http.Request) (*protoregistry.Files, error) {
+	resps, err := reflect(ctx, next, req, []*rpb.ServerReflectionRequest{{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if e := resps[0].GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("server reflection error: %s", e.ErrorMessage)
+	}
+	var msgs []*rpb.ServerReflectionRequest
+	for _, s := range resps[0].GetListServicesResponse().GetService() {
+		if strings.HasPrefix(s.Name, "grpc.reflection.") {
+			continue
+		}
+		msgs = append(msgs, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: s.Name},
+		})
+	}
+	if len(msgs) == 0 {
+		return &protoregistry.Files{}, nil
+	}
+	if resps, err = reflect(ctx, next, req, msgs); err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	for i, resp := range resps {
+		if e := resp.GetErrorResponse(); e != nil {
+			log.Warnf("Failed to load the descriptors of %s by the server reflection: %s", msgs[i].GetFileContainingSymbol(), e.ErrorMessage)
+			continue
+		}
+		for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			f := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, f); err != nil {
+				return nil, err
+			}
+			if !seen[f.GetName()] {
+				seen[f.GetName()] = true
+				set.File = append(set.File, f)
+			}
+		}
+	}
+	return newFiles(set)
+}
+
+// newUpstreamRequest returns the gRPC request of the method to the backend.
+func newUpstreamRequest(req *http.Request, method string, body []byte) *http.Request {
+	upstream := req.Clone(req.Context())
+	upstream.Method = http.MethodPost
+	upstream.URL.Path = method
+	upstream.URL.RawPath = ""
+	upstream.URL.RawQuery = ""
+	upstream.ContentLength = int64(len(body))
+	upstream.Body = ioutil.NopCloser(bytes.NewReader(body))
+	upstream.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return upstream
+}
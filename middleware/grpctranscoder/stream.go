@@ -0,0 +1,140 @@
+package grpctranscoder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// the max size of a message received from the backend.
+const _maxMessageSize = 16 << 20
+
+func frameHeader(n int) []byte {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(n))
+	return header
+}
+
+// frameReader reads the length-prefixed messages of the gRPC response.
+type frameReader struct {
+	r        *bufio.Reader
+	encoding string
+}
+
+func newFrameReader(resp *http.Response) *frameReader {
+	return &frameReader{r: bufio.NewReader(resp.Body), encoding: resp.Header.Get("Grpc-Encoding")}
+}
+
+// next returns the next message, or io.EOF at the end of the stream.
+func (f *frameReader) next() ([]byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(f.r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("unexpected end of the gRPC message")
+		}
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n > _maxMessageSize {
+		return nil, fmt.Errorf("gRPC message size %d exceeds the limit %d", n, _maxMessageSize)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(f.r, data); err != nil {
+		return nil, fmt.Errorf("unexpected end of the gRPC message: %w", err)
+	}
+	if header[0] == 0 {
+		return data, nil
+	}
+	if f.encoding != "gzip" {
+		return nil, fmt.Errorf("unsupported gRPC encoding: %q", f.encoding)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(io.LimitReader(zr, _maxMessageSize))
+}
+
+func decodeBinHeader(v string) ([]byte, error) {
+	if len(v)%4 == 0 {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
+
+// grpcStatus returns the status of the response by the trailers, or the
+// headers of the trailers-only responses. It's read after the body.
+func grpcStatus(resp *http.Response) *spb.Status {
+	get := func(name string) string {
+		if v := resp.Trailer.Get(name); v != "" {
+			return v
+		}
+		return resp.Header.Get(name)
+	}
+	code := get("Grpc-Status")
+	if code == "" {
+		return &spb.Status{Code: int32(codes.Unknown), Message: "missing grpc-status"}
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return &spb.Status{Code: int32(codes.Unknown), Message: "invalid grpc-status: " + code}
+	}
+	st := &spb.Status{Code: int32(n)}
+	if details := get("Grpc-Status-Details-Bin"); details != "" {
+		if data, err := decodeBinHeader(details); err == nil && proto.Unmarshal(data, st) == nil {
+			return st
+		}
+	}
+	if msg, err := url.PathUnescape(get("Grpc-Message")); err == nil {
+		st.Message = msg
+	}
+	return st
+}
+
+// streamWriter writes the messages of the server streaming responses.
+type streamWriter interface {
+	contentType() string
+	message(w io.Writer, data []byte) error
+	error(w io.Writer, data []byte) error
+}
+
+type sseWriter struct{}
+
+func (sseWriter) contentType() string { return "text/event-stream" }
+
+func (sseWriter) message(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+func (sseWriter) error(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+	return err
+}
+
+type ndjsonWriter struct{}
+
+func (ndjsonWriter) contentType() string { return "application/x-ndjson" }
+
+func (ndjsonWriter) message(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func (ndjsonWriter) error(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, "{\"error\":%s}\n", data)
+	return err
+}
@@ -0,0 +1,170 @@
+package grpctranscoder
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	// * matches a segment
+	segmentSingle
+	// ** matches the rest segments
+	segmentMulti
+)
+
+type segment struct {
+	kind    segmentKind
+	literal string
+}
+
+// binding is the field bound to the segments [start, end) of the template.
+type binding struct {
+	field      string
+	start, end int
+}
+
+var _escapedSlash = strings.NewReplacer("%2F", "%252F", "%2f", "%252f")
+
+// pathTemplate is the path template of google.api.http, e.g.
+// /v1/{name=shelves/*/books/*}:cancel.
+type pathTemplate struct {
+	segments []segment
+	bindings []binding
+	verb     string
+}
+
+func parseSegments(s string) ([]segment, error) {
+	var segments []segment
+	for _, part := range strings.Split(s, "/") {
+		switch part {
+		case "":
+			return nil, fmt.Errorf("empty segment")
+		case "*":
+			segments = append(segments, segment{kind: segmentSingle})
+		case "**":
+			segments = append(segments, segment{kind: segmentMulti})
+		default:
+			if strings.ContainsAny(part, "{}*=") {
+				return nil, fmt.Errorf("invalid segment %q", part)
+			}
+			segments = append(segments, segment{literal: part})
+		}
+	}
+	return segments, nil
+}
+
+func parseTemplate(template string) (*pathTemplate, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("invalid path template %q: want the beginning of /", template)
+	}
+	t := &pathTemplate{}
+	rest := template[1:]
+	// the verb is after the last colon out of the variables.
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 && !strings.Contains(rest[i:], "}") {
+		t.verb = rest[i+1:]
+		rest = rest[:i]
+	}
+	for rest != "" {
+		var part string
+		if rest[0] == '{' {
+			i := strings.IndexByte(rest, '}')
+			if i < 0 {
+				return nil, fmt.Errorf("invalid path template %q: unclosed variable", template)
+			}
+			part, rest = rest[1:i], rest[i+1:]
+			field, pattern := part, "*"
+			if j := strings.IndexByte(part, '='); j >= 0 {
+				field, pattern = part[:j], part[j+1:]
+			}
+			segments, err := parseSegments(pattern)
+			if err != nil || field == "" {
+				return nil, fmt.Errorf("invalid path template %q: invalid variable %q", template, part)
+			}
+			start := len(t.segments)
+			t.segments = append(t.segments, segments...)
+			t.bindings = append(t.bindings, binding{field: field, start: start, end: len(t.segments)})
+		} else {
+			i := strings.IndexByte(rest, '/')
+			if i < 0 {
+				i = len(rest)
+			}
+			part, rest = rest[:i], rest[i:]
+			segments, err := parseSegments(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path template %q: %w", template, err)
+			}
+			t.segments = append(t.segments, segments...)
+		}
+		if rest == "" {
+			break
+		}
+		if rest[0] != '/' {
+			return nil, fmt.Errorf("invalid path template %q", template)
+		}
+		rest = rest[1:]
+	}
+	for i, s := range t.segments {
+		if s.kind == segmentMulti && i != len(t.segments)-1 {
+			return nil, fmt.Errorf("invalid path template %q: ** must be the last segment", template)
+		}
+	}
+	return t, nil
+}
+
+// match returns the values of the bound fields if the escaped path matches the
+// template, the values of the single segments are unescaped while the slashes
+// are kept escaped in the ones of the multiple segments.
+func (t *pathTemplate) match(path string) (map[string]string, bool) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	path = path[1:]
+	if t.verb != "" {
+		if !strings.HasSuffix(path, ":"+t.verb) {
+			return nil, false
+		}
+		path = path[:len(path)-len(t.verb)-1]
+	}
+	parts := strings.Split(path, "/")
+	n := len(t.segments)
+	if len(parts) < n || (len(parts) > n && (n == 0 || t.segments[n-1].kind != segmentMulti)) {
+		return nil, false
+	}
+	for i, s := range t.segments {
+		if s.kind == segmentLiteral && parts[i] != s.literal {
+			return nil, false
+		}
+		if s.kind == segmentSingle && parts[i] == "" {
+			return nil, false
+		}
+	}
+	values := make(map[string]string, len(t.bindings))
+	for _, b := range t.bindings {
+		end := b.end
+		if end == n {
+			end = len(parts)
+		}
+		if end-b.start == 1 {
+			v, err := url.PathUnescape(parts[b.start])
+			if err != nil {
+				return nil, false
+			}
+			values[b.field] = v
+			continue
+		}
+		matched := make([]string, 0, end-b.start)
+		for _, p := range parts[b.start:end] {
+			v, err := url.PathUnescape(_escapedSlash.Replace(p))
+			if err != nil {
+				return nil, false
+			}
+			matched = append(matched, v)
+		}
+		values[b.field] = strings.Join(matched, "/")
+	}
+	return values, true
+}
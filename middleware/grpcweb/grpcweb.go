@@ -0,0 +1,208 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+)
+
+const (
+	_contentTypeGRPC    = "application/grpc"
+	_contentTypeWeb     = "application/grpc-web"
+	_contentTypeWebText = "application/grpc-web-text"
+	// the flag of the trailer frame of gRPC-Web.
+	_trailerFlag = 0x80
+)
+
+func init() {
+	middleware.Register("grpcweb", Middleware)
+}
+
+// isWeb reports whether the content type is of gRPC-Web, and whether it's of
+// the text encoding.
+func isWeb(contentType string) (web bool, text bool) {
+	switch {
+	case strings.HasPrefix(contentType, _contentTypeWebText):
+		return true, true
+	case strings.HasPrefix(contentType, _contentTypeWeb):
+		return true, false
+	}
+	return false, false
+}
+
+// decodeText decodes the base64 body, it can be the concatenation of the
+// padded chunks sent by the streaming clients.
+func decodeText(data []byte) ([]byte, error) {
+	data = bytes.Join(bytes.Fields(data), nil)
+	out := make([]byte, 0, base64.StdEncoding.DecodedLen(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if i := bytes.IndexByte(data, '='); i >= 0 {
+			// the chunk ends at the group of the padding.
+			n = (i/4 + 1) * 4
+			if n > len(data) {
+				n = len(data)
+			}
+		}
+		chunk := make([]byte, base64.StdEncoding.DecodedLen(n))
+		m, err := base64.StdEncoding.Decode(chunk, data[:n])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk[:m]...)
+		data = data[n:]
+	}
+	return out, nil
+}
+
+// encodeTrailer returns the trailer frame of the lowercase names.
+func encodeTrailer(trailer http.Header) []byte {
+	names := make([]string, 0, len(trailer))
+	for name := range trailer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		for _, v := range trailer[name] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", strings.ToLower(name), v)
+		}
+	}
+	frame := make([]byte, 5, 5+buf.Len())
+	frame[0] = _trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+	return append(frame, buf.Bytes()...)
+}
+
+// webBody is the response body of the gRPC frames followed by the trailer
+// frame, the HTTP trailers are removed once sent in the frame.
+type webBody struct {
+	io.ReadCloser
+	resp    *http.Response
+	trailer *bytes.Reader
+}
+
+func (b *webBody) Read(p []byte) (int, error) {
+	if b.trailer != nil {
+		return b.trailer.Read(p)
+	}
+	n, err := b.ReadCloser.Read(p)
+	if err != io.EOF {
+		return n, err
+	}
+	// the trailers-only responses have the status in the headers.
+	var frame []byte
+	if len(b.resp.Trailer) > 0 {
+		frame = encodeTrailer(b.resp.Trailer)
+	}
+	b.trailer = bytes.NewReader(frame)
+	b.resp.Trailer = nil
+	if n > 0 {
+		return n, nil
+	}
+	return b.trailer.Read(p)
+}
+
+// textBody encodes the body by base64 as a stream, the rest bytes of the
+// incomplete group are encoded at the end.
+type textBody struct {
+	io.ReadCloser
+	rest []byte
+	out  bytes.Buffer
+	eof  bool
+}
+
+func (b *textBody) Read(p []byte) (int, error) {
+	for b.out.Len() == 0 {
+		if b.eof {
+			return 0, io.EOF
+		}
+		buf := make([]byte, 3*1024)
+		n, err := b.ReadCloser.Read(buf)
+		data := append(b.rest, buf[:n]...)
+		if err == io.EOF {
+			b.eof = true
+		} else if err != nil {
+			return 0, err
+		}
+		m := len(data)
+		if !b.eof {
+			m -= m % 3
+		}
+		enc := make([]byte, base64.StdEncoding.EncodedLen(m))
+		base64.StdEncoding.Encode(enc, data[:m])
+		b.out.Write(enc)
+		b.rest = append([]byte(nil), data[m:]...)
+	}
+	return b.out.Read(p)
+}
+
+func newResponse(statusCode int) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}
+}
+
+// Middleware translates the gRPC-Web requests to the gRPC ones, the trailers
+// of the responses are sent in the trailer frames.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			contentType := req.Header.Get("Content-Type")
+			web, text := isWeb(contentType)
+			if !web {
+				return next.RoundTrip(req)
+			}
+			if text {
+				data, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				if data, err = decodeText(data); err != nil {
+					return newResponse(http.StatusBadRequest), nil
+				}
+				req.Body = ioutil.NopCloser(bytes.NewReader(data))
+				req.ContentLength = int64(len(data))
+				contentType = _contentTypeGRPC + strings.TrimPrefix(contentType, _contentTypeWebText)
+			} else {
+				contentType = _contentTypeGRPC + strings.TrimPrefix(contentType, _contentTypeWeb)
+			}
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Te", "trailers")
+			req.Header.Del("Content-Length")
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			webType := _contentTypeWeb
+			if text {
+				webType = _contentTypeWebText
+			}
+			if ct := resp.Header.Get("Content-Type"); strings.HasPrefix(ct, _contentTypeGRPC) {
+				resp.Header.Set("Content-Type", webType+strings.TrimPrefix(ct, _contentTypeGRPC))
+			}
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			if resp.Body == nil {
+				resp.Body = http.NoBody
+			}
+			var body io.ReadCloser = &webBody{ReadCloser: resp.Body, resp: resp}
+			if text {
+				body = &textBody{ReadCloser: body}
+			}
+			resp.Body = body
+			return resp, nil
+		})
+	}, nil
+}
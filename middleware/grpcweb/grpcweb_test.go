@@ -0,0 +1,111 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+)
+
+func frame(data string) []byte {
+	return append([]byte{0, 0, 0, 0, byte(len(data))}, data...)
+}
+
+func TestGRPCWeb(t *testing.T) {
+	m, err := Middleware(&config.Middleware{Name: "grpcweb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream *http.Request
+	var upstreamBody []byte
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req
+		upstreamBody, _ = ioutil.ReadAll(req.Body)
+		header := http.Header{"Content-Type": {"application/grpc+proto"}}
+		if req.URL.Path == "/trailers-only" {
+			header.Set("Grpc-Status", "5")
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Trailer:    http.Header{"Grpc-Status": {"0"}, "Grpc-Message": {"ok"}},
+			Body:       ioutil.NopCloser(bytes.NewReader(frame("reply"))),
+		}, nil
+	}))
+	trailer := append([]byte{0x80, 0, 0, 0, 34}, "grpc-message: ok\r\ngrpc-status: 0\r\n"...)
+	want := append(frame("reply"), trailer...)
+
+	req := httptest.NewRequest("POST", "/helloworld.Greeter/SayHello", bytes.NewReader(frame("hello")))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if upstream.Header.Get("Content-Type") != "application/grpc+proto" || upstream.Header.Get("Te") != "trailers" || !bytes.Equal(upstreamBody, frame("hello")) {
+		t.Fatalf("want the gRPC request but got %v %q", upstream.Header, upstreamBody)
+	}
+	if resp.Header.Get("Content-Type") != "application/grpc-web+proto" || !bytes.Equal(body, want) || resp.Trailer != nil {
+		t.Fatalf("want the gRPC-Web response but got %v %q %v", resp.Header, body, resp.Trailer)
+	}
+
+	// the text request of the padded chunks.
+	text := base64.StdEncoding.EncodeToString(frame("he")) + base64.StdEncoding.EncodeToString([]byte("llo"))
+	req = httptest.NewRequest("POST", "/helloworld.Greeter/SayHello", strings.NewReader(text))
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	if resp, err = next.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	if upstream.Header.Get("Content-Type") != "application/grpc" || !bytes.Equal(upstreamBody, append(frame("he"), "llo"...)) {
+		t.Fatalf("want the decoded request but got %v %q", upstream.Header, upstreamBody)
+	}
+	if resp.Header.Get("Content-Type") != "application/grpc-web-text+proto" || string(body) != base64.StdEncoding.EncodeToString(want) {
+		t.Fatalf("want the text response but got %v %s", resp.Header, body)
+	}
+
+	req = httptest.NewRequest("POST", "/trailers-only", bytes.NewReader(frame("hello")))
+	req.Header.Set("Content-Type", "application/grpc-web")
+	if resp, err = next.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	if resp.Header.Get("Grpc-Status") != "5" || len(body) != 0 {
+		t.Fatalf("want the trailers-only response but got %v %q", resp.Header, body)
+	}
+
+	req = httptest.NewRequest("POST", "/helloworld.Greeter/SayHello", strings.NewReader("!!"))
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	if resp, err = next.RoundTrip(req); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("want 400 of the invalid text but got %v %v", resp, err)
+	}
+
+	upstream = nil
+	req = httptest.NewRequest("POST", "/helloworld.Greeter/SayHello", bytes.NewReader(frame("hello")))
+	req.Header.Set("Content-Type", "application/grpc")
+	if _, err = next.RoundTrip(req); err != nil || upstream.Header.Get("Te") != "" {
+		t.Fatalf("want the gRPC request passed as is but got %v", err)
+	}
+}
+
+func TestTextBody(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	for _, n := range []int{0, 1, 2, 3, 3071, 3072, 3073, len(data)} {
+		b := &textBody{ReadCloser: ioutil.NopCloser(iotest.HalfReader(bytes.NewReader(data[:n])))}
+		out, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := base64.StdEncoding.EncodeToString(data[:n]); string(out) != want {
+			t.Errorf("%d: want %s but got %s", n, want, out)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package headers
+
+import (
+	"net/http"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/headers/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func init() {
+	middleware.Register("headers", Middleware)
+}
+
+type header struct {
+	name  string
+	value template
+}
+
+// operations is the compiled operations of the headers.
+type operations struct {
+	remove      []string
+	set         []header
+	add         []header
+	setIfAbsent []header
+}
+
+func compileHeaders(in map[string]string, response bool) ([]header, error) {
+	out := make([]header, 0, len(in))
+	for name, value := range in {
+		t, err := parse(value, response)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, header{name: http.CanonicalHeaderKey(name), value: t})
+	}
+	return out, nil
+}
+
+func compile(c *v1.HeaderOperations, response bool) (*operations, error) {
+	if c == nil {
+		return nil, nil
+	}
+	ops := &operations{remove: c.Remove}
+	var err error
+	if ops.set, err = compileHeaders(c.Set, response); err != nil {
+		return nil, err
+	}
+	if ops.add, err = compileHeaders(c.Add, response); err != nil {
+		return nil, err
+	}
+	if ops.setIfAbsent, err = compileHeaders(c.SetIfAbsent, response); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// apply applies the operations to the headers, the values are expanded
+// before any change to refer to the original headers.
+func (ops *operations) apply(h http.Header, s *scope) {
+	expand := func(headers []header) []string {
+		values := make([]string, len(headers))
+		for i, e := range headers {
+			values[i] = e.value.expand(s)
+		}
+		return values
+	}
+	set, add, setIfAbsent := expand(ops.set), expand(ops.add), expand(ops.setIfAbsent)
+	for _, name := range ops.remove {
+		h.Del(name)
+	}
+	for i, e := range ops.set {
+		if set[i] == "" {
+			h.Del(e.name)
+			continue
+		}
+		h.Set(e.name, set[i])
+	}
+	for i, e := range ops.add {
+		if add[i] != "" {
+			h.Add(e.name, add[i])
+		}
+	}
+	for i, e := range ops.setIfAbsent {
+		if _, ok := h[e.name]; !ok && setIfAbsent[i] != "" {
+			h.Set(e.name, setIfAbsent[i])
+		}
+	}
+}
+
+// Middleware adds, sets and removes the headers of the requests and the
+// responses by the templates.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Headers{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	request, err := compile(options.Request, false)
+	if err != nil {
+		return nil, err
+	}
+	response, err := compile(options.Response, true)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			s := &scope{req: req, start: time.Now()}
+			s.reqOpt, _ = middleware.FromRequestContext(req.Context())
+			if request != nil {
+				request.apply(req.Header, s)
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || response == nil {
+				return resp, err
+			}
+			s.resp = resp
+			response.apply(resp.Header, s)
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,103 @@
+package headers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/headers/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestHeaders(t *testing.T) {
+	any, err := anypb.New(&v1.Headers{
+		Request: &v1.HeaderOperations{
+			Remove: []string{"X-Debug"},
+			Set: map[string]string{
+				"X-Client":  "%DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT%",
+				"X-User-Id": "%CLAIM(sub)%",
+				"X-Roles":   "%CLAIM(realm.roles)%",
+				"X-Tenant":  "%PATH_VAR(tenant)%",
+				"X-Route":   "route=%ROUTE% 100%%",
+				"X-Ghost":   "%CLAIM(missing)%",
+			},
+			Add:         map[string]string{"X-Via": "gateway %REQ(:method)% %REQ(:path)%"},
+			SetIfAbsent: map[string]string{"X-Request-Id": "generated", "X-Origin": "%REQ(Origin)%"},
+		},
+		Response: &v1.HeaderOperations{
+			Remove: []string{"Server"},
+			Set:    map[string]string{"X-Upstream": "%UPSTREAM_REMOTE_ADDRESS% %RESPONSE_CODE% %RESP(X-Version)%"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "headers", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+			reqOpt.Backends = append(reqOpt.Backends, "10.0.0.1:8000")
+		}
+		header := http.Header{"Server": {"nginx"}, "X-Version": {"v2"}}
+		return &http.Response{StatusCode: http.StatusCreated, Header: header, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	req := httptest.NewRequest("POST", "/tenants/acme/users?page=2", nil)
+	req.RemoteAddr = "1.2.3.4:5678"
+	req.Header.Set("X-Debug", "1")
+	req.Header.Set("X-Request-Id", "abc")
+	req.Header.Set("X-Ghost", "spoofed")
+	req.Header.Add("X-Via", "client")
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/tenants/{tenant}/users"})
+	reqOpt.PathVars = map[string]string{"tenant": "acme"}
+	reqOpt.Claims = map[string]interface{}{"sub": "alice", "realm": map[string]interface{}{"roles": []interface{}{"admin", "dev"}}}
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"X-Debug":      "",
+		"X-Client":     "1.2.3.4",
+		"X-User-Id":    "alice",
+		"X-Roles":      "admin,dev",
+		"X-Tenant":     "acme",
+		"X-Route":      "route=/tenants/{tenant}/users 100%",
+		"X-Ghost":      "",
+		"X-Request-Id": "abc",
+		"X-Origin":     "",
+	} {
+		if got := upstream.Get(name); got != want {
+			t.Errorf("%s: want %q but got %q", name, want, got)
+		}
+	}
+	if via := upstream.Values("X-Via"); len(via) != 2 || via[1] != "gateway POST /tenants/acme/users?page=2" {
+		t.Errorf("want the header added but got %v", via)
+	}
+	if resp.Header.Get("Server") != "" || resp.Header.Get("X-Upstream") != "10.0.0.1:8000 201 v2" {
+		t.Errorf("want the response headers rewritten but got %v", resp.Header)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, value := range []string{"plain", "", "%%", "a%HOSTNAME%b", "%REQ(Host)%"} {
+		if _, err := parse(value, false); err != nil {
+			t.Errorf("%q: %v", value, err)
+		}
+	}
+	for _, value := range []string{"%UNKNOWN%", "%REQ%", "%REQ()%", "100%", "%RESPONSE_CODE%", "%RESP(X-Version)%", "%FOO(bar)%"} {
+		if _, err := parse(value, false); err == nil {
+			t.Errorf("%q: want error", value)
+		}
+	}
+	if _, err := parse("%RESPONSE_CODE%", true); err != nil {
+		t.Errorf("want the response variable of the response: %v", err)
+	}
+}
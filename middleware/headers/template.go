@@ -0,0 +1,209 @@
+package headers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+)
+
+// scope is the values of the templates, the response is nil for the request
+// headers.
+type scope struct {
+	req    *http.Request
+	reqOpt *middleware.RequestOptions
+	resp   *http.Response
+	start  time.Time
+}
+
+type variable func(s *scope) string
+
+// template is the literals and the variables of the header value.
+type template []variable
+
+func (t template) expand(s *scope) string {
+	if len(t) == 1 {
+		return t[0](s)
+	}
+	var b strings.Builder
+	for _, v := range t {
+		b.WriteString(v(s))
+	}
+	return b.String()
+}
+
+func literal(s string) variable {
+	return func(*scope) string { return s }
+}
+
+var _hostname, _ = os.Hostname()
+
+var _variables = map[string]variable{
+	"DOWNSTREAM_REMOTE_ADDRESS": func(s *scope) string { return s.req.RemoteAddr },
+	"DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT": func(s *scope) string {
+		host, _, err := net.SplitHostPort(s.req.RemoteAddr)
+		if err != nil {
+			return s.req.RemoteAddr
+		}
+		return host
+	},
+	"DOWNSTREAM_LOCAL_ADDRESS": func(s *scope) string {
+		if addr, ok := s.req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			return addr.String()
+		}
+		return ""
+	},
+	"CLIENT_IP": func(s *scope) string {
+		if ip := clientip.FromRequest(s.req); ip != nil {
+			return ip.String()
+		}
+		return ""
+	},
+	"PROTOCOL":   func(s *scope) string { return s.req.Proto },
+	"START_TIME": func(s *scope) string { return s.start.UTC().Format(time.RFC3339Nano) },
+	"HOSTNAME":   func(s *scope) string { return _hostname },
+	"ROUTE": func(s *scope) string {
+		if s.reqOpt != nil {
+			return s.reqOpt.Endpoint.GetPath()
+		}
+		return ""
+	},
+	"CONSUMER": func(s *scope) string {
+		if s.reqOpt != nil && s.reqOpt.Consumer != nil {
+			return s.reqOpt.Consumer.Name
+		}
+		return ""
+	},
+}
+
+var _responseVariables = map[string]variable{
+	"RESPONSE_CODE": func(s *scope) string { return strconv.Itoa(s.resp.StatusCode) },
+	"UPSTREAM_REMOTE_ADDRESS": func(s *scope) string {
+		if s.reqOpt != nil && len(s.reqOpt.Backends) > 0 {
+			return s.reqOpt.Backends[len(s.reqOpt.Backends)-1]
+		}
+		return ""
+	},
+}
+
+func requestHeader(name string) variable {
+	switch name {
+	case ":method":
+		return func(s *scope) string { return s.req.Method }
+	case ":path":
+		return func(s *scope) string { return s.req.URL.RequestURI() }
+	case ":authority":
+		return func(s *scope) string { return s.req.Host }
+	case ":scheme":
+		return func(s *scope) string {
+			if s.req.URL.Scheme != "" {
+				return s.req.URL.Scheme
+			}
+			if s.req.TLS != nil {
+				return "https"
+			}
+			return "http"
+		}
+	}
+	return func(s *scope) string { return s.req.Header.Get(name) }
+}
+
+func pathVar(name string) variable {
+	return func(s *scope) string {
+		if s.reqOpt != nil {
+			return s.reqOpt.PathVars[name]
+		}
+		return ""
+	}
+}
+
+func claim(name string) variable {
+	return func(s *scope) string {
+		if s.reqOpt == nil || s.reqOpt.Claims == nil {
+			return ""
+		}
+		if v, ok := middleware.LookupClaim(s.reqOpt.Claims, name); ok {
+			return middleware.FormatClaim(v)
+		}
+		return ""
+	}
+}
+
+// lookup returns the variable of the name, e.g. REQ(X-Request-Id).
+func lookup(name string, response bool) (variable, error) {
+	if v, ok := _variables[name]; ok {
+		return v, nil
+	}
+	if v, ok := _responseVariables[name]; ok {
+		if !response {
+			return nil, fmt.Errorf("variable %%%s%% is only of the response", name)
+		}
+		return v, nil
+	}
+	i := strings.IndexByte(name, '(')
+	if i <= 0 || !strings.HasSuffix(name, ")") || i+2 >= len(name) {
+		return nil, fmt.Errorf("unknown variable %%%s%%", name)
+	}
+	fn, arg := name[:i], name[i+1:len(name)-1]
+	switch fn {
+	case "REQ":
+		return requestHeader(arg), nil
+	case "RESP":
+		if !response {
+			return nil, fmt.Errorf("variable %%%s%% is only of the response", name)
+		}
+		return func(s *scope) string { return s.resp.Header.Get(arg) }, nil
+	case "PATH_VAR":
+		return pathVar(arg), nil
+	case "CLAIM":
+		return claim(arg), nil
+	}
+	return nil, fmt.Errorf("unknown variable %%%s%%", name)
+}
+
+// parse parses the template of the %VARIABLE% and the literals, the %% is
+// the percent sign.
+func parse(value string, response bool) (template, error) {
+	var (
+		t   template
+		lit strings.Builder
+	)
+	for rest := value; rest != ""; {
+		i := strings.IndexByte(rest, '%')
+		if i < 0 {
+			lit.WriteString(rest)
+			break
+		}
+		lit.WriteString(rest[:i])
+		rest = rest[i+1:]
+		j := strings.IndexByte(rest, '%')
+		if j < 0 {
+			return nil, fmt.Errorf("invalid header template %q: unclosed variable", value)
+		}
+		if j == 0 {
+			lit.WriteByte('%')
+			rest = rest[1:]
+			continue
+		}
+		v, err := lookup(rest[:j], response)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header template %q: %w", value, err)
+		}
+		if lit.Len() > 0 {
+			t = append(t, literal(lit.String()))
+			lit.Reset()
+		}
+		t = append(t, v)
+		rest = rest[j+1:]
+	}
+	if lit.Len() > 0 || len(t) == 0 {
+		t = append(t, literal(lit.String()))
+	}
+	return t, nil
+}
@@ -0,0 +1,239 @@
+package hmac
+
+import (
+	"bytes"
+	stdhmac "crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/hmac/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultKeyIDHeader     = "X-Key-Id"
+	_defaultSignatureHeader = "X-Signature"
+	_defaultTimestampHeader = "X-Timestamp"
+	_defaultNonceHeader     = "X-Nonce"
+	_defaultMaxSkew         = 5 * time.Minute
+	// the metadata key of the verified request, the retries are not verified
+	// again as the nonce is seen.
+	_verifiedKey = "hmac.verified"
+)
+
+func init() {
+	middleware.Register("hmac", Middleware)
+}
+
+// nonces remembers the nonces until they're expired by the timestamp skew.
+type nonces struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	seen    map[string]time.Time
+	sweptAt time.Time
+}
+
+// add reports whether the nonce is not seen.
+func (n *nonces) add(nonce string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if now.Sub(n.sweptAt) >= n.ttl {
+		for k, expiry := range n.seen {
+			if now.After(expiry) {
+				delete(n.seen, k)
+			}
+		}
+		n.sweptAt = now
+	}
+	if expiry, ok := n.seen[nonce]; ok && !now.After(expiry) {
+		return false
+	}
+	n.seen[nonce] = now.Add(n.ttl)
+	return true
+}
+
+type verifier struct {
+	options         *v1.HMAC
+	secrets         map[string]*v1.Credential
+	hash            func() hash.Hash
+	keyIDHeader     string
+	signatureHeader string
+	timestampHeader string
+	nonceHeader     string
+	maxSkew         time.Duration
+	nonces          *nonces
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// canonical returns the canonical request signed by the client.
+func (v *verifier) canonical(req *http.Request, timestamp, nonce string, body []byte) string {
+	if v.options.Canonicalization == v1.HMAC_TIMESTAMP_BODY {
+		return timestamp + "." + string(body)
+	}
+	var b strings.Builder
+	b.WriteString(req.Method + "\n")
+	b.WriteString(req.URL.EscapedPath() + "\n")
+	b.WriteString(req.URL.Query().Encode() + "\n")
+	b.WriteString(timestamp + "\n")
+	b.WriteString(nonce + "\n")
+	for _, name := range v.options.SignedHeaders {
+		value := req.Header.Get(name)
+		if strings.EqualFold(name, "Host") {
+			value = req.Host
+		}
+		b.WriteString(strings.ToLower(name) + ":" + strings.TrimSpace(value) + "\n")
+	}
+	sum := sha256.Sum256(body)
+	b.WriteString(hex.EncodeToString(sum[:]))
+	return b.String()
+}
+
+// verify verifies the signature of the request, and returns the credential of
+// the key id.
+func (v *verifier) verify(req *http.Request) (*v1.Credential, error) {
+	cred, ok := v.secrets[req.Header.Get(v.keyIDHeader)]
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	signature, err := hex.DecodeString(req.Header.Get(v.signatureHeader))
+	if err != nil || len(signature) == 0 {
+		return nil, errors.New("malformed signature")
+	}
+	timestamp := req.Header.Get(v.timestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("malformed timestamp")
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(unix, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return nil, fmt.Errorf("timestamp skewed by %s", skew)
+	}
+	nonce := req.Header.Get(v.nonceHeader)
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	mac := stdhmac.New(v.hash, []byte(cred.Secret))
+	_, _ = mac.Write([]byte(v.canonical(req, timestamp, nonce, body)))
+	if !stdhmac.Equal(mac.Sum(nil), signature) {
+		return nil, errors.New("invalid signature")
+	}
+	if nonce == "" {
+		nonce = hex.EncodeToString(signature)
+	}
+	if !v.nonces.add(cred.KeyId+"\n"+nonce, now) {
+		return nil, errors.New("replayed nonce")
+	}
+	return cred, nil
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware verifies the HMAC signatures of the requests.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.HMAC{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	v := &verifier{
+		options:         options,
+		secrets:         make(map[string]*v1.Credential, len(options.Credentials)),
+		keyIDHeader:     orDefault(options.KeyIdHeader, _defaultKeyIDHeader),
+		signatureHeader: orDefault(options.SignatureHeader, _defaultSignatureHeader),
+		timestampHeader: orDefault(options.TimestampHeader, _defaultTimestampHeader),
+		nonceHeader:     orDefault(options.NonceHeader, _defaultNonceHeader),
+		maxSkew:         _defaultMaxSkew,
+	}
+	switch strings.ToUpper(options.Algorithm) {
+	case "", "SHA256":
+		v.hash = sha256.New
+	case "SHA512":
+		v.hash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported hmac algorithm: %s", options.Algorithm)
+	}
+	if options.MaxSkew != nil {
+		v.maxSkew = options.MaxSkew.AsDuration()
+	}
+	v.nonces = &nonces{ttl: 2 * v.maxSkew, seen: make(map[string]time.Time)}
+	for i, cred := range options.Credentials {
+		if cred.KeyId == "" || cred.Secret == "" {
+			return nil, fmt.Errorf("hmac key id and secret of credentials[%d] are required", i)
+		}
+		v.secrets[cred.KeyId] = cred
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpt, ok := middleware.FromRequestContext(req.Context())
+			if ok && reqOpt.Metadata[_verifiedKey] != "" {
+				return next.RoundTrip(req)
+			}
+			cred, err := v.verify(req)
+			if err != nil {
+				audit.AuthenticationFailed(req, "hmac", http.StatusUnauthorized, err.Error())
+				return newResponse(http.StatusUnauthorized)
+			}
+			if cred.Consumer != "" {
+				if consumer, ok := middleware.GetConsumers().ByName(cred.Consumer); ok {
+					if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+						audit.PolicyDenied(req, "hmac", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+						return newResponse(http.StatusForbidden)
+					}
+					middleware.WithConsumer(req.Context(), consumer)
+				}
+			}
+			if ok {
+				reqOpt.Metadata[_verifiedKey] = cred.KeyId
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
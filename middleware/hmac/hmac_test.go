@@ -0,0 +1,129 @@
+package hmac
+
+import (
+	"bytes"
+	stdhmac "crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/hmac/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newMiddleware(t *testing.T, options *v1.HMAC) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "hmac", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+	}))
+}
+
+func sign(secret, canonical string) string {
+	mac := stdhmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func do(t *testing.T, rt http.RoundTripper, req *http.Request, reqOpt *middleware.RequestOptions) *http.Response {
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHMACRequest(t *testing.T) {
+	middleware.SetConsumers([]*config.Consumer{{Name: "partner"}})
+	defer middleware.SetConsumers(nil)
+	rt := newMiddleware(t, &v1.HMAC{
+		Credentials:   []*v1.Credential{{KeyId: "partner-1", Secret: "secret", Consumer: "partner"}},
+		SignedHeaders: []string{"Host", "Content-Type"},
+	})
+	body := `{"amount":100}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	newRequest := func(nonce, signature string) *http.Request {
+		req := httptest.NewRequest("POST", "http://api.example.com/v1/orders?b=2&a=1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(_defaultKeyIDHeader, "partner-1")
+		req.Header.Set(_defaultTimestampHeader, timestamp)
+		req.Header.Set(_defaultNonceHeader, nonce)
+		req.Header.Set(_defaultSignatureHeader, signature)
+		return req
+	}
+	bodySum := sha256.Sum256([]byte(body))
+	canonical := func(nonce string) string {
+		return "POST\n/v1/orders\na=1&b=2\n" + timestamp + "\n" + nonce + "\nhost:api.example.com\ncontent-type:application/json\n" + hex.EncodeToString(bodySum[:])
+	}
+
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/v1/*"})
+	resp := do(t, rt, newRequest("n-1", sign("secret", canonical("n-1"))), reqOpt)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %d", resp.StatusCode)
+	}
+	if data, _ := ioutil.ReadAll(resp.Body); string(data) != body {
+		t.Fatalf("want the body kept for the upstream but got %q", data)
+	}
+	if reqOpt.Consumer == nil || reqOpt.Consumer.Name != "partner" {
+		t.Fatalf("want the consumer of the key id but got %v", reqOpt.Consumer)
+	}
+	// the retry of the verified request is not a replay.
+	if resp := do(t, rt, newRequest("n-1", sign("secret", canonical("n-1"))), reqOpt); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 of the retry but got %d", resp.StatusCode)
+	}
+
+	tests := map[string]*http.Request{
+		"replayed":    newRequest("n-1", sign("secret", canonical("n-1"))),
+		"wrong nonce": newRequest("n-2", sign("secret", canonical("n-1"))),
+		"wrong key":   newRequest("n-3", sign("wrong", canonical("n-3"))),
+		"malformed":   newRequest("n-4", "not-hex"),
+	}
+	skewed := newRequest("n-5", "")
+	skewed.Header.Set(_defaultTimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	tests["skewed"] = skewed
+	unknown := newRequest("n-6", sign("secret", canonical("n-6")))
+	unknown.Header.Set(_defaultKeyIDHeader, "unknown")
+	tests["unknown key id"] = unknown
+	for name, req := range tests {
+		if resp := do(t, rt, req, middleware.NewRequestOptions(&config.Endpoint{Path: "/v1/*"})); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: want 401 but got %d", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestHMACTimestampBody(t *testing.T) {
+	rt := newMiddleware(t, &v1.HMAC{
+		Credentials:      []*v1.Credential{{KeyId: "partner-1", Secret: "secret"}},
+		Canonicalization: v1.HMAC_TIMESTAMP_BODY,
+	})
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString("event"))
+		req.Header.Set(_defaultKeyIDHeader, "partner-1")
+		req.Header.Set(_defaultTimestampHeader, timestamp)
+		req.Header.Set(_defaultSignatureHeader, sign("secret", timestamp+".event"))
+		return req
+	}
+	if resp := do(t, rt, newRequest(), middleware.NewRequestOptions(&config.Endpoint{})); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %d", resp.StatusCode)
+	}
+	// the signature is the nonce without the nonce header.
+	if resp := do(t, rt, newRequest(), middleware.NewRequestOptions(&config.Endpoint{})); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("want 401 of the replayed signature but got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,244 @@
+package introspection
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/introspection/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultCacheTTL  = time.Minute
+	_defaultCacheSize = 10000
+	_defaultTimeout   = 5 * time.Second
+	_bearerPrefix     = "Bearer "
+)
+
+func init() {
+	middleware.Register("introspection", Middleware)
+}
+
+type result struct {
+	active bool
+	claims map[string]interface{}
+	expiry time.Time
+}
+
+// cache is the introspection results by the hash of the tokens.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	results map[[sha256.Size]byte]*result
+}
+
+func newCache(ttl time.Duration, size int) *cache {
+	return &cache{ttl: ttl, size: size, results: make(map[[sha256.Size]byte]*result)}
+}
+
+func (c *cache) get(key [sha256.Size]byte) (*result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.results[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(r.expiry) {
+		delete(c.results, key)
+		return nil, false
+	}
+	return r, true
+}
+
+func (c *cache) set(key [sha256.Size]byte, r *result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.results) >= c.size {
+		now := time.Now()
+		for k, v := range c.results {
+			if now.After(v.expiry) {
+				delete(c.results, k)
+			}
+		}
+		// evicts the arbitrary results if there are not enough expired ones.
+		for k := range c.results {
+			if len(c.results) < c.size {
+				break
+			}
+			delete(c.results, k)
+		}
+	}
+	c.results[key] = r
+}
+
+type introspector struct {
+	options *v1.Introspection
+	client  *http.Client
+	cache   *cache
+}
+
+// introspect returns the introspection result of the token by cache or the
+// authorization server.
+func (i *introspector) introspect(ctx context.Context, token string) (*result, error) {
+	key := sha256.Sum256([]byte(token))
+	if r, ok := i.cache.get(key); ok {
+		return r, nil
+	}
+	req, err := http.NewRequest(http.MethodPost, i.options.Url, strings.NewReader(url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if i.options.ClientId != "" {
+		req.SetBasicAuth(url.QueryEscape(i.options.ClientId), url.QueryEscape(i.options.ClientSecret))
+	}
+	resp, err := i.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code of introspection: %d", resp.StatusCode)
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, err
+	}
+	r := &result{claims: claims, expiry: time.Now().Add(i.cache.ttl)}
+	r.active, _ = claims["active"].(bool)
+	if exp, ok := claims["exp"].(float64); ok {
+		expiry := time.Unix(int64(exp), 0)
+		if expiry.Before(r.expiry) {
+			r.expiry = expiry
+		}
+		if time.Now().After(expiry) {
+			r.active = false
+		}
+	}
+	i.cache.set(key, r)
+	return r, nil
+}
+
+// missingScopes returns the required scopes not granted to the token.
+func (i *introspector) missingScopes(claims map[string]interface{}) []string {
+	scope, _ := claims["scope"].(string)
+	granted := make(map[string]bool)
+	for _, s := range strings.Fields(scope) {
+		granted[s] = true
+	}
+	var missing []string
+	for _, s := range i.options.RequiredScopes {
+		if !granted[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+func newResponse(statusCode int, authenticate string) (*http.Response, error) {
+	header := http.Header{}
+	if authenticate != "" {
+		header.Set("WWW-Authenticate", authenticate)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware validates the opaque access tokens by the token introspection.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Introspection{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Url == "" {
+		return nil, errors.New("introspection url is required")
+	}
+	ttl, size, timeout := _defaultCacheTTL, _defaultCacheSize, _defaultTimeout
+	if options.CacheTtl != nil {
+		ttl = options.CacheTtl.AsDuration()
+	}
+	if options.CacheSize > 0 {
+		size = int(options.CacheSize)
+	}
+	if options.Timeout != nil {
+		timeout = options.Timeout.AsDuration()
+	}
+	i := &introspector{
+		options: options,
+		client:  &http.Client{Timeout: timeout},
+		cache:   newCache(ttl, size),
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			token := req.Header.Get("Authorization")
+			if len(token) <= len(_bearerPrefix) || !strings.EqualFold(token[:len(_bearerPrefix)], _bearerPrefix) {
+				audit.AuthenticationFailed(req, "introspection", http.StatusUnauthorized, "bearer token is required")
+				return newResponse(http.StatusUnauthorized, `Bearer error="invalid_request"`)
+			}
+			r, err := i.introspect(req.Context(), token[len(_bearerPrefix):])
+			if err != nil {
+				log.Errorf("Failed to introspect token by %s: %+v", options.Url, err)
+				return newResponse(http.StatusBadGateway, "")
+			}
+			if !r.active {
+				audit.AuthenticationFailed(req, "introspection", http.StatusUnauthorized, "token is inactive")
+				return newResponse(http.StatusUnauthorized, `Bearer error="invalid_token"`)
+			}
+			if missing := i.missingScopes(r.claims); len(missing) > 0 {
+				sub, _ := r.claims["sub"].(string)
+				audit.PolicyDenied(req, "introspection", sub, http.StatusForbidden, "scopes are missing: "+strings.Join(missing, " "))
+				return newResponse(http.StatusForbidden, fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(options.RequiredScopes, " ")))
+			}
+			middleware.WithClaims(req.Context(), r.claims)
+			if sub, ok := r.claims["sub"].(string); ok {
+				if consumer, ok := middleware.GetConsumers().BySubject(sub); ok {
+					if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+						audit.PolicyDenied(req, "introspection", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+						return newResponse(http.StatusForbidden, "")
+					}
+					middleware.WithConsumer(req.Context(), consumer)
+				}
+			}
+			if !options.Forward {
+				req.Header.Del("Authorization")
+			}
+			for name, key := range options.ClaimHeaders {
+				// the headers are always overwritten to be trusted by the upstream.
+				req.Header.Del(key)
+				if claim, ok := middleware.LookupClaim(r.claims, name); ok {
+					req.Header.Set(key, middleware.FormatClaim(claim))
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,113 @@
+package introspection
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/introspection/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestIntrospection(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "gateway" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp := map[string]interface{}{"active": false}
+		switch r.PostFormValue("token") {
+		case "reader":
+			resp = map[string]interface{}{"active": true, "sub": "alice", "scope": "orders:read", "exp": time.Now().Add(time.Hour).Unix()}
+		case "writer":
+			resp = map[string]interface{}{"active": true, "sub": "bob", "client_id": "web", "scope": "orders:read orders:write"}
+		case "expired":
+			resp = map[string]interface{}{"active": true, "sub": "carol", "scope": "orders:write", "exp": time.Now().Add(-time.Minute).Unix()}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	any, err := anypb.New(&v1.Introspection{
+		Url:            srv.URL,
+		ClientId:       "gateway",
+		ClientSecret:   "secret",
+		RequiredScopes: []string{"orders:write"},
+		ClaimHeaders:   map[string]string{"sub": "X-User-Id", "client_id": "X-Client-Id"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "introspection", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	do := func(token string) *http.Response {
+		req := httptest.NewRequest("POST", "/orders", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("X-Client-Id", "forged")
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(&config.Endpoint{Path: "/orders"})))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	for i := 0; i < 3; i++ {
+		if resp := do("writer"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("want 200 but got %d", resp.StatusCode)
+		}
+	}
+	if upstream.Get("X-User-Id") != "bob" || upstream.Get("X-Client-Id") != "web" || upstream.Get("Authorization") != "" {
+		t.Fatalf("unexpected upstream headers: %v", upstream)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("want the result cached but introspected %d times", n)
+	}
+	resp := do("reader")
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get("WWW-Authenticate") != `Bearer error="insufficient_scope", scope="orders:write"` {
+		t.Fatalf("want 403 of the insufficient scope but got %d %s", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+	for _, token := range []string{"", "unknown", "expired", "unknown"} {
+		if resp := do(token); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("want 401 of the token %q but got %d", token, resp.StatusCode)
+		}
+	}
+	// the inactive result is cached either.
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Fatalf("want 4 introspections but got %d", n)
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := newCache(time.Minute, 2)
+	keys := [][sha256.Size]byte{sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b")), sha256.Sum256([]byte("c"))}
+	c.set(keys[0], &result{active: true, expiry: time.Now().Add(-time.Second)})
+	if _, ok := c.get(keys[0]); ok {
+		t.Fatal("want the expired result evicted")
+	}
+	for _, key := range keys {
+		c.set(key, &result{active: true, expiry: time.Now().Add(time.Minute)})
+	}
+	if len(c.results) != 2 {
+		t.Fatalf("want the size of the cache bounded but got %d", len(c.results))
+	}
+	if _, ok := c.get(keys[2]); !ok {
+		t.Fatal("want the last result cached")
+	}
+}
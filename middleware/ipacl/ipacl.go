@@ -0,0 +1,194 @@
+package ipacl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/ipacl/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// the list files are checked at most once in the interval.
+const _checkInterval = 5 * time.Second
+
+func init() {
+	middleware.Register("ipacl", Middleware)
+}
+
+func parseList(data []byte) ([]*net.IPNet, error) {
+	var cidrs []*net.IPNet
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		cidr, err := clientip.ParseCIDR(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR of line %d: %v", n, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, scanner.Err()
+}
+
+// listFile is the CIDRs loaded from the file, it's reloaded once the
+// modification time is changed, the last CIDRs are kept if failed to reload.
+type listFile struct {
+	path string
+
+	mu        sync.Mutex
+	cidrs     []*net.IPNet
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (f *listFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	cidrs, err := parseList(data)
+	if err != nil {
+		return err
+	}
+	f.cidrs = cidrs
+	f.modTime = info.ModTime()
+	return nil
+}
+
+func (f *listFile) contains(ip net.IP) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.Sub(f.checkedAt) >= _checkInterval {
+		f.checkedAt = now
+		if err := f.load(); err != nil {
+			log.Errorf("Failed to load the IP list from %s: %+v", f.path, err)
+		}
+	}
+	return clientip.Contains(f.cidrs, ip)
+}
+
+// list is the inline CIDRs and the files of the CIDRs.
+type list struct {
+	cidrs []*net.IPNet
+	files []*listFile
+}
+
+func newList(cidrs, paths []string) (*list, error) {
+	l := &list{}
+	var err error
+	if l.cidrs, err = clientip.ParseCIDRs(cidrs); err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		f := &listFile{path: path, checkedAt: time.Now()}
+		if err := f.load(); err != nil {
+			return nil, err
+		}
+		l.files = append(l.files, f)
+	}
+	return l, nil
+}
+
+func (l *list) empty() bool {
+	return len(l.cidrs) == 0 && len(l.files) == 0
+}
+
+func (l *list) contains(ip net.IP) bool {
+	if clientip.Contains(l.cidrs, ip) {
+		return true
+	}
+	for _, f := range l.files {
+		if f.contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// acl reports whether the client IP is allowed.
+type acl struct {
+	allow *list
+	deny  *list
+}
+
+func (a *acl) allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if a.deny.contains(ip) {
+		return false
+	}
+	return a.allow.empty() || a.allow.contains(ip)
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware rejects the requests by the allow and deny lists of the client IPs.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.IPACL{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	allow, err := newList(options.Allow, options.AllowFiles)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := newList(options.Deny, options.DenyFiles)
+	if err != nil {
+		return nil, err
+	}
+	a := &acl{allow: allow, deny: deny}
+	clientIP := clientip.FromRequest
+	if len(options.TrustedProxies) > 0 {
+		proxies, err := clientip.ParseCIDRs(options.TrustedProxies)
+		if err != nil {
+			return nil, err
+		}
+		clientIP = func(req *http.Request) net.IP {
+			return clientip.FromRequestWithProxies(req, proxies)
+		}
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !a.allowed(clientIP(req)) {
+				audit.PolicyDenied(req, "ipacl", "", http.StatusForbidden, "client ip is not allowed")
+				return newResponse(http.StatusForbidden)
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
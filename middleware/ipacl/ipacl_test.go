@@ -0,0 +1,115 @@
+package ipacl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/ipacl/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestIPACL(t *testing.T) {
+	dir := t.TempDir()
+	allowFile := filepath.Join(dir, "allow")
+	if err := ioutil.WriteFile(allowFile, []byte("# office\n192.168.0.0/16\n172.16.0.1 # vpn\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	any, err := anypb.New(&v1.IPACL{
+		Allow:          []string{"10.0.0.0/8"},
+		Deny:           []string{"10.0.0.100"},
+		AllowFiles:     []string{allowFile},
+		TrustedProxies: []string{"127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "ipacl", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func(remoteAddr, xff string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	testCases := []struct {
+		remoteAddr string
+		xff        string
+		want       int
+	}{
+		{"10.0.0.1:80", "", http.StatusOK},
+		{"10.0.0.100:80", "", http.StatusForbidden},
+		{"192.168.1.1:80", "", http.StatusOK},
+		{"172.16.0.1:80", "", http.StatusOK},
+		{"8.8.8.8:80", "", http.StatusForbidden},
+		{"127.0.0.1:80", "10.0.0.2", http.StatusOK},
+		{"127.0.0.1:80", "10.0.0.100", http.StatusForbidden},
+		{"8.8.8.8:80", "10.0.0.2", http.StatusForbidden},
+		{"invalid", "", http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		if got := do(tc.remoteAddr, tc.xff); got != tc.want {
+			t.Errorf("%s %s: want %d but got %d", tc.remoteAddr, tc.xff, tc.want, got)
+		}
+	}
+
+}
+
+func TestListFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny")
+	if err := ioutil.WriteFile(path, []byte("1.1.1.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := &listFile{path: path, checkedAt: time.Now()}
+	if err := f.load(); err != nil {
+		t.Fatal(err)
+	}
+	ip, other := net.ParseIP("1.1.1.1"), net.ParseIP("2.2.2.2")
+	if !f.contains(ip) || f.contains(other) {
+		t.Fatal("want 1.1.1.1 in the list only")
+	}
+	if err := ioutil.WriteFile(path, []byte("2.2.2.0/24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if !f.contains(ip) {
+		t.Fatal("want the list not reloaded in the check interval")
+	}
+	f.checkedAt = time.Time{}
+	if f.contains(ip) || !f.contains(other) {
+		t.Fatal("want the list reloaded")
+	}
+	if err := ioutil.WriteFile(path, []byte("invalid\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime = modTime.Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	f.checkedAt = time.Time{}
+	if !f.contains(other) {
+		t.Fatal("want the last list kept if failed to reload")
+	}
+}
@@ -0,0 +1,189 @@
+package jsontransform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/jsontransform/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultMaxBodyBytes = 1 << 20
+
+func init() {
+	middleware.Register("jsontransform", Middleware)
+}
+
+type renaming struct {
+	from []string
+	to   []string
+}
+
+type transformer struct {
+	renames  []renaming
+	removes  [][]string
+	template node
+}
+
+func newTransformer(c *v1.BodyTransform) (*transformer, error) {
+	if c == nil {
+		return nil, nil
+	}
+	t := &transformer{}
+	for from, to := range c.Rename {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid json transform rename: %s to %s", from, to)
+		}
+		t.renames = append(t.renames, renaming{from: splitPath(from), to: splitPath(to)})
+	}
+	for _, path := range c.Remove {
+		if path == "" {
+			return nil, fmt.Errorf("invalid json transform remove: empty path")
+		}
+		t.removes = append(t.removes, splitPath(path))
+	}
+	if c.Template != "" {
+		n, err := compileTemplate(c.Template)
+		if err != nil {
+			return nil, err
+		}
+		t.template = n
+	}
+	return t, nil
+}
+
+func (t *transformer) transform(data []byte) ([]byte, error) {
+	body, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range t.renames {
+		rename(body, r.from, r.to)
+	}
+	for _, path := range t.removes {
+		remove(body, path)
+	}
+	if t.template != nil {
+		body, _ = t.template(body)
+	}
+	return json.Marshal(body)
+}
+
+func isJSON(h http.Header) bool {
+	if enc := h.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// readBody reads the body up to the max bytes, the body is restored and not ok
+// if it's larger, it's closed otherwise.
+func readBody(body io.ReadCloser, maxBytes int64) ([]byte, io.ReadCloser, bool, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		body.Close()
+		return nil, nil, false, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), body), body}, false, nil
+	}
+	body.Close()
+	return data, nil, true, nil
+}
+
+func (t *transformer) transformRequest(req *http.Request, maxBytes int64) error {
+	if req.Body == nil || req.Body == http.NoBody || !isJSON(req.Header) {
+		return nil
+	}
+	data, rest, ok, err := readBody(req.Body, maxBytes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		req.Body = rest
+		return nil
+	}
+	if out, err := t.transform(data); err == nil {
+		data = out
+	}
+	req.Header.Del("Content-Length")
+	req.ContentLength = int64(len(data))
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil
+}
+
+func (t *transformer) transformResponse(resp *http.Response, maxBytes int64) error {
+	if resp.Body == nil || resp.Body == http.NoBody || !isJSON(resp.Header) {
+		return nil
+	}
+	data, rest, ok, err := readBody(resp.Body, maxBytes)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		resp.Body = rest
+		return nil
+	}
+	if out, err := t.transform(data); err == nil {
+		data = out
+	}
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(data))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return nil
+}
+
+// Middleware reshapes the JSON bodies of the requests and the responses.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.JSONTransform{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	request, err := newTransformer(options.Request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := newTransformer(options.Response)
+	if err != nil {
+		return nil, err
+	}
+	maxBytes := int64(_defaultMaxBodyBytes)
+	if options.MaxBodyBytes > 0 {
+		maxBytes = options.MaxBodyBytes
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if request != nil {
+				if err := request.transformRequest(req, maxBytes); err != nil {
+					return nil, err
+				}
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || response == nil {
+				return resp, err
+			}
+			if err := response.transformResponse(resp, maxBytes); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,106 @@
+package jsontransform
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/jsontransform/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestTransform(t *testing.T) {
+	testCases := []struct {
+		transform *v1.BodyTransform
+		in        string
+		want      string
+	}{
+		{&v1.BodyTransform{Template: `{"code": 0, "data": "$"}`}, `{"id":1}`, `{"code":0,"data":{"id":1}}`},
+		{&v1.BodyTransform{Template: `"$.data"`}, `{"code":0,"data":{"id":1}}`, `{"id":1}`},
+		{&v1.BodyTransform{Template: `{"ids": "$.items[*].id", "first": "$.items[0].name", "last": "$.items[-1].name", "none": "$.missing", "tag": "$$vip"}`},
+			`{"items":[{"id":1,"name":"a"},{"id":2},{"name":"c"}]}`, `{"first":"a","ids":[1,2],"last":"c","tag":"$vip"}`},
+		{&v1.BodyTransform{Template: `{"last": "$.items[-1].id"}`}, `{"items":[{"id":1},{"id":2}]}`, `{"last":2}`},
+		{&v1.BodyTransform{Rename: map[string]string{"userName": "user.name", "old.id": "id"}, Remove: []string{"user.password", "old"}},
+			`{"userName":"alice","user":{"password":"secret"},"old":{"id":9007199254740993}}`, `{"id":9007199254740993,"user":{"name":"alice"}}`},
+		{&v1.BodyTransform{Rename: map[string]string{"a": "b.c"}}, `{"a":1,"b":2}`, `{"b":2}`},
+		{&v1.BodyTransform{Remove: []string{"a.b"}}, `[1,2]`, `[1,2]`},
+	}
+	for _, tc := range testCases {
+		tr, err := newTransformer(tc.transform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := tr.transform([]byte(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tc.want {
+			t.Errorf("%s: want %s but got %s", tc.in, tc.want, out)
+		}
+	}
+	for _, c := range []*v1.BodyTransform{
+		{Template: `{"a": "$.items[x]"}`},
+		{Template: `{"a": "$items"}`},
+		{Template: `{"a": "$.items[0"}`},
+		{Template: `{"a":`},
+		{Rename: map[string]string{"a": ""}},
+	} {
+		if _, err := newTransformer(c); err == nil {
+			t.Errorf("want error of %v", c)
+		}
+	}
+}
+
+func TestJSONTransform(t *testing.T) {
+	any, err := anypb.New(&v1.JSONTransform{
+		Request:      &v1.BodyTransform{Rename: map[string]string{"user_name": "userName"}},
+		Response:     &v1.BodyTransform{Template: `{"code": 0, "data": "$"}`},
+		MaxBodyBytes: 64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "jsontransform", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream []byte
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream, _ = ioutil.ReadAll(req.Body)
+		if int64(len(upstream)) != req.ContentLength {
+			t.Errorf("want the content length %d but got %d", len(upstream), req.ContentLength)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {req.Header.Get("Content-Type")}},
+			Body:       ioutil.NopCloser(bytes.NewReader(upstream)),
+		}, nil
+	}))
+	do := func(contentType, body string) string {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(resp.Body)
+		return string(data)
+	}
+	if got := do("application/json; charset=utf-8", `{"user_name":"alice"}`); string(upstream) != `{"userName":"alice"}` || got != `{"code":0,"data":{"userName":"alice"}}` {
+		t.Fatalf("want the bodies transformed but got %s %s", upstream, got)
+	}
+	for _, tc := range [][2]string{
+		{"text/plain", `{"user_name":"alice"}`},
+		{"application/json", `{"user_name":`},
+		{"application/json", `{"user_name":"` + strings.Repeat("a", 64) + `"}`},
+	} {
+		if got := do(tc[0], tc[1]); got != tc[1] {
+			t.Errorf("%s: want the body passed as is but got %s", tc[0], got)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package jsontransform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+type step struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+// parsePath parses the path of $, .name, [n] and [*], e.g. $.items[*].id.
+func parsePath(path string) ([]step, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid path %q: want the beginning of $", path)
+	}
+	var steps []step
+	for rest := path[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			i := strings.IndexAny(rest, ".[")
+			if i < 0 {
+				i = len(rest)
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty field", path)
+			}
+			steps = append(steps, step{kind: stepField, name: rest[:i]})
+			rest = rest[i:]
+		case '[':
+			i := strings.IndexByte(rest, ']')
+			if i < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed bracket", path)
+			}
+			if s := rest[1:i]; s == "*" {
+				steps = append(steps, step{kind: stepWildcard})
+			} else {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: invalid index %q", path, s)
+				}
+				steps = append(steps, step{kind: stepIndex, index: n})
+			}
+			rest = rest[i+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return steps, nil
+}
+
+// lookup returns the value of the steps, the wildcard returns the array of
+// the values of the following steps of the elements. The negative index is
+// counted from the end.
+func lookup(v interface{}, steps []step) (interface{}, bool) {
+	for i, s := range steps {
+		switch s.kind {
+		case stepField:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[s.name]; !ok {
+				return nil, false
+			}
+		case stepIndex:
+			a, ok := v.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			n := s.index
+			if n < 0 {
+				n += len(a)
+			}
+			if n < 0 || n >= len(a) {
+				return nil, false
+			}
+			v = a[n]
+		case stepWildcard:
+			a, ok := v.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			out := make([]interface{}, 0, len(a))
+			for _, e := range a {
+				if r, ok := lookup(e, steps[i+1:]); ok {
+					out = append(out, r)
+				}
+			}
+			return out, true
+		}
+	}
+	return v, true
+}
+
+// node renders the value of the template by the body, it's omitted if not ok.
+type node func(body interface{}) (interface{}, bool)
+
+func compileNode(v interface{}) (node, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		fields := make(map[string]node, len(v))
+		for k, e := range v {
+			n, err := compileNode(e)
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = n
+		}
+		return func(body interface{}) (interface{}, bool) {
+			out := make(map[string]interface{}, len(fields))
+			for k, n := range fields {
+				if r, ok := n(body); ok {
+					out[k] = r
+				}
+			}
+			return out, true
+		}, nil
+	case []interface{}:
+		elements := make([]node, 0, len(v))
+		for _, e := range v {
+			n, err := compileNode(e)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, n)
+		}
+		return func(body interface{}) (interface{}, bool) {
+			out := make([]interface{}, 0, len(elements))
+			for _, n := range elements {
+				if r, ok := n(body); ok {
+					out = append(out, r)
+				}
+			}
+			return out, true
+		}, nil
+	case string:
+		if strings.HasPrefix(v, "$$") {
+			s := v[1:]
+			return func(interface{}) (interface{}, bool) { return s, true }, nil
+		}
+		if strings.HasPrefix(v, "$") {
+			steps, err := parsePath(v)
+			if err != nil {
+				return nil, err
+			}
+			return func(body interface{}) (interface{}, bool) { return lookup(body, steps) }, nil
+		}
+	}
+	return func(interface{}) (interface{}, bool) { return v, true }, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// the numbers are kept as is, e.g. the int64 ids.
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the value")
+	}
+	return v, nil
+}
+
+// compileTemplate compiles the JSON template of the body.
+func compileTemplate(template string) (node, error) {
+	v, err := decode([]byte(template))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return compileNode(v)
+}
+
+// the dotted paths of the objects for the rename and the remove, e.g. a.b.
+func splitPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+// parent returns the object of the parent fields, it's created if create.
+func parent(body interface{}, fields []string, create bool) (map[string]interface{}, bool) {
+	m, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	for _, f := range fields {
+		child, ok := m[f].(map[string]interface{})
+		if !ok {
+			if _, exists := m[f]; exists || !create {
+				return nil, false
+			}
+			child = map[string]interface{}{}
+			m[f] = child
+		}
+		m = child
+	}
+	return m, true
+}
+
+func remove(body interface{}, path []string) (interface{}, bool) {
+	m, ok := parent(body, path[:len(path)-1], false)
+	if !ok {
+		return nil, false
+	}
+	v, ok := m[path[len(path)-1]]
+	delete(m, path[len(path)-1])
+	return v, ok
+}
+
+func rename(body interface{}, from, to []string) {
+	v, ok := remove(body, from)
+	if !ok {
+		return
+	}
+	if m, ok := parent(body, to[:len(to)-1], true); ok {
+		m[to[len(to)-1]] = v
+	}
+}
@@ -0,0 +1,250 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/jwt/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultHeader = "Authorization"
+	_bearerPrefix  = "Bearer "
+)
+
+func init() {
+	middleware.Register("jwt", Middleware)
+}
+
+type verifier struct {
+	issuers   []string
+	audiences []string
+	keys      []*key
+	keySets   []*keySet
+	clockSkew time.Duration
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verify verifies the signature and the registered claims of the token, and
+// returns the claims.
+func (v *verifier) verify(req *http.Request, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+	input := []byte(token[:len(parts[0])+1+len(parts[1])])
+	verified := false
+	for _, k := range v.keysOf(req, h.Kid) {
+		if err = k.verify(h.Alg, input, sig); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		if err == nil {
+			err = errUnknownKey
+		}
+		return nil, err
+	}
+	claims := map[string]interface{}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *verifier) keysOf(req *http.Request, kid string) []*key {
+	var keys []*key
+	for _, k := range v.keys {
+		// the static keys without id are tried for any token.
+		if k.id == "" || k.id == kid {
+			keys = append(keys, k)
+		}
+	}
+	for _, s := range v.keySets {
+		keys = append(keys, s.get(req.Context(), kid)...)
+	}
+	return keys
+}
+
+func (v *verifier) validate(claims map[string]interface{}) error {
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token without exp claim")
+	}
+	if now.After(unixTime(exp).Add(v.clockSkew)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.clockSkew).Before(unixTime(nbf)) {
+		return errors.New("token is not valid yet")
+	}
+	if len(v.issuers) > 0 {
+		iss, _ := claims["iss"].(string)
+		if !contains(v.issuers, iss) {
+			return fmt.Errorf("unexpected issuer: %s", iss)
+		}
+	}
+	if len(v.audiences) > 0 {
+		var auds []string
+		switch aud := claims["aud"].(type) {
+		case string:
+			auds = []string{aud}
+		case []interface{}:
+			for _, a := range aud {
+				if s, ok := a.(string); ok {
+					auds = append(auds, s)
+				}
+			}
+		}
+		matched := false
+		for _, aud := range auds {
+			if contains(v.audiences, aud) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("unexpected audience: %v", auds)
+		}
+	}
+	return nil
+}
+
+func unixTime(v float64) time.Time {
+	return time.Unix(int64(v), 0)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func newResponse(statusCode int, err error) (*http.Response, error) {
+	header := http.Header{}
+	if statusCode == http.StatusUnauthorized {
+		header.Set("WWW-Authenticate", fmt.Sprintf("Bearer error=\"invalid_token\", error_description=%q", err.Error()))
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// Middleware verifies the JWT of the requests.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.JWT{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	v := &verifier{
+		issuers:   options.Issuers,
+		audiences: options.Audiences,
+		clockSkew: options.ClockSkew.AsDuration(),
+	}
+	for i, k := range options.Keys {
+		key, err := parseKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt key of keys[%d]: %w", i, err)
+		}
+		v.keys = append(v.keys, key)
+	}
+	for i, c := range options.Jwks {
+		s, err := newKeySet(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks of jwks[%d]: %w", i, err)
+		}
+		v.keySets = append(v.keySets, s)
+	}
+	if len(v.keys) == 0 && len(v.keySets) == 0 {
+		return nil, errors.New("jwt keys or jwks are required")
+	}
+	tokenHeader := options.Header
+	if tokenHeader == "" {
+		tokenHeader = _defaultHeader
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			token := req.Header.Get(tokenHeader)
+			if tokenHeader == _defaultHeader {
+				if len(token) < len(_bearerPrefix) || !strings.EqualFold(token[:len(_bearerPrefix)], _bearerPrefix) {
+					audit.AuthenticationFailed(req, "jwt", http.StatusUnauthorized, "bearer token is required")
+					return newResponse(http.StatusUnauthorized, errors.New("bearer token is required"))
+				}
+				token = token[len(_bearerPrefix):]
+			}
+			if token == "" {
+				audit.AuthenticationFailed(req, "jwt", http.StatusUnauthorized, "token is required")
+				return newResponse(http.StatusUnauthorized, errors.New("token is required"))
+			}
+			claims, err := v.verify(req, token)
+			if err != nil {
+				audit.AuthenticationFailed(req, "jwt", http.StatusUnauthorized, err.Error())
+				return newResponse(http.StatusUnauthorized, err)
+			}
+			middleware.WithClaims(req.Context(), claims)
+			if sub, ok := claims["sub"].(string); ok {
+				if consumer, ok := middleware.GetConsumers().BySubject(sub); ok {
+					if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+						audit.PolicyDenied(req, "jwt", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+						return newResponse(http.StatusForbidden, nil)
+					}
+					middleware.WithConsumer(req.Context(), consumer)
+				}
+			}
+			if !options.Forward {
+				req.Header.Del(tokenHeader)
+			}
+			for name, key := range options.ClaimHeaders {
+				// the headers are always overwritten to be trusted by the upstream.
+				req.Header.Del(key)
+				if claim, ok := middleware.LookupClaim(claims, name); ok {
+					req.Header.Set(key, middleware.FormatClaim(claim))
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
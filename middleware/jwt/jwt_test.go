@@ -0,0 +1,309 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/jwt/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newMiddleware(t *testing.T, options *v1.JWT) middleware.Middleware {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "jwt", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sign signs the claims by the RSA key, the ECDSA key or the HMAC secret.
+func sign(t *testing.T, signer interface{}, kid string, claims map[string]interface{}) string {
+	alg := "HS256"
+	switch signer.(type) {
+	case *rsa.PrivateKey:
+		alg = "RS256"
+	case *ecdsa.PrivateKey:
+		alg = "ES256"
+	}
+	input := encodeSegment(t, map[string]string{"alg": alg, "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	var sig []byte
+	switch k := signer.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		_, _ = mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func rsaJWK(kid string, k *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+	}
+}
+
+type result struct {
+	code   int
+	header http.Header
+}
+
+func do(t *testing.T, m middleware.Middleware, endpoint *config.Endpoint, header http.Header) result {
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	req := httptest.NewRequest("GET", "/api/hello", nil)
+	req.Header = header
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(endpoint)))
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result{code: resp.StatusCode, header: upstream}
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + token}}
+}
+
+func TestJWT(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+	m := newMiddleware(t, &v1.JWT{
+		Issuers:   []string{"https://auth.example.com"},
+		Audiences: []string{"api"},
+		Keys: []*v1.Key{
+			{Id: "ec", Key: &v1.Key_PublicKey{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}},
+			{Id: "hs", Key: &v1.Key_Secret{Secret: string(secret)}},
+		},
+		ClaimHeaders: map[string]string{
+			"sub":                "X-User-Id",
+			"realm_access.roles": "X-User-Roles",
+		},
+	})
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":          "https://auth.example.com",
+			"aud":          []string{"web", "api"},
+			"sub":          "alice",
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{"roles": []string{"admin", "dev"}},
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	endpoint := &config.Endpoint{Path: "/api/*"}
+
+	r := do(t, m, endpoint, http.Header{"Authorization": []string{"Bearer " + sign(t, ecKey, "ec", claims(nil))}, "X-User-Id": []string{"mallory"}})
+	if r.code != http.StatusOK {
+		t.Fatalf("want 200 but got %d", r.code)
+	}
+	if got := r.header.Get("X-User-Id"); got != "alice" {
+		t.Fatalf("want the user id of the claim but got %q", got)
+	}
+	if got := r.header.Get("X-User-Roles"); got != "admin,dev" {
+		t.Fatalf("want the roles of the nested claim but got %q", got)
+	}
+	if got := r.header.Get("Authorization"); got != "" {
+		t.Fatalf("want the token removed but got %q", got)
+	}
+	if r := do(t, m, endpoint, bearer(sign(t, secret, "hs", claims(nil)))); r.code != http.StatusOK {
+		t.Fatalf("want 200 of the HMAC token but got %d", r.code)
+	}
+
+	bad := map[string]string{
+		"no token":          "",
+		"malformed":         "Bearer abc",
+		"expired":           "Bearer " + sign(t, ecKey, "ec", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"no exp":            "Bearer " + sign(t, ecKey, "ec", claims(map[string]interface{}{"exp": nil})),
+		"not before":        "Bearer " + sign(t, ecKey, "ec", claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"issuer":            "Bearer " + sign(t, ecKey, "ec", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"audience":          "Bearer " + sign(t, ecKey, "ec", claims(map[string]interface{}{"aud": "web"})),
+		"unknown kid":       "Bearer " + sign(t, ecKey, "unknown", claims(nil)),
+		"wrong secret":      "Bearer " + sign(t, []byte("wrong"), "hs", claims(nil)),
+		"secret of the key": "Bearer " + sign(t, secret, "ec", claims(nil)),
+	}
+	for name, token := range bad {
+		if r := do(t, m, endpoint, http.Header{"Authorization": []string{token}}); r.code != http.StatusUnauthorized {
+			t.Errorf("%s: want 401 but got %d", name, r.code)
+		}
+	}
+
+	middleware.SetConsumers([]*config.Consumer{{Name: "alice", JwtSubjects: []string{"alice"}, AllowedRoutes: []string{"/admin/*"}}})
+	defer middleware.SetConsumers(nil)
+	if r := do(t, m, endpoint, bearer(sign(t, ecKey, "ec", claims(nil)))); r.code != http.StatusForbidden {
+		t.Fatalf("want 403 of the consumer but got %d", r.code)
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotated, fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		keys := []map[string]string{rsaJWK("first", first)}
+		if atomic.LoadInt32(&rotated) == 1 {
+			keys = append(keys, rsaJWK("second", second))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer srv.Close()
+	m := newMiddleware(t, &v1.JWT{Jwks: []*v1.JWKS{{Url: srv.URL}}, Header: "X-Token", Forward: true})
+	claims := map[string]interface{}{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}
+	endpoint := &config.Endpoint{Path: "/api/*"}
+
+	for i := 0; i < 3; i++ {
+		r := do(t, m, endpoint, http.Header{"X-Token": []string{sign(t, first, "first", claims)}})
+		if r.code != http.StatusOK {
+			t.Fatalf("want 200 but got %d", r.code)
+		}
+		if r.header.Get("X-Token") == "" {
+			t.Fatal("want the token forwarded")
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("want the keys cached but fetched %d times", n)
+	}
+	// the unknown kid refreshes the keys at most once in the minimal interval.
+	atomic.StoreInt32(&rotated, 1)
+	if r := do(t, m, endpoint, http.Header{"X-Token": []string{sign(t, second, "second", claims)}}); r.code != http.StatusUnauthorized {
+		t.Fatalf("want 401 before the minimal refresh interval but got %d", r.code)
+	}
+
+	s, err := newKeySet(&v1.JWKS{Url: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&rotated, 0)
+	if keys := s.get(context.Background(), "second"); len(keys) != 0 {
+		t.Fatalf("want no keys of the unknown kid but got %d", len(keys))
+	}
+	atomic.StoreInt32(&rotated, 1)
+	s.triedAt = s.triedAt.Add(-_minRefreshInterval)
+	if keys := s.get(context.Background(), "second"); len(keys) != 1 {
+		t.Fatalf("want the rotated key but got %d", len(keys))
+	}
+	if keys := s.get(context.Background(), ""); len(keys) != 2 {
+		t.Fatalf("want all the keys but got %d", len(keys))
+	}
+}
+
+func TestJWKSSlowRefresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{rsaJWK("first", key)}})
+	}))
+	defer srv.Close()
+	defer close(release)
+	s, err := newKeySet(&v1.JWKS{Url: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the callers wait for the slow fetch by their own deadlines and share it.
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+			defer cancel()
+			start := time.Now()
+			s.get(ctx, "first")
+			if elapsed := time.Since(start); elapsed > time.Second {
+				errs <- fmt.Errorf("blocked by the fetch for %s", elapsed)
+				return
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("want the single fetch but fetched %d times", n)
+	}
+	// the canceled callers don't cancel the fetch.
+	release <- struct{}{}
+	if keys := s.get(context.Background(), "first"); len(keys) != 1 {
+		t.Fatalf("want the fetched key but got %d", len(keys))
+	}
+}
@@ -0,0 +1,359 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/jwt/v1"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	_defaultRefreshInterval = 10 * time.Minute
+	_defaultFetchTimeout    = 5 * time.Second
+	// the keys are refreshed at most once in the interval for the unknown kid.
+	_minRefreshInterval = time.Minute
+)
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// key is a verification key, the public key of RS, PS and ES algorithms, or
+// the secret of HS algorithms.
+type key struct {
+	id     string
+	public crypto.PublicKey
+	secret []byte
+}
+
+func hashOf(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, false
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// verify verifies the signature of the input by the algorithm, the algorithm
+// must match with the type of the key.
+func (k *key) verify(alg string, input, sig []byte) error {
+	hash, ok := hashOf(alg)
+	if !ok {
+		return fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+	h := hash.New()
+	_, _ = h.Write(input)
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "HS":
+		if k.secret == nil {
+			return errUnknownKey
+		}
+		mac := hmac.New(hash.New, k.secret)
+		_, _ = mac.Write(input)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case "RS", "PS":
+		pub, ok := k.public.(*rsa.PublicKey)
+		if !ok {
+			return errUnknownKey
+		}
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+	case "ES":
+		pub, ok := k.public.(*ecdsa.PublicKey)
+		if !ok {
+			return errUnknownKey
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm: %s", alg)
+}
+
+func parseKey(c *v1.Key) (*key, error) {
+	switch k := c.Key.(type) {
+	case *v1.Key_Secret:
+		if k.Secret == "" {
+			return nil, errors.New("empty secret")
+		}
+		return &key{id: c.Id, secret: []byte(k.Secret)}, nil
+	case *v1.Key_PublicKey:
+		pub, err := parsePublicKey([]byte(k.PublicKey))
+		if err != nil {
+			return nil, err
+		}
+		return &key{id: c.Id, public: pub}, nil
+	}
+	return nil, errors.New("public key or secret is required")
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM of the public key")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// jwk is a JSON web key of RFC 7517.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (j *jwk) key() (*key, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent of key: %s", j.Kid)
+		}
+		return &key{id: j.Kid, public: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve of key %s: %s", j.Kid, j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid EC point of key: %s", j.Kid)
+		}
+		return &key{id: j.Kid, public: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(j.K)
+		if err != nil {
+			return nil, err
+		}
+		return &key{id: j.Kid, secret: k}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type of key %s: %s", j.Kid, j.Kty)
+}
+
+// keySet is the keys fetched from the JWKS URL, the keys are refreshed by the
+// interval or the unknown kid, the last keys are kept if failed to refresh.
+type keySet struct {
+	url      string
+	interval time.Duration
+	timeout  time.Duration
+	client   *http.Client
+
+	mu        sync.Mutex
+	keys      []*key
+	fetchedAt time.Time
+	triedAt   time.Time
+	// refreshing is closed when the in-flight refresh is done.
+	refreshing chan struct{}
+}
+
+func newKeySet(c *v1.JWKS) (*keySet, error) {
+	if c.Url == "" {
+		return nil, errors.New("JWKS url is required")
+	}
+	s := &keySet{
+		url:      c.Url,
+		interval: _defaultRefreshInterval,
+		timeout:  _defaultFetchTimeout,
+		client:   &http.Client{},
+	}
+	if c.RefreshInterval != nil {
+		s.interval = c.RefreshInterval.AsDuration()
+	}
+	if c.Timeout != nil && c.Timeout.AsDuration() > 0 {
+		s.timeout = c.Timeout.AsDuration()
+	}
+	return s, nil
+}
+
+// get returns the keys of the kid, all the keys if the kid is empty.
+func (s *keySet) get(ctx context.Context, kid string) []*key {
+	s.mu.Lock()
+	keys, stale := s.keys, time.Since(s.fetchedAt) >= s.interval
+	s.mu.Unlock()
+	if stale {
+		keys = s.refresh(ctx)
+	}
+	matched := matchKeys(keys, kid)
+	if len(matched) == 0 && kid != "" {
+		// the signing keys may be rotated.
+		matched = matchKeys(s.refresh(ctx), kid)
+	}
+	return matched
+}
+
+// refresh fetches the keys at most once in the minimal interval, the
+// concurrent callers share the in-flight fetch and wait for it until their
+// contexts are done, then the last keys are returned.
+func (s *keySet) refresh(ctx context.Context) []*key {
+	s.mu.Lock()
+	done := s.refreshing
+	if done == nil {
+		if time.Since(s.triedAt) < _minRefreshInterval {
+			keys := s.keys
+			s.mu.Unlock()
+			return keys
+		}
+		done = make(chan struct{})
+		s.refreshing = done
+		s.triedAt = time.Now()
+		go s.refreshproc(done)
+	}
+	s.mu.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys
+}
+
+// refreshproc fetches the keys detached from the request which triggers the
+// refresh, the canceled request doesn't fail the fetch of the other callers.
+func (s *keySet) refreshproc(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	keys, err := s.fetch(ctx)
+	s.mu.Lock()
+	if err != nil {
+		log.Errorf("Failed to fetch JWKS from %s: %+v", s.url, err)
+	} else {
+		s.keys = keys
+		s.fetchedAt = time.Now()
+	}
+	s.refreshing = nil
+	s.mu.Unlock()
+	close(done)
+}
+
+func (s *keySet) fetch(ctx context.Context) ([]*key, error) {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make([]*key, 0, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		k, err := j.key()
+		if err != nil {
+			log.Warnf("Skip the key of JWKS %s: %v", s.url, err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func matchKeys(keys []*key, kid string) []*key {
+	if kid == "" {
+		return keys
+	}
+	var matched []*key
+	for _, k := range keys {
+		if k.id == kid {
+			matched = append(matched, k)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+)
+
+// fluentSink forwards the lines to the Fluentd or the Fluent Bit by the
+// forward mode of the Forward protocol, the line is the log field of the
+// record. It's written by the goroutine of the async sink only.
+type fluentSink struct {
+	address string
+	tag     string
+	timeout time.Duration
+	conn    net.Conn
+}
+
+func newFluentSink(c *v1.FluentSink) (Sink, error) {
+	if c.Address == "" {
+		return nil, fmt.Errorf("fluent address is required")
+	}
+	s := &fluentSink{address: c.Address, tag: c.Tag, timeout: 5 * time.Second}
+	if s.tag == "" {
+		s.tag = "gateway.access"
+	}
+	if c.Timeout != nil && c.Timeout.AsDuration() > 0 {
+		s.timeout = c.Timeout.AsDuration()
+	}
+	return s, nil
+}
+
+func (s *fluentSink) Write(lines [][]byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout("tcp", s.address, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(newForwardMessage(s.tag, lines, time.Now())); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// newForwardMessage encodes the [tag, [[time, {"log": line}], ...]] by the
+// MessagePack.
+func newForwardMessage(tag string, lines [][]byte, now time.Time) []byte {
+	buf := []byte{0x92}
+	buf = appendMsgpackString(buf, []byte(tag))
+	buf = appendMsgpackArray(buf, len(lines))
+	for _, line := range lines {
+		buf = append(buf, 0x92, 0xce)
+		buf = append(buf, make([]byte, 4)...)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(now.Unix()))
+		buf = append(buf, 0x81)
+		buf = appendMsgpackString(buf, []byte("log"))
+		buf = appendMsgpackString(buf, line)
+	}
+	return buf
+}
+
+func appendMsgpackArray(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= 0xffff:
+		return append(buf, 0xdc, byte(n>>8), byte(n))
+	default:
+		return append(buf, 0xdd, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func appendMsgpackString(buf []byte, s []byte) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= 0xff:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= 0xffff:
+		buf = append(buf, 0xda, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(buf, s...)
+}
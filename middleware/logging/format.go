@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/gateway/middleware"
+)
+
+var _placeholderPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// _fields is all the fields of the access logs in the order.
+var _fields = []string{
+	"time", "remote_addr", "host", "method", "scheme", "path", "query", "protocol",
+	"code", "error", "latency", "request_bytes", "response_bytes", "user_agent", "referer",
+	"route", "cluster", "upstream_host", "upstream_code", "upstream_latency", "retries",
+	"last_attempt", "trace_id", "request_id", "consumer",
+}
+
+// entry is the fields of the access log of a request.
+type entry map[string]interface{}
+
+func (e entry) string(name string) string {
+	switch v := e[name].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatter formats the entry to a line without the line break.
+type formatter func(e entry) []byte
+
+func jsonFormatter(fields []string) formatter {
+	return func(e entry) []byte {
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, name := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(name)
+			v, err := json.Marshal(e[name])
+			if err != nil {
+				v = []byte("null")
+			}
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(v)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes()
+	}
+}
+
+// orDash returns - for the empty values of the Apache formats.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// apacheFormatter formats the common log format, and the referer and the
+// user agent are appended for the combined one.
+func apacheFormatter(combined bool) formatter {
+	return func(e entry) []byte {
+		uri := e.string("path")
+		if q := e.string("query"); q != "" {
+			uri += "?" + q
+		}
+		t, _ := e["time"].(time.Time)
+		size := "-"
+		if n, _ := e["response_bytes"].(int64); n > 0 {
+			size = strconv.FormatInt(n, 10)
+		}
+		line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %s %s",
+			orDash(e.string("remote_addr")), orDash(e.string("consumer")), t.Format("02/Jan/2006:15:04:05 -0700"),
+			e.string("method"), uri, e.string("protocol"), e.string("code"), size)
+		if combined {
+			line += fmt.Sprintf(" %q %q", orDash(e.string("referer")), orDash(e.string("user_agent")))
+		}
+		return []byte(line)
+	}
+}
+
+// templateFormatter expands the {field} placeholders of the template.
+func templateFormatter(template string) formatter {
+	return func(e entry) []byte {
+		values := make(map[string]string, len(e))
+		for name := range e {
+			values[name] = e.string(name)
+		}
+		return []byte(middleware.ExpandPlaceholders(template, values))
+	}
+}
+
+// checkTemplate returns the error of the unknown fields of the template.
+func checkTemplate(template string) error {
+	for _, match := range _placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if !knownField(match[1]) {
+			return fmt.Errorf("unknown access log field: %s", match[1])
+		}
+	}
+	return nil
+}
+
+func knownField(name string) bool {
+	for _, f := range _fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// traceID returns the trace id of the traceparent or the B3 headers sent to
+// the upstream by the tracing middleware.
+func traceID(header map[string][]string) string {
+	get := func(name string) string {
+		if v := header[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	if tp := strings.Split(get("Traceparent"), "-"); len(tp) == 4 {
+		return tp[1]
+	}
+	if b3 := get("B3"); b3 != "" {
+		if i := strings.IndexByte(b3, '-'); i > 0 {
+			return b3[:i]
+		}
+	}
+	return get("X-B3-Traceid")
+}
@@ -0,0 +1,317 @@
+package logging
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+)
+
+const (
+	_kafkaClientID = "gateway"
+
+	_kafkaProduceKey  = 0
+	_kafkaMetadataKey = 3
+)
+
+var _crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaSink produces the lines to the partitions of the topic by turns, the
+// batch is produced by the version 2 record batch with the leader acked. It's
+// written by the goroutine of the async sink only.
+type kafkaSink struct {
+	brokers []string
+	topic   string
+	timeout time.Duration
+
+	// the partitions and their leaders are refreshed once failed.
+	partitions    []int32
+	leaders       map[int32]string
+	conns         map[string]net.Conn
+	next          int
+	correlationID int32
+}
+
+func newKafkaSink(c *v1.KafkaSink) (Sink, error) {
+	if len(c.Brokers) == 0 || c.Topic == "" {
+		return nil, fmt.Errorf("kafka brokers and topic are required")
+	}
+	s := &kafkaSink{brokers: c.Brokers, topic: c.Topic, timeout: 5 * time.Second, conns: map[string]net.Conn{}}
+	if c.Timeout != nil && c.Timeout.AsDuration() > 0 {
+		s.timeout = c.Timeout.AsDuration()
+	}
+	return s, nil
+}
+
+func (s *kafkaSink) Write(lines [][]byte) error {
+	if s.leaders == nil {
+		if err := s.refresh(); err != nil {
+			return err
+		}
+	}
+	partition := s.partitions[s.next%len(s.partitions)]
+	s.next++
+	addr := s.leaders[partition]
+	if err := s.produce(addr, partition, lines); err != nil {
+		s.close(addr)
+		s.leaders = nil
+		return err
+	}
+	return nil
+}
+
+// refresh requests the metadata of the topic from the brokers in order.
+func (s *kafkaSink) refresh() error {
+	var err error
+	for _, addr := range s.brokers {
+		if err = s.metadata(addr); err == nil {
+			return nil
+		}
+		s.close(addr)
+	}
+	return err
+}
+
+func (s *kafkaSink) metadata(addr string) error {
+	e := &kafkaEncoder{}
+	e.int32(1)
+	e.string(s.topic)
+	d, err := s.roundTrip(addr, _kafkaMetadataKey, 0, e.buf)
+	if err != nil {
+		return err
+	}
+	brokers := map[int32]string{}
+	for i, n := 0, d.int32(); i < int(n) && d.err == nil; i++ {
+		id, host, port := d.int32(), d.string(), d.int32()
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	leaders := map[int32]string{}
+	var partitions []int32
+	for i, n := 0, d.int32(); i < int(n) && d.err == nil; i++ {
+		code, topic := d.int16(), d.string()
+		if code != 0 {
+			return fmt.Errorf("kafka metadata of topic %s error code: %d", topic, code)
+		}
+		for j, m := 0, d.int32(); j < int(m) && d.err == nil; j++ {
+			code, partition, leader := d.int16(), d.int32(), d.int32()
+			d.int32s() // replicas
+			d.int32s() // isr
+			if addr, ok := brokers[leader]; ok && code == 0 && topic == s.topic {
+				leaders[partition] = addr
+				partitions = append(partitions, partition)
+			}
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("kafka topic has no partition available: %s", s.topic)
+	}
+	s.partitions, s.leaders = partitions, leaders
+	return nil
+}
+
+func (s *kafkaSink) produce(addr string, partition int32, lines [][]byte) error {
+	batch := newRecordBatch(lines, time.Now())
+	e := &kafkaEncoder{}
+	e.int16(-1) // transactional id
+	e.int16(1)  // acks
+	e.int32(int32(s.timeout / time.Millisecond))
+	e.int32(1)
+	e.string(s.topic)
+	e.int32(1)
+	e.int32(partition)
+	e.bytes(batch)
+	d, err := s.roundTrip(addr, _kafkaProduceKey, 3, e.buf)
+	if err != nil {
+		return err
+	}
+	for i, n := 0, d.int32(); i < int(n) && d.err == nil; i++ {
+		d.string()
+		for j, m := 0, d.int32(); j < int(m) && d.err == nil; j++ {
+			d.int32()
+			if code := d.int16(); code != 0 {
+				return fmt.Errorf("kafka produce error code: %d", code)
+			}
+			d.int64() // base offset
+			d.int64() // log append time
+		}
+	}
+	return d.err
+}
+
+func (s *kafkaSink) roundTrip(addr string, key, version int16, body []byte) (*kafkaDecoder, error) {
+	conn, ok := s.conns[addr]
+	if !ok {
+		var err error
+		if conn, err = net.DialTimeout("tcp", addr, s.timeout); err != nil {
+			return nil, err
+		}
+		s.conns[addr] = conn
+	}
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return nil, err
+	}
+	s.correlationID++
+	e := &kafkaEncoder{buf: make([]byte, 4, 4+len(body)+32)}
+	e.int16(key)
+	e.int16(version)
+	e.int32(s.correlationID)
+	e.string(_kafkaClientID)
+	e.buf = append(e.buf, body...)
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	if _, err := conn.Write(e.buf); err != nil {
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	d := &kafkaDecoder{buf: resp}
+	if id := d.int32(); id != s.correlationID {
+		return nil, fmt.Errorf("kafka correlation id mismatched: %d", id)
+	}
+	return d, nil
+}
+
+func (s *kafkaSink) close(addr string) {
+	if conn, ok := s.conns[addr]; ok {
+		conn.Close()
+		delete(s.conns, addr)
+	}
+}
+
+// newRecordBatch encodes the lines to the records of the batch without keys.
+func newRecordBatch(lines [][]byte, now time.Time) []byte {
+	ts := now.UnixNano() / int64(time.Millisecond)
+	records := &kafkaEncoder{}
+	for i, line := range lines {
+		r := &kafkaEncoder{}
+		r.buf = append(r.buf, 0) // attributes
+		r.varint(0)              // timestamp delta
+		r.varint(int64(i))       // offset delta
+		r.varint(-1)             // key
+		r.varint(int64(len(line)))
+		r.buf = append(r.buf, line...)
+		r.varint(0) // headers
+		records.varint(int64(len(r.buf)))
+		records.buf = append(records.buf, r.buf...)
+	}
+	e := &kafkaEncoder{}
+	e.int64(0)  // base offset
+	e.int32(0)  // batch length
+	e.int32(-1) // partition leader epoch
+	e.buf = append(e.buf, 2)
+	e.int32(0) // crc
+	e.int16(0) // attributes
+	e.int32(int32(len(lines) - 1))
+	e.int64(ts)
+	e.int64(ts)
+	e.int64(-1) // producer id
+	e.int16(-1) // producer epoch
+	e.int32(-1) // base sequence
+	e.int32(int32(len(lines)))
+	e.buf = append(e.buf, records.buf...)
+	binary.BigEndian.PutUint32(e.buf[8:], uint32(len(e.buf)-12))
+	binary.BigEndian.PutUint32(e.buf[17:], crc32.Checksum(e.buf[21:], _crc32c))
+	return e.buf
+}
+
+type kafkaEncoder struct {
+	buf []byte
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	e.buf = append(e.buf, byte(uint16(v)>>8), byte(v))
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *kafkaEncoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *kafkaEncoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+var errKafkaShortBuffer = errors.New("kafka response is too short")
+
+// kafkaDecoder decodes the response, the values are zero once failed.
+type kafkaDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil || n < 0 || len(d.buf) < n {
+		if d.err == nil {
+			d.err = errKafkaShortBuffer
+		}
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *kafkaDecoder) int32s() {
+	d.next(4 * int(d.int32()))
+}
@@ -1,53 +1,278 @@
 package logging
 
 import (
+	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+	"github.com/go-kratos/gateway/clientip"
 	"github.com/go-kratos/gateway/middleware"
 	"github.com/go-kratos/kratos/v2/log"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 func init() {
 	middleware.Register("logging", Middleware)
 }
 
-// Middleware is a logging middleware.
+// policy is whether the requests of the route are logged.
+type policy struct {
+	disabled bool
+	ratio    float64
+}
+
+func newPolicy(disabled bool, ratio *float32) policy {
+	p := policy{disabled: disabled, ratio: 1}
+	if ratio != nil {
+		p.ratio = float64(*ratio)
+	}
+	return p
+}
+
+type routePolicy struct {
+	path string
+	policy
+}
+
+// policyOf returns the policy of the first route matched by the path of the
+// endpoint, the path ends with * for the prefix.
+func policyOf(routes []routePolicy, def policy, path string) policy {
+	for _, r := range routes {
+		if r.path == path || (strings.HasSuffix(r.path, "*") && strings.HasPrefix(path, strings.TrimSuffix(r.path, "*"))) {
+			return r.policy
+		}
+	}
+	return def
+}
+
+// countingBody counts the bytes read, the done func is called once at the
+// end of the body or once it's closed if not nil.
+type countingBody struct {
+	io.ReadCloser
+	read int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.read, int64(n))
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *countingBody) finish() {
+	b.once.Do(func() {
+		if b.done != nil {
+			b.done(atomic.LoadInt64(&b.read))
+		}
+	})
+}
+
+// newEntry returns the fields of the access log once the response is sent.
+func newEntry(req *http.Request, reply *http.Response, err error, startTime time.Time, received, sent int64) entry {
+	code := http.StatusBadGateway
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	} else {
+		code = reply.StatusCode
+	}
+	ctx := req.Context()
+	reqOpt, _ := middleware.FromRequestContext(ctx)
+	if reqOpt == nil {
+		reqOpt = &middleware.RequestOptions{}
+	}
+	consumer := ""
+	if reqOpt.Consumer != nil {
+		consumer = reqOpt.Consumer.Name
+	}
+	remoteAddr := ""
+	if ip := clientip.FromRequest(req); ip != nil {
+		remoteAddr = ip.String()
+	}
+	e := entry{
+		"time":             startTime,
+		"remote_addr":      remoteAddr,
+		"host":             req.Host,
+		"method":           req.Method,
+		"scheme":           req.URL.Scheme,
+		"path":             req.URL.Path,
+		"query":            req.URL.RawQuery,
+		"protocol":         req.Proto,
+		"code":             code,
+		"error":            errMsg,
+		"latency":          time.Since(startTime).Seconds(),
+		"request_bytes":    received,
+		"response_bytes":   sent,
+		"user_agent":       req.UserAgent(),
+		"referer":          req.Referer(),
+		"route":            reqOpt.Endpoint.GetPath(),
+		"cluster":          reqOpt.Cluster,
+		"upstream_host":    "",
+		"upstream_code":    0,
+		"upstream_latency": 0.0,
+		"retries":          0,
+		"last_attempt":     reqOpt.LastAttempt,
+		"trace_id":         "",
+		"request_id":       reqOpt.RequestID,
+		"consumer":         consumer,
+	}
+	if n := len(reqOpt.Backends); n > 0 {
+		e["upstream_host"] = reqOpt.Backends[n-1]
+	}
+	if n := len(reqOpt.UpstreamStatusCode); n > 0 {
+		e["upstream_code"] = reqOpt.UpstreamStatusCode[n-1]
+		e["retries"] = n - 1
+	}
+	if n := len(reqOpt.UpstreamResponseTime); n > 0 {
+		e["upstream_latency"] = reqOpt.UpstreamResponseTime[n-1]
+	}
+	// the span is in the context if the tracing middleware is before, or the
+	// trace context is in the headers sent to the upstream otherwise.
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		e["trace_id"] = sc.TraceID().String()
+	} else {
+		e["trace_id"] = traceID(req.Header)
+	}
+	return e
+}
+
+// Middleware is a logging middleware, the access logs are written by the
+// gateway logger unless the format or the template is configured.
 func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Logging{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	var format formatter
+	switch {
+	case options.Template != "":
+		if err := checkTemplate(options.Template); err != nil {
+			return nil, err
+		}
+		format = templateFormatter(options.Template)
+	case options.Format == "json":
+		fields := _fields
+		if len(options.Fields) > 0 {
+			for _, name := range options.Fields {
+				if !knownField(name) {
+					return nil, fmt.Errorf("unknown access log field: %s", name)
+				}
+			}
+			fields = options.Fields
+		}
+		format = jsonFormatter(fields)
+	case options.Format == "combined", options.Format == "common":
+		format = apacheFormatter(options.Format == "combined")
+	case options.Format != "":
+		return nil, fmt.Errorf("invalid access log format: %s", options.Format)
+	}
+	var out *asyncSink
+	if format != nil {
+		var err error
+		if out, err = sinkOf(options.Sink, options.Output); err != nil {
+			return nil, err
+		}
+	}
+	def := newPolicy(options.Disabled, options.SampleRatio)
+	routes := make([]routePolicy, 0, len(options.Routes))
+	for _, r := range options.Routes {
+		routes = append(routes, routePolicy{path: r.Path, policy: newPolicy(r.Disabled, r.SampleRatio)})
+	}
+	write := func(req *http.Request, reply *http.Response, err error, startTime time.Time, received, sent int64) {
+		e := newEntry(req, reply, err, startTime, received, sent)
+		if format != nil {
+			out.write(format(e))
+			return
+		}
+		level := log.LevelInfo
+		if err != nil {
+			level = log.LevelError
+		}
+		reqOpt, _ := middleware.FromRequestContext(req.Context())
+		if reqOpt == nil {
+			reqOpt = &middleware.RequestOptions{}
+		}
+		log.Context(req.Context()).Log(level,
+			"source", "accesslog",
+			"host", e["host"],
+			"method", e["method"],
+			"scheme", e["scheme"],
+			"path", e["path"],
+			"query", e["query"],
+			"code", e["code"],
+			"error", e["error"],
+			"latency", e["latency"],
+			"backend", strings.Join(reqOpt.Backends, ","),
+			"backend_code", reqOpt.UpstreamStatusCode,
+			"backend_latency", reqOpt.UpstreamResponseTime,
+			"last_attempt", e["last_attempt"],
+			"consumer", e["consumer"],
+			"request_id", e["request_id"],
+			"route", e["route"],
+			"cluster", e["cluster"],
+			"retries", e["retries"],
+			"request_bytes", e["request_bytes"],
+			"response_bytes", e["response_bytes"],
+			"trace_id", e["trace_id"],
+		)
+	}
 	return func(next http.RoundTripper) http.RoundTripper {
 		return middleware.RoundTripperFunc(func(req *http.Request) (reply *http.Response, err error) {
+			endpoint, _ := middleware.EndpointFromContext(req.Context())
+			p := policyOf(routes, def, endpoint.GetPath())
+			if p.disabled {
+				return next.RoundTrip(req)
+			}
 			startTime := time.Now()
+			// the bytes of the chunked bodies are counted once sent.
+			var reqBody *countingBody
+			if req.ContentLength < 0 && req.Body != nil && req.Body != http.NoBody {
+				reqBody = &countingBody{ReadCloser: req.Body}
+				req.Body = reqBody
+			}
+			received := func() int64 {
+				if reqBody != nil {
+					return atomic.LoadInt64(&reqBody.read)
+				}
+				if req.ContentLength < 0 {
+					return 0
+				}
+				return req.ContentLength
+			}
 			reply, err = next.RoundTrip(req)
-			level := log.LevelInfo
-			code := http.StatusBadGateway
-			errMsg := ""
-			if err != nil {
-				level = log.LevelError
-				errMsg = err.Error()
-			} else {
-				code = reply.StatusCode
+			// the failed requests are always logged regardless of the ratio.
+			if err == nil && reply.StatusCode < http.StatusInternalServerError && p.ratio < 1 && rand.Float64() >= p.ratio {
+				return reply, nil
+			}
+			if err != nil || reply.Body == nil || reply.Body == http.NoBody {
+				write(req, reply, err, startTime, received(), 0)
+				return reply, err
 			}
-			ctx := req.Context()
-			// nodes, _ := middleware.RequestBackendsFromContext(ctx)
-			reqOpt, _ := middleware.FromRequestContext(ctx)
-			log.Context(ctx).Log(level,
-				"source", "accesslog",
-				"host", req.Host,
-				"method", req.Method,
-				"scheme", req.URL.Scheme,
-				"path", req.URL.Path,
-				"query", req.URL.RawQuery,
-				"code", code,
-				"error", errMsg,
-				"latency", time.Since(startTime).Seconds(),
-				"backend", strings.Join(reqOpt.Backends, ","),
-				"backend_code", reqOpt.UpstreamStatusCode,
-				"backend_latency", reqOpt.UpstreamResponseTime,
-				"last_attempt", reqOpt.LastAttempt,
-			)
-			return reply, err
+			reply.Body = &countingBody{ReadCloser: reply.Body, done: func(sent int64) {
+				write(req, reply, nil, startTime, received(), sent)
+			}}
+			return reply, nil
 		})
 	}, nil
 }
@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newTripper(t *testing.T, options *v1.Logging) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "logging", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqOpt, _ := middleware.FromRequestContext(req.Context())
+		reqOpt.Backends = append(reqOpt.Backends, "10.0.0.1:8000")
+		reqOpt.UpstreamStatusCode = append(reqOpt.UpstreamStatusCode, 0, http.StatusOK)
+		req.Header.Set("Traceparent", "00-80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-01")
+		code := http.StatusOK
+		if strings.HasPrefix(req.URL.Path, "/fail") {
+			code = http.StatusServiceUnavailable
+		}
+		return &http.Response{StatusCode: code, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("hello"))}, nil
+	}))
+}
+
+func send(t *testing.T, tripper http.RoundTripper, route, path string) {
+	req := httptest.NewRequest("POST", path+"?q=1", strings.NewReader("body"))
+	req.Header.Set("User-Agent", "test")
+	reqOpts := middleware.NewRequestOptions(&config.Endpoint{Path: route})
+	reqOpts.RequestID = "req-1"
+	resp, err := tripper.RoundTrip(req.WithContext(middleware.NewRequestContext(req.Context(), reqOpts)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+}
+
+// readLines waits for the n lines written to the file by the async sink.
+func readLines(t *testing.T, file string, n int) []string {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines = strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) >= n && lines[0] != "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return lines
+}
+
+func TestJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "access.log")
+	tripper := newTripper(t, &v1.Logging{Format: "json", Output: file})
+	send(t, tripper, "/users/*", "/users/1")
+	lines := readLines(t, file, 1)
+	if len(lines) != 1 {
+		t.Fatalf("want 1 line but got %q", lines)
+	}
+	var e map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"method":         "POST",
+		"path":           "/users/1",
+		"query":          "q=1",
+		"code":           float64(200),
+		"route":          "/users/*",
+		"upstream_host":  "10.0.0.1:8000",
+		"retries":        float64(1),
+		"request_bytes":  float64(4),
+		"response_bytes": float64(5),
+		"trace_id":       "80f198ee56343ba864fe8b2a57d3eff7",
+		"request_id":     "req-1",
+		"user_agent":     "test",
+	}
+	for k, v := range want {
+		if e[k] != v {
+			t.Errorf("want %s %v but got %v", k, v, e[k])
+		}
+	}
+	if !strings.HasPrefix(lines[0], `{"time":`) {
+		t.Fatalf("want the fields in the order but got %s", lines[0])
+	}
+}
+
+func TestTextFormats(t *testing.T) {
+	dir := t.TempDir()
+	combined := filepath.Join(dir, "combined.log")
+	send(t, newTripper(t, &v1.Logging{Format: "combined", Output: combined}), "/users/*", "/users/1")
+	if line := readLines(t, combined, 1)[0]; !strings.Contains(line, `"POST /users/1?q=1 HTTP/1.1" 200 5 "-" "test"`) || !strings.HasPrefix(line, "192.0.2.1 - - [") {
+		t.Fatalf("want the combined log but got %s", line)
+	}
+	template := filepath.Join(dir, "template.log")
+	send(t, newTripper(t, &v1.Logging{Template: "{method} {route} {code} {upstream_host} {unknown", Output: template}), "/users/*", "/users/1")
+	if line := readLines(t, template, 1)[0]; line != "POST /users/* 200 10.0.0.1:8000 {unknown" {
+		t.Fatalf("want the template log but got %s", line)
+	}
+
+	for _, options := range []*v1.Logging{{Template: "{latency} {unknown}"}, {Format: "json", Fields: []string{"unknown"}}, {Format: "xml"}} {
+		any, _ := anypb.New(options)
+		if _, err := Middleware(&config.Middleware{Name: "logging", Options: any}); err == nil {
+			t.Fatalf("want the error of %v", options)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "access.log")
+	ratio := float32(0)
+	tripper := newTripper(t, &v1.Logging{
+		Template: "{path}",
+		Output:   file,
+		Routes: []*v1.Route{
+			{Path: "/healthz", Disabled: true},
+			{Path: "/hot/*", SampleRatio: &ratio},
+			{Path: "/fail/*", SampleRatio: &ratio},
+		},
+	})
+	send(t, tripper, "/healthz", "/healthz")
+	send(t, tripper, "/hot/*", "/hot/1")
+	send(t, tripper, "/fail/*", "/fail/1")
+	send(t, tripper, "/users/*", "/users/1")
+	// the failed requests are logged regardless of the ratio.
+	if lines := readLines(t, file, 2); strings.Join(lines, ",") != "/fail/1,/users/1" {
+		t.Fatalf("want the logs of the routes enabled but got %q", lines)
+	}
+}
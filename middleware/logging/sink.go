@@ -0,0 +1,273 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	_defaultBufferSize = 10000
+	_defaultBatchSize  = 100
+	_defaultMaxBytes   = 100 << 20
+	_defaultMaxBackups = 5
+)
+
+var _metricDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "go",
+	Subsystem: "gateway",
+	Name:      "access_log_dropped_total",
+	Help:      "The total number of the access logs dropped by the sinks",
+}, []string{"sink", "reason"})
+
+func init() {
+	prometheus.MustRegister(_metricDroppedTotal)
+}
+
+// Sink writes the lines of the access logs in batches, the lines are without
+// the line breaks, the registered sinks should be safe for the concurrent
+// writes as they're shared by the sink configs of the name.
+type Sink interface {
+	Write(lines [][]byte) error
+}
+
+var (
+	_registeredMu sync.Mutex
+	_registered   = map[string]Sink{}
+)
+
+// RegisterSink registers the sink referred by the name of the sink config.
+func RegisterSink(name string, s Sink) {
+	_registeredMu.Lock()
+	defer _registeredMu.Unlock()
+	_registered[name] = s
+}
+
+// asyncSink buffers the lines written to the sink in the background, the
+// lines are dropped once the buffer is full or the sink is failed.
+type asyncSink struct {
+	name      string
+	sink      Sink
+	lines     chan []byte
+	batchSize int
+	// failed is logged once the sink is failed, until it's recovered.
+	failed bool
+}
+
+func newAsyncSink(name string, sink Sink, bufferSize, batchSize int) *asyncSink {
+	s := &asyncSink{name: name, sink: sink, lines: make(chan []byte, bufferSize), batchSize: batchSize}
+	go s.run()
+	return s
+}
+
+func (s *asyncSink) write(line []byte) {
+	select {
+	case s.lines <- line:
+	default:
+		_metricDroppedTotal.WithLabelValues(s.name, "full").Inc()
+	}
+}
+
+func (s *asyncSink) run() {
+	batch := make([][]byte, 0, s.batchSize)
+	for line := range s.lines {
+		batch = append(batch[:0], line)
+	fill:
+		for len(batch) < s.batchSize {
+			select {
+			case line := <-s.lines:
+				batch = append(batch, line)
+			default:
+				break fill
+			}
+		}
+		if err := s.sink.Write(batch); err != nil {
+			_metricDroppedTotal.WithLabelValues(s.name, "error").Add(float64(len(batch)))
+			if !s.failed {
+				s.failed = true
+				log.Errorf("Failed to write the access logs to the %s sink: %+v", s.name, err)
+			}
+			continue
+		}
+		if s.failed {
+			s.failed = false
+			log.Infof("The %s sink of the access logs is recovered", s.name)
+		}
+	}
+}
+
+// writerSink writes the lines to the writer, e.g. the stdout.
+type writerSink struct {
+	w io.Writer
+}
+
+func (s *writerSink) Write(lines [][]byte) error {
+	var buf []byte
+	for _, line := range lines {
+		buf = append(append(buf, line...), '\n')
+	}
+	_, err := s.w.Write(buf)
+	return err
+}
+
+// fileSink rotates the file once it's larger than the max bytes, the file of
+// the path is renamed to path.1, and path.1 to path.2 and so on.
+type fileSink struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func newFileSink(c *v1.FileSink) (*fileSink, error) {
+	s := &fileSink{path: c.Path, maxBytes: c.MaxBytes, maxBackups: int(c.MaxBackups)}
+	if s.path == "" {
+		return nil, fmt.Errorf("access log file path is required")
+	}
+	if s.maxBytes <= 0 {
+		s.maxBytes = _defaultMaxBytes
+	}
+	if s.maxBackups <= 0 {
+		s.maxBackups = _defaultMaxBackups
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, info.Size()
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+	}
+	_ = os.Remove(s.path + "." + strconv.Itoa(s.maxBackups))
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(s.path+"."+strconv.Itoa(i), s.path+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) Write(lines [][]byte) error {
+	var buf []byte
+	for _, line := range lines {
+		buf = append(append(buf, line...), '\n')
+	}
+	if s.f == nil || (s.size > 0 && s.size+int64(len(buf)) > s.maxBytes) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(buf)
+	s.size += int64(n)
+	return err
+}
+
+func newSink(c *v1.Sink) (string, Sink, error) {
+	switch sink := c.Sink.(type) {
+	case *v1.Sink_File:
+		s, err := newFileSink(sink.File)
+		return "file", s, err
+	case *v1.Sink_Syslog:
+		s, err := newSyslogSink(sink.Syslog)
+		return "syslog", s, err
+	case *v1.Sink_Kafka:
+		s, err := newKafkaSink(sink.Kafka)
+		return "kafka", s, err
+	case *v1.Sink_Fluent:
+		s, err := newFluentSink(sink.Fluent)
+		return "fluent", s, err
+	case *v1.Sink_Name:
+		_registeredMu.Lock()
+		defer _registeredMu.Unlock()
+		s, ok := _registered[sink.Name]
+		if !ok {
+			return "", nil, fmt.Errorf("access log sink is not registered: %s", sink.Name)
+		}
+		return sink.Name, s, nil
+	}
+	return "", nil, fmt.Errorf("access log sink is required")
+}
+
+var (
+	_sinksMu sync.Mutex
+	// the sinks are shared by the middlewares of the same config, and kept
+	// across the config updates.
+	_sinks = map[string]*asyncSink{}
+)
+
+// sinkOf returns the sink of the config, or the one of the output if nil.
+func sinkOf(c *v1.Sink, output string) (*asyncSink, error) {
+	if output == "" {
+		output = "stdout"
+	}
+	key := "output:" + output
+	if c != nil {
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		key = "sink:" + string(data)
+	}
+	_sinksMu.Lock()
+	defer _sinksMu.Unlock()
+	if s, ok := _sinks[key]; ok {
+		return s, nil
+	}
+	var (
+		name string
+		sink Sink
+	)
+	bufferSize, batchSize := _defaultBufferSize, _defaultBatchSize
+	switch {
+	case c != nil:
+		var err error
+		if name, sink, err = newSink(c); err != nil {
+			return nil, err
+		}
+		if c.BufferSize > 0 {
+			bufferSize = int(c.BufferSize)
+		}
+		if c.BatchSize > 0 {
+			batchSize = int(c.BatchSize)
+		}
+	case output == "stdout":
+		name, sink = output, &writerSink{w: os.Stdout}
+	case output == "stderr":
+		name, sink = output, &writerSink{w: os.Stderr}
+	default:
+		f, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		name, sink = "file", &writerSink{w: f}
+	}
+	s := newAsyncSink(name, sink, bufferSize, batchSize)
+	_sinks[key] = s
+	return s, nil
+}
@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	s, err := newFileSink(&v1.FileSink{Path: path, MaxBytes: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"one", "two", "three", "four", "five"} {
+		if err := s.Write([][]byte{[]byte(line), []byte(line)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]string{"": "five\nfive\n", ".1": "four\nfour\n", ".2": "three\nthree\n", ".3": ""}
+	for suffix, content := range want {
+		data, err := ioutil.ReadFile(path + suffix)
+		if err != nil && content != "" {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("want %q of the file%s but got %q", content, suffix, data)
+		}
+	}
+}
+
+type recordedSink struct {
+	mu    sync.Mutex
+	lines []string
+	block chan struct{}
+}
+
+func (s *recordedSink) Write(lines [][]byte) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range lines {
+		s.lines = append(s.lines, string(line))
+	}
+	return nil
+}
+
+func (s *recordedSink) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lines...)
+}
+
+func TestRegisteredSink(t *testing.T) {
+	s := &recordedSink{}
+	RegisterSink("recorded", s)
+	tripper := newTripper(t, &v1.Logging{Template: "{path}", Sink: &v1.Sink{Sink: &v1.Sink_Name{Name: "recorded"}}})
+	send(t, tripper, "/users/*", "/users/1")
+	send(t, tripper, "/users/*", "/users/2")
+	for i := 0; i < 100 && len(s.recorded()) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := strings.Join(s.recorded(), ","); got != "/users/1,/users/2" {
+		t.Fatalf("want the lines of the registered sink but got %s", got)
+	}
+	if _, err := sinkOf(&v1.Sink{Sink: &v1.Sink_Name{Name: "unknown"}}, ""); err == nil {
+		t.Fatal("want the error of the sink not registered")
+	}
+}
+
+func TestDropped(t *testing.T) {
+	s := &recordedSink{block: make(chan struct{})}
+	dropped := _metricDroppedTotal.WithLabelValues("blocked", "full")
+	before := testutil.ToFloat64(dropped)
+	async := newAsyncSink("blocked", s, 1, 1)
+	// the first line is blocked in the sink, and the second one is buffered.
+	async.write([]byte("1"))
+	for i := 0; i < 100 && len(async.lines) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	async.write([]byte("2"))
+	async.write([]byte("3"))
+	if got := testutil.ToFloat64(dropped) - before; got != 1 {
+		t.Fatalf("want 1 line dropped but got %v", got)
+	}
+	close(s.block)
+}
+
+// serve serves the first connection accepted by the listener.
+func serve(t *testing.T, handle func(conn net.Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+	return ln.Addr().String()
+}
+
+func TestKafkaSink(t *testing.T) {
+	batches := make(chan []byte, 1)
+	addr := serve(t, func(conn net.Conn) {
+		host, port, _ := net.SplitHostPort(conn.LocalAddr().String())
+		portNum, _ := strconv.Atoi(port)
+		for {
+			var size [4]byte
+			if _, err := io.ReadFull(conn, size[:]); err != nil {
+				return
+			}
+			req := make([]byte, binary.BigEndian.Uint32(size[:]))
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			d := &kafkaDecoder{buf: req}
+			key, _, id, _ := d.int16(), d.int16(), d.int32(), d.string()
+			e := &kafkaEncoder{buf: make([]byte, 4)}
+			e.int32(id)
+			switch key {
+			case _kafkaMetadataKey:
+				e.int32(1)
+				e.int32(1)
+				e.string(host)
+				e.int32(int32(portNum))
+				e.int32(1)
+				e.int16(0)
+				e.string("access")
+				e.int32(1)
+				e.int16(0)
+				e.int32(0)
+				e.int32(1)
+				e.int32(0)
+				e.int32(0)
+			case _kafkaProduceKey:
+				d.int16()
+				d.int16()
+				d.int32()
+				d.int32()
+				d.string()
+				d.int32()
+				d.int32()
+				batches <- d.next(int(d.int32()))
+				e.int32(1)
+				e.string("access")
+				e.int32(1)
+				e.int32(0)
+				e.int16(0)
+				e.int64(0)
+				e.int64(-1)
+				e.int32(0)
+			}
+			binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+			if _, err := conn.Write(e.buf); err != nil {
+				return
+			}
+		}
+	})
+	s, err := newKafkaSink(&v1.KafkaSink{Brokers: []string{addr}, Topic: "access"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([][]byte{[]byte("hello"), []byte("world")}); err != nil {
+		t.Fatal(err)
+	}
+	batch := <-batches
+	if crc := binary.BigEndian.Uint32(batch[17:]); crc != crc32.Checksum(batch[21:], _crc32c) {
+		t.Fatalf("want the crc of the batch but got %d", crc)
+	}
+	if n := binary.BigEndian.Uint32(batch[57:]); n != 2 || !bytes.Contains(batch, []byte("hello")) || !bytes.Contains(batch, []byte("world")) {
+		t.Fatalf("want the records of the lines but got %q", batch)
+	}
+}
+
+func TestFluentSink(t *testing.T) {
+	messages := make(chan []byte, 1)
+	addr := serve(t, func(conn net.Conn) {
+		data, _ := ioutil.ReadAll(conn)
+		messages <- data
+	})
+	s, err := newFluentSink(&v1.FluentSink{Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([][]byte{[]byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	s.(*fluentSink).conn.Close()
+	data := <-messages
+	want := []byte("\x92\xaegateway.access\x91\x92\xce")
+	if !bytes.HasPrefix(data, want) || !bytes.HasSuffix(data, []byte("\x81\xa3log\xa5hello")) || len(data) != len(want)+4+11 {
+		t.Fatalf("want the forward message but got %q", data)
+	}
+}
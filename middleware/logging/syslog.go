@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package logging
+
+import (
+	"log/syslog"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+)
+
+// syslogSink writes the lines to the syslog, the local syslog is dialed if
+// the address is empty.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink(c *v1.SyslogSink) (Sink, error) {
+	tag := c.Tag
+	if tag == "" {
+		tag = "gateway"
+	}
+	w, err := syslog.Dial(c.Network, c.Address, syslog.LOG_INFO|syslog.LOG_LOCAL0, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(lines [][]byte) error {
+	for _, line := range lines {
+		// the writer reconnects once the connection is broken.
+		if err := s.w.Info(string(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package logging
+
+import (
+	"fmt"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/logging/v1"
+)
+
+func newSyslogSink(c *v1.SyslogSink) (Sink, error) {
+	return nil, fmt.Errorf("syslog sink is not supported on this platform")
+}
@@ -0,0 +1,321 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/metrics/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultMaxLabelValues = 1000
+	// the label value of the routes and the clusters beyond the max values.
+	_otherValue = "other"
+)
+
+var (
+	_defaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	_defaultSizeBuckets     = prometheus.ExponentialBuckets(64, 4, 10)
+
+	_standardMethods = map[string]bool{
+		http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+		http.MethodDelete: true, http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+	}
+
+	_metricRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "route_requests_total",
+		Help:      "The total number of the requests by the routes",
+	}, []string{"route", "method", "status_class", "cluster"})
+	_metricRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "route_requests_in_flight",
+		Help:      "The number of the requests in flight by the routes",
+	}, []string{"route", "method"})
+)
+
+func init() {
+	middleware.Register("metrics", Middleware)
+	prometheus.MustRegister(_metricRequestsTotal)
+	prometheus.MustRegister(_metricRequestsInFlight)
+}
+
+// histograms are replaced once the buckets are changed, they're shared by
+// the routes, so the buckets of the middleware built last are applied.
+type histograms struct {
+	durationBuckets []float64
+	sizeBuckets     []float64
+	duration        *prometheus.HistogramVec
+	requestSize     *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+}
+
+func newHistograms(durationBuckets, sizeBuckets []float64) *histograms {
+	labels := []string{"route", "method", "status_class", "cluster"}
+	return &histograms{
+		durationBuckets: durationBuckets,
+		sizeBuckets:     sizeBuckets,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "go",
+			Subsystem: "gateway",
+			Name:      "route_request_duration_seconds",
+			Help:      "The durations of the requests by the routes until the responses are sent",
+			Buckets:   durationBuckets,
+		}, labels),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "go",
+			Subsystem: "gateway",
+			Name:      "route_request_size_bytes",
+			Help:      "The sizes of the request bodies by the routes",
+			Buckets:   sizeBuckets,
+		}, labels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "go",
+			Subsystem: "gateway",
+			Name:      "route_response_size_bytes",
+			Help:      "The sizes of the response bodies by the routes",
+			Buckets:   sizeBuckets,
+		}, labels),
+	}
+}
+
+func (h *histograms) collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.duration, h.requestSize, h.responseSize}
+}
+
+func equalBuckets(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	_histogramsMu sync.RWMutex
+	_histograms   *histograms
+)
+
+// setHistograms registers the histograms of the buckets if they're changed.
+func setHistograms(durationBuckets, sizeBuckets []float64) error {
+	_histogramsMu.Lock()
+	defer _histogramsMu.Unlock()
+	if h := _histograms; h != nil && equalBuckets(h.durationBuckets, durationBuckets) && equalBuckets(h.sizeBuckets, sizeBuckets) {
+		return nil
+	}
+	if h := _histograms; h != nil {
+		for _, c := range h.collectors() {
+			prometheus.Unregister(c)
+		}
+	}
+	h := newHistograms(durationBuckets, sizeBuckets)
+	for _, c := range h.collectors() {
+		if err := prometheus.Register(c); err != nil {
+			return err
+		}
+	}
+	_histograms = h
+	return nil
+}
+
+func currentHistograms() *histograms {
+	_histogramsMu.RLock()
+	defer _histogramsMu.RUnlock()
+	return _histograms
+}
+
+// guard limits the distinct values of the labels, the values beyond the max
+// values are labeled as other.
+type guard struct {
+	mu        sync.Mutex
+	maxValues int
+	values    map[string]map[string]struct{}
+}
+
+var _guard = &guard{maxValues: _defaultMaxLabelValues, values: map[string]map[string]struct{}{}}
+
+func (g *guard) setMaxValues(n int) {
+	g.mu.Lock()
+	g.maxValues = n
+	g.mu.Unlock()
+}
+
+func (g *guard) value(label, value string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	values, ok := g.values[label]
+	if !ok {
+		values = map[string]struct{}{}
+		g.values[label] = values
+	}
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= g.maxValues {
+		return _otherValue
+	}
+	values[value] = struct{}{}
+	return value
+}
+
+// statusClass returns the class of the status code responded by the proxy,
+// e.g. 2xx, the errors are responded with the status codes of the proxy.
+func statusClass(resp *http.Response, err error) string {
+	code := http.StatusBadGateway
+	var statusError *middleware.StatusError
+	switch {
+	case err == nil:
+		code = resp.StatusCode
+	case errors.As(err, &statusError):
+		code = statusError.StatusCode
+	case errors.Is(err, context.Canceled):
+		code = 499
+	case errors.Is(err, context.DeadlineExceeded):
+		code = http.StatusGatewayTimeout
+	}
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// countingBody counts the bytes read, the done func is called once at the
+// end of the body or once it's closed if not nil.
+type countingBody struct {
+	io.ReadCloser
+	read int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.read, int64(n))
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *countingBody) finish() {
+	b.once.Do(func() {
+		if b.done != nil {
+			b.done(atomic.LoadInt64(&b.read))
+		}
+	})
+}
+
+// Middleware observes the requests by the routes, the methods, the status
+// classes and the clusters, the metrics are exposed by /metrics.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.Metrics{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	durationBuckets := _defaultDurationBuckets
+	if len(options.DurationBuckets) > 0 {
+		durationBuckets = options.DurationBuckets
+	}
+	sizeBuckets := _defaultSizeBuckets
+	if len(options.SizeBuckets) > 0 {
+		sizeBuckets = options.SizeBuckets
+	}
+	for _, buckets := range [][]float64{durationBuckets, sizeBuckets} {
+		for i := 1; i < len(buckets); i++ {
+			if buckets[i] <= buckets[i-1] {
+				return nil, fmt.Errorf("metrics buckets should be in the increasing order: %v", buckets)
+			}
+		}
+	}
+	if err := setHistograms(durationBuckets, sizeBuckets); err != nil {
+		return nil, err
+	}
+	maxValues := _defaultMaxLabelValues
+	if options.MaxLabelValues > 0 {
+		maxValues = int(options.MaxLabelValues)
+	}
+	_guard.setMaxValues(maxValues)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			startTime := time.Now()
+			route := ""
+			reqOpts, _ := middleware.FromRequestContext(req.Context())
+			if reqOpts != nil {
+				route = reqOpts.Endpoint.GetPath()
+			}
+			route = _guard.value("route", route)
+			method := req.Method
+			if !_standardMethods[method] {
+				method = "OTHER"
+			}
+			inFlight := _metricRequestsInFlight.WithLabelValues(route, method)
+			inFlight.Inc()
+			var reqBody *countingBody
+			if req.Body != nil && req.Body != http.NoBody {
+				reqBody = &countingBody{ReadCloser: req.Body}
+				req.Body = reqBody
+			}
+			resp, err := next.RoundTrip(req)
+			class := statusClass(resp, err)
+			cluster := ""
+			if reqOpts != nil {
+				cluster = reqOpts.Cluster
+			}
+			cluster = _guard.value("cluster", cluster)
+			observe := func(sent int64) {
+				inFlight.Dec()
+				_metricRequestsTotal.WithLabelValues(route, method, class, cluster).Inc()
+				h := currentHistograms()
+				if h == nil {
+					return
+				}
+				received := req.ContentLength
+				if reqBody != nil {
+					received = atomic.LoadInt64(&reqBody.read)
+				}
+				if received < 0 {
+					received = 0
+				}
+				h.duration.WithLabelValues(route, method, class, cluster).Observe(time.Since(startTime).Seconds())
+				h.requestSize.WithLabelValues(route, method, class, cluster).Observe(float64(received))
+				h.responseSize.WithLabelValues(route, method, class, cluster).Observe(float64(sent))
+			}
+			if err != nil {
+				observe(0)
+				return nil, err
+			}
+			if resp.Body == nil || resp.Body == http.NoBody {
+				observe(0)
+				return resp, nil
+			}
+			resp.Body = &countingBody{ReadCloser: resp.Body, done: observe}
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/metrics/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		resp *http.Response
+		err  error
+		want string
+	}{
+		{&http.Response{StatusCode: 204}, nil, "2xx"},
+		{&http.Response{StatusCode: 404}, nil, "4xx"},
+		{nil, &middleware.StatusError{StatusCode: 413}, "4xx"},
+		{nil, context.Canceled, "4xx"},
+		{nil, context.DeadlineExceeded, "5xx"},
+		{nil, errors.New("connection reset"), "5xx"},
+	}
+	for _, test := range tests {
+		if got := statusClass(test.resp, test.err); got != test.want {
+			t.Errorf("want %s of %v but got %s", test.want, test.err, got)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	any, err := anypb.New(&v1.Metrics{DurationBuckets: []float64{0.1, 1}, SizeBuckets: []float64{10, 100}, MaxLabelValues: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "metrics", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tripper := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if reqOpts, ok := middleware.FromRequestContext(req.Context()); ok {
+			reqOpts.Cluster = "blue"
+		}
+		data, _ := ioutil.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(string(data) + "!"))}, nil
+	}))
+	roundTrip := func(route, method string) *http.Response {
+		req := httptest.NewRequest(method, "/users/1", strings.NewReader("hello"))
+		reqOpts := middleware.NewRequestOptions(&config.Endpoint{Path: route})
+		resp, err := tripper.RoundTrip(req.WithContext(middleware.NewRequestContext(req.Context(), reqOpts)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	send := func(route, method string) {
+		resp := roundTrip(route, method)
+		_, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	resp := roundTrip("/users/*", "POST")
+	// the request is in flight until the body is read.
+	if got := testutil.ToFloat64(_metricRequestsInFlight.WithLabelValues("/users/*", "POST")); got != 1 {
+		t.Fatalf("want the request in flight but got %v", got)
+	}
+	_, _ = ioutil.ReadAll(resp.Body)
+	send("/users/*", "PURGE")
+	if got := testutil.ToFloat64(_metricRequestsTotal.WithLabelValues("/users/*", "POST", "2xx", "blue")); got != 1 {
+		t.Fatalf("want 1 request but got %v", got)
+	}
+	if got := testutil.ToFloat64(_metricRequestsTotal.WithLabelValues("/users/*", "OTHER", "2xx", "blue")); got != 1 {
+		t.Fatalf("want 1 request of the other method but got %v", got)
+	}
+	if got := testutil.ToFloat64(_metricRequestsInFlight.WithLabelValues("/users/*", "POST")); got != 0 {
+		t.Fatalf("want no request in flight but got %v", got)
+	}
+	h := currentHistograms()
+	if n := testutil.CollectAndCount(h.responseSize); n != 2 {
+		t.Fatalf("want 2 series of the response sizes but got %d", n)
+	}
+
+	// the routes beyond the max label values are labeled as other.
+	send("/orders/*", "GET")
+	send("/items/*", "GET")
+	if got := testutil.ToFloat64(_metricRequestsTotal.WithLabelValues(_otherValue, "GET", "2xx", "blue")); got != 1 {
+		t.Fatalf("want 1 request of the other route but got %v", got)
+	}
+
+	if _, err := Middleware(&config.Middleware{Name: "metrics", Options: mustAny(t, &v1.Metrics{SizeBuckets: []float64{100, 10}})}); err == nil {
+		t.Fatal("want the error of the buckets not increasing")
+	}
+}
+
+func mustAny(t *testing.T, options *v1.Metrics) *anypb.Any {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return any
+}
@@ -0,0 +1,156 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/mirror/v1"
+	"github.com/go-kratos/gateway/client"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultTimeout = time.Second
+	// the mirrored requests are dropped if there are too many in flight.
+	_maxInflight = 1024
+)
+
+func Init(clientFactory client.Factory) {
+	middleware.Register("mirror", New(clientFactory))
+	prometheus.MustRegister(_metricMirroredTotal)
+}
+
+var (
+	_metricMirroredTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "go",
+		Subsystem: "gateway",
+		Name:      "requests_mirrored_total",
+		Help:      "The total number of mirrored requests",
+	}, []string{"protocol", "method", "path", "service", "basePath", "result"})
+)
+
+func mirroredRequestIncr(labels middleware.MetricsLabels, result string) {
+	_metricMirroredTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), result).Inc()
+}
+
+type mirror struct {
+	endpoint   *config.Endpoint
+	client     http.RoundTripper
+	percentage float64
+	timeout    time.Duration
+	inflight   chan struct{}
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+func (m *mirror) sampled() bool {
+	if m.percentage >= 100 {
+		return true
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.rand.Float64()*100 < m.percentage
+}
+
+// send copies the request to the mirror in background, the response is discarded.
+func (m *mirror) send(req *http.Request) {
+	labels, ok := middleware.MetricsLabelsFromContext(req.Context())
+	if !ok {
+		return
+	}
+	if req.GetBody == nil && req.Body != nil && req.Body != http.NoBody {
+		// the body can't be read twice.
+		return
+	}
+	select {
+	case m.inflight <- struct{}{}:
+	default:
+		mirroredRequestIncr(labels, "dropped")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	ctx = middleware.NewRequestContext(ctx, middleware.NewRequestOptions(m.endpoint))
+	shadow := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			<-m.inflight
+			return
+		}
+		shadow.Body = body
+	}
+	go func() {
+		defer func() {
+			cancel()
+			<-m.inflight
+		}()
+		resp, err := m.client.RoundTrip(shadow)
+		if err != nil {
+			mirroredRequestIncr(labels, "failed")
+			log.Debugf("failed to mirror request: %s: %+v", req.URL.Path, err)
+			return
+		}
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		mirroredRequestIncr(labels, "success")
+	}()
+}
+
+// New returns the mirror middleware factory, the mirror clients are created by the client factory.
+func New(factory client.Factory) middleware.Factory {
+	return func(c *config.Middleware) (middleware.Middleware, error) {
+		options := &v1.Mirror{}
+		if c.Options != nil {
+			if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+				return nil, err
+			}
+		}
+		if options.Endpoint == nil {
+			return nil, errors.New("mirror endpoint is required")
+		}
+		client, err := factory(options.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		m := &mirror{
+			endpoint:   options.Endpoint,
+			client:     client,
+			percentage: 100,
+			timeout:    _defaultTimeout,
+			inflight:   make(chan struct{}, _maxInflight),
+			rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		}
+		if options.Percentage != nil {
+			if p := options.GetPercentage(); p < 0 || p > 100 {
+				return nil, fmt.Errorf("mirror percentage should be in [0, 100]: %v", p)
+			}
+			m.percentage = options.GetPercentage()
+		}
+		if options.Endpoint.Timeout != nil {
+			m.timeout = options.Endpoint.Timeout.AsDuration()
+		}
+		return func(next http.RoundTripper) http.RoundTripper {
+			return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				// the retries are not mirrored.
+				if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok && len(reqOpt.Backends) == 0 && m.sampled() {
+					m.send(req)
+				}
+				return next.RoundTrip(req)
+			})
+		}, nil
+	}
+}
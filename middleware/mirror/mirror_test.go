@@ -0,0 +1,98 @@
+package mirror
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/mirror/v1"
+	"github.com/go-kratos/gateway/client"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestMirror(t *testing.T) {
+	mirrored := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mirrored <- r.URL.Path + " " + string(body)
+		_, _ = w.Write([]byte("discarded"))
+	}))
+	defer srv.Close()
+
+	newMiddleware := func(percentage *float64) middleware.Middleware {
+		options, err := anypb.New(&v1.Mirror{
+			Endpoint: &config.Endpoint{
+				Protocol: config.Protocol_HTTP,
+				Backends: []*config.Backend{{Target: strings.TrimPrefix(srv.URL, "http://")}},
+			},
+			Percentage: percentage,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := New(client.NewFactory(nil))(&config.Middleware{Name: "mirror", Options: options})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	next := middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	do := func(rt http.RoundTripper, backends ...string) {
+		body := []byte("hello")
+		req := httptest.NewRequest("POST", "/api/echo", bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/api/*"})
+		reqOpt.Backends = backends
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want the response of the primary but got: %d", resp.StatusCode)
+		}
+	}
+
+	do(newMiddleware(nil)(next))
+	select {
+	case got := <-mirrored:
+		if got != "/api/echo hello" {
+			t.Fatalf("unexpected mirrored request: %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("want the request to be mirrored")
+	}
+
+	// neither the retries nor the unsampled requests are mirrored.
+	do(newMiddleware(nil)(next), "127.0.0.1:8000")
+	do(newMiddleware(proto.Float64(0))(next))
+	select {
+	case got := <-mirrored:
+		t.Fatalf("unexpected mirrored request: %s", got)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestMirrorInvalid(t *testing.T) {
+	for _, options := range []*v1.Mirror{
+		{},
+		{Endpoint: &config.Endpoint{Backends: []*config.Backend{{Target: "127.0.0.1:8000"}}}, Percentage: proto.Float64(120)},
+	} {
+		any, _ := anypb.New(options)
+		if _, err := New(client.NewFactory(nil))(&config.Middleware{Name: "mirror", Options: any}); err == nil {
+			t.Errorf("want error of the options: %v", options)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package mtls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/mtls/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func init() {
+	middleware.Register("mtls", Middleware)
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+func newSet(values []string, fold func(string) string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[fold(v)] = struct{}{}
+	}
+	return set
+}
+
+func identity(s string) string { return s }
+
+// fingerprint returns the SHA-256 fingerprint of the certificate in hex.
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// sans returns the DNS, email and URI SANs of the certificate.
+func sans(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs))
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	return names
+}
+
+type authorizer struct {
+	commonNames  map[string]struct{}
+	sans         map[string]struct{}
+	fingerprints map[string]struct{}
+}
+
+func (a *authorizer) allowed(cert *x509.Certificate) bool {
+	if a.commonNames != nil {
+		if _, ok := a.commonNames[cert.Subject.CommonName]; !ok {
+			return false
+		}
+	}
+	if a.fingerprints != nil {
+		if _, ok := a.fingerprints[fingerprint(cert)]; !ok {
+			return false
+		}
+	}
+	if a.sans != nil {
+		for _, name := range sans(cert) {
+			if _, ok := a.sans[name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Middleware authorizes the requests by the verified client certificates.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.MTLS{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	a := &authorizer{
+		commonNames:  newSet(options.CommonNames, identity),
+		sans:         newSet(options.Sans, identity),
+		fingerprints: newSet(options.Fingerprints, strings.ToLower),
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// the headers are always replaced to not trust the clients.
+			for _, header := range []string{options.SubjectHeader, options.SanHeader, options.FingerprintHeader} {
+				if header != "" {
+					req.Header.Del(header)
+				}
+			}
+			// the peer certificates are verified by the listener only if
+			// the verified chains are set.
+			if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+				audit.AuthenticationFailed(req, "mtls", http.StatusForbidden, "client certificate is required")
+				return newResponse(http.StatusForbidden)
+			}
+			cert := req.TLS.VerifiedChains[0][0]
+			if !a.allowed(cert) {
+				audit.PolicyDenied(req, "mtls", cert.Subject.CommonName, http.StatusForbidden, "client certificate is not allowed")
+				return newResponse(http.StatusForbidden)
+			}
+			if consumer, ok := middleware.GetConsumers().ByName(cert.Subject.CommonName); ok {
+				if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+					audit.PolicyDenied(req, "mtls", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+					return newResponse(http.StatusForbidden)
+				}
+				middleware.WithConsumer(req.Context(), consumer)
+			}
+			if options.SubjectHeader != "" {
+				req.Header.Set(options.SubjectHeader, cert.Subject.String())
+			}
+			if options.SanHeader != "" {
+				if names := sans(cert); len(names) > 0 {
+					req.Header.Set(options.SanHeader, strings.Join(names, ","))
+				}
+			}
+			if options.FingerprintHeader != "" {
+				req.Header.Set(options.FingerprintHeader, fingerprint(cert))
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,99 @@
+package mtls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/mtls/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestMTLS(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.com/orders")
+	orders := &x509.Certificate{
+		Raw:      []byte("orders"),
+		Subject:  pkix.Name{CommonName: "orders", Organization: []string{"example"}},
+		DNSNames: []string{"orders.example.com"},
+		URIs:     []*url.URL{spiffe},
+	}
+	other := &x509.Certificate{Raw: []byte("other"), Subject: pkix.Name{CommonName: "other"}}
+	middleware.SetConsumers([]*config.Consumer{{Name: "orders"}})
+	defer middleware.SetConsumers(nil)
+	any, err := anypb.New(&v1.MTLS{
+		Sans:              []string{"spiffe://example.com/orders"},
+		SubjectHeader:     "X-Client-Subject",
+		SanHeader:         "X-Client-SAN",
+		FingerprintHeader: "X-Client-Fingerprint",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "mtls", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func(cert *x509.Certificate) (*http.Response, *middleware.RequestOptions) {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.Header.Set("X-Client-Subject", "CN=admin")
+		if cert != nil {
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/orders"})
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, reqOpt
+	}
+
+	resp, reqOpt := do(orders)
+	if resp.StatusCode != http.StatusOK || reqOpt.Consumer == nil || reqOpt.Consumer.Name != "orders" {
+		t.Fatalf("want the consumer orders but got %d %v", resp.StatusCode, reqOpt.Consumer)
+	}
+	if upstream.Get("X-Client-Subject") != "CN=orders,O=example" ||
+		upstream.Get("X-Client-SAN") != "orders.example.com,spiffe://example.com/orders" ||
+		upstream.Get("X-Client-Fingerprint") != fingerprint(orders) {
+		t.Fatalf("unexpected upstream headers: %v", upstream)
+	}
+	if resp, _ := do(other); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("want the SAN not allowed but got %d", resp.StatusCode)
+	}
+	if resp, _ := do(nil); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("want the request without certificate rejected but got %d", resp.StatusCode)
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("orders"), Subject: pkix.Name{CommonName: "orders"}}
+	testCases := []struct {
+		a    *authorizer
+		want bool
+	}{
+		{&authorizer{}, true},
+		{&authorizer{commonNames: newSet([]string{"orders"}, identity)}, true},
+		{&authorizer{commonNames: newSet([]string{"users"}, identity)}, false},
+		{&authorizer{fingerprints: newSet([]string{fingerprint(cert)}, identity)}, true},
+		{&authorizer{fingerprints: newSet([]string{"00"}, identity)}, false},
+		{&authorizer{sans: newSet([]string{"orders.example.com"}, identity)}, false},
+	}
+	for i, tc := range testCases {
+		if got := tc.a.allowed(cert); got != tc.want {
+			t.Errorf("%d: want %t but got %t", i, tc.want, got)
+		}
+	}
+}
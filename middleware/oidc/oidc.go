@@ -0,0 +1,337 @@
+package oidc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/oidc/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultCookieName = "_gateway_oidc"
+	_minSecretLength   = 16
+	// the lifetime of the login state from the redirect to the callback.
+	_stateTTL = 10 * time.Minute
+)
+
+var _defaultScopes = []string{"openid", "profile", "email"}
+
+func init() {
+	middleware.Register("oidc", Middleware)
+}
+
+// session is the identity kept in the session cookie.
+type session struct {
+	Subject string            `json:"sub"`
+	Claims  map[string]string `json:"claims,omitempty"`
+	Expiry  int64             `json:"exp"`
+}
+
+// state is the login state kept in the state cookie from the redirect to the
+// callback.
+type state struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	URL      string `json:"url"`
+	Expiry   int64  `json:"exp"`
+}
+
+type oidc struct {
+	options     *v1.OIDC
+	provider    *provider
+	redirectURL *url.URL
+	secret      []byte
+	cookieName  string
+	scopes      []string
+}
+
+// sign encodes the value as the cookie value signed by the secret, the name
+// of the cookie is signed either so that a cookie can't be used as another.
+func (o *oidc) sign(name string, v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	mac := hmac.New(sha256.New, o.secret)
+	_, _ = mac.Write([]byte(name + "=" + payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verify decodes the cookie value if it's signed by the secret.
+func (o *oidc) verify(name, value string, v interface{}) error {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return errors.New("malformed cookie")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, o.secret)
+	_, _ = mac.Write([]byte(name + "=" + value[:i]))
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return errors.New("invalid cookie signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value[:i])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (o *oidc) stateCookieName() string {
+	return o.cookieName + "_state"
+}
+
+func (o *oidc) cookie(name, value string, path string, expiry time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Expires:  expiry,
+		Secure:   o.redirectURL.Scheme == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// isBrowser reports whether the request is a navigation of the browser which
+// can be redirected to the identity provider.
+func isBrowser(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) &&
+		strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+func newResponse(statusCode int, header http.Header) (*http.Response, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+func redirect(location string, cookies ...*http.Cookie) (*http.Response, error) {
+	header := http.Header{"Location": []string{location}}
+	for _, c := range cookies {
+		header.Add("Set-Cookie", c.String())
+	}
+	return newResponse(http.StatusFound, header)
+}
+
+// login redirects the request to the authorization endpoint.
+func (o *oidc) login(req *http.Request) (*http.Response, error) {
+	m, err := o.provider.discover(req.Context())
+	if err != nil {
+		log.Errorf("Failed to discover OIDC provider %s: %+v", o.options.Issuer, err)
+		return newResponse(http.StatusBadGateway, nil)
+	}
+	s := &state{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		URL:      req.URL.RequestURI(),
+		Expiry:   time.Now().Add(_stateTTL).Unix(),
+	}
+	value, err := o.sign(o.stateCookieName(), s)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(s.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.options.ClientId},
+		"redirect_uri":          {o.redirectURL.String()},
+		"scope":                 {strings.Join(o.scopes, " ")},
+		"state":                 {s.State},
+		"nonce":                 {s.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	location := m.AuthorizationEndpoint
+	if strings.Contains(location, "?") {
+		location += "&" + query.Encode()
+	} else {
+		location += "?" + query.Encode()
+	}
+	return redirect(location, o.cookie(o.stateCookieName(), value, o.redirectURL.Path, time.Unix(s.Expiry, 0)))
+}
+
+// callback exchanges the authorization code for the ID token, and redirects
+// the request to the original URL with the session.
+func (o *oidc) callback(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	if e := query.Get("error"); e != "" {
+		log.Warnf("OIDC authorization failed: %s: %s", e, query.Get("error_description"))
+		audit.AuthenticationFailed(req, "oidc", http.StatusForbidden, "authorization failed: "+e)
+		return newResponse(http.StatusForbidden, nil)
+	}
+	c, err := req.Cookie(o.stateCookieName())
+	if err != nil {
+		return newResponse(http.StatusBadRequest, nil)
+	}
+	s := &state{}
+	if err := o.verify(o.stateCookieName(), c.Value, s); err != nil || time.Now().Unix() > s.Expiry ||
+		!hmac.Equal([]byte(s.State), []byte(query.Get("state"))) {
+		return newResponse(http.StatusBadRequest, nil)
+	}
+	m, err := o.provider.discover(req.Context())
+	if err != nil {
+		log.Errorf("Failed to discover OIDC provider %s: %+v", o.options.Issuer, err)
+		return newResponse(http.StatusBadGateway, nil)
+	}
+	claims, err := o.provider.exchange(req.Context(), m, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {query.Get("code")},
+		"redirect_uri":  {o.redirectURL.String()},
+		"client_id":     {o.options.ClientId},
+		"client_secret": {o.options.ClientSecret},
+		"code_verifier": {s.Verifier},
+	})
+	if err != nil {
+		log.Errorf("Failed to exchange OIDC authorization code: %+v", err)
+		return newResponse(http.StatusBadGateway, nil)
+	}
+	if err := o.provider.validate(claims, o.options.ClientId, s.Nonce); err != nil {
+		log.Warnf("Invalid OIDC ID token: %v", err)
+		audit.AuthenticationFailed(req, "oidc", http.StatusForbidden, err.Error())
+		return newResponse(http.StatusForbidden, nil)
+	}
+	expiry := time.Unix(int64(claims["exp"].(float64)), 0)
+	if o.options.SessionTtl != nil {
+		expiry = time.Now().Add(o.options.SessionTtl.AsDuration())
+	}
+	sess := &session{Subject: claims["sub"].(string), Expiry: expiry.Unix()}
+	for name := range o.options.ClaimHeaders {
+		if claim, ok := middleware.LookupClaim(claims, name); ok {
+			if sess.Claims == nil {
+				sess.Claims = make(map[string]string, len(o.options.ClaimHeaders))
+			}
+			sess.Claims[name] = middleware.FormatClaim(claim)
+		}
+	}
+	value, err := o.sign(o.cookieName, sess)
+	if err != nil {
+		return nil, err
+	}
+	location := s.URL
+	if !strings.HasPrefix(location, "/") || strings.HasPrefix(location, "//") {
+		location = "/"
+	}
+	return redirect(location,
+		o.cookie(o.cookieName, value, "/", expiry),
+		o.cookie(o.stateCookieName(), "", o.redirectURL.Path, time.Unix(0, 0)),
+	)
+}
+
+// removeCookies removes the cookies of the middleware from the request to
+// the upstream.
+func (o *oidc) removeCookies(req *http.Request) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != o.cookieName && c.Name != o.stateCookieName() {
+			req.AddCookie(c)
+		}
+	}
+}
+
+// Middleware authenticates the requests by the OIDC login.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.OIDC{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Issuer == "" || options.ClientId == "" {
+		return nil, errors.New("oidc issuer and client id are required")
+	}
+	if len(options.CookieSecret) < _minSecretLength {
+		return nil, fmt.Errorf("oidc cookie secret should be at least %d bytes", _minSecretLength)
+	}
+	redirectURL, err := url.Parse(options.RedirectUrl)
+	if err != nil || !redirectURL.IsAbs() || redirectURL.Path == "" {
+		return nil, fmt.Errorf("invalid oidc redirect url: %q", options.RedirectUrl)
+	}
+	o := &oidc{
+		options:     options,
+		provider:    newProvider(options.Issuer),
+		redirectURL: redirectURL,
+		secret:      []byte(options.CookieSecret),
+		cookieName:  options.CookieName,
+		scopes:      options.Scopes,
+	}
+	if o.cookieName == "" {
+		o.cookieName = _defaultCookieName
+	}
+	if len(o.scopes) == 0 {
+		o.scopes = _defaultScopes
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Path {
+			case o.redirectURL.Path:
+				return o.callback(req)
+			case options.LogoutPath:
+				if options.LogoutPath != "" {
+					return redirect("/", o.cookie(o.cookieName, "", "/", time.Unix(0, 0)))
+				}
+			}
+			sess := &session{}
+			c, err := req.Cookie(o.cookieName)
+			if err != nil || o.verify(o.cookieName, c.Value, sess) != nil || time.Now().Unix() > sess.Expiry {
+				if isBrowser(req) {
+					return o.login(req)
+				}
+				audit.AuthenticationFailed(req, "oidc", http.StatusUnauthorized, "session is required")
+				return newResponse(http.StatusUnauthorized, nil)
+			}
+			if consumer, ok := middleware.GetConsumers().BySubject(sess.Subject); ok {
+				if e, ok := middleware.EndpointFromContext(req.Context()); ok && !middleware.ConsumerAllowed(consumer, e) {
+					audit.PolicyDenied(req, "oidc", consumer.Name, http.StatusForbidden, "consumer is not allowed to access the route")
+					return newResponse(http.StatusForbidden, nil)
+				}
+				middleware.WithConsumer(req.Context(), consumer)
+			}
+			o.removeCookies(req)
+			for name, key := range options.ClaimHeaders {
+				// the headers are always overwritten to be trusted by the upstream.
+				req.Header.Del(key)
+				if value, ok := sess.Claims[name]; ok {
+					req.Header.Set(key, value)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/oidc/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// newProviderServer serves the discovery and the token endpoint which issues
+// the ID token of the nonce and the challenge of the authorization.
+func newProviderServer(t *testing.T) (*httptest.Server, func(code, nonce, challenge string)) {
+	type grant struct{ nonce, challenge string }
+	grants := map[string]grant{}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 srv.URL,
+				"authorization_endpoint": srv.URL + "/authorize",
+				"token_endpoint":         srv.URL + "/token",
+			})
+		case "/token":
+			g, ok := grants[r.PostFormValue("code")]
+			verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+			if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != g.challenge || r.PostFormValue("client_secret") != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			claims, _ := json.Marshal(map[string]interface{}{
+				"iss":    srv.URL,
+				"aud":    "dashboard",
+				"sub":    "alice",
+				"email":  "alice@example.com",
+				"groups": []string{"admin", "dev"},
+				"nonce":  g.nonce,
+				"exp":    time.Now().Add(time.Hour).Unix(),
+			})
+			idToken := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+			_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv, func(code, nonce, challenge string) {
+		grants[code] = grant{nonce: nonce, challenge: challenge}
+	}
+}
+
+func TestOIDC(t *testing.T) {
+	srv, authorize := newProviderServer(t)
+	defer srv.Close()
+	any, err := anypb.New(&v1.OIDC{
+		Issuer:       srv.URL,
+		ClientId:     "dashboard",
+		ClientSecret: "secret",
+		RedirectUrl:  "https://dashboard.example.com/oauth2/callback",
+		CookieSecret: "0123456789abcdef",
+		ClaimHeaders: map[string]string{"email": "X-User-Email", "groups": "X-User-Groups"},
+		LogoutPath:   "/oauth2/logout",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "oidc", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream *http.Request
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	do := func(rawURL string, header http.Header, cookies ...*http.Cookie) *http.Response {
+		upstream = nil
+		req := httptest.NewRequest("GET", rawURL, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(&config.Endpoint{Path: "/*"})))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	cookieOf := func(resp *http.Response, name string) *http.Cookie {
+		for _, c := range resp.Cookies() {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("want cookie %s of the response", name)
+		return nil
+	}
+	browser := http.Header{"Accept": []string{"text/html,application/xhtml+xml"}}
+
+	if resp := do("/api/orders", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("want 401 of the API request but got %d", resp.StatusCode)
+	}
+	resp := do("/reports?month=1", browser)
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("want the login redirect but got %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := location.Query()
+	if !strings.HasPrefix(location.String(), srv.URL+"/authorize?") || query.Get("client_id") != "dashboard" ||
+		query.Get("code_challenge_method") != "S256" || query.Get("redirect_uri") != "https://dashboard.example.com/oauth2/callback" {
+		t.Fatalf("unexpected authorization redirect: %s", location)
+	}
+	stateCookie := cookieOf(resp, _defaultCookieName+"_state")
+	if stateCookie.Path != "/oauth2/callback" || !stateCookie.Secure || !stateCookie.HttpOnly {
+		t.Fatalf("unexpected state cookie: %s", stateCookie)
+	}
+	authorize("code-1", query.Get("nonce"), query.Get("code_challenge"))
+
+	if resp := do("/oauth2/callback?code=code-1&state=forged", browser, stateCookie); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("want 400 of the forged state but got %d", resp.StatusCode)
+	}
+	resp = do("/oauth2/callback?code=code-1&state="+url.QueryEscape(query.Get("state")), browser, stateCookie)
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/reports?month=1" {
+		t.Fatalf("want the redirect to the original URL but got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	sessionCookie := cookieOf(resp, _defaultCookieName)
+
+	resp = do("/reports", http.Header{"X-User-Email": []string{"mallory@example.com"}}, sessionCookie, &http.Cookie{Name: "theme", Value: "dark"})
+	if resp.StatusCode != http.StatusOK || upstream == nil {
+		t.Fatalf("want the request of the session proxied but got %d", resp.StatusCode)
+	}
+	if got := upstream.Header.Get("X-User-Email"); got != "alice@example.com" {
+		t.Fatalf("want the email of the claims but got %q", got)
+	}
+	if got := upstream.Header.Get("X-User-Groups"); got != "admin,dev" {
+		t.Fatalf("want the groups of the claims but got %q", got)
+	}
+	if got := upstream.Header.Get("Cookie"); got != "theme=dark" {
+		t.Fatalf("want the session cookie removed but got %q", got)
+	}
+	// the state cookie is signed for the state only.
+	if resp := do("/reports", nil, &http.Cookie{Name: _defaultCookieName, Value: stateCookie.Value}); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("want 401 of the state cookie as the session but got %d", resp.StatusCode)
+	}
+
+	resp = do("/oauth2/logout", browser, sessionCookie)
+	if c := cookieOf(resp, _defaultCookieName); resp.StatusCode != http.StatusFound || c.Value != "" {
+		t.Fatalf("want the session cleared but got %d %s", resp.StatusCode, c)
+	}
+}
@@ -0,0 +1,151 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const _fetchTimeout = 10 * time.Second
+
+// metadata is the provider metadata of OpenID Connect Discovery.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// provider discovers the endpoints of the identity provider once, it's
+// retried by the next request if failed.
+type provider struct {
+	issuer string
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+}
+
+func newProvider(issuer string) *provider {
+	return &provider{
+		issuer: strings.TrimSuffix(issuer, "/"),
+		client: &http.Client{Timeout: _fetchTimeout},
+	}
+}
+
+func (p *provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code of discovery: %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	m := &metadata{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(m.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("unexpected issuer of discovery: %s", m.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" {
+		return nil, errors.New("authorization and token endpoints are required")
+	}
+	p.metadata = m
+	return m, nil
+}
+
+// exchange exchanges the authorization code for the claims of the ID token.
+// The signature of the ID token is not verified as it's received from the
+// token endpoint directly by TLS, see OpenID Connect Core 3.1.3.7.
+func (p *provider) exchange(ctx context.Context, m *metadata, form url.Values) (map[string]interface{}, error) {
+	req, err := http.NewRequest(http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code of token: %d: %s", resp.StatusCode, data)
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(token.IDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ID token: %w", err)
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token: %w", err)
+	}
+	return claims, nil
+}
+
+// validate validates the registered claims and the nonce of the ID token.
+func (p *provider) validate(claims map[string]interface{}, clientID, nonce string) error {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != p.issuer {
+		return fmt.Errorf("unexpected issuer: %s", iss)
+	}
+	audienced := false
+	switch aud := claims["aud"].(type) {
+	case string:
+		audienced = aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				audienced = true
+			}
+		}
+	}
+	if !audienced {
+		return fmt.Errorf("unexpected audience: %v", claims["aud"])
+	}
+	if exp, ok := claims["exp"].(float64); !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+		return errors.New("ID token is expired")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return errors.New("unexpected nonce")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return errors.New("ID token without subject")
+	}
+	return nil
+}
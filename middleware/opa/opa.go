@@ -0,0 +1,212 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/opa/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultTimeout = time.Second
+
+func init() {
+	middleware.Register("opa", Middleware)
+}
+
+// input is the attributes of the request to the policy decision.
+type input struct {
+	Method   string              `json:"method"`
+	Host     string              `json:"host"`
+	Path     string              `json:"path"`
+	Query    map[string][]string `json:"query"`
+	Headers  map[string]string   `json:"headers"`
+	Route    string              `json:"route,omitempty"`
+	PathVars map[string]string   `json:"path_vars,omitempty"`
+	Consumer string              `json:"consumer,omitempty"`
+	SourceIP string              `json:"source_ip,omitempty"`
+	Body     string              `json:"body,omitempty"`
+}
+
+// decision is the result of the policy, it's a boolean or an object.
+type decision struct {
+	Allow   bool              `json:"allow"`
+	Headers map[string]string `json:"headers"`
+	Status  int               `json:"status"`
+}
+
+func (d *decision) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &d.Allow); err == nil {
+		return nil
+	}
+	type object decision
+	return json.Unmarshal(data, (*object)(d))
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+// readBody reads the body up to the bytes without consuming it.
+func readBody(req *http.Request, max int64) ([]byte, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(io.LimitReader(body, max))
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if int64(len(data)) > max {
+		data = data[:max]
+	}
+	return data, nil
+}
+
+type client struct {
+	url    string
+	client *http.Client
+}
+
+// decide queries the decision of the input by the data API of the server, the
+// undefined decision denies the request.
+func (c *client) decide(ctx context.Context, in *input) (*decision, error) {
+	data, err := json.Marshal(map[string]interface{}{"input": in})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status of the decision: %d", resp.StatusCode)
+	}
+	var result struct {
+		Result *decision `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Result == nil {
+		return &decision{}, nil
+	}
+	return result.Result, nil
+}
+
+func newInput(req *http.Request, allowedHeaders []string, body []byte) *input {
+	in := &input{
+		Method:  req.Method,
+		Host:    req.Host,
+		Path:    req.URL.Path,
+		Query:   req.URL.Query(),
+		Headers: make(map[string]string, len(req.Header)),
+		Body:    string(body),
+	}
+	if len(allowedHeaders) > 0 {
+		for _, name := range allowedHeaders {
+			if values := req.Header.Values(name); len(values) > 0 {
+				in.Headers[strings.ToLower(name)] = strings.Join(values, ",")
+			}
+		}
+	} else {
+		for name, values := range req.Header {
+			in.Headers[strings.ToLower(name)] = strings.Join(values, ",")
+		}
+	}
+	if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+		in.Route = reqOpt.Endpoint.GetPath()
+		in.PathVars = reqOpt.PathVars
+		if reqOpt.Consumer != nil {
+			in.Consumer = reqOpt.Consumer.Name
+		}
+	}
+	if ip := clientip.FromRequest(req); ip != nil {
+		in.SourceIP = ip.String()
+	}
+	return in
+}
+
+// Middleware authorizes the requests by the policy decisions of the OPA server.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.OPA{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Url == "" {
+		return nil, errors.New("opa url is required")
+	}
+	timeout := _defaultTimeout
+	if options.Timeout != nil {
+		timeout = options.Timeout.AsDuration()
+	}
+	cli := &client{url: options.Url, client: &http.Client{}}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := readBody(req, int64(options.MaxRequestBytes))
+			if err != nil {
+				return nil, err
+			}
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			d, err := cli.decide(ctx, newInput(req, options.AllowedHeaders, body))
+			cancel()
+			if err != nil {
+				log.Errorf("Failed to query the policy decision of %s: %+v", req.URL.Path, err)
+				if options.FailureOpen {
+					return next.RoundTrip(req)
+				}
+				audit.PolicyDenied(req, "opa", "", http.StatusForbidden, "policy decision failed")
+				return newResponse(http.StatusForbidden)
+			}
+			if !d.Allow {
+				status := d.Status
+				if status < 400 || status > 599 {
+					status = http.StatusForbidden
+				}
+				audit.PolicyDenied(req, "opa", "", status, "denied by the policy")
+				return newResponse(status)
+			}
+			for name, value := range d.Headers {
+				req.Header.Set(name, value)
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
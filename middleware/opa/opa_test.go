@@ -0,0 +1,117 @@
+package opa
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/opa/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newMiddleware(t *testing.T, options *v1.OPA) (http.RoundTripper, *http.Request) {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "opa", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &http.Request{}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		*upstream = *req
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	})), upstream
+}
+
+func do(t *testing.T, rt http.RoundTripper, user string) *http.Response {
+	req := httptest.NewRequest("POST", "/orders/1?page=1", bytes.NewBufferString("order"))
+	req.Header.Set("X-User", user)
+	req.Header.Set("X-Other", "other")
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/orders/{id}"})
+	reqOpt.PathVars = map[string]string{"id": "1"}
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestOPA(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input *input `json:"input"`
+		}
+		if r.URL.Path != "/v1/data/gateway/authz" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		in := body.Input
+		if in.Method != "POST" || in.Path != "/orders/1" || in.Query["page"][0] != "1" || in.Route != "/orders/{id}" ||
+			in.PathVars["id"] != "1" || in.Body != "ord" || in.Headers["x-other"] != "" || in.SourceIP == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch in.Headers["x-user"] {
+		case "alice":
+			_, _ = w.Write([]byte(`{"result": {"allow": true, "headers": {"X-User-Id": "1"}}}`))
+		case "bob":
+			_, _ = w.Write([]byte(`{"result": false}`))
+		case "carol":
+			_, _ = w.Write([]byte(`{"result": {"allow": false, "status": 401}}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	rt, upstream := newMiddleware(t, &v1.OPA{
+		Url:             srv.URL + "/v1/data/gateway/authz",
+		AllowedHeaders:  []string{"X-User"},
+		MaxRequestBytes: 3,
+	})
+
+	if resp := do(t, rt, "alice"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want 200 but got %d", resp.StatusCode)
+	}
+	if upstream.Header.Get("X-User-Id") != "1" {
+		t.Fatalf("unexpected upstream headers: %v", upstream.Header)
+	}
+	if body, _ := ioutil.ReadAll(upstream.Body); string(body) != "order" {
+		t.Fatalf("want the whole body sent to the upstream but got %q", body)
+	}
+	testCases := []struct {
+		user string
+		want int
+	}{
+		{"bob", http.StatusForbidden},
+		{"carol", http.StatusUnauthorized},
+		{"undefined", http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		if resp := do(t, rt, tc.user); resp.StatusCode != tc.want {
+			t.Errorf("%s: want %d but got %d", tc.user, tc.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestFailurePolicy(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	for _, failureOpen := range []bool{false, true} {
+		rt, _ := newMiddleware(t, &v1.OPA{Url: srv.URL, FailureOpen: failureOpen})
+		want := http.StatusForbidden
+		if failureOpen {
+			want = http.StatusOK
+		}
+		if resp := do(t, rt, "alice"); resp.StatusCode != want {
+			t.Fatalf("failure open %t: want %d but got %d", failureOpen, want, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/openapi/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	_defaultMaxBodyBytes = 1 << 20
+	// the metadata key of the validated request, the retries are not
+	// validated again.
+	_validatedKey = "openapi.validated"
+)
+
+var (
+	_methods          = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+	_templatePattern  = regexp.MustCompile(`\{([^{}/]+)\}`)
+	_defaultSeparator = map[string]string{"simple": ",", "form": ",", "spaceDelimited": " ", "pipeDelimited": "|"}
+)
+
+func init() {
+	middleware.Register("openapi", Middleware)
+}
+
+// invalidParam is the member of the invalid-params extension of the problem
+// details, the name of the body is the JSON pointer of the value.
+type invalidParam struct {
+	In     string `json:"in"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// problem is the problem details of RFC 7807.
+type problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []invalidParam `json:"invalid-params,omitempty"`
+}
+
+func newProblem(req *http.Request, statusCode int, detail string, params []invalidParam) (*http.Response, error) {
+	body, err := json.Marshal(&problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(statusCode),
+		Status:        statusCode,
+		Detail:        detail,
+		Instance:      req.URL.Path,
+		InvalidParams: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/problem+json")
+	return &http.Response{
+		StatusCode:    statusCode,
+		Header:        header,
+		ContentLength: int64(len(body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+	}, nil
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	explode  bool
+	sep      string
+	// the value is in JSON if the parameter is described by the content.
+	json   bool
+	schema *schema
+}
+
+// value converts the raw values to the types of the schema, the raw values
+// are kept if they're not converted, and rejected by the schema then.
+func (p *parameter) value(raws []string) interface{} {
+	if p.json {
+		var v interface{}
+		if err := json.Unmarshal([]byte(raws[0]), &v); err != nil {
+			return raws[0]
+		}
+		return v
+	}
+	if !p.schema.hasType("array") {
+		return primitive(p.schema, raws[0])
+	}
+	parts := raws
+	if p.in != "query" || !p.explode {
+		parts = nil
+		for _, raw := range raws {
+			parts = append(parts, strings.Split(raw, p.sep)...)
+		}
+	}
+	items := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		items = append(items, primitive(p.schema.items, part))
+	}
+	return items
+}
+
+func primitive(s *schema, raw string) interface{} {
+	if s == nil {
+		return raw
+	}
+	switch {
+	case s.hasType("integer"), s.hasType("number"):
+		var f float64
+		if err := json.Unmarshal([]byte(raw), &f); err == nil {
+			return f
+		}
+	case s.hasType("boolean"):
+		if raw == "true" || raw == "false" {
+			return raw == "true"
+		}
+	}
+	return raw
+}
+
+type requestBody struct {
+	required bool
+	// the schemas of the media types, e.g. application/json, application/*.
+	content map[string]*schema
+}
+
+// schemaOf returns the schema of the most specific media type matched.
+func (b *requestBody) schemaOf(mediaType string) (*schema, bool) {
+	if s, ok := b.content[mediaType]; ok {
+		return s, true
+	}
+	if i := strings.IndexByte(mediaType, '/'); i > 0 {
+		if s, ok := b.content[mediaType[:i]+"/*"]; ok {
+			return s, true
+		}
+	}
+	s, ok := b.content["*/*"]
+	return s, ok
+}
+
+type operation struct {
+	params []*parameter
+	body   *requestBody
+}
+
+// route is the path of the document, the templates are matched by the path
+// segments.
+type route struct {
+	pattern    *regexp.Regexp
+	names      []string
+	literal    int
+	operations map[string]*operation
+}
+
+type validator struct {
+	routes       []*route
+	prefix       string
+	allowUnknown bool
+	maxBodyBytes int64
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func newValidator(options *v1.OpenAPI) (*validator, error) {
+	data := []byte(options.Spec)
+	if len(data) == 0 {
+		if options.SpecFile == "" {
+			return nil, fmt.Errorf("openapi spec or spec file is required")
+		}
+		var err error
+		if data, err = ioutil.ReadFile(options.SpecFile); err != nil {
+			return nil, err
+		}
+	}
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %v", err)
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %v", err)
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("openapi document version is not 3.x: %v", doc["openapi"])
+	}
+	v := &validator{prefix: options.PathPrefix, allowUnknown: options.AllowUnknownOperations, maxBodyBytes: options.MaxBodyBytes}
+	if v.maxBodyBytes <= 0 {
+		v.maxBodyBytes = _defaultMaxBodyBytes
+	}
+	if v.prefix == "" {
+		if servers, _ := doc["servers"].([]interface{}); len(servers) > 0 {
+			server, _ := servers[0].(map[string]interface{})
+			if u, err := url.Parse(fmt.Sprint(server["url"])); err == nil {
+				v.prefix = u.Path
+			}
+		}
+	}
+	v.prefix = strings.TrimSuffix(v.prefix, "/")
+	c := newCompiler(doc)
+	paths, _ := doc["paths"].(map[string]interface{})
+	for template, item := range paths {
+		r, err := c.route(template, item)
+		if err != nil {
+			return nil, err
+		}
+		v.routes = append(v.routes, r)
+	}
+	// the concrete paths are matched before the templated ones.
+	sort.Slice(v.routes, func(i, j int) bool {
+		if v.routes[i].literal != v.routes[j].literal {
+			return v.routes[i].literal > v.routes[j].literal
+		}
+		return v.routes[i].pattern.String() < v.routes[j].pattern.String()
+	})
+	return v, nil
+}
+
+func (c *compiler) route(template string, v interface{}) (*route, error) {
+	item, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid openapi path item: %s", template)
+	}
+	item, err := c.deref(item)
+	if err != nil {
+		return nil, err
+	}
+	r := &route{operations: map[string]*operation{}}
+	pattern := "^"
+	last := 0
+	for _, loc := range _templatePattern.FindAllStringSubmatchIndex(template, -1) {
+		pattern += regexp.QuoteMeta(template[last:loc[0]]) + "([^/]+)"
+		r.literal += loc[0] - last
+		r.names = append(r.names, template[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern += regexp.QuoteMeta(template[last:]) + "$"
+	r.literal += len(template) - last
+	if r.pattern, err = regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	shared, _ := item["parameters"].([]interface{})
+	for _, method := range _methods {
+		op, ok := item[method].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		o := &operation{}
+		// the parameters of the operation override the ones of the path.
+		seen := map[string]bool{}
+		params, _ := op["parameters"].([]interface{})
+		for _, list := range [][]interface{}{params, shared} {
+			for _, v := range list {
+				p, err := c.parameter(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid openapi parameter of %s %s: %v", method, template, err)
+				}
+				if key := p.in + ":" + p.name; !seen[key] {
+					seen[key] = true
+					o.params = append(o.params, p)
+				}
+			}
+		}
+		if body, ok := op["requestBody"].(map[string]interface{}); ok {
+			if o.body, err = c.requestBody(body); err != nil {
+				return nil, fmt.Errorf("invalid openapi request body of %s %s: %v", method, template, err)
+			}
+		}
+		r.operations[strings.ToUpper(method)] = o
+	}
+	return r, nil
+}
+
+func (c *compiler) parameter(v interface{}) (*parameter, error) {
+	obj, _ := v.(map[string]interface{})
+	obj, err := c.deref(obj)
+	if err != nil {
+		return nil, err
+	}
+	p := &parameter{}
+	p.name, _ = obj["name"].(string)
+	p.in, _ = obj["in"].(string)
+	p.required, _ = obj["required"].(bool)
+	if p.name == "" || p.in == "" {
+		return nil, fmt.Errorf("name and in are required")
+	}
+	if p.in == "path" {
+		p.required = true
+	}
+	style, _ := obj["style"].(string)
+	if style == "" {
+		style = "simple"
+		if p.in == "query" || p.in == "cookie" {
+			style = "form"
+		}
+	}
+	p.sep = _defaultSeparator[style]
+	if p.sep == "" {
+		p.sep = ","
+	}
+	explode, ok := obj["explode"].(bool)
+	if !ok {
+		explode = style == "form"
+	}
+	p.explode = explode
+	raw, ok := obj["schema"]
+	if content, _ := obj["content"].(map[string]interface{}); !ok && len(content) > 0 {
+		for _, media := range content {
+			m, _ := media.(map[string]interface{})
+			raw, p.json = m["schema"], true
+		}
+	}
+	if p.schema, err = c.schema(raw); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (c *compiler) requestBody(obj map[string]interface{}) (*requestBody, error) {
+	obj, err := c.deref(obj)
+	if err != nil {
+		return nil, err
+	}
+	b := &requestBody{content: map[string]*schema{}}
+	b.required, _ = obj["required"].(bool)
+	content, _ := obj["content"].(map[string]interface{})
+	for mediaType, v := range content {
+		media, _ := v.(map[string]interface{})
+		var s *schema
+		if raw, ok := media["schema"]; ok {
+			if s, err = c.schema(raw); err != nil {
+				return nil, err
+			}
+		}
+		b.content[strings.ToLower(mediaType)] = s
+	}
+	return b, nil
+}
+
+// match returns the route and the path parameters of the request path.
+func (v *validator) match(path string) (*route, map[string]string, bool) {
+	if v.prefix != "" {
+		if path != v.prefix && !strings.HasPrefix(path, v.prefix+"/") {
+			return nil, nil, false
+		}
+		path = strings.TrimPrefix(path, v.prefix)
+	}
+	for _, r := range v.routes {
+		m := r.pattern.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		vars := make(map[string]string, len(r.names))
+		for i, name := range r.names {
+			value, err := url.PathUnescape(m[i+1])
+			if err != nil {
+				value = m[i+1]
+			}
+			vars[name] = value
+		}
+		return r, vars, true
+	}
+	return nil, nil, false
+}
+
+func (v *validator) validate(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	r, vars, ok := v.match(req.URL.EscapedPath())
+	if !ok {
+		if v.allowUnknown {
+			return next.RoundTrip(req)
+		}
+		return newProblem(req, http.StatusNotFound, "the path is not in the API document", nil)
+	}
+	op, ok := r.operations[req.Method]
+	if !ok {
+		if v.allowUnknown {
+			return next.RoundTrip(req)
+		}
+		allowed := make([]string, 0, len(r.operations))
+		for method := range r.operations {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		resp, err := newProblem(req, http.StatusMethodNotAllowed, "the method is not in the API document", nil)
+		if resp != nil {
+			resp.Header.Set("Allow", strings.Join(allowed, ", "))
+		}
+		return resp, err
+	}
+	var params []invalidParam
+	query := req.URL.Query()
+	for _, p := range op.params {
+		var raws []string
+		switch p.in {
+		case "path":
+			if value, ok := vars[p.name]; ok {
+				raws = []string{value}
+			}
+		case "query":
+			raws = query[p.name]
+		case "header":
+			raws = req.Header.Values(p.name)
+		case "cookie":
+			if c, err := req.Cookie(p.name); err == nil {
+				raws = []string{c.Value}
+			}
+		}
+		if len(raws) == 0 {
+			if p.required {
+				params = append(params, invalidParam{In: p.in, Name: p.name, Reason: "is required"})
+			}
+			continue
+		}
+		for _, violation := range p.schema.validate(p.value(raws), "") {
+			params = append(params, invalidParam{In: p.in, Name: p.name + violation.pointer, Reason: violation.reason})
+		}
+	}
+	if op.body != nil {
+		invalid, resp, err := v.validateBody(req, op.body)
+		if resp != nil || err != nil {
+			return resp, err
+		}
+		params = append(params, invalid...)
+	}
+	if len(params) > 0 {
+		return newProblem(req, http.StatusBadRequest, "the request is invalid", params)
+	}
+	return next.RoundTrip(req)
+}
+
+func (v *validator) validateBody(req *http.Request, b *requestBody) ([]invalidParam, *http.Response, error) {
+	data, err := readBody(req, v.maxBodyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > v.maxBodyBytes {
+		resp, err := newProblem(req, http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body is larger than %d bytes", v.maxBodyBytes), nil)
+		return nil, resp, err
+	}
+	if len(data) == 0 {
+		if b.required {
+			return []invalidParam{{In: "body", Reason: "is required"}}, nil, nil
+		}
+		return nil, nil, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	s, ok := b.schemaOf(mediaType)
+	if !ok {
+		resp, err := newProblem(req, http.StatusUnsupportedMediaType, fmt.Sprintf("the content type is not in the API document: %s", mediaType), nil)
+		return nil, resp, err
+	}
+	if s == nil || !isJSON(mediaType) {
+		return nil, nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []invalidParam{{In: "body", Reason: "must be valid JSON"}}, nil, nil
+	}
+	var params []invalidParam
+	for _, violation := range s.validate(value, "") {
+		params = append(params, invalidParam{In: "body", Name: violation.pointer, Reason: violation.reason})
+	}
+	return params, nil, nil
+}
+
+// readBody reads the body buffered by the proxy, at most one byte more than
+// the max bytes are read.
+func readBody(req *http.Request, max int64) ([]byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(io.LimitReader(body, max+1))
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if int64(len(data)) > max+1 {
+		data = data[:max+1]
+	}
+	return data, nil
+}
+
+// Middleware validates the requests by the operations of the OpenAPI document.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.OpenAPI{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	v, err := newValidator(options)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpt, ok := middleware.FromRequestContext(req.Context())
+			if ok && reqOpt.Metadata[_validatedKey] != "" {
+				return next.RoundTrip(req)
+			}
+			return v.validate(req, middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if ok {
+					reqOpt.Metadata[_validatedKey] = "true"
+				}
+				return next.RoundTrip(req)
+			}))
+		})
+	}, nil
+}
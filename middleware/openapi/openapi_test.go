@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/openapi/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _spec = `
+openapi: 3.0.3
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users/me:
+    get: {}
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        schema: {type: integer, minimum: 1}
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: array
+            items: {type: string, enum: [name, email]}
+        - name: X-Tenant
+          in: header
+          required: true
+          schema: {type: string, format: uuid}
+    put:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      additionalProperties: false
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string, minLength: 1}
+        email: {type: string, format: email, nullable: true}
+        friends:
+          type: array
+          items: {$ref: '#/components/schemas/User'}
+`
+
+func newTripper(t *testing.T, options *v1.OpenAPI) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "openapi", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}))
+}
+
+func TestValidate(t *testing.T) {
+	tripper := newTripper(t, &v1.OpenAPI{Spec: _spec})
+	const tenant = "0b6d3e0e-8f4e-4c55-bd4e-3f2b1f1a2c3d"
+	tests := []struct {
+		method, path, body string
+		header             map[string]string
+		code               int
+		invalid            []invalidParam
+	}{
+		{method: "GET", path: "/v1/users/me", code: 200},
+		{method: "GET", path: "/v1/users/1?fields=name&fields=email", header: map[string]string{"X-Tenant": tenant}, code: 200},
+		{method: "GET", path: "/v1/users/0?fields=phone", code: 400, invalid: []invalidParam{
+			{In: "query", Name: "fields/0", Reason: "must be one of the enum values"},
+			{In: "header", Name: "X-Tenant", Reason: "is required"},
+			{In: "path", Name: "id", Reason: "must be greater than or equal to 1"},
+		}},
+		{method: "GET", path: "/v1/users/abc", header: map[string]string{"X-Tenant": "x"}, code: 400, invalid: []invalidParam{
+			{In: "header", Name: "X-Tenant", Reason: "must be the format uuid"},
+			{In: "path", Name: "id", Reason: "must be integer"},
+		}},
+		{method: "PUT", path: "/v1/users/1", body: `{"name":"alice","email":null,"friends":[{"id":2,"name":""}]}`, header: map[string]string{"Content-Type": "application/json"}, code: 400, invalid: []invalidParam{
+			{In: "body", Name: "/friends/0/name", Reason: "must be at least 1 characters"},
+		}},
+		{method: "PUT", path: "/v1/users/1", body: `{"name":"alice","age":1}`, header: map[string]string{"Content-Type": "application/json; charset=utf-8"}, code: 400, invalid: []invalidParam{
+			{In: "body", Name: "/age", Reason: "is not allowed"},
+		}},
+		{method: "PUT", path: "/v1/users/1", body: `{"name":"alice"}`, header: map[string]string{"Content-Type": "application/json"}, code: 200},
+		{method: "PUT", path: "/v1/users/1", code: 400, invalid: []invalidParam{{In: "body", Reason: "is required"}}},
+		{method: "PUT", path: "/v1/users/1", body: `name=alice`, header: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, code: 415},
+		{method: "DELETE", path: "/v1/users/1", code: 405},
+		{method: "GET", path: "/v1/orders/1", code: 404},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		resp, err := tripper.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != test.code {
+			body, _ := ioutil.ReadAll(resp.Body)
+			t.Errorf("want %d of %s %s but got %d: %s", test.code, test.method, test.path, resp.StatusCode, body)
+			continue
+		}
+		if test.code == http.StatusOK {
+			continue
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("want the problem details but got %s", ct)
+		}
+		p := &problem{}
+		if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Status != test.code || p.Instance != req.URL.Path {
+			t.Errorf("want the problem of %d but got %+v", test.code, p)
+		}
+		if len(p.InvalidParams) != len(test.invalid) {
+			t.Errorf("want %+v of %s %s but got %+v", test.invalid, test.method, test.path, p.InvalidParams)
+			continue
+		}
+		for i, want := range test.invalid {
+			if p.InvalidParams[i] != want {
+				t.Errorf("want %+v of %s %s but got %+v", want, test.method, test.path, p.InvalidParams[i])
+			}
+		}
+	}
+	if resp, _ := tripper.RoundTrip(httptest.NewRequest("DELETE", "/v1/users/1", nil)); resp.Header.Get("Allow") != "GET, PUT" {
+		t.Fatalf("want the allowed methods but got %s", resp.Header.Get("Allow"))
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tripper := newTripper(t, &v1.OpenAPI{Spec: _spec, PathPrefix: "/api", AllowUnknownOperations: true, MaxBodyBytes: 8})
+	for _, path := range []string{"/v1/users/me", "/api/orders/1"} {
+		if resp, _ := tripper.RoundTrip(httptest.NewRequest("DELETE", path, nil)); resp.StatusCode != http.StatusOK {
+			t.Fatalf("want the unknown operation of %s forwarded but got %d", path, resp.StatusCode)
+		}
+	}
+	req := httptest.NewRequest("PUT", "/api/users/1", strings.NewReader(`{"name":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, _ := tripper.RoundTrip(req); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413 but got %d", resp.StatusCode)
+	}
+
+	for _, spec := range []string{"", "swagger: '2.0'", "openapi: 3.0.0\npaths: {/a: {get: {parameters: [{$ref: '#/components/parameters/missing'}]}}}"} {
+		any, _ := anypb.New(&v1.OpenAPI{Spec: spec})
+		if _, err := Middleware(&config.Middleware{Name: "openapi", Options: any}); err == nil {
+			t.Fatalf("want the error of the spec %q", spec)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// schema is the compiled schema object of the document, the keywords of the
+// JSON Schema subset of OpenAPI 3.0 and 3.1 are supported.
+type schema struct {
+	types    []string
+	nullable bool
+	enum     []interface{}
+	format   string
+	readOnly bool
+
+	pattern   *regexp.Regexp
+	minLength *int
+	maxLength *int
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum bool
+	exclusiveMaximum bool
+	multipleOf       float64
+
+	items       *schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	properties    map[string]*schema
+	required      []string
+	additional    *schema
+	noAdditional  bool
+	minProperties *int
+	maxProperties *int
+
+	allOf []*schema
+	anyOf []*schema
+	oneOf []*schema
+	not   *schema
+}
+
+// violation is the reason of the value invalid, the pointer is the JSON
+// pointer of the value in the body.
+type violation struct {
+	pointer string
+	reason  string
+}
+
+// compiler compiles the schemas of the document, the local references are
+// resolved by the JSON pointers.
+type compiler struct {
+	doc   map[string]interface{}
+	cache map[string]*schema
+}
+
+func newCompiler(doc map[string]interface{}) *compiler {
+	return &compiler{doc: doc, cache: map[string]*schema{}}
+}
+
+// resolve returns the object of the reference, e.g. #/components/schemas/User.
+func (c *compiler) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("openapi reference is not local: %s", ref)
+	}
+	var v interface{} = c.doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("openapi reference is not found: %s", ref)
+		}
+		if v, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("openapi reference is not found: %s", ref)
+		}
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("openapi reference is not an object: %s", ref)
+	}
+	return obj, nil
+}
+
+// deref returns the object referenced by the $ref of the object if any.
+func (c *compiler) deref(obj map[string]interface{}) (map[string]interface{}, error) {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, nil
+		}
+		var err error
+		if obj, err = c.resolve(ref); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("openapi references are too deep")
+}
+
+func (c *compiler) schema(v interface{}) (*schema, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		// the schemas of true and false in 3.1.
+		if b, ok := v.(bool); ok && !b {
+			return &schema{not: &schema{}}, nil
+		}
+		return &schema{}, nil
+	}
+	if ref, ok := obj["$ref"].(string); ok {
+		// the schema is cached before compiled for the recursive references.
+		if s, ok := c.cache[ref]; ok {
+			return s, nil
+		}
+		target, err := c.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		s := &schema{}
+		c.cache[ref] = s
+		compiled, err := c.schema(target)
+		if err != nil {
+			return nil, err
+		}
+		*s = *compiled
+		return s, nil
+	}
+	s := &schema{}
+	switch t := obj["type"].(type) {
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				s.types = append(s.types, name)
+			}
+		}
+	}
+	s.nullable, _ = obj["nullable"].(bool)
+	s.readOnly, _ = obj["readOnly"].(bool)
+	s.format, _ = obj["format"].(string)
+	if enum, ok := obj["enum"].([]interface{}); ok {
+		s.enum = enum
+	}
+	if v, ok := obj["const"]; ok {
+		s.enum = []interface{}{v}
+	}
+	if p, ok := obj["pattern"].(string); ok {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid openapi schema pattern: %s: %v", p, err)
+		}
+		s.pattern = pattern
+	}
+	s.minLength, s.maxLength = intOf(obj, "minLength"), intOf(obj, "maxLength")
+	s.minItems, s.maxItems = intOf(obj, "minItems"), intOf(obj, "maxItems")
+	s.minProperties, s.maxProperties = intOf(obj, "minProperties"), intOf(obj, "maxProperties")
+	s.minimum, s.maximum = floatOf(obj, "minimum"), floatOf(obj, "maximum")
+	// the exclusive bounds are the booleans in 3.0 and the numbers in 3.1.
+	switch v := obj["exclusiveMinimum"].(type) {
+	case bool:
+		s.exclusiveMinimum = v
+	case float64:
+		s.minimum, s.exclusiveMinimum = &v, true
+	}
+	switch v := obj["exclusiveMaximum"].(type) {
+	case bool:
+		s.exclusiveMaximum = v
+	case float64:
+		s.maximum, s.exclusiveMaximum = &v, true
+	}
+	s.multipleOf, _ = obj["multipleOf"].(float64)
+	s.uniqueItems, _ = obj["uniqueItems"].(bool)
+	if v, ok := obj["items"]; ok {
+		items, err := c.schema(v)
+		if err != nil {
+			return nil, err
+		}
+		s.items = items
+	}
+	if props, ok := obj["properties"].(map[string]interface{}); ok {
+		s.properties = make(map[string]*schema, len(props))
+		for name, v := range props {
+			prop, err := c.schema(v)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = prop
+		}
+	}
+	if required, ok := obj["required"].([]interface{}); ok {
+		for _, v := range required {
+			if name, ok := v.(string); ok {
+				s.required = append(s.required, name)
+			}
+		}
+	}
+	switch v := obj["additionalProperties"].(type) {
+	case bool:
+		s.noAdditional = !v
+	case map[string]interface{}:
+		additional, err := c.schema(v)
+		if err != nil {
+			return nil, err
+		}
+		s.additional = additional
+	}
+	for key, out := range map[string]*[]*schema{"allOf": &s.allOf, "anyOf": &s.anyOf, "oneOf": &s.oneOf} {
+		list, _ := obj[key].([]interface{})
+		for _, v := range list {
+			sub, err := c.schema(v)
+			if err != nil {
+				return nil, err
+			}
+			*out = append(*out, sub)
+		}
+	}
+	if v, ok := obj["not"]; ok {
+		not, err := c.schema(v)
+		if err != nil {
+			return nil, err
+		}
+		s.not = not
+	}
+	return s, nil
+}
+
+func intOf(obj map[string]interface{}, key string) *int {
+	if v, ok := obj[key].(float64); ok {
+		n := int(v)
+		return &n
+	}
+	return nil
+}
+
+func floatOf(obj map[string]interface{}, key string) *float64 {
+	if v, ok := obj[key].(float64); ok {
+		return &v
+	}
+	return nil
+}
+
+// typeOf returns the JSON type of the value decoded.
+func typeOf(v interface{}) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+func (s *schema) hasType(name string) bool {
+	for _, t := range s.types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schema) typeMatched(v interface{}) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	t := typeOf(v)
+	return s.hasType(t) || (t == "integer" && s.hasType("number"))
+}
+
+// validate returns the violations of the value, the first one of each value
+// is returned only.
+func (s *schema) validate(v interface{}, pointer string) []violation {
+	fail := func(format string, args ...interface{}) []violation {
+		return []violation{{pointer: pointer, reason: fmt.Sprintf(format, args...)}}
+	}
+	if v == nil && s.nullable {
+		return nil
+	}
+	if !s.typeMatched(v) {
+		return fail("must be %s", strings.Join(s.types, " or "))
+	}
+	if len(s.enum) > 0 {
+		matched := false
+		for _, e := range s.enum {
+			if reflect.DeepEqual(e, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fail("must be one of the enum values")
+		}
+	}
+	var out []violation
+	switch value := v.(type) {
+	case string:
+		n := len([]rune(value))
+		if s.minLength != nil && n < *s.minLength {
+			return fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fail("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			return fail("must match the pattern %s", s.pattern)
+		}
+		if !formatMatched(s.format, value) {
+			return fail("must be the format %s", s.format)
+		}
+	case float64:
+		if s.minimum != nil && (value < *s.minimum || (s.exclusiveMinimum && value == *s.minimum)) {
+			return fail("must be greater than %s%v", orEqual(!s.exclusiveMinimum), *s.minimum)
+		}
+		if s.maximum != nil && (value > *s.maximum || (s.exclusiveMaximum && value == *s.maximum)) {
+			return fail("must be less than %s%v", orEqual(!s.exclusiveMaximum), *s.maximum)
+		}
+		if s.multipleOf > 0 {
+			if q := value / s.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				return fail("must be a multiple of %v", s.multipleOf)
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(value) < *s.minItems {
+			return fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(value) > *s.maxItems {
+			return fail("must have at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+			for i := range value {
+				for j := 0; j < i; j++ {
+					if reflect.DeepEqual(value[i], value[j]) {
+						return fail("must have the unique items")
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range value {
+				out = append(out, s.items.validate(item, pointer+"/"+strconv.Itoa(i))...)
+			}
+		}
+	case map[string]interface{}:
+		if s.minProperties != nil && len(value) < *s.minProperties {
+			return fail("must have at least %d properties", *s.minProperties)
+		}
+		if s.maxProperties != nil && len(value) > *s.maxProperties {
+			return fail("must have at most %d properties", *s.maxProperties)
+		}
+		for _, name := range s.required {
+			// the read only properties are not sent in the requests.
+			if prop, ok := s.properties[name]; ok && prop.readOnly {
+				continue
+			}
+			if _, ok := value[name]; !ok {
+				out = append(out, violation{pointer: pointer + "/" + escapePointer(name), reason: "is required"})
+			}
+		}
+		for _, name := range sortedKeys(value) {
+			p := pointer + "/" + escapePointer(name)
+			if prop, ok := s.properties[name]; ok {
+				out = append(out, prop.validate(value[name], p)...)
+				continue
+			}
+			if s.noAdditional {
+				out = append(out, violation{pointer: p, reason: "is not allowed"})
+			} else if s.additional != nil {
+				out = append(out, s.additional.validate(value[name], p)...)
+			}
+		}
+	}
+	for _, sub := range s.allOf {
+		out = append(out, sub.validate(v, pointer)...)
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if len(sub.validate(v, pointer)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out = append(out, violation{pointer: pointer, reason: "must match any of the schemas"})
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if len(sub.validate(v, pointer)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			out = append(out, violation{pointer: pointer, reason: "must match exactly one of the schemas"})
+		}
+	}
+	if s.not != nil && len(s.not.validate(v, pointer)) == 0 {
+		out = append(out, violation{pointer: pointer, reason: "must not match the schema"})
+	}
+	return out
+}
+
+func orEqual(inclusive bool) string {
+	if inclusive {
+		return "or equal to "
+	}
+	return ""
+}
+
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+// formatMatched reports whether the value is of the format, the unknown
+// formats are matched.
+func formatMatched(format, value string) bool {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "uuid":
+		return _uuidPattern.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	case "uri":
+		var u *url.URL
+		if u, err = url.Parse(value); err == nil && u.Scheme == "" {
+			return false
+		}
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(value)
+	}
+	return err == nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
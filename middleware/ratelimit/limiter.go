@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+// quota is the result of the limiter, the reset is the duration until the
+// quota is fully restored, and the retry is the duration until the next
+// request is allowed if it's rejected.
+type quota struct {
+	allowed   bool
+	limit     int
+	remaining int
+	reset     time.Duration
+	retry     time.Duration
+}
+
+// limiter limits the requests of a key.
+type limiter interface {
+	take(now time.Time) quota
+}
+
+// rule is the limit of the requests, either the token bucket of the rate per
+// second and the burst, or the sliding window of the requests per period.
+type rule struct {
+	sliding  bool
+	requests int
+	period   time.Duration
+	rate     float64
+	burst    float64
+}
+
+func (r rule) newLimiter(now time.Time) limiter {
+	if r.sliding {
+		return newSlidingWindow(r.requests, r.period, now)
+	}
+	return newTokenBucket(r.rate, r.burst, now)
+}
+
+// tokenBucket refills the tokens at the rate of per second up to the burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+func (b *tokenBucket) take(now time.Time) quota {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return bucketQuota(b.rate, b.burst, b.tokens, allowed)
+}
+
+// bucketQuota returns the quota of the tokens left in the bucket.
+func bucketQuota(rate, burst, tokens float64, allowed bool) quota {
+	q := quota{allowed: allowed, limit: int(burst), remaining: int(tokens)}
+	if !allowed {
+		q.retry = seconds((1 - tokens) / rate)
+	}
+	q.reset = seconds((burst - tokens) / rate)
+	return q
+}
+
+// slidingWindow estimates the requests of the last period by the requests of
+// the current window and the weighted ones of the previous window.
+type slidingWindow struct {
+	limit    int
+	period   time.Duration
+	start    time.Time
+	current  int
+	previous int
+}
+
+func newSlidingWindow(limit int, period time.Duration, now time.Time) *slidingWindow {
+	return &slidingWindow{limit: limit, period: period, start: now.Truncate(period)}
+}
+
+func (w *slidingWindow) take(now time.Time) quota {
+	if elapsed := now.Sub(w.start); elapsed >= w.period {
+		w.previous = w.current
+		if elapsed >= 2*w.period {
+			w.previous = 0
+		}
+		w.current = 0
+		w.start = now.Truncate(w.period)
+	}
+	elapsed := now.Sub(w.start)
+	count := float64(w.previous)*windowWeight(elapsed, w.period) + float64(w.current)
+	allowed := count+1 <= float64(w.limit)
+	if allowed {
+		w.current++
+		count++
+	}
+	return windowQuota(w.limit, w.period, elapsed, count, allowed)
+}
+
+// windowWeight returns the weight of the previous window.
+func windowWeight(elapsed, period time.Duration) float64 {
+	return float64(period-elapsed) / float64(period)
+}
+
+// windowQuota returns the quota of the requests counted in the window.
+func windowQuota(limit int, period, elapsed time.Duration, count float64, allowed bool) quota {
+	q := quota{allowed: allowed, limit: limit, reset: period - elapsed}
+	if !allowed {
+		q.retry = period - elapsed
+	}
+	q.remaining = int(math.Max(0, float64(limit)-count))
+	return q
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+type entry struct {
+	key     string
+	limiter limiter
+}
+
+// store is the limiters of the keys, the least recently used ones are evicted
+// if it's full.
+type store struct {
+	mu       sync.Mutex
+	size     int
+	lru      *list.List
+	limiters map[string]*list.Element
+}
+
+func newStore(size int) *store {
+	return &store{size: size, lru: list.New(), limiters: make(map[string]*list.Element)}
+}
+
+// take takes the quota of the key, the limiter of the rule is created if not
+// found.
+func (s *store) take(key string, r rule, now time.Time) quota {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.limiters[key]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*entry).limiter.take(now)
+	}
+	if s.lru.Len() >= s.size {
+		e := s.lru.Back()
+		s.lru.Remove(e)
+		delete(s.limiters, e.Value.(*entry).key)
+	}
+	l := r.newLimiter(now)
+	s.limiters[key] = s.lru.PushFront(&entry{key: key, limiter: l})
+	return l.take(now)
+}
@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/ratelimit/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultPeriod  = time.Second
+	_defaultMaxKeys = 10000
+)
+
+// the sequence of the middlewares, the quota of a request is kept in the
+// metadata of the sequence to not limit the retries again.
+var _seq uint64
+
+func init() {
+	middleware.Register("ratelimit", Middleware)
+}
+
+func setHeaders(header http.Header, q quota) {
+	header.Set("RateLimit-Limit", strconv.Itoa(q.limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(q.remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(q.reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func newResponse(q quota) (*http.Response, error) {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}
+	setHeaders(resp.Header, q)
+	retry := ceilSeconds(q.retry)
+	if retry < 1 {
+		retry = 1
+	}
+	resp.Header.Set("Retry-After", strconv.Itoa(retry))
+	return resp, nil
+}
+
+func encodeQuota(q quota) string {
+	return fmt.Sprintf("%d,%d,%d", q.limit, q.remaining, q.reset)
+}
+
+func decodeQuota(s string) (q quota, ok bool) {
+	q.allowed = true
+	n, err := fmt.Sscanf(s, "%d,%d,%d", &q.limit, &q.remaining, &q.reset)
+	return q, err == nil && n == 3
+}
+
+type rateLimiter struct {
+	options *v1.RateLimit
+	rule    rule
+	store   *store
+	redis   *redisStore
+	// failed is 1 if the redis is unreachable, it's logged once failed.
+	failed int32
+}
+
+// key returns the key of the request, and the consumer of the CONSUMER key.
+func (l *rateLimiter) key(req *http.Request) (string, *config.Consumer) {
+	switch l.options.Key {
+	case v1.RateLimit_HEADER:
+		if v := req.Header.Get(l.options.Header); v != "" {
+			return "header:" + v, nil
+		}
+	case v1.RateLimit_CONSUMER:
+		if consumer, ok := middleware.ConsumerFromContext(req.Context()); ok {
+			return "consumer:" + consumer.Name, consumer
+		}
+	case v1.RateLimit_ROUTE:
+		if e, ok := middleware.EndpointFromContext(req.Context()); ok {
+			return fmt.Sprintf("route:%s %s %s", e.Host, e.Method, e.Path), nil
+		}
+	}
+	var ip string
+	if v := clientip.FromRequest(req); v != nil {
+		ip = v.String()
+	}
+	return "ip:" + ip, nil
+}
+
+func (l *rateLimiter) take(req *http.Request) quota {
+	key, consumer := l.key(req)
+	r := l.rule
+	if rl := consumer.GetRateLimit(); rl != nil {
+		// the limits are a part of the key to apply the updated consumers.
+		key = fmt.Sprintf("%s:%g:%d", key, rl.RequestsPerSecond, rl.Burst)
+		r = rule{rate: rl.RequestsPerSecond, burst: float64(rl.Burst)}
+		if r.burst == 0 {
+			r.burst = math.Max(1, math.Ceil(rl.RequestsPerSecond))
+		}
+	}
+	now := time.Now()
+	if l.redis != nil {
+		q, err := l.redis.take(req.Context(), key, r, now)
+		if err == nil {
+			if atomic.CompareAndSwapInt32(&l.failed, 1, 0) {
+				log.Info("The redis of the rate limits is recovered")
+			}
+			return q
+		}
+		if atomic.CompareAndSwapInt32(&l.failed, 0, 1) {
+			log.Errorf("Failed to limit the requests by the redis, limited locally: %+v", err)
+		}
+	}
+	return l.store.take(key, r, now)
+}
+
+// Middleware limits the requests by the key locally or globally by the redis.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.RateLimit{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Requests == 0 {
+		return nil, errors.New("ratelimit requests should be greater than 0")
+	}
+	if options.Key == v1.RateLimit_HEADER && options.Header == "" {
+		return nil, errors.New("ratelimit header is required by the header key")
+	}
+	period := _defaultPeriod
+	if options.Period != nil {
+		period = options.Period.AsDuration()
+	}
+	if period <= 0 {
+		return nil, errors.New("ratelimit period should be greater than 0")
+	}
+	maxKeys := _defaultMaxKeys
+	if options.MaxKeys > 0 {
+		maxKeys = int(options.MaxKeys)
+	}
+	l := &rateLimiter{options: options, store: newStore(maxKeys)}
+	switch options.Algorithm {
+	case v1.RateLimit_SLIDING_WINDOW:
+		l.rule = rule{sliding: true, requests: int(options.Requests), period: period}
+	default:
+		l.rule = rule{rate: float64(options.Requests) / period.Seconds(), burst: float64(options.Requests)}
+		if options.Burst > 0 {
+			l.rule.burst = float64(options.Burst)
+		}
+	}
+	if options.Redis != nil {
+		if len(options.Redis.Addrs) == 0 {
+			return nil, errors.New("ratelimit redis addrs are required")
+		}
+		l.redis = newRedisStore(options.Redis)
+	}
+	metadataKey := fmt.Sprintf("ratelimit.%d", atomic.AddUint64(&_seq, 1))
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpt, _ := middleware.FromRequestContext(req.Context())
+			q, ok := quota{}, false
+			if reqOpt != nil {
+				q, ok = decodeQuota(reqOpt.Metadata[metadataKey])
+			}
+			if !ok {
+				q = l.take(req)
+				if !q.allowed {
+					return newResponse(q)
+				}
+				if reqOpt != nil {
+					reqOpt.Metadata[metadataKey] = encodeQuota(q)
+				}
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			setHeaders(resp.Header, q)
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/ratelimit/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(1, 2, now)
+	for i := 0; i < 2; i++ {
+		if q := b.take(now); !q.allowed || q.remaining != 1-i {
+			t.Fatalf("%d: want allowed but got %+v", i, q)
+		}
+	}
+	if q := b.take(now); q.allowed || q.retry != time.Second || q.reset != 2*time.Second {
+		t.Fatalf("want rejected but got %+v", q)
+	}
+	if q := b.take(now.Add(time.Second)); !q.allowed {
+		t.Fatalf("want the token refilled but got %+v", q)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(100, 0)
+	w := newSlidingWindow(4, 10*time.Second, now)
+	for i := 0; i < 4; i++ {
+		if q := w.take(now); !q.allowed {
+			t.Fatalf("%d: want allowed but got %+v", i, q)
+		}
+	}
+	if q := w.take(now.Add(5 * time.Second)); q.allowed || q.retry != 5*time.Second {
+		t.Fatalf("want rejected but got %+v", q)
+	}
+	// the half of the previous window is counted.
+	now = now.Add(15 * time.Second)
+	for i := 0; i < 2; i++ {
+		if q := w.take(now); !q.allowed {
+			t.Fatalf("%d: want allowed but got %+v", i, q)
+		}
+	}
+	if q := w.take(now); q.allowed {
+		t.Fatalf("want rejected but got %+v", q)
+	}
+	if q := w.take(now.Add(20 * time.Second)); !q.allowed || q.remaining != 3 {
+		t.Fatalf("want the windows reset but got %+v", q)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := newStore(2)
+	now := time.Now()
+	r := rule{rate: 1, burst: 1}
+	s.take("a", r, now)
+	s.take("b", r, now)
+	s.take("a", r, now)
+	s.take("c", r, now)
+	if _, ok := s.limiters["b"]; ok || len(s.limiters) != 2 {
+		t.Fatalf("want the least recently used evicted but got %v", s.limiters)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	any, err := anypb.New(&v1.RateLimit{
+		Key:      v1.RateLimit_CONSUMER,
+		Requests: 2,
+		Period:   durationpb.New(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "ratelimit", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	do := func(reqOpt *middleware.RequestOptions, ip string) *http.Response {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.RemoteAddr = ip + ":1234"
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	newRequestOptions := func(consumer *config.Consumer) *middleware.RequestOptions {
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/orders"})
+		reqOpt.Consumer = consumer
+		return reqOpt
+	}
+
+	reqOpt := newRequestOptions(nil)
+	resp := do(reqOpt, "10.0.0.1")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Limit") != "2" || resp.Header.Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("unexpected response: %d %v", resp.StatusCode, resp.Header)
+	}
+	// the retries are not limited again.
+	for i := 0; i < 3; i++ {
+		if resp := do(reqOpt, "10.0.0.1"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("want the retry allowed but got %d", resp.StatusCode)
+		}
+	}
+	do(newRequestOptions(nil), "10.0.0.1")
+	resp = do(newRequestOptions(nil), "10.0.0.1")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "30" || resp.Header.Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("want 429 but got %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp := do(newRequestOptions(nil), "10.0.0.2"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want the other client allowed but got %d", resp.StatusCode)
+	}
+
+	alice := &config.Consumer{Name: "alice", RateLimit: &config.ConsumerRateLimit{RequestsPerSecond: 0.1, Burst: 3}}
+	for i := 0; i < 3; i++ {
+		if resp := do(newRequestOptions(alice), "10.0.0.1"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("%d: want the consumer limit applied but got %d", i, resp.StatusCode)
+		}
+	}
+	if resp := do(newRequestOptions(alice), "10.0.0.1"); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "10" {
+		t.Fatalf("want 429 but got %d %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestInvalidPeriod(t *testing.T) {
+	for _, period := range []time.Duration{0, -time.Second} {
+		any, err := anypb.New(&v1.RateLimit{Requests: 2, Period: durationpb.New(period)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Middleware(&config.Middleware{Name: "ratelimit", Options: any}); err == nil {
+			t.Fatalf("want the error of the period %s", period)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/ratelimit/v1"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	_defaultKeyPrefix    = "gateway:ratelimit:"
+	_defaultRedisTimeout = 100 * time.Millisecond
+)
+
+// the tokens of the bucket are refilled by the time of the gateway, and the
+// bucket expires once it's full.
+var _tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1]) or burst
+local last = tonumber(state[2]) or now
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) / 1000 * rate)
+	last = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(last))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// the count of the current window is increased if allowed, the windows expire
+// after the next period.
+var _slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local count = previous * weight + current
+local allowed = 0
+if count + 1 <= limit then
+	redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+return {allowed, tostring(count)}
+`)
+
+var (
+	_clientsMu sync.Mutex
+	// the clients are shared by the middlewares of the same redis, as the
+	// middlewares are rebuilt on the config updates.
+	_clients = map[string]redis.UniversalClient{}
+)
+
+func newClient(c *v1.Redis) redis.UniversalClient {
+	key := fmt.Sprintf("%s/%d/%s", strings.Join(c.Addrs, ","), c.Db, c.Password)
+	_clientsMu.Lock()
+	defer _clientsMu.Unlock()
+	if client, ok := _clients[key]; ok {
+		return client
+	}
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    c.Addrs,
+		Password: c.Password,
+		DB:       int(c.Db),
+	})
+	_clients[key] = client
+	return client
+}
+
+// redisStore limits the requests globally by the scripts of the redis.
+type redisStore struct {
+	client  redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+}
+
+func newRedisStore(c *v1.Redis) *redisStore {
+	s := &redisStore{client: newClient(c), prefix: c.KeyPrefix, timeout: _defaultRedisTimeout}
+	if s.prefix == "" {
+		s.prefix = _defaultKeyPrefix
+	}
+	if c.Timeout != nil {
+		s.timeout = c.Timeout.AsDuration()
+	}
+	return s
+}
+
+// take takes the quota of the key, the keys of the rule are hashed to the
+// same slot of the redis cluster by the hash tag.
+func (s *redisStore) take(ctx context.Context, key string, r rule, now time.Time) (quota, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	key = s.prefix + "{" + key + "}"
+	if r.sliding {
+		start := now.Truncate(r.period)
+		elapsed := now.Sub(start)
+		keys := []string{
+			key + ":" + strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10),
+			key + ":" + strconv.FormatInt(start.Add(-r.period).UnixNano()/int64(time.Millisecond), 10),
+		}
+		allowed, count, err := run(ctx, _slidingWindowScript, s.client, keys,
+			r.requests, windowWeight(elapsed, r.period), (2 * r.period).Milliseconds())
+		if err != nil {
+			return quota{}, err
+		}
+		return windowQuota(r.requests, r.period, elapsed, count, allowed), nil
+	}
+	allowed, tokens, err := run(ctx, _tokenBucketScript, s.client, []string{key},
+		r.rate, r.burst, now.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return quota{}, err
+	}
+	return bucketQuota(r.rate, r.burst, tokens, allowed), nil
+}
+
+// run runs the script replying whether it's allowed and the number left or
+// counted.
+func run(ctx context.Context, script *redis.Script, client redis.UniversalClient, keys []string, args ...interface{}) (bool, float64, error) {
+	reply, err := script.Run(ctx, client, keys, args...).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(reply) != 2 {
+		return false, 0, fmt.Errorf("unexpected reply of the script: %v", reply)
+	}
+	allowed, _ := reply[0].(int64)
+	s, _ := reply[1].(string)
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed == 1, n, nil
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/ratelimit/v1"
+)
+
+func TestRedisStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	// the stores of the gateways share the limits of the redis.
+	stores := []*redisStore{
+		newRedisStore(&v1.Redis{Addrs: []string{mr.Addr()}}),
+		newRedisStore(&v1.Redis{Addrs: []string{mr.Addr()}, KeyPrefix: _defaultKeyPrefix}),
+	}
+	ctx := context.Background()
+	now := time.Unix(100, 0)
+
+	bucket := rule{rate: 1, burst: 2}
+	for i := 0; i < 2; i++ {
+		if q, err := stores[i].take(ctx, "ip:10.0.0.1", bucket, now); err != nil || !q.allowed || q.remaining != 1-i {
+			t.Fatalf("%d: want allowed but got %+v %v", i, q, err)
+		}
+	}
+	q, err := stores[0].take(ctx, "ip:10.0.0.1", bucket, now)
+	if err != nil || q.allowed || q.retry != time.Second {
+		t.Fatalf("want rejected but got %+v %v", q, err)
+	}
+	if q, err := stores[1].take(ctx, "ip:10.0.0.1", bucket, now.Add(time.Second)); err != nil || !q.allowed {
+		t.Fatalf("want the token refilled but got %+v %v", q, err)
+	}
+	if !mr.Exists(_defaultKeyPrefix + "{ip:10.0.0.1}") {
+		t.Fatalf("want the bucket of the hash tag but got %v", mr.Keys())
+	}
+
+	window := rule{sliding: true, requests: 2, period: 10 * time.Second}
+	for i := 0; i < 2; i++ {
+		if q, err := stores[i].take(ctx, "ip:10.0.0.1", window, now); err != nil || !q.allowed {
+			t.Fatalf("%d: want allowed but got %+v %v", i, q, err)
+		}
+	}
+	if q, err := stores[0].take(ctx, "ip:10.0.0.1", window, now.Add(5*time.Second)); err != nil || q.allowed || q.retry != 5*time.Second {
+		t.Fatalf("want rejected but got %+v %v", q, err)
+	}
+	// the half of the previous window is counted.
+	if q, err := stores[1].take(ctx, "ip:10.0.0.1", window, now.Add(15*time.Second)); err != nil || !q.allowed || q.remaining != 0 {
+		t.Fatalf("want allowed but got %+v %v", q, err)
+	}
+}
+
+func TestRedisFallback(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := mr.Addr()
+	mr.Close()
+	l := &rateLimiter{
+		options: &v1.RateLimit{},
+		rule:    rule{rate: 1, burst: 1},
+		store:   newStore(10),
+		redis:   newRedisStore(&v1.Redis{Addrs: []string{addr}}),
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	if q := l.take(req); !q.allowed {
+		t.Fatalf("want allowed locally but got %+v", q)
+	}
+	if q := l.take(req); q.allowed {
+		t.Fatalf("want rejected locally but got %+v", q)
+	}
+	if l.failed != 1 {
+		t.Fatal("want the redis failed")
+	}
+}
@@ -0,0 +1,198 @@
+package rbac
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/rbac/v1"
+	"github.com/go-kratos/gateway/audit"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultRolesClaim  = "roles"
+	_defaultScopesClaim = "scope"
+)
+
+func init() {
+	middleware.Register("rbac", Middleware)
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+func newSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func split(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+}
+
+// values returns the strings of the claim, either the array or the string
+// separated by space or comma.
+func values(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return split(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+type policy struct {
+	routes  map[string]struct{}
+	methods map[string]struct{}
+	roles   []string
+	scopes  []string
+	public  bool
+}
+
+func newPolicy(c *v1.Policy) *policy {
+	p := &policy{roles: c.Roles, scopes: c.Scopes, public: c.Public}
+	if len(c.Routes) > 0 {
+		p.routes = newSet(c.Routes)
+	}
+	if len(c.Methods) > 0 {
+		p.methods = make(map[string]struct{}, len(c.Methods))
+		for _, m := range c.Methods {
+			p.methods[strings.ToUpper(m)] = struct{}{}
+		}
+	}
+	return p
+}
+
+func (p *policy) match(route, method string) bool {
+	if p.routes != nil {
+		if _, ok := p.routes[route]; !ok {
+			return false
+		}
+	}
+	if p.methods != nil {
+		if _, ok := p.methods[method]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// grants reports whether the policy grants the principal of any of the roles
+// or all of the scopes.
+func (p *policy) grants(roles, scopes map[string]struct{}) bool {
+	if p.public {
+		return true
+	}
+	for _, role := range p.roles {
+		if _, ok := roles[role]; ok {
+			return true
+		}
+	}
+	if len(p.scopes) == 0 {
+		return false
+	}
+	for _, scope := range p.scopes {
+		if _, ok := scopes[scope]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type authorizer struct {
+	options  *v1.RBAC
+	policies []*policy
+}
+
+// principal returns the roles and the scopes of the request.
+func (a *authorizer) principal(req *http.Request) (roles, scopes map[string]struct{}) {
+	if claims, ok := middleware.ClaimsFromContext(req.Context()); ok {
+		rolesClaim, scopesClaim := a.options.RolesClaim, a.options.ScopesClaim
+		if rolesClaim == "" {
+			rolesClaim = _defaultRolesClaim
+		}
+		if scopesClaim == "" {
+			scopesClaim = _defaultScopesClaim
+		}
+		claim, _ := middleware.LookupClaim(claims, rolesClaim)
+		roles = newSet(values(claim))
+		claim, _ = middleware.LookupClaim(claims, scopesClaim)
+		scopes = newSet(values(claim))
+		return roles, scopes
+	}
+	// the headers are read only if they're set by the auth middlewares, the
+	// clients can't grant themselves the roles.
+	roles, scopes = map[string]struct{}{}, map[string]struct{}{}
+	if name := a.options.RolesHeader; name != "" && middleware.TrustedHeader(req.Context(), name) {
+		roles = newSet(split(req.Header.Get(name)))
+	}
+	if name := a.options.ScopesHeader; name != "" && middleware.TrustedHeader(req.Context(), name) {
+		scopes = newSet(split(req.Header.Get(name)))
+	}
+	return roles, scopes
+}
+
+func (a *authorizer) allowed(req *http.Request) bool {
+	var route string
+	if e, ok := middleware.EndpointFromContext(req.Context()); ok {
+		route = e.Path
+	}
+	var roles, scopes map[string]struct{}
+	matched := false
+	for _, p := range a.policies {
+		if !p.match(route, req.Method) {
+			continue
+		}
+		if !matched {
+			matched = true
+			roles, scopes = a.principal(req)
+		}
+		if p.grants(roles, scopes) {
+			return true
+		}
+	}
+	return !matched && a.options.AllowUnmatched
+}
+
+// Middleware authorizes the requests by the roles and the scopes.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.RBAC{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	a := &authorizer{options: options, policies: make([]*policy, 0, len(options.Policies))}
+	for _, p := range options.Policies {
+		a.policies = append(a.policies, newPolicy(p))
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !a.allowed(req) {
+				audit.PolicyDenied(req, "rbac", "", http.StatusForbidden, "no policy allows the request")
+				return newResponse(http.StatusForbidden)
+			}
+			return next.RoundTrip(req)
+		})
+	}, nil
+}
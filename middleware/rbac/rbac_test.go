@@ -0,0 +1,101 @@
+package rbac
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/rbac/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newMiddleware(t *testing.T, options *v1.RBAC) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "rbac", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+}
+
+func do(t *testing.T, rt http.RoundTripper, method, route string, claims map[string]interface{}, header http.Header) int {
+	req := httptest.NewRequest(method, "/", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: route})
+	reqOpt.Claims = claims
+	req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestRBAC(t *testing.T) {
+	rt := newMiddleware(t, &v1.RBAC{
+		RolesClaim: "realm_access.roles",
+		Policies: []*v1.Policy{
+			{Routes: []string{"/health"}, Public: true},
+			{Routes: []string{"/orders/*"}, Methods: []string{"get"}, Roles: []string{"viewer", "admin"}},
+			{Routes: []string{"/orders/*"}, Roles: []string{"admin"}, Scopes: []string{"orders:write"}},
+		},
+	})
+	viewer := map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"viewer"}}}
+	writer := map[string]interface{}{"scope": "openid orders:write"}
+	testCases := []struct {
+		name, method, route string
+		claims              map[string]interface{}
+		want                int
+	}{
+		{"public", "GET", "/health", nil, http.StatusOK},
+		{"viewer get", "GET", "/orders/*", viewer, http.StatusOK},
+		{"viewer post", "POST", "/orders/*", viewer, http.StatusForbidden},
+		{"writer post", "POST", "/orders/*", writer, http.StatusOK},
+		{"anonymous", "GET", "/orders/*", nil, http.StatusForbidden},
+		{"unmatched", "GET", "/users/*", viewer, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		if got := do(t, rt, tc.method, tc.route, tc.claims, nil); got != tc.want {
+			t.Errorf("%s: want %d but got %d", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRolesHeader(t *testing.T) {
+	rt := newMiddleware(t, &v1.RBAC{
+		RolesHeader:    "X-User-Roles",
+		Policies:       []*v1.Policy{{Routes: []string{"/admin/*"}, Roles: []string{"admin"}}},
+		AllowUnmatched: true,
+	})
+	// the roles header is set by the auth middlewares, e.g. extauthz.
+	trusted := func(roles string) int {
+		return do(t, middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-User-Roles", roles)
+			middleware.WithTrustedHeaders(req.Context(), "x-user-roles")
+			return rt.RoundTrip(req)
+		}), "GET", "/admin/*", nil, nil)
+	}
+	if got := trusted("viewer, admin"); got != http.StatusOK {
+		t.Fatalf("want the roles of the header but got %d", got)
+	}
+	if got := trusted("viewer"); got != http.StatusForbidden {
+		t.Fatalf("want 403 but got %d", got)
+	}
+	if got := do(t, rt, "GET", "/admin/*", nil, http.Header{"X-User-Roles": []string{"admin"}}); got != http.StatusForbidden {
+		t.Fatalf("want the roles header sent by the client ignored but got %d", got)
+	}
+	if got := do(t, rt, "GET", "/users/*", nil, nil); got != http.StatusOK {
+		t.Fatalf("want the unmatched allowed but got %d", got)
+	}
+}
@@ -16,6 +16,7 @@ var ErrNotFound = errors.New("Middleware has not been registered")
 type Registry interface {
 	Register(name string, factory Factory)
 	Create(cfg *configv1.Middleware) (Middleware, error)
+	Exists(name string) bool
 }
 
 type middlewareRegistry struct {
@@ -42,6 +43,12 @@ func (p *middlewareRegistry) Create(cfg *configv1.Middleware) (Middleware, error
 	return nil, ErrNotFound
 }
 
+// Exists reports whether the middleware has been registered.
+func (p *middlewareRegistry) Exists(name string) bool {
+	_, ok := p.getMiddleware(createFullName(name))
+	return ok
+}
+
 func (p *middlewareRegistry) getMiddleware(name string) (Factory, bool) {
 	nameLower := strings.ToLower(name)
 	middlewareFn, ok := p.middleware[nameLower]
@@ -64,3 +71,8 @@ func Register(name string, factory Factory) {
 func Create(cfg *configv1.Middleware) (Middleware, error) {
 	return globalRegistry.Create(cfg)
 }
+
+// Exists reports whether the middleware has been registered.
+func Exists(name string) bool {
+	return globalRegistry.Exists(name)
+}
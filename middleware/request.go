@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
 	"github.com/go-kratos/kratos/v2/selector"
@@ -9,6 +11,8 @@ import (
 
 type contextKey struct{}
 
+var _placeholderPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
 // RequestOptions is a request option.
 type RequestOptions struct {
 	Endpoint             *config.Endpoint
@@ -19,6 +23,22 @@ type RequestOptions struct {
 	UpstreamResponseTime []float64
 	DoneFunc             selector.DoneFunc
 	LastAttempt          bool
+	Consumer             *config.Consumer
+	// Cluster is the backend cluster picked for the request, the retries are
+	// sent to the same cluster.
+	Cluster string
+	// PathVars is the variables of the path template, e.g. the id of
+	// /users/{id}, they are referred by {id} in the rewrites and the keys.
+	PathVars map[string]string
+	// Claims is the claims of the token verified by the auth middlewares,
+	// they're referred by the policy middlewares.
+	Claims map[string]interface{}
+	// TrustedHeaders is the canonical names of the request headers set by the
+	// auth middlewares, e.g. the upstream headers of the extauthz service.
+	TrustedHeaders []string
+	// RequestID is the id of the request generated or propagated by the
+	// requestid middleware, it's attached to the access logs and the traces.
+	RequestID string
 }
 
 type MetricsLabels interface {
@@ -69,6 +89,20 @@ func NewRequestOptions(c *config.Endpoint) *RequestOptions {
 	return o
 }
 
+// ExpandPlaceholders replaces the {name} placeholders of the template by the
+// values, e.g. the path variables, the unknown placeholders are kept.
+func ExpandPlaceholders(template string, values map[string]string) string {
+	if len(values) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+	return _placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		if v, ok := values[match[1:len(match)-1]]; ok {
+			return v
+		}
+		return match
+	})
+}
+
 // NewRequestContext returns a new Context that carries value.
 func NewRequestContext(ctx context.Context, o *RequestOptions) context.Context {
 	return context.WithValue(ctx, contextKey{}, o)
@@ -0,0 +1,113 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/requestid/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultHeader = "X-Request-Id"
+	// the max length of the request ids sent by the clients.
+	_maxLength = 128
+)
+
+var _generators = map[string]func() string{
+	"uuidv7": newUUIDv7,
+	"uuidv4": func() string { return uuid.New().String() },
+	"hex": func() string {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		return hex.EncodeToString(b)
+	},
+}
+
+func init() {
+	middleware.Register("requestid", Middleware)
+}
+
+// newUUIDv7 returns the UUID of version 7, the unix milliseconds followed by
+// the random bits, so the ids are sorted by the time generated.
+func newUUIDv7() string {
+	var u uuid.UUID
+	_, _ = rand.Read(u[:])
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(u[:6], ts[2:])
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return u.String()
+}
+
+// valid reports whether the request id sent by the client is propagated, it
+// should be the visible ASCII characters of the max length.
+func valid(id string) bool {
+	if id == "" || len(id) > _maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware generates the request ids of the requests without the valid
+// ones, they're sent to the upstreams and echoed in the responses.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.RequestID{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	header := _defaultHeader
+	if options.Header != "" {
+		header = http.CanonicalHeaderKey(options.Header)
+	}
+	format := options.Format
+	if format == "" {
+		format = "uuidv7"
+	}
+	generate, ok := _generators[format]
+	if !ok {
+		return nil, fmt.Errorf("invalid request id format: %s", format)
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpts, _ := middleware.FromRequestContext(req.Context())
+			id := req.Header.Get(header)
+			switch {
+			case reqOpts != nil && reqOpts.RequestID != "":
+				// the retries are sent with the id of the first attempt.
+				id = reqOpts.RequestID
+			case options.Override || !valid(id):
+				id = generate()
+			}
+			req.Header.Set(header, id)
+			if reqOpts != nil {
+				reqOpts.RequestID = id
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			if !options.SkipResponse {
+				resp.Header.Set(header, id)
+			}
+			return resp, nil
+		})
+	}, nil
+}
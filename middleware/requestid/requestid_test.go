@@ -0,0 +1,100 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/requestid/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var _uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDv7(t *testing.T) {
+	prev := newUUIDv7()
+	for i := 0; i < 100; i++ {
+		id := newUUIDv7()
+		if !_uuidv7Pattern.MatchString(id) {
+			t.Fatalf("want the uuidv7 but got %s", id)
+		}
+		// the ids are sorted by the milliseconds.
+		if id[:13] < prev[:13] {
+			t.Fatalf("want %s after %s", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	newMiddleware := func(options *v1.RequestID) http.RoundTripper {
+		any, err := anypb.New(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Middleware(&config.Middleware{Name: "requestid", Options: any})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+			resp.Header.Set("X-Upstream-Id", req.Header.Get("X-Request-Id"))
+			return resp, nil
+		}))
+	}
+	send := func(tripper http.RoundTripper, id string) (*http.Response, *middleware.RequestOptions) {
+		req := httptest.NewRequest("GET", "/", nil)
+		if id != "" {
+			req.Header.Set("X-Request-Id", id)
+		}
+		reqOpts := middleware.NewRequestOptions(&config.Endpoint{})
+		resp, err := tripper.RoundTrip(req.WithContext(middleware.NewRequestContext(req.Context(), reqOpts)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, reqOpts
+	}
+
+	tripper := newMiddleware(&v1.RequestID{})
+	resp, reqOpts := send(tripper, "")
+	id := resp.Header.Get("X-Request-Id")
+	if !_uuidv7Pattern.MatchString(id) || resp.Header.Get("X-Upstream-Id") != id || reqOpts.RequestID != id {
+		t.Fatalf("want the id generated and propagated but got %v %s", resp.Header, reqOpts.RequestID)
+	}
+	if resp, _ := send(tripper, "abc-123"); resp.Header.Get("X-Request-Id") != "abc-123" || resp.Header.Get("X-Upstream-Id") != "abc-123" {
+		t.Fatalf("want the id of the client but got %v", resp.Header)
+	}
+	if resp, _ := send(tripper, "bad id"); resp.Header.Get("X-Request-Id") == "bad id" {
+		t.Fatal("want the invalid id regenerated")
+	}
+
+	// the retries share the request options of the first attempt.
+	req := httptest.NewRequest("GET", "/", nil)
+	reqOpts = middleware.NewRequestOptions(&config.Endpoint{})
+	ctx := middleware.NewRequestContext(req.Context(), reqOpts)
+	first, _ := tripper.RoundTrip(req.Clone(ctx))
+	second, _ := tripper.RoundTrip(req.Clone(ctx))
+	if first.Header.Get("X-Request-Id") != second.Header.Get("X-Request-Id") {
+		t.Fatalf("want the same id of the retries but got %v and %v", first.Header, second.Header)
+	}
+
+	tripper = newMiddleware(&v1.RequestID{Format: "hex", Override: true, SkipResponse: true})
+	resp, reqOpts = send(tripper, "abc-123")
+	if resp.Header.Get("X-Request-Id") != "" || len(resp.Header.Get("X-Upstream-Id")) != 32 || reqOpts.RequestID == "abc-123" {
+		t.Fatalf("want the hex id overridden but got %v", resp.Header)
+	}
+	if _, err := Middleware(&config.Middleware{Name: "requestid", Options: mustAny(t, &v1.RequestID{Format: "snowflake"})}); err == nil {
+		t.Fatal("want the error of the invalid format")
+	}
+}
+
+func mustAny(t *testing.T, options *v1.RequestID) *anypb.Any {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return any
+}
@@ -1,8 +1,10 @@
 package rewrite
 
 import (
+	"fmt"
 	"net/http"
 	"path"
+	"regexp"
 	"strings"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
@@ -28,6 +30,17 @@ func stripPrefix(origin string, prefix string) string {
 	return out
 }
 
+func replacePrefix(origin string, prefix string, replacement string) string {
+	if !strings.HasPrefix(origin, prefix) {
+		return origin
+	}
+	out := replacement + strings.TrimPrefix(origin, prefix)
+	if out == "" || out[0] != '/' {
+		return path.Join("/", out)
+	}
+	return out
+}
+
 func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 	options := &v1.Rewrite{}
 	if c.Options != nil {
@@ -35,25 +48,47 @@ func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 			return nil, err
 		}
 	}
+	var regexRewrite *regexp.Regexp
+	if options.RegexRewrite != nil {
+		re, err := regexp.Compile(options.RegexRewrite.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex rewrite pattern: %s: %w", options.RegexRewrite.Pattern, err)
+		}
+		regexRewrite = re
+	}
 	requestHeadersRewrite := options.RequestHeadersRewrite
 	responseHeadersRewrite := options.ResponseHeadersRewrite
 	return func(next http.RoundTripper) http.RoundTripper {
 		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var vars map[string]string
+			if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+				vars = reqOpt.PathVars
+			}
 			if options.PathRewrite != nil {
-				req.URL.Path = *options.PathRewrite
+				req.URL.Path = middleware.ExpandPlaceholders(*options.PathRewrite, vars)
 			}
 			if options.HostRewrite != nil {
 				req.Host = *options.HostRewrite
+			} else if options.AutoHostRewrite {
+				// the client sends the host of the request URL which is
+				// set to the upstream node address.
+				req.Host = ""
 			}
 			if options.StripPrefix != nil {
 				req.URL.Path = stripPrefix(req.URL.Path, options.GetStripPrefix())
 			}
+			if options.PrefixReplace != nil {
+				req.URL.Path = replacePrefix(req.URL.Path, options.PrefixReplace.Prefix, middleware.ExpandPlaceholders(options.PrefixReplace.Replacement, vars))
+			}
+			if regexRewrite != nil {
+				req.URL.Path = regexRewrite.ReplaceAllString(req.URL.Path, options.RegexRewrite.Substitution)
+			}
 			if requestHeadersRewrite != nil {
 				for key, value := range requestHeadersRewrite.Set {
-					req.Header.Set(key, value)
+					req.Header.Set(key, middleware.ExpandPlaceholders(value, vars))
 				}
 				for key, value := range requestHeadersRewrite.Add {
-					req.Header.Add(key, value)
+					req.Header.Add(key, middleware.ExpandPlaceholders(value, vars))
 				}
 				for _, value := range requestHeadersRewrite.Remove {
 					req.Header.Del(value)
@@ -66,10 +101,10 @@ func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 			}
 			if responseHeadersRewrite != nil {
 				for key, value := range responseHeadersRewrite.Set {
-					resp.Header.Set(key, value)
+					resp.Header.Set(key, middleware.ExpandPlaceholders(value, vars))
 				}
 				for key, value := range responseHeadersRewrite.Add {
-					resp.Header.Add(key, value)
+					resp.Header.Add(key, middleware.ExpandPlaceholders(value, vars))
 				}
 				for _, value := range responseHeadersRewrite.Remove {
 					resp.Header.Del(value)
@@ -1,6 +1,18 @@
 package rewrite
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/rewrite/v1"
+	"github.com/go-kratos/gateway/client"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
 
 func TestStripPrefix(t *testing.T) {
 	p1 := "/dddd/"
@@ -29,3 +41,137 @@ func TestStripPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestReplacePrefix(t *testing.T) {
+	testCases := []struct {
+		origin, prefix, replacement, want string
+	}{
+		{"/api/v1/users", "/api/v1/", "/v1/", "/v1/users"},
+		{"/api/v1/users", "/api", "", "/v1/users"},
+		{"/api/v1/users", "/api/v1/users", "", "/"},
+		{"/api/v1/users", "/api/", "v2/", "/v2/v1/users"},
+		{"/other", "/api/", "/v1/", "/other"},
+	}
+	for _, tc := range testCases {
+		if got := replacePrefix(tc.origin, tc.prefix, tc.replacement); got != tc.want {
+			t.Errorf("replacePrefix(%s, %s, %s) = %s, want %s", tc.origin, tc.prefix, tc.replacement, got, tc.want)
+		}
+	}
+}
+
+func TestPathRewrite(t *testing.T) {
+	options, err := anypb.New(&v1.Rewrite{
+		StripPrefix:   proto.String("/public"),
+		PrefixReplace: &v1.PrefixReplace{Prefix: "/api/v1/", Replacement: "/v1/"},
+		RegexRewrite:  &v1.RegexRewrite{Pattern: `^/v1/users/(\w+)/posts$`, Substitution: "/v1/posts/${1}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "rewrite", Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.URL.Path
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	for origin, want := range map[string]string{
+		"/public/api/v1/users/alice/posts": "/v1/posts/alice",
+		"/public/api/v1/users/alice":       "/v1/users/alice",
+		"/public/health":                   "/health",
+	} {
+		if _, err := next.RoundTrip(httptest.NewRequest("GET", origin, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: want %s but got %s", origin, want, got)
+		}
+	}
+
+	options, _ = anypb.New(&v1.Rewrite{RegexRewrite: &v1.RegexRewrite{Pattern: "("}})
+	if _, err := Middleware(&config.Middleware{Name: "rewrite", Options: options}); err == nil {
+		t.Error("want error of the invalid regex")
+	}
+}
+
+func TestPathVarsRewrite(t *testing.T) {
+	options, err := anypb.New(&v1.Rewrite{
+		PathRewrite: proto.String("/orders/{order_id}"),
+		RequestHeadersRewrite: &v1.HeadersPolicy{
+			Set: map[string]string{"X-User-Id": "{id}", "X-Unknown": "{unknown}"},
+		},
+		ResponseHeadersRewrite: &v1.HeadersPolicy{
+			Set: map[string]string{"X-Order-Id": "{order_id}"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "rewrite", Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *http.Request
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+	}))
+	req := httptest.NewRequest("GET", "/users/alice/orders/42", nil)
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/users/{id}/orders/{order_id}"})
+	reqOpt.PathVars = map[string]string{"id": "alice", "order_id": "42"}
+	resp, err := next.RoundTrip(req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.Path != "/orders/42" || got.Header.Get("X-User-Id") != "alice" || got.Header.Get("X-Unknown") != "{unknown}" {
+		t.Errorf("unexpected rewritten request: %s %v", got.URL.Path, got.Header)
+	}
+	if resp.Header.Get("X-Order-Id") != "42" {
+		t.Errorf("unexpected rewritten response header: %v", resp.Header)
+	}
+}
+
+func TestHostRewrite(t *testing.T) {
+	hosts := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	endpoint := &config.Endpoint{Protocol: config.Protocol_HTTP, Backends: []*config.Backend{{Target: addr}}}
+	upstream, err := client.NewFactory(nil)(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		options *v1.Rewrite
+		want    string
+	}{
+		{&v1.Rewrite{}, "api.example.com"},
+		{&v1.Rewrite{HostRewrite: proto.String("internal.example.com")}, "internal.example.com"},
+		{&v1.Rewrite{AutoHostRewrite: true}, addr},
+		{&v1.Rewrite{HostRewrite: proto.String("internal.example.com"), AutoHostRewrite: true}, "internal.example.com"},
+	}
+	for _, tc := range testCases {
+		options, err := anypb.New(tc.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Middleware(&config.Middleware{Name: "rewrite", Options: options})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest("GET", "http://api.example.com/hello", nil)
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), middleware.NewRequestOptions(endpoint)))
+		resp, err := m(upstream).RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := <-hosts; got != tc.want {
+			t.Errorf("%v: want host %s but got %s", tc.options, tc.want, got)
+		}
+	}
+}
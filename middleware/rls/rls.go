@@ -0,0 +1,235 @@
+package rls
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/rls/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const _defaultTimeout = 100 * time.Millisecond
+
+var (
+	// the connections are shared by the targets, they're kept across the
+	// rebuilds of the middlewares on the config updates.
+	_connsLock sync.Mutex
+	_conns     = map[string]*grpc.ClientConn{}
+
+	// the sequence of the middlewares, the decision of a request is kept in
+	// the metadata of the sequence to not limit the retries again.
+	_seq uint64
+)
+
+func init() {
+	middleware.Register("rls", Middleware)
+}
+
+func dial(target string) (*grpc.ClientConn, error) {
+	_connsLock.Lock()
+	defer _connsLock.Unlock()
+	if conn, ok := _conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	_conns[target] = conn
+	return conn, nil
+}
+
+// decision is the headers of the service applied to the allowed request and
+// its response.
+type decision struct {
+	Request  http.Header `json:"request,omitempty"`
+	Response http.Header `json:"response,omitempty"`
+}
+
+// entryValue returns the value of the entry of the request.
+func entryValue(req *http.Request, e *v1.Entry) string {
+	switch e.Source {
+	case v1.Entry_HEADER:
+		return req.Header.Get(e.Value)
+	case v1.Entry_CLIENT_IP:
+		if ip := clientip.FromRequest(req); ip != nil {
+			return ip.String()
+		}
+	case v1.Entry_CONSUMER:
+		if consumer, ok := middleware.ConsumerFromContext(req.Context()); ok {
+			return consumer.Name
+		}
+	case v1.Entry_ROUTE:
+		if e, ok := middleware.EndpointFromContext(req.Context()); ok {
+			return e.Path
+		}
+	case v1.Entry_METHOD:
+		return req.Method
+	case v1.Entry_PATH_VAR:
+		if reqOpt, ok := middleware.FromRequestContext(req.Context()); ok {
+			return reqOpt.PathVars[e.Value]
+		}
+	default:
+		return e.Value
+	}
+	return ""
+}
+
+func descriptors(req *http.Request, descs []*v1.Descriptor) []*ratelimitv3.RateLimitDescriptor {
+	out := make([]*ratelimitv3.RateLimitDescriptor, 0, len(descs))
+next:
+	for _, d := range descs {
+		entries := make([]*ratelimitv3.RateLimitDescriptor_Entry, 0, len(d.Entries))
+		for _, e := range d.Entries {
+			value := entryValue(req, e)
+			if value == "" {
+				continue next
+			}
+			entries = append(entries, &ratelimitv3.RateLimitDescriptor_Entry{Key: e.Key, Value: value})
+		}
+		out = append(out, &ratelimitv3.RateLimitDescriptor{Entries: entries})
+	}
+	return out
+}
+
+func unitSeconds(unit rlsv3.RateLimitResponse_RateLimit_Unit) float64 {
+	switch unit {
+	case rlsv3.RateLimitResponse_RateLimit_SECOND:
+		return 1
+	case rlsv3.RateLimitResponse_RateLimit_MINUTE:
+		return 60
+	case rlsv3.RateLimitResponse_RateLimit_HOUR:
+		return 3600
+	case rlsv3.RateLimitResponse_RateLimit_DAY:
+		return 86400
+	}
+	return 0
+}
+
+// setLimitHeaders sets the RateLimit headers of the closest limit.
+func setLimitHeaders(header http.Header, statuses []*rlsv3.RateLimitResponse_DescriptorStatus) {
+	var closest *rlsv3.RateLimitResponse_DescriptorStatus
+	for _, s := range statuses {
+		if s.CurrentLimit == nil {
+			continue
+		}
+		if closest == nil || s.LimitRemaining < closest.LimitRemaining {
+			closest = s
+		}
+	}
+	if closest == nil {
+		return
+	}
+	reset := unitSeconds(closest.CurrentLimit.Unit)
+	if closest.DurationUntilReset != nil {
+		reset = closest.DurationUntilReset.AsDuration().Seconds()
+	}
+	header.Set("RateLimit-Limit", strconv.FormatUint(uint64(closest.CurrentLimit.RequestsPerUnit), 10))
+	header.Set("RateLimit-Remaining", strconv.FormatUint(uint64(closest.LimitRemaining), 10))
+	header.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset))))
+}
+
+func newResponse(statusCode int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// Middleware limits the requests by the external rate limit service.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.RLS{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	if options.Target == "" || options.Domain == "" {
+		return nil, errors.New("rls target and domain are required")
+	}
+	timeout := _defaultTimeout
+	if options.Timeout != nil {
+		timeout = options.Timeout.AsDuration()
+	}
+	conn, err := dial(options.Target)
+	if err != nil {
+		return nil, err
+	}
+	client := rlsv3.NewRateLimitServiceClient(conn)
+	metadataKey := fmt.Sprintf("rls.%d", atomic.AddUint64(&_seq, 1))
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpt, _ := middleware.FromRequestContext(req.Context())
+			d := &decision{}
+			if reqOpt == nil || json.Unmarshal([]byte(reqOpt.Metadata[metadataKey]), d) != nil {
+				descs := descriptors(req, options.Descriptors)
+				if len(descs) == 0 {
+					return next.RoundTrip(req)
+				}
+				ctx, cancel := context.WithTimeout(req.Context(), timeout)
+				resp, err := client.ShouldRateLimit(ctx, &rlsv3.RateLimitRequest{Domain: options.Domain, Descriptors: descs})
+				cancel()
+				if err != nil {
+					log.Errorf("Failed to call the rate limit service of %s: %+v", req.URL.Path, err)
+					if options.FailureModeDeny {
+						return newResponse(http.StatusInternalServerError, http.Header{}, nil), nil
+					}
+					return next.RoundTrip(req)
+				}
+				d = &decision{Request: http.Header{}, Response: http.Header{}}
+				for _, h := range resp.RequestHeadersToAdd {
+					d.Request.Add(h.Key, h.Value)
+				}
+				for _, h := range resp.ResponseHeadersToAdd {
+					d.Response.Add(h.Key, h.Value)
+				}
+				setLimitHeaders(d.Response, resp.Statuses)
+				if resp.OverallCode == rlsv3.RateLimitResponse_OVER_LIMIT {
+					if d.Response.Get("Retry-After") == "" {
+						retry := d.Response.Get("RateLimit-Reset")
+						if n, _ := strconv.Atoi(retry); n < 1 {
+							retry = "1"
+						}
+						d.Response.Set("Retry-After", retry)
+					}
+					return newResponse(http.StatusTooManyRequests, d.Response, resp.RawBody), nil
+				}
+				if reqOpt != nil {
+					data, _ := json.Marshal(d)
+					reqOpt.Metadata[metadataKey] = string(data)
+				}
+			}
+			for name, values := range d.Request {
+				req.Header[name] = values
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			for name, values := range d.Response {
+				resp.Header[name] = values
+			}
+			return resp, nil
+		})
+	}, nil
+}
@@ -0,0 +1,164 @@
+package rls
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/rls/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type rateLimitServer struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+	calls int
+}
+
+func (s *rateLimitServer) ShouldRateLimit(ctx context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	s.calls++
+	if req.Domain != "gateway" || len(req.Descriptors) != 1 {
+		return &rlsv3.RateLimitResponse{OverallCode: rlsv3.RateLimitResponse_OVER_LIMIT}, nil
+	}
+	entries := req.Descriptors[0].Entries
+	if len(entries) != 3 || entries[0].Value != "api" || entries[1].Value != "/orders/{id}" || entries[2].Value != "1" {
+		return &rlsv3.RateLimitResponse{OverallCode: rlsv3.RateLimitResponse_OVER_LIMIT}, nil
+	}
+	status := &rlsv3.RateLimitResponse_DescriptorStatus{
+		CurrentLimit:       &rlsv3.RateLimitResponse_RateLimit{RequestsPerUnit: 10, Unit: rlsv3.RateLimitResponse_RateLimit_MINUTE},
+		LimitRemaining:     9,
+		DurationUntilReset: durationpb.New(30 * time.Second),
+	}
+	if s.calls > 2 {
+		status.LimitRemaining = 0
+		return &rlsv3.RateLimitResponse{
+			OverallCode: rlsv3.RateLimitResponse_OVER_LIMIT,
+			Statuses:    []*rlsv3.RateLimitResponse_DescriptorStatus{status},
+			RawBody:     []byte("over limit"),
+		}, nil
+	}
+	return &rlsv3.RateLimitResponse{
+		OverallCode:         rlsv3.RateLimitResponse_OK,
+		Statuses:            []*rlsv3.RateLimitResponse_DescriptorStatus{status},
+		RequestHeadersToAdd: []*corev3.HeaderValue{{Key: "x-ratelimit-tier", Value: "free"}},
+	}, nil
+}
+
+func TestRLS(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &rateLimitServer{}
+	srv := grpc.NewServer()
+	rlsv3.RegisterRateLimitServiceServer(srv, server)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	any, err := anypb.New(&v1.RLS{
+		Target: lis.Addr().String(),
+		Domain: "gateway",
+		Descriptors: []*v1.Descriptor{
+			{Entries: []*v1.Entry{
+				{Key: "generic_key", Value: "api"},
+				{Key: "route", Source: v1.Entry_ROUTE},
+				{Key: "id", Source: v1.Entry_PATH_VAR, Value: "id"},
+			}},
+			{Entries: []*v1.Entry{{Key: "tenant", Source: v1.Entry_HEADER, Value: "X-Tenant"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "rls", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var upstream http.Header
+	next := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		upstream = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+	newRequestOptions := func() *middleware.RequestOptions {
+		reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/orders/{id}"})
+		reqOpt.PathVars = map[string]string{"id": "1"}
+		return reqOpt
+	}
+	do := func(reqOpt *middleware.RequestOptions) *http.Response {
+		req := httptest.NewRequest("GET", "/orders/1", nil)
+		req = req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	reqOpt := newRequestOptions()
+	for i := 0; i < 2; i++ {
+		resp := do(reqOpt)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Limit") != "10" ||
+			resp.Header.Get("RateLimit-Remaining") != "9" || resp.Header.Get("RateLimit-Reset") != "30" {
+			t.Fatalf("unexpected response: %d %v", resp.StatusCode, resp.Header)
+		}
+		if upstream.Get("X-Ratelimit-Tier") != "free" {
+			t.Fatalf("unexpected upstream headers: %v", upstream)
+		}
+	}
+	if server.calls != 1 {
+		t.Fatalf("want the retry not limited again but got %d calls", server.calls)
+	}
+	do(newRequestOptions())
+	resp := do(newRequestOptions())
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "30" || string(body) != "over limit" {
+		t.Fatalf("want 429 but got %d %v %q", resp.StatusCode, resp.Header, body)
+	}
+}
+
+func TestFailureMode(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := lis.Addr().String()
+	lis.Close()
+	for _, deny := range []bool{false, true} {
+		any, err := anypb.New(&v1.RLS{
+			Target:          target,
+			Domain:          "gateway",
+			Descriptors:     []*v1.Descriptor{{Entries: []*v1.Entry{{Key: "generic_key", Value: "api"}}}},
+			FailureModeDeny: deny,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Middleware(&config.Middleware{Name: "rls", Options: any})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+		})).RoundTrip(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := http.StatusOK
+		if deny {
+			want = http.StatusInternalServerError
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("failure mode deny %t: want %d but got %d", deny, want, resp.StatusCode)
+		}
+	}
+}
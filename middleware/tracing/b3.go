@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_b3Single  = "b3"
+	_b3TraceID = "X-B3-Traceid"
+	_b3SpanID  = "X-B3-Spanid"
+	_b3Sampled = "X-B3-Sampled"
+	_b3Flags   = "X-B3-Flags"
+)
+
+// deferredKey marks the context of the remote parent without the sampling
+// decision, the span is sampled by the route as the root one.
+type deferredKey struct{}
+
+// b3 propagates the trace context by the B3 headers of zipkin, both the
+// single and the multiple headers are extracted, and the multiple ones are
+// injected only if multi.
+type b3 struct {
+	multi bool
+}
+
+var _ propagation.TextMapPropagator = b3{}
+
+// parseB3 returns the span context of the ids, the 64-bit trace ids are left
+// padded, and the sampling state is deferred if it's empty.
+func parseB3(traceID, spanID, sampling string) (trace.SpanContext, bool, bool) {
+	if len(traceID) == 16 {
+		traceID = "0000000000000000" + traceID
+	}
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return trace.SpanContext{}, false, false
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return trace.SpanContext{}, false, false
+	}
+	cfg := trace.SpanContextConfig{TraceID: tid, SpanID: sid, Remote: true}
+	deferred := false
+	switch strings.ToLower(sampling) {
+	case "1", "true", "d":
+		cfg.TraceFlags = trace.FlagsSampled
+	case "0", "false":
+	case "":
+		deferred = true
+	default:
+		return trace.SpanContext{}, false, false
+	}
+	return trace.NewSpanContext(cfg), deferred, true
+}
+
+func (b3) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	var (
+		sc       trace.SpanContext
+		deferred bool
+		ok       bool
+	)
+	if v := carrier.Get(_b3Single); v != "" {
+		// {trace id}-{span id}-{sampling state}-{parent span id}, the
+		// sampling state only is not the span context.
+		parts := strings.Split(v, "-")
+		if len(parts) < 2 {
+			return ctx
+		}
+		sampling := ""
+		if len(parts) > 2 {
+			sampling = parts[2]
+		}
+		sc, deferred, ok = parseB3(parts[0], parts[1], sampling)
+	} else {
+		sampling := carrier.Get(_b3Sampled)
+		if carrier.Get(_b3Flags) == "1" {
+			// the debug flag implies the sampled one.
+			sampling = "d"
+		}
+		sc, deferred, ok = parseB3(carrier.Get(_b3TraceID), carrier.Get(_b3SpanID), sampling)
+	}
+	if !ok {
+		return ctx
+	}
+	if deferred {
+		ctx = context.WithValue(ctx, deferredKey{}, true)
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+func (b b3) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+	if b.multi {
+		carrier.Set(_b3TraceID, sc.TraceID().String())
+		carrier.Set(_b3SpanID, sc.SpanID().String())
+		carrier.Set(_b3Sampled, sampled)
+		return
+	}
+	carrier.Set(_b3Single, sc.TraceID().String()+"-"+sc.SpanID().String()+"-"+sampled)
+}
+
+func (b b3) Fields() []string {
+	if b.multi {
+		return []string{_b3TraceID, _b3SpanID, _b3Sampled}
+	}
+	return []string{_b3Single}
+}
@@ -0,0 +1,181 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/kratos/v2/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+var _propagators = map[string]propagation.TextMapPropagator{
+	"tracecontext": propagation.TraceContext{},
+	"baggage":      propagation.Baggage{},
+	"b3":           b3{},
+	"b3multi":      b3{multi: true},
+}
+
+// the provider configured by the gateway config, the one of the middleware
+// options is used if it's nil.
+var _gatewayProvider = &struct {
+	sync.RWMutex
+	conf       *config.Tracing
+	provider   *sdktrace.TracerProvider
+	propagator propagation.TextMapPropagator
+}{}
+
+// samplerKey is the sampler of the route in the context.
+type samplerKey struct{}
+
+// routeSampler samples the root spans by the sampler of the route in the
+// context or the default one, the spans of the remote parents follow the
+// decisions of the parents unless they're deferred.
+type routeSampler struct {
+	root   sdktrace.Sampler
+	parent sdktrace.Sampler
+}
+
+type rootSampler struct {
+	def sdktrace.Sampler
+}
+
+func (s rootSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if sampler, ok := p.ParentContext.Value(samplerKey{}).(sdktrace.Sampler); ok {
+		return sampler.ShouldSample(p)
+	}
+	return s.def.ShouldSample(p)
+}
+
+func (s rootSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{%s}", s.def.Description())
+}
+
+func newRouteSampler(def sdktrace.Sampler) sdktrace.Sampler {
+	root := rootSampler{def: def}
+	return routeSampler{root: root, parent: sdktrace.ParentBased(root)}
+}
+
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if deferred, _ := p.ParentContext.Value(deferredKey{}).(bool); deferred {
+		return s.root.ShouldSample(p)
+	}
+	return s.parent.ShouldSample(p)
+}
+
+func (s routeSampler) Description() string {
+	return s.parent.Description()
+}
+
+func newPropagator(names []string) (propagation.TextMapPropagator, error) {
+	if len(names) == 0 {
+		names = []string{"tracecontext", "baggage"}
+	}
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+	for _, name := range names {
+		p, ok := _propagators[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid tracing propagator: %s", name)
+		}
+		propagators = append(propagators, p)
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+func newGatewayProvider(c *config.Tracing) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Endpoint)}
+	if c.UrlPath != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(c.UrlPath))
+	}
+	if c.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(c.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(c.Headers))
+	}
+	if c.Compression {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+	timeout := defaultTimeout
+	if c.Timeout != nil {
+		timeout = c.Timeout.AsDuration()
+	}
+	opts = append(opts, otlptracehttp.WithTimeout(timeout))
+	exporter, err := otlptrace.New(context.Background(), otlptracehttp.NewClient(opts...))
+	if err != nil {
+		return nil, err
+	}
+	serviceName := c.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	sampler := sdktrace.AlwaysSample()
+	if c.SampleRatio != nil {
+		sampler = sdktrace.TraceIDRatioBased(float64(*c.SampleRatio))
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(newRouteSampler(sampler)),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	), nil
+}
+
+// SetProvider replaces the tracer provider of the tracing middlewares by the
+// tracing of the gateway config, it's called by the proxy on the config
+// update, the spans of the previous provider are flushed in the background.
+func SetProvider(c *config.Tracing) error {
+	_gatewayProvider.Lock()
+	defer _gatewayProvider.Unlock()
+	if proto.Equal(_gatewayProvider.conf, c) {
+		return nil
+	}
+	var (
+		provider   *sdktrace.TracerProvider
+		propagator propagation.TextMapPropagator
+	)
+	if c != nil {
+		var err error
+		if propagator, err = newPropagator(c.Propagators); err != nil {
+			return err
+		}
+		if provider, err = newGatewayProvider(c); err != nil {
+			return err
+		}
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}
+	if prev := _gatewayProvider.provider; prev != nil {
+		if provider == nil {
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		}
+		go func() {
+			if err := prev.Shutdown(context.Background()); err != nil {
+				log.Errorf("Failed to shutdown the tracer provider: %+v", err)
+			}
+		}()
+	}
+	_gatewayProvider.conf = c
+	_gatewayProvider.provider = provider
+	_gatewayProvider.propagator = propagator
+	return nil
+}
+
+// gatewayProvider returns the provider and the propagator of the gateway
+// config, it's false if not configured.
+func gatewayProvider() (trace.TracerProvider, propagation.TextMapPropagator, bool) {
+	_gatewayProvider.RLock()
+	defer _gatewayProvider.RUnlock()
+	if _gatewayProvider.provider == nil {
+		return nil, nil, false
+	}
+	return _gatewayProvider.provider, _gatewayProvider.propagator, true
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/go-kratos/gateway/middleware"
 	"github.com/go-kratos/kratos/v2"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
@@ -40,6 +41,21 @@ func init() {
 	middleware.Register("tracing", Middleware)
 }
 
+// tracerOf returns the tracer and the propagator of the gateway config, the
+// provider of the middleware options is initialized once otherwise.
+func tracerOf(options *v1.Tracing) (trace.Tracer, propagation.TextMapPropagator) {
+	if provider, propagator, ok := gatewayProvider(); ok {
+		return provider.Tracer(defaultTracerName), propagator
+	}
+	globaltp.initOnce.Do(func() {
+		globaltp.provider = newTracerProvider(context.Background(), options)
+		propagator := propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{})
+		otel.SetTracerProvider(globaltp.provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return globaltp.provider.Tracer(defaultTracerName), otel.GetTextMapPropagator()
+}
+
 // Middleware is a opentelemetry middleware.
 func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 	options := &v1.Tracing{}
@@ -48,19 +64,19 @@ func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 			return nil, err
 		}
 	}
-	if globaltp.provider == nil {
-		globaltp.initOnce.Do(func() {
-			globaltp.provider = newTracerProvider(context.Background(), options)
-			propagator := propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{})
-			otel.SetTracerProvider(globaltp.provider)
-			otel.SetTextMapPropagator(propagator)
-		})
+	var sampler sdktrace.Sampler
+	if options.SampleRatio != nil {
+		sampler = sdktrace.TraceIDRatioBased(float64(*options.SampleRatio))
 	}
-	tracer := otel.Tracer(defaultTracerName)
 	return func(next http.RoundTripper) http.RoundTripper {
 		return middleware.RoundTripperFunc(func(req *http.Request) (reply *http.Response, err error) {
+			tracer, propagator := tracerOf(options)
+			ctx := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			if sampler != nil {
+				ctx = context.WithValue(ctx, samplerKey{}, sampler)
+			}
 			ctx, span := tracer.Start(
-				req.Context(),
+				ctx,
 				fmt.Sprintf("%s %s", req.Method, req.URL.Path),
 				trace.WithSpanKind(trace.SpanKindClient),
 			)
@@ -71,6 +87,14 @@ func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 				semconv.HTTPTargetKey.String(req.URL.Path),
 				semconv.NetPeerIPKey.String(req.RemoteAddr),
 			)
+			reqOpts, _ := middleware.FromRequestContext(ctx)
+			if reqOpts != nil {
+				// the attempts of the retries are in the spans of the same parent.
+				span.SetAttributes(
+					semconv.HTTPRouteKey.String(reqOpts.Endpoint.GetPath()),
+					attribute.Int("gateway.attempt", len(reqOpts.UpstreamStatusCode)),
+				)
+			}
 
 			defer func() {
 				if err != nil {
@@ -82,8 +106,22 @@ func Middleware(c *config.Middleware) (middleware.Middleware, error) {
 				if reply != nil {
 					span.SetAttributes(semconv.HTTPStatusCodeKey.Int(reply.StatusCode))
 				}
+				if reqOpts != nil {
+					span.SetAttributes(attribute.Bool("gateway.last_attempt", reqOpts.LastAttempt))
+					if reqOpts.Cluster != "" {
+						span.SetAttributes(attribute.String("gateway.cluster", reqOpts.Cluster))
+					}
+					if n := len(reqOpts.Backends); n > 0 {
+						span.SetAttributes(attribute.String("gateway.backend", reqOpts.Backends[n-1]))
+					}
+					// the request id is set by the requestid middleware before or after.
+					if reqOpts.RequestID != "" {
+						span.SetAttributes(attribute.String("http.request_id", reqOpts.RequestID))
+					}
+				}
 				span.End()
 			}()
+			propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
 			return next.RoundTrip(req.WithContext(ctx))
 		})
 	}, nil
@@ -103,10 +141,8 @@ func newTracerProvider(ctx context.Context, options *v1.Tracing) trace.TracerPro
 		timeout = options.Timeout.AsDuration()
 	}
 
-	var sampler sdktrace.Sampler
-	if options.SampleRatio == nil {
-		sampler = sdktrace.AlwaysSample()
-	} else {
+	sampler := sdktrace.AlwaysSample()
+	if options.SampleRatio != nil {
 		sampler = sdktrace.TraceIDRatioBased(float64(*options.SampleRatio))
 	}
 
@@ -134,7 +170,7 @@ func newTracerProvider(ctx context.Context, options *v1.Tracing) trace.TracerPro
 	)
 
 	return sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sampler),
+		sdktrace.WithSampler(newRouteSampler(sampler)),
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(resources),
 	)
@@ -2,14 +2,18 @@ package tracing
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
 	v1 "github.com/go-kratos/gateway/api/gateway/middleware/tracing/v1"
 	"github.com/go-kratos/gateway/middleware"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -40,3 +44,81 @@ func TestTracer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestB3(t *testing.T) {
+	ctx := b3{}.Extract(context.Background(), propagation.HeaderCarrier(http.Header{
+		"B3": {"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"},
+	}))
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.TraceID().String() != "80f198ee56343ba864fe8b2a57d3eff7" || sc.SpanID().String() != "e457b5a2e4d86bd1" || !sc.IsSampled() || !sc.IsRemote() {
+		t.Fatalf("want the span context of the single header but got %v", sc)
+	}
+	header := http.Header{}
+	header.Set("X-B3-TraceId", "64fe8b2a57d3eff7")
+	header.Set("X-B3-SpanId", "e457b5a2e4d86bd1")
+	ctx = b3{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+	if sc := trace.SpanContextFromContext(ctx); sc.TraceID().String() != "000000000000000064fe8b2a57d3eff7" || sc.IsSampled() || ctx.Value(deferredKey{}) != true {
+		t.Fatalf("want the deferred span context of the multiple headers but got %v", sc)
+	}
+	if ctx := (b3{}).Extract(context.Background(), propagation.HeaderCarrier(http.Header{"B3": {"0"}})); trace.SpanContextFromContext(ctx).IsValid() {
+		t.Fatal("want no span context of the sampling state only")
+	}
+
+	out := http.Header{}
+	b3{multi: true}.Inject(trace.ContextWithSpanContext(context.Background(), sc), propagation.HeaderCarrier(out))
+	if out.Get("X-B3-TraceId") != sc.TraceID().String() || out.Get("X-B3-SpanId") != sc.SpanID().String() || out.Get("X-B3-Sampled") != "1" {
+		t.Fatalf("want the multiple headers but got %v", out)
+	}
+}
+
+func TestGatewayProvider(t *testing.T) {
+	if err := SetProvider(&config.Tracing{Endpoint: "127.0.0.1:4318", Insecure: true, Propagators: []string{"tracecontext", "b3"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProvider(nil)
+	if err := SetProvider(&config.Tracing{Propagators: []string{"jaeger"}}); err == nil {
+		t.Fatal("want the error of the invalid propagator")
+	}
+
+	var header http.Header
+	next := middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString("Hello Kratos"))}, nil
+	})
+	send := func(options *v1.Tracing, header http.Header) {
+		cfg, err := anypb.New(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Middleware(&config.Middleware{Options: cfg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest("GET", "/api/v1/hello", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		reqOpts := middleware.NewRequestOptions(&config.Endpoint{Path: "/api/v1/*"})
+		if _, err := m(next).RoundTrip(req.WithContext(middleware.NewRequestContext(req.Context(), reqOpts))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(&v1.Tracing{}, http.Header{"B3": {"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"}})
+	if b3 := header.Get("B3"); !strings.HasPrefix(b3, "80f198ee56343ba864fe8b2a57d3eff7-") || strings.Contains(b3, "e457b5a2e4d86bd1") || !strings.HasSuffix(b3, "-1") {
+		t.Fatalf("want the child span of the b3 parent but got %s", b3)
+	}
+	if tp := header.Get("Traceparent"); !strings.Contains(tp, "80f198ee56343ba864fe8b2a57d3eff7") {
+		t.Fatalf("want the traceparent of the trace but got %s", tp)
+	}
+	ratio := float32(0)
+	send(&v1.Tracing{SampleRatio: &ratio}, nil)
+	if b3 := header.Get("B3"); !strings.HasSuffix(b3, "-0") {
+		t.Fatalf("want the root span not sampled by the route but got %s", b3)
+	}
+	// the deferred decision is made by the route.
+	send(&v1.Tracing{SampleRatio: &ratio}, http.Header{"X-B3-Traceid": {"64fe8b2a57d3eff7"}, "X-B3-Spanid": {"e457b5a2e4d86bd1"}})
+	if b3 := header.Get("B3"); !strings.HasPrefix(b3, "000000000000000064fe8b2a57d3eff7-") || !strings.HasSuffix(b3, "-0") {
+		t.Fatalf("want the deferred span not sampled but got %s", b3)
+	}
+}
@@ -0,0 +1,130 @@
+package waf
+
+import (
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/waf/v1"
+)
+
+// builtinRule is the rule of the built-in rule set, the ids and the paranoia
+// levels are borrowed from the OWASP Core Rule Set for reference, while the
+// patterns are simplified and far from the coverage of the CRS.
+type builtinRule struct {
+	set   string
+	level int32
+	rule  *v1.Rule
+}
+
+var (
+	_args       = []v1.Variable{v1.Variable_ARGS, v1.Variable_ARGS_NAMES, v1.Variable_REQUEST_COOKIES}
+	_argsAndURI = []v1.Variable{v1.Variable_ARGS, v1.Variable_ARGS_NAMES, v1.Variable_REQUEST_COOKIES, v1.Variable_REQUEST_URI}
+	_decode     = []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_HTML_ENTITY_DECODE, v1.Transform_LOWERCASE}
+)
+
+var _builtinRules = []builtinRule{
+	{"protocol", 1, &v1.Rule{
+		Id:         "920270",
+		Message:    "Invalid character in request (null character)",
+		Variables:  []v1.Variable{v1.Variable_ARGS, v1.Variable_REQUEST_URI, v1.Variable_REQUEST_HEADERS},
+		Pattern:    `\x00`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE},
+	}},
+	{"protocol", 1, &v1.Rule{
+		Id:        "921110",
+		Message:   "HTTP request smuggling attack",
+		Variables: []v1.Variable{v1.Variable_ARGS, v1.Variable_ARGS_NAMES, v1.Variable_REQUEST_BODY},
+		Pattern:   `(?i)[\n\r]+(?:get|post|put|delete|head|options|connect|trace|patch)\s+\S+\s+http/\d`,
+	}},
+	{"protocol", 1, &v1.Rule{
+		Id:         "921130",
+		Message:    "HTTP response splitting attack",
+		Variables:  []v1.Variable{v1.Variable_ARGS, v1.Variable_ARGS_NAMES},
+		Pattern:    `(?i)[\n\r]+(?:content-(?:type|length)|set-cookie|location)\s*:`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE},
+	}},
+	{"lfi", 1, &v1.Rule{
+		Id:        "930100",
+		Message:   "Path traversal attack (/../)",
+		Variables: []v1.Variable{v1.Variable_ARGS, v1.Variable_REQUEST_URI, v1.Variable_REQUEST_HEADERS},
+		Pattern:   `(?:^|[\\/])\.\.(?:[\\/]|$)`,
+		// twice for the double encoded ones, e.g. %252e%252e%252f
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_URL_DECODE},
+	}},
+	{"lfi", 1, &v1.Rule{
+		Id:         "930120",
+		Message:    "OS file access attempt",
+		Variables:  _argsAndURI,
+		Pattern:    `(?:etc/(?:passwd|shadow|group|hosts)|proc/self/|win\.ini|boot\.ini|\.htaccess|\.git/)`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_LOWERCASE},
+	}},
+	{"rce", 1, &v1.Rule{
+		Id:         "932100",
+		Message:    "Remote command execution: Unix command injection",
+		Variables:  _args,
+		Pattern:    "(?:[;|&`\\n]|\\$\\()\\s*(?:cat|ls|id|whoami|uname|wget|curl|nc|ncat|bash|sh|chmod|rm|ping|nslookup)\\b",
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_COMPRESS_WHITESPACE},
+	}},
+	{"rce", 1, &v1.Rule{
+		Id:         "932160",
+		Message:    "Remote command execution: Unix shell code found",
+		Variables:  _args,
+		Pattern:    `(?:/bin/(?:ba|z|k|da)?sh\b|/usr/bin/(?:env|perl|python)|\bcmd(?:\.exe)?\s+/c\b|\bpowershell(?:\.exe)?\s+-)`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_LOWERCASE},
+	}},
+	{"xss", 1, &v1.Rule{
+		Id:         "941110",
+		Message:    "XSS filter: script tag vector",
+		Variables:  _args,
+		Pattern:    `<script[^>]*>`,
+		Transforms: _decode,
+	}},
+	{"xss", 1, &v1.Rule{
+		Id:         "941170",
+		Message:    "XSS filter: javascript URI vector",
+		Variables:  _args,
+		Pattern:    `(?:javascript|vbscript|livescript)\s*:`,
+		Transforms: _decode,
+	}},
+	{"xss", 2, &v1.Rule{
+		Id:         "941120",
+		Message:    "XSS filter: event handler vector",
+		Variables:  _args,
+		Pattern:    `[\s"'/]on[a-z]{3,}\s*=`,
+		Transforms: _decode,
+	}},
+	{"sqli", 1, &v1.Rule{
+		Id:         "942190",
+		Message:    "SQL injection: UNION SELECT attempt",
+		Variables:  _args,
+		Pattern:    `\bunion\b(?:\s|/\*.*?\*/)+(?:all\s+|distinct\s+)?\bselect\b`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_LOWERCASE},
+	}},
+	{"sqli", 1, &v1.Rule{
+		Id:         "942130",
+		Message:    "SQL injection: SQL tautology",
+		Variables:  _args,
+		Pattern:    `['"]\s*(?:or|and|xor|\|\||&&)\s+['"]?\w+['"]?\s*(?:=|like)\s*['"]?\w+`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_LOWERCASE},
+	}},
+	{"sqli", 1, &v1.Rule{
+		Id:         "942160",
+		Message:    "SQL injection: blind attempt of sleep or benchmark",
+		Variables:  _args,
+		Pattern:    `\b(?:sleep|benchmark|pg_sleep|waitfor\s+delay)\s*[('"]`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_LOWERCASE},
+	}},
+	{"sqli", 1, &v1.Rule{
+		Id:         "942140",
+		Message:    "SQL injection: common DB names",
+		Variables:  _args,
+		Pattern:    `\b(?:information_schema|mysql\.user|pg_catalog|sysobjects|sqlite_master)\b`,
+		Transforms: []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_LOWERCASE},
+	}},
+	{"sqli", 2, &v1.Rule{
+		Id:        "942440",
+		Message:   "SQL injection: SQL comment sequence",
+		Variables: _args,
+		Pattern:   `(?:/\*!?|\*/|--\s|#\s*$|;\s*--)`,
+	}},
+}
+
+// _ruleSets is the names of the built-in rule sets.
+var _ruleSets = map[string]bool{"protocol": true, "lfi": true, "rce": true, "xss": true, "sqli": true}
@@ -0,0 +1,67 @@
+package waf
+
+import (
+	"html"
+	"strings"
+
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/waf/v1"
+)
+
+func transform(value string, transforms []v1.Transform) string {
+	for _, t := range transforms {
+		switch t {
+		case v1.Transform_LOWERCASE:
+			value = strings.ToLower(value)
+		case v1.Transform_URL_DECODE:
+			value = urlDecode(value)
+		case v1.Transform_HTML_ENTITY_DECODE:
+			value = html.UnescapeString(value)
+		case v1.Transform_COMPRESS_WHITESPACE:
+			value = strings.Join(strings.Fields(value), " ")
+		case v1.Transform_REMOVE_NULLS:
+			value = strings.Replace(value, "\x00", "", -1)
+		}
+	}
+	return value
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// urlDecode decodes the value leniently, the invalid escapes are kept as is
+// unlike url.QueryUnescape which fails on them.
+func urlDecode(value string) string {
+	if !strings.ContainsAny(value, "%+") {
+		return value
+	}
+	b := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '+':
+			b = append(b, ' ')
+		case '%':
+			if i+2 < len(value) {
+				hi, ok1 := unhex(value[i+1])
+				lo, ok2 := unhex(value[i+2])
+				if ok1 && ok2 {
+					b = append(b, hi<<4|lo)
+					i += 2
+					continue
+				}
+			}
+			b = append(b, c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,353 @@
+package waf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/waf/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/flags"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	_defaultThreshold    = 5
+	_defaultMaxBodyBytes = 128 << 10
+	// the max length of the matched value logged in the audit events.
+	_maxLoggedValue = 64
+)
+
+var _severityScores = map[v1.Severity]int{
+	v1.Severity_CRITICAL: 5,
+	v1.Severity_ERROR:    4,
+	v1.Severity_WARNING:  3,
+	v1.Severity_NOTICE:   2,
+}
+
+var _metricWAFTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "go",
+	Subsystem: "gateway",
+	Name:      "requests_waf_total",
+	Help:      "The total number of the requests reaching the anomaly threshold of the WAF",
+}, []string{"protocol", "method", "path", "service", "basePath", "action"})
+
+func init() {
+	middleware.Register("waf", Middleware)
+	prometheus.MustRegister(_metricWAFTotal)
+}
+
+type rule struct {
+	id         string
+	message    string
+	variables  []v1.Variable
+	re         *regexp.Regexp
+	score      int
+	transforms []v1.Transform
+}
+
+func compile(r *v1.Rule) (*rule, error) {
+	if r.Id == "" {
+		return nil, fmt.Errorf("waf rule id is required")
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern of waf rule %s: %w", r.Id, err)
+	}
+	variables := r.Variables
+	if len(variables) == 0 {
+		variables = []v1.Variable{v1.Variable_ARGS}
+	}
+	return &rule{
+		id:         r.Id,
+		message:    r.Message,
+		variables:  variables,
+		re:         re,
+		score:      _severityScores[r.Severity],
+		transforms: r.Transforms,
+	}, nil
+}
+
+// match is the rule matched by the value of the variable.
+type match struct {
+	rule     *rule
+	variable string
+	value    string
+}
+
+func (m match) String() string {
+	value := m.value
+	if len(value) > _maxLoggedValue {
+		value = value[:_maxLoggedValue] + "..."
+	}
+	return fmt.Sprintf("%s %s=%q %s", m.rule.id, m.variable, value, m.rule.message)
+}
+
+// field is a value of the variable, the name is the key of the collection,
+// e.g. ARGS:id.
+type field struct {
+	name  string
+	value string
+}
+
+// transaction is the variables of the request inspected by the rules.
+type transaction struct {
+	fields map[v1.Variable][]field
+}
+
+func (tx *transaction) add(v v1.Variable, name, value string) {
+	tx.fields[v] = append(tx.fields[v], field{name: name, value: value})
+}
+
+func (tx *transaction) addArgs(values url.Values) {
+	for name, vs := range values {
+		tx.add(v1.Variable_ARGS_NAMES, "ARGS_NAMES", name)
+		for _, v := range vs {
+			tx.add(v1.Variable_ARGS, "ARGS:"+name, v)
+		}
+	}
+}
+
+// addJSON adds the leaves of the JSON as the arguments of the dotted names.
+func (tx *transaction) addJSON(prefix string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			name := k
+			if prefix != "" {
+				name = prefix + "." + k
+			}
+			tx.add(v1.Variable_ARGS_NAMES, "ARGS_NAMES", name)
+			tx.addJSON(name, child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			tx.addJSON(prefix, child)
+		}
+	case string:
+		tx.add(v1.Variable_ARGS, "ARGS:"+prefix, v)
+	}
+}
+
+func readBody(req *http.Request, maxBytes int64) ([]byte, error) {
+	if req.GetBody == nil || req.ContentLength == 0 {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(io.LimitReader(body, maxBytes))
+}
+
+func newTransaction(req *http.Request, maxBodyBytes int64) *transaction {
+	tx := &transaction{fields: make(map[v1.Variable][]field)}
+	tx.add(v1.Variable_REQUEST_METHOD, "REQUEST_METHOD", req.Method)
+	tx.add(v1.Variable_REQUEST_URI, "REQUEST_URI", req.URL.RequestURI())
+	tx.addArgs(req.URL.Query())
+	for name, values := range req.Header {
+		tx.add(v1.Variable_REQUEST_HEADERS_NAMES, "REQUEST_HEADERS_NAMES", name)
+		if name == "Cookie" {
+			continue
+		}
+		for _, v := range values {
+			tx.add(v1.Variable_REQUEST_HEADERS, "REQUEST_HEADERS:"+name, v)
+		}
+	}
+	for _, c := range req.Cookies() {
+		tx.add(v1.Variable_REQUEST_COOKIES, "REQUEST_COOKIES:"+c.Name, c.Value)
+	}
+	if maxBodyBytes < 0 {
+		return tx
+	}
+	body, err := readBody(req, maxBodyBytes)
+	if err != nil {
+		log.Errorf("Failed to read the request body of %s: %+v", req.URL.Path, err)
+		return tx
+	}
+	if len(body) == 0 {
+		return tx
+	}
+	tx.add(v1.Variable_REQUEST_BODY, "REQUEST_BODY", string(body))
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			tx.addArgs(values)
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			tx.addJSON("", v)
+		}
+	}
+	return tx
+}
+
+// evaluate returns the total score and the matches of the rules, each rule is
+// scored once at most.
+func evaluate(rules []*rule, tx *transaction) (int, []match) {
+	var (
+		score   int
+		matches []match
+	)
+	for _, r := range rules {
+	variables:
+		for _, v := range r.variables {
+			for _, f := range tx.fields[v] {
+				if r.re.MatchString(transform(f.value, r.transforms)) {
+					score += r.score
+					matches = append(matches, match{rule: r, variable: f.name, value: f.value})
+					break variables
+				}
+			}
+		}
+	}
+	return score, matches
+}
+
+func newResponse(statusCode int) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(&bytes.Buffer{}),
+	}, nil
+}
+
+func wafIncr(req *http.Request, action string) {
+	if labels, ok := middleware.MetricsLabelsFromContext(req.Context()); ok {
+		_metricWAFTotal.WithLabelValues(labels.Protocol(), labels.Method(), labels.Path(), labels.Service(), labels.BasePath(), action).Inc()
+	}
+}
+
+// audit logs the audit event of the request reaching the anomaly threshold.
+func audit(req *http.Request, route, action string, score int, matches []match) {
+	ids := make([]string, 0, len(matches))
+	details := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.rule.id)
+		details = append(details, m.String())
+	}
+	clientIP := ""
+	if ip := clientip.FromRequest(req); ip != nil {
+		clientIP = ip.String()
+	}
+	log.Context(req.Context()).Log(log.LevelWarn,
+		"source", "waf",
+		"action", action,
+		"host", req.Host,
+		"method", req.Method,
+		"path", req.URL.Path,
+		"route", route,
+		"client_ip", clientIP,
+		"score", score,
+		"rules", strings.Join(ids, ","),
+		"matches", strings.Join(details, "; "),
+	)
+}
+
+func compileRules(options *v1.WAF) ([]*rule, error) {
+	sets := make(map[string]bool, len(options.RuleSets))
+	for _, name := range options.RuleSets {
+		if !_ruleSets[name] {
+			return nil, fmt.Errorf("unknown waf rule set: %s", name)
+		}
+		sets[name] = true
+	}
+	level := options.ParanoiaLevel
+	if level <= 0 {
+		level = 1
+	}
+	disabled := make(map[string]bool, len(options.DisabledRules))
+	for _, id := range options.DisabledRules {
+		disabled[id] = true
+	}
+	var rules []*rule
+	for _, b := range _builtinRules {
+		if (len(sets) > 0 && !sets[b.set]) || b.level > level || disabled[b.rule.Id] {
+			continue
+		}
+		r, err := compile(b.rule)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	for _, c := range options.Rules {
+		if disabled[c.Id] {
+			continue
+		}
+		r, err := compile(c)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Middleware inspects the requests by the regular expression rules and blocks
+// the ones reaching the anomaly threshold.
+func Middleware(c *config.Middleware) (middleware.Middleware, error) {
+	options := &v1.WAF{}
+	if c.Options != nil {
+		if err := anypb.UnmarshalTo(c.Options, options, proto.UnmarshalOptions{Merge: true}); err != nil {
+			return nil, err
+		}
+	}
+	rules, err := compileRules(options)
+	if err != nil {
+		return nil, err
+	}
+	threshold := _defaultThreshold
+	if options.AnomalyThreshold > 0 {
+		threshold = int(options.AnomalyThreshold)
+	}
+	denyStatus := http.StatusForbidden
+	if options.DenyStatus != 0 {
+		denyStatus = int(options.DenyStatus)
+	}
+	maxBodyBytes := int64(_defaultMaxBodyBytes)
+	if options.MaxBodyBytes != 0 {
+		maxBodyBytes = options.MaxBodyBytes
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			detectionOnly := options.DetectionOnly
+			route := ""
+			if e, ok := middleware.EndpointFromContext(req.Context()); ok {
+				route = e.Path
+				prefix := "waf." + e.Path + "."
+				if flags.Bool(prefix+"disabled", false) {
+					return next.RoundTrip(req)
+				}
+				detectionOnly = flags.Bool(prefix+"detection_only", detectionOnly)
+			}
+			score, matches := evaluate(rules, newTransaction(req, maxBodyBytes))
+			if score < threshold {
+				return next.RoundTrip(req)
+			}
+			if detectionOnly {
+				wafIncr(req, "detected")
+				audit(req, route, "detected", score, matches)
+				return next.RoundTrip(req)
+			}
+			wafIncr(req, "blocked")
+			audit(req, route, "blocked", score, matches)
+			return newResponse(denyStatus)
+		})
+	}, nil
+}
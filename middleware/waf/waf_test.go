@@ -0,0 +1,167 @@
+package waf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	v1 "github.com/go-kratos/gateway/api/gateway/middleware/waf/v1"
+	"github.com/go-kratos/gateway/flags"
+	"github.com/go-kratos/gateway/middleware"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newWAF(t *testing.T, options *v1.WAF) http.RoundTripper {
+	any, err := anypb.New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Middleware(&config.Middleware{Name: "waf", Options: any})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m(middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}))
+}
+
+func newRequest(method, target, contentType, body string) *http.Request {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != "" {
+		req.Header.Set("Content-Type", contentType)
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(body)), nil
+		}
+	}
+	reqOpt := middleware.NewRequestOptions(&config.Endpoint{Path: "/api/*"})
+	return req.WithContext(middleware.NewRequestContext(req.Context(), reqOpt))
+}
+
+func TestWAF(t *testing.T) {
+	next := newWAF(t, &v1.WAF{})
+	testCases := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"plain", newRequest("GET", "/api/users?name=alice&sort=-created", "", ""), http.StatusOK},
+		{"plain json", newRequest("POST", "/api/users", "application/json", `{"name":"alice","tags":["admin"]}`), http.StatusOK},
+		{"union select", newRequest("GET", "/api/users?id=1%20UNION%20SELECT%20password%20FROM%20users", "", ""), http.StatusForbidden},
+		{"tautology", newRequest("GET", "/api/users?id=1'%20or%20'1'='1", "", ""), http.StatusForbidden},
+		{"script tag", newRequest("GET", "/api/search?q=%3Cscript%3Ealert(1)%3C/script%3E", "", ""), http.StatusForbidden},
+		{"entity encoded", newRequest("POST", "/api/comments", "application/x-www-form-urlencoded", "text=%26lt%3Bscript%26gt%3B"), http.StatusForbidden},
+		{"path traversal", newRequest("GET", "/api/files?name=..%252f..%252fetc%252fpasswd", "", ""), http.StatusForbidden},
+		{"command injection", newRequest("POST", "/api/ping", "application/json", `{"host":{"name":"127.0.0.1; cat /etc/hosts"}}`), http.StatusForbidden},
+		{"cookie", newRequest("GET", "/api/users", "", ""), http.StatusForbidden},
+	}
+	testCases[len(testCases)-1].req.Header.Set("Cookie", "session=javascript:alert(1)")
+	for _, tc := range testCases {
+		resp, err := next.RoundTrip(tc.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: want %d but got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestWAFOptions(t *testing.T) {
+	attack := "/api/users?id=1%20UNION%20SELECT%201"
+	do := func(next http.RoundTripper, target string) int {
+		resp, err := next.RoundTrip(newRequest("GET", target, "", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	if got := do(newWAF(t, &v1.WAF{DetectionOnly: true}), attack); got != http.StatusOK {
+		t.Fatalf("want the detection only passed but got %d", got)
+	}
+	if got := do(newWAF(t, &v1.WAF{RuleSets: []string{"xss"}}), attack); got != http.StatusOK {
+		t.Fatalf("want the sqli rules disabled but got %d", got)
+	}
+	if got := do(newWAF(t, &v1.WAF{DisabledRules: []string{"942190"}}), attack); got != http.StatusOK {
+		t.Fatalf("want the rule disabled but got %d", got)
+	}
+	if got := do(newWAF(t, &v1.WAF{AnomalyThreshold: 10}), attack); got != http.StatusOK {
+		t.Fatalf("want the score under the threshold but got %d", got)
+	}
+	comment := "/api/users?id=1%20--%20x"
+	if got := do(newWAF(t, &v1.WAF{}), comment); got != http.StatusOK {
+		t.Fatalf("want the paranoia level 2 rules disabled but got %d", got)
+	}
+	if got := do(newWAF(t, &v1.WAF{ParanoiaLevel: 2, DenyStatus: http.StatusNotAcceptable}), comment); got != http.StatusNotAcceptable {
+		t.Fatalf("want the paranoia level 2 rules enabled but got %d", got)
+	}
+	custom := newWAF(t, &v1.WAF{
+		RuleSets: []string{"protocol"},
+		Rules: []*v1.Rule{{
+			Id:         "100001",
+			Message:    "Scanner detected",
+			Variables:  []v1.Variable{v1.Variable_REQUEST_HEADERS},
+			Pattern:    `(?:sqlmap|nikto)`,
+			Severity:   v1.Severity_WARNING,
+			Transforms: []v1.Transform{v1.Transform_LOWERCASE},
+		}},
+		AnomalyThreshold: 3,
+	})
+	req := newRequest("GET", "/api/users", "", "")
+	req.Header.Set("User-Agent", "SQLMap/1.5")
+	if resp, err := custom.RoundTrip(req); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("want the custom rule matched but got %v %v", resp, err)
+	}
+
+	next := newWAF(t, &v1.WAF{})
+	flags.Default().Set("waf./api/*.detection_only", "true")
+	if got := do(next, attack); got != http.StatusOK {
+		t.Fatalf("want the detection only by the flag but got %d", got)
+	}
+	flags.Default().Delete("waf./api/*.detection_only")
+	flags.Default().Set("waf./api/*.disabled", "true")
+	defer flags.Default().Delete("waf./api/*.disabled")
+	if got := do(next, attack); got != http.StatusOK {
+		t.Fatalf("want the waf disabled by the flag but got %d", got)
+	}
+
+	for _, options := range []*v1.WAF{
+		{RuleSets: []string{"unknown"}},
+		{Rules: []*v1.Rule{{Pattern: "a"}}},
+		{Rules: []*v1.Rule{{Id: "1", Pattern: "("}}},
+	} {
+		any, _ := anypb.New(options)
+		if _, err := Middleware(&config.Middleware{Name: "waf", Options: any}); err == nil {
+			t.Errorf("want error of %v", options)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	testCases := []struct {
+		in         string
+		transforms []v1.Transform
+		want       string
+	}{
+		{"%3Cscript%3E+x", []v1.Transform{v1.Transform_URL_DECODE}, "<script> x"},
+		{"100%", []v1.Transform{v1.Transform_URL_DECODE}, "100%"},
+		{"%zz%4", []v1.Transform{v1.Transform_URL_DECODE}, "%zz%4"},
+		{"%252e", []v1.Transform{v1.Transform_URL_DECODE, v1.Transform_URL_DECODE}, "."},
+		{"&lt;A&gt;", []v1.Transform{v1.Transform_HTML_ENTITY_DECODE, v1.Transform_LOWERCASE}, "<a>"},
+		{" a \t\n b ", []v1.Transform{v1.Transform_COMPRESS_WHITESPACE}, "a b"},
+		{"a\x00b", []v1.Transform{v1.Transform_REMOVE_NULLS}, "ab"},
+	}
+	for _, tc := range testCases {
+		if got := transform(tc.in, tc.transforms); got != tc.want {
+			t.Errorf("%q: want %q but got %q", tc.in, tc.want, got)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/go-kratos/gateway/audit"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/gorilla/mux"
 )
@@ -30,10 +31,18 @@ func Register(name string, debuggable Debuggable) {
 	globalService.Register(name, debuggable)
 }
 
+// Handle registers the handler for the exact debug path.
+func Handle(path string, handler http.Handler) {
+	globalService.Handle(path, handler)
+}
+
+// MashupWithDebugHandler serves the debug paths by the debug handlers, the
+// mutations of the debug handlers are recorded in the audit events.
 func MashupWithDebugHandler(origin http.Handler) http.Handler {
+	debugHandler := audit.Handler("debug", globalService)
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if strings.HasPrefix(req.URL.Path, _debugPrefix) {
-			globalService.ServeHTTP(w, req)
+			debugHandler.ServeHTTP(w, req)
 			return
 		}
 		origin.ServeHTTP(w, req)
@@ -64,3 +73,8 @@ func (d *debugService) Register(name string, debuggable Debuggable) {
 	d.mux.PathPrefix(path).Handler(debuggable.DebugHandler())
 	log.Infof("register debug: %s", path)
 }
+
+func (d *debugService) Handle(path string, handler http.Handler) {
+	d.handlers[path] = handler.ServeHTTP
+	log.Infof("register debug: %s", path)
+}
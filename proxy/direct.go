@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+)
+
+// buildDirectResponse returns the handler responding the fixed response of the endpoint without proxying.
+func buildDirectResponse(e *config.Endpoint) (http.Handler, error) {
+	resp := e.DirectResponse
+	code := int(resp.StatusCode)
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if code < 100 || code > 599 {
+		return nil, fmt.Errorf("invalid direct response status code: %d", code)
+	}
+	body := []byte(resp.GetBody())
+	if file := resp.GetFile(); file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read direct response file: %w", err)
+		}
+		body = b
+	}
+	labels := middleware.NewMetricsLabels(e)
+	respHeaders := resp.Headers
+	if resp.Template && len(body) > 0 && contentTypeOf(respHeaders) == "" {
+		// the Content-Type of the template is detected before the values are
+		// expanded, so that the values can't change the detected type.
+		respHeaders = make(map[string]string, len(resp.Headers)+1)
+		for k, v := range resp.Headers {
+			respHeaders[k] = v
+		}
+		respHeaders["Content-Type"] = http.DetectContentType(body)
+	}
+	escape := placeholderEscaper(respHeaders)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		headers := w.Header()
+		for k, v := range respHeaders {
+			headers.Set(k, v)
+		}
+		body := body
+		if resp.Template {
+			body = []byte(middleware.ExpandPlaceholders(string(body), map[string]string{
+				"method": escape(req.Method),
+				"host":   escape(req.Host),
+				"path":   escape(req.URL.Path),
+				"query":  escape(req.URL.RawQuery),
+			}))
+		}
+		headers.Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(code)
+		if req.Method != http.MethodHead {
+			sent, _ := w.Write(body)
+			sentBytesAdd(labels, int64(sent))
+		}
+		requestsTotalIncr(labels, code)
+	}), nil
+}
+
+// placeholderEscaper returns the escaper of the placeholder values by the
+// Content-Type of the direct response.
+func placeholderEscaper(headers map[string]string) func(string) string {
+	contentType := strings.ToLower(contentTypeOf(headers))
+	switch {
+	case strings.Contains(contentType, "html"):
+		return html.EscapeString
+	case strings.Contains(contentType, "json"):
+		return func(s string) string {
+			b, _ := json.Marshal(s)
+			return string(b[1 : len(b)-1])
+		}
+	}
+	return func(s string) string { return s }
+}
+
+func contentTypeOf(headers map[string]string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return v
+		}
+	}
+	return ""
+}
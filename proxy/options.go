@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/gateway/router"
+)
+
+// _allowedMethods is the methods of the Allow header if all the methods or
+// the methods except the excluded ones are matched by the endpoint.
+var _allowedMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// allowHeader returns the Allow header of the endpoint method.
+func allowHeader(method string) (string, error) {
+	methods, err := router.ParseMethods(method)
+	if err != nil {
+		return "", err
+	}
+	var allowed []string
+	if methods.Any() || methods.Excluded() {
+		for _, m := range _allowedMethods {
+			if methods.Match(m) {
+				allowed = append(allowed, m)
+			}
+		}
+	} else {
+		allowed = append(allowed, methods.List()...)
+		options := false
+		for _, m := range allowed {
+			options = options || m == http.MethodOptions
+		}
+		if !options {
+			allowed = append(allowed, http.MethodOptions)
+		}
+	}
+	return strings.Join(allowed, ", "), nil
+}
+
+// autoOptions responds the OPTIONS requests with the Allow header instead of
+// sending them to the backends.
+func autoOptions(allow string, next http.RoundTripper) http.RoundTripper {
+	return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodOptions {
+			return next.RoundTrip(req)
+		}
+		return &http.Response{
+			Status:     "204 No Content",
+			StatusCode: http.StatusNoContent,
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Header:     http.Header{"Allow": {allow}},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	})
+}
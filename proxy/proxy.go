@@ -12,20 +12,27 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
 	"github.com/go-kratos/gateway/client"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/flags"
 	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/gateway/middleware/tracing"
 	"github.com/go-kratos/gateway/router"
+	"github.com/go-kratos/gateway/router/matcher"
 	"github.com/go-kratos/gateway/router/mux"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/selector"
 	"github.com/go-kratos/kratos/v2/transport/http/status"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -87,9 +94,25 @@ func setXFFHeader(req *http.Request) {
 	}
 }
 
+// errEndpointDisabled is returned if the endpoint is disabled by the runtime flag.
+var errEndpointDisabled = errors.New("endpoint is disabled")
+
+// endpointDisabledFlag returns the runtime flag key of the endpoint kill switch,
+// e.g. `route./helloworld/*.disabled: true`.
+func endpointDisabledFlag(e *config.Endpoint) string {
+	return "route." + e.Path + ".disabled"
+}
+
 func writeError(w http.ResponseWriter, r *http.Request, err error, labels middleware.MetricsLabels) {
-	var statusCode int
+	var (
+		statusCode  int
+		statusError *middleware.StatusError
+	)
 	switch {
+	case errors.As(err, &statusError):
+		statusCode = statusError.StatusCode
+	case errors.Is(err, errEndpointDisabled):
+		statusCode = 503
 	case errors.Is(err, context.Canceled):
 		statusCode = 499
 	case errors.Is(err, context.DeadlineExceeded):
@@ -149,6 +172,56 @@ type Proxy struct {
 	router            atomic.Value
 	clientFactory     client.Factory
 	middlewareFactory middleware.Factory
+
+	mu          sync.Mutex
+	endpoints   map[string]*builtEndpoint
+	middlewares []*config.Middleware
+	// routes is the endpoints in the order of the config.
+	routes []*config.Endpoint
+	// sniRouting is 1 if the TLS requests are routed by the server name.
+	sniRouting int32
+}
+
+// builtEndpoint is the handler built for an endpoint, it's reused by the
+// next update if neither the endpoint nor the global middlewares are changed.
+type builtEndpoint struct {
+	endpoint *config.Endpoint
+	handler  http.Handler
+	closer   io.Closer
+}
+
+func endpointKey(e *config.Endpoint) string {
+	key := e.Host + " " + strings.ToUpper(e.Method) + " " + e.Path
+	if m := matcher.Key(e); m != "" {
+		key += " " + m
+	}
+	return key
+}
+
+// _fallbackKey is the key of the fallback endpoint, it's not a route key.
+const _fallbackKey = "fallback"
+
+// fallbackEndpoint returns the fallback endpoint serving the unmatched
+// requests, the path is /* if not set for the metrics and the redirects.
+func fallbackEndpoint(e *config.Endpoint) *config.Endpoint {
+	if e.Path != "" {
+		return e
+	}
+	e = proto.Clone(e).(*config.Endpoint)
+	e.Path = "/*"
+	return e
+}
+
+func equalMiddlewares(a, b []*config.Middleware) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 // New is new a gateway proxy.
@@ -161,7 +234,10 @@ func New(clientFactory client.Factory, middlewareFactory middleware.Factory) (*P
 	return p, nil
 }
 
-func (p *Proxy) buildMiddleware(ms []*config.Middleware, next http.RoundTripper) (http.RoundTripper, error) {
+// buildMiddleware returns the chain of the middlewares, and the request body
+// filters of them in the order of the chain.
+func (p *Proxy) buildMiddleware(ms []*config.Middleware, next http.RoundTripper) (http.RoundTripper, []middleware.RequestBodyFilter, error) {
+	var filters []middleware.RequestBodyFilter
 	for i := len(ms) - 1; i >= 0; i-- {
 		m, err := p.middlewareFactory(ms[i])
 		if err != nil {
@@ -169,11 +245,40 @@ func (p *Proxy) buildMiddleware(ms []*config.Middleware, next http.RoundTripper)
 				log.Errorf("Skip does not exist middleware: %s", ms[i].Name)
 				continue
 			}
-			return nil, err
+			return nil, nil, err
 		}
 		next = m(next)
+		if filter, ok := next.(middleware.RequestBodyFilter); ok {
+			filters = append([]middleware.RequestBodyFilter{filter}, filters...)
+		}
+	}
+	return next, filters, nil
+}
+
+// stageOrder is the order of the middleware stages in the chain.
+var stageOrder = map[config.Middleware_Stage]int{
+	config.Middleware_PRE:     0,
+	config.Middleware_AUTH:    1,
+	config.Middleware_DEFAULT: 2,
+	config.Middleware_POST:    3,
+}
+
+// middlewareChain returns the middlewares of the endpoint in the order of the
+// chain, the endpoint middlewares are appended to the global ones unless
+// they're overridden by the endpoint.
+func middlewareChain(e *config.Endpoint, ms []*config.Middleware) []*config.Middleware {
+	chain := make([]*config.Middleware, 0, len(ms)+len(e.Middlewares))
+	if !e.OverrideMiddlewares {
+		chain = append(chain, ms...)
 	}
-	return next, nil
+	chain = append(chain, e.Middlewares...)
+	sort.SliceStable(chain, func(i, j int) bool {
+		if si, sj := stageOrder[chain[i].Stage], stageOrder[chain[j].Stage]; si != sj {
+			return si < sj
+		}
+		return chain[i].Priority > chain[j].Priority
+	})
+	return chain
 }
 
 func splitRetryMetricsHandler(e *config.Endpoint) (func(int), func(int, error)) {
@@ -196,30 +301,73 @@ func splitRetryMetricsHandler(e *config.Endpoint) (func(int), func(int, error))
 	return success, failed
 }
 
-func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http.Handler, error) {
-	tripper, err := p.clientFactory(e)
-	if err != nil {
-		return nil, err
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// closerOf returns the closer of the client to release its resources (e.g. the
+// discovery watchers), a nop closer is returned if it's not closable.
+func closerOf(client http.RoundTripper) io.Closer {
+	if closer, ok := client.(io.Closer); ok {
+		return closer
+	}
+	return nopCloser{}
+}
+
+func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (_ http.Handler, _ io.Closer, retErr error) {
+	if e.Redirect != nil {
+		handler, err := buildRedirect(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		return handler, nopCloser{}, nil
+	}
+	if e.DirectResponse != nil {
+		handler, err := buildDirectResponse(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		return handler, nopCloser{}, nil
 	}
-	tripper, err = p.buildMiddleware(e.Middlewares, tripper)
+	client, err := p.clientFactory(e)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	tripper, err = p.buildMiddleware(ms, tripper)
+	closer := closerOf(client)
+	defer func() {
+		if retErr != nil {
+			closer.Close()
+		}
+	}()
+	var next http.RoundTripper = client
+	if e.AutoOptions {
+		allow, err := allowHeader(e.Method)
+		if err != nil {
+			return nil, nil, err
+		}
+		next = autoOptions(allow, client)
+	}
+	tripper, bodyFilters, err := p.buildMiddleware(middlewareChain(e, ms), next)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	retryStrategy, err := prepareRetryStrategy(e)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	labels := middleware.NewMetricsLabels(e)
 	markSuccess, markFailed := splitRetryMetricsHandler(e)
+	disabledFlag := endpointDisabledFlag(e)
 	return http.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if flags.Bool(disabledFlag, false) {
+			writeError(w, req, errEndpointDisabled, labels)
+			return
+		}
 		startTime := time.Now()
 		setXFFHeader(req)
 
 		reqOpts := middleware.NewRequestOptions(e)
+		reqOpts.PathVars = mux.Vars(req)
 		ctx := middleware.NewRequestContext(req.Context(), reqOpts)
 		ctx, cancel := context.WithTimeout(ctx, retryStrategy.timeout)
 		defer cancel()
@@ -227,7 +375,18 @@ func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http
 			requestsDurationObserve(labels, time.Since(startTime).Seconds())
 		}()
 
-		body, err := io.ReadAll(req.Body)
+		var err error
+		reqBody := req.Body
+		for _, filter := range bodyFilters {
+			if reqBody, err = filter.FilterRequestBody(req, reqBody); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			writeError(w, req, err, labels)
+			return
+		}
+		body, err := io.ReadAll(reqBody)
 		if err != nil {
 			writeError(w, req, err, labels)
 			return
@@ -238,6 +397,7 @@ func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http
 			return ioutil.NopCloser(reader), nil
 		}
 
+		defer retryStrategy.budget.start()()
 		var resp *http.Response
 		for i := 0; i < retryStrategy.attempts; i++ {
 			if (i + 1) >= retryStrategy.attempts {
@@ -245,25 +405,57 @@ func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http
 			}
 			// canceled or deadline exceeded
 			if err = ctx.Err(); err != nil {
+				if i > 0 {
+					retryStrategy.budget.release()
+				}
 				markFailed(i, err)
 				break
 			}
 			tryCtx, cancel := context.WithTimeout(ctx, retryStrategy.perTryTimeout)
 			defer cancel()
+			tryCtx, sent := withSentTrace(tryCtx)
 			reader := bytes.NewReader(body)
 			req.Body = ioutil.NopCloser(reader)
-			resp, err = tripper.RoundTrip(req.Clone(tryCtx))
+			resp, err = roundTripWithHeaderTimeout(tripper, req.Clone(tryCtx), cancel, retryStrategy.responseHeaderTimeout)
+			if i > 0 {
+				retryStrategy.budget.release()
+			}
+			var declined bool
 			if err != nil {
 				markFailed(i, err)
 				log.Errorf("Attempt at [%d/%d], failed to handle request: %s: %+v", i+1, retryStrategy.attempts, req.URL.String(), err)
-				continue
+			} else {
+				var retry bool
+				if retry, declined = retryStrategy.judgeResponse(resp); !retry {
+					reqOpts.LastAttempt = true
+					markSuccess(i)
+					break
+				}
+				markFailed(i, errors.New("assertion failed"))
 			}
-			if !judgeRetryRequired(retryStrategy.conditions, resp) {
-				reqOpts.LastAttempt = true
-				markSuccess(i)
+			if i+1 >= retryStrategy.attempts {
+				break
+			}
+			// the response of the last attempt is sent to the client if the
+			// request is not retried.
+			if !retryStrategy.safeToRetry(req.Method, sent(), declined) {
 				break
 			}
-			markFailed(i, errors.New("assertion failed"))
+			delay, ok := retryStrategy.retryDelay(ctx, resp, i+1)
+			if !ok || !retryStrategy.budget.acquire() {
+				break
+			}
+			if err == nil && resp.Body != nil {
+				resp.Body.Close()
+			}
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+				}
+				timer.Stop()
+			}
 			// continue the retry loop
 		}
 		if err != nil {
@@ -271,6 +463,9 @@ func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http
 			return
 		}
 
+		if retryStrategy.idleTimeout > 0 && resp.Body != nil {
+			resp.Body = newIdleTimeoutBody(resp.Body, cancel, retryStrategy.idleTimeout)
+		}
 		headers := w.Header()
 		for k, v := range resp.Header {
 			headers[k] = v
@@ -282,7 +477,11 @@ func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http
 				return true
 			}
 			defer resp.Body.Close()
-			sent, err := io.Copy(w, resp.Body)
+			var dst io.Writer = w
+			if f, ok := w.(http.Flusher); ok && isStreaming(resp) {
+				dst = &flushWriter{w: w, flusher: f}
+			}
+			sent, err := io.Copy(dst, resp.Body)
 			if err != nil {
 				reqOpts.DoneFunc(ctx, selector.DoneInfo{Err: err})
 				sentBytesAdd(labels, sent)
@@ -299,7 +498,26 @@ func (p *Proxy) buildEndpoint(e *config.Endpoint, ms []*config.Middleware) (http
 		}
 		doCopyBody()
 		requestsTotalIncr(labels, resp.StatusCode)
-	})), nil
+	})), closer, nil
+}
+
+// isStreaming reports whether the response is streamed, e.g. the server-sent
+// events or the responses of unknown length, they're flushed on every write.
+func isStreaming(resp *http.Response) bool {
+	return resp.ContentLength == -1 || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.flusher.Flush()
+	}
+	return n, err
 }
 
 func receivedBytesAdd(labels middleware.MetricsLabels, received int64) {
@@ -327,19 +545,90 @@ func retryStateIncr(labels middleware.MetricsLabels, success bool) {
 }
 
 // Update updates service endpoint.
+// Only the changed endpoints are rebuilt, the clients of the unchanged ones
+// are kept to leave their connection pools intact.
 func (p *Proxy) Update(c *config.Gateway) error {
-	router := mux.NewRouter(http.HandlerFunc(notFoundHandler), http.HandlerFunc(methodNotAllowedHandler))
-	for _, e := range c.Endpoints {
-		handler, err := p.buildEndpoint(e, c.Middlewares)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rebuildAll := !equalMiddlewares(p.middlewares, c.Middlewares)
+	endpoints := make(map[string]*builtEndpoint, len(c.Endpoints))
+	built := make([]io.Closer, 0, len(c.Endpoints))
+	closeBuilt := func() {
+		for _, closer := range built {
+			closer.Close()
+		}
+	}
+	var reused int
+	build := func(key string, e *config.Endpoint) (*builtEndpoint, error) {
+		b, ok := p.endpoints[key]
+		if ok && !rebuildAll && proto.Equal(b.endpoint, e) {
+			reused++
+			return b, nil
+		}
+		handler, closer, err := p.buildEndpoint(e, c.Middlewares)
 		if err != nil {
+			return nil, err
+		}
+		built = append(built, closer)
+		log.Infof("build endpoint: [%s] %s %s", e.Protocol, e.Method, e.Path)
+		return &builtEndpoint{endpoint: e, handler: handler, closer: closer}, nil
+	}
+	var notFound http.Handler = http.HandlerFunc(notFoundHandler)
+	if c.Fallback != nil {
+		b, err := build(_fallbackKey, fallbackEndpoint(c.Fallback))
+		if err != nil {
+			closeBuilt()
 			return err
 		}
-		if err = router.Handle(e.Path, e.Method, e.Host, handler); err != nil {
+		endpoints[_fallbackKey] = b
+		notFound = b.handler
+	}
+	router := mux.NewRouter(notFound, http.HandlerFunc(methodNotAllowedHandler))
+	for _, e := range matcher.Sort(c.Endpoints) {
+		key := endpointKey(e)
+		b, err := build(key, e)
+		if err != nil {
+			// the clients built for the failed config are released,
+			// the current router keeps serving.
+			closeBuilt()
 			return err
 		}
-		log.Infof("build endpoint: [%s] %s %s", e.Protocol, e.Method, e.Path)
+		matchers, err := matcher.New(e)
+		if err != nil {
+			closeBuilt()
+			return err
+		}
+		if err := router.Handle(e.Path, e.Method, e.Host, b.handler, matchers...); err != nil {
+			closeBuilt()
+			return err
+		}
+		endpoints[key] = b
+	}
+	if err := clientip.SetTrustedProxies(c.TrustedProxies); err != nil {
+		closeBuilt()
+		return err
+	}
+	if err := tracing.SetProvider(c.Tracing); err != nil {
+		closeBuilt()
+		return err
+	}
+	middleware.SetConsumers(c.Consumers)
+	var sniRouting int32
+	if c.Tls.GetSniRouting() {
+		sniRouting = 1
 	}
+	atomic.StoreInt32(&p.sniRouting, sniRouting)
 	p.router.Store(router)
+	// release the clients of the removed and the rebuilt endpoints.
+	for key, b := range p.endpoints {
+		if endpoints[key] != b {
+			b.closer.Close()
+		}
+	}
+	p.endpoints = endpoints
+	p.middlewares = c.Middlewares
+	p.routes = c.Endpoints
+	log.Infof("proxy updated, endpoints rebuilt: %d, reused: %d", len(built), reused)
 	return nil
 }
 
@@ -353,9 +642,26 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			fmt.Fprintf(os.Stderr, "panic recovered: %s\n", buf[:n])
 		}
 	}()
+	if atomic.LoadInt32(&p.sniRouting) == 1 && misdirected(req) {
+		w.WriteHeader(http.StatusMisdirectedRequest)
+		return
+	}
 	p.router.Load().(router.Router).ServeHTTP(w, req)
 }
 
+// misdirected reports whether the host of the TLS request is not the server
+// name of the handshake, the request is not routed by another host's table.
+func misdirected(req *http.Request) bool {
+	if req.TLS == nil || req.TLS.ServerName == "" {
+		return false
+	}
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return !strings.EqualFold(strings.TrimSuffix(host, "."), strings.TrimSuffix(req.TLS.ServerName, "."))
+}
+
 // DebugHandler implemented debug handler.
 func (p *Proxy) DebugHandler() http.Handler {
 	debugMux := http.NewServeMux()
@@ -368,5 +674,20 @@ func (p *Proxy) DebugHandler() http.Handler {
 		rw.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(rw).Encode(inspect)
 	})
+	debugMux.HandleFunc("/debug/proxy/router/shadowed", func(rw http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		endpoints := p.routes
+		p.mu.Unlock()
+		type shadowed struct {
+			Endpoint   string `json:"endpoint"`
+			ShadowedBy string `json:"shadowed_by"`
+		}
+		out := []shadowed{}
+		for _, s := range matcher.Shadowed(endpoints) {
+			out = append(out, shadowed{Endpoint: endpointKey(s.Endpoint), ShadowedBy: endpointKey(s.By)})
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(out)
+	})
 	return debugMux
 }
@@ -2,14 +2,28 @@ package proxy
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	bodylimitv1 "github.com/go-kratos/gateway/api/gateway/middleware/bodylimit/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/flags"
 	"github.com/go-kratos/gateway/middleware"
+	_ "github.com/go-kratos/gateway/middleware/bodylimit"
 	"github.com/go-kratos/gateway/middleware/logging"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type responseWriter struct {
@@ -124,3 +138,828 @@ func TestProxy(t *testing.T) {
 		}
 	}
 }
+
+type countingClient struct {
+	path   string
+	closed int
+}
+
+func (c *countingClient) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func (c *countingClient) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestProxyPartialUpdate(t *testing.T) {
+	var clients []*countingClient
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		c := &countingClient{path: e.Path}
+		clients = append(clients, c)
+		return c, nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/foo", Method: "GET"},
+			{Protocol: config.Protocol_HTTP, Path: "/bar", Method: "GET"},
+			{Protocol: config.Protocol_HTTP, Path: "/baz", Method: "GET"},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 3 {
+		t.Fatalf("want 3 clients but got %d", len(clients))
+	}
+	foo, bar, baz := clients[0], clients[1], clients[2]
+
+	// /foo is unchanged, /bar is changed and /baz is removed.
+	c = &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/foo", Method: "GET"},
+			{Protocol: config.Protocol_HTTP, Path: "/bar", Method: "GET", Description: "changed"},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 4 || clients[3].path != "/bar" {
+		t.Fatalf("want only /bar to be rebuilt but got %d clients", len(clients))
+	}
+	if foo.closed != 0 {
+		t.Fatalf("the unchanged endpoint client must not be closed")
+	}
+	if bar.closed != 1 || baz.closed != 1 {
+		t.Fatalf("the changed and removed endpoint clients must be closed: %d %d", bar.closed, baz.closed)
+	}
+	for _, path := range []string{"/foo", "/bar"} {
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.statusCode != http.StatusOK {
+			t.Fatalf("%s: want ok but got: %d", path, w.statusCode)
+		}
+	}
+
+	// the global middlewares are changed, all endpoints are rebuilt.
+	c = &config.Gateway{
+		Middlewares: []*config.Middleware{{Name: "logging"}},
+		Endpoints:   c.Endpoints,
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 6 || foo.closed != 1 || clients[3].closed != 1 {
+		t.Fatalf("want all endpoints to be rebuilt but got %d clients", len(clients))
+	}
+}
+
+func TestProxyEndpointDisabled(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return &countingClient{path: e.Path}, nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/foo", Method: "GET"},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	key := endpointDisabledFlag(c.Endpoints[0])
+	flags.Default().Set(key, "true")
+	defer flags.Default().Delete(key)
+	w := newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/foo", nil))
+	if w.statusCode != http.StatusServiceUnavailable {
+		t.Fatalf("want 503 but got: %d", w.statusCode)
+	}
+
+	flags.Default().Delete(key)
+	w = newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/foo", nil))
+	if w.statusCode != http.StatusOK {
+		t.Fatalf("want ok but got: %d", w.statusCode)
+	}
+}
+
+type targetClient string
+
+func (c targetClient) RoundTrip(*http.Request) (*http.Response, error) {
+	header := http.Header{"X-Target": {string(c)}}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody}, nil
+}
+
+func (c targetClient) Close() error { return nil }
+
+func TestProxyRouteMatch(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return targetClient(e.Backends[0].Target), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{
+				Protocol: config.Protocol_HTTP, Path: "/api/*", Method: "GET",
+				Headers:  []*config.HeaderMatcher{{Name: "X-Api-Version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}}},
+				Backends: []*config.Backend{{Target: "v2"}},
+			},
+			{
+				Protocol: config.Protocol_HTTP, Path: "/api/*", Method: "GET",
+				Queries:  []*config.QueryMatcher{{Name: "beta", Match: &config.QueryMatcher_Exact{Exact: "true"}}},
+				Backends: []*config.Backend{{Target: "beta"}},
+			},
+			{Protocol: config.Protocol_HTTP, Path: "/api/*", Method: "GET", Backends: []*config.Backend{{Target: "v1"}}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	for version, want := range map[string]string{"": "v1", "v1": "v1", "v2": "v2"} {
+		req := httptest.NewRequest("GET", "/api/hello", nil)
+		if version != "" {
+			req.Header.Set("X-Api-Version", version)
+		}
+		w := newResponseWriter()
+		p.ServeHTTP(w, req)
+		if got := w.header.Get("X-Target"); w.statusCode != http.StatusOK || got != want {
+			t.Fatalf("version %q: want %s but got: %d %s", version, want, w.statusCode, got)
+		}
+	}
+	w := newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/api/hello?beta=true", nil))
+	if got := w.header.Get("X-Target"); got != "beta" {
+		t.Fatalf("want beta but got: %s", got)
+	}
+}
+
+func TestProxyRedirect(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		t.Fatalf("the client of the redirect endpoint must not be created: %s", e.Path)
+		return nil, nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Path: "/old/*", Redirect: &config.Redirect{Path: "/new/{suffix}", StatusCode: http.StatusPermanentRedirect}},
+			{Path: "/users/{name}", Redirect: &config.Redirect{Host: "users.example.com", Path: "/{name}", StripQuery: true}},
+			{Path: "/secure/*", Redirect: &config.Redirect{Scheme: "https", StatusCode: http.StatusFound}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		target   string
+		code     int
+		location string
+	}{
+		{"http://example.com/old/a/b?c=d", http.StatusPermanentRedirect, "http://example.com/new/a/b?c=d"},
+		{"http://example.com/users/alice?c=d", http.StatusMovedPermanently, "http://users.example.com/alice"},
+		{"http://example.com/secure/login", http.StatusFound, "https://example.com/secure/login"},
+	}
+	for _, tc := range testCases {
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest("GET", tc.target, nil))
+		if w.statusCode != tc.code || w.header.Get("Location") != tc.location {
+			t.Errorf("%s: want %d %s but got %d %s", tc.target, tc.code, tc.location, w.statusCode, w.header.Get("Location"))
+		}
+	}
+}
+
+func TestProxyDirectResponse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintenance.html")
+	if err := ioutil.WriteFile(file, []byte("<h1>maintenance</h1>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		t.Fatalf("the client of the direct response endpoint must not be created: %s", e.Path)
+		return nil, nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Path: "/robots.txt", DirectResponse: &config.DirectResponse{
+				Headers: map[string]string{"Content-Type": "text/plain"},
+				Content: &config.DirectResponse_Body{Body: "User-agent: *\nDisallow: /"},
+			}},
+			{Path: "/legacy/*", DirectResponse: &config.DirectResponse{
+				StatusCode: http.StatusServiceUnavailable,
+				Content:    &config.DirectResponse_File{File: file},
+			}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/robots.txt", http.StatusOK, "User-agent: *\nDisallow: /"},
+		{"/legacy/api", http.StatusServiceUnavailable, "<h1>maintenance</h1>"},
+	}
+	for _, tc := range testCases {
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.statusCode != tc.code || w.body.String() != tc.body {
+			t.Errorf("%s: want %d %q but got %d %q", tc.path, tc.code, tc.body, w.statusCode, w.body.String())
+		}
+	}
+	w := newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/robots.txt", nil))
+	if w.header.Get("Content-Type") != "text/plain" {
+		t.Errorf("want the content type header but got: %v", w.header)
+	}
+}
+
+func TestProxyRoutePriority(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return targetClient(e.Backends[0].Target), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/api/*", Backends: []*config.Backend{{Target: "api"}}},
+			{Protocol: config.Protocol_HTTP, Path: "/api/users", Backends: []*config.Backend{{Target: "users"}}},
+			{Protocol: config.Protocol_HTTP, Path: "/api/v1/*", Backends: []*config.Backend{{Target: "v1"}}},
+			{Protocol: config.Protocol_HTTP, Path: "/*", Priority: 1, Method: "POST", Backends: []*config.Backend{{Target: "post"}}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		method, path, want string
+	}{
+		{"GET", "/api/hello", "api"},
+		{"GET", "/api/users", "users"},
+		{"GET", "/api/v1/users", "v1"},
+		{"POST", "/api/users", "post"},
+	}
+	for _, tc := range testCases {
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if got := w.header.Get("X-Target"); got != tc.want {
+			t.Errorf("%s %s: want %s but got: %d %s", tc.method, tc.path, tc.want, w.statusCode, got)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/proxy/router/shadowed", nil))
+	if body := strings.TrimSpace(w.Body.String()); body != "[]" {
+		t.Errorf("want no shadowed endpoints but got: %s", body)
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	global := []*config.Middleware{
+		{Name: "logging"},
+		{Name: "cors", Stage: config.Middleware_PRE},
+		{Name: "rewrite", Stage: config.Middleware_POST},
+	}
+	testCases := []struct {
+		endpoint *config.Endpoint
+		want     string
+	}{
+		{&config.Endpoint{}, "cors,logging,rewrite"},
+		{&config.Endpoint{Middlewares: []*config.Middleware{
+			{Name: "auth", Stage: config.Middleware_AUTH},
+			{Name: "transcoder"},
+			{Name: "tracing", Stage: config.Middleware_PRE, Priority: 1},
+		}}, "tracing,cors,auth,logging,transcoder,rewrite"},
+		{&config.Endpoint{OverrideMiddlewares: true, Middlewares: []*config.Middleware{
+			{Name: "transcoder"},
+			{Name: "bbr", Priority: 10},
+		}}, "bbr,transcoder"},
+	}
+	for _, tc := range testCases {
+		var names []string
+		for _, m := range middlewareChain(tc.endpoint, global) {
+			names = append(names, m.Name)
+		}
+		if got := strings.Join(names, ","); got != tc.want {
+			t.Errorf("want %s but got %s", tc.want, got)
+		}
+	}
+}
+
+func TestProxySourceRoute(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return targetClient(e.Backends[0].Target), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		TrustedProxies: []string{"192.168.0.0/16"},
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/api/*", SourceRanges: []string{"10.0.0.0/8"}, Backends: []*config.Backend{{Target: "internal"}}},
+			{Protocol: config.Protocol_HTTP, Path: "/api/*", Backends: []*config.Backend{{Target: "public"}}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	defer clientip.SetTrustedProxies(nil)
+	testCases := []struct {
+		remoteAddr, xff, want string
+	}{
+		{"10.0.0.1:1234", "", "internal"},
+		{"8.8.8.8:1234", "", "public"},
+		{"8.8.8.8:1234", "10.0.0.1", "public"},
+		{"192.168.0.1:1234", "10.0.0.1", "internal"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/api/hello", nil)
+		req.RemoteAddr = tc.remoteAddr
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		w := newResponseWriter()
+		p.ServeHTTP(w, req)
+		if got := w.header.Get("X-Target"); got != tc.want {
+			t.Errorf("%s %s: want %s but got: %d %s", tc.remoteAddr, tc.xff, tc.want, w.statusCode, got)
+		}
+	}
+}
+
+func TestProxySNIRouting(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return targetClient(e.Backends[0].Target), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Tls: &config.TLS{SniRouting: true},
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Host: "api.example.com", Path: "/*", Backends: []*config.Backend{{Target: "api"}}},
+			{Protocol: config.Protocol_HTTP, Host: "admin.example.com", Path: "/*", Backends: []*config.Backend{{Target: "admin"}}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		host, serverName string
+		code             int
+		want             string
+	}{
+		{"api.example.com", "api.example.com", 0, "api"},
+		{"admin.example.com:8443", "admin.example.com", 0, "admin"},
+		{"admin.example.com", "api.example.com", http.StatusMisdirectedRequest, ""},
+		{"admin.example.com", "", 0, "admin"},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/hello", nil)
+		req.Host = tc.host
+		req.TLS = &tls.ConnectionState{ServerName: tc.serverName}
+		w := newResponseWriter()
+		p.ServeHTTP(w, req)
+		if (tc.code != 0 && w.statusCode != tc.code) || w.header.Get("X-Target") != tc.want {
+			t.Errorf("%s %s: want %d %s but got: %d %s", tc.host, tc.serverName, tc.code, tc.want, w.statusCode, w.header.Get("X-Target"))
+		}
+	}
+}
+
+// stalledBody responds the data then stalls until the request is canceled.
+type stalledBody struct {
+	ctx  context.Context
+	data []byte
+}
+
+func (b *stalledBody) Read(p []byte) (int, error) {
+	if len(b.data) > 0 {
+		n := copy(p, b.data)
+		b.data = b.data[n:]
+		return n, nil
+	}
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b *stalledBody) Close() error { return nil }
+
+func TestProxyTimeouts(t *testing.T) {
+	var attempts int
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if req.URL.Path == "/slow" && attempts == 1 {
+				<-req.Context().Done()
+				return nil, req.Context().Err()
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       &stalledBody{ctx: req.Context(), data: []byte("hello")},
+			}, nil
+		}), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{{
+			Protocol:              config.Protocol_HTTP,
+			Path:                  "/*",
+			Timeout:               durationpb.New(5 * time.Second),
+			ResponseHeaderTimeout: durationpb.New(50 * time.Millisecond),
+			IdleTimeout:           durationpb.New(50 * time.Millisecond),
+			Retry:                 &config.Retry{Attempts: 2},
+			Backends:              []*config.Backend{{Target: "127.0.0.1"}},
+		}},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/slow", "/stalled"} {
+		attempts = 0
+		startTime := time.Now()
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.statusCode != http.StatusOK || w.body.String() != "hello" {
+			t.Errorf("%s: want the response of the next attempt but got: %d %s", path, w.statusCode, w.body.String())
+		}
+		if elapsed := time.Since(startTime); elapsed > time.Second {
+			t.Errorf("%s: want the stalled request to be canceled but took %s", path, elapsed)
+		}
+	}
+	if attempts != 1 {
+		t.Errorf("want the stalled response body not to be retried but got %d attempts", attempts)
+	}
+}
+
+func TestProxyRetryPolicy(t *testing.T) {
+	var attempts int
+	clientFactory := func(*config.Endpoint) (http.RoundTripper, error) {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			// the request is sent to the upstream.
+			if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.WroteHeaders != nil {
+				trace.WroteHeaders()
+			}
+			resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}
+			switch req.URL.Path {
+			case "/grpc":
+				resp.StatusCode = http.StatusOK
+				resp.Header.Set("Grpc-Status", "14")
+			case "/throttled":
+				resp.Header.Set("Retry-After", "10")
+			}
+			return resp, nil
+		}), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{{
+			Protocol: config.Protocol_HTTP,
+			Path:     "/*",
+			Timeout:  durationpb.New(time.Second),
+			Retry: &config.Retry{
+				Attempts:           3,
+				Conditions:         []*config.Condition{{Condition: &config.Condition_ByStatusCode{ByStatusCode: "503"}}},
+				RetriableGrpcCodes: []string{"UNAVAILABLE"},
+				Backoff:            &config.Backoff{BaseInterval: durationpb.New(time.Millisecond)},
+				HonorRetryAfter:    true,
+			},
+			Backends: []*config.Backend{{Target: "127.0.0.1"}},
+		}},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		method   string
+		path     string
+		attempts int
+	}{
+		{method: "GET", path: "/unavailable", attempts: 3},
+		// the sent non-idempotent request may have been processed.
+		{method: "POST", path: "/unavailable", attempts: 1},
+		// the request is declined by the gRPC status code explicitly.
+		{method: "POST", path: "/grpc", attempts: 3},
+		// the Retry-After delay exceeds the timeout.
+		{method: "GET", path: "/throttled", attempts: 1},
+	}
+	for _, testCase := range testCases {
+		attempts = 0
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest(testCase.method, testCase.path, bytes.NewBufferString("hello")))
+		if attempts != testCase.attempts {
+			t.Errorf("%s %s: want %d attempts but got %d", testCase.method, testCase.path, testCase.attempts, attempts)
+		}
+	}
+}
+
+func TestProxyRequestBodyFilter(t *testing.T) {
+	var attempts int
+	clientFactory := func(*config.Endpoint) (http.RoundTripper, error) {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: req.Body}, nil
+		}), nil
+	}
+	p, err := New(clientFactory, middleware.Create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	any, err := anypb.New(&bodylimitv1.BodyLimit{MaxBytes: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{{
+			Protocol:    config.Protocol_HTTP,
+			Path:        "/upload",
+			Middlewares: []*config.Middleware{{Name: "logging"}, {Name: "bodylimit", Options: any}},
+			Backends:    []*config.Backend{{Target: "127.0.0.1"}},
+		}},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	w := newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("POST", "/upload", bytes.NewBufferString("hello")))
+	if w.statusCode != http.StatusRequestEntityTooLarge || attempts != 0 {
+		t.Fatalf("want 413 before proxied but got %d in %d attempts", w.statusCode, attempts)
+	}
+	w = newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("POST", "/upload", bytes.NewBufferString("hi")))
+	if w.statusCode != http.StatusOK || w.body.String() != "hi" {
+		t.Fatalf("want the body in the limit proxied but got %d %s", w.statusCode, w.body.String())
+	}
+}
+
+func TestProxyRequestBodyFilterConcurrent(t *testing.T) {
+	clientFactory := func(*config.Endpoint) (http.RoundTripper, error) {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: req.Body}, nil
+		}), nil
+	}
+	p, err := New(clientFactory, middleware.Create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	any, err := anypb.New(&bodylimitv1.BodyLimit{MaxBytes: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{{
+			Protocol:    config.Protocol_HTTP,
+			Path:        "/upload",
+			Middlewares: []*config.Middleware{{Name: "bodylimit", Options: any}},
+			Backends:    []*config.Backend{{Target: "127.0.0.1"}},
+		}},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	// the oversized requests must not fail the ones in the limit served at
+	// the same time.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for body, code := range map[string]int{"hello": http.StatusRequestEntityTooLarge, "hi": http.StatusOK} {
+			wg.Add(1)
+			go func(body string, code int) {
+				defer wg.Done()
+				w := newResponseWriter()
+				p.ServeHTTP(w, httptest.NewRequest("POST", "/upload", bytes.NewBufferString(body)))
+				if w.statusCode != code {
+					t.Errorf("want %d of the body %q but got %d", code, body, w.statusCode)
+				}
+			}(body, code)
+		}
+	}
+	wg.Wait()
+}
+
+func TestProxyStreamingFlush(t *testing.T) {
+	clientFactory := func(*config.Endpoint) (http.RoundTripper, error) {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			contentLength := int64(-1)
+			if req.URL.Path == "/fixed" {
+				contentLength = 4
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, ContentLength: contentLength, Body: ioutil.NopCloser(bytes.NewBufferString("data"))}, nil
+		}), nil
+	}
+	p, err := New(clientFactory, middleware.Create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{{
+			Protocol: config.Protocol_HTTP,
+			Path:     "/*",
+			Backends: []*config.Backend{{Target: "127.0.0.1"}},
+		}},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{"/stream": true, "/fixed": false} {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Body.String() != "data" || w.Flushed != want {
+			t.Errorf("%s: want flushed %v but got %v %s", path, want, w.Flushed, w.Body.String())
+		}
+	}
+}
+
+func TestProxyFallback(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return targetClient(e.Backends[0].Target), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/api/*", Method: "GET", Backends: []*config.Backend{{Target: "api"}}},
+		},
+		Fallback: &config.Endpoint{
+			DirectResponse: &config.DirectResponse{
+				StatusCode: http.StatusNotFound,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Content:    &config.DirectResponse_Body{Body: `{"error": "{method} {path} is not found"}`},
+				Template:   true,
+			},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	w := newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("GET", `/not/"found"`, nil))
+	if want := `{"error": "GET /not/\"found\" is not found"}`; w.statusCode != http.StatusNotFound || w.body.String() != want {
+		t.Errorf("want the fallback response %s but got: %d %s", want, w.statusCode, w.body.String())
+	}
+	// the values are escaped by the Content-Type detected of the template.
+	c.Fallback.DirectResponse = &config.DirectResponse{
+		StatusCode: http.StatusNotFound,
+		Content:    &config.DirectResponse_Body{Body: `<html>Not found: {path}</html>`},
+		Template:   true,
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	w = newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/%3Cscript%3Ealert(1)%3C/script%3E", nil))
+	if want := `<html>Not found: /&lt;script&gt;alert(1)&lt;/script&gt;</html>`; w.body.String() != want || w.header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("want the fallback response %s but got: %v %s", want, w.header, w.body.String())
+	}
+	w = newResponseWriter()
+	p.ServeHTTP(w, httptest.NewRequest("POST", "/api/hello", nil))
+	if w.statusCode != http.StatusMethodNotAllowed {
+		t.Errorf("want 405 of the method mismatch but got: %d", w.statusCode)
+	}
+
+	c.Fallback = &config.Endpoint{Protocol: config.Protocol_HTTP, Backends: []*config.Backend{{Target: "default"}}}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ path, want string }{{"/api/hello", "api"}, {"/unknown", "default"}} {
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if got := w.header.Get("X-Target"); got != tc.want {
+			t.Errorf("%s: want %s but got: %d %s", tc.path, tc.want, w.statusCode, got)
+		}
+	}
+}
+
+func TestProxyAutoOptions(t *testing.T) {
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return targetClient(e.Backends[0].Target), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/read", Method: "GET,HEAD", AutoOptions: true, Backends: []*config.Backend{{Target: "read"}}},
+			{Protocol: config.Protocol_HTTP, Path: "/write", Method: "!GET,HEAD,TRACE", AutoOptions: true, Backends: []*config.Backend{{Target: "write"}}},
+			{Protocol: config.Protocol_HTTP, Path: "/proxied", Method: "GET", Backends: []*config.Backend{{Target: "proxied"}}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		method, path string
+		code         int
+		allow, want  string
+	}{
+		{"GET", "/read", http.StatusOK, "", "read"},
+		{"OPTIONS", "/read", http.StatusNoContent, "GET, HEAD, OPTIONS", ""},
+		{"OPTIONS", "/write", http.StatusNoContent, "POST, PUT, PATCH, DELETE, OPTIONS", ""},
+		{"DELETE", "/write", http.StatusOK, "", "write"},
+		{"GET", "/write", http.StatusMethodNotAllowed, "", ""},
+		{"OPTIONS", "/proxied", http.StatusOK, "", "proxied"},
+	}
+	for _, tc := range testCases {
+		w := newResponseWriter()
+		p.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.statusCode != tc.code || w.header.Get("Allow") != tc.allow || w.header.Get("X-Target") != tc.want {
+			t.Errorf("%s %s: want %d %q %q but got: %d %q %q", tc.method, tc.path, tc.code, tc.allow, tc.want, w.statusCode, w.header.Get("Allow"), w.header.Get("X-Target"))
+		}
+	}
+}
+
+func TestProxyPathVars(t *testing.T) {
+	var vars map[string]string
+	clientFactory := func(e *config.Endpoint) (http.RoundTripper, error) {
+		return middleware.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			reqOpt, _ := middleware.FromRequestContext(req.Context())
+			vars = reqOpt.PathVars
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+		}), nil
+	}
+	middlewareFactory := func(c *config.Middleware) (middleware.Middleware, error) {
+		return logging.Middleware(c)
+	}
+	p, err := New(clientFactory, middlewareFactory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Gateway{
+		Endpoints: []*config.Endpoint{
+			{Protocol: config.Protocol_HTTP, Path: "/users/{id}/orders/{order_id:[0-9]+}", Backends: []*config.Backend{{Target: "orders"}}},
+		},
+	}
+	if err := p.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	p.ServeHTTP(newResponseWriter(), httptest.NewRequest("GET", "/users/alice/orders/42", nil))
+	if want := map[string]string{"id": "alice", "order_id": "42"}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("want the path vars %v but got %v", want, vars)
+	}
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/middleware"
+	"github.com/go-kratos/gateway/router/mux"
+)
+
+func isRedirectStatusCode(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func requestScheme(req *http.Request) string {
+	if req.TLS != nil {
+		return "https"
+	}
+	if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+	return "http"
+}
+
+// buildRedirect returns the handler responding the redirect of the endpoint without proxying.
+func buildRedirect(e *config.Endpoint) (http.Handler, error) {
+	redirect := e.Redirect
+	code := int(redirect.StatusCode)
+	if code == 0 {
+		code = http.StatusMovedPermanently
+	}
+	if !isRedirectStatusCode(code) {
+		return nil, fmt.Errorf("invalid redirect status code: %d", code)
+	}
+	prefix := ""
+	if strings.HasSuffix(e.Path, "*") {
+		prefix = strings.TrimRight(e.Path, "*")
+	}
+	labels := middleware.NewMetricsLabels(e)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		values := make(map[string]string)
+		for k, v := range mux.Vars(req) {
+			values[k] = v
+		}
+		values["scheme"] = requestScheme(req)
+		values["host"] = req.Host
+		values["path"] = req.URL.Path
+		if prefix != "" {
+			values["suffix"] = strings.TrimPrefix(req.URL.Path, prefix)
+		}
+		target := &url.URL{
+			Scheme:   values["scheme"],
+			Host:     values["host"],
+			Path:     values["path"],
+			RawQuery: req.URL.RawQuery,
+		}
+		if redirect.Scheme != "" {
+			target.Scheme = middleware.ExpandPlaceholders(redirect.Scheme, values)
+		}
+		if redirect.Host != "" {
+			target.Host = middleware.ExpandPlaceholders(redirect.Host, values)
+		}
+		if redirect.Path != "" {
+			target.Path = middleware.ExpandPlaceholders(redirect.Path, values)
+		}
+		if redirect.StripQuery {
+			target.RawQuery = ""
+		}
+		http.Redirect(w, req, target.String(), code)
+		requestsTotalIncr(labels, code)
+	}), nil
+}
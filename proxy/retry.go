@@ -1,11 +1,24 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
 	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	config "github.com/go-kratos/gateway/api/gateway/config/v1"
 	"github.com/go-kratos/gateway/proxy/condition"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	_defaultBackoffBaseInterval = 25 * time.Millisecond
+	_defaultBudgetPercent       = 20
+	_defaultMinRetryConcurrency = 3
 )
 
 type retryStrategy struct {
@@ -13,6 +26,16 @@ type retryStrategy struct {
 	timeout       time.Duration
 	perTryTimeout time.Duration
 	conditions    []condition.Condition
+	// zero if no timeout
+	responseHeaderTimeout time.Duration
+	idleTimeout           time.Duration
+	// the gRPC status codes to retry, e.g. "14"
+	grpcCodes          map[string]struct{}
+	baseInterval       time.Duration
+	maxInterval        time.Duration
+	honorRetryAfter    bool
+	budget             *retryBudget
+	retryNonIdempotent bool
 }
 
 func calcTimeout(endpoint *config.Endpoint) time.Duration {
@@ -55,14 +78,66 @@ func prepareRetryStrategy(e *config.Endpoint) (*retryStrategy, error) {
 		timeout:       calcTimeout(e),
 		perTryTimeout: calcPerTryTimeout(e),
 	}
+	if e.ResponseHeaderTimeout != nil {
+		strategy.responseHeaderTimeout = e.ResponseHeaderTimeout.AsDuration()
+	}
+	if e.IdleTimeout != nil {
+		strategy.idleTimeout = e.IdleTimeout.AsDuration()
+	}
 	conditions, err := parseRetryConditon(e)
 	if err != nil {
 		return nil, err
 	}
 	strategy.conditions = conditions
+	if e.Retry == nil {
+		return strategy, nil
+	}
+	if strategy.grpcCodes, err = parseGRPCCodes(e.Retry.RetriableGrpcCodes); err != nil {
+		return nil, err
+	}
+	if b := e.Retry.Backoff; b != nil {
+		strategy.baseInterval = _defaultBackoffBaseInterval
+		if b.BaseInterval != nil && b.BaseInterval.AsDuration() > 0 {
+			strategy.baseInterval = b.BaseInterval.AsDuration()
+		}
+		strategy.maxInterval = 10 * strategy.baseInterval
+		if b.MaxInterval != nil && b.MaxInterval.AsDuration() > 0 {
+			strategy.maxInterval = b.MaxInterval.AsDuration()
+		}
+	}
+	if b := e.Retry.Budget; b != nil {
+		strategy.budget = &retryBudget{percent: _defaultBudgetPercent, minConcurrency: _defaultMinRetryConcurrency}
+		if b.BudgetPercent > 0 {
+			strategy.budget.percent = b.BudgetPercent
+		}
+		if b.MinRetryConcurrency > 0 {
+			strategy.budget.minConcurrency = int64(b.MinRetryConcurrency)
+		}
+	}
+	strategy.honorRetryAfter = e.Retry.HonorRetryAfter
+	strategy.retryNonIdempotent = e.Retry.RetryNonIdempotent
 	return strategy, nil
 }
 
+func parseGRPCCodes(in []string) (map[string]struct{}, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]struct{}, len(in))
+	for _, name := range in {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(name))); err != nil {
+			if n, err := strconv.ParseUint(name, 10, 32); err == nil {
+				code = codes.Code(n)
+			} else {
+				return nil, fmt.Errorf("invalid grpc code: %s", name)
+			}
+		}
+		out[strconv.Itoa(int(code))] = struct{}{}
+	}
+	return out, nil
+}
+
 func parseRetryConditon(endpoint *config.Endpoint) ([]condition.Condition, error) {
 	if endpoint.Retry == nil {
 		return []condition.Condition{}, nil
@@ -73,3 +148,117 @@ func parseRetryConditon(endpoint *config.Endpoint) ([]condition.Condition, error
 func judgeRetryRequired(conditions []condition.Condition, resp *http.Response) bool {
 	return condition.JudgeConditons(conditions, resp, false)
 }
+
+// judgeResponse reports whether the response is required to retry, and
+// whether it's declined by the upstream explicitly by the gRPC status code.
+func (s *retryStrategy) judgeResponse(resp *http.Response) (retry, declined bool) {
+	if _, ok := s.grpcCodes[resp.Header.Get("Grpc-Status")]; ok {
+		return true, true
+	}
+	return judgeRetryRequired(s.conditions, resp), false
+}
+
+// safeToRetry reports whether the request is safe to retry, the requests of
+// the non-idempotent methods may have been processed by the upstream once
+// they're sent, so they're only retried if they're not sent or declined.
+func (s *retryStrategy) safeToRetry(method string, sent, declined bool) bool {
+	return s.retryNonIdempotent || isIdempotent(method) || !sent || declined
+}
+
+// retryDelay returns the delay before the nth retry, ok is false
+// if the delay exceeds the deadline of the request.
+func (s *retryStrategy) retryDelay(ctx context.Context, resp *http.Response, retry int) (delay time.Duration, ok bool) {
+	if s.honorRetryAfter && resp != nil {
+		if d, found := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); found {
+			delay = d
+		}
+	}
+	if delay == 0 && s.baseInterval > 0 {
+		max := s.maxInterval
+		// the delay of the nth retry is up to base_interval * 2^(n-1).
+		if shift := uint(retry - 1); shift < 32 && s.baseInterval<<shift < max {
+			max = s.baseInterval << shift
+		}
+		delay = time.Duration(rand.Int63n(int64(max) + 1))
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+	return delay, true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses the Retry-After header of the seconds or the HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := date.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// withSentTrace returns the context tracing whether the request headers are
+// written to the upstream.
+func withSentTrace(ctx context.Context) (context.Context, func() bool) {
+	var sent int32
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteHeaders: func() { atomic.StoreInt32(&sent, 1) },
+	})
+	return ctx, func() bool { return atomic.LoadInt32(&sent) == 1 }
+}
+
+// retryBudget limits the active retries by the percent of the active
+// requests, the nil budget is unlimited.
+type retryBudget struct {
+	percent        float64
+	minConcurrency int64
+	active         int64
+	retries        int64
+}
+
+func (b *retryBudget) start() func() {
+	if b == nil {
+		return func() {}
+	}
+	atomic.AddInt64(&b.active, 1)
+	return func() { atomic.AddInt64(&b.active, -1) }
+}
+
+// acquire reports whether the retry is allowed, the retry is released once
+// it's done.
+func (b *retryBudget) acquire() bool {
+	if b == nil {
+		return true
+	}
+	limit := int64(b.percent / 100 * float64(atomic.LoadInt64(&b.active)))
+	if limit < b.minConcurrency {
+		limit = b.minConcurrency
+	}
+	if atomic.AddInt64(&b.retries, 1) > limit {
+		atomic.AddInt64(&b.retries, -1)
+		return false
+	}
+	return true
+}
+
+func (b *retryBudget) release() {
+	if b != nil {
+		atomic.AddInt64(&b.retries, -1)
+	}
+}
@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -71,3 +73,95 @@ func TestCalcTimeout(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryStrategy(t *testing.T) {
+	s, err := prepareRetryStrategy(&config.Endpoint{
+		Timeout: durationpb.New(time.Second),
+		Retry: &config.Retry{
+			Attempts:           3,
+			RetriableGrpcCodes: []string{"UNAVAILABLE", "8"},
+			Backoff:            &config.Backoff{BaseInterval: durationpb.New(10 * time.Millisecond)},
+			HonorRetryAfter:    true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for code, want := range map[string]bool{"14": true, "8": true, "2": false, "": false} {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Grpc-Status": []string{code}}}
+		if retry, declined := s.judgeResponse(resp); retry != want || declined != want {
+			t.Errorf("grpc-status %s: want retry %v but got %v", code, want, retry)
+		}
+	}
+	if !s.safeToRetry("GET", true, false) || !s.safeToRetry("POST", false, false) || !s.safeToRetry("POST", true, true) {
+		t.Error("want the idempotent, the unsent and the declined requests retried")
+	}
+	if s.safeToRetry("POST", true, false) {
+		t.Error("want the sent non-idempotent request not retried")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for retry, max := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond} {
+		delay, ok := s.retryDelay(ctx, nil, retry+1)
+		if !ok || delay < 0 || delay > max {
+			t.Errorf("retry %d: want the delay up to %s but got %s", retry+1, max, delay)
+		}
+	}
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"0"}}}
+	if delay, ok := s.retryDelay(ctx, resp, 1); !ok || delay > 10*time.Millisecond {
+		t.Errorf("want the backoff by the zero Retry-After but got %s", delay)
+	}
+	resp.Header.Set("Retry-After", "2")
+	if _, ok := s.retryDelay(ctx, resp, 1); ok {
+		t.Error("want not retried if the Retry-After exceeds the deadline")
+	}
+
+	if _, err := parseGRPCCodes([]string{"UNKNOWN_CODE"}); err == nil {
+		t.Error("want the invalid grpc code rejected")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{value: "3", delay: 3 * time.Second, ok: true},
+		{value: "Sat, 01 Jan 2022 00:00:05 GMT", delay: 5 * time.Second, ok: true},
+		{value: "Fri, 31 Dec 2021 00:00:00 GMT", delay: 0, ok: true},
+		{value: "soon", ok: false},
+		{value: "", ok: false},
+	}
+	for _, testCase := range testCases {
+		delay, ok := parseRetryAfter(testCase.value, now)
+		if delay != testCase.delay || ok != testCase.ok {
+			t.Errorf("parseRetryAfter(%q) = %s %v, want %s %v", testCase.value, delay, ok, testCase.delay, testCase.ok)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := &retryBudget{percent: 50, minConcurrency: 1}
+	for i := 0; i < 4; i++ {
+		defer b.start()()
+	}
+	if !b.acquire() || !b.acquire() {
+		t.Fatal("want the retries allowed in the budget")
+	}
+	if b.acquire() {
+		t.Fatal("want the retries over the budget rejected")
+	}
+	b.release()
+	if !b.acquire() {
+		t.Fatal("want the released retry allowed")
+	}
+	var unlimited *retryBudget
+	unlimited.start()()
+	if !unlimited.acquire() {
+		t.Fatal("want the nil budget unlimited")
+	}
+	unlimited.release()
+}
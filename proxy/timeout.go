@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// errResponseHeaderTimeout is returned if the upstream doesn't respond the
+// headers in the response header timeout, it's responded with 504.
+var errResponseHeaderTimeout = fmt.Errorf("timeout awaiting upstream response headers: %w", context.DeadlineExceeded)
+
+// roundTripWithHeaderTimeout cancels the attempt if the response headers are
+// not received in the timeout.
+func roundTripWithHeaderTimeout(tripper http.RoundTripper, req *http.Request, cancel context.CancelFunc, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return tripper.RoundTrip(req)
+	}
+	timer := time.AfterFunc(timeout, cancel)
+	resp, err := tripper.RoundTrip(req)
+	if !timer.Stop() {
+		if err == nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, errResponseHeaderTimeout
+	}
+	return resp, err
+}
+
+// idleTimeoutBody cancels the request if no data is read from the body in
+// the idle timeout.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, cancel context.CancelFunc, timeout time.Duration) io.ReadCloser {
+	return &idleTimeoutBody{
+		ReadCloser: body,
+		timer:      time.AfterFunc(timeout, cancel),
+		timeout:    timeout,
+	}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
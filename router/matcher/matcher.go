@@ -0,0 +1,165 @@
+// Package matcher builds the router matchers of the endpoint predicates.
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/clientip"
+	"github.com/go-kratos/gateway/router"
+)
+
+// New returns the matchers of the endpoint.
+func New(e *config.Endpoint) ([]router.Matcher, error) {
+	matchers := make([]router.Matcher, 0, len(e.Headers)+len(e.Queries)+1)
+	for _, h := range e.Headers {
+		m, err := Header(h)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	for _, q := range e.Queries {
+		m, err := Query(q)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	if len(e.SourceRanges) > 0 {
+		m, err := Source(e.SourceRanges)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// Header returns the matcher of the request header, it matches if any of the header values matches.
+func Header(h *config.HeaderMatcher) (router.Matcher, error) {
+	if h.Name == "" {
+		return nil, errors.New("header name is required")
+	}
+	name := http.CanonicalHeaderKey(h.Name)
+	var match func(string) bool
+	switch m := h.Match.(type) {
+	case *config.HeaderMatcher_Exact:
+		match = func(v string) bool { return v == m.Exact }
+	case *config.HeaderMatcher_Prefix:
+		match = func(v string) bool { return strings.HasPrefix(v, m.Prefix) }
+	case *config.HeaderMatcher_Regex:
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header regex: %s: %w", m.Regex, err)
+		}
+		match = re.MatchString
+	case *config.HeaderMatcher_Present:
+		return func(req *http.Request) bool {
+			return (len(req.Header[name]) > 0) == m.Present
+		}, nil
+	default:
+		return nil, fmt.Errorf("header match is required: %s", h.Name)
+	}
+	return func(req *http.Request) bool {
+		for _, v := range req.Header[name] {
+			if match(v) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// Query returns the matcher of the request query, it matches if any of the query values matches.
+func Query(q *config.QueryMatcher) (router.Matcher, error) {
+	if q.Name == "" {
+		return nil, errors.New("query name is required")
+	}
+	var match func(string) bool
+	switch m := q.Match.(type) {
+	case *config.QueryMatcher_Exact:
+		match = func(v string) bool { return v == m.Exact }
+	case *config.QueryMatcher_Regex:
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query regex: %s: %w", m.Regex, err)
+		}
+		match = re.MatchString
+	default:
+		return nil, fmt.Errorf("query match is required: %s", q.Name)
+	}
+	return func(req *http.Request) bool {
+		for _, v := range req.URL.Query()[q.Name] {
+			if match(v) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// Source returns the matcher of the client IP, it matches if the client IP is in any of the CIDRs.
+func Source(ranges []string) (router.Matcher, error) {
+	cidrs, err := clientip.ParseCIDRs(ranges)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source range: %w", err)
+	}
+	return func(req *http.Request) bool {
+		ip := clientip.FromRequest(req)
+		return ip != nil && clientip.Contains(cidrs, ip)
+	}, nil
+}
+
+func sourceKey(ranges []string) string {
+	keys := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if cidr, err := clientip.ParseCIDR(r); err == nil {
+			r = cidr.String()
+		}
+		keys = append(keys, r)
+	}
+	sort.Strings(keys)
+	return "source:" + strings.Join(keys, "|")
+}
+
+// Key returns the canonical key of the endpoint matchers, it's empty if there are no matchers.
+func Key(e *config.Endpoint) string {
+	keys := make([]string, 0, len(e.Headers)+len(e.Queries)+1)
+	for _, h := range e.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		switch m := h.Match.(type) {
+		case *config.HeaderMatcher_Exact:
+			keys = append(keys, "header:"+name+"="+m.Exact)
+		case *config.HeaderMatcher_Prefix:
+			keys = append(keys, "header:"+name+"^="+m.Prefix)
+		case *config.HeaderMatcher_Regex:
+			keys = append(keys, "header:"+name+"~="+m.Regex)
+		case *config.HeaderMatcher_Present:
+			if m.Present {
+				keys = append(keys, "header:"+name)
+			} else {
+				keys = append(keys, "!header:"+name)
+			}
+		}
+	}
+	for _, q := range e.Queries {
+		switch m := q.Match.(type) {
+		case *config.QueryMatcher_Exact:
+			keys = append(keys, "query:"+q.Name+"="+m.Exact)
+		case *config.QueryMatcher_Regex:
+			keys = append(keys, "query:"+q.Name+"~="+m.Regex)
+		}
+	}
+	if len(e.SourceRanges) > 0 {
+		// the source ranges are matched as a whole.
+		keys = append(keys, sourceKey(e.SourceRanges))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
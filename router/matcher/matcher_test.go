@@ -0,0 +1,143 @@
+package matcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/clientip"
+)
+
+func TestHeader(t *testing.T) {
+	testCases := []struct {
+		matcher *config.HeaderMatcher
+		header  http.Header
+		want    bool
+	}{
+		{&config.HeaderMatcher{Name: "x-api-version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}}, http.Header{"X-Api-Version": {"v2"}}, true},
+		{&config.HeaderMatcher{Name: "x-api-version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}}, http.Header{"X-Api-Version": {"v1"}}, false},
+		{&config.HeaderMatcher{Name: "X-Api-Version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}}, http.Header{"X-Api-Version": {"v1", "v2"}}, true},
+		{&config.HeaderMatcher{Name: "User-Agent", Match: &config.HeaderMatcher_Prefix{Prefix: "curl/"}}, http.Header{"User-Agent": {"curl/7.79"}}, true},
+		{&config.HeaderMatcher{Name: "User-Agent", Match: &config.HeaderMatcher_Prefix{Prefix: "curl/"}}, http.Header{}, false},
+		{&config.HeaderMatcher{Name: "X-Api-Version", Match: &config.HeaderMatcher_Regex{Regex: "^v[2-9]$"}}, http.Header{"X-Api-Version": {"v3"}}, true},
+		{&config.HeaderMatcher{Name: "X-Api-Version", Match: &config.HeaderMatcher_Regex{Regex: "^v[2-9]$"}}, http.Header{"X-Api-Version": {"v10"}}, false},
+		{&config.HeaderMatcher{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: true}}, http.Header{"X-Canary": {""}}, true},
+		{&config.HeaderMatcher{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: true}}, http.Header{}, false},
+		{&config.HeaderMatcher{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: false}}, http.Header{}, true},
+	}
+	for _, tc := range testCases {
+		m, err := Header(tc.matcher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header = tc.header
+		if got := m(req); got != tc.want {
+			t.Errorf("%v with %v: want %v but got %v", tc.matcher, tc.header, tc.want, got)
+		}
+	}
+}
+
+func TestHeaderInvalid(t *testing.T) {
+	for _, h := range []*config.HeaderMatcher{
+		{Match: &config.HeaderMatcher_Exact{Exact: "v2"}},
+		{Name: "X-Api-Version"},
+		{Name: "X-Api-Version", Match: &config.HeaderMatcher_Regex{Regex: "v[2-"}},
+	} {
+		if _, err := Header(h); err == nil {
+			t.Errorf("want error of %v", h)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		matcher *config.QueryMatcher
+		target  string
+		want    bool
+	}{
+		{&config.QueryMatcher{Name: "beta", Match: &config.QueryMatcher_Exact{Exact: "true"}}, "/?beta=true", true},
+		{&config.QueryMatcher{Name: "beta", Match: &config.QueryMatcher_Exact{Exact: "true"}}, "/?beta=false", false},
+		{&config.QueryMatcher{Name: "beta", Match: &config.QueryMatcher_Exact{Exact: "true"}}, "/?beta=false&beta=true", true},
+		{&config.QueryMatcher{Name: "beta", Match: &config.QueryMatcher_Exact{Exact: "true"}}, "/", false},
+		{&config.QueryMatcher{Name: "region", Match: &config.QueryMatcher_Regex{Regex: "^(eu|us)-"}}, "/?region=eu-west", true},
+		{&config.QueryMatcher{Name: "region", Match: &config.QueryMatcher_Regex{Regex: "^(eu|us)-"}}, "/?region=ap-east", false},
+	}
+	for _, tc := range testCases {
+		m, err := Query(tc.matcher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m(httptest.NewRequest("GET", tc.target, nil)); got != tc.want {
+			t.Errorf("%v with %s: want %v but got %v", tc.matcher, tc.target, tc.want, got)
+		}
+	}
+	for _, q := range []*config.QueryMatcher{
+		{Match: &config.QueryMatcher_Exact{Exact: "true"}},
+		{Name: "beta"},
+		{Name: "beta", Match: &config.QueryMatcher_Regex{Regex: "("}},
+	} {
+		if _, err := Query(q); err == nil {
+			t.Errorf("want error of %v", q)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	if err := clientip.SetTrustedProxies([]string{"192.168.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	defer clientip.SetTrustedProxies(nil)
+	m, err := Source([]string{"10.0.0.0/8", "172.16.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		remoteAddr, xff string
+		want            bool
+	}{
+		{"10.1.2.3:1234", "", true},
+		{"172.16.0.1:1234", "", true},
+		{"172.16.0.2:1234", "", false},
+		{"8.8.8.8:1234", "10.1.2.3", false},
+		{"192.168.0.1:1234", "10.1.2.3", true},
+		{"192.168.0.1:1234", "10.1.2.3, 8.8.8.8", false},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if got := m(req); got != tc.want {
+			t.Errorf("%s with %s: want %v but got %v", tc.remoteAddr, tc.xff, tc.want, got)
+		}
+	}
+	if _, err := Source([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("want error of the invalid CIDR")
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := &config.Endpoint{Headers: []*config.HeaderMatcher{
+		{Name: "x-api-version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}},
+		{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: false}},
+	}}
+	b := &config.Endpoint{Headers: []*config.HeaderMatcher{
+		{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: false}},
+		{Name: "X-Api-Version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}},
+	}, Queries: []*config.QueryMatcher{
+		{Name: "beta", Match: &config.QueryMatcher_Exact{Exact: "true"}},
+	}}
+	a.Queries = b.Queries
+	if Key(a) != Key(b) || Key(a) != "!header:X-Canary,header:X-Api-Version=v2,query:beta=true" {
+		t.Fatalf("unexpected keys: %s %s", Key(a), Key(b))
+	}
+	if key := Key(&config.Endpoint{SourceRanges: []string{"10.1.0.0/8", "172.16.0.1"}}); key != "source:10.0.0.0/8|172.16.0.1/32" {
+		t.Fatalf("unexpected source key: %s", key)
+	}
+	if Key(&config.Endpoint{}) != "" {
+		t.Fatal("want empty key without matchers")
+	}
+}
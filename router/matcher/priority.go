@@ -0,0 +1,142 @@
+package matcher
+
+import (
+	"sort"
+	"strings"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+	"github.com/go-kratos/gateway/router"
+)
+
+// The kinds of the path patterns, the smaller is more specific.
+const (
+	pathExact = iota
+	pathTemplate
+	pathPrefix
+)
+
+func pathKind(pattern string) int {
+	switch {
+	case strings.HasSuffix(pattern, "*"):
+		return pathPrefix
+	case strings.Contains(pattern, "{"):
+		return pathTemplate
+	}
+	return pathExact
+}
+
+// literalPrefix returns the literal part of the pattern before the variables or wildcard.
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "{*"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+func anyMethod(method string) bool {
+	return method == "" || method == "*"
+}
+
+// coversMethods reports whether all the methods of b are matched by a, the
+// invalid methods are compared as is.
+func coversMethods(a, b string) bool {
+	ma, errA := router.ParseMethods(a)
+	mb, errB := router.ParseMethods(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return ma.Covers(mb)
+}
+
+// moreSpecific reports whether the endpoint a is matched before b.
+func moreSpecific(a, b *config.Endpoint) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if ka, kb := pathKind(a.Path), pathKind(b.Path); ka != kb {
+		return ka < kb
+	}
+	if la, lb := len(literalPrefix(a.Path)), len(literalPrefix(b.Path)); la != lb {
+		return la > lb
+	}
+	if ma, mb := anyMethod(a.Method), anyMethod(b.Method); ma != mb {
+		return mb
+	}
+	return matcherCount(a) > matcherCount(b)
+}
+
+func matcherCount(e *config.Endpoint) int {
+	n := len(e.Headers) + len(e.Queries)
+	if len(e.SourceRanges) > 0 {
+		n++
+	}
+	return n
+}
+
+// Sort returns the endpoints in the order of matching, see the priority of the endpoint.
+func Sort(endpoints []*config.Endpoint) []*config.Endpoint {
+	out := make([]*config.Endpoint, len(endpoints))
+	copy(out, endpoints)
+	sort.SliceStable(out, func(i, j int) bool {
+		return moreSpecific(out[i], out[j])
+	})
+	return out
+}
+
+// Shadow is the endpoint never matched since all the requests are matched by the endpoint before it.
+type Shadow struct {
+	Endpoint *config.Endpoint
+	By       *config.Endpoint
+}
+
+func matcherKeys(e *config.Endpoint) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, key := range strings.Split(Key(e), ",") {
+		if key != "" {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// covers reports whether all the requests matched by b are matched by a.
+func covers(a, b *config.Endpoint) bool {
+	if !strings.EqualFold(a.Host, b.Host) {
+		return false
+	}
+	if !coversMethods(a.Method, b.Method) {
+		return false
+	}
+	switch pathKind(a.Path) {
+	case pathPrefix:
+		if !strings.HasPrefix(literalPrefix(b.Path), literalPrefix(a.Path)) {
+			return false
+		}
+	default:
+		if a.Path != b.Path {
+			return false
+		}
+	}
+	keys := matcherKeys(b)
+	for key := range matcherKeys(a) {
+		if _, ok := keys[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Shadowed returns the endpoints shadowed by the endpoints matched before them.
+func Shadowed(endpoints []*config.Endpoint) []Shadow {
+	sorted := Sort(endpoints)
+	var out []Shadow
+	for i, e := range sorted {
+		for _, before := range sorted[:i] {
+			if covers(before, e) {
+				out = append(out, Shadow{Endpoint: e, By: before})
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+func TestSort(t *testing.T) {
+	endpoints := []*config.Endpoint{
+		{Path: "/*", Description: "root"},
+		{Path: "/api/*", Description: "api"},
+		{Path: "/api/v1/*", Description: "v1"},
+		{Path: "/api/{name}", Description: "template"},
+		{Path: "/api/users", Description: "users"},
+		{Path: "/api/users", Method: "GET", Description: "get users"},
+		{Path: "/api/*", Headers: []*config.HeaderMatcher{{Name: "X-Api-Version", Match: &config.HeaderMatcher_Exact{Exact: "v2"}}}, Description: "api v2"},
+		{Path: "/*", Priority: 1, Description: "priority"},
+	}
+	var got []string
+	for _, e := range Sort(endpoints) {
+		got = append(got, e.Description)
+	}
+	want := []string{"priority", "get users", "users", "template", "v1", "api v2", "api", "root"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v but got %v", want, got)
+	}
+	if endpoints[0].Description != "root" {
+		t.Fatal("the endpoints must not be sorted in place")
+	}
+}
+
+func TestShadowed(t *testing.T) {
+	endpoints := []*config.Endpoint{
+		{Path: "/api/*", Priority: 1, Description: "api"},
+		{Path: "/api/users", Description: "users"},
+		{Path: "/api/v1/*", Method: "GET", Description: "v1"},
+		{Path: "/other/*", Method: "GET", Description: "get other"},
+		{Path: "/other/*", Method: "POST", Description: "post other"},
+		{Path: "/other/*", Description: "any other"},
+		{Path: "/api/*", Host: "api.example.com", Description: "host"},
+		{Path: "/canary/*", Headers: []*config.HeaderMatcher{{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: true}}}, Description: "canary"},
+		{Path: "/canary/*", Description: "stable"},
+		{Path: "/canary/v2", Headers: []*config.HeaderMatcher{{Name: "X-Canary", Match: &config.HeaderMatcher_Present{Present: true}}}, Description: "canary v2"},
+		{Path: "/methods/*", Method: "!OPTIONS", Description: "not options"},
+		{Path: "/methods/*", Method: "GET,HEAD", Description: "read"},
+		{Path: "/methods/*", Method: "OPTIONS", Description: "options"},
+	}
+	got := map[string]string{}
+	for _, s := range Shadowed(endpoints) {
+		got[s.Endpoint.Description] = s.By.Description
+	}
+	want := map[string]string{
+		"users":   "api",
+		"v1":      "api",
+		"options": "read",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v but got %v", want, got)
+	}
+}
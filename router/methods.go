@@ -0,0 +1,95 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Methods is the method set of a route, it's parsed from the method of the
+// endpoint: a method, e.g. GET, the methods separated by comma, e.g. GET,HEAD,
+// or all the methods except the ones after !, e.g. !OPTIONS,TRACE. All the
+// methods are matched if the method is empty or *, and OPTIONS is matched
+// unless it's excluded for the CORS preflights.
+type Methods struct {
+	methods  []string
+	excluded bool
+}
+
+// ParseMethods parses the method set of the endpoint method.
+func ParseMethods(method string) (*Methods, error) {
+	if method == "" || method == "*" {
+		return &Methods{}, nil
+	}
+	m := &Methods{}
+	if strings.HasPrefix(method, "!") {
+		m.excluded = true
+		method = method[1:]
+	}
+	for _, name := range strings.Split(method, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, "!* ") {
+			return nil, fmt.Errorf("invalid method: %q", method)
+		}
+		m.methods = append(m.methods, name)
+	}
+	return m, nil
+}
+
+// Any reports whether all the methods are matched.
+func (m *Methods) Any() bool {
+	return len(m.methods) == 0
+}
+
+// Excluded reports whether the methods are the excluded ones.
+func (m *Methods) Excluded() bool {
+	return m.excluded
+}
+
+// List returns the methods of the set, or the excluded ones if Excluded.
+func (m *Methods) List() []string {
+	return m.methods
+}
+
+func (m *Methods) contains(method string) bool {
+	for _, name := range m.methods {
+		if strings.EqualFold(name, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the method is in the set.
+func (m *Methods) Match(method string) bool {
+	if m.Any() || (!m.excluded && strings.EqualFold(method, http.MethodOptions)) {
+		return true
+	}
+	return m.contains(method) != m.excluded
+}
+
+// Covers reports whether all the methods of the other set are in the set.
+func (m *Methods) Covers(other *Methods) bool {
+	switch {
+	case m.Any():
+		return true
+	case other.Any():
+		return false
+	case !m.excluded && other.excluded:
+		return false
+	case m.excluded && other.excluded:
+		// the excluded methods of the set are excluded by the other either.
+		for _, name := range m.methods {
+			if !other.contains(name) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, name := range other.methods {
+		if !m.Match(name) {
+			return false
+		}
+	}
+	return m.Match(http.MethodOptions)
+}
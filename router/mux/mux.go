@@ -1,9 +1,12 @@
 package mux
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -28,20 +31,90 @@ func parseBool(in string, defV bool) bool {
 var _ = new(router.Router)
 
 type muxRouter struct {
-	*mux.Router
+	*table
+	notFoundHandler         http.Handler
+	methodNotAllowedHandler http.Handler
+	// the route tables of the virtual hosts, the routes of the default
+	// table are matched if none of the host routes matches.
+	hosts     map[string]*table
+	wildcards []*wildcardHost
+}
+
+// wildcardHost is the route table of the wildcard domain, e.g. *.api.example.com.
+type wildcardHost struct {
+	suffix string
+	table  *table
 }
 
 // NewRouter new a mux router.
 func NewRouter(notFoundHandler, methodNotAllowedHandler http.Handler) router.Router {
 	r := &muxRouter{
-		Router: mux.NewRouter().StrictSlash(EnableStrictSlash),
+		table:                   newTable(),
+		notFoundHandler:         notFoundHandler,
+		methodNotAllowedHandler: methodNotAllowedHandler,
+		hosts:                   make(map[string]*table),
 	}
-	r.Router.Handle("/metrics", promhttp.Handler())
-	r.Router.NotFoundHandler = notFoundHandler
-	r.Router.MethodNotAllowedHandler = methodNotAllowedHandler
+	r.add("/metrics", r.router.Handle("/metrics", promhttp.Handler()), nil)
 	return r
 }
 
+// hostTable returns the route table of the exact or wildcard host, nil is
+// returned for the host templates which are matched in the default table.
+func (r *muxRouter) hostTable(host string) (*table, error) {
+	host = strings.ToLower(host)
+	if strings.Contains(host, "{") {
+		return nil, nil
+	}
+	if !strings.HasPrefix(host, "*") {
+		if strings.Contains(host, "*") {
+			return nil, fmt.Errorf("wildcard is only allowed at the beginning of the host: %s", host)
+		}
+		ht, ok := r.hosts[host]
+		if !ok {
+			ht = newTable()
+			r.hosts[host] = ht
+		}
+		return ht, nil
+	}
+	suffix := host[1:]
+	if suffix == "" || strings.Contains(suffix, "*") {
+		return nil, fmt.Errorf("invalid wildcard host: %s", host)
+	}
+	for _, w := range r.wildcards {
+		if w.suffix == suffix {
+			return w.table, nil
+		}
+	}
+	w := &wildcardHost{suffix: suffix, table: newTable()}
+	r.wildcards = append(r.wildcards, w)
+	// the longest suffix is matched first.
+	sort.SliceStable(r.wildcards, func(i, j int) bool {
+		return len(r.wildcards[i].suffix) > len(r.wildcards[j].suffix)
+	})
+	return w.table, nil
+}
+
+// matchHost returns the route table of the request host, the exact host
+// is preferred over the wildcard hosts.
+func (r *muxRouter) matchHost(host string) (*table, bool) {
+	if len(r.hosts) == 0 && len(r.wildcards) == 0 {
+		return nil, false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if ht, ok := r.hosts[host]; ok {
+		return ht, true
+	}
+	for _, w := range r.wildcards {
+		if len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) {
+			return w.table, true
+		}
+	}
+	return nil, false
+}
+
 func cleanPath(p string) string {
 	if p == "" {
 		return "/"
@@ -61,12 +134,48 @@ func cleanPath(p string) string {
 
 func (r *muxRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	req.URL.Path = cleanPath(req.URL.Path)
-	r.Router.ServeHTTP(w, req)
+	if ht, ok := r.matchHost(req.Host); ok {
+		if r.serve(ht, w, req) {
+			return
+		}
+	}
+	if !r.serve(r.table, w, req) {
+		r.notFoundHandler.ServeHTTP(w, req)
+	}
 }
 
-func (r *muxRouter) Handle(pattern, method, host string, handler http.Handler) error {
-	next := r.Router.NewRoute().Handler(handler)
+// serve serves the request by the matched route of the table, it returns false if no route matches.
+func (r *muxRouter) serve(t *table, w http.ResponseWriter, req *http.Request) bool {
+	match, ok, methodMismatch := t.match(req)
+	if ok {
+		match.Handler.ServeHTTP(w, mux.SetURLVars(req, match.Vars))
+		return true
+	}
+	if methodMismatch {
+		r.methodNotAllowedHandler.ServeHTTP(w, req)
+		return true
+	}
+	return false
+}
+
+func (r *muxRouter) Handle(pattern, method, host string, handler http.Handler, matchers ...router.Matcher) error {
+	methods, err := router.ParseMethods(method)
+	if err != nil {
+		return err
+	}
+	t := r.table
 	if host != "" {
+		ht, err := r.hostTable(host)
+		if err != nil {
+			return err
+		}
+		if ht != nil {
+			t = ht
+		}
+	}
+	next := t.router.NewRoute().Handler(handler)
+	if host != "" && t == r.table {
+		// /api/{name}.example.com
 		next = next.Host(host)
 	}
 	if strings.HasSuffix(pattern, "*") {
@@ -78,18 +187,35 @@ func (r *muxRouter) Handle(pattern, method, host string, handler http.Handler) e
 		// /api/echo/{name}
 		next = next.Path(pattern)
 	}
-	if method != "" && method != "*" {
-		next = next.Methods(method, http.MethodOptions)
+	// the excluded methods are checked by the table to respond 405.
+	var excluded *router.Methods
+	if methods.Excluded() {
+		excluded = methods
+	} else if !methods.Any() {
+		// OPTIONS is matched for the CORS preflight requests.
+		next = next.Methods(append(methods.List(), http.MethodOptions)...)
+	}
+	for _, m := range matchers {
+		m := m
+		next = next.MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+			return m(req)
+		})
+	}
+	if err := next.GetError(); err != nil {
+		return err
 	}
-	return next.GetError()
+	t.add(pattern, next, excluded)
+	return nil
 }
 
 type RouterInspect struct {
+	Host             string   `json:"host,omitempty"`
 	PathTemplate     string   `json:"path_template"`
 	PathRegexp       string   `json:"path_regexp"`
 	QueriesTemplates []string `json:"queries_templates"`
 	QueriesRegexps   []string `json:"queries_regexps"`
 	Methods          []string `json:"methods"`
+	ExcludedMethods  []string `json:"excluded_methods,omitempty"`
 }
 
 func InspectMuxRouter(in interface{}) []*RouterInspect {
@@ -97,21 +223,46 @@ func InspectMuxRouter(in interface{}) []*RouterInspect {
 	if !ok {
 		return nil
 	}
-	var out []*RouterInspect
-	_ = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+	out := inspectRoutes("", r.table)
+	hosts := make([]string, 0, len(r.hosts))
+	for host := range r.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		out = append(out, inspectRoutes(host, r.hosts[host])...)
+	}
+	for _, w := range r.wildcards {
+		out = append(out, inspectRoutes("*"+w.suffix, w.table)...)
+	}
+	return out
+}
+
+func inspectRoutes(host string, t *table) []*RouterInspect {
+	out := make([]*RouterInspect, 0, len(t.routes))
+	for i, route := range t.routes {
 		pathTemplate, _ := route.GetPathTemplate()
 		pathRegexp, _ := route.GetPathRegexp()
 		queriesTemplates, _ := route.GetQueriesTemplates()
 		queriesRegexps, _ := route.GetQueriesRegexp()
 		methods, _ := route.GetMethods()
-		out = append(out, &RouterInspect{
+		inspect := &RouterInspect{
+			Host:             host,
 			PathTemplate:     pathTemplate,
 			PathRegexp:       pathRegexp,
 			QueriesTemplates: queriesTemplates,
 			QueriesRegexps:   queriesRegexps,
 			Methods:          methods,
-		})
-		return nil
-	})
+		}
+		if excluded := t.excluded[i]; excluded != nil {
+			inspect.ExcludedMethods = excluded.List()
+		}
+		out = append(out, inspect)
+	}
 	return out
 }
+
+// Vars returns the route variables of the request.
+func Vars(req *http.Request) map[string]string {
+	return mux.Vars(req)
+}
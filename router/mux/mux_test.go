@@ -1,7 +1,15 @@
 package mux
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
 func TestPathClean(t *testing.T) {
@@ -32,3 +40,235 @@ func TestPathClean(t *testing.T) {
 		}
 	}
 }
+
+func namedHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name))
+	})
+}
+
+func TestHostRouting(t *testing.T) {
+	r := NewRouter(http.NotFoundHandler(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	routes := []struct {
+		pattern, method, host, name string
+	}{
+		{"/api/*", "GET", "", "default"},
+		{"/api/*", "GET", "*.example.com", "wildcard"},
+		{"/api/*", "GET", "*.api.example.com", "api-wildcard"},
+		{"/api/*", "GET", "www.example.com", "www"},
+		{"/only-default", "GET", "", "only-default"},
+		{"/post", "POST", "post.example.com", "post"},
+	}
+	for _, route := range routes {
+		if err := r.Handle(route.pattern, route.method, route.host, namedHandler(route.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testCases := []struct {
+		method, host, path string
+		code               int
+		want               string
+	}{
+		{"GET", "other.com", "/api/hello", 200, "default"},
+		{"GET", "www.example.com", "/api/hello", 200, "www"},
+		{"GET", "WWW.Example.com:8080", "/api/hello", 200, "www"},
+		{"GET", "foo.example.com", "/api/hello", 200, "wildcard"},
+		{"GET", "foo.api.example.com", "/api/hello", 200, "api-wildcard"},
+		{"GET", "example.com", "/api/hello", 200, "default"},
+		{"GET", "foo.example.com", "/only-default", 200, "only-default"},
+		{"GET", "post.example.com", "/post", 405, ""},
+		{"GET", "foo.example.com", "/not-found", 404, ""},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Host = tc.host
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s %s%s: want %d but got %d", tc.method, tc.host, tc.path, tc.code, w.Code)
+			continue
+		}
+		if tc.want != "" && w.Body.String() != tc.want {
+			t.Errorf("%s %s%s: want %s but got %s", tc.method, tc.host, tc.path, tc.want, w.Body.String())
+		}
+	}
+	for _, host := range []string{"*", "api.*.com", "**.example.com"} {
+		if err := r.Handle("/", "GET", host, namedHandler(host)); err == nil {
+			t.Errorf("want error of the host: %s", host)
+		}
+	}
+	if inspect := InspectMuxRouter(r); len(inspect) != 7 || inspect[3].Host != "post.example.com" {
+		t.Errorf("unexpected inspect: %+v", inspect)
+	}
+}
+
+func TestTableMatch(t *testing.T) {
+	r := NewRouter(http.NotFoundHandler(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	routes := []struct {
+		pattern, method, name string
+	}{
+		{"/api/echo/hello", "GET", "exact"},
+		{"/api/echo/{name}", "GET", "template"},
+		{"/api/echo/*", "", "prefix"},
+		{"/api/user/{id}/profile", "POST", "profile"},
+		{"/api/users", "GET", "users"},
+		{"/api/user", "GET", "user"},
+		{"/api/*", "", "api"},
+	}
+	for _, route := range routes {
+		if err := r.Handle(route.pattern, route.method, "", namedHandler(route.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testCases := []struct {
+		method, path string
+		code         int
+		want         string
+	}{
+		{"GET", "/api/echo/hello", 200, "exact"},
+		{"GET", "/api/echo/world", 200, "template"},
+		{"POST", "/api/echo/world", 200, "prefix"},
+		{"GET", "/api/echo/a/b", 200, "prefix"},
+		{"GET", "/api/user/1/profile", 200, "api"},
+		{"POST", "/api/user/1/profile", 200, "profile"},
+		{"GET", "/api/users", 200, "users"},
+		{"GET", "/api/user", 200, "user"},
+		{"GET", "/api/use", 200, "api"},
+		{"GET", "/ap", 404, ""},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s %s: want %d but got %d", tc.method, tc.path, tc.code, w.Code)
+			continue
+		}
+		if tc.want != "" && w.Body.String() != tc.want {
+			t.Errorf("%s %s: want %s but got %s", tc.method, tc.path, tc.want, w.Body.String())
+		}
+	}
+}
+
+func TestMethodSet(t *testing.T) {
+	r := NewRouter(http.NotFoundHandler(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	routes := []struct {
+		pattern, method, name string
+	}{
+		{"/read", "GET,HEAD", "read"},
+		{"/write", "!GET,HEAD,OPTIONS", "write"},
+		{"/write", "GET", "write-get"},
+	}
+	for _, route := range routes {
+		if err := r.Handle(route.pattern, route.method, "", namedHandler(route.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testCases := []struct {
+		method, path string
+		code         int
+		want         string
+	}{
+		{"GET", "/read", 200, "read"},
+		{"HEAD", "/read", 200, ""},
+		{"OPTIONS", "/read", 200, "read"},
+		{"POST", "/read", 405, ""},
+		{"POST", "/write", 200, "write"},
+		{"PROPFIND", "/write", 200, "write"},
+		{"GET", "/write", 200, "write-get"},
+		{"OPTIONS", "/write", 200, "write-get"},
+		{"HEAD", "/write", 405, ""},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.code || (tc.want != "" && w.Body.String() != tc.want) {
+			t.Errorf("%s %s: want %d %s but got %d %s", tc.method, tc.path, tc.code, tc.want, w.Code, w.Body.String())
+		}
+	}
+	for _, method := range []string{"!", "GET,", "!*"} {
+		if err := r.Handle("/invalid", method, "", namedHandler(method)); err == nil {
+			t.Errorf("want error of the method: %s", method)
+		}
+	}
+	if inspect := InspectMuxRouter(r); len(inspect) != 4 || len(inspect[2].ExcludedMethods) != 3 {
+		t.Errorf("unexpected inspect: %+v", inspect)
+	}
+}
+
+func TestTableVars(t *testing.T) {
+	r := NewRouter(http.NotFoundHandler(), http.NotFoundHandler())
+	if err := r.Handle("/api/{service}/{name}", "GET", "", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		vars := Vars(req)
+		_, _ = w.Write([]byte(vars["service"] + "." + vars["name"]))
+	})); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/echo/hello", nil))
+	if w.Body.String() != "echo.hello" {
+		t.Errorf("unexpected vars: %s", w.Body.String())
+	}
+}
+
+const benchmarkRoutes = 5000
+
+func benchmarkPaths() []string {
+	paths := make([]string, 0, benchmarkRoutes)
+	for i := 0; i < benchmarkRoutes; i++ {
+		paths = append(paths, fmt.Sprintf("/api/service%d/method%d", i/10, i%10))
+	}
+	return paths
+}
+
+// benchmarkRouter reports the latency of routing the requests with the
+// routes of both the literal paths and the path templates.
+func benchmarkRouter(b *testing.B, r http.Handler, handle func(pattern string)) {
+	paths := benchmarkPaths()
+	for i, path := range paths {
+		if i%10 == 0 {
+			handle(path[:strings.LastIndex(path, "/")] + "/{name}/detail")
+		}
+		handle(path)
+	}
+	reqs := make([]*http.Request, 0, len(paths))
+	for _, path := range paths {
+		reqs = append(reqs, httptest.NewRequest("GET", path, nil))
+	}
+	w := httptest.NewRecorder()
+	latencies := make([]time.Duration, 0, b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		r.ServeHTTP(w, reqs[i%len(reqs)])
+		latencies = append(latencies, time.Since(start))
+	}
+	b.StopTimer()
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns/op")
+}
+
+func BenchmarkRadixRouter(b *testing.B) {
+	r := NewRouter(http.NotFoundHandler(), http.NotFoundHandler())
+	handler := namedHandler("ok")
+	benchmarkRouter(b, r, func(pattern string) {
+		if err := r.Handle(pattern, "GET", "", handler); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func BenchmarkLinearRouter(b *testing.B) {
+	r := mux.NewRouter()
+	handler := namedHandler("ok")
+	benchmarkRouter(b, r, func(pattern string) {
+		r.Handle(pattern, handler).Methods("GET", http.MethodOptions)
+	})
+}
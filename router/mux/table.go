@@ -0,0 +1,166 @@
+package mux
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-kratos/gateway/router"
+	"github.com/gorilla/mux"
+)
+
+// table is a route table, the routes are matched in the order of registration.
+// The candidates of the request path are looked up by the radix tree of the
+// literal paths, the path prefixes and the literal prefixes of the path
+// templates, so the routes are not matched one by one.
+type table struct {
+	router *mux.Router
+	routes []*mux.Route
+	// the excluded methods of the routes, nil if not excluded.
+	excluded []*router.Methods
+	tree     *node
+}
+
+func newTable() *table {
+	return &table{
+		router: mux.NewRouter().StrictSlash(EnableStrictSlash),
+		tree:   &node{},
+	}
+}
+
+// add indexes the route of the pattern which is built by the router of the table.
+func (t *table) add(pattern string, route *mux.Route, excluded *router.Methods) {
+	id := len(t.routes)
+	t.routes = append(t.routes, route)
+	t.excluded = append(t.excluded, excluded)
+	switch {
+	case strings.Contains(pattern, "{"):
+		// the template matches the paths of the literal prefix only.
+		t.tree.insert(pattern[:strings.Index(pattern, "{")], id, keyTemplate)
+	case strings.HasSuffix(pattern, "*"):
+		t.tree.insert(strings.TrimRight(pattern, "*"), id, keyPrefix)
+	default:
+		t.tree.insert(pattern, id, keyExact)
+		if EnableStrictSlash {
+			// the route redirects the path with or without the trailing slash.
+			if strings.HasSuffix(pattern, "/") {
+				t.tree.insert(strings.TrimSuffix(pattern, "/"), id, keyExact)
+			} else {
+				t.tree.insert(pattern+"/", id, keyExact)
+			}
+		}
+	}
+}
+
+// candidates returns the ids of the routes which may match the path in order.
+func (t *table) candidates(path string, dst []int) []int {
+	dst = t.tree.lookup(path, dst)
+	sort.Ints(dst)
+	return dst
+}
+
+// match returns the first matched route of the request, methodMismatch is
+// reported if a route is matched except the method.
+func (t *table) match(req *http.Request) (match *mux.RouteMatch, ok bool, methodMismatch bool) {
+	var buf [16]int
+	for _, id := range t.candidates(req.URL.Path, buf[:0]) {
+		m := &mux.RouteMatch{}
+		if t.routes[id].Match(req, m) {
+			if excluded := t.excluded[id]; excluded != nil && !excluded.Match(req.Method) {
+				methodMismatch = true
+				continue
+			}
+			return m, true, false
+		}
+		if m.MatchErr == mux.ErrMethodMismatch {
+			methodMismatch = true
+		}
+	}
+	return nil, false, methodMismatch
+}
+
+// The kinds of the keys in the radix tree.
+const (
+	keyExact = iota
+	keyPrefix
+	keyTemplate
+)
+
+// node is the node of the radix tree of the literal paths.
+type node struct {
+	label    string
+	children []*node
+	// the routes of the exact path, the path prefix and the path templates
+	// of the literal prefix of the node.
+	exact     []int
+	prefixes  []int
+	templates []int
+}
+
+func (n *node) child(c byte) *node {
+	for _, child := range n.children {
+		if child.label[0] == c {
+			return child
+		}
+	}
+	return nil
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func (n *node) insert(key string, id int, kind int) {
+	for key != "" {
+		child := n.child(key[0])
+		if child == nil {
+			child = &node{label: key}
+			n.children = append(n.children, child)
+			n = child
+			break
+		}
+		l := commonPrefix(key, child.label)
+		if l < len(child.label) {
+			// split the child at the common prefix.
+			split := &node{label: child.label[:l], children: []*node{child}}
+			child.label = child.label[l:]
+			for i := range n.children {
+				if n.children[i] == child {
+					n.children[i] = split
+				}
+			}
+			child = split
+		}
+		key = key[l:]
+		n = child
+	}
+	switch kind {
+	case keyPrefix:
+		n.prefixes = append(n.prefixes, id)
+	case keyTemplate:
+		n.templates = append(n.templates, id)
+	default:
+		n.exact = append(n.exact, id)
+	}
+}
+
+// lookup appends the routes of the prefixes of the path and the exact path.
+func (n *node) lookup(path string, dst []int) []int {
+	for {
+		dst = append(dst, n.prefixes...)
+		dst = append(dst, n.templates...)
+		if path == "" {
+			return append(dst, n.exact...)
+		}
+		child := n.child(path[0])
+		if child == nil || !strings.HasPrefix(path, child.label) {
+			return dst
+		}
+		path = path[len(child.label):]
+		n = child
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"net/http"
 )
 
+// Matcher matches the request besides the pattern, method and host.
+type Matcher func(*http.Request) bool
+
 // Router is a gateway router.
 type Router interface {
 	http.Handler
-	Handle(pattern, method, host string, handler http.Handler) error
+	Handle(pattern, method, host string, handler http.Handler, matchers ...Matcher) error
 }
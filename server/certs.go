@@ -0,0 +1,135 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+// Certificates is the certificates of the TLS listeners selected by the
+// server name, they are replaced on the config update.
+type Certificates struct {
+	store atomic.Value
+}
+
+type certificates struct {
+	names map[string]*tls.Certificate
+	def   *tls.Certificate
+	// the client certificates aren't requested if nil.
+	clientCAs  *x509.CertPool
+	clientAuth tls.ClientAuthType
+}
+
+// NewCertificates new the certificates.
+func NewCertificates() *Certificates {
+	c := &Certificates{}
+	c.store.Store(&certificates{})
+	return c
+}
+
+// Update loads the certificates of the config, the current ones are kept if
+// any of them fails to load.
+func (c *Certificates) Update(conf *config.Gateway) error {
+	certs := &certificates{names: make(map[string]*tls.Certificate)}
+	for _, cc := range conf.Tls.GetCertificates() {
+		cert, err := tls.LoadX509KeyPair(cc.CertFile, cc.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate %s: %w", cc.CertFile, err)
+		}
+		names := cc.ServerNames
+		if len(names) == 0 {
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse certificate %s: %w", cc.CertFile, err)
+			}
+			names = leaf.DNSNames
+		}
+		for _, name := range names {
+			name = strings.ToLower(name)
+			if _, ok := certs.names[name]; ok {
+				return fmt.Errorf("duplicate certificate server name: %s", name)
+			}
+			certs.names[name] = &cert
+		}
+		if certs.def == nil || cc.Default {
+			certs.def = &cert
+		}
+	}
+	if ca := conf.Tls.GetClientAuth(); ca != nil {
+		certs.clientCAs = x509.NewCertPool()
+		for _, file := range ca.CaFiles {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to load client CA %s: %w", file, err)
+			}
+			if !certs.clientCAs.AppendCertsFromPEM(data) {
+				return fmt.Errorf("no certificate found in client CA %s", file)
+			}
+		}
+		certs.clientAuth = tls.VerifyClientCertIfGiven
+		if ca.Required {
+			certs.clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	c.store.Store(certs)
+	return nil
+}
+
+// GetCertificate returns the certificate of the server name, it's used as
+// the GetCertificate of the tls.Config.
+func (c *Certificates) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certs := c.store.Load().(*certificates)
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if cert, ok := certs.names[name]; ok {
+		return cert, nil
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if cert, ok := certs.names["*"+name[i:]]; ok {
+			return cert, nil
+		}
+	}
+	if certs.def == nil {
+		return nil, errors.New("no certificate available")
+	}
+	return certs.def, nil
+}
+
+// TLSConfig returns the TLS config serving the certificates, the client
+// certificates are verified by the client CAs of the current config.
+func (c *Certificates) TLSConfig() *tls.Config {
+	conf := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+	conf.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		certs := c.store.Load().(*certificates)
+		if certs.clientCAs == nil {
+			return nil, nil
+		}
+		// the config is cloned after the server set the ALPN protocols.
+		client := conf.Clone()
+		client.GetConfigForClient = nil
+		client.ClientCAs = certs.clientCAs
+		client.ClientAuth = certs.clientAuth
+		if !contains(client.NextProtos, "http/1.1") {
+			client.NextProtos = append(client.NextProtos, "http/1.1")
+		}
+		return client, nil
+	}
+	return conf
+}
+
+func contains(protos []string, proto string) bool {
+	for _, p := range protos {
+		if p == proto {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	config "github.com/go-kratos/gateway/api/gateway/config/v1"
+)
+
+// writeCertificate writes a self-signed certificate of the DNS names.
+func writeCertificate(t *testing.T, dir, name string, dnsNames ...string) *config.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &config.Certificate{
+		CertFile: filepath.Join(dir, name+".pem"),
+		KeyFile:  filepath.Join(dir, name+"-key.pem"),
+	}
+	if err := ioutil.WriteFile(cert.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(cert.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestCertificates(t *testing.T) {
+	dir := t.TempDir()
+	api := writeCertificate(t, dir, "api", "api.example.com")
+	wildcard := writeCertificate(t, dir, "wildcard", "*.example.com")
+	fallback := writeCertificate(t, dir, "fallback", "example.org")
+	fallback.ServerNames = []string{"www.example.org"}
+	fallback.Default = true
+
+	certs := NewCertificates()
+	if _, err := certs.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.example.com"}); err == nil {
+		t.Fatal("want error without certificates")
+	}
+	c := &config.Gateway{Tls: &config.TLS{Certificates: []*config.Certificate{api, wildcard, fallback}}}
+	if err := certs.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		serverName, want string
+	}{
+		{"api.example.com", "api"},
+		{"API.example.com.", "api"},
+		{"admin.example.com", "wildcard"},
+		{"a.b.example.com", "fallback"},
+		{"www.example.org", "fallback"},
+		{"example.org", "fallback"},
+		{"", "fallback"},
+	}
+	for _, tc := range testCases {
+		cert, err := certs.GetCertificate(&tls.ClientHelloInfo{ServerName: tc.serverName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaf.Subject.CommonName != tc.want {
+			t.Errorf("%q: want certificate %s but got %s", tc.serverName, tc.want, leaf.Subject.CommonName)
+		}
+	}
+
+	// the current certificates are kept if the update fails.
+	c.Tls.Certificates = append(c.Tls.Certificates, &config.Certificate{CertFile: filepath.Join(dir, "not-exists.pem")})
+	if err := certs.Update(c); err == nil {
+		t.Fatal("want error of the missing certificate")
+	}
+	if _, err := certs.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSProxy(t *testing.T) {
+	dir := t.TempDir()
+	certs := NewCertificates()
+	if err := certs.Update(&config.Gateway{Tls: &config.TLS{Certificates: []*config.Certificate{
+		writeCertificate(t, dir, "api", "api.example.com"),
+		writeCertificate(t, dir, "admin", "admin.example.com"),
+	}}}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewTLSProxy(http.NotFoundHandler(), "127.0.0.1:0", certs.TLSConfig())
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", s.TLSConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(ln)
+	defer s.Close()
+
+	for _, serverName := range []string{"api.example.com", "admin.example.com"} {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{ServerName: serverName, InsecureSkipVerify: true}) //nolint:gosec
+		if err != nil {
+			t.Fatal(err)
+		}
+		peer := conn.ConnectionState().PeerCertificates[0]
+		conn.Close()
+		if peer.Subject.CommonName+".example.com" != serverName {
+			t.Errorf("want certificate of %s but got %s", serverName, peer.Subject.CommonName)
+		}
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	client := writeCertificate(t, dir, "client")
+	certs := NewCertificates()
+	if err := certs.Update(&config.Gateway{Tls: &config.TLS{
+		Certificates: []*config.Certificate{writeCertificate(t, dir, "api", "api.example.com")},
+		ClientAuth:   &config.ClientAuth{CaFiles: []string{client.CertFile}, Required: true},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewTLSProxy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}), "127.0.0.1:0", certs.TLSConfig())
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", s.TLSConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(ln)
+	defer s.Close()
+
+	cert, err := tls.LoadX509KeyPair(client.CertFile, client.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(certs ...tls.Certificate) (string, error) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			ServerName:         "api.example.com",
+			InsecureSkipVerify: true, //nolint:gosec
+			Certificates:       certs,
+		}}}
+		resp, err := c.Get("https://" + ln.Addr().String())
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return string(body), err
+	}
+	if body, err := get(cert); err != nil || body != "client" {
+		t.Fatalf("want the client certificate verified but got %q %v", body, err)
+	}
+	if _, err := get(); err == nil {
+		t.Fatal("want the handshake rejected without the client certificate")
+	}
+	other := writeCertificate(t, t.TempDir(), "client")
+	if cert, err = tls.LoadX509KeyPair(other.CertFile, other.KeyFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(cert); err == nil {
+		t.Fatal("want the handshake rejected with the unknown client certificate")
+	}
+}
@@ -2,10 +2,13 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"math"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
@@ -18,6 +21,8 @@ var (
 	readTimeout       = time.Second * 15
 	writeTimeout      = time.Second * 15
 	idleTimeout       = time.Second * 120
+	// the connections are accepted with the PROXY protocol header if enabled.
+	proxyProtocol = false
 )
 
 func init() {
@@ -42,6 +47,11 @@ func init() {
 			panic(err)
 		}
 	}
+	if v := os.Getenv("PROXY_PROTOCOL"); v != "" {
+		if proxyProtocol, err = strconv.ParseBool(v); err != nil {
+			panic(err)
+		}
+	}
 }
 
 // ProxyServer is a proxy server.
@@ -66,16 +76,45 @@ func NewProxy(handler http.Handler, addr string) *ProxyServer {
 	}
 }
 
+// NewTLSProxy new a gateway server terminating the TLS, the HTTP/2 is
+// negotiated by the ALPN.
+func NewTLSProxy(handler http.Handler, addr string, config *tls.Config) *ProxyServer {
+	s := NewProxy(handler, addr)
+	s.TLSConfig = config
+	return s
+}
+
 // Start the server.
 func (s *ProxyServer) Start(ctx context.Context) error {
 	log.Infof("proxy listening on %s", s.Addr)
-	err := s.ListenAndServe()
+	err := s.listenAndServe()
 	if errors.Is(err, http.ErrServerClosed) {
 		return nil
 	}
 	return err
 }
 
+func (s *ProxyServer) listenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+		if s.TLSConfig != nil {
+			addr = ":https"
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if proxyProtocol {
+		ln = newProxyProtocolListener(ln, readHeaderTimeout)
+	}
+	if s.TLSConfig != nil {
+		return s.ServeTLS(ln, "", "")
+	}
+	return s.Serve(ln)
+}
+
 // Stop the server.
 func (s *ProxyServer) Stop(ctx context.Context) error {
 	log.Info("proxy stopping")
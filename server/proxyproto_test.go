@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func proxyProtocolV2Header(command byte, family byte, addrs []byte) []byte {
+	header := append([]byte{}, _proxyProtocolV2Signature...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(addrs)))
+	return append(header, addrs...)
+}
+
+func TestReadProxyProtocolHeader(t *testing.T) {
+	v4 := []byte{192, 168, 0, 1, 10, 0, 0, 1, 0x1f, 0x90, 0, 80}
+	v6 := make([]byte, 36)
+	v6[15] = 1
+	binary.BigEndian.PutUint16(v6[32:34], 443)
+	testCases := []struct {
+		header []byte
+		want   string
+	}{
+		{[]byte("PROXY TCP4 192.168.0.1 10.0.0.1 8080 80\r\n"), "192.168.0.1:8080"},
+		{[]byte("PROXY TCP6 ::1 ::2 443 80\r\n"), "[::1]:443"},
+		{[]byte("PROXY UNKNOWN\r\n"), ""},
+		{proxyProtocolV2Header(1, 0x11, v4), "192.168.0.1:8080"},
+		{proxyProtocolV2Header(1, 0x21, v6), "[::1]:443"},
+		{proxyProtocolV2Header(0, 0x00, nil), ""},
+	}
+	for _, tc := range testCases {
+		r := bufio.NewReader(bytes.NewReader(append(tc.header, "GET / HTTP/1.1\r\n"...)))
+		addr, err := readProxyProtocolHeader(r)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.header, err)
+		}
+		got := ""
+		if addr != nil {
+			got = addr.String()
+		}
+		if got != tc.want {
+			t.Errorf("%q: want %q but got %q", tc.header, tc.want, got)
+		}
+		if rest, _ := ioutil.ReadAll(r); string(rest) != "GET / HTTP/1.1\r\n" {
+			t.Errorf("%q: unexpected rest: %q", tc.header, rest)
+		}
+	}
+	for _, header := range []string{
+		"GET / HTTP/1.1\r\n",
+		"PROXY TCP4 192.168.0.1 10.0.0.1 8080\r\n",
+		"PROXY TCP4 invalid 10.0.0.1 8080 80\r\n",
+	} {
+		if _, err := readProxyProtocolHeader(bufio.NewReader(bytes.NewBufferString(header))); err == nil {
+			t.Errorf("want error of %q", header)
+		}
+	}
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	pln := newProxyProtocolListener(ln, 0)
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("PROXY TCP4 192.168.0.1 10.0.0.1 8080 80\r\nhello"))
+	}()
+	conn, err := pln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if addr := conn.RemoteAddr().String(); addr != "192.168.0.1:8080" {
+		t.Errorf("unexpected remote address: %s", addr)
+	}
+	if b, _ := ioutil.ReadAll(conn); string(b) != "hello" {
+		t.Errorf("unexpected body: %q", b)
+	}
+}